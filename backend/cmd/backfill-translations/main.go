@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"vista-backend/config"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+	"vista-backend/pkg/i18n"
+)
+
+type translatableRow struct {
+	ID     uint
+	Source string
+	Target models.JSONB
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Report rows needing translation without queuing jobs")
+	run := flag.Bool("run", false, "Process queued jobs before exiting instead of leaving them for the server")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		log.Fatal("Usage: backfill-translations [-dry-run] [-run] <database-path>")
+	}
+	dbPath := flag.Arg(0)
+
+	// Open database
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.TranslationJob{}, &models.GlossaryTerm{}); err != nil {
+		log.Fatalf("Failed to migrate translation tables: %v", err)
+	}
+	if err := translation.LoadGlossary(db); err != nil {
+		log.Fatalf("Failed to load glossary: %v", err)
+	}
+	languages := config.Load().Language
+	i18n.Configure(languages.Enabled, languages.Default)
+
+	totalQueued := 0
+	for _, field := range translation.TranslatableFields {
+		var rows []translatableRow
+		if err := db.Table(field.Table).
+			Select("id, " + field.SourceColumn + " AS source, " + field.TargetColumn + " AS target").
+			Where(field.SourceColumn + " IS NOT NULL AND " + field.SourceColumn + " <> ''").
+			Scan(&rows).Error; err != nil {
+			log.Printf("Failed to scan %s.%s: %v", field.Table, field.SourceColumn, err)
+			continue
+		}
+
+		queued := 0
+		for _, row := range rows {
+			// Skip rows that are already fully translated or waiting on a job
+			if translation.IsComplete(row.Target) {
+				continue
+			}
+			if translation.HasOpenJob(db, field.Table, row.ID, field.TargetColumn) {
+				continue
+			}
+
+			queued++
+			if *dryRun {
+				continue
+			}
+			if err := translation.Enqueue(db, field.Table, row.ID, field.TargetColumn, row.Source, translation.FromJSON(row.Target)); err != nil {
+				log.Printf("Failed to queue %s #%d %s: %v", field.Table, row.ID, field.TargetColumn, err)
+				queued--
+			}
+		}
+
+		if queued > 0 {
+			log.Printf("%s.%s: %d of %d rows need translation", field.Table, field.TargetColumn, queued, len(rows))
+		}
+		totalQueued += queued
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d translation jobs would be queued", totalQueued)
+		return
+	}
+	log.Printf("Queued %d translation jobs", totalQueued)
+
+	if !*run {
+		return
+	}
+
+	// Drain the queue; jobs waiting on a retry backoff are left for the server worker
+	queue := translation.NewJobQueue(db)
+	processed := 0
+	for {
+		n := queue.RunOnce()
+		if n == 0 {
+			break
+		}
+		processed += n
+	}
+	log.Printf("Processed %d translation jobs", processed)
+}
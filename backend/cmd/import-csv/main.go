@@ -14,7 +14,10 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"vista-backend/config"
 	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+	"vista-backend/pkg/i18n"
 )
 
 type ProductData struct {
@@ -42,6 +45,13 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// Translations are queued here and processed by the running server
+	if err := db.AutoMigrate(&models.Product{}, &models.TranslationJob{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	languages := config.Load().Language
+	i18n.Configure(languages.Enabled, languages.Default)
+
 	// Open CSV file
 	file, err := os.Open(csvPath)
 	if err != nil {
@@ -119,6 +129,7 @@ func main() {
 	rand.Seed(time.Now().UnixNano())
 	inserted := 0
 	updated := 0
+	queued := 0
 
 	for _, product := range productMap {
 		if product.OrderCount < 2 {
@@ -142,6 +153,7 @@ func main() {
 			// Update stock with random quantity (10-100)
 			existing.Stock = rand.Intn(91) + 10
 			db.Save(&existing)
+			queued += queueTranslations(db, &existing)
 			updated++
 			fmt.Printf("Updated: %s (ASIN: %s, Orders: %d)\n", truncate(product.Title, 50), product.ASIN, product.OrderCount)
 		} else {
@@ -165,6 +177,7 @@ func main() {
 				ProductURL:  fmt.Sprintf("https://www.amazon.com.mx/dp/%s", product.ASIN),
 			}
 			db.Create(&newProduct)
+			queued += queueTranslations(db, &newProduct)
 			inserted++
 			fmt.Printf("Inserted: %s (ASIN: %s, Orders: %d)\n", truncate(product.Title, 50), product.ASIN, product.OrderCount)
 		}
@@ -175,6 +188,19 @@ func main() {
 	fmt.Printf("Products with 2+ orders: %d\n", inserted+updated)
 	fmt.Printf("New products inserted: %d\n", inserted)
 	fmt.Printf("Existing products updated: %d\n", updated)
+	fmt.Printf("Translation jobs queued: %d\n", queued)
+}
+
+// queueTranslations queues machine translation of a product's missing localized fields
+func queueTranslations(db *gorm.DB, product *models.Product) int {
+	if product.ID == 0 {
+		return 0
+	}
+	queued, err := translation.EnqueueProduct(db, product, false)
+	if err != nil {
+		log.Printf("Failed to queue translations for %s: %v", product.SKU, err)
+	}
+	return queued
 }
 
 func truncate(s string, maxLen int) string {
@@ -0,0 +1,95 @@
+// mock-ldap is a small in-memory LDAP server for trying directory login and
+// sync offline. It serves the entries of an LDIF file, or a built-in sample
+// directory, with the embedded server the directory tests use
+// (internal/services/directory/ldaptest); the file is read again
+// whenever it changes, so removing an entry and syncing disables that user.
+// Point the backend at it with LDAP_URL=ldap://127.0.0.1:3890,
+// LDAP_BASE_DN=ou=people,dc=company,dc=com,
+// LDAP_BIND_DN=cn=vista-sync,ou=services,dc=company,dc=com and
+// LDAP_BIND_PASSWORD=sync-secret.
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"vista-backend/internal/services/directory/ldaptest"
+)
+
+var (
+	addr     = flag.String("addr", "127.0.0.1:3890", "Address to listen on")
+	ldifPath = flag.String("ldif", "", "LDIF file with the directory entries (default the built-in sample)")
+)
+
+//go:embed sample.ldif
+var sampleLDIF []byte
+
+var (
+	mu        sync.Mutex
+	entries   []*ldaptest.Entry
+	loadedMod time.Time
+)
+
+func main() {
+	flag.Parse()
+
+	if _, err := directory(); err != nil {
+		log.Fatalf("Failed to load directory: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+	source := "built-in sample"
+	if *ldifPath != "" {
+		source = *ldifPath
+	}
+	log.Printf("Mock LDAP server listening on %s, serving %s", *addr, source)
+
+	server := &ldaptest.Server{Directory: directory, Logf: log.Printf}
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("Failed to accept connection: %v", err)
+	}
+}
+
+// directory returns the current entries, reading the LDIF file again if it changed
+func directory() ([]*ldaptest.Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if *ldifPath == "" {
+		if entries == nil {
+			parsed, err := ldaptest.ParseLDIF(sampleLDIF)
+			if err != nil {
+				return nil, err
+			}
+			entries = parsed
+		}
+		return entries, nil
+	}
+
+	info, err := os.Stat(*ldifPath)
+	if err != nil {
+		return nil, err
+	}
+	if entries != nil && info.ModTime().Equal(loadedMod) {
+		return entries, nil
+	}
+	data, err := os.ReadFile(*ldifPath)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ldaptest.ParseLDIF(data)
+	if err != nil {
+		return nil, err
+	}
+	entries, loadedMod = parsed, info.ModTime()
+	log.Printf("Loaded %d entries from %s", len(entries), *ldifPath)
+	return entries, nil
+}
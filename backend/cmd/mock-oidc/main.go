@@ -0,0 +1,340 @@
+// mock-oidc is a local stand-in OpenID Connect identity provider for trying
+// single sign-on offline. Its login page lets you sign in as anyone: it
+// shows a form with the claims to put in the ID token, prefilled from the
+// flags. With -auto it skips the form and signs in as the flag user right
+// away, which makes scripted tests easy. Point the backend at it with
+// OIDC_ISSUER=http://127.0.0.1:9400 and OIDC_CLIENT_ID=vista.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	addr           = flag.String("addr", "127.0.0.1:9400", "Address to listen on")
+	issuer         = flag.String("issuer", "", "Issuer URL (default http://<addr>)")
+	clientID       = flag.String("client-id", "vista", "Client ID to accept")
+	clientSecret   = flag.String("client-secret", "", "Client secret to require (public client with PKCE only if empty)")
+	auto           = flag.Bool("auto", false, "Sign in as the flag user without showing the login form")
+	subject        = flag.String("sub", "", "Subject of the flag user (default derived from -email)")
+	email          = flag.String("email", "jane.doe@company.com", "Email of the flag user")
+	name           = flag.String("name", "Jane Doe", "Name of the flag user")
+	employeeNumber = flag.String("employee-number", "E1001", "Employee number of the flag user")
+	department     = flag.String("department", "Engineering", "Department of the flag user")
+	groups         = flag.String("groups", "", "Comma-separated groups of the flag user")
+)
+
+// codeValidFor is how long an authorization code can be redeemed
+const codeValidFor = time.Minute
+
+// grant is an authorization code waiting to be redeemed
+type grant struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	claims        jwt.MapClaims
+	expiresAt     time.Time
+}
+
+var (
+	signingKey *rsa.PrivateKey
+	keyID      string
+
+	mu     sync.Mutex
+	grants = map[string]*grant{}
+)
+
+func main() {
+	flag.Parse()
+	if *issuer == "" {
+		*issuer = "http://" + *addr
+	}
+	*issuer = strings.TrimRight(*issuer, "/")
+
+	var err error
+	if signingKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+	keyID = randomString()[:8]
+
+	http.HandleFunc("/.well-known/openid-configuration", handleDiscovery)
+	http.HandleFunc("/authorize", handleAuthorize)
+	http.HandleFunc("/token", handleToken)
+	http.HandleFunc("/jwks", handleJWKS)
+
+	log.Printf("Mock OpenID Connect provider %s listening on %s", *issuer, *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+}
+
+func handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                *issuer,
+		"authorization_endpoint":                *issuer + "/authorize",
+		"token_endpoint":                        *issuer + "/token",
+		"jwks_uri":                              *issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+func handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(signingKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signingKey.E)).Bytes()),
+		}},
+	})
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><title>Mock identity provider</title>
+<style>body{font-family:sans-serif;max-width:420px;margin:40px auto}label{display:block;margin-top:12px}input{width:100%;padding:6px}button{margin-top:16px;padding:8px 16px}</style>
+</head><body>
+<h2>Mock identity provider</h2>
+<p>Sign in to <b>{{.ClientID}}</b> as:</p>
+<form method="post">
+{{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">
+{{end}}<label>Subject <input name="sub" value="{{.Sub}}"></label>
+<label>Email <input name="email" value="{{.Email}}"></label>
+<label>Name <input name="name" value="{{.Name}}"></label>
+<label>Employee number <input name="employee_number" value="{{.EmployeeNumber}}"></label>
+<label>Department <input name="department" value="{{.Department}}"></label>
+<label>Groups (comma-separated) <input name="groups" value="{{.Groups}}"></label>
+<label><input type="checkbox" name="email_verified" value="true" checked style="width:auto"> Email verified</label>
+<button type="submit">Sign in</button>
+</form></body></html>`))
+
+// authorizeParams are the authorization request parameters carried through
+// the login form
+var authorizeParams = []string{"response_type", "client_id", "redirect_uri", "scope", "state", "nonce", "code_challenge", "code_challenge_method"}
+
+func handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	redirectURI := r.Form.Get("redirect_uri")
+	if _, err := url.ParseRequestURI(redirectURI); err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("client_id") != *clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("response_type") != "code" {
+		redirectError(w, r, redirectURI, "unsupported_response_type")
+		return
+	}
+	if r.Form.Get("code_challenge") == "" || r.Form.Get("code_challenge_method") != "S256" {
+		redirectError(w, r, redirectURI, "invalid_request")
+		return
+	}
+
+	if r.Method != http.MethodPost && !*auto {
+		params := map[string]string{}
+		for _, key := range authorizeParams {
+			params[key] = r.Form.Get(key)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		loginPage.Execute(w, map[string]interface{}{
+			"ClientID":       *clientID,
+			"Params":         params,
+			"Sub":            subjectFor(*subject, *email),
+			"Email":          *email,
+			"Name":           *name,
+			"EmployeeNumber": *employeeNumber,
+			"Department":     *department,
+			"Groups":         *groups,
+		})
+		return
+	}
+
+	// Claims come from the login form, or from the flags with -auto
+	user := map[string]string{
+		"sub": *subject, "email": *email, "name": *name, "employee_number": *employeeNumber,
+		"department": *department, "groups": *groups, "email_verified": "true",
+	}
+	if r.Method == http.MethodPost {
+		for key := range user {
+			user[key] = r.PostForm.Get(key)
+		}
+	}
+
+	claims := jwt.MapClaims{
+		"sub":            subjectFor(user["sub"], user["email"]),
+		"email":          user["email"],
+		"email_verified": user["email_verified"] == "true",
+		"name":           user["name"],
+		"groups":         splitList(user["groups"]),
+	}
+	if user["employee_number"] != "" {
+		claims["employee_number"] = user["employee_number"]
+	}
+	if user["department"] != "" {
+		claims["department"] = user["department"]
+	}
+
+	code := randomString()
+	mu.Lock()
+	grants[code] = &grant{
+		clientID:      *clientID,
+		redirectURI:   redirectURI,
+		codeChallenge: r.Form.Get("code_challenge"),
+		nonce:         r.Form.Get("nonce"),
+		claims:        claims,
+		expiresAt:     time.Now().Add(codeValidFor),
+	}
+	mu.Unlock()
+
+	log.Printf("Signed in %s (%s)", claims["email"], claims["sub"])
+	redirect(w, r, redirectURI, url.Values{"code": {code}, "state": {r.Form.Get("state")}})
+}
+
+func handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+		return
+	}
+
+	// Client authentication, with HTTP basic auth or in the form
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != *clientID || (*clientSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(*clientSecret)) != 1) {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "")
+		return
+	}
+
+	// Codes are single use
+	mu.Lock()
+	g := grants[r.PostForm.Get("code")]
+	delete(grants, r.PostForm.Get("code"))
+	mu.Unlock()
+
+	if g == nil || time.Now().After(g.expiresAt) || g.clientID != id || g.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "unknown, expired or mismatched code")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   *issuer,
+		"aud":   id,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": g.nonce,
+	}
+	for key, value := range g.claims {
+		claims[key] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(signingKey)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, redirectURI, code string) {
+	redirect(w, r, redirectURI, url.Values{"error": {code}, "state": {r.Form.Get("state")}})
+}
+
+func redirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	separator := "?"
+	if strings.Contains(redirectURI, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, redirectURI+separator+params.Encode(), http.StatusFound)
+}
+
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// subjectFor returns the subject, or one derived from the email address so
+// the same address always gets the same subject
+func subjectFor(sub, email string) string {
+	if sub != "" {
+		return sub
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return "mock-" + base64.RawURLEncoding.EncodeToString(sum[:9])
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to generate random string: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
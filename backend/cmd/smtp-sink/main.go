@@ -0,0 +1,253 @@
+// smtp-sink is a local stand-in SMTP server for trying the SMTP email
+// provider offline. It accepts every message, prints it and optionally saves
+// it as an .eml file. It has no TLS, so configure the provider with
+// smtp_security "none" and smtp_host "localhost".
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	addr     = flag.String("addr", "127.0.0.1:2525", "Address to listen on")
+	dir      = flag.String("dir", "", "Directory to save received messages in as .eml files")
+	user     = flag.String("user", "", "Require AUTH with this username (any credentials are accepted if empty)")
+	password = flag.String("password", "", "Password required with -user")
+	quiet    = flag.Bool("quiet", false, "Print only the envelope, not the message")
+)
+
+var received atomic.Int64
+
+func main() {
+	flag.Parse()
+
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			log.Fatalf("Failed to create %s: %v", *dir, err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+	log.Printf("SMTP sink listening on %s", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("Failed to accept connection: %v", err)
+			continue
+		}
+		go handle(conn)
+	}
+}
+
+// session is the state of one SMTP conversation
+type session struct {
+	conn          net.Conn
+	reader        *bufio.Reader
+	authenticated bool
+	from          string
+	to            []string
+}
+
+func handle(conn net.Conn) {
+	defer conn.Close()
+	s := &session{conn: conn, reader: bufio.NewReader(conn)}
+	s.reply("220 smtp-sink ready")
+
+	for {
+		conn.SetDeadline(time.Now().Add(5 * time.Minute))
+		line, err := s.readLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			s.reset()
+			s.reply("250-smtp-sink greets " + arg)
+			s.reply("250-8BITMIME")
+			s.reply("250-AUTH PLAIN LOGIN")
+			s.reply("250 SIZE 26214400")
+		case "HELO":
+			s.reset()
+			s.reply("250 smtp-sink")
+		case "AUTH":
+			s.auth(arg)
+		case "MAIL":
+			if *user != "" && !s.authenticated {
+				s.reply("530 Authentication required")
+				continue
+			}
+			s.reset()
+			s.from = parsePath(arg)
+			s.reply("250 OK")
+		case "RCPT":
+			if s.from == "" {
+				s.reply("503 MAIL first")
+				continue
+			}
+			s.to = append(s.to, parsePath(arg))
+			s.reply("250 OK")
+		case "DATA":
+			if len(s.to) == 0 {
+				s.reply("503 RCPT first")
+				continue
+			}
+			s.reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := s.readData()
+			if err != nil {
+				return
+			}
+			id := s.deliver(data)
+			s.reply("250 OK queued as " + id)
+			s.reset()
+		case "RSET":
+			s.reset()
+			s.reply("250 OK")
+		case "NOOP":
+			s.reply("250 OK")
+		case "QUIT":
+			s.reply("221 Bye")
+			return
+		case "STARTTLS":
+			s.reply("454 TLS not available")
+		default:
+			s.reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *session) reply(line string) {
+	fmt.Fprintf(s.conn, "%s\r\n", line)
+}
+
+func (s *session) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *session) reset() {
+	s.from = ""
+	s.to = nil
+}
+
+// auth handles AUTH PLAIN and AUTH LOGIN
+func (s *session) auth(arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	var username, pass string
+
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		if initial == "" {
+			s.reply("334 ")
+			line, err := s.readLine()
+			if err != nil {
+				return
+			}
+			initial = line
+		}
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			s.reply("501 Invalid base64")
+			return
+		}
+		parts := strings.SplitN(string(decoded), "\x00", 3)
+		if len(parts) != 3 {
+			s.reply("501 Invalid PLAIN credentials")
+			return
+		}
+		username, pass = parts[1], parts[2]
+	case "LOGIN":
+		values := make([]string, 0, 2)
+		for _, prompt := range []string{"Username:", "Password:"} {
+			s.reply("334 " + base64.StdEncoding.EncodeToString([]byte(prompt)))
+			line, err := s.readLine()
+			if err != nil {
+				return
+			}
+			decoded, err := base64.StdEncoding.DecodeString(line)
+			if err != nil {
+				s.reply("501 Invalid base64")
+				return
+			}
+			values = append(values, string(decoded))
+		}
+		username, pass = values[0], values[1]
+	default:
+		s.reply("504 Unrecognized authentication type")
+		return
+	}
+
+	if *user != "" && (username != *user || pass != *password) {
+		log.Printf("AUTH failed for %q", username)
+		s.reply("535 Authentication credentials invalid")
+		return
+	}
+	s.authenticated = true
+	log.Printf("AUTH as %q", username)
+	s.reply("235 Authentication successful")
+}
+
+// readData reads the message up to the terminating dot, undoing dot-stuffing
+func (s *session) readData() (string, error) {
+	var b strings.Builder
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return b.String(), nil
+		}
+		b.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
+
+// deliver prints and saves a received message and returns its queue ID
+func (s *session) deliver(data string) string {
+	n := received.Add(1)
+	id := fmt.Sprintf("%d-%d", time.Now().Unix(), n)
+
+	log.Printf("Message %s from %s to %s (%d bytes)", id, s.from, strings.Join(s.to, ", "), len(data))
+	if !*quiet {
+		fmt.Println(strings.TrimRight(data, "\r\n"))
+		fmt.Println(strings.Repeat("-", 72))
+	}
+
+	if *dir != "" {
+		path := filepath.Join(*dir, id+".eml")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			log.Printf("Failed to save %s: %v", path, err)
+		}
+	}
+	return id
+}
+
+// parsePath extracts the address from "FROM:<a@b> SIZE=123"
+func parsePath(arg string) string {
+	_, path, _ := strings.Cut(arg, ":")
+	path = strings.TrimSpace(path)
+	if end := strings.Index(path, ">"); strings.HasPrefix(path, "<") && end > 0 {
+		return path[1:end]
+	}
+	if field, _, _ := strings.Cut(path, " "); field != "" {
+		return field
+	}
+	return path
+}
@@ -3,20 +3,27 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Crypto   CryptoConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Crypto    CryptoConfig
+	Language  LanguageConfig
+	Login     LoginConfig
+	TwoFactor TwoFactorConfig
+	SSO       SSOConfig
+	LDAP      LDAPConfig
 }
 
 type ServerConfig struct {
 	Port         string
 	Environment  string
 	AllowOrigins []string
+	AppURL       string // Public frontend URL, used for links in chat messages
 }
 
 type DatabaseConfig struct {
@@ -33,12 +40,60 @@ type CryptoConfig struct {
 	EncryptionKey string
 }
 
+type LoginConfig struct {
+	MaxFailures     int           // Failed logins for one account before it's locked out
+	IPMaxFailures   int           // Failed logins from one IP address before it's slowed down
+	LockoutDuration time.Duration // How long lockouts last and failures are remembered
+}
+
+type TwoFactorConfig struct {
+	Issuer        string   // Account name prefix shown in authenticator apps
+	RequiredRoles []string // Roles that must use two-factor authentication to log in
+}
+
+// SSOConfig configures OpenID Connect single sign-on; it's off unless
+// Issuer is set
+type SSOConfig struct {
+	Issuer              string
+	ClientID            string
+	ClientSecret        string
+	RedirectURL         string // Frontend page the identity provider sends users back to
+	Scopes              []string
+	ProviderName        string // Shown on the login button
+	EmployeeNumberClaim string // ID token claims mapped to user fields
+	DepartmentClaim     string
+	GroupsClaim         string
+	RoleGroups          []string // "role=group" pairs, most privileged first
+	AutoApprove         bool     // Approve accounts created at first login instead of leaving them pending
+}
+
+// LDAPConfig configures directory (LDAP / Active Directory) login and user
+// sync; it's off unless URL is set
+type LDAPConfig struct {
+	URL          string
+	StartTLS     bool
+	BindDN       string // Service account used to look users up
+	BindPassword string
+	BaseDN       string
+	UserFilter   string
+	Attributes   []string      // "field=attribute" pairs overriding where user fields are read from
+	SyncInterval time.Duration // 0 syncs only when an admin starts it
+}
+
+type LanguageConfig struct {
+	Enabled []string // Language codes users can choose and content is translated into
+	Default string
+}
+
 func Load() *Config {
+	appURL := getEnv("APP_URL", getEnv("CORS_ORIGIN", "http://localhost:3000"))
+
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnv("PORT", "8080"),
 			Environment:  getEnv("ENVIRONMENT", "development"),
 			AllowOrigins: []string{getEnv("CORS_ORIGIN", "http://localhost:3000")},
+			AppURL:       appURL,
 		},
 		Database: DatabaseConfig{
 			Path: getEnv("DATABASE_PATH", "./vista.db"),
@@ -51,6 +106,42 @@ func Load() *Config {
 		Crypto: CryptoConfig{
 			EncryptionKey: getEnv("ENCRYPTION_KEY", "32-byte-long-key-for-aes256!!!!!"), // Must be 32 bytes for AES-256
 		},
+		Language: LanguageConfig{
+			Enabled: getListEnv("ENABLED_LANGUAGES", []string{"en", "zh", "es"}),
+			Default: getEnv("DEFAULT_LANGUAGE", "en"),
+		},
+		Login: LoginConfig{
+			MaxFailures:     getIntEnv("LOGIN_MAX_FAILURES", 5),
+			IPMaxFailures:   getIntEnv("LOGIN_IP_MAX_FAILURES", 20),
+			LockoutDuration: getDurationEnv("LOGIN_LOCKOUT", 15*time.Minute),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:        getEnv("TOTP_ISSUER", "Vista"),
+			RequiredRoles: getListEnv("TWO_FACTOR_REQUIRED_ROLES", nil),
+		},
+		SSO: SSOConfig{
+			Issuer:              getEnv("OIDC_ISSUER", ""),
+			ClientID:            getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:        getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:         getEnv("OIDC_REDIRECT_URL", strings.TrimRight(appURL, "/")+"/sso/callback"),
+			Scopes:              getListEnv("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+			ProviderName:        getEnv("OIDC_PROVIDER_NAME", "SSO"),
+			EmployeeNumberClaim: getEnv("OIDC_EMPLOYEE_NUMBER_CLAIM", "employee_number"),
+			DepartmentClaim:     getEnv("OIDC_DEPARTMENT_CLAIM", "department"),
+			GroupsClaim:         getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			RoleGroups:          getListEnv("OIDC_ROLE_GROUPS", nil),
+			AutoApprove:         getBoolEnv("OIDC_AUTO_APPROVE", false),
+		},
+		LDAP: LDAPConfig{
+			URL:          getEnv("LDAP_URL", ""),
+			StartTLS:     getBoolEnv("LDAP_START_TLS", false),
+			BindDN:       getEnv("LDAP_BIND_DN", ""),
+			BindPassword: getEnv("LDAP_BIND_PASSWORD", ""),
+			BaseDN:       getEnv("LDAP_BASE_DN", ""),
+			UserFilter:   getEnv("LDAP_USER_FILTER", "(objectClass=person)"),
+			Attributes:   getListEnv("LDAP_ATTRIBUTES", nil),
+			SyncInterval: getDurationEnv("LDAP_SYNC_INTERVAL", time.Hour),
+		},
 	}
 }
 
@@ -61,6 +152,39 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if minutes, err := strconv.Atoi(value); err == nil {
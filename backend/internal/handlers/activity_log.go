@@ -60,13 +60,15 @@ type ActivityStats struct {
 	UniqueUsers        int64 `json:"unique_users"`
 	TodayLogins        int64 `json:"today_logins"`
 	TodayFailedLogins  int64 `json:"today_failed_logins"`
+	LockedLogins       int64 `json:"locked_logins"`       // Logins refused by throttling or lockout
+	TodayLockedLogins  int64 `json:"today_locked_logins"`
 }
 
-// GetActivityLogs returns paginated activity logs (admin only)
+// GetActivityLogs returns paginated activity logs (needs logs.view)
 func (h *ActivityLogHandler) GetActivityLogs(c *gin.Context) {
 	user := h.getCurrentUser(c)
-	if user == nil || !user.IsAdmin() {
-		response.Forbidden(c, "Admin access required")
+	if user == nil || !middleware.HasPermission(c, models.PermLogsView) {
+		response.Forbidden(c, "Insufficient permissions")
 		return
 	}
 
@@ -166,11 +168,11 @@ func (h *ActivityLogHandler) GetActivityLogs(c *gin.Context) {
 	})
 }
 
-// GetActivityStats returns activity statistics (admin only)
+// GetActivityStats returns activity statistics (needs logs.view)
 func (h *ActivityLogHandler) GetActivityStats(c *gin.Context) {
 	user := h.getCurrentUser(c)
-	if user == nil || !user.IsAdmin() {
-		response.Forbidden(c, "Admin access required")
+	if user == nil || !middleware.HasPermission(c, models.PermLogsView) {
+		response.Forbidden(c, "Insufficient permissions")
 		return
 	}
 
@@ -205,14 +207,20 @@ func (h *ActivityLogHandler) GetActivityStats(c *gin.Context) {
 			models.ActivityLoginFailed, models.ActivityLogin, false, today).
 		Count(&stats.TodayFailedLogins)
 
+	// Logins refused for too many failed attempts
+	h.db.Model(&models.ActivityLog{}).Where("type = ?", models.ActivityLoginLocked).Count(&stats.LockedLogins)
+	h.db.Model(&models.ActivityLog{}).
+		Where("type = ? AND created_at >= ?", models.ActivityLoginLocked, today).
+		Count(&stats.TodayLockedLogins)
+
 	response.Success(c, stats)
 }
 
-// GetActiveSessions returns currently active sessions (admin only)
+// GetActiveSessions returns currently active sessions (needs logs.view)
 func (h *ActivityLogHandler) GetActiveSessions(c *gin.Context) {
 	user := h.getCurrentUser(c)
-	if user == nil || !user.IsAdmin() {
-		response.Forbidden(c, "Admin access required")
+	if user == nil || !middleware.HasPermission(c, models.PermLogsView) {
+		response.Forbidden(c, "Insufficient permissions")
 		return
 	}
 
@@ -255,11 +263,11 @@ func (h *ActivityLogHandler) GetActiveSessions(c *gin.Context) {
 	response.Success(c, responses)
 }
 
-// EndSession ends a specific session (admin only)
+// EndSession ends a specific session (needs logs.view)
 func (h *ActivityLogHandler) EndSession(c *gin.Context) {
 	user := h.getCurrentUser(c)
-	if user == nil || !user.IsAdmin() {
-		response.Forbidden(c, "Admin access required")
+	if user == nil || !middleware.HasPermission(c, models.PermLogsView) {
+		response.Forbidden(c, "Insufficient permissions")
 		return
 	}
 
@@ -270,10 +278,14 @@ func (h *ActivityLogHandler) EndSession(c *gin.Context) {
 	}
 
 	var log models.ActivityLog
-	if err := h.db.First(&log, id).Error; err != nil {
+	if err := h.db.First(&log, id).Error; err != nil || !log.IsSession() {
 		response.NotFound(c, "Session not found")
 		return
 	}
+	if log.EndedAt != nil {
+		response.BadRequest(c, "Session has already ended")
+		return
+	}
 
 	now := time.Now()
 	log.EndedAt = &now
@@ -286,3 +298,47 @@ func (h *ActivityLogHandler) EndSession(c *gin.Context) {
 
 	response.Success(c, gin.H{"message": "Session ended successfully"})
 }
+
+// GetAuditLogs returns the audit trail of request changes, newest first.
+// Filters: resource, resource_id, user_id, action.
+func (h *ActivityLogHandler) GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.AuditLog{}).Preload("User")
+	if resource := c.Query("resource"); resource != "" {
+		query = query.Where("resource = ?", resource)
+	}
+	if resourceID := c.Query("resource_id"); resourceID != "" {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.AuditLog
+	offset := (page - 1) * perPage
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(perPage).Find(&logs).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch audit logs")
+		return
+	}
+
+	response.SuccessWithMeta(c, logs, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +9,7 @@ import (
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/events"
 	"vista-backend/internal/services/translation"
 	"vista-backend/pkg/crypto"
 	"vista-backend/pkg/response"
@@ -17,38 +17,22 @@ import (
 
 type AdminHandler struct {
 	db              *gorm.DB
+	bus             *events.Bus
 	encryptionSvc   *crypto.EncryptionService
 	amazonSvc       *amazon.AutomationService
 	asyncTranslator *translation.AsyncTranslator
 }
 
-func NewAdminHandler(db *gorm.DB, encryptionSvc *crypto.EncryptionService, amazonSvc *amazon.AutomationService) *AdminHandler {
+func NewAdminHandler(db *gorm.DB, bus *events.Bus, encryptionSvc *crypto.EncryptionService, amazonSvc *amazon.AutomationService) *AdminHandler {
 	return &AdminHandler{
 		db:              db,
+		bus:             bus,
 		encryptionSvc:   encryptionSvc,
 		amazonSvc:       amazonSvc,
 		asyncTranslator: translation.NewAsyncTranslator(db),
 	}
 }
 
-// getUserLanguage extracts user's preferred language from request headers
-func (h *AdminHandler) getUserLanguage(c *gin.Context) string {
-	if lang := c.GetHeader("X-User-Language"); lang != "" {
-		lang = strings.ToLower(lang)
-		if lang == "en" || lang == "zh" || lang == "es" {
-			return lang
-		}
-	}
-	acceptLang := c.GetHeader("Accept-Language")
-	if strings.HasPrefix(strings.ToLower(acceptLang), "zh") {
-		return "zh"
-	}
-	if strings.HasPrefix(strings.ToLower(acceptLang), "es") {
-		return "es"
-	}
-	return "en"
-}
-
 // Amazon Config types
 
 type AmazonConfigRequest struct {
@@ -72,10 +56,10 @@ type AmazonConfigResponse struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
-// GetAmazonConfig returns the current Amazon configuration
+// GetAmazonConfig returns the company's Amazon configuration
 func (h *AdminHandler) GetAmazonConfig(c *gin.Context) {
 	var config models.AmazonConfig
-	if err := h.db.First(&config).Error; err != nil {
+	if err := inCompany(c, h.db).First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.Success(c, AmazonConfigResponse{
 				Marketplace: "www.amazon.com.mx",
@@ -103,7 +87,7 @@ func (h *AdminHandler) GetAmazonConfig(c *gin.Context) {
 	})
 }
 
-// SaveAmazonConfig saves or updates the Amazon configuration
+// SaveAmazonConfig saves or updates the company's Amazon configuration
 func (h *AdminHandler) SaveAmazonConfig(c *gin.Context) {
 	var req AmazonConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -114,7 +98,7 @@ func (h *AdminHandler) SaveAmazonConfig(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var config models.AmazonConfig
-	isNew := h.db.First(&config).Error == gorm.ErrRecordNotFound
+	isNew := inCompany(c, h.db).First(&config).Error == gorm.ErrRecordNotFound
 
 	config.Email = req.Email
 
@@ -140,6 +124,7 @@ func (h *AdminHandler) SaveAmazonConfig(c *gin.Context) {
 	}
 
 	if isNew {
+		config.CompanyCode = middleware.GetCompany(c)
 		config.CreatedByID = userID
 		if err := h.db.Create(&config).Error; err != nil {
 			response.InternalServerError(c, "Failed to create Amazon config")
@@ -167,10 +152,10 @@ func (h *AdminHandler) SaveAmazonConfig(c *gin.Context) {
 	})
 }
 
-// TestAmazonConnection tests the Amazon Business connection
+// TestAmazonConnection tests the company's Amazon Business connection
 func (h *AdminHandler) TestAmazonConnection(c *gin.Context) {
 	var config models.AmazonConfig
-	if err := h.db.First(&config).Error; err != nil {
+	if err := inCompany(c, h.db).First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.BadRequest(c, "Amazon configuration not found")
 			return
@@ -246,7 +231,7 @@ func (h *AdminHandler) GetApprovedOrders(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	query := h.db.Model(&models.PurchaseRequest{}).
+	query := inCompany(c, h.db.Model(&models.PurchaseRequest{})).
 		Preload("Requester").
 		Preload("ApprovedBy").
 		Preload("PurchasedBy").
@@ -305,13 +290,14 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 	var input struct {
 		Notes       string `json:"notes"`
 		OrderNumber string `json:"order_number"`
+		Language    string `json:"language"` // Overrides language detection for notes
 	}
 	c.ShouldBindJSON(&input)
 
 	userID := middleware.GetUserID(c)
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -325,6 +311,7 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 		return
 	}
 
+	oldStatus := request.Status
 	now := time.Now()
 	request.Status = models.StatusPurchased
 	request.PurchasedByID = &userID
@@ -333,29 +320,33 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 	request.OrderNumber = input.OrderNumber
 
 	// Translate purchase notes
+	var translationResult *translation.TranslateFieldResult
 	if input.Notes != "" {
-		userLang := h.getUserLanguage(c)
-		translationResult, _ := h.asyncTranslator.TranslateField(
-			input.Notes,
-			userLang,
-			func(t *translation.TranslatedText) error {
-				return h.db.Model(&models.PurchaseRequest{}).
-					Where("id = ?", request.ID).
-					Update("purchase_notes_translated", translation.ToJSON(t)).Error
-			},
-		)
+		if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+			response.BadRequest(c, "Unsupported notes language")
+			return
+		}
+		userLang := getUserLanguage(c)
+		translationResult, _ = h.asyncTranslator.TranslateField(input.Notes, userLang, input.Language)
 		if translationResult != nil {
 			request.PurchaseNotesTranslated = translationResult.JSON
 		}
 	}
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
 
 		history := models.NewHistory(request.ID, userID, models.ActionCompleted, models.StatusApproved, models.StatusPurchased, "Marked as purchased")
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		event := &events.OrderPurchased{RequestEvent: newRequestEvent(c, &request, oldStatus)}
+		event.Translate("purchase_notes_translated", translationResult)
+		emit(event)
+		return nil
 	})
 
 	if err != nil {
@@ -382,7 +373,7 @@ func (h *AdminHandler) RetryAddToCart(c *gin.Context) {
 	}
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -401,9 +392,9 @@ func (h *AdminHandler) RetryAddToCart(c *gin.Context) {
 		return
 	}
 
-	// Check Amazon config
+	// Check the Amazon config of the request's company
 	var config models.AmazonConfig
-	if err := h.db.First(&config).Error; err != nil {
+	if err := h.db.Where("company_code = ?", request.CompanyCode).First(&config).Error; err != nil {
 		response.BadRequest(c, "Amazon is not configured")
 		return
 	}
@@ -465,6 +456,7 @@ func (h *AdminHandler) UpdateOrderNotes(c *gin.Context) {
 
 	var input struct {
 		AdminNotes string `json:"admin_notes"`
+		Language   string `json:"language"` // Overrides language detection for notes
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		response.BadRequest(c, "Invalid request body")
@@ -472,7 +464,7 @@ func (h *AdminHandler) UpdateOrderNotes(c *gin.Context) {
 	}
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -485,23 +477,30 @@ func (h *AdminHandler) UpdateOrderNotes(c *gin.Context) {
 	request.AdminNotes = input.AdminNotes
 
 	// Translate admin notes
+	var translationResult *translation.TranslateFieldResult
 	if input.AdminNotes != "" {
-		userLang := h.getUserLanguage(c)
-		translationResult, _ := h.asyncTranslator.TranslateField(
-			input.AdminNotes,
-			userLang,
-			func(t *translation.TranslatedText) error {
-				return h.db.Model(&models.PurchaseRequest{}).
-					Where("id = ?", request.ID).
-					Update("admin_notes_translated", translation.ToJSON(t)).Error
-			},
-		)
+		if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+			response.BadRequest(c, "Unsupported notes language")
+			return
+		}
+		userLang := getUserLanguage(c)
+		translationResult, _ = h.asyncTranslator.TranslateField(input.AdminNotes, userLang, input.Language)
 		if translationResult != nil {
 			request.AdminNotesTranslated = translationResult.JSON
 		}
 	}
 
-	if err := h.db.Save(&request).Error; err != nil {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+
+		event := &events.OrderNotesUpdated{RequestEvent: newRequestEvent(c, &request, request.Status)}
+		event.Translate("admin_notes_translated", translationResult)
+		emit(event)
+		return nil
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to update notes")
 		return
 	}
@@ -525,14 +524,15 @@ func (h *AdminHandler) MarkAsDelivered(c *gin.Context) {
 	}
 
 	var input struct {
-		Notes string `json:"notes"`
+		Notes    string `json:"notes"`
+		Language string `json:"language"` // Overrides language detection for notes
 	}
 	c.ShouldBindJSON(&input)
 
 	userID := middleware.GetUserID(c)
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -554,23 +554,20 @@ func (h *AdminHandler) MarkAsDelivered(c *gin.Context) {
 	request.DeliveryNotes = input.Notes
 
 	// Translate delivery notes
+	var translationResult *translation.TranslateFieldResult
 	if input.Notes != "" {
-		userLang := h.getUserLanguage(c)
-		translationResult, _ := h.asyncTranslator.TranslateField(
-			input.Notes,
-			userLang,
-			func(t *translation.TranslatedText) error {
-				return h.db.Model(&models.PurchaseRequest{}).
-					Where("id = ?", request.ID).
-					Update("delivery_notes_translated", translation.ToJSON(t)).Error
-			},
-		)
+		if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+			response.BadRequest(c, "Unsupported notes language")
+			return
+		}
+		userLang := getUserLanguage(c)
+		translationResult, _ = h.asyncTranslator.TranslateField(input.Notes, userLang, input.Language)
 		if translationResult != nil {
 			request.DeliveryNotesTranslated = translationResult.JSON
 		}
 	}
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
@@ -580,7 +577,14 @@ func (h *AdminHandler) MarkAsDelivered(c *gin.Context) {
 			comment = input.Notes
 		}
 		history := models.NewHistory(request.ID, userID, models.ActionDelivered, oldStatus, models.StatusDelivered, comment)
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		event := &events.OrderDelivered{RequestEvent: newRequestEvent(c, &request, oldStatus)}
+		event.Translate("delivery_notes_translated", translationResult)
+		emit(event)
+		return nil
 	})
 
 	if err != nil {
@@ -608,7 +612,8 @@ func (h *AdminHandler) CancelOrder(c *gin.Context) {
 	}
 
 	var input struct {
-		Notes string `json:"notes" binding:"required"`
+		Notes    string `json:"notes" binding:"required"`
+		Language string `json:"language"` // Overrides language detection for notes
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		response.BadRequest(c, "Cancellation reason is required")
@@ -618,7 +623,7 @@ func (h *AdminHandler) CancelOrder(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -640,27 +645,30 @@ func (h *AdminHandler) CancelOrder(c *gin.Context) {
 	request.CancellationNotes = input.Notes
 
 	// Translate cancellation notes
-	userLang := h.getUserLanguage(c)
-	translationResult, _ := h.asyncTranslator.TranslateField(
-		input.Notes,
-		userLang,
-		func(t *translation.TranslatedText) error {
-			return h.db.Model(&models.PurchaseRequest{}).
-				Where("id = ?", request.ID).
-				Update("cancellation_notes_translated", translation.ToJSON(t)).Error
-		},
-	)
+	if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+		response.BadRequest(c, "Unsupported notes language")
+		return
+	}
+	userLang := getUserLanguage(c)
+	translationResult, _ := h.asyncTranslator.TranslateField(input.Notes, userLang, input.Language)
 	if translationResult != nil {
 		request.CancellationNotesTranslated = translationResult.JSON
 	}
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
 
 		history := models.NewHistory(request.ID, userID, models.ActionCancelled, oldStatus, models.StatusCancelled, input.Notes)
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		event := &events.OrderCancelled{RequestEvent: newRequestEvent(c, &request, oldStatus), Reason: input.Notes}
+		event.Translate("cancellation_notes_translated", translationResult)
+		emit(event)
+		return nil
 	})
 
 	if err != nil {
@@ -695,7 +703,7 @@ func (h *AdminHandler) MarkItemPurchased(c *gin.Context) {
 
 	// Get the purchase request
 	var request models.PurchaseRequest
-	if err := h.db.Preload("Items").First(&request, requestID).Error; err != nil {
+	if err := inCompany(c, h.db).Preload("Items").First(&request, requestID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -710,52 +718,31 @@ func (h *AdminHandler) MarkItemPurchased(c *gin.Context) {
 		return
 	}
 
-	// Find and update the item
-	var itemFound bool
-	now := time.Now()
+	// Find the item
+	var item *models.PurchaseRequestItem
 	for i := range request.Items {
 		if request.Items[i].ID == uint(itemID) {
-			request.Items[i].IsPurchased = true
-			request.Items[i].PurchasedAt = &now
-			itemFound = true
-			if err := h.db.Save(&request.Items[i]).Error; err != nil {
-				response.InternalServerError(c, "Failed to update item")
-				return
-			}
+			item = &request.Items[i]
 			break
 		}
 	}
-
-	if !itemFound {
+	if item == nil {
 		response.NotFound(c, "Item not found in this request")
 		return
 	}
 
-	// Reload items to check purchase progress
-	h.db.Preload("Items").First(&request, requestID)
-
-	// Check if ALL items are now purchased - auto-mark order as purchased
-	allPurchased := true
-	for _, item := range request.Items {
-		if !item.IsPurchased {
-			allPurchased = false
-			break
-		}
-	}
-
-	if allPurchased && request.Status == models.StatusApproved {
-		userID := middleware.GetUserID(c)
-		request.Status = models.StatusPurchased
-		request.PurchasedByID = &userID
-		request.PurchasedAt = &now
-		if err := h.db.Save(&request).Error; err != nil {
-			response.InternalServerError(c, "Failed to update order status")
-			return
+	now := time.Now()
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
+		item.IsPurchased = true
+		item.PurchasedAt = &now
+		if err := tx.Save(item).Error; err != nil {
+			return err
 		}
-
-		// Create history entry
-		history := models.NewHistory(request.ID, userID, models.ActionCompleted, models.StatusApproved, models.StatusPurchased, "All items purchased")
-		h.db.Create(history)
+		return h.completeIfAllPurchased(c, tx, emit, &request, now)
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to update item")
+		return
 	}
 
 	// Reload with relations
@@ -779,7 +766,7 @@ func (h *AdminHandler) MarkAllItemsPurchased(c *gin.Context) {
 
 	// Get the purchase request
 	var request models.PurchaseRequest
-	if err := h.db.Preload("Items").First(&request, requestID).Error; err != nil {
+	if err := inCompany(c, h.db).Preload("Items").First(&request, requestID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -794,33 +781,22 @@ func (h *AdminHandler) MarkAllItemsPurchased(c *gin.Context) {
 		return
 	}
 
-	// Mark all items as purchased
 	now := time.Now()
-	for i := range request.Items {
-		if !request.Items[i].IsPurchased {
-			request.Items[i].IsPurchased = true
-			request.Items[i].PurchasedAt = &now
-			if err := h.db.Save(&request.Items[i]).Error; err != nil {
-				response.InternalServerError(c, "Failed to update items")
-				return
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
+		for i := range request.Items {
+			if !request.Items[i].IsPurchased {
+				request.Items[i].IsPurchased = true
+				request.Items[i].PurchasedAt = &now
+				if err := tx.Save(&request.Items[i]).Error; err != nil {
+					return err
+				}
 			}
 		}
-	}
-
-	// Auto-mark order as purchased if status is still approved
-	if request.Status == models.StatusApproved {
-		userID := middleware.GetUserID(c)
-		request.Status = models.StatusPurchased
-		request.PurchasedByID = &userID
-		request.PurchasedAt = &now
-		if err := h.db.Save(&request).Error; err != nil {
-			response.InternalServerError(c, "Failed to update order status")
-			return
-		}
-
-		// Create history entry
-		history := models.NewHistory(request.ID, userID, models.ActionCompleted, models.StatusApproved, models.StatusPurchased, "All items purchased")
-		h.db.Create(history)
+		return h.completeIfAllPurchased(c, tx, emit, &request, now)
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to update items")
+		return
 	}
 
 	// Reload with relations
@@ -834,6 +810,36 @@ func (h *AdminHandler) MarkAllItemsPurchased(c *gin.Context) {
 	response.SuccessWithMessage(c, "All items marked as purchased", requestToResponse(request))
 }
 
+// completeIfAllPurchased marks an approved order as purchased once all its
+// items are, emitting OrderPurchased so the requester hears about it
+func (h *AdminHandler) completeIfAllPurchased(c *gin.Context, tx *gorm.DB, emit func(events.Event), request *models.PurchaseRequest, now time.Time) error {
+	if request.Status != models.StatusApproved {
+		return nil
+	}
+	for _, item := range request.Items {
+		if !item.IsPurchased {
+			return nil
+		}
+	}
+
+	userID := middleware.GetUserID(c)
+	oldStatus := request.Status
+	request.Status = models.StatusPurchased
+	request.PurchasedByID = &userID
+	request.PurchasedAt = &now
+	if err := tx.Omit("Items").Save(request).Error; err != nil {
+		return err
+	}
+
+	history := models.NewHistory(request.ID, userID, models.ActionCompleted, oldStatus, models.StatusPurchased, "All items purchased")
+	if err := tx.Create(history).Error; err != nil {
+		return err
+	}
+
+	emit(&events.OrderPurchased{RequestEvent: newRequestEvent(c, request, oldStatus), AllItemsPurchased: true})
+	return nil
+}
+
 // GetDashboardStats returns admin dashboard statistics
 func (h *AdminHandler) GetDashboardStats(c *gin.Context) {
 	var stats struct {
@@ -850,20 +856,28 @@ func (h *AdminHandler) GetDashboardStats(c *gin.Context) {
 		AmazonConfigured bool  `json:"amazon_configured"`
 	}
 
-	h.db.Model(&models.User{}).Count(&stats.TotalUsers)
-	h.db.Model(&models.User{}).Where("status = ?", "approved").Count(&stats.ActiveUsers)
-	h.db.Model(&models.User{}).Where("status = ?", "pending").Count(&stats.PendingUsers)
-	h.db.Model(&models.Product{}).Where("is_active = ?", true).Count(&stats.TotalProducts)
-	h.db.Model(&models.PurchaseRequest{}).Count(&stats.TotalRequests)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusPending).Count(&stats.PendingApprovals)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusApproved).Count(&stats.ApprovedRequests)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusPurchased).Count(&stats.PurchasedOrders)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ? AND is_amazon_url = ? AND added_to_cart = ?", models.StatusApproved, true, true).Count(&stats.AmazonInCart)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ? AND (is_amazon_url = ? OR (is_amazon_url = ? AND added_to_cart = ?))",
+	company := middleware.GetCompany(c)
+	users := func() *gorm.DB {
+		return models.CompanyMembers(h.db.Model(&models.User{}), company)
+	}
+	requests := func() *gorm.DB {
+		return inCompany(c, h.db.Model(&models.PurchaseRequest{}))
+	}
+
+	users().Count(&stats.TotalUsers)
+	users().Where("status = ?", "approved").Count(&stats.ActiveUsers)
+	users().Where("status = ?", "pending").Count(&stats.PendingUsers)
+	inCompany(c, h.db.Model(&models.Product{})).Where("is_active = ?", true).Count(&stats.TotalProducts)
+	requests().Count(&stats.TotalRequests)
+	requests().Where("status = ?", models.StatusPending).Count(&stats.PendingApprovals)
+	requests().Where("status = ?", models.StatusApproved).Count(&stats.ApprovedRequests)
+	requests().Where("status = ?", models.StatusPurchased).Count(&stats.PurchasedOrders)
+	requests().Where("status = ? AND is_amazon_url = ? AND added_to_cart = ?", models.StatusApproved, true, true).Count(&stats.AmazonInCart)
+	requests().Where("status = ? AND (is_amazon_url = ? OR (is_amazon_url = ? AND added_to_cart = ?))",
 		models.StatusApproved, false, true, false).Count(&stats.PendingManual)
 
 	var config models.AmazonConfig
-	stats.AmazonConfigured = h.db.First(&config).Error == nil && config.IsConfigured()
+	stats.AmazonConfigured = inCompany(c, h.db).First(&config).Error == nil && config.IsConfigured()
 
 	response.Success(c, stats)
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"vista-backend/pkg/i18n"
 	"vista-backend/pkg/response"
 )
 
@@ -77,11 +78,8 @@ func (h *AISummaryHandler) GenerateSummary(c *gin.Context) {
 
 	// Determine language for response
 	langInstruction := "Respond in English."
-	switch req.Language {
-	case "es":
-		langInstruction = "Responde en español."
-	case "zh":
-		langInstruction = "用中文回复。"
+	if lang, ok := i18n.Lookup(req.Language); ok && lang.Code != "en" && i18n.IsEnabled(lang.Code) {
+		langInstruction = fmt.Sprintf("Respond in %s (%s).", lang.Name, lang.NativeName)
 	}
 
 	// Build the prompt based on whether this is a question or initial summary
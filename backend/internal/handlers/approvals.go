@@ -1,60 +1,36 @@
 package handlers
 
 import (
-	"log"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
-	"vista-backend/internal/services/amazon"
-	"vista-backend/internal/services/notifications"
+	"vista-backend/internal/services/events"
 	"vista-backend/internal/services/translation"
-	"vista-backend/pkg/crypto"
 	"vista-backend/pkg/response"
 )
 
 type ApprovalHandler struct {
 	db              *gorm.DB
-	amazonSvc       *amazon.AutomationService
-	encryptionSvc   *crypto.EncryptionService
-	notificationSvc *notifications.NotificationService
+	bus             *events.Bus
 	asyncTranslator *translation.AsyncTranslator
 }
 
-func NewApprovalHandler(db *gorm.DB, amazonSvc *amazon.AutomationService, encryptionSvc *crypto.EncryptionService) *ApprovalHandler {
+func NewApprovalHandler(db *gorm.DB, bus *events.Bus) *ApprovalHandler {
 	return &ApprovalHandler{
 		db:              db,
-		amazonSvc:       amazonSvc,
-		encryptionSvc:   encryptionSvc,
-		notificationSvc: notifications.NewNotificationService(db),
+		bus:             bus,
 		asyncTranslator: translation.NewAsyncTranslator(db),
 	}
 }
 
-// getUserLanguage extracts user's preferred language from request headers
-func (h *ApprovalHandler) getUserLanguage(c *gin.Context) string {
-	if lang := c.GetHeader("X-User-Language"); lang != "" {
-		lang = strings.ToLower(lang)
-		if lang == "en" || lang == "zh" || lang == "es" {
-			return lang
-		}
-	}
-	acceptLang := c.GetHeader("Accept-Language")
-	if strings.HasPrefix(strings.ToLower(acceptLang), "zh") {
-		return "zh"
-	}
-	if strings.HasPrefix(strings.ToLower(acceptLang), "es") {
-		return "es"
-	}
-	return "en"
-}
-
 type ApprovalAction struct {
 	Comment string `json:"comment"`
+	// Language overrides language detection for the comment
+	Language string `json:"language"`
 }
 
 // ListPendingApprovals returns a list of requests for approval (filtered by status)
@@ -66,7 +42,7 @@ func (h *ApprovalHandler) ListPendingApprovals(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	query := h.db.Model(&models.PurchaseRequest{}).
+	query := inCompany(c, h.db.Model(&models.PurchaseRequest{})).
 		Preload("Requester").
 		Preload("Items")
 
@@ -135,11 +111,12 @@ func (h *ApprovalHandler) GetApprovalDetails(c *gin.Context) {
 	}
 
 	var request models.PurchaseRequest
-	if err := h.db.
+	if err := inCompany(c, h.db).
 		Preload("Requester").
 		Preload("Items").
 		Preload("History").
 		Preload("History.User").
+		Preload("InfoRequestedBy").
 		First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
@@ -168,7 +145,7 @@ func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -191,12 +168,7 @@ func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 	// Generate PO number when approved (converts PR-YYYY-XXXX to PO-YYYY-XXXX)
 	request.PONumber = models.GeneratePONumber(request.RequestNumber)
 
-	// If it's an Amazon URL, try to add to cart
-	if request.IsAmazonURL && h.amazonSvc != nil {
-		go h.addToAmazonCart(&request)
-	}
-
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
@@ -206,7 +178,12 @@ func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 			comment = "Request approved"
 		}
 		history := models.NewHistory(request.ID, userID, models.ActionApproved, oldStatus, models.StatusApproved, comment)
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		emit(&events.RequestApproved{RequestEvent: newRequestEvent(c, &request, oldStatus), Comment: input.Comment})
+		return nil
 	})
 
 	if err != nil {
@@ -223,85 +200,9 @@ func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 		Preload("ApprovedBy").
 		First(&request, request.ID)
 
-	// Send notifications (async to not block the response)
-	go func() {
-		if err := h.notificationSvc.NotifyRequestApproved(&request); err != nil {
-			log.Printf("Failed to send approval notification: %v", err)
-		}
-		if err := h.notificationSvc.NotifyNewApprovedOrder(&request); err != nil {
-			log.Printf("Failed to send new order notification: %v", err)
-		}
-	}()
-
 	response.SuccessWithMessage(c, "Request approved successfully", requestToResponse(request))
 }
 
-// addToAmazonCart adds the product to Amazon cart asynchronously
-func (h *ApprovalHandler) addToAmazonCart(request *models.PurchaseRequest) {
-	// Check if Amazon is configured
-	var config models.AmazonConfig
-	if err := h.db.First(&config).Error; err != nil {
-		log.Printf("Amazon config not found, skipping cart automation for request %d", request.ID)
-		return
-	}
-
-	if !config.CanConnect() {
-		log.Printf("Amazon not configured or inactive, skipping cart automation for request %d", request.ID)
-		return
-	}
-
-	// Decrypt password
-	password, err := h.encryptionSvc.Decrypt(config.EncryptedPassword)
-	if err != nil {
-		log.Printf("Failed to decrypt Amazon password: %v", err)
-		h.updateCartError(request.ID, "Failed to decrypt credentials")
-		return
-	}
-
-	// Set credentials
-	h.amazonSvc.SetCredentials(config.Email, password, config.Marketplace)
-
-	// Initialize browser if needed
-	if err := h.amazonSvc.Initialize(); err != nil {
-		log.Printf("Failed to initialize Amazon browser: %v", err)
-		h.updateCartError(request.ID, "Failed to initialize browser: "+err.Error())
-		return
-	}
-
-	// Login if needed
-	if !h.amazonSvc.IsLoggedIn() {
-		if err := h.amazonSvc.Login(); err != nil {
-			log.Printf("Failed to login to Amazon: %v", err)
-			h.updateCartError(request.ID, "Failed to login: "+err.Error())
-			return
-		}
-	}
-
-	// Add to cart
-	if err := h.amazonSvc.AddToCart(request.URL, request.Quantity); err != nil {
-		log.Printf("Failed to add to cart: %v", err)
-		h.updateCartError(request.ID, "Failed to add to cart: "+err.Error())
-		return
-	}
-
-	// Update request as successfully added to cart
-	now := time.Now()
-	h.db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
-		"added_to_cart":    true,
-		"added_to_cart_at": now,
-		"cart_error":       "",
-	})
-
-	log.Printf("Successfully added request %d to Amazon cart", request.ID)
-}
-
-// updateCartError updates the cart error for a request
-func (h *ApprovalHandler) updateCartError(requestID uint, errMsg string) {
-	h.db.Model(&models.PurchaseRequest{}).Where("id = ?", requestID).Updates(map[string]interface{}{
-		"cart_error": errMsg,
-	})
-}
-
 // RejectRequest rejects a purchase request
 func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -324,7 +225,7 @@ func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -345,28 +246,31 @@ func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 	request.RejectedAt = &now
 	request.RejectionReason = input.Comment
 
-	// Translate rejection reason
-	userLang := h.getUserLanguage(c)
-	translationResult, _ := h.asyncTranslator.TranslateField(
-		input.Comment,
-		userLang,
-		func(t *translation.TranslatedText) error {
-			return h.db.Model(&models.PurchaseRequest{}).
-				Where("id = ?", request.ID).
-				Update("rejection_reason_translated", translation.ToJSON(t)).Error
-		},
-	)
+	// Translate rejection reason (remaining languages are queued with the save)
+	if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+		response.BadRequest(c, "Unsupported comment language")
+		return
+	}
+	userLang := getUserLanguage(c)
+	translationResult, _ := h.asyncTranslator.TranslateField(input.Comment, userLang, input.Language)
 	if translationResult != nil {
 		request.RejectionReasonTranslated = translationResult.JSON
 	}
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
 
 		history := models.NewHistory(request.ID, userID, models.ActionRejected, oldStatus, models.StatusRejected, input.Comment)
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		event := &events.RequestRejected{RequestEvent: newRequestEvent(c, &request, oldStatus), Reason: input.Comment}
+		event.Translate("rejection_reason_translated", translationResult)
+		emit(event)
+		return nil
 	})
 
 	if err != nil {
@@ -383,13 +287,6 @@ func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 		Preload("RejectedBy").
 		First(&request, request.ID)
 
-	// Send notification (async)
-	go func() {
-		if err := h.notificationSvc.NotifyRequestRejected(&request, input.Comment); err != nil {
-			log.Printf("Failed to send rejection notification: %v", err)
-		}
-	}()
-
 	response.SuccessWithMessage(c, "Request rejected", requestToResponse(request))
 }
 
@@ -415,7 +312,7 @@ func (h *ApprovalHandler) RequestInfo(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
 		} else {
@@ -432,31 +329,39 @@ func (h *ApprovalHandler) RequestInfo(c *gin.Context) {
 	oldStatus := request.Status
 	now := time.Now()
 	request.Status = models.StatusInfoRequested
+	request.InfoRequestedByID = &userID
 	request.InfoRequestedAt = &now
 	request.InfoRequestNote = input.Comment
-
-	// Translate info request note
-	userLang := h.getUserLanguage(c)
-	translationResult, _ := h.asyncTranslator.TranslateField(
-		input.Comment,
-		userLang,
-		func(t *translation.TranslatedText) error {
-			return h.db.Model(&models.PurchaseRequest{}).
-				Where("id = ?", request.ID).
-				Update("info_request_note_translated", translation.ToJSON(t)).Error
-		},
-	)
+	// A previous answer doesn't answer the new question
+	request.InfoResponse = ""
+	request.InfoRespondedAt = nil
+	request.InfoResponseTranslated = nil
+
+	// Translate info request note (remaining languages are queued with the save)
+	if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+		response.BadRequest(c, "Unsupported comment language")
+		return
+	}
+	userLang := getUserLanguage(c)
+	translationResult, _ := h.asyncTranslator.TranslateField(input.Comment, userLang, input.Language)
 	if translationResult != nil {
 		request.InfoRequestNoteTranslated = translationResult.JSON
 	}
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
 
 		history := models.NewHistory(request.ID, userID, models.ActionReturned, oldStatus, models.StatusInfoRequested, input.Comment)
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		event := &events.InfoRequested{RequestEvent: newRequestEvent(c, &request, oldStatus), Note: input.Comment}
+		event.Translate("info_request_note_translated", translationResult)
+		emit(event)
+		return nil
 	})
 
 	if err != nil {
@@ -470,15 +375,9 @@ func (h *ApprovalHandler) RequestInfo(c *gin.Context) {
 		Preload("Items").
 		Preload("History").
 		Preload("History.User").
+		Preload("InfoRequestedBy").
 		First(&request, request.ID)
 
-	// Send notification (async)
-	go func() {
-		if err := h.notificationSvc.NotifyRequestInfoRequired(&request, input.Comment); err != nil {
-			log.Printf("Failed to send info required notification: %v", err)
-		}
-	}()
-
 	response.SuccessWithMessage(c, "Information requested from requester", requestToResponse(request))
 }
 
@@ -495,14 +394,15 @@ func (h *ApprovalHandler) GetApprovalStats(c *gin.Context) {
 		AmazonInCart int64 `json:"amazon_in_cart"`
 	}
 
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusPending).Count(&stats.Pending)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusApproved).Count(&stats.Approved)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusRejected).Count(&stats.Rejected)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusInfoRequested).Count(&stats.InfoRequired)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusPurchased).Count(&stats.Purchased)
-	h.db.Model(&models.PurchaseRequest{}).Count(&stats.Total)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ? AND urgency = ?", models.StatusPending, models.UrgencyUrgent).Count(&stats.Urgent)
-	h.db.Model(&models.PurchaseRequest{}).Where("added_to_cart = ?", true).Count(&stats.AmazonInCart)
+	requests := func() *gorm.DB { return inCompany(c, h.db.Model(&models.PurchaseRequest{})) }
+	requests().Where("status = ?", models.StatusPending).Count(&stats.Pending)
+	requests().Where("status = ?", models.StatusApproved).Count(&stats.Approved)
+	requests().Where("status = ?", models.StatusRejected).Count(&stats.Rejected)
+	requests().Where("status = ?", models.StatusInfoRequested).Count(&stats.InfoRequired)
+	requests().Where("status = ?", models.StatusPurchased).Count(&stats.Purchased)
+	requests().Count(&stats.Total)
+	requests().Where("status = ? AND urgency = ?", models.StatusPending, models.UrgencyUrgent).Count(&stats.Urgent)
+	requests().Where("added_to_cart = ?", true).Count(&stats.AmazonInCart)
 
 	response.Success(c, stats)
 }
@@ -1,25 +1,34 @@
 package handlers
 
 import (
-	"time"
+	"errors"
+	"log"
+	"math"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services"
+	"vista-backend/pkg/i18n"
+	"vista-backend/pkg/jwt"
 	"vista-backend/pkg/response"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
-	db          *gorm.DB
+	authService          *services.AuthService
+	passwordResetService *services.PasswordResetService
+	ssoService           *services.SSOService
+	db                   *gorm.DB
 }
 
-func NewAuthHandler(authService *services.AuthService, db *gorm.DB) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, passwordResetService *services.PasswordResetService, ssoService *services.SSOService, db *gorm.DB) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		db:          db,
+		authService:          authService,
+		passwordResetService: passwordResetService,
+		ssoService:           ssoService,
+		db:                   db,
 	}
 }
 
@@ -28,17 +37,11 @@ func (h *AuthHandler) logActivity(c *gin.Context, activityType models.ActivityTy
 	ipAddress := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
-	log := models.NewActivityLog(activityType, userID, identifier, ipAddress, userAgent).
+	entry := models.NewActivityLog(activityType, userID, identifier, ipAddress, userAgent).
 		WithSuccess(success).
 		WithDetails(details)
 
-	// For login, set session expiry (24 hours by default)
-	if activityType == models.ActivityLogin && success {
-		expiresAt := time.Now().Add(24 * time.Hour)
-		log.WithSession("", expiresAt)
-	}
-
-	h.db.Create(log)
+	h.db.Create(entry)
 }
 
 type LoginRequest struct {
@@ -58,6 +61,7 @@ type RegisterRequest struct {
 	Name           string `json:"name" binding:"required,min=2,max=255"`
 	Email          string `json:"email" binding:"required,email"`
 	Password       string `json:"password" binding:"required,min=8"`
+	Language       string `json:"language"`
 }
 
 type RegisterResponse struct {
@@ -69,15 +73,35 @@ type RegisterResponse struct {
 }
 
 type UserResponse struct {
-	ID             uint   `json:"id"`
-	EmployeeNumber string `json:"employee_number"`
-	Email          string `json:"email"`
-	Name           string `json:"name"`
-	Role           string `json:"role"`
-	CompanyCode    string `json:"company_code"`
-	CostCenter     string `json:"cost_center"`
-	Department     string `json:"department"`
-	Status         string `json:"status"`
+	ID               uint     `json:"id"`
+	EmployeeNumber   string   `json:"employee_number"`
+	Email            string   `json:"email"`
+	Name             string   `json:"name"`
+	Role             string   `json:"role"`
+	Permissions      []string `json:"permissions,omitempty"` // What the role allows, for showing the right pages
+	CompanyCode      string   `json:"company_code"`
+	CostCenter       string   `json:"cost_center"`
+	Department       string   `json:"department"`
+	Status           string   `json:"status"`
+	Language         string   `json:"language"`
+	TwoFactorEnabled bool     `json:"two_factor_enabled"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when a
+// second factor is needed
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	TwoFactorToken     string `json:"two_factor_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"` // Two-factor must be set up before logging in
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
 }
 
 type RefreshRequest struct {
@@ -92,12 +116,13 @@ type RefreshResponse struct {
 
 // Login handles user login with email
 // @Summary User login
-// @Description Authenticates a user with email and returns JWT tokens
+// @Description Authenticates a user with email and returns JWT tokens. Users with two-factor authentication, or whose role requires it, get a two-factor token instead, for /auth/2fa/verify or, to set it up first, /auth/2fa/setup and /auth/2fa/enable.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login credentials"
 // @Success 200 {object} LoginResponse
+// @Success 200 {object} TwoFactorChallengeResponse
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
@@ -109,68 +134,127 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tokens, user, err := h.authService.Login(req.Email, req.Password)
+	// A successful login is recorded as the session it starts
+	tokens, user, err := h.authService.Login(req.Email, req.Password, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
-		// Log failed login attempt
-		var details string
-		switch err {
-		case services.ErrInvalidCredentials:
-			details = "Invalid credentials"
-			h.logActivity(c, models.ActivityLoginFailed, nil, req.Email, false, details)
-			response.Unauthorized(c, "Invalid email or password")
-		case services.ErrUserPending:
-			details = "Account pending approval"
-			h.logActivity(c, models.ActivityLoginFailed, nil, req.Email, false, details)
-			c.JSON(403, gin.H{
-				"success": false,
-				"error":   "Account pending approval",
-				"code":    "PENDING_APPROVAL",
-				"message": "Your account is awaiting admin approval. Please wait for confirmation.",
-			})
-		case services.ErrUserRejected:
-			details = "Account rejected"
-			h.logActivity(c, models.ActivityLoginFailed, nil, req.Email, false, details)
-			c.JSON(403, gin.H{
-				"success": false,
-				"error":   "Account registration rejected",
-				"code":    "REJECTED",
-				"message": "Your registration was rejected. Please contact the administrator.",
-			})
-		case services.ErrUserDisabled:
-			details = "Account disabled"
-			h.logActivity(c, models.ActivityLoginFailed, nil, req.Email, false, details)
-			c.JSON(403, gin.H{
-				"success": false,
-				"error":   "Account disabled",
-				"code":    "DISABLED",
-				"message": "Your account has been disabled. Please contact the administrator.",
+		var challenge *services.TwoFactorChallenge
+		if errors.As(err, &challenge) {
+			response.Success(c, TwoFactorChallengeResponse{
+				TwoFactorRequired:  true,
+				TwoFactorToken:     challenge.Token,
+				EnrollmentRequired: challenge.Enroll,
 			})
-		default:
-			h.logActivity(c, models.ActivityLoginFailed, nil, req.Email, false, err.Error())
-			response.InternalServerError(c, "Login failed")
+			return
+		}
+
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			h.respondThrottled(c, nil, req.Email, throttled)
+			return
 		}
+
+		h.respondLoginError(c, nil, req.Email, err)
 		return
 	}
 
-	// Log successful login
-	h.logActivity(c, models.ActivityLogin, &user.ID, req.Email, true, "")
-
-	response.Success(c, LoginResponse{
-		User: UserResponse{
-			ID:             user.ID,
-			EmployeeNumber: user.EmployeeNumber,
-			Email:          user.Email,
-			Name:           user.Name,
-			Role:           string(user.Role),
-			CompanyCode:    user.CompanyCode,
-			CostCenter:     user.CostCenter,
-			Department:     user.Department,
-			Status:         string(user.Status),
-		},
+	response.Success(c, h.newLoginResponse(user, tokens))
+}
+
+// respondThrottled refuses a login attempt made too soon after failed ones
+func (h *AuthHandler) respondThrottled(c *gin.Context, userID *uint, identifier string, throttled *services.LoginThrottledError) {
+	h.logActivity(c, models.ActivityLoginLocked, userID, identifier, false, throttled.Reason)
+	retryAfter := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+	code, message := "TOO_MANY_ATTEMPTS", "Too many failed login attempts. Please wait before trying again."
+	if throttled.Locked {
+		code, message = "LOCKED", "Your account is temporarily locked after too many failed login attempts. Try again later or contact the administrator."
+	}
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(429, gin.H{
+		"success":     false,
+		"error":       "Too many login attempts",
+		"code":        code,
+		"message":     message,
+		"retry_after": retryAfter,
+	})
+}
+
+// respondLoginError logs a failed login and tells the user why it failed.
+// Only bad credentials are logged as login_failed, which counts toward the
+// lockout; other refusals are logged as login_refused.
+func (h *AuthHandler) respondLoginError(c *gin.Context, userID *uint, identifier string, err error) {
+	var details string
+	switch err {
+	case services.ErrInvalidCredentials:
+		details = "Invalid credentials"
+		h.logActivity(c, models.ActivityLoginFailed, userID, identifier, false, details)
+		response.Unauthorized(c, "Invalid email or password")
+	case services.ErrUserPending:
+		details = "Account pending approval"
+		h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, details)
+		c.JSON(403, gin.H{
+			"success": false,
+			"error":   "Account pending approval",
+			"code":    "PENDING_APPROVAL",
+			"message": "Your account is awaiting admin approval. Please wait for confirmation.",
+		})
+	case services.ErrUserRejected:
+		details = "Account rejected"
+		h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, details)
+		c.JSON(403, gin.H{
+			"success": false,
+			"error":   "Account registration rejected",
+			"code":    "REJECTED",
+			"message": "Your registration was rejected. Please contact the administrator.",
+		})
+	case services.ErrUserDisabled:
+		details = "Account disabled"
+		h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, details)
+		c.JSON(403, gin.H{
+			"success": false,
+			"error":   "Account disabled",
+			"code":    "DISABLED",
+			"message": "Your account has been disabled. Please contact the administrator.",
+		})
+	case services.ErrDirectoryUnavailable:
+		h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, "Directory unavailable")
+		response.Error(c, 503, "DIRECTORY_UNAVAILABLE", "The directory can't be reached. Please try again later.")
+	case services.ErrDirectoryConflict:
+		h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, err.Error())
+		response.Error(c, 409, "DIRECTORY_ACCOUNT_CONFLICT", "Your directory account can't be linked to an account here. Please contact the administrator.")
+	default:
+		h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, err.Error())
+		response.InternalServerError(c, "Login failed")
+	}
+}
+
+func (h *AuthHandler) newLoginResponse(user *models.User, tokens *jwt.TokenPair) LoginResponse {
+	return LoginResponse{
+		User:         h.newUserResponse(user),
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 		ExpiresIn:    tokens.ExpiresIn,
-	})
+	}
+}
+
+func (h *AuthHandler) newUserResponse(user *models.User) UserResponse {
+	permissions := []string{}
+	if role, err := models.FindRole(h.db, user.Role); err == nil {
+		permissions = role.Permissions
+	}
+	return UserResponse{
+		ID:               user.ID,
+		EmployeeNumber:   user.EmployeeNumber,
+		Email:            user.Email,
+		Name:             user.Name,
+		Role:             string(user.Role),
+		Permissions:      permissions,
+		CompanyCode:      user.CompanyCode,
+		CostCenter:       user.CostCenter,
+		Department:       user.Department,
+		Status:           string(user.Status),
+		Language:         user.Language,
+		TwoFactorEnabled: user.TwoFactorEnabled(),
+	}
 }
 
 // Register handles new user registration
@@ -191,7 +275,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.Register(req.EmployeeNumber, req.Name, req.Email, req.Password)
+	// Default to the language the user registered in
+	language := req.Language
+	if language == "" {
+		language = getUserLanguage(c)
+	} else if !i18n.IsEnabled(language) {
+		response.BadRequest(c, "Unsupported language")
+		return
+	}
+
+	user, err := h.authService.Register(req.EmployeeNumber, req.Name, req.Email, req.Password, i18n.Normalize(language))
 	if err != nil {
 		switch err {
 		case services.ErrEmployeeNumberExists:
@@ -226,20 +319,27 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Logout handles user logout
 // @Summary User logout
-// @Description Logs out the current user
+// @Description Ends the current session; its access and refresh tokens stop working
 // @Tags Auth
 // @Security BearerAuth
 // @Success 200 {object} response.Response
 // @Router /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is handled client-side by removing tokens
-	// For additional security, you could implement a token blacklist here
+	if err := h.authService.EndSession(middleware.GetSessionID(c), "Logged out"); err != nil {
+		log.Printf("Failed to end session: %v", err)
+		response.InternalServerError(c, "Logout failed")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	h.logActivity(c, models.ActivityLogout, &userID, middleware.GetUserEmail(c), true, "")
+
 	response.SuccessWithMessage(c, "Logged out successfully", nil)
 }
 
 // Refresh refreshes the access token
 // @Summary Refresh access token
-// @Description Refreshes the access token using a valid refresh token
+// @Description Exchanges a refresh token for new tokens. Refresh tokens are single use; reusing one ends the session.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -255,7 +355,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshTokens(req.RefreshToken)
+	tokens, err := h.authService.RefreshTokens(req.RefreshToken, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
 		response.Unauthorized(c, "Invalid or expired refresh token")
 		return
@@ -268,6 +368,59 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	})
 }
 
+// ForgotPassword emails a password reset link
+// @Summary Request a password reset
+// @Description Emails a single-use reset link if the address belongs to an active account. The response is the same either way.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/v1/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	h.passwordResetService.RequestReset(req.Email, c.ClientIP(), c.GetHeader("User-Agent"))
+
+	response.SuccessWithMessage(c, "If an account exists for this email, a password reset link has been sent.", nil)
+}
+
+// ResetPassword sets a new password from a reset link
+// @Summary Reset password
+// @Description Sets a new password with the token from a reset link and ends all of the user's sessions
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/v1/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	err := h.passwordResetService.ResetPassword(req.Token, req.NewPassword, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			response.BadRequest(c, "This reset link is invalid or has expired")
+			return
+		}
+		log.Printf("Failed to reset password: %v", err)
+		response.InternalServerError(c, "Failed to reset password")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Password reset. Please log in with your new password.", nil)
+}
+
 // Me returns the current user's information
 // @Summary Get current user
 // @Description Returns the authenticated user's information
@@ -289,15 +442,5 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, UserResponse{
-		ID:             user.ID,
-		EmployeeNumber: user.EmployeeNumber,
-		Email:          user.Email,
-		Name:           user.Name,
-		Role:           string(user.Role),
-		CompanyCode:    user.CompanyCode,
-		CostCenter:     user.CostCenter,
-		Department:     user.Department,
-		Status:         string(user.Status),
-	})
+	response.Success(c, h.newUserResponse(user))
 }
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/chat"
+	"vista-backend/pkg/crypto"
+	"vista-backend/pkg/i18n"
+	"vista-backend/pkg/response"
+)
+
+type ChatChannelHandler struct {
+	db            *gorm.DB
+	encryptionSvc *crypto.EncryptionService
+	sender        *chat.Sender
+}
+
+func NewChatChannelHandler(db *gorm.DB, encryptionSvc *crypto.EncryptionService, appURL string) *ChatChannelHandler {
+	return &ChatChannelHandler{
+		db:            db,
+		encryptionSvc: encryptionSvc,
+		sender:        chat.NewSender(encryptionSvc, appURL),
+	}
+}
+
+// ChatChannelRequest is the body for creating or updating a chat channel
+type ChatChannelRequest struct {
+	Name        *string              `json:"name"`
+	Provider    *models.ChatProvider `json:"provider"`
+	WebhookURL  *string              `json:"webhook_url"` // Omit on update to keep the current one
+	Secret      *string              `json:"secret"`      // DingTalk signing secret; empty clears it
+	Language    *string              `json:"language"`
+	Roles       *models.StringList   `json:"roles"`
+	Departments *models.StringList   `json:"departments"`
+	Types       *models.StringList   `json:"types"`
+	IsActive    *bool                `json:"is_active"`
+}
+
+// ChatChannelResponse is a chat channel without its secrets
+type ChatChannelResponse struct {
+	models.ChatChannel
+	WebhookURLSet bool `json:"webhook_url_set"`
+	SecretSet     bool `json:"secret_set"`
+}
+
+func chatChannelToResponse(channel models.ChatChannel) ChatChannelResponse {
+	return ChatChannelResponse{
+		ChatChannel:   channel,
+		WebhookURLSet: channel.WebhookURL != "",
+		SecretSet:     channel.Secret != "",
+	}
+}
+
+// ChatChannelOptions lists the values a channel can be configured with
+type ChatChannelOptions struct {
+	Providers []models.ChatProvider     `json:"providers"`
+	Types     []models.NotificationType `json:"types"`
+	Roles     []models.UserRole         `json:"roles"`
+	Languages []i18n.Language           `json:"languages"`
+}
+
+// loadChannel fetches the channel in the :id parameter, writing the error
+// response if it can't
+func (h *ChatChannelHandler) loadChannel(c *gin.Context) (*models.ChatChannel, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid channel ID")
+		return nil, false
+	}
+
+	var channel models.ChatChannel
+	if err := h.db.First(&channel, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Chat channel not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch chat channel")
+		}
+		return nil, false
+	}
+	return &channel, true
+}
+
+// apply copies the request onto channel, returning a validation message on failure
+func (h *ChatChannelHandler) apply(req *ChatChannelRequest, channel *models.ChatChannel) string {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return "Name is required"
+		}
+		channel.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Provider != nil {
+		if !req.Provider.IsValid() {
+			return "Invalid provider"
+		}
+		channel.Provider = *req.Provider
+	}
+	if req.WebhookURL != nil {
+		if !validateWebhookURL(*req.WebhookURL) {
+			return "A valid http or https webhook URL is required"
+		}
+		encrypted, err := h.encryptionSvc.Encrypt(*req.WebhookURL)
+		if err != nil {
+			return "Failed to encrypt webhook URL"
+		}
+		channel.WebhookURL = encrypted
+	}
+	if req.Secret != nil {
+		channel.Secret = ""
+		if *req.Secret != "" {
+			encrypted, err := h.encryptionSvc.Encrypt(*req.Secret)
+			if err != nil {
+				return "Failed to encrypt secret"
+			}
+			channel.Secret = encrypted
+		}
+	}
+	if req.Language != nil {
+		lang := i18n.Normalize(*req.Language)
+		if !i18n.IsEnabled(lang) {
+			return "Language is not enabled"
+		}
+		channel.Language = lang
+	}
+	if req.Roles != nil {
+		for _, role := range *req.Roles {
+			if !models.RoleExists(h.db, models.UserRole(role)) {
+				return "Invalid role: " + role
+			}
+		}
+		channel.Roles = *req.Roles
+	}
+	if req.Departments != nil {
+		var departments models.StringList
+		for _, department := range *req.Departments {
+			if department = strings.TrimSpace(department); department != "" {
+				departments = append(departments, department)
+			}
+		}
+		channel.Departments = departments
+	}
+	if req.Types != nil {
+		for _, t := range *req.Types {
+			if !models.IsChatNotificationType(models.NotificationType(t)) {
+				return "Invalid notification type: " + t
+			}
+		}
+		channel.Types = *req.Types
+	}
+	if req.IsActive != nil {
+		channel.IsActive = *req.IsActive
+	}
+	return ""
+}
+
+// GetChatChannelOptions lists the providers, notification types, roles and
+// languages channels can use
+func (h *ChatChannelHandler) GetChatChannelOptions(c *gin.Context) {
+	var roles []models.UserRole
+	if err := h.db.Model(&models.Role{}).Order("built_in DESC, name").Pluck("name", &roles).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch roles")
+		return
+	}
+
+	response.Success(c, ChatChannelOptions{
+		Providers: models.ChatProviders,
+		Types:     models.ChatNotificationTypes,
+		Roles:     roles,
+		Languages: i18n.EnabledLanguages(),
+	})
+}
+
+// ListChatChannels returns all chat channels
+func (h *ChatChannelHandler) ListChatChannels(c *gin.Context) {
+	var channels []models.ChatChannel
+	if err := h.db.Order("created_at DESC").Find(&channels).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch chat channels")
+		return
+	}
+
+	responses := make([]ChatChannelResponse, len(channels))
+	for i, channel := range channels {
+		responses[i] = chatChannelToResponse(channel)
+	}
+	response.Success(c, responses)
+}
+
+// GetChatChannel returns one chat channel
+func (h *ChatChannelHandler) GetChatChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+	response.Success(c, chatChannelToResponse(*channel))
+}
+
+// CreateChatChannel adds a chat channel
+func (h *ChatChannelHandler) CreateChatChannel(c *gin.Context) {
+	var req ChatChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Name == nil || req.Provider == nil || req.WebhookURL == nil {
+		response.BadRequest(c, "Name, provider and webhook_url are required")
+		return
+	}
+
+	channel := models.ChatChannel{
+		Language:    i18n.Default(),
+		Roles:       models.StringList{},
+		Departments: models.StringList{},
+		Types:       models.StringList{},
+		IsActive:    true,
+	}
+	if msg := h.apply(&req, &channel); msg != "" {
+		response.BadRequest(c, msg)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	channel.CreatedByID = &userID
+
+	if err := h.db.Create(&channel).Error; err != nil {
+		response.InternalServerError(c, "Failed to create chat channel")
+		return
+	}
+
+	response.Created(c, chatChannelToResponse(channel))
+}
+
+// UpdateChatChannel changes a chat channel's settings
+func (h *ChatChannelHandler) UpdateChatChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	var req ChatChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if msg := h.apply(&req, channel); msg != "" {
+		response.BadRequest(c, msg)
+		return
+	}
+
+	if err := h.db.Save(channel).Error; err != nil {
+		response.InternalServerError(c, "Failed to update chat channel")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Chat channel updated", chatChannelToResponse(*channel))
+}
+
+// DeleteChatChannel removes a chat channel. Its message log is kept.
+func (h *ChatChannelHandler) DeleteChatChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(channel).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete chat channel")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Chat channel deleted successfully", nil)
+}
+
+// TestChatChannel posts a test card in the channel's language and records the result
+func (h *ChatChannelHandler) TestChatChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	err := h.sender.SendTest(channel)
+
+	now := time.Now()
+	channel.LastTestAt = &now
+	channel.LastTestSuccess = err == nil
+	channel.LastTestError = ""
+	if err != nil {
+		channel.LastTestError = err.Error()
+	}
+	h.db.Model(channel).Updates(map[string]interface{}{
+		"last_test_at":      now,
+		"last_test_success": channel.LastTestSuccess,
+		"last_test_error":   channel.LastTestError,
+	})
+
+	if err != nil {
+		response.BadRequest(c, "Test failed: "+err.Error())
+		return
+	}
+	response.SuccessWithMessage(c, "Test message sent to "+channel.Name, chatChannelToResponse(*channel))
+}
+
+// ListChatMessages returns the chat message log, newest first. Filters:
+// channel_id (or the :id route parameter), status, type, request_id.
+func (h *ChatChannelHandler) ListChatMessages(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.ChatMessage{})
+	channelID := c.Param("id")
+	if channelID == "" {
+		channelID = c.Query("channel_id")
+	}
+	if channelID != "" {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if t := c.Query("type"); t != "" {
+		query = query.Where("type = ?", t)
+	}
+	if requestID := c.Query("request_id"); requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var messages []models.ChatMessage
+	offset := (page - 1) * perPage
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(perPage).
+		Find(&messages).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch chat messages")
+		return
+	}
+
+	response.SuccessWithMeta(c, messages, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// ResendChatMessage queues a failed chat message to be sent again
+func (h *ChatChannelHandler) ResendChatMessage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid message ID")
+		return
+	}
+
+	message, err := chat.Resend(h.db, uint(id))
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			response.NotFound(c, "Chat message not found")
+		case chat.ErrNotFailed:
+			response.Conflict(c, "Only failed messages can be resent")
+		default:
+			response.InternalServerError(c, "Failed to resend chat message")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "Chat message queued for resending", message)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/response"
+)
+
+type CompanyHandler struct {
+	db *gorm.DB
+}
+
+func NewCompanyHandler(db *gorm.DB) *CompanyHandler {
+	return &CompanyHandler{db: db}
+}
+
+type CreateCompanyRequest struct {
+	Code         string `json:"code" binding:"required"`
+	Name         string `json:"name" binding:"required,max=255"`
+	NumberPrefix string `json:"number_prefix"`
+}
+
+type UpdateCompanyRequest struct {
+	Name         *string `json:"name" binding:"omitempty,min=1,max=255"`
+	NumberPrefix *string `json:"number_prefix"`
+	IsDefault    *bool   `json:"is_default"` // Only true is accepted; make another company the default instead
+}
+
+// CompanyResponse is a company with the number of users who belong to it
+type CompanyResponse struct {
+	models.Company
+	UserCount int64 `json:"user_count"`
+}
+
+// inCompany scopes a query to the company the request works in
+func inCompany(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return db.Where("company_code = ?", middleware.GetCompany(c))
+}
+
+// companyUsers scopes a users query to the members of the company the
+// request works in
+func companyUsers(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return models.CompanyMembers(db, middleware.GetCompany(c))
+}
+
+// ListCompanies returns every company
+func (h *CompanyHandler) ListCompanies(c *gin.Context) {
+	var companies []models.Company
+	if err := h.db.Order("is_default DESC, name").Find(&companies).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch companies")
+		return
+	}
+
+	result := make([]CompanyResponse, len(companies))
+	for i, company := range companies {
+		result[i] = CompanyResponse{Company: company}
+		models.CompanyMembers(h.db.Model(&models.User{}), company.Code).Count(&result[i].UserCount)
+	}
+
+	response.Success(c, result)
+}
+
+// CreateCompany adds a company
+func (h *CompanyHandler) CreateCompany(c *gin.Context) {
+	var req CreateCompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	code := strings.TrimSpace(req.Code)
+	if !models.IsValidCompanyCode(code) {
+		response.BadRequest(c, "Company code must be up to 50 letters, digits, dashes or underscores")
+		return
+	}
+	prefix := strings.ToUpper(strings.TrimSpace(req.NumberPrefix))
+	if !h.validNumberPrefix(c, prefix, 0) {
+		return
+	}
+	if models.CompanyExists(h.db, code) {
+		response.Conflict(c, "Company already exists")
+		return
+	}
+
+	company := models.Company{
+		Code:         code,
+		Name:         strings.TrimSpace(req.Name),
+		NumberPrefix: prefix,
+	}
+	if err := h.db.Create(&company).Error; err != nil {
+		log.Printf("Failed to create company: %v", err)
+		response.InternalServerError(c, "Failed to create company")
+		return
+	}
+
+	h.audit(c, "company.created", &company, "", company.Name)
+	response.Created(c, CompanyResponse{Company: company})
+}
+
+// UpdateCompany renames a company, changes its number prefix or makes it the
+// default company. A new prefix only applies to requests created afterwards.
+func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
+	company, ok := h.loadCompany(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	old := *company
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.NumberPrefix != nil {
+		prefix := strings.ToUpper(strings.TrimSpace(*req.NumberPrefix))
+		if !h.validNumberPrefix(c, prefix, company.ID) {
+			return
+		}
+		updates["number_prefix"] = prefix
+	}
+	if req.IsDefault != nil && *req.IsDefault != company.IsDefault {
+		if !*req.IsDefault {
+			response.BadRequest(c, "Make another company the default instead")
+			return
+		}
+		updates["is_default"] = true
+	}
+
+	if len(updates) > 0 {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if updates["is_default"] == true {
+				if err := tx.Model(&models.Company{}).Where("is_default = ?", true).
+					Update("is_default", false).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(company).Updates(updates).Error
+		})
+		if err != nil {
+			response.InternalServerError(c, "Failed to update company")
+			return
+		}
+		h.db.First(company, company.ID)
+	}
+
+	if company.Name != old.Name || company.NumberPrefix != old.NumberPrefix || company.IsDefault != old.IsDefault {
+		h.audit(c, "company.updated", company, companySummary(&old), companySummary(company))
+	}
+	response.Success(c, company)
+}
+
+// DeleteCompany removes a company nothing belongs to yet. The default
+// company can't be deleted.
+func (h *CompanyHandler) DeleteCompany(c *gin.Context) {
+	company, ok := h.loadCompany(c)
+	if !ok {
+		return
+	}
+	if company.IsDefault {
+		response.BadRequest(c, "The default company can't be deleted")
+		return
+	}
+
+	var users, requests, products int64
+	h.db.Model(&models.User{}).Where("company_code = ?", company.Code).Count(&users)
+	h.db.Model(&models.PurchaseRequest{}).Where("company_code = ?", company.Code).Count(&requests)
+	h.db.Model(&models.Product{}).Where("company_code = ?", company.Code).Count(&products)
+	if users+requests+products > 0 {
+		response.Conflict(c, "Company still has users, requests or products")
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, config := range []interface{}{&models.PurchaseConfig{}, &models.EmailConfig{}, &models.AmazonConfig{}} {
+			if err := tx.Where("company_code = ?", company.Code).Delete(config).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(company).Error
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to delete company")
+		return
+	}
+
+	h.audit(c, "company.deleted", company, company.Name, "")
+	response.SuccessWithMessage(c, "Company deleted", nil)
+}
+
+// validNumberPrefix checks a company's new number prefix, responding with an
+// error if it's malformed or another company already numbers with it
+func (h *CompanyHandler) validNumberPrefix(c *gin.Context, prefix string, companyID uint) bool {
+	if !models.IsValidNumberPrefix(prefix) {
+		response.BadRequest(c, "Number prefix must be up to 10 uppercase letters or digits, starting with a letter")
+		return false
+	}
+	var count int64
+	h.db.Model(&models.Company{}).Where("number_prefix = ? AND id <> ?", prefix, companyID).Count(&count)
+	if count > 0 {
+		if prefix == "" {
+			response.Conflict(c, "Another company already has no number prefix")
+		} else {
+			response.Conflict(c, "Another company already uses this number prefix")
+		}
+		return false
+	}
+	return true
+}
+
+// loadCompany fetches the company in the :id parameter, responding with an
+// error if there is none
+func (h *CompanyHandler) loadCompany(c *gin.Context) (*models.Company, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid company ID")
+		return nil, false
+	}
+
+	var company models.Company
+	if err := h.db.First(&company, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Company not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch company")
+		}
+		return nil, false
+	}
+	return &company, true
+}
+
+// audit records an admin's change to a company
+func (h *CompanyHandler) audit(c *gin.Context, action string, company *models.Company, oldValue, newValue string) {
+	entry := models.NewAuditLog(middleware.GetUserID(c), action, "company", company.ID,
+		oldValue, newValue, c.ClientIP(), c.Request.UserAgent())
+	if err := h.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to audit %s for company %s: %v", action, company.Code, err)
+	}
+}
+
+// companySummary describes a company's settings for the audit log
+func companySummary(company *models.Company) string {
+	summary := company.Name + " (prefix " + company.NumberPrefix + ")"
+	if company.IsDefault {
+		summary += " default"
+	}
+	return summary
+}
+
+// validCompany checks that users can be put in the company, responding with
+// an error if they can't. An empty code puts them in the default company.
+// Only users with companies.manage can put users in another company than
+// the one the request works in.
+func validCompany(c *gin.Context, db *gorm.DB, code string) bool {
+	if code != "" && !models.CompanyExists(db, code) {
+		response.BadRequest(c, "Invalid company: "+code)
+		return false
+	}
+	if code != "" && code != middleware.GetCompany(c) && !middleware.HasPermission(c, models.PermCompaniesManage) {
+		response.Forbidden(c, "You can't put users in another company")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/directory"
+	"vista-backend/pkg/response"
+)
+
+type DirectoryHandler struct {
+	db        *gorm.DB
+	directory *directory.Service
+}
+
+func NewDirectoryHandler(db *gorm.DB, directoryService *directory.Service) *DirectoryHandler {
+	return &DirectoryHandler{db: db, directory: directoryService}
+}
+
+type DirectoryStatusResponse struct {
+	Enabled             bool                     `json:"enabled"`
+	SyncIntervalMinutes int                      `json:"sync_interval_minutes"` // 0 when sync isn't scheduled
+	LastRun             *models.DirectorySyncRun `json:"last_run,omitempty"`
+}
+
+// GetDirectoryStatus returns whether a directory is connected and its last sync
+func (h *DirectoryHandler) GetDirectoryStatus(c *gin.Context) {
+	status := DirectoryStatusResponse{
+		Enabled:             h.directory.Enabled(),
+		SyncIntervalMinutes: int(h.directory.Interval().Minutes()),
+	}
+
+	var run models.DirectorySyncRun
+	if err := h.db.Omit("report").Preload("TriggeredBy").Order("id DESC").First(&run).Error; err == nil {
+		status.LastRun = &run
+	}
+
+	response.Success(c, status)
+}
+
+// SyncDirectory syncs users with the directory now. With ?dry_run=true
+// nothing is changed; the report shows what a sync would do.
+func (h *DirectoryHandler) SyncDirectory(c *gin.Context) {
+	adminID := middleware.GetUserID(c)
+	dryRun := c.Query("dry_run") == "true"
+
+	run, err := h.directory.Sync(dryRun, &adminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, directory.ErrNotConfigured):
+			response.NotFound(c, "No directory is configured")
+		case errors.Is(err, directory.ErrSyncRunning):
+			response.Error(c, 409, "SYNC_RUNNING", "A directory sync is already running")
+		case run != nil:
+			// The run was recorded with the error
+			log.Printf("Directory sync failed: %v", err)
+			response.Error(c, 502, "SYNC_FAILED", "Directory sync failed: "+err.Error())
+		default:
+			log.Printf("Failed to start directory sync: %v", err)
+			response.InternalServerError(c, "Failed to sync directory")
+		}
+		return
+	}
+
+	message := "Directory synced"
+	if dryRun {
+		message = "Dry run completed; nothing was changed"
+	}
+	response.SuccessWithMessage(c, message, run)
+}
+
+// ListSyncRuns returns past directory syncs, newest first, without their reports
+func (h *DirectoryHandler) ListSyncRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := h.db.Model(&models.DirectorySyncRun{})
+	if dryRun := c.Query("dry_run"); dryRun != "" {
+		query = query.Where("dry_run = ?", dryRun == "true")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var runs []models.DirectorySyncRun
+	if err := query.Omit("report").Preload("TriggeredBy").
+		Offset((page - 1) * perPage).Limit(perPage).Order("id DESC").Find(&runs).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch directory syncs")
+		return
+	}
+
+	response.SuccessWithMeta(c, runs, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// GetSyncRun returns a directory sync with its report
+func (h *DirectoryHandler) GetSyncRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid sync ID")
+		return
+	}
+
+	var run models.DirectorySyncRun
+	if err := h.db.Preload("TriggeredBy").First(&run, id).Error; err != nil {
+		response.NotFound(c, "Directory sync not found")
+		return
+	}
+
+	response.Success(c, run)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +29,7 @@ func NewEmailConfigHandler(db *gorm.DB, emailSvc *email.EmailService, encryption
 
 // EmailConfigRequest represents the request body for updating email config
 type EmailConfigRequest struct {
+	Provider          *string `json:"provider"`
 	APIKey            *string `json:"api_key"`
 	FromEmail         *string `json:"from_email"`
 	FromName          *string `json:"from_name"`
@@ -40,6 +42,15 @@ type EmailConfigRequest struct {
 	SendOnNewRequest  *bool   `json:"send_on_new_request"`
 	SendOnUrgent      *bool   `json:"send_on_urgent"`
 	SendReminders     *bool   `json:"send_reminders"`
+
+	SMTPHost       *string `json:"smtp_host"`
+	SMTPPort       *int    `json:"smtp_port"`
+	SMTPSecurity   *string `json:"smtp_security"`
+	SMTPUsername   *string `json:"smtp_username"`
+	SMTPPassword   *string `json:"smtp_password"`
+	DKIMDomain     *string `json:"dkim_domain"`
+	DKIMSelector   *string `json:"dkim_selector"`
+	DKIMPrivateKey *string `json:"dkim_private_key"` // PEM encoded RSA key
 }
 
 // EmailConfigResponse represents the response for email config
@@ -58,6 +69,14 @@ type EmailConfigResponse struct {
 	SendOnNewRequest bool       `json:"send_on_new_request"`
 	SendOnUrgent     bool       `json:"send_on_urgent"`
 	SendReminders    bool       `json:"send_reminders"`
+	SMTPHost         string     `json:"smtp_host"`
+	SMTPPort         int        `json:"smtp_port"`
+	SMTPSecurity     string     `json:"smtp_security"`
+	SMTPUsername     string     `json:"smtp_username"`
+	SMTPPasswordSet  bool       `json:"smtp_password_set"`
+	DKIMDomain       string     `json:"dkim_domain"`
+	DKIMSelector     string     `json:"dkim_selector"`
+	DKIMKeySet       bool       `json:"dkim_key_set"`
 	LastTestAt       *time.Time `json:"last_test_at,omitempty"`
 	LastTestSuccess  bool       `json:"last_test_success"`
 	LastTestError    string     `json:"last_test_error,omitempty"`
@@ -65,10 +84,10 @@ type EmailConfigResponse struct {
 	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
-// GetEmailConfig returns the current email configuration
+// GetEmailConfig returns the company's email configuration
 func (h *EmailConfigHandler) GetEmailConfig(c *gin.Context) {
 	var config models.EmailConfig
-	if err := h.db.First(&config).Error; err != nil {
+	if err := inCompany(c, h.db).First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Return default config
 			defaultConfig := models.GetDefaultEmailConfig()
@@ -82,7 +101,7 @@ func (h *EmailConfigHandler) GetEmailConfig(c *gin.Context) {
 	response.Success(c, h.configToResponse(config))
 }
 
-// SaveEmailConfig saves or updates the email configuration
+// SaveEmailConfig saves or updates the company's email configuration
 func (h *EmailConfigHandler) SaveEmailConfig(c *gin.Context) {
 	var req EmailConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -93,13 +112,21 @@ func (h *EmailConfigHandler) SaveEmailConfig(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var config models.EmailConfig
-	isNew := h.db.First(&config).Error == gorm.ErrRecordNotFound
+	isNew := inCompany(c, h.db).First(&config).Error == gorm.ErrRecordNotFound
 
 	if isNew {
 		config = models.GetDefaultEmailConfig()
+		config.CompanyCode = middleware.GetCompany(c)
 	}
 
 	// Update fields if provided
+	if req.Provider != nil {
+		if *req.Provider != models.EmailProviderResend && *req.Provider != models.EmailProviderSMTP {
+			response.BadRequest(c, "Invalid provider. Must be one of: resend, smtp")
+			return
+		}
+		config.Provider = *req.Provider
+	}
 	if req.APIKey != nil && *req.APIKey != "" {
 		// Encrypt the API key before storing
 		encrypted, err := h.encryptionSvc.Encrypt(*req.APIKey)
@@ -109,6 +136,54 @@ func (h *EmailConfigHandler) SaveEmailConfig(c *gin.Context) {
 		}
 		config.APIKey = encrypted
 	}
+	if req.SMTPHost != nil {
+		config.SMTPHost = strings.TrimSpace(*req.SMTPHost)
+	}
+	if req.SMTPPort != nil {
+		if *req.SMTPPort < 1 || *req.SMTPPort > 65535 {
+			response.BadRequest(c, "SMTP port must be between 1 and 65535")
+			return
+		}
+		config.SMTPPort = *req.SMTPPort
+	}
+	if req.SMTPSecurity != nil {
+		switch *req.SMTPSecurity {
+		case models.SMTPSecurityStartTLS, models.SMTPSecurityTLS, models.SMTPSecurityNone:
+			config.SMTPSecurity = *req.SMTPSecurity
+		default:
+			response.BadRequest(c, "Invalid SMTP security. Must be one of: starttls, tls, none")
+			return
+		}
+	}
+	if req.SMTPUsername != nil {
+		config.SMTPUsername = *req.SMTPUsername
+	}
+	if req.SMTPPassword != nil && *req.SMTPPassword != "" {
+		encrypted, err := h.encryptionSvc.Encrypt(*req.SMTPPassword)
+		if err != nil {
+			response.InternalServerError(c, "Failed to encrypt SMTP password")
+			return
+		}
+		config.SMTPPassword = encrypted
+	}
+	if req.DKIMDomain != nil {
+		config.DKIMDomain = strings.TrimSpace(*req.DKIMDomain)
+	}
+	if req.DKIMSelector != nil {
+		config.DKIMSelector = strings.TrimSpace(*req.DKIMSelector)
+	}
+	if req.DKIMPrivateKey != nil && *req.DKIMPrivateKey != "" {
+		if _, err := email.ParseDKIMPrivateKey(*req.DKIMPrivateKey); err != nil {
+			response.BadRequest(c, "Invalid DKIM private key: "+err.Error())
+			return
+		}
+		encrypted, err := h.encryptionSvc.Encrypt(*req.DKIMPrivateKey)
+		if err != nil {
+			response.InternalServerError(c, "Failed to encrypt DKIM private key")
+			return
+		}
+		config.DKIMPrivateKey = encrypted
+	}
 	if req.FromEmail != nil {
 		config.FromEmail = *req.FromEmail
 	}
@@ -172,28 +247,13 @@ func (h *EmailConfigHandler) TestEmailConfig(c *gin.Context) {
 
 	// Get current config
 	var config models.EmailConfig
-	if err := h.db.First(&config).Error; err != nil {
+	if err := inCompany(c, h.db).First(&config).Error; err != nil {
 		response.BadRequest(c, "Email configuration not found. Please save configuration first.")
 		return
 	}
 
-	// Decrypt API key for testing
-	if config.APIKey != "" {
-		decrypted, err := h.encryptionSvc.Decrypt(config.APIKey)
-		if err != nil {
-			response.InternalServerError(c, "Failed to decrypt API key")
-			return
-		}
-		config.APIKey = decrypted
-	}
-
-	// Update config temporarily with decrypted key for test
-	h.db.Model(&config).Updates(map[string]interface{}{
-		"api_key": config.APIKey,
-	})
-
-	// Test the connection
-	err := h.emailSvc.TestConnection(req.Email)
+	// Test the connection; the email service decrypts the stored secrets itself
+	err := h.emailSvc.TestConnection(config.CompanyCode, req.Email)
 
 	// Record test result
 	now := time.Now()
@@ -238,6 +298,14 @@ func (h *EmailConfigHandler) configToResponse(config models.EmailConfig) EmailCo
 		SendOnNewRequest: config.SendOnNewRequest,
 		SendOnUrgent:     config.SendOnUrgent,
 		SendReminders:    config.SendReminders,
+		SMTPHost:         config.SMTPHost,
+		SMTPPort:         config.SMTPPort,
+		SMTPSecurity:     config.SMTPSecurity,
+		SMTPUsername:     config.SMTPUsername,
+		SMTPPasswordSet:  config.SMTPPassword != "",
+		DKIMDomain:       config.DKIMDomain,
+		DKIMSelector:     config.DKIMSelector,
+		DKIMKeySet:       config.DKIMPrivateKey != "",
 		LastTestAt:       config.LastTestAt,
 		LastTestSuccess:  config.LastTestSuccess,
 		LastTestError:    config.LastTestError,
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/email"
+	"vista-backend/pkg/response"
+)
+
+type EmailOutboxHandler struct {
+	db *gorm.DB
+}
+
+func NewEmailOutboxHandler(db *gorm.DB) *EmailOutboxHandler {
+	return &EmailOutboxHandler{db: db}
+}
+
+// EmailMessageResponse is an outbox entry in the delivery log
+type EmailMessageResponse struct {
+	ID                uint               `json:"id"`
+	To                string             `json:"to"`
+	Subject           string             `json:"subject"`
+	Kind              string             `json:"kind"`
+	CompanyCode       string             `json:"company_code"`
+	UserID            *uint              `json:"user_id,omitempty"`
+	NotificationID    *uint              `json:"notification_id,omitempty"`
+	Status            models.EmailStatus `json:"status"`
+	Attempts          int                `json:"attempts"`
+	MaxAttempts       int                `json:"max_attempts"`
+	LastError         string             `json:"last_error,omitempty"`
+	Provider          string             `json:"provider,omitempty"`
+	ProviderMessageID string             `json:"provider_message_id,omitempty"`
+	NextAttemptAt     time.Time          `json:"next_attempt_at"`
+	SentAt            *time.Time         `json:"sent_at,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+
+	// Only included when fetching a single message
+	HTMLBody string `json:"html_body,omitempty"`
+	TextBody string `json:"text_body,omitempty"`
+}
+
+func emailMessageToResponse(m models.EmailMessage, withBody bool) EmailMessageResponse {
+	resp := EmailMessageResponse{
+		ID:                m.ID,
+		To:                m.To,
+		Subject:           m.Subject,
+		Kind:              m.Kind,
+		CompanyCode:       m.CompanyCode,
+		UserID:            m.UserID,
+		NotificationID:    m.NotificationID,
+		Status:            m.Status,
+		Attempts:          m.Attempts,
+		MaxAttempts:       m.MaxAttempts,
+		LastError:         m.LastError,
+		Provider:          m.Provider,
+		ProviderMessageID: m.ProviderMessageID,
+		NextAttemptAt:     m.NextAttemptAt,
+		SentAt:            m.SentAt,
+		CreatedAt:         m.CreatedAt,
+	}
+	if withBody {
+		resp.HTMLBody = m.HTMLBody
+		resp.TextBody = m.TextBody
+	}
+	return resp
+}
+
+// ListEmails returns the email delivery log, newest first
+func (h *EmailOutboxHandler) ListEmails(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+
+	query := inCompany(c, h.db.Model(&models.EmailMessage{}))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("to_addresses LIKE ? OR subject LIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var messages []models.EmailMessage
+	offset := (page - 1) * perPage
+	if err := query.Omit("html_body", "text_body").
+		Order("created_at DESC").Offset(offset).Limit(perPage).
+		Find(&messages).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch emails")
+		return
+	}
+
+	responses := make([]EmailMessageResponse, len(messages))
+	for i, m := range messages {
+		responses[i] = emailMessageToResponse(m, false)
+	}
+
+	response.SuccessWithMeta(c, responses, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// GetEmail returns one outbox message including its body
+func (h *EmailOutboxHandler) GetEmail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid email ID")
+		return
+	}
+
+	var message models.EmailMessage
+	if err := inCompany(c, h.db).First(&message, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Email not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch email")
+		}
+		return
+	}
+
+	response.Success(c, emailMessageToResponse(message, true))
+}
+
+// ResendEmail queues a failed email to be sent again
+func (h *EmailOutboxHandler) ResendEmail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid email ID")
+		return
+	}
+
+	var count int64
+	inCompany(c, h.db.Model(&models.EmailMessage{})).Where("id = ?", id).Count(&count)
+	if count == 0 {
+		response.NotFound(c, "Email not found")
+		return
+	}
+
+	message, err := email.Resend(h.db, uint(id))
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			response.NotFound(c, "Email not found")
+		case email.ErrNotFailed:
+			response.Conflict(c, "Only failed emails can be resent")
+		default:
+			response.InternalServerError(c, "Failed to resend email")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "Email queued for resending", emailMessageToResponse(*message, false))
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/email"
+	"vista-backend/pkg/i18n"
+	"vista-backend/pkg/response"
+)
+
+type EmailTemplateHandler struct {
+	db *gorm.DB
+}
+
+func NewEmailTemplateHandler(db *gorm.DB) *EmailTemplateHandler {
+	return &EmailTemplateHandler{db: db}
+}
+
+// EmailTemplateResponse is the template used for one kind and language.
+// Body is the built-in template when IsCustom is false.
+type EmailTemplateResponse struct {
+	Kind        models.EmailTemplateKind `json:"kind"`
+	Language    string                   `json:"language"`
+	Subject     string                   `json:"subject"` // Empty uses the built-in subject
+	Body        string                   `json:"body"`
+	IsCustom    bool                     `json:"is_custom"`
+	UpdatedAt   *time.Time               `json:"updated_at,omitempty"`
+	UpdatedByID *uint                    `json:"updated_by_id,omitempty"`
+}
+
+// EmailTemplateRequest is the body for saving a template
+type EmailTemplateRequest struct {
+	Subject string `json:"subject" binding:"max=500"`
+	Body    string `json:"body" binding:"required"`
+}
+
+// EmailTemplatePreviewRequest renders a template without saving it.
+// Without a body the saved (or built-in) template is rendered; without a
+// request ID sample data is used.
+type EmailTemplatePreviewRequest struct {
+	Kind      models.EmailTemplateKind `json:"kind" binding:"required"`
+	Language  string                   `json:"language" binding:"required"`
+	Subject   string                   `json:"subject"`
+	Body      string                   `json:"body"`
+	RequestID *uint                    `json:"request_id"`
+}
+
+func emailTemplateToResponse(t models.EmailTemplate) EmailTemplateResponse {
+	updatedAt := t.UpdatedAt
+	return EmailTemplateResponse{
+		Kind:        t.Kind,
+		Language:    t.Language,
+		Subject:     t.Subject,
+		Body:        t.Body,
+		IsCustom:    true,
+		UpdatedAt:   &updatedAt,
+		UpdatedByID: t.UpdatedByID,
+	}
+}
+
+// parseTemplateKey validates the :kind and :language path parameters
+func parseTemplateKey(c *gin.Context) (models.EmailTemplateKind, string, bool) {
+	kind := models.EmailTemplateKind(c.Param("kind"))
+	if !kind.IsValid() {
+		response.BadRequest(c, "Invalid template kind")
+		return "", "", false
+	}
+	lang := i18n.Normalize(c.Param("language"))
+	if !i18n.IsEnabled(lang) {
+		response.BadRequest(c, "Language is not enabled")
+		return "", "", false
+	}
+	return kind, lang, true
+}
+
+// ListEmailTemplates returns the template for every kind and enabled language
+func (h *EmailTemplateHandler) ListEmailTemplates(c *gin.Context) {
+	var custom []models.EmailTemplate
+	if err := h.db.Find(&custom).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch email templates")
+		return
+	}
+	byKey := make(map[string]models.EmailTemplate, len(custom))
+	for _, t := range custom {
+		byKey[string(t.Kind)+"/"+t.Language] = t
+	}
+
+	languages := i18n.Enabled()
+	templates := make([]EmailTemplateResponse, 0, len(models.EmailTemplateKinds)*len(languages))
+	for _, kind := range models.EmailTemplateKinds {
+		for _, lang := range languages {
+			if t, ok := byKey[string(kind)+"/"+lang]; ok {
+				templates = append(templates, emailTemplateToResponse(t))
+				continue
+			}
+			templates = append(templates, EmailTemplateResponse{
+				Kind:     kind,
+				Language: lang,
+				Body:     email.DefaultTemplateBody(kind),
+			})
+		}
+	}
+
+	response.Success(c, templates)
+}
+
+// GetTemplateVariables returns the variables each template kind can use
+func (h *EmailTemplateHandler) GetTemplateVariables(c *gin.Context) {
+	variables := make(map[models.EmailTemplateKind][]email.TemplateVariable, len(models.EmailTemplateKinds))
+	for _, kind := range models.EmailTemplateKinds {
+		variables[kind] = email.TemplateVariables(kind)
+	}
+	response.Success(c, variables)
+}
+
+// SaveEmailTemplate validates and saves the template for a kind and language
+func (h *EmailTemplateHandler) SaveEmailTemplate(c *gin.Context) {
+	kind, lang, ok := parseTemplateKey(c)
+	if !ok {
+		return
+	}
+
+	var req EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := email.ValidateEmailTemplate(kind, lang, req.Subject, req.Body); err != nil {
+		response.BadRequest(c, "Invalid template: "+err.Error())
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+
+	var template models.EmailTemplate
+	err := h.db.Where("kind = ? AND language = ?", kind, lang).First(&template).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		response.InternalServerError(c, "Failed to fetch email template")
+		return
+	}
+	template.Kind = kind
+	template.Language = lang
+	template.Subject = req.Subject
+	template.Body = req.Body
+	template.UpdatedByID = &userID
+
+	if err := h.db.Save(&template).Error; err != nil {
+		response.InternalServerError(c, "Failed to save email template")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Email template saved", emailTemplateToResponse(template))
+}
+
+// ResetEmailTemplate deletes a custom template so the built-in one is used again
+func (h *EmailTemplateHandler) ResetEmailTemplate(c *gin.Context) {
+	kind, lang, ok := parseTemplateKey(c)
+	if !ok {
+		return
+	}
+
+	result := h.db.Where("kind = ? AND language = ?", kind, lang).Delete(&models.EmailTemplate{})
+	if result.Error != nil {
+		response.InternalServerError(c, "Failed to reset email template")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.NotFound(c, "No custom template for this kind and language")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Email template reset to default", EmailTemplateResponse{
+		Kind:     kind,
+		Language: lang,
+		Body:     email.DefaultTemplateBody(kind),
+	})
+}
+
+// PreviewEmailTemplate renders a template against sample data or a real request
+func (h *EmailTemplateHandler) PreviewEmailTemplate(c *gin.Context) {
+	var req EmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if !req.Kind.IsValid() {
+		response.BadRequest(c, "Invalid template kind")
+		return
+	}
+	lang := i18n.Normalize(req.Language)
+	if !i18n.IsEnabled(lang) {
+		response.BadRequest(c, "Language is not enabled")
+		return
+	}
+
+	subject, body := req.Subject, req.Body
+	if body == "" {
+		var saved models.EmailTemplate
+		err := h.db.Where("kind = ? AND language = ?", req.Kind, lang).First(&saved).Error
+		switch {
+		case err == nil:
+			subject, body = saved.Subject, saved.Body
+		case err == gorm.ErrRecordNotFound:
+			body = email.DefaultTemplateBody(req.Kind)
+		default:
+			response.InternalServerError(c, "Failed to fetch email template")
+			return
+		}
+	}
+
+	ctx := email.SampleTemplateContext(lang)
+	if req.RequestID != nil {
+		var request models.PurchaseRequest
+		if err := inCompany(c, h.db).Preload("Requester").First(&request, *req.RequestID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				response.NotFound(c, "Request not found")
+				return
+			}
+			response.InternalServerError(c, "Failed to fetch request")
+			return
+		}
+
+		// New request emails go to approvers; the rest go to the requester
+		recipientName := request.Requester.Name
+		if req.Kind == models.EmailTemplateNewRequest {
+			var user models.User
+			if err := h.db.First(&user, middleware.GetUserID(c)).Error; err == nil {
+				recipientName = user.Name
+			}
+		}
+		ctx = email.NewTemplateContext(&request, recipientName, lang)
+	}
+
+	renderedSubject, html, err := email.RenderEmailTemplate(req.Kind, subject, body, ctx)
+	if err != nil {
+		response.BadRequest(c, "Invalid template: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"subject": renderedSubject,
+		"html":    html,
+		"sample":  req.RequestID == nil,
+	})
+}
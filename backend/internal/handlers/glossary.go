@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+	"vista-backend/pkg/i18n"
+	"vista-backend/pkg/response"
+)
+
+type GlossaryHandler struct {
+	db *gorm.DB
+}
+
+func NewGlossaryHandler(db *gorm.DB) *GlossaryHandler {
+	return &GlossaryHandler{db: db}
+}
+
+type GlossaryTermRequest struct {
+	Term          string            `json:"term" binding:"required"`
+	Translations  map[string]string `json:"translations"` // Language code -> term
+	Protected     bool              `json:"protected"`
+	CaseSensitive bool              `json:"case_sensitive"`
+	Notes         string            `json:"notes"`
+	IsActive      *bool             `json:"is_active"`
+}
+
+// translations validates and normalizes the per-language forms of a term
+func (r *GlossaryTermRequest) translations() (models.LocalizedText, bool) {
+	var result models.LocalizedText
+	for lang, text := range r.Translations {
+		if !i18n.IsKnown(lang) {
+			return nil, false
+		}
+		result.Set(i18n.Normalize(lang), strings.TrimSpace(text))
+	}
+	return result, true
+}
+
+// reloadGlossary refreshes the in-memory glossary used by the translators
+func (h *GlossaryHandler) reloadGlossary() {
+	if err := translation.LoadGlossary(h.db); err != nil {
+		log.Printf("Failed to reload glossary: %v", err)
+	}
+}
+
+// ListTerms returns glossary terms, optionally filtered by search text
+func (h *GlossaryHandler) ListTerms(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	search := strings.TrimSpace(c.Query("search"))
+
+	offset := (page - 1) * perPage
+
+	query := h.db.Model(&models.GlossaryTerm{})
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("term LIKE ? OR translations LIKE ?", like, like)
+	}
+	if active := c.Query("active"); active != "" {
+		query = query.Where("is_active = ?", active == "true")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var terms []models.GlossaryTerm
+	if err := query.Offset(offset).Limit(perPage).Order("term ASC").Find(&terms).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch glossary")
+		return
+	}
+
+	response.SuccessWithMeta(c, terms, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// CreateTerm adds a glossary term
+func (h *GlossaryHandler) CreateTerm(c *gin.Context) {
+	var req GlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	req.Term = strings.TrimSpace(req.Term)
+	if req.Term == "" {
+		response.BadRequest(c, "Term is required")
+		return
+	}
+
+	translations, ok := req.translations()
+	if !ok {
+		response.BadRequest(c, "Unsupported language in translations")
+		return
+	}
+
+	var existing models.GlossaryTerm
+	if err := h.db.Where("term = ?", req.Term).First(&existing).Error; err == nil {
+		response.Conflict(c, "Term already exists")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	term := models.GlossaryTerm{
+		Term:          req.Term,
+		Translations:  translations,
+		Protected:     req.Protected,
+		CaseSensitive: req.CaseSensitive,
+		Notes:         req.Notes,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+		CreatedByID:   &userID,
+	}
+
+	if err := h.db.Create(&term).Error; err != nil {
+		response.InternalServerError(c, "Failed to create term")
+		return
+	}
+
+	h.reloadGlossary()
+	response.Created(c, term)
+}
+
+// UpdateTerm updates a glossary term
+func (h *GlossaryHandler) UpdateTerm(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid term ID")
+		return
+	}
+
+	var term models.GlossaryTerm
+	if err := h.db.First(&term, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Term not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch term")
+		}
+		return
+	}
+
+	var req GlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	req.Term = strings.TrimSpace(req.Term)
+	translations, ok := req.translations()
+	if !ok {
+		response.BadRequest(c, "Unsupported language in translations")
+		return
+	}
+	if req.Term != term.Term {
+		var existing models.GlossaryTerm
+		if err := h.db.Where("term = ? AND id <> ?", req.Term, term.ID).First(&existing).Error; err == nil {
+			response.Conflict(c, "Term already exists")
+			return
+		}
+	}
+
+	term.Term = req.Term
+	term.Translations = translations
+	term.Protected = req.Protected
+	term.CaseSensitive = req.CaseSensitive
+	term.Notes = req.Notes
+	if req.IsActive != nil {
+		term.IsActive = *req.IsActive
+	}
+
+	if err := h.db.Save(&term).Error; err != nil {
+		response.InternalServerError(c, "Failed to update term")
+		return
+	}
+
+	h.reloadGlossary()
+	response.Success(c, term)
+}
+
+// DeleteTerm removes a glossary term
+func (h *GlossaryHandler) DeleteTerm(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid term ID")
+		return
+	}
+
+	result := h.db.Delete(&models.GlossaryTerm{}, id)
+	if result.Error != nil {
+		response.InternalServerError(c, "Failed to delete term")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.NotFound(c, "Term not found")
+		return
+	}
+
+	h.reloadGlossary()
+	response.SuccessWithMessage(c, "Term deleted successfully", nil)
+}
+
+// PreviewTerms shows how glossary terms and protected identifiers in a text
+// will be handled when translating to the target language
+func (h *GlossaryHandler) PreviewTerms(c *gin.Context) {
+	var req struct {
+		Text           string `json:"text" binding:"required"`
+		TargetLanguage string `json:"target_language" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if !i18n.IsEnabled(req.TargetLanguage) {
+		response.BadRequest(c, "Unsupported target language")
+		return
+	}
+
+	protected, restore := translation.ProtectTerms(req.Text, req.TargetLanguage)
+	response.Success(c, gin.H{
+		"protected": protected,
+		"result":    restore(protected),
+	})
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"vista-backend/pkg/i18n"
+	"vista-backend/pkg/response"
+)
+
+// getUserLanguage extracts user's preferred language from request headers.
+// The X-User-Language header (set by frontend) wins over Accept-Language;
+// languages that aren't enabled fall back to the default language.
+func getUserLanguage(c *gin.Context) string {
+	return i18n.FromRequest(c.GetHeader("X-User-Language"), c.GetHeader("Accept-Language"))
+}
+
+// ListLanguages returns the enabled languages and the default language
+func ListLanguages(c *gin.Context) {
+	response.Success(c, gin.H{
+		"languages": i18n.EnabledLanguages(),
+		"default":   i18n.Default(),
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/notifications"
+	"vista-backend/internal/services/realtime"
+)
+
+const (
+	// streamHeartbeat keeps idle connections open through proxies
+	streamHeartbeat = 25 * time.Second
+	// streamRetry tells EventSource how long to wait before reconnecting
+	streamRetry = 5 * time.Second
+)
+
+// StreamNotifications holds open a Server-Sent Events stream of the user's
+// notifications, badge counts and request status changes. Reconnecting
+// clients send Last-Event-ID (or ?last_event_id=) to receive missed events;
+// if they can't be replayed a "reset" event tells the client to refetch.
+func (h *NotificationHandler) StreamNotifications(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	permissions := middleware.GetPermissions(c)
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	sub, missed := realtime.Default().Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	for _, event := range missed {
+		if err := writeStreamEvent(c.Writer, event); err != nil {
+			return
+		}
+	}
+
+	// Current counts so the client is in sync without a separate request
+	counts := notifications.GetCounts(h.db, userID, middleware.GetCompany(c), permissions)
+	if err := writeStreamEvent(c.Writer, realtime.Event{Type: realtime.EventCounts, Data: counts}); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and replays
+				return
+			}
+			if err := writeStreamEvent(c.Writer, event); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			// Close streams of sessions that were logged out or revoked
+			if _, err := models.FindActiveSession(h.db, middleware.GetSessionID(c)); errors.Is(err, gorm.ErrRecordNotFound) {
+				return
+			}
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes one event in text/event-stream format. Events
+// without an ID (such as the initial counts) don't move the client's
+// Last-Event-ID.
+func writeStreamEvent(w io.Writer, event realtime.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Type, err)
+		return nil
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -8,15 +8,21 @@ import (
 	"gorm.io/gorm"
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
+	"vista-backend/internal/services/notifications"
+	"vista-backend/internal/services/realtime"
 	"vista-backend/pkg/response"
 )
 
 type NotificationHandler struct {
-	db *gorm.DB
+	db              *gorm.DB
+	notificationSvc *notifications.NotificationService
 }
 
 func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
-	return &NotificationHandler{db: db}
+	return &NotificationHandler{
+		db:              db,
+		notificationSvc: notifications.NewNotificationService(db),
+	}
 }
 
 // NotificationResponse represents a notification in API responses
@@ -57,7 +63,7 @@ func (h *NotificationHandler) ListNotifications(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	query := h.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	query := h.db.Model(&models.Notification{}).Scopes(models.InAppNotifications).Where("user_id = ?", userID)
 
 	if unreadOnly {
 		query = query.Where("read_at IS NULL")
@@ -91,11 +97,11 @@ func (h *NotificationHandler) GetNotificationCount(c *gin.Context) {
 
 	var count models.NotificationCount
 
-	h.db.Model(&models.Notification{}).
+	h.db.Model(&models.Notification{}).Scopes(models.InAppNotifications).
 		Where("user_id = ? AND read_at IS NULL", userID).
 		Count(&count.Unread)
 
-	h.db.Model(&models.Notification{}).
+	h.db.Model(&models.Notification{}).Scopes(models.InAppNotifications).
 		Where("user_id = ?", userID).
 		Count(&count.Total)
 
@@ -112,7 +118,7 @@ func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
 	}
 
 	var notification models.Notification
-	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+	if err := h.db.Scopes(models.InAppNotifications).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Notification not found")
 		} else {
@@ -131,6 +137,7 @@ func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
 		response.InternalServerError(c, "Failed to mark notification as read")
 		return
 	}
+	h.publishCounts(c)
 
 	response.Success(c, notificationToResponse(notification))
 }
@@ -140,7 +147,7 @@ func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	now := time.Now()
-	result := h.db.Model(&models.Notification{}).
+	result := h.db.Model(&models.Notification{}).Scopes(models.InAppNotifications).
 		Where("user_id = ? AND read_at IS NULL", userID).
 		Update("read_at", now)
 
@@ -148,6 +155,7 @@ func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 		response.InternalServerError(c, "Failed to mark notifications as read")
 		return
 	}
+	h.publishCounts(c)
 
 	response.SuccessWithMessage(c, "All notifications marked as read", gin.H{
 		"updated": result.RowsAffected,
@@ -158,38 +166,186 @@ func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 // This is a convenience endpoint for the sidebar badges
 func (h *NotificationHandler) GetPendingCounts(c *gin.Context) {
 	userID := middleware.GetUserID(c)
-	userRole := middleware.GetUserRole(c)
 
-	counts := gin.H{
-		"unread_notifications": int64(0),
-		"pending_approvals":    int64(0),
-		"pending_orders":       int64(0),
+	counts := notifications.GetCounts(h.db, userID, middleware.GetCompany(c), middleware.GetPermissions(c))
+	response.Success(c, counts)
+}
+
+// NotificationPreferenceItem is one notification type in the preferences response
+type NotificationPreferenceItem struct {
+	Type      models.NotificationType    `json:"type"`
+	Channel   models.NotificationChannel `json:"channel"`
+	Available notifications.Availability `json:"available"`
+}
+
+// NotificationPreferencesResponse is the current user's notification preferences
+type NotificationPreferencesResponse struct {
+	Preferences     []NotificationPreferenceItem `json:"preferences"`
+	QuietHoursStart string                       `json:"quiet_hours_start"`
+	QuietHoursEnd   string                       `json:"quiet_hours_end"`
+	Timezone        string                       `json:"timezone"`
+	DigestFrequency models.DigestFrequency       `json:"digest_frequency"`
+	DigestHour      int                          `json:"digest_hour"`
+	DigestWeekday   int                          `json:"digest_weekday"`
+	LastDigestAt    *time.Time                   `json:"last_digest_at,omitempty"`
+	NextDigestAt    *time.Time                   `json:"next_digest_at,omitempty"`
+}
+
+// UpdateNotificationPreferencesRequest changes the current user's preferences.
+// Types left out of Channels keep their current setting.
+type UpdateNotificationPreferencesRequest struct {
+	Channels        map[models.NotificationType]models.NotificationChannel `json:"channels"`
+	QuietHoursStart *string                                                `json:"quiet_hours_start"`
+	QuietHoursEnd   *string                                                `json:"quiet_hours_end"`
+	Timezone        *string                                                `json:"timezone"`
+	DigestFrequency *models.DigestFrequency                                `json:"digest_frequency"`
+	DigestHour      *int                                                   `json:"digest_hour"`
+	DigestWeekday   *int                                                   `json:"digest_weekday"`
+}
+
+func (h *NotificationHandler) preferencesResponse(c *gin.Context, pref *models.NotificationPreference) NotificationPreferencesResponse {
+	availability := h.notificationSvc.Availability(middleware.GetCompany(c))
+	items := make([]NotificationPreferenceItem, len(models.ConfigurableNotificationTypes))
+	for i, t := range models.ConfigurableNotificationTypes {
+		items[i] = NotificationPreferenceItem{
+			Type:      t,
+			Channel:   pref.ChannelFor(t),
+			Available: availability[t],
+		}
 	}
 
-	// Get unread notifications count
-	var unreadNotifications int64
-	h.db.Model(&models.Notification{}).
-		Where("user_id = ? AND read_at IS NULL", userID).
-		Count(&unreadNotifications)
-	counts["unread_notifications"] = unreadNotifications
+	resp := NotificationPreferencesResponse{
+		Preferences:     items,
+		DigestFrequency: models.DigestOff,
+		DigestHour:      8,
+		DigestWeekday:   int(time.Monday),
+	}
+	if pref != nil {
+		resp.QuietHoursStart = pref.QuietHoursStart
+		resp.QuietHoursEnd = pref.QuietHoursEnd
+		resp.Timezone = pref.Timezone
+		resp.DigestHour = pref.DigestHour
+		resp.DigestWeekday = pref.DigestWeekday
+		resp.LastDigestAt = pref.LastDigestAt
+		if pref.DigestEnabled() {
+			resp.DigestFrequency = pref.DigestFrequency
+			next := pref.NextDigestAt(time.Now())
+			resp.NextDigestAt = &next
+		}
+	}
+	return resp
+}
+
+// GetPreferences returns the current user's notification preferences
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	response.Success(c, h.preferencesResponse(c, notifications.GetPreference(h.db, userID)))
+}
+
+// UpdatePreferences updates the current user's notification channels, quiet hours and digest schedule
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID := middleware.GetUserID(c)
 
-	// Pending approvals count (for GM and Admin)
-	if userRole == string(models.RoleGeneralManager) || userRole == string(models.RoleAdmin) {
-		var pendingApprovals int64
-		h.db.Model(&models.PurchaseRequest{}).
-			Where("status = ?", models.StatusPending).
-			Count(&pendingApprovals)
-		counts["pending_approvals"] = pendingApprovals
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
 	}
 
-	// Pending orders count (for Admin, Purchase Admin, and Supply Chain Manager)
-	if userRole == string(models.RoleAdmin) || userRole == string(models.RolePurchaseAdmin) || userRole == string(models.RoleSupplyChainManager) {
-		var pendingOrders int64
-		h.db.Model(&models.PurchaseRequest{}).
-			Where("status = ?", models.StatusApproved).
-			Count(&pendingOrders)
-		counts["pending_orders"] = pendingOrders
+	pref := notifications.GetPreference(h.db, userID)
+	if pref == nil {
+		pref = &models.NotificationPreference{
+			UserID:          userID,
+			DigestFrequency: models.DigestOff,
+			DigestHour:      8,
+			DigestWeekday:   int(time.Monday),
+		}
 	}
+	wasDigest := pref.DigestEnabled()
 
-	response.Success(c, counts)
+	for t, channel := range req.Channels {
+		if !models.IsConfigurableNotificationType(t) {
+			response.BadRequest(c, "Unknown notification type: "+string(t))
+			return
+		}
+		if !channel.IsValid() {
+			response.BadRequest(c, "Invalid channel for "+string(t)+": must be in_app, email, both or none")
+			return
+		}
+		if pref.Channels == nil {
+			pref.Channels = make(models.NotificationChannels)
+		}
+		pref.Channels[t] = channel
+	}
+
+	if req.QuietHoursStart != nil {
+		pref.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		pref.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	for _, value := range []string{pref.QuietHoursStart, pref.QuietHoursEnd} {
+		if value == "" {
+			continue
+		}
+		if _, err := models.ParseClock(value); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
+	if (pref.QuietHoursStart == "") != (pref.QuietHoursEnd == "") {
+		response.BadRequest(c, "Quiet hours need both a start and an end")
+		return
+	}
+
+	if req.Timezone != nil {
+		if *req.Timezone != "" {
+			if _, err := time.LoadLocation(*req.Timezone); err != nil {
+				response.BadRequest(c, "Unknown timezone: "+*req.Timezone)
+				return
+			}
+		}
+		pref.Timezone = *req.Timezone
+	}
+
+	if req.DigestFrequency != nil {
+		if !req.DigestFrequency.IsValid() {
+			response.BadRequest(c, "Invalid digest frequency: must be off, daily or weekly")
+			return
+		}
+		pref.DigestFrequency = *req.DigestFrequency
+	}
+	if req.DigestHour != nil {
+		if *req.DigestHour < 0 || *req.DigestHour > 23 {
+			response.BadRequest(c, "Digest hour must be between 0 and 23")
+			return
+		}
+		pref.DigestHour = *req.DigestHour
+	}
+	if req.DigestWeekday != nil {
+		if *req.DigestWeekday < 0 || *req.DigestWeekday > 6 {
+			response.BadRequest(c, "Digest weekday must be between 0 (Sunday) and 6 (Saturday)")
+			return
+		}
+		pref.DigestWeekday = *req.DigestWeekday
+	}
+	// The first digest covers activity from when digests were turned on
+	if pref.DigestEnabled() && !wasDigest {
+		now := time.Now()
+		pref.LastDigestAt = &now
+	}
+
+	if err := h.db.Save(pref).Error; err != nil {
+		response.InternalServerError(c, "Failed to update notification preferences")
+		return
+	}
+
+	response.Success(c, h.preferencesResponse(c, pref))
+}
+
+// publishCounts pushes the current user's badge counts to their other open streams
+func (h *NotificationHandler) publishCounts(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	counts := notifications.GetCounts(h.db, userID, middleware.GetCompany(c), middleware.GetPermissions(c))
+	realtime.Publish(userID, realtime.EventCounts, counts)
 }
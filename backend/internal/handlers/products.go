@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"io"
+	"log"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+	"vista-backend/pkg/i18n"
 	"vista-backend/pkg/response"
 )
 
@@ -22,14 +27,19 @@ type ProductResponse struct {
 	ID            uint                  `json:"id"`
 	SKU           string                `json:"sku"`
 	Name          string                `json:"name"`
-	NameZh        string                `json:"name_zh"`
-	NameEs        string                `json:"name_es"`
+	NameZh        string                `json:"name_zh"` // Deprecated: use NameTranslations
+	NameEs        string                `json:"name_es"` // Deprecated: use NameTranslations
 	Description   string                `json:"description"`
 	Category      string                `json:"category"`
 	Model         string                `json:"model"`
 	Specification string                `json:"specification"`
-	SpecZh        string                `json:"spec_zh"`
-	SpecEs        string                `json:"spec_es"`
+	SpecZh        string                `json:"spec_zh"` // Deprecated: use SpecTranslations
+	SpecEs        string                `json:"spec_es"` // Deprecated: use SpecTranslations
+	NameTranslations models.LocalizedText `json:"name_translations"`
+	DescTranslations models.LocalizedText `json:"description_translations"`
+	SpecTranslations models.LocalizedText `json:"spec_translations"`
+	// Field -> languages filled by machine translation
+	MachineTranslated map[string][]string `json:"machine_translated,omitempty"`
 	Supplier      string                `json:"supplier"`
 	SupplierCode  string                `json:"supplier_code"`
 	Price         float64               `json:"price"`
@@ -71,6 +81,10 @@ type CreateProductRequest struct {
 	Specification string              `json:"specification"`
 	SpecZh        string              `json:"spec_zh"`
 	SpecEs        string              `json:"spec_es"`
+	// Per-language translations; legacy *_zh/*_es fields are merged in
+	NameTranslations map[string]string `json:"name_translations"`
+	DescTranslations map[string]string `json:"description_translations"`
+	SpecTranslations map[string]string `json:"spec_translations"`
 	Supplier      string              `json:"supplier"`
 	SupplierCode  string              `json:"supplier_code"`
 	Price         float64             `json:"price" binding:"required,gte=0"`
@@ -107,6 +121,10 @@ type UpdateProductRequest struct {
 	Specification string              `json:"specification"`
 	SpecZh        string              `json:"spec_zh"`
 	SpecEs        string              `json:"spec_es"`
+	// Per-language translations; legacy *_zh/*_es fields are merged in
+	NameTranslations map[string]string `json:"name_translations"`
+	DescTranslations map[string]string `json:"description_translations"`
+	SpecTranslations map[string]string `json:"spec_translations"`
 	Supplier      string              `json:"supplier"`
 	SupplierCode  string              `json:"supplier_code"`
 	Price         float64             `json:"price" binding:"omitempty,gte=0"`
@@ -127,6 +145,42 @@ type UpdateProductRequest struct {
 	Images        []ProductImageInput `json:"images"`
 }
 
+// applyProductTranslations applies request input, including the legacy zh/es
+// fields, to a product field's localized values. Changed values count as human
+// edits and are never overwritten by machine translation. Unknown language
+// codes are ignored; an empty string removes that language.
+func applyProductTranslations(p *models.Product, field string, translations map[string]string, zh, es string) {
+	for lang, text := range translations {
+		if i18n.IsKnown(lang) {
+			p.SetHumanTranslation(field, i18n.Normalize(lang), strings.TrimSpace(text))
+		}
+	}
+	if zh != "" {
+		p.SetHumanTranslation(field, "zh", zh)
+	}
+	if es != "" {
+		p.SetHumanTranslation(field, "es", es)
+	}
+}
+
+// machineTranslated lists the machine-translated languages of each product field
+func machineTranslated(p models.Product) map[string][]string {
+	result := make(map[string][]string)
+	for _, field := range models.ProductTranslatableFields {
+		if langs := p.MachineTranslatedLanguages(field); len(langs) > 0 {
+			result[field] = langs
+		}
+	}
+	return result
+}
+
+// enqueueProductTranslations queues background translation of a product's
+// missing localized fields. Pass the transaction that writes the product.
+func enqueueProductTranslations(tx *gorm.DB, product *models.Product) error {
+	_, err := translation.EnqueueProduct(tx, product, false)
+	return err
+}
+
 func productToResponse(p models.Product) ProductResponse {
 	images := make([]ProductImageResponse, len(p.Images))
 	for i, img := range p.Images {
@@ -143,14 +197,18 @@ func productToResponse(p models.Product) ProductResponse {
 		ID:            p.ID,
 		SKU:           p.SKU,
 		Name:          p.Name,
-		NameZh:        p.NameZh,
-		NameEs:        p.NameEs,
+		NameZh:        p.NameTranslations.Get("zh"),
+		NameEs:        p.NameTranslations.Get("es"),
 		Description:   p.Description,
 		Category:      p.Category,
 		Model:         p.Model,
 		Specification: p.Specification,
-		SpecZh:        p.SpecZh,
-		SpecEs:        p.SpecEs,
+		SpecZh:        p.SpecTranslations.Get("zh"),
+		SpecEs:        p.SpecTranslations.Get("es"),
+		NameTranslations: p.NameTranslations,
+		DescTranslations: p.DescTranslations,
+		SpecTranslations: p.SpecTranslations,
+		MachineTranslated: machineTranslated(p),
 		Supplier:      p.Supplier,
 		SupplierCode:  p.SupplierCode,
 		Price:         p.Price,
@@ -187,7 +245,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	query := h.db.Model(&models.Product{}).Where("is_active = ?", true)
+	query := inCompany(c, h.db.Model(&models.Product{})).Where("is_active = ?", true)
 
 	// Filter by source
 	if source != "" && source != "all" {
@@ -251,7 +309,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := h.db.Preload("Images").First(&product, id).Error; err != nil {
+	if err := inCompany(c, h.db).Preload("Images").First(&product, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Product not found")
 		} else {
@@ -267,7 +325,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 func (h *ProductHandler) GetCategories(c *gin.Context) {
 	source := c.DefaultQuery("source", "internal")
 
-	query := h.db.Model(&models.Product{}).Where("is_active = ?", true)
+	query := inCompany(c, h.db.Model(&models.Product{})).Where("is_active = ?", true)
 	if source != "" && source != "all" {
 		query = query.Where("source = ?", source)
 	}
@@ -291,7 +349,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 	// Check if SKU already exists
 	var existingProduct models.Product
-	if err := h.db.Where("sku = ?", req.SKU).First(&existingProduct).Error; err == nil {
+	if err := inCompany(c, h.db).Where("sku = ?", req.SKU).First(&existingProduct).Error; err == nil {
 		response.Conflict(c, "SKU already exists")
 		return
 	}
@@ -302,16 +360,13 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}
 
 	product := models.Product{
+		CompanyCode:   middleware.GetCompany(c),
 		SKU:           req.SKU,
 		Name:          req.Name,
-		NameZh:        req.NameZh,
-		NameEs:        req.NameEs,
 		Description:   req.Description,
 		Category:      req.Category,
 		Model:         req.Model,
 		Specification: req.Specification,
-		SpecZh:        req.SpecZh,
-		SpecEs:        req.SpecEs,
 		Supplier:      req.Supplier,
 		SupplierCode:  req.SupplierCode,
 		Price:         req.Price,
@@ -331,8 +386,17 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		ASIN:          req.ASIN,
 		Brand:         req.Brand,
 	}
+	applyProductTranslations(&product, models.ProductFieldName, req.NameTranslations, req.NameZh, req.NameEs)
+	applyProductTranslations(&product, models.ProductFieldDescription, req.DescTranslations, "", "")
+	applyProductTranslations(&product, models.ProductFieldSpecification, req.SpecTranslations, req.SpecZh, req.SpecEs)
 
-	if err := h.db.Create(&product).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		return enqueueProductTranslations(tx, &product)
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to create product")
 		return
 	}
@@ -365,7 +429,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := h.db.First(&product, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&product, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Product not found")
 		} else {
@@ -383,12 +447,8 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Name != "" {
 		product.Name = req.Name
 	}
-	if req.NameZh != "" {
-		product.NameZh = req.NameZh
-	}
-	if req.NameEs != "" {
-		product.NameEs = req.NameEs
-	}
+	applyProductTranslations(&product, models.ProductFieldName, req.NameTranslations, req.NameZh, req.NameEs)
+	applyProductTranslations(&product, models.ProductFieldDescription, req.DescTranslations, "", "")
 	if req.Description != "" {
 		product.Description = req.Description
 	}
@@ -401,12 +461,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Specification != "" {
 		product.Specification = req.Specification
 	}
-	if req.SpecZh != "" {
-		product.SpecZh = req.SpecZh
-	}
-	if req.SpecEs != "" {
-		product.SpecEs = req.SpecEs
-	}
+	applyProductTranslations(&product, models.ProductFieldSpecification, req.SpecTranslations, req.SpecZh, req.SpecEs)
 	if req.Supplier != "" {
 		product.Supplier = req.Supplier
 	}
@@ -457,7 +512,13 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		product.Brand = req.Brand
 	}
 
-	if err := h.db.Save(&product).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		return enqueueProductTranslations(tx, &product)
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to update product")
 		return
 	}
@@ -493,7 +554,7 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := h.db.First(&product, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&product, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Product not found")
 		} else {
@@ -527,7 +588,7 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := h.db.First(&product, id).Error; err != nil {
+	if err := inCompany(c, h.db).First(&product, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Product not found")
 		} else {
@@ -557,6 +618,9 @@ type BulkImportProduct struct {
 	Specification string  `json:"specification"`
 	SpecZh        string  `json:"spec_zh"`
 	SpecEs        string  `json:"spec_es"`
+	NameTranslations map[string]string `json:"name_translations"`
+	DescTranslations map[string]string `json:"description_translations"`
+	SpecTranslations map[string]string `json:"spec_translations"`
 	Supplier      string  `json:"supplier"`
 	SupplierCode  string  `json:"supplier_code"`
 	Price         float64 `json:"price"`
@@ -607,7 +671,7 @@ func (h *ProductHandler) BulkImportProducts(c *gin.Context) {
 
 		// Check if SKU already exists
 		var existingProduct models.Product
-		if err := h.db.Where("sku = ?", p.SKU).First(&existingProduct).Error; err == nil {
+		if err := inCompany(c, h.db).Where("sku = ?", p.SKU).First(&existingProduct).Error; err == nil {
 			result.Error = "SKU already exists"
 			results[i] = result
 			continue
@@ -631,16 +695,13 @@ func (h *ProductHandler) BulkImportProducts(c *gin.Context) {
 		}
 
 		product := models.Product{
+			CompanyCode:   middleware.GetCompany(c),
 			SKU:           p.SKU,
 			Name:          p.Name,
-			NameZh:        p.NameZh,
-			NameEs:        p.NameEs,
 			Description:   p.Description,
 			Category:      p.Category,
 			Model:         p.Model,
 			Specification: p.Specification,
-			SpecZh:        p.SpecZh,
-			SpecEs:        p.SpecEs,
 			Supplier:      p.Supplier,
 			SupplierCode:  p.SupplierCode,
 			Price:         p.Price,
@@ -657,8 +718,17 @@ func (h *ProductHandler) BulkImportProducts(c *gin.Context) {
 			Brand:         p.Brand,
 			ASIN:          p.ASIN,
 		}
+		applyProductTranslations(&product, models.ProductFieldName, p.NameTranslations, p.NameZh, p.NameEs)
+		applyProductTranslations(&product, models.ProductFieldDescription, p.DescTranslations, "", "")
+		applyProductTranslations(&product, models.ProductFieldSpecification, p.SpecTranslations, p.SpecZh, p.SpecEs)
 
-		if err := h.db.Create(&product).Error; err != nil {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&product).Error; err != nil {
+				return err
+			}
+			return enqueueProductTranslations(tx, &product)
+		})
+		if err != nil {
 			result.Error = "Failed to create product: " + err.Error()
 			results[i] = result
 			continue
@@ -676,3 +746,54 @@ func (h *ProductHandler) BulkImportProducts(c *gin.Context) {
 		"results": results,
 	})
 }
+
+// RetranslateCatalogRequest selects which products to retranslate
+type RetranslateCatalogRequest struct {
+	ProductIDs []uint `json:"product_ids"` // Empty means all active products
+	Force      bool   `json:"force"`       // Also redo current machine translations, e.g. after glossary changes
+}
+
+// RetranslateCatalog queues machine translation of missing or outdated
+// localized product fields. Values entered by people are left untouched.
+func (h *ProductHandler) RetranslateCatalog(c *gin.Context) {
+	var req RetranslateCatalogRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	query := inCompany(c, h.db.Model(&models.Product{}))
+	if len(req.ProductIDs) > 0 {
+		query = query.Where("id IN ?", req.ProductIDs)
+	} else {
+		query = query.Where("is_active = ?", true)
+	}
+
+	productCount := 0
+	jobCount := 0
+	var products []models.Product
+	result := query.FindInBatches(&products, 100, func(_ *gorm.DB, _ int) error {
+		for i := range products {
+			err := h.db.Transaction(func(tx *gorm.DB) error {
+				queued, err := translation.EnqueueProduct(tx, &products[i], req.Force)
+				jobCount += queued
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			productCount++
+		}
+		return nil
+	})
+	if result.Error != nil {
+		log.Printf("Failed to queue catalog translations: %v", result.Error)
+		response.InternalServerError(c, "Failed to queue catalog translations")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Catalog retranslation queued", gin.H{
+		"products": productCount,
+		"queued":   jobCount,
+	})
+}
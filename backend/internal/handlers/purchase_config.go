@@ -153,10 +153,10 @@ type UserBasicResponse struct {
 	Role  string `json:"role"`
 }
 
-// GetPurchaseConfig returns the current purchase configuration
+// GetPurchaseConfig returns the company's purchase configuration
 func (h *PurchaseConfigHandler) GetPurchaseConfig(c *gin.Context) {
 	var config models.PurchaseConfig
-	if err := h.db.Preload("DefaultApprover").First(&config).Error; err != nil {
+	if err := inCompany(c, h.db).Preload("DefaultApprover").First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Return default config
 			defaultConfig := models.GetDefaultPurchaseConfig()
@@ -186,7 +186,7 @@ type PublicConfigResponse struct {
 // GetPublicConfig returns public-facing configuration for authenticated users
 func (h *PurchaseConfigHandler) GetPublicConfig(c *gin.Context) {
 	var config models.PurchaseConfig
-	if err := h.db.First(&config).Error; err != nil {
+	if err := inCompany(c, h.db).First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			config = models.GetDefaultPurchaseConfig()
 		} else {
@@ -208,7 +208,7 @@ func (h *PurchaseConfigHandler) GetPublicConfig(c *gin.Context) {
 	})
 }
 
-// SavePurchaseConfig saves or updates the purchase configuration
+// SavePurchaseConfig saves or updates the company's purchase configuration
 func (h *PurchaseConfigHandler) SavePurchaseConfig(c *gin.Context) {
 	var req PurchaseConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -219,10 +219,11 @@ func (h *PurchaseConfigHandler) SavePurchaseConfig(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var config models.PurchaseConfig
-	isNew := h.db.First(&config).Error == gorm.ErrRecordNotFound
+	isNew := inCompany(c, h.db).First(&config).Error == gorm.ErrRecordNotFound
 
 	if isNew {
 		config = models.GetDefaultPurchaseConfig()
+		config.CompanyCode = middleware.GetCompany(c)
 	}
 
 	// Update fields if provided
@@ -363,7 +364,7 @@ func (h *PurchaseConfigHandler) SavePurchaseConfig(c *gin.Context) {
 	}
 
 	// Reload with relations
-	h.db.Preload("DefaultApprover").First(&config)
+	h.db.Preload("DefaultApprover").First(&config, config.ID)
 
 	response.SuccessWithMessage(c, "Purchase configuration saved", h.configToResponse(config))
 }
@@ -387,7 +388,7 @@ func (h *PurchaseConfigHandler) TestMetadataExtraction(c *gin.Context) {
 
 	// Get config to check domain restrictions
 	var config models.PurchaseConfig
-	if err := h.db.First(&config).Error; err == nil {
+	if err := inCompany(c, h.db).First(&config).Error; err == nil {
 		// Check if domain is allowed
 		domain := parsedURL.Host
 		// Remove www. prefix if present
@@ -417,10 +418,11 @@ func (h *PurchaseConfigHandler) TestMetadataExtraction(c *gin.Context) {
 	response.Success(c, result)
 }
 
-// GetApprovers returns users who can be approvers
+// GetApprovers returns users in the company who can be approvers
 func (h *PurchaseConfigHandler) GetApprovers(c *gin.Context) {
 	var users []models.User
-	if err := h.db.Where("status = ? AND role IN ?", "active", []models.UserRole{
+	members := models.CompanyMembers(h.db, middleware.GetCompany(c))
+	if err := members.Where("status = ? AND role IN ?", "active", []models.UserRole{
 		models.RoleAdmin,
 		models.RoleGeneralManager,
 		models.RoleSupplyChainManager,
@@ -12,67 +12,37 @@ import (
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/events"
 	"vista-backend/internal/services/metadata"
-	"vista-backend/internal/services/notifications"
 	"vista-backend/internal/services/translation"
 	"vista-backend/pkg/response"
 )
 
 type RequestHandler struct {
 	db              *gorm.DB
+	bus             *events.Bus
 	metadataService *metadata.Service
-	notificationSvc *notifications.NotificationService
 	asyncTranslator *translation.AsyncTranslator
 }
 
-func NewRequestHandler(db *gorm.DB) *RequestHandler {
+func NewRequestHandler(db *gorm.DB, bus *events.Bus) *RequestHandler {
 	return &RequestHandler{
 		db:              db,
+		bus:             bus,
 		metadataService: metadata.NewService(),
-		notificationSvc: notifications.NewNotificationService(db),
 		asyncTranslator: translation.NewAsyncTranslator(db),
 	}
 }
 
-// getUserLanguage extracts user's preferred language from request headers
-func (h *RequestHandler) getUserLanguage(c *gin.Context) string {
-	// First check custom header (set by frontend)
-	if lang := c.GetHeader("X-User-Language"); lang != "" {
-		lang = strings.ToLower(lang)
-		if lang == "en" || lang == "zh" || lang == "es" {
-			return lang
-		}
-	}
-
-	// Fallback to Accept-Language header
-	acceptLang := c.GetHeader("Accept-Language")
-	if strings.HasPrefix(strings.ToLower(acceptLang), "zh") {
-		return "zh"
-	}
-	if strings.HasPrefix(strings.ToLower(acceptLang), "es") {
-		return "es"
-	}
-
-	return "en"
-}
-
-// TranslatedTextInput represents translated text in all languages
-type TranslatedTextInput struct {
-	Original string `json:"original"`
-	En       string `json:"en"`
-	Zh       string `json:"zh"`
-	Es       string `json:"es"`
-}
-
 // CreateRequestItemInput represents a single product item in a multi-product request
 type CreateRequestItemInput struct {
 	URL                          string               `json:"url"` // URL is optional for catalog products
 	Quantity                     int                  `json:"quantity" binding:"required,gte=1"`
 	ProductTitle                 string               `json:"product_title"`
-	ProductTitleTranslated       *TranslatedTextInput `json:"product_title_translated"`
+	ProductTitleTranslated       *translation.TranslatedText `json:"product_title_translated"`
 	ProductImageURL              string               `json:"product_image_url"`
 	ProductDescription           string               `json:"product_description"`
-	ProductDescriptionTranslated *TranslatedTextInput `json:"product_description_translated"`
+	ProductDescriptionTranslated *translation.TranslatedText `json:"product_description_translated"`
 	EstimatedPrice               *float64             `json:"estimated_price"`
 	Currency                     string               `json:"currency"`
 }
@@ -95,6 +65,9 @@ type CreateRequestInput struct {
 	// Common fields
 	Justification string   `json:"justification" binding:"required"`
 	Urgency       string   `json:"urgency" binding:"omitempty,oneof=normal urgent"`
+
+	// JustificationLanguage overrides language detection for the justification
+	JustificationLanguage string `json:"justification_language"`
 }
 
 // ExtractMetadataInput represents the input for metadata extraction
@@ -129,6 +102,7 @@ type RequestResponse struct {
 	ID                 uint          `json:"id"`
 	RequestNumber      string        `json:"request_number"`
 	PONumber           string        `json:"po_number,omitempty"`
+	CompanyCode        string        `json:"company_code"`
 
 	// Multi-product support
 	Items          []RequestItemResponse `json:"items,omitempty"`
@@ -167,8 +141,11 @@ type RequestResponse struct {
 	RejectionReason string        `json:"rejection_reason,omitempty"`
 
 	// Info request
-	InfoRequestedAt *time.Time `json:"info_requested_at,omitempty"`
-	InfoRequestNote string     `json:"info_request_note,omitempty"`
+	InfoRequestedBy *UserResponse `json:"info_requested_by,omitempty"`
+	InfoRequestedAt *time.Time    `json:"info_requested_at,omitempty"`
+	InfoRequestNote string        `json:"info_request_note,omitempty"`
+	InfoResponse    string        `json:"info_response,omitempty"`
+	InfoRespondedAt *time.Time    `json:"info_responded_at,omitempty"`
 
 	// Purchase info
 	PurchasedBy   *UserResponse `json:"purchased_by,omitempty"`
@@ -193,6 +170,7 @@ type RequestResponse struct {
 	JustificationTranslated     models.JSONB `json:"justification_translated,omitempty"`
 	RejectionReasonTranslated   models.JSONB `json:"rejection_reason_translated,omitempty"`
 	InfoRequestNoteTranslated   models.JSONB `json:"info_request_note_translated,omitempty"`
+	InfoResponseTranslated      models.JSONB `json:"info_response_translated,omitempty"`
 	PurchaseNotesTranslated     models.JSONB `json:"purchase_notes_translated,omitempty"`
 	DeliveryNotesTranslated     models.JSONB `json:"delivery_notes_translated,omitempty"`
 	CancellationNotesTranslated models.JSONB `json:"cancellation_notes_translated,omitempty"`
@@ -232,6 +210,7 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		ID:                     r.ID,
 		RequestNumber:          r.RequestNumber,
 		PONumber:               poNumber,
+		CompanyCode:            r.CompanyCode,
 		ProductCount:           r.ProductCount,
 		TotalEstimated:         r.TotalEstimated,
 		URL:                    r.URL,
@@ -257,6 +236,8 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		RejectionReason:    r.RejectionReason,
 		InfoRequestedAt:    r.InfoRequestedAt,
 		InfoRequestNote:    r.InfoRequestNote,
+		InfoResponse:       r.InfoResponse,
+		InfoRespondedAt:    r.InfoRespondedAt,
 		PurchasedAt:        r.PurchasedAt,
 		PurchaseNotes:      r.PurchaseNotes,
 		OrderNumber:        r.OrderNumber,
@@ -269,6 +250,7 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		JustificationTranslated:     r.JustificationTranslated,
 		RejectionReasonTranslated:   r.RejectionReasonTranslated,
 		InfoRequestNoteTranslated:   r.InfoRequestNoteTranslated,
+		InfoResponseTranslated:      r.InfoResponseTranslated,
 		PurchaseNotesTranslated:     r.PurchaseNotesTranslated,
 		DeliveryNotesTranslated:     r.DeliveryNotesTranslated,
 		CancellationNotesTranslated: r.CancellationNotesTranslated,
@@ -332,6 +314,15 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		}
 	}
 
+	if r.InfoRequestedBy != nil && r.InfoRequestedBy.ID != 0 {
+		resp.InfoRequestedBy = &UserResponse{
+			ID:    r.InfoRequestedBy.ID,
+			Email: r.InfoRequestedBy.Email,
+			Name:  r.InfoRequestedBy.Name,
+			Role:  string(r.InfoRequestedBy.Role),
+		}
+	}
+
 	if r.PurchasedBy != nil && r.PurchasedBy.ID != 0 {
 		resp.PurchasedBy = &UserResponse{
 			ID:    r.PurchasedBy.ID,
@@ -384,6 +375,18 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 	return resp
 }
 
+// newRequestEvent describes a change the current user made to request
+func newRequestEvent(c *gin.Context, request *models.PurchaseRequest, previous models.RequestStatus) events.RequestEvent {
+	return events.RequestEvent{
+		RequestID: request.ID,
+		Status:    request.Status,
+		Previous:  previous,
+		ActorID:   middleware.GetUserID(c),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
 // ExtractMetadata extracts metadata from a URL (for preview before submission)
 func (h *RequestHandler) ExtractMetadata(c *gin.Context) {
 	var input ExtractMetadataInput
@@ -433,6 +436,10 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if input.JustificationLanguage != "" && !translation.IsSupportedLanguage(input.JustificationLanguage) {
+		response.BadRequest(c, "Unsupported justification language")
+		return
+	}
 
 	userID := middleware.GetUserID(c)
 
@@ -441,29 +448,29 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		urgency = models.UrgencyUrgent
 	}
 
+	// Requests are numbered and approved within the company they're made in
+	company, err := models.FindCompany(h.db, middleware.GetCompany(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to load company")
+		return
+	}
+
 	request := models.PurchaseRequest{
-		RequestNumber: models.GenerateRequestNumber(h.db),
+		RequestNumber: models.GenerateRequestNumber(h.db, company.NumberPrefix),
+		CompanyCode:   company.Code,
 		Justification: input.Justification,
 		Urgency:       urgency,
 		RequesterID:   userID,
 		Status:        models.StatusPending,
 	}
 
-	// Translate justification (sync for user's language, async for others)
-	userLang := h.getUserLanguage(c)
+	// Translate justification (sync for user's language, queued for others)
+	userLang := getUserLanguage(c)
+	var justificationTranslation *translation.TranslateFieldResult
 	if input.Justification != "" {
-		translationResult, _ := h.asyncTranslator.TranslateField(
-			input.Justification,
-			userLang,
-			func(t *translation.TranslatedText) error {
-				// Background callback to save complete translations
-				return h.db.Model(&models.PurchaseRequest{}).
-					Where("id = ?", request.ID).
-					Update("justification_translated", translation.ToJSON(t)).Error
-			},
-		)
-		if translationResult != nil {
-			request.JustificationTranslated = translationResult.JSON
+		justificationTranslation, _ = h.asyncTranslator.TranslateField(input.Justification, userLang, input.JustificationLanguage)
+		if justificationTranslation != nil {
+			request.JustificationTranslated = justificationTranslation.JSON
 		}
 	}
 
@@ -652,9 +659,8 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		return
 	}
 
-	// Check if the requester is a General Manager - auto-approve their requests
-	userRole := middleware.GetUserRole(c)
-	isGMRequest := userRole == string(models.RoleGeneralManager)
+	// Requests from roles that can approve them (general managers) are auto-approved
+	isGMRequest := middleware.HasPermission(c, models.PermRequestsAutoApprove)
 
 	if isGMRequest {
 		// Auto-approve GM requests
@@ -665,7 +671,7 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		request.PONumber = models.GeneratePONumber(request.RequestNumber)
 	}
 
-	err := h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Create(&request).Error; err != nil {
 			return err
 		}
@@ -687,7 +693,15 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 			}
 			history = models.NewHistory(request.ID, userID, models.ActionCreated, "", models.StatusPending, comment)
 		}
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		// Remaining translations are queued now that the request has an ID
+		event := &events.RequestCreated{RequestEvent: newRequestEvent(c, &request, ""), AutoApproved: isGMRequest}
+		event.Translate("justification_translated", justificationTranslation)
+		emit(event)
+		return nil
 	})
 
 	if err != nil {
@@ -704,21 +718,6 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		Preload("History.User").
 		First(&request, request.ID)
 
-	// Send notification (async)
-	go func() {
-		if isGMRequest {
-			// GM request auto-approved: notify purchase admins about new approved order
-			if err := h.notificationSvc.NotifyNewApprovedOrder(&request); err != nil {
-				log.Printf("Failed to send new approved order notification: %v", err)
-			}
-		} else {
-			// Normal request: notify approvers
-			if err := h.notificationSvc.NotifyRequestCreated(&request); err != nil {
-				log.Printf("Failed to send request created notification: %v", err)
-			}
-		}
-	}()
-
 	response.Created(c, requestToResponse(request))
 }
 
@@ -732,7 +731,7 @@ func (h *RequestHandler) ListRequests(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	canViewAll := middleware.CanViewAll(c)
 
-	query := h.db.Model(&models.PurchaseRequest{}).
+	query := inCompany(c, h.db.Model(&models.PurchaseRequest{})).
 		Preload("Requester").
 		Preload("Items")
 
@@ -782,6 +781,7 @@ func (h *RequestHandler) GetRequest(c *gin.Context) {
 		Preload("History.User").
 		Preload("ApprovedBy").
 		Preload("RejectedBy").
+		Preload("InfoRequestedBy").
 		Preload("PurchasedBy").
 		First(&req, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -792,8 +792,10 @@ func (h *RequestHandler) GetRequest(c *gin.Context) {
 		return
 	}
 
+	// Users can always see their own requests; others' only in the company
+	// they work in
 	userID := middleware.GetUserID(c)
-	canViewAll := middleware.CanViewAll(c)
+	canViewAll := middleware.CanViewAll(c) && req.CompanyCode == middleware.GetCompany(c)
 	if !canViewAll && req.RequesterID != userID {
 		response.Forbidden(c, "Access denied")
 		return
@@ -874,13 +876,18 @@ func (h *RequestHandler) CancelRequest(c *gin.Context) {
 	oldStatus := request.Status
 	request.Status = models.StatusRejected
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
 		if err := tx.Save(&request).Error; err != nil {
 			return err
 		}
 
 		history := models.NewHistory(request.ID, userID, models.ActionCancelled, oldStatus, models.StatusRejected, "Request cancelled by requester")
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		emit(&events.RequestCancelled{RequestEvent: newRequestEvent(c, &request, oldStatus)})
+		return nil
 	})
 
 	if err != nil {
@@ -891,8 +898,68 @@ func (h *RequestHandler) CancelRequest(c *gin.Context) {
 	response.SuccessWithMessage(c, "Request cancelled successfully", nil)
 }
 
-// UpdateRequest allows the requester to update their pending request
-func (h *RequestHandler) UpdateRequest(c *gin.Context) {
+// RequestEditInput holds the fields a requester can change while the request
+// is pending or waiting for more information. Empty fields are left unchanged.
+type RequestEditInput struct {
+	Quantity           int      `json:"quantity"`
+	Justification      string   `json:"justification"`
+	Urgency            string   `json:"urgency"`
+	ProductTitle       string   `json:"product_title"`
+	ProductDescription string   `json:"product_description"`
+	EstimatedPrice     *float64 `json:"estimated_price"`
+
+	JustificationLanguage string `json:"justification_language"`
+}
+
+// applyEdits copies the provided fields onto request, translating a changed
+// justification. It returns that translation, to be queued with the save,
+// or a validation message.
+func (h *RequestHandler) applyEdits(c *gin.Context, request *models.PurchaseRequest, input *RequestEditInput) (*translation.TranslateFieldResult, string) {
+	if input.JustificationLanguage != "" && !translation.IsSupportedLanguage(input.JustificationLanguage) {
+		return nil, "Unsupported justification language"
+	}
+
+	if input.Quantity > 0 {
+		request.Quantity = input.Quantity
+	}
+	var justificationTranslation *translation.TranslateFieldResult
+	if input.Justification != "" && input.Justification != request.Justification {
+		request.Justification = input.Justification
+		justificationTranslation, _ = h.asyncTranslator.TranslateField(input.Justification, getUserLanguage(c), input.JustificationLanguage)
+		if justificationTranslation != nil {
+			request.JustificationTranslated = justificationTranslation.JSON
+		}
+	}
+	if input.Urgency != "" {
+		if input.Urgency == "urgent" {
+			request.Urgency = models.UrgencyUrgent
+		} else {
+			request.Urgency = models.UrgencyNormal
+		}
+	}
+	if input.ProductTitle != "" {
+		request.ProductTitle = input.ProductTitle
+	}
+	if input.ProductDescription != "" {
+		request.ProductDescription = input.ProductDescription
+	}
+	if input.EstimatedPrice != nil {
+		request.EstimatedPrice = input.EstimatedPrice
+	}
+	return justificationTranslation, ""
+}
+
+// RespondToInfoRequestInput is the requester's answer to an info request,
+// optionally with edits to the request
+type RespondToInfoRequestInput struct {
+	Message  string `json:"message" binding:"required"`
+	Language string `json:"language"` // Overrides language detection for the message
+	RequestEditInput
+}
+
+// RespondToInfoRequest answers the approver's question and sends the request
+// back for approval
+func (h *RequestHandler) RespondToInfoRequest(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		response.BadRequest(c, "Invalid request ID")
@@ -916,56 +983,132 @@ func (h *RequestHandler) UpdateRequest(c *gin.Context) {
 		return
 	}
 
-	// Can only update if pending or info_requested
-	if request.Status != models.StatusPending && request.Status != models.StatusInfoRequested {
-		response.BadRequest(c, "Request cannot be updated in current status")
+	if !request.CanRespondToInfo() {
+		response.BadRequest(c, "No information was requested for this request")
 		return
 	}
 
-	var input struct {
-		Quantity           int      `json:"quantity"`
-		Justification      string   `json:"justification"`
-		Urgency            string   `json:"urgency"`
-		ProductTitle       string   `json:"product_title"`
-		ProductDescription string   `json:"product_description"`
-		EstimatedPrice     *float64 `json:"estimated_price"`
+	var input RespondToInfoRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "A response message is required")
+		return
+	}
+	input.Message = strings.TrimSpace(input.Message)
+	if input.Message == "" {
+		response.BadRequest(c, "A response message is required")
+		return
+	}
+	if input.Language != "" && !translation.IsSupportedLanguage(input.Language) {
+		response.BadRequest(c, "Unsupported message language")
+		return
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		response.BadRequest(c, "Invalid request: "+err.Error())
+	justificationTranslation, msg := h.applyEdits(c, &request, &input.RequestEditInput)
+	if msg != "" {
+		response.BadRequest(c, msg)
 		return
 	}
 
-	// Update fields if provided
-	if input.Quantity > 0 {
-		request.Quantity = input.Quantity
+	oldStatus := request.Status
+	now := time.Now()
+	request.Status = models.StatusPending
+	request.InfoResponse = input.Message
+	request.InfoRespondedAt = &now
+
+	// Translate the response (remaining languages are queued with the save)
+	responseTranslation, _ := h.asyncTranslator.TranslateField(input.Message, getUserLanguage(c), input.Language)
+	if responseTranslation != nil {
+		request.InfoResponseTranslated = responseTranslation.JSON
 	}
-	if input.Justification != "" {
-		request.Justification = input.Justification
+
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+
+		history := models.NewHistory(request.ID, userID, models.ActionReturned, oldStatus, models.StatusPending, input.Message)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		event := &events.InfoProvided{RequestEvent: newRequestEvent(c, &request, oldStatus), Response: input.Message}
+		event.Translate("info_response_translated", responseTranslation)
+		event.Translate("justification_translated", justificationTranslation)
+		emit(event)
+		return nil
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to send response")
+		return
 	}
-	if input.Urgency != "" {
-		if input.Urgency == "urgent" {
-			request.Urgency = models.UrgencyUrgent
+
+	// Reload with relations
+	h.db.
+		Preload("Requester").
+		Preload("Items").
+		Preload("History").
+		Preload("History.User").
+		Preload("InfoRequestedBy").
+		First(&request, request.ID)
+
+	response.SuccessWithMessage(c, "Response sent to the approver", requestToResponse(request))
+}
+
+// UpdateRequest allows the requester to update their pending request
+func (h *RequestHandler) UpdateRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid request ID")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+
+	var request models.PurchaseRequest
+	if err := h.db.First(&request, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Request not found")
 		} else {
-			request.Urgency = models.UrgencyNormal
+			response.InternalServerError(c, "Failed to fetch request")
 		}
+		return
 	}
-	if input.ProductTitle != "" {
-		request.ProductTitle = input.ProductTitle
-	}
-	if input.ProductDescription != "" {
-		request.ProductDescription = input.ProductDescription
+
+	if request.RequesterID != userID {
+		response.Forbidden(c, "Access denied")
+		return
 	}
-	if input.EstimatedPrice != nil {
-		request.EstimatedPrice = input.EstimatedPrice
+
+	// Can only update if pending or info_requested
+	if request.Status != models.StatusPending && request.Status != models.StatusInfoRequested {
+		response.BadRequest(c, "Request cannot be updated in current status")
+		return
 	}
 
-	// If status was info_requested, change back to pending
-	if request.Status == models.StatusInfoRequested {
-		request.Status = models.StatusPending
+	var input RequestEditInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	justificationTranslation, msg := h.applyEdits(c, &request, &input)
+	if msg != "" {
+		response.BadRequest(c, msg)
+		return
 	}
 
-	if err := h.db.Save(&request).Error; err != nil {
+	// The status doesn't change: answering an info request goes through
+	// RespondToInfoRequest, which records it and notifies the approver
+	err = h.bus.Transaction(h.db, func(tx *gorm.DB, emit func(events.Event)) error {
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+
+		event := &events.RequestUpdated{RequestEvent: newRequestEvent(c, &request, request.Status)}
+		event.Translate("justification_translated", justificationTranslation)
+		emit(event)
+		return nil
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to update request")
 		return
 	}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/response"
+)
+
+type RoleHandler struct {
+	db *gorm.DB
+}
+
+func NewRoleHandler(db *gorm.DB) *RoleHandler {
+	return &RoleHandler{db: db}
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	DisplayName string   `json:"display_name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=500"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	DisplayName *string  `json:"display_name" binding:"omitempty,min=1,max=100"`
+	Description *string  `json:"description" binding:"omitempty,max=500"`
+	Permissions []string `json:"permissions"` // Replaces the role's permissions when set
+}
+
+// RoleResponse is a role with the number of users who have it
+type RoleResponse struct {
+	models.Role
+	UserCount int64 `json:"user_count"`
+}
+
+// ListPermissions returns the catalog of permissions roles can grant
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	response.Success(c, models.PermissionCatalog)
+}
+
+// ListRoles returns every role with its permissions
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	var roles []models.Role
+	if err := h.db.Order("built_in DESC, name").Find(&roles).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch roles")
+		return
+	}
+
+	type roleCount struct {
+		Role  models.UserRole
+		Count int64
+	}
+	var counts []roleCount
+	if err := h.db.Model(&models.User{}).Select("role, COUNT(*) AS count").
+		Group("role").Scan(&counts).Error; err != nil {
+		response.InternalServerError(c, "Failed to count users")
+		return
+	}
+
+	result := make([]RoleResponse, len(roles))
+	for i, role := range roles {
+		result[i] = RoleResponse{Role: role}
+		for _, count := range counts {
+			if count.Role == role.Name {
+				result[i].UserCount = count.Count
+			}
+		}
+	}
+
+	response.Success(c, result)
+}
+
+// CreateRole defines a new role
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if !models.IsValidRoleName(name) {
+		response.BadRequest(c, "Role name must be 2-50 lowercase letters, digits or underscores, starting with a letter")
+		return
+	}
+	permissions, ok := validPermissions(c, req.Permissions)
+	if !ok {
+		return
+	}
+	if models.RoleExists(h.db, models.UserRole(name)) {
+		response.Conflict(c, "Role already exists")
+		return
+	}
+
+	role := models.Role{
+		Name:        models.UserRole(name),
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissions,
+	}
+	if err := h.db.Create(&role).Error; err != nil {
+		log.Printf("Failed to create role: %v", err)
+		response.InternalServerError(c, "Failed to create role")
+		return
+	}
+
+	h.audit(c, "role.created", &role, "", strings.Join(role.Permissions, " "))
+	response.Created(c, RoleResponse{Role: role})
+}
+
+// UpdateRole changes a role's name shown to users or its permissions. The
+// admin role can't give up roles.manage.
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	role, ok := h.loadRole(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	oldPermissions := strings.Join(role.Permissions, " ")
+	updates := map[string]interface{}{}
+	if req.DisplayName != nil {
+		updates["display_name"] = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.Permissions != nil {
+		permissions, ok := validPermissions(c, req.Permissions)
+		if !ok {
+			return
+		}
+		if role.Name == models.RoleAdmin && !permissions.Contains(string(models.PermRolesManage)) {
+			response.BadRequest(c, "The admin role must keep the "+string(models.PermRolesManage)+" permission")
+			return
+		}
+		updates["permissions"] = permissions
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(role).Updates(updates).Error; err != nil {
+			response.InternalServerError(c, "Failed to update role")
+			return
+		}
+		h.db.First(role, role.ID)
+	}
+
+	if newPermissions := strings.Join(role.Permissions, " "); newPermissions != oldPermissions {
+		h.audit(c, "role.permissions_changed", role, oldPermissions, newPermissions)
+	}
+	response.Success(c, role)
+}
+
+// DeleteRole removes a role nobody has. Built-in roles can't be deleted.
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	role, ok := h.loadRole(c)
+	if !ok {
+		return
+	}
+	if role.BuiltIn {
+		response.BadRequest(c, "Built-in roles can't be deleted")
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.User{}).Where("role = ?", role.Name).Count(&count)
+	if count > 0 {
+		response.Conflict(c, "Role is still assigned to users")
+		return
+	}
+
+	if err := h.db.Delete(role).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete role")
+		return
+	}
+
+	h.audit(c, "role.deleted", role, strings.Join(role.Permissions, " "), "")
+	response.SuccessWithMessage(c, "Role deleted", nil)
+}
+
+// loadRole fetches the role in the :id parameter, responding with an error
+// if there is none
+func (h *RoleHandler) loadRole(c *gin.Context) (*models.Role, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return nil, false
+	}
+
+	var role models.Role
+	if err := h.db.First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Role not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch role")
+		}
+		return nil, false
+	}
+	return &role, true
+}
+
+// audit records an admin's change to a role
+func (h *RoleHandler) audit(c *gin.Context, action string, role *models.Role, oldValue, newValue string) {
+	entry := models.NewAuditLog(middleware.GetUserID(c), action, "role", role.ID,
+		oldValue, newValue, c.ClientIP(), c.Request.UserAgent())
+	if err := h.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to audit %s for role %s: %v", action, role.Name, err)
+	}
+}
+
+// validPermissions checks the permissions are in the catalog and removes
+// duplicates, responding with an error if one isn't
+func validPermissions(c *gin.Context, permissions []string) (models.StringList, bool) {
+	result := models.StringList{}
+	seen := map[string]bool{}
+	for _, p := range permissions {
+		if !models.Permission(p).IsValid() {
+			response.BadRequest(c, "Invalid permission: "+p)
+			return nil, false
+		}
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	return result, true
+}
+
+// validRole checks that users can be given the role, and that the caller
+// may give it, responding with an error if not
+func validRole(c *gin.Context, db *gorm.DB, role string) bool {
+	if !models.RoleExists(db, models.UserRole(role)) {
+		response.BadRequest(c, "Invalid role: "+role)
+		return false
+	}
+	if !canGrantRole(c, db, models.UserRole(role)) {
+		response.Forbidden(c, "You can't give users a role with permissions you don't have")
+		return false
+	}
+	return true
+}
+
+// canGrantRole returns true if the caller may give users the role, or take
+// it away from them. Callers with roles.manage can already define any role;
+// others only handle roles whose permissions they have themselves, so they
+// can't raise anyone, themselves included, above their own access.
+func canGrantRole(c *gin.Context, db *gorm.DB, role models.UserRole) bool {
+	if middleware.HasPermission(c, models.PermRolesManage) {
+		return true
+	}
+	own := middleware.GetPermissions(c)
+	for p := range models.RolePermissions(db, role) {
+		if !own.Has(p) {
+			return false
+		}
+	}
+	return true
+}
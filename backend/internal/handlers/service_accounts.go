@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+const (
+	// serviceAccountEmailDomain is reserved (RFC 2606), so service account
+	// addresses can never receive mail or match a real account
+	serviceAccountEmailDomain = "service-accounts.invalid"
+	// defaultAPIKeyExpiryDays is used when a key is created without an expiry
+	defaultAPIKeyExpiryDays = 90
+)
+
+type ServiceAccountHandler struct {
+	db          *gorm.DB
+	authService *services.AuthService
+}
+
+func NewServiceAccountHandler(db *gorm.DB, authService *services.AuthService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{db: db, authService: authService}
+}
+
+type CreateServiceAccountRequest struct {
+	Name           string `json:"name" binding:"required,max=255"`
+	EmployeeNumber string `json:"employee_number" binding:"omitempty,max=50"` // Defaults to svc-<name>
+	Role           string `json:"role" binding:"required"`
+	CompanyCode    string `json:"company_code"`
+	CostCenter     string `json:"cost_center"`
+	Department     string `json:"department"`
+}
+
+type UpdateServiceAccountRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Role        *string `json:"role"`
+	CompanyCode *string `json:"company_code"`
+	CostCenter  *string `json:"cost_center"`
+	Department  *string `json:"department"`
+	Active      *bool   `json:"active"` // False disables every key of the account
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=730"`
+}
+
+// ServiceAccountResponse is a service account with its API keys
+type ServiceAccountResponse struct {
+	models.User
+	APIKeys []models.APIKey `json:"api_keys"`
+}
+
+// CreateAPIKeyResponse includes the key itself, which is never shown again
+type CreateAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// GetAPIScopes lists the resources and actions API keys can be scoped to
+func (h *ServiceAccountHandler) GetAPIScopes(c *gin.Context) {
+	response.Success(c, gin.H{
+		"resources": models.APIResources,
+		"actions":   models.APIScopeActions,
+	})
+}
+
+// ListServiceAccounts returns the service accounts with their API keys
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	var accounts []models.User
+	if err := h.db.Where("service_account = ?", true).Order("name").Find(&accounts).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch service accounts")
+		return
+	}
+
+	ids := make([]uint, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	var keys []models.APIKey
+	if len(ids) > 0 {
+		if err := h.db.Preload("CreatedBy").Where("service_account_id IN ?", ids).
+			Order("created_at DESC").Find(&keys).Error; err != nil {
+			response.InternalServerError(c, "Failed to fetch API keys")
+			return
+		}
+	}
+
+	result := make([]ServiceAccountResponse, len(accounts))
+	for i, account := range accounts {
+		result[i] = ServiceAccountResponse{User: account, APIKeys: []models.APIKey{}}
+		for _, key := range keys {
+			if key.ServiceAccountID == account.ID {
+				result[i].APIKeys = append(result[i].APIKeys, key)
+			}
+		}
+	}
+
+	response.Success(c, result)
+}
+
+// CreateServiceAccount creates an account for a script or integration. It
+// has no password; it calls the API with the keys issued to it.
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	var req CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if !validRole(c, h.db, req.Role) || !validCompany(c, h.db, req.CompanyCode) {
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	employeeNumber := strings.TrimSpace(req.EmployeeNumber)
+	if employeeNumber == "" {
+		slug := slugify(name)
+		if slug == "" {
+			response.BadRequest(c, "Employee number is required for this name")
+			return
+		}
+		employeeNumber = "svc-" + slug
+	}
+	email := strings.ToLower(employeeNumber) + "@" + serviceAccountEmailDomain
+
+	var count int64
+	h.db.Unscoped().Model(&models.User{}).
+		Where("employee_number = ? OR email = ?", employeeNumber, email).Count(&count)
+	if count > 0 {
+		response.Conflict(c, "Employee number already exists")
+		return
+	}
+
+	adminID := middleware.GetUserID(c)
+	now := time.Now()
+	account := models.User{
+		EmployeeNumber: employeeNumber,
+		Email:          email,
+		Name:           name,
+		Role:           models.UserRole(req.Role),
+		CompanyCode:    req.CompanyCode,
+		CostCenter:     req.CostCenter,
+		Department:     req.Department,
+		Status:         models.UserStatusApproved,
+		ApprovedByID:   &adminID,
+		ApprovedAt:     &now,
+		ServiceAccount: true,
+	}
+	if err := h.db.Create(&account).Error; err != nil {
+		log.Printf("Failed to create service account: %v", err)
+		response.InternalServerError(c, "Failed to create service account")
+		return
+	}
+
+	response.Created(c, ServiceAccountResponse{User: account, APIKeys: []models.APIKey{}})
+}
+
+// UpdateServiceAccount changes a service account's name, role or
+// organization, or disables it
+func (h *ServiceAccountHandler) UpdateServiceAccount(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Role != nil {
+		if !validRole(c, h.db, *req.Role) {
+			return
+		}
+		updates["role"] = *req.Role
+	}
+	if req.CompanyCode != nil {
+		if !validCompany(c, h.db, *req.CompanyCode) {
+			return
+		}
+		updates["company_code"] = *req.CompanyCode
+	}
+	if req.CostCenter != nil {
+		updates["cost_center"] = *req.CostCenter
+	}
+	if req.Department != nil {
+		updates["department"] = *req.Department
+	}
+	if req.Active != nil {
+		if *req.Active {
+			updates["status"] = models.UserStatusApproved
+		} else {
+			updates["status"] = models.UserStatusDisabled
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(account).Updates(updates).Error; err != nil {
+			response.InternalServerError(c, "Failed to update service account")
+			return
+		}
+		h.db.First(account, account.ID)
+	}
+
+	response.Success(c, account)
+}
+
+// DeleteServiceAccount deletes a service account and revokes its keys
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.APIKey{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Delete(account).Error
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to delete service account")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Service account deleted", nil)
+}
+
+// CreateAPIKey issues a key to a service account. The key is in the response
+// only; it can't be retrieved later.
+func (h *ServiceAccountHandler) CreateAPIKey(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !models.IsValidAPIScope(scope) {
+			response.BadRequest(c, "Invalid scope: "+scope)
+			return
+		}
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultAPIKeyExpiryDays
+	}
+	expiresAt := time.Now().AddDate(0, 0, days)
+
+	adminID := middleware.GetUserID(c)
+	key, apiKey, err := h.authService.CreateAPIKey(account, strings.TrimSpace(req.Name), req.Scopes, &expiresAt, adminID)
+	if err != nil {
+		log.Printf("Failed to create API key for service account %d: %v", account.ID, err)
+		response.InternalServerError(c, "Failed to create API key")
+		return
+	}
+
+	h.audit(c, "api_key.created", apiKey, strings.Join(req.Scopes, " "))
+	response.Created(c, CreateAPIKeyResponse{Key: key, APIKey: apiKey})
+}
+
+// RevokeAPIKey stops a key from working. Revoked keys stay listed for the record.
+func (h *ServiceAccountHandler) RevokeAPIKey(c *gin.Context) {
+	account, ok := h.loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var apiKey models.APIKey
+	if err := h.db.Where("id = ? AND service_account_id = ?", c.Param("key_id"), account.ID).First(&apiKey).Error; err != nil {
+		response.NotFound(c, "API key not found")
+		return
+	}
+	if apiKey.RevokedAt != nil {
+		response.Success(c, apiKey)
+		return
+	}
+
+	now := time.Now()
+	if err := h.db.Model(&apiKey).Update("revoked_at", now).Error; err != nil {
+		response.InternalServerError(c, "Failed to revoke API key")
+		return
+	}
+
+	h.audit(c, "api_key.revoked", &apiKey, "")
+	response.Success(c, apiKey)
+}
+
+// loadServiceAccount fetches the service account in the :id parameter,
+// responding with an error if there is none
+func (h *ServiceAccountHandler) loadServiceAccount(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid service account ID")
+		return nil, false
+	}
+
+	var account models.User
+	if err := h.db.Where("service_account = ?", true).First(&account, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Service account not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch service account")
+		}
+		return nil, false
+	}
+	return &account, true
+}
+
+// audit records an admin's change to an API key
+func (h *ServiceAccountHandler) audit(c *gin.Context, action string, apiKey *models.APIKey, newValue string) {
+	entry := models.NewAuditLog(middleware.GetUserID(c), action, "api_key", apiKey.ID,
+		"", strings.TrimSpace(apiKey.Prefix+" "+newValue), c.ClientIP(), c.Request.UserAgent())
+	if err := h.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to audit %s for API key %d: %v", action, apiKey.ID, err)
+	}
+}
+
+// slugify turns a name into lowercase letters, digits and dashes
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+// The single sign-on binding cookie ties a callback to the browser that
+// started the login. It's only sent to the SSO endpoints.
+const (
+	ssoBindingCookie = "vista_sso_binding"
+	ssoCookiePath    = "/api/v1/auth/sso"
+)
+
+type SSOConfigResponse struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+type SSOStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type SSOCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// GetSSOConfig tells the login page whether to offer single sign-on
+// @Summary Single sign-on settings
+// @Description Returns whether OpenID Connect single sign-on is configured and the identity provider's name
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} SSOConfigResponse
+// @Router /api/v1/auth/sso [get]
+func (h *AuthHandler) GetSSOConfig(c *gin.Context) {
+	if !h.ssoService.Enabled() {
+		response.Success(c, SSOConfigResponse{Enabled: false})
+		return
+	}
+	response.Success(c, SSOConfigResponse{
+		Enabled:      true,
+		ProviderName: h.ssoService.ProviderName(),
+	})
+}
+
+// StartSSOLogin begins a single sign-on login
+// @Summary Start single sign-on
+// @Description Returns the identity provider URL to send the user to, and sets an HttpOnly cookie the callback needs. The provider sends them back to the SSO callback page with a code and state for /auth/sso/callback.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} SSOStartResponse
+// @Failure 404 {object} response.Response
+// @Failure 502 {object} response.Response
+// @Router /api/v1/auth/sso/start [post]
+func (h *AuthHandler) StartSSOLogin(c *gin.Context) {
+	authURL, binding, err := h.ssoService.StartLogin(c.Request.Context(), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSSONotConfigured):
+			response.NotFound(c, "Single sign-on is not configured")
+		case errors.Is(err, services.ErrSSOFailed):
+			log.Printf("Failed to start SSO login: %v", err)
+			response.Error(c, 502, "SSO_UNAVAILABLE", "The identity provider can't be reached. Please try again later.")
+		default:
+			log.Printf("Failed to start SSO login: %v", err)
+			response.InternalServerError(c, "Failed to start single sign-on")
+		}
+		return
+	}
+
+	setSSOBindingCookie(c, binding, int(services.SSOLoginValidFor.Seconds()))
+	response.Success(c, SSOStartResponse{AuthorizationURL: authURL})
+}
+
+// CompleteSSOLogin finishes a single sign-on login
+// @Summary Complete single sign-on
+// @Description Exchanges the code the identity provider sent the user back with for a session. Only works in the browser that started the login, which sends the cookie set by /auth/sso/start. First-time users get an account from their ID token claims, which waits for admin approval unless SSO accounts are approved automatically. Like login, it returns a two-factor token instead if a second factor is needed.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body SSOCallbackRequest true "Code and state from the identity provider"
+// @Success 200 {object} LoginResponse
+// @Success 200 {object} TwoFactorChallengeResponse
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /api/v1/auth/sso/callback [post]
+func (h *AuthHandler) CompleteSSOLogin(c *gin.Context) {
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	// A missing cookie fails the same way as a wrong one
+	binding, _ := c.Cookie(ssoBindingCookie)
+	tokens, user, err := h.ssoService.CompleteLogin(c.Request.Context(), req.Code, req.State, binding,
+		getUserLanguage(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	setSSOBindingCookie(c, "", -1)
+	if err != nil {
+		var challenge *services.TwoFactorChallenge
+		if errors.As(err, &challenge) {
+			response.Success(c, TwoFactorChallengeResponse{
+				TwoFactorRequired:  true,
+				TwoFactorToken:     challenge.Token,
+				EnrollmentRequired: challenge.Enroll,
+			})
+			return
+		}
+
+		var userID *uint
+		identifier := "sso"
+		if user != nil {
+			userID, identifier = &user.ID, user.Email
+		}
+
+		switch {
+		case errors.Is(err, services.ErrSSONotConfigured):
+			response.NotFound(c, "Single sign-on is not configured")
+		case errors.Is(err, services.ErrInvalidSSOState):
+			response.Error(c, 400, "INVALID_STATE", "This sign-in attempt has expired. Please try again.")
+		case errors.Is(err, services.ErrSSOFailed):
+			h.logActivity(c, models.ActivityLoginFailed, userID, identifier, false, err.Error())
+			response.Error(c, 401, "SSO_FAILED", "Single sign-on failed. Please try again.")
+		case errors.Is(err, services.ErrSSOAccountConflict), errors.Is(err, services.ErrEmployeeNumberExists):
+			h.logActivity(c, models.ActivityLoginRefused, userID, identifier, false, err.Error())
+			response.Error(c, 409, "SSO_ACCOUNT_CONFLICT", "Your identity provider account can't be linked to an account here. Please contact the administrator.")
+		default:
+			h.respondLoginError(c, userID, identifier, err)
+		}
+		return
+	}
+
+	response.Success(c, h.newLoginResponse(user, tokens))
+}
+
+// setSSOBindingCookie sets the binding cookie, or deletes it if maxAge is negative
+func setSSOBindingCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoBindingCookie, value, maxAge, ssoCookiePath, "", secure, true)
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorSetupLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorEnabledLoginResponse is the login response after setting up
+// two-factor authentication at login
+type TwoFactorEnabledLoginResponse struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"` // The user's role requires it, so it can't be turned off
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code
+// @Summary Verify two-factor code
+// @Description Exchanges the two-factor token from login and a TOTP or recovery code for a session
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "Two-factor token and code"
+// @Success 200 {object} LoginResponse
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /api/v1/auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	tokens, user, err := h.authService.VerifyTwoFactor(req.TwoFactorToken, req.Code, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.respondTwoFactorLoginError(c, user, err)
+		return
+	}
+
+	response.Success(c, h.newLoginResponse(user, tokens))
+}
+
+// BeginTwoFactorSetupAtLogin starts two-factor setup for a user whose role
+// requires it before they can log in
+// @Summary Start two-factor setup at login
+// @Description Returns a new TOTP secret and provisioning URI for a user who must enroll before logging in
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorSetupLoginRequest true "Two-factor token"
+// @Success 200 {object} services.TwoFactorSetup
+// @Failure 401 {object} response.Response
+// @Router /api/v1/auth/2fa/setup [post]
+func (h *AuthHandler) BeginTwoFactorSetupAtLogin(c *gin.Context) {
+	var req TwoFactorSetupLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	setup, err := h.authService.BeginTwoFactorSetupAtLogin(req.TwoFactorToken)
+	if err != nil {
+		h.respondTwoFactorLoginError(c, nil, err)
+		return
+	}
+
+	response.Success(c, setup)
+}
+
+// EnableTwoFactorAtLogin finishes two-factor setup at login and logs the user in
+// @Summary Finish two-factor setup at login
+// @Description Confirms the authenticator with a TOTP code, enables two-factor authentication and starts a session. The recovery codes are only returned once.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "Two-factor token and TOTP code"
+// @Success 200 {object} TwoFactorEnabledLoginResponse
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /api/v1/auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactorAtLogin(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	tokens, user, codes, err := h.authService.EnableTwoFactorAtLogin(req.TwoFactorToken, req.Code, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.respondTwoFactorLoginError(c, user, err)
+		return
+	}
+
+	h.logActivity(c, models.ActivityTwoFactorEnabled, &user.ID, user.Email, true, "Set up at login")
+
+	response.Success(c, TwoFactorEnabledLoginResponse{
+		LoginResponse: h.newLoginResponse(user, tokens),
+		RecoveryCodes: codes,
+	})
+}
+
+// respondTwoFactorLoginError answers a failed second login step. user is set
+// when the two-factor token was valid.
+func (h *AuthHandler) respondTwoFactorLoginError(c *gin.Context, user *models.User, err error) {
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		var userID *uint
+		identifier := ""
+		if user != nil {
+			userID, identifier = &user.ID, user.Email
+		}
+		h.respondThrottled(c, userID, identifier, throttled)
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		h.logActivity(c, models.ActivityLoginFailed, &user.ID, user.Email, false, "Invalid two-factor code")
+		response.Error(c, http.StatusUnauthorized, "INVALID_CODE", "Invalid verification code")
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, services.ErrTwoFactorNotStarted):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, services.ErrUserDisabled), errors.Is(err, services.ErrUserNotFound):
+		response.Unauthorized(c, "Your account can't log in")
+	default:
+		if user == nil {
+			// The two-factor token is invalid or expired
+			response.Unauthorized(c, "Your login has expired. Please log in again.")
+			return
+		}
+		log.Printf("Failed to verify two-factor login for user %d: %v", user.ID, err)
+		response.InternalServerError(c, "Login failed")
+	}
+}
+
+// GetTwoFactorStatus returns the current user's two-factor settings
+// @Summary Get two-factor status
+// @Tags Profile
+// @Security BearerAuth
+// @Success 200 {object} TwoFactorStatusResponse
+// @Router /api/v1/profile/2fa [get]
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	remaining, err := h.authService.RemainingRecoveryCodes(user.ID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch two-factor status")
+		return
+	}
+
+	response.Success(c, TwoFactorStatusResponse{
+		Enabled:                user.TwoFactorEnabled(),
+		Required:               h.authService.RequiresTwoFactor(user),
+		EnabledAt:              user.TOTPEnabledAt,
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// BeginTwoFactorSetup starts two-factor setup for the current user
+// @Summary Start two-factor setup
+// @Description Returns a new TOTP secret and provisioning URI. Two-factor authentication is enabled once a code from it is confirmed.
+// @Tags Profile
+// @Security BearerAuth
+// @Success 200 {object} services.TwoFactorSetup
+// @Failure 400 {object} response.Response
+// @Router /api/v1/profile/2fa/setup [post]
+func (h *AuthHandler) BeginTwoFactorSetup(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.authService.BeginTwoFactorSetup(user)
+	if err != nil {
+		h.respondTwoFactorError(c, user, err)
+		return
+	}
+
+	response.Success(c, setup)
+}
+
+// EnableTwoFactor confirms the authenticator and enables two-factor authentication
+// @Summary Enable two-factor authentication
+// @Description Enables two-factor authentication with a TOTP code from the authenticator being set up. The recovery codes are only returned once.
+// @Tags Profile
+// @Security BearerAuth
+// @Accept json
+// @Param request body TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} response.Response
+// @Router /api/v1/profile/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok || !h.checkTwoFactorThrottle(c, user) {
+		return
+	}
+
+	codes, err := h.authService.EnableTwoFactor(user, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(c, user, err)
+		return
+	}
+
+	h.logActivity(c, models.ActivityTwoFactorEnabled, &user.ID, user.Email, true, "")
+
+	response.SuccessWithMessage(c, "Two-factor authentication enabled", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes after checking a TOTP code. The old codes stop working.
+// @Tags Profile
+// @Security BearerAuth
+// @Accept json
+// @Param request body TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} response.Response
+// @Router /api/v1/profile/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok || !h.checkTwoFactorThrottle(c, user) {
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(user, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(c, user, err)
+		return
+	}
+
+	response.Success(c, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns off two-factor authentication for the current user
+// @Summary Disable two-factor authentication
+// @Description Turns off two-factor authentication after checking a TOTP or recovery code. Not allowed if the user's role requires it.
+// @Tags Profile
+// @Security BearerAuth
+// @Accept json
+// @Param request body TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/profile/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok || !h.checkTwoFactorThrottle(c, user) {
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(user, req.Code); err != nil {
+		h.respondTwoFactorError(c, user, err)
+		return
+	}
+
+	h.logActivity(c, models.ActivityTwoFactorDisabled, &user.ID, user.Email, true, "Turned off by user")
+
+	response.SuccessWithMessage(c, "Two-factor authentication disabled", nil)
+}
+
+// ResetUserTwoFactor removes a user's two-factor authentication (admin)
+// @Summary Reset a user's two-factor authentication
+// @Description For users who lost their authenticator and recovery codes. Ends their sessions; if their role requires two-factor authentication they set it up again at their next login.
+// @Tags Users
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/users/{id}/2fa [delete]
+func (h *AuthHandler) ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var user models.User
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+
+	var admin models.User
+	if err := h.db.First(&admin, middleware.GetUserID(c)).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch user")
+		return
+	}
+
+	if err := h.authService.ResetTwoFactor(user.ID, "Two-factor authentication reset"); err != nil {
+		log.Printf("Failed to reset two-factor authentication for user %d: %v", user.ID, err)
+		response.InternalServerError(c, "Failed to reset two-factor authentication")
+		return
+	}
+
+	h.logActivity(c, models.ActivityTwoFactorDisabled, &user.ID, user.Email, true, "Reset by admin: "+admin.Name)
+
+	response.SuccessWithMessage(c, "Two-factor authentication reset", nil)
+}
+
+// currentUser loads the authenticated user, responding if that fails
+func (h *AuthHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	user, err := h.authService.GetUserByID(middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "User not found")
+		return nil, false
+	}
+	return user, true
+}
+
+// checkTwoFactorThrottle refuses code checks while the user's logins are
+// throttled, so codes can't be guessed from a logged-in session either
+func (h *AuthHandler) checkTwoFactorThrottle(c *gin.Context, user *models.User) bool {
+	err := h.authService.CheckLoginThrottle(user.Email, c.ClientIP())
+	if err == nil {
+		return true
+	}
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		h.respondThrottled(c, &user.ID, user.Email, throttled)
+	} else {
+		response.InternalServerError(c, "Failed to verify code")
+	}
+	return false
+}
+
+// respondTwoFactorError answers a failed two-factor profile change
+func (h *AuthHandler) respondTwoFactorError(c *gin.Context, user *models.User, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		h.logActivity(c, models.ActivityLoginFailed, &user.ID, user.Email, false, "Invalid two-factor code")
+		response.Error(c, http.StatusBadRequest, "INVALID_CODE", "Invalid verification code")
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		response.Forbidden(c, "Two-factor authentication is required for your role")
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, services.ErrTwoFactorNotStarted):
+		response.BadRequest(c, err.Error())
+	default:
+		log.Printf("Failed to update two-factor authentication for user %d: %v", user.ID, err)
+		response.InternalServerError(c, "Failed to update two-factor authentication")
+	}
+}
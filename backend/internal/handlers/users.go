@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"strconv"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services"
+	"vista-backend/pkg/i18n"
 	"vista-backend/pkg/response"
 )
 
@@ -25,21 +27,27 @@ type CreateUserRequest struct {
 	Email          string `json:"email" binding:"required,email"`
 	Password       string `json:"password" binding:"required,min=6"`
 	Name           string `json:"name" binding:"required"`
-	Role           string `json:"role" binding:"required,oneof=admin purchase_admin supply_chain_manager general_manager employee"`
+	Role           string `json:"role" binding:"required"`
 	CompanyCode    string `json:"company_code"`
 	CostCenter     string `json:"cost_center"`
 	Department     string `json:"department"`
+	Language       string `json:"language"`
 }
 
 type UpdateUserRequest struct {
 	EmployeeNumber string `json:"employee_number" binding:"omitempty,min=1,max=50"`
 	Email          string `json:"email" binding:"omitempty,email"`
 	Name           string `json:"name"`
-	Role           string `json:"role" binding:"omitempty,oneof=admin purchase_admin supply_chain_manager general_manager employee"`
+	Role           string `json:"role"`
 	CompanyCode    string `json:"company_code"`
 	CostCenter     string `json:"cost_center"`
 	Department     string `json:"department"`
 	Status         string `json:"status" binding:"omitempty,oneof=pending approved rejected disabled"`
+	Language       string `json:"language"`
+}
+
+type UpdateLanguageRequest struct {
+	Language string `json:"language" binding:"required"`
 }
 
 type ChangePasswordRequest struct {
@@ -48,7 +56,7 @@ type ChangePasswordRequest struct {
 }
 
 type ApproveUserRequest struct {
-	Role        string `json:"role" binding:"required,oneof=admin purchase_admin supply_chain_manager general_manager employee"`
+	Role        string `json:"role" binding:"required"`
 	CompanyCode string `json:"company_code"`
 	CostCenter  string `json:"cost_center"`
 	Department  string `json:"department"`
@@ -77,7 +85,8 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 
 	offset := (page - 1) * perPage
 
-	query := h.db.Model(&models.User{})
+	// Service accounts are listed under /admin/service-accounts
+	query := companyUsers(c, h.db.Model(&models.User{})).Where("service_account = ?", false)
 
 	if search != "" {
 		query = query.Where("name LIKE ? OR email LIKE ? OR employee_number LIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
@@ -110,6 +119,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 			CostCenter:     user.CostCenter,
 			Department:     user.Department,
 			Status:         string(user.Status),
+			Language:       user.Language,
 		}
 	}
 
@@ -130,7 +140,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.db.First(&user, id).Error; err != nil {
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "User not found")
 		} else {
@@ -149,6 +159,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		CostCenter:     user.CostCenter,
 		Department:     user.Department,
 		Status:         string(user.Status),
+		Language:       user.Language,
 	})
 }
 
@@ -159,6 +170,17 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if req.Language != "" && !i18n.IsEnabled(req.Language) {
+		response.BadRequest(c, "Unsupported language")
+		return
+	}
+	if !validRole(c, h.db, req.Role) || !validCompany(c, h.db, req.CompanyCode) {
+		return
+	}
+	// New users join the company they're created in unless given another
+	if req.CompanyCode == "" {
+		req.CompanyCode = middleware.GetCompany(c)
+	}
 
 	// Check if employee number already exists
 	var existingUser models.User
@@ -193,6 +215,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		CompanyCode:    req.CompanyCode,
 		CostCenter:     req.CostCenter,
 		Department:     req.Department,
+		Language:       i18n.Normalize(req.Language),
 		Status:         models.UserStatusApproved, // Admin-created users are pre-approved
 		ApprovedByID:   &adminID,
 		ApprovedAt:     &now,
@@ -213,6 +236,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		CostCenter:     user.CostCenter,
 		Department:     user.Department,
 		Status:         string(user.Status),
+		Language:       user.Language,
 	})
 }
 
@@ -225,7 +249,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.db.First(&user, id).Error; err != nil {
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "User not found")
 		} else {
@@ -239,6 +263,18 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if req.Role != "" && req.Role != string(user.Role) {
+		if !validRole(c, h.db, req.Role) {
+			return
+		}
+		if !canGrantRole(c, h.db, user.Role) {
+			response.Forbidden(c, "You can't change the role of a user with permissions you don't have")
+			return
+		}
+	}
+	if !validCompany(c, h.db, req.CompanyCode) {
+		return
+	}
 
 	// Check if employee number already exists (if being changed)
 	if req.EmployeeNumber != "" && req.EmployeeNumber != user.EmployeeNumber {
@@ -278,12 +314,25 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	if req.Status != "" {
 		user.Status = models.UserStatus(req.Status)
 	}
+	if req.Language != "" {
+		if !i18n.IsEnabled(req.Language) {
+			response.BadRequest(c, "Unsupported language")
+			return
+		}
+		user.Language = i18n.Normalize(req.Language)
+	}
 
 	if err := h.db.Save(&user).Error; err != nil {
 		response.InternalServerError(c, "Failed to update user")
 		return
 	}
 
+	if !user.CanLogin() {
+		if err := models.EndUserSessions(h.db, user.ID, "User "+string(user.Status)); err != nil {
+			log.Printf("Failed to end sessions of user %d: %v", user.ID, err)
+		}
+	}
+
 	response.Success(c, UserResponse{
 		ID:             user.ID,
 		EmployeeNumber: user.EmployeeNumber,
@@ -294,6 +343,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		CostCenter:     user.CostCenter,
 		Department:     user.Department,
 		Status:         string(user.Status),
+		Language:       user.Language,
 	})
 }
 
@@ -313,7 +363,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.db.First(&user, id).Error; err != nil {
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "User not found")
 		} else {
@@ -327,6 +377,11 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	// Sign the user out everywhere
+	if err := models.EndUserSessions(h.db, user.ID, "User deleted"); err != nil {
+		log.Printf("Failed to end sessions of user %d: %v", user.ID, err)
+	}
+
 	response.SuccessWithMessage(c, "User deleted successfully", nil)
 }
 
@@ -346,7 +401,7 @@ func (h *UserHandler) ToggleUserStatus(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.db.First(&user, id).Error; err != nil {
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "User not found")
 		} else {
@@ -367,6 +422,12 @@ func (h *UserHandler) ToggleUserStatus(c *gin.Context) {
 		return
 	}
 
+	if !user.CanLogin() {
+		if err := models.EndUserSessions(h.db, user.ID, "User disabled"); err != nil {
+			log.Printf("Failed to end sessions of user %d: %v", user.ID, err)
+		}
+	}
+
 	response.SuccessWithMessage(c, "User status updated", UserResponse{
 		ID:             user.ID,
 		EmployeeNumber: user.EmployeeNumber,
@@ -377,6 +438,7 @@ func (h *UserHandler) ToggleUserStatus(c *gin.Context) {
 		CostCenter:     user.CostCenter,
 		Department:     user.Department,
 		Status:         string(user.Status),
+		Language:       user.Language,
 	})
 }
 
@@ -422,10 +484,42 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	response.SuccessWithMessage(c, "Password changed successfully", nil)
 }
 
+// UpdateLanguage sets the current user's preferred language
+func (h *UserHandler) UpdateLanguage(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		response.Unauthorized(c, "Authentication required")
+		return
+	}
+
+	var req UpdateLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if !i18n.IsEnabled(req.Language) {
+		response.BadRequest(c, "Unsupported language")
+		return
+	}
+
+	language := i18n.Normalize(req.Language)
+	result := h.db.Model(&models.User{}).Where("id = ?", userID).Update("language", language)
+	if result.Error != nil {
+		response.InternalServerError(c, "Failed to update language")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Language updated successfully", gin.H{"language": language})
+}
+
 // ListPendingUsers returns a list of users with pending status
 func (h *UserHandler) ListPendingUsers(c *gin.Context) {
 	var users []models.User
-	if err := h.db.Where("status = ?", models.UserStatusPending).Order("created_at ASC").Find(&users).Error; err != nil {
+	if err := companyUsers(c, h.db).Where("status = ?", models.UserStatusPending).Order("created_at ASC").Find(&users).Error; err != nil {
 		response.InternalServerError(c, "Failed to fetch pending users")
 		return
 	}
@@ -448,7 +542,7 @@ func (h *UserHandler) ListPendingUsers(c *gin.Context) {
 // GetPendingUsersCount returns count of pending users for badge
 func (h *UserHandler) GetPendingUsersCount(c *gin.Context) {
 	var count int64
-	if err := h.db.Model(&models.User{}).Where("status = ?", models.UserStatusPending).Count(&count).Error; err != nil {
+	if err := companyUsers(c, h.db.Model(&models.User{})).Where("status = ?", models.UserStatusPending).Count(&count).Error; err != nil {
 		response.InternalServerError(c, "Failed to count pending users")
 		return
 	}
@@ -465,7 +559,7 @@ func (h *UserHandler) ApproveUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.db.First(&user, id).Error; err != nil {
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "User not found")
 		} else {
@@ -485,6 +579,9 @@ func (h *UserHandler) ApproveUser(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if !validRole(c, h.db, req.Role) || !validCompany(c, h.db, req.CompanyCode) {
+		return
+	}
 
 	// Update user status and role
 	adminID := middleware.GetUserID(c)
@@ -521,6 +618,7 @@ func (h *UserHandler) ApproveUser(c *gin.Context) {
 		CostCenter:     user.CostCenter,
 		Department:     user.Department,
 		Status:         string(user.Status),
+		Language:       user.Language,
 	})
 }
 
@@ -533,7 +631,7 @@ func (h *UserHandler) RejectUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.db.First(&user, id).Error; err != nil {
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "User not found")
 		} else {
@@ -573,16 +671,53 @@ func (h *UserHandler) RejectUser(c *gin.Context) {
 		CostCenter:     user.CostCenter,
 		Department:     user.Department,
 		Status:         string(user.Status),
+		Language:       user.Language,
 	})
 }
 
+// UnlockUser lifts a lockout caused by repeated failed logins. Failures
+// before the unlock no longer count against the account.
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var user models.User
+	if err := companyUsers(c, h.db).First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+
+	var admin models.User
+	if err := h.db.First(&admin, middleware.GetUserID(c)).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch user")
+		return
+	}
+
+	entry := models.NewActivityLog(models.ActivityLoginUnlocked, &user.ID, user.Email, c.ClientIP(), c.GetHeader("User-Agent")).
+		WithDetails("Unlocked by admin: " + admin.Name)
+	if err := h.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to unlock user %d: %v", user.ID, err)
+		response.InternalServerError(c, "Failed to unlock user")
+		return
+	}
+
+	response.SuccessWithMessage(c, "User unlocked", nil)
+}
+
 // BulkImportUser represents a single user in bulk import
 type BulkImportUser struct {
 	EmployeeNumber string `json:"employee_number" binding:"required"`
 	Email          string `json:"email" binding:"required,email"`
 	Password       string `json:"password" binding:"required,min=6"`
 	Name           string `json:"name" binding:"required"`
-	Role           string `json:"role" binding:"required,oneof=admin purchase_admin supply_chain_manager general_manager employee"`
+	Role           string `json:"role" binding:"required"`
 	CompanyCode    string `json:"company_code"`
 	CostCenter     string `json:"cost_center"`
 	Department     string `json:"department"`
@@ -621,6 +756,31 @@ func (h *UserHandler) BulkImportUsers(c *gin.Context) {
 			Email:          u.Email,
 		}
 
+		if !models.RoleExists(h.db, models.UserRole(u.Role)) {
+			result.Error = "Invalid role: " + u.Role
+			results[i] = result
+			continue
+		}
+		if !canGrantRole(c, h.db, models.UserRole(u.Role)) {
+			result.Error = "You can't give users a role with permissions you don't have: " + u.Role
+			results[i] = result
+			continue
+		}
+		if u.CompanyCode != "" && !models.CompanyExists(h.db, u.CompanyCode) {
+			result.Error = "Invalid company: " + u.CompanyCode
+			results[i] = result
+			continue
+		}
+		if u.CompanyCode != "" && u.CompanyCode != middleware.GetCompany(c) && !middleware.HasPermission(c, models.PermCompaniesManage) {
+			result.Error = "You can't put users in another company: " + u.CompanyCode
+			results[i] = result
+			continue
+		}
+
+		if u.CompanyCode == "" {
+			u.CompanyCode = middleware.GetCompany(c)
+		}
+
 		// Check if employee number already exists
 		var existingUser models.User
 		if err := h.db.Where("employee_number = ?", u.EmployeeNumber).First(&existingUser).Error; err == nil {
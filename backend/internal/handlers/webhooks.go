@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/webhooks"
+	"vista-backend/pkg/crypto"
+	"vista-backend/pkg/response"
+)
+
+type WebhookHandler struct {
+	db            *gorm.DB
+	encryptionSvc *crypto.EncryptionService
+}
+
+func NewWebhookHandler(db *gorm.DB, encryptionSvc *crypto.EncryptionService) *WebhookHandler {
+	return &WebhookHandler{db: db, encryptionSvc: encryptionSvc}
+}
+
+// WebhookSubscriptionRequest is the body for creating or updating a subscription
+type WebhookSubscriptionRequest struct {
+	Name        *string               `json:"name"`
+	URL         *string               `json:"url"`
+	Description *string               `json:"description"`
+	Events      *models.WebhookEvents `json:"events"` // Empty or omitted on create subscribes to every event
+	IsActive    *bool                 `json:"is_active"`
+	Secret      *string               `json:"secret"` // Only on create; generated if omitted
+}
+
+// WebhookSubscriptionResponse is a subscription. Secret is only included
+// when it was just created or rotated.
+type WebhookSubscriptionResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDeliveryResponse is a delivery log entry
+type WebhookDeliveryResponse struct {
+	ID             uint                         `json:"id"`
+	SubscriptionID uint                         `json:"subscription_id"`
+	EventID        string                       `json:"event_id"`
+	Event          models.WebhookEvent          `json:"event"`
+	RequestID      *uint                        `json:"request_id,omitempty"`
+	RedeliveryOfID *uint                        `json:"redelivery_of_id,omitempty"`
+	Status         models.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                          `json:"attempts"`
+	MaxAttempts    int                          `json:"max_attempts"`
+	NextAttemptAt  time.Time                    `json:"next_attempt_at"`
+	ResponseStatus int                          `json:"response_status,omitempty"`
+	LastError      string                       `json:"last_error,omitempty"`
+	DurationMs     int64                        `json:"duration_ms,omitempty"`
+	DeliveredAt    *time.Time                   `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                    `json:"created_at"`
+
+	// Only included when fetching a single delivery
+	Payload      string `json:"payload,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+func webhookDeliveryToResponse(d models.WebhookDelivery, withBody bool) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		RequestID:      d.RequestID,
+		RedeliveryOfID: d.RedeliveryOfID,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		MaxAttempts:    d.MaxAttempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		DurationMs:     d.DurationMs,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+	if withBody {
+		resp.Payload = d.Payload
+		resp.ResponseBody = d.ResponseBody
+	}
+	return resp
+}
+
+// validateWebhookURL requires an absolute http(s) URL
+func validateWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// validateWebhookEvents checks every event is one subscriptions can filter on
+func validateWebhookEvents(events models.WebhookEvents) bool {
+	for _, event := range events {
+		if !event.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// loadSubscription fetches the subscription in the :id parameter, writing
+// the error response if it can't
+func (h *WebhookHandler) loadSubscription(c *gin.Context) (*models.WebhookSubscription, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid webhook ID")
+		return nil, false
+	}
+
+	var sub models.WebhookSubscription
+	if err := h.db.First(&sub, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Webhook not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch webhook")
+		}
+		return nil, false
+	}
+	return &sub, true
+}
+
+// GetWebhookEvents lists the events subscriptions can filter on
+func (h *WebhookHandler) GetWebhookEvents(c *gin.Context) {
+	response.Success(c, models.AllWebhookEvents)
+}
+
+// ListWebhooks returns all webhook subscriptions
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	var subs []models.WebhookSubscription
+	if err := h.db.Order("created_at DESC").Find(&subs).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch webhooks")
+		return
+	}
+	response.Success(c, subs)
+}
+
+// GetWebhook returns one webhook subscription
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+	response.Success(c, sub)
+}
+
+// CreateWebhook registers a new subscription and returns its signing secret
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		response.BadRequest(c, "Name is required")
+		return
+	}
+	if req.URL == nil || !validateWebhookURL(*req.URL) {
+		response.BadRequest(c, "A valid http or https URL is required")
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		Name:     strings.TrimSpace(*req.Name),
+		URL:      *req.URL,
+		Events:   models.WebhookEvents{},
+		IsActive: true,
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	if req.Events != nil {
+		if !validateWebhookEvents(*req.Events) {
+			response.BadRequest(c, "Invalid event in events")
+			return
+		}
+		sub.Events = *req.Events
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	secret := ""
+	if req.Secret != nil && *req.Secret != "" {
+		if len(*req.Secret) < 16 {
+			response.BadRequest(c, "Secret must be at least 16 characters")
+			return
+		}
+		secret = *req.Secret
+	} else {
+		generated, err := webhooks.GenerateSecret()
+		if err != nil {
+			response.InternalServerError(c, "Failed to generate secret")
+			return
+		}
+		secret = generated
+	}
+	encrypted, err := h.encryptionSvc.Encrypt(secret)
+	if err != nil {
+		response.InternalServerError(c, "Failed to encrypt secret")
+		return
+	}
+	sub.Secret = encrypted
+
+	userID := middleware.GetUserID(c)
+	sub.CreatedByID = &userID
+
+	if err := h.db.Create(&sub).Error; err != nil {
+		response.InternalServerError(c, "Failed to create webhook")
+		return
+	}
+
+	response.Created(c, WebhookSubscriptionResponse{WebhookSubscription: sub, Secret: secret})
+}
+
+// UpdateWebhook changes a subscription's settings
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			response.BadRequest(c, "Name is required")
+			return
+		}
+		sub.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		if !validateWebhookURL(*req.URL) {
+			response.BadRequest(c, "A valid http or https URL is required")
+			return
+		}
+		sub.URL = *req.URL
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	if req.Events != nil {
+		if !validateWebhookEvents(*req.Events) {
+			response.BadRequest(c, "Invalid event in events")
+			return
+		}
+		sub.Events = *req.Events
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	if err := h.db.Save(sub).Error; err != nil {
+		response.InternalServerError(c, "Failed to update webhook")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Webhook updated", sub)
+}
+
+// DeleteWebhook removes a subscription. Its delivery log is kept.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(sub).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete webhook")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Webhook deleted successfully", nil)
+}
+
+// RotateWebhookSecret replaces a subscription's signing secret and returns the new one
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		response.InternalServerError(c, "Failed to generate secret")
+		return
+	}
+	encrypted, err := h.encryptionSvc.Encrypt(secret)
+	if err != nil {
+		response.InternalServerError(c, "Failed to encrypt secret")
+		return
+	}
+
+	if err := h.db.Model(sub).Update("secret", encrypted).Error; err != nil {
+		response.InternalServerError(c, "Failed to rotate secret")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Webhook secret rotated", WebhookSubscriptionResponse{WebhookSubscription: *sub, Secret: secret})
+}
+
+// TestWebhook queues a webhook.ping delivery to the subscription
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := webhooks.Ping(h.db, sub)
+	if err != nil {
+		response.InternalServerError(c, "Failed to queue test delivery")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Test delivery queued", webhookDeliveryToResponse(*delivery, false))
+}
+
+// ListWebhookDeliveries returns the delivery log, newest first. Filters:
+// subscription_id (or the :id route parameter), status, event, request_id.
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+
+	query := h.db.Model(&models.WebhookDelivery{})
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		subscriptionID = c.Query("subscription_id")
+	}
+	if subscriptionID != "" {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	if requestID := c.Query("request_id"); requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var deliveries []models.WebhookDelivery
+	offset := (page - 1) * perPage
+	if err := query.Omit("payload", "response_body").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(perPage).
+		Find(&deliveries).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch webhook deliveries")
+		return
+	}
+
+	responses := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		responses[i] = webhookDeliveryToResponse(d, false)
+	}
+
+	response.SuccessWithMeta(c, responses, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// GetWebhookDelivery returns one delivery including its payload and response
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid delivery ID")
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	if err := h.db.First(&delivery, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Delivery not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch delivery")
+		}
+		return
+	}
+
+	response.Success(c, webhookDeliveryToResponse(delivery, true))
+}
+
+// RedeliverWebhook queues the payload of a finished delivery again
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := webhooks.Redeliver(h.db, uint(id))
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			response.NotFound(c, "Delivery not found")
+		case webhooks.ErrStillPending:
+			response.Conflict(c, "Delivery is still pending")
+		default:
+			response.InternalServerError(c, "Failed to redeliver")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "Delivery queued", webhookDeliveryToResponse(*delivery, false))
+}
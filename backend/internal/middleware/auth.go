@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"vista-backend/internal/models"
 	"vista-backend/pkg/jwt"
 	"vista-backend/pkg/response"
 )
@@ -14,11 +16,48 @@ const (
 	UserIDKey           = "user_id"
 	UserEmailKey        = "user_email"
 	UserRoleKey         = "user_role"
+	SessionIDKey        = "session_id"
+	APIKeyIDKey         = "api_key_id"
+
+	// APIKeyHeader carries a service account's API key. Keys are also
+	// accepted as Bearer tokens.
+	APIKeyHeader = "X-API-Key"
+	// apiBasePath is stripped from route paths to find the scope they need
+	apiBasePath = "/api/v1"
 )
 
-// Auth returns an authentication middleware
-func Auth(jwtService *jwt.JWTService) gin.HandlerFunc {
+// SessionValidator checks that the session a token was issued for hasn't
+// ended, so logged out and revoked tokens stop working before they expire
+type SessionValidator interface {
+	ValidateSession(claims *jwt.Claims) error
+}
+
+// APIKeyAuthenticator checks the API keys of service accounts and records
+// their use
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (*models.APIKey, error)
+	RecordAPIKeyUse(apiKey *models.APIKey, method, path string, status int, ipAddress, userAgent string)
+}
+
+// Authenticator validates both kinds of credentials Auth accepts and loads
+// the permissions of the caller's role and their company
+type Authenticator interface {
+	SessionValidator
+	APIKeyAuthenticator
+	PermissionResolver
+	CompanyResolver
+}
+
+// Auth returns an authentication middleware. Requests carry either a user's
+// access token or a service account's API key; keys only work on routes
+// their scopes cover. Requests are scoped to the caller's company.
+func Auth(jwtService *jwt.JWTService, auth Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := apiKeyFromRequest(c); key != "" {
+			authenticateAPIKey(c, auth, key)
+			return
+		}
+
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
 			response.Unauthorized(c, "Authorization header is required")
@@ -46,15 +85,103 @@ func Auth(jwtService *jwt.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		if err := auth.ValidateSession(claims); err != nil {
+			response.Unauthorized(c, "Session has ended")
+			c.Abort()
+			return
+		}
+
 		// Store user info in context
 		c.Set(UserIDKey, claims.UserID)
 		c.Set(UserEmailKey, claims.Email)
 		c.Set(UserRoleKey, claims.Role)
+		c.Set(SessionIDKey, claims.SessionID)
+		c.Set(PermissionsKey, auth.RolePermissions(models.UserRole(claims.Role)))
+		if !setCompany(c, auth) {
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// apiKeyFromRequest returns the API key the request carries, if any
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	parts := strings.SplitN(c.GetHeader(AuthorizationHeader), " ", 2)
+	if len(parts) == 2 && parts[0] == BearerSchema && strings.HasPrefix(parts[1], models.APIKeyPrefix) {
+		return parts[1]
+	}
+	return ""
+}
+
+// authenticateAPIKey lets the request through as the key's service account
+// if the key is valid and has the scope the route needs. Every request made
+// with a valid key is recorded, including refused ones.
+func authenticateAPIKey(c *gin.Context, keys Authenticator, key string) {
+	apiKey, err := keys.AuthenticateAPIKey(key)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrAPIKeyExpired):
+			response.Unauthorized(c, "API key has expired")
+		case errors.Is(err, models.ErrServiceAccountDisabled):
+			response.Unauthorized(c, "Service account is disabled")
+		case errors.Is(err, models.ErrInvalidAPIKey):
+			response.Unauthorized(c, "Invalid API key")
+		default:
+			response.InternalServerError(c, "Failed to check API key")
+		}
+		c.Abort()
+		return
+	}
+	defer func() {
+		keys.RecordAPIKeyUse(apiKey, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), c.ClientIP(), c.Request.UserAgent())
+	}()
+
+	scope, ok := models.APIScopeFor(c.Request.Method, strings.TrimPrefix(c.FullPath(), apiBasePath))
+	if !ok {
+		response.Forbidden(c, "API keys can't be used for this endpoint")
+		c.Abort()
+		return
+	}
+	if !apiKey.HasScope(scope) {
+		response.Forbidden(c, "API key is missing the "+scope+" scope")
+		c.Abort()
+		return
+	}
+
+	account := apiKey.ServiceAccount
+	c.Set(UserIDKey, account.ID)
+	c.Set(UserEmailKey, account.EmployeeNumber)
+	c.Set(UserRoleKey, string(account.Role))
+	c.Set(APIKeyIDKey, apiKey.ID)
+	c.Set(PermissionsKey, keys.RolePermissions(account.Role))
+	if !setCompany(c, keys) {
+		return
+	}
+
+	c.Next()
+}
+
+// AccessTokenQueryParam carries the access token for clients that can't set
+// headers, such as the browser EventSource API
+const AccessTokenQueryParam = "access_token"
+
+// TokenFromQuery lets Auth accept the access token from the query string.
+// Only use it on routes that need it; query strings end up in logs and history.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(AuthorizationHeader) == "" {
+			if token := c.Query(AccessTokenQueryParam); token != "" {
+				c.Request.Header.Set(AuthorizationHeader, BearerSchema+" "+token)
+			}
+		}
+		c.Next()
+	}
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c *gin.Context) uint {
 	if userID, exists := c.Get(UserIDKey); exists {
@@ -79,8 +206,25 @@ func GetUserRole(c *gin.Context) string {
 	return ""
 }
 
+// GetAPIKeyID returns the ID of the API key the request was made with, or 0
+// for requests made with an access token
+func GetAPIKeyID(c *gin.Context) uint {
+	if keyID, exists := c.Get(APIKeyIDKey); exists {
+		return keyID.(uint)
+	}
+	return 0
+}
+
+// GetSessionID extracts the session ID from context
+func GetSessionID(c *gin.Context) string {
+	if sessionID, exists := c.Get(SessionIDKey); exists {
+		return sessionID.(string)
+	}
+	return ""
+}
+
 // OptionalAuth is middleware that doesn't require auth but sets user info if present
-func OptionalAuth(jwtService *jwt.JWTService) gin.HandlerFunc {
+func OptionalAuth(jwtService *jwt.JWTService, sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
@@ -96,10 +240,11 @@ func OptionalAuth(jwtService *jwt.JWTService) gin.HandlerFunc {
 
 		token := parts[1]
 		claims, err := jwtService.ValidateAccessToken(token)
-		if err == nil {
+		if err == nil && sessions.ValidateSession(claims) == nil {
 			c.Set(UserIDKey, claims.UserID)
 			c.Set(UserEmailKey, claims.Email)
 			c.Set(UserRoleKey, claims.Role)
+			c.Set(SessionIDKey, claims.SessionID)
 		}
 
 		c.Next()
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/response"
+)
+
+const (
+	// CompanyKey holds the code of the company the request works in
+	CompanyKey = "company_code"
+
+	// CompanyHeader lets users with companies.manage work in another
+	// company than their own
+	CompanyHeader = "X-Company"
+)
+
+// CompanyResolver finds the company a user belongs to
+type CompanyResolver interface {
+	UserCompany(userID uint) (string, error)
+	CompanyExists(code string) bool
+}
+
+// setCompany scopes the request to the user's company, or to the company in
+// the X-Company header for users who can switch. It responds with an error
+// and returns false if the request can't go on.
+func setCompany(c *gin.Context, companies CompanyResolver) bool {
+	company, err := companies.UserCompany(GetUserID(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to load company")
+		c.Abort()
+		return false
+	}
+
+	if requested := c.GetHeader(CompanyHeader); requested != "" && requested != company {
+		if !HasPermission(c, models.PermCompaniesManage) {
+			response.Forbidden(c, "You can't work in another company")
+			c.Abort()
+			return false
+		}
+		if !companies.CompanyExists(requested) {
+			response.BadRequest(c, "Unknown company: "+requested)
+			c.Abort()
+			return false
+		}
+		company = requested
+	}
+
+	c.Set(CompanyKey, company)
+	return true
+}
+
+// GetCompany returns the code of the company the request works in
+func GetCompany(c *gin.Context) string {
+	if company, exists := c.Get(CompanyKey); exists {
+		return company.(string)
+	}
+	return ""
+}
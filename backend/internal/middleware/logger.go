@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"log"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +13,7 @@ func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
@@ -35,6 +36,19 @@ func Logger() gin.HandlerFunc {
 	}
 }
 
+// redactQuery hides access tokens passed in the query string
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil || values.Get(AccessTokenQueryParam) == "" {
+		return rawQuery
+	}
+	values.Set(AccessTokenQueryParam, "REDACTED")
+	return values.Encode()
+}
+
 // Recovery returns a recovery middleware that recovers from panics
 func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
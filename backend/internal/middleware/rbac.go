@@ -6,96 +6,49 @@ import (
 	"vista-backend/pkg/response"
 )
 
-// RequireRole returns middleware that requires specific roles
-func RequireRole(allowedRoles ...models.UserRole) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userRole := GetUserRole(c)
-		if userRole == "" {
-			response.Unauthorized(c, "Authentication required")
-			c.Abort()
-			return
-		}
-
-		// Check if user's role is in allowed roles
-		for _, role := range allowedRoles {
-			if models.UserRole(userRole) == role {
-				c.Next()
-				return
-			}
-		}
-
-		response.Forbidden(c, "Insufficient permissions")
-		c.Abort()
-	}
-}
-
-// RequireAdmin requires admin role
-func RequireAdmin() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin)
-}
-
-// RequireAdminOrSupplyChain requires admin or supply chain manager role
-func RequireAdminOrSupplyChain() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RoleSupplyChainManager)
-}
-
-// RequireInventoryAccess requires admin, purchase_admin, or supply chain manager role
-func RequireInventoryAccess() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RolePurchaseAdmin, models.RoleSupplyChainManager)
-}
+// PermissionsKey holds the permissions of the authenticated user's role
+const PermissionsKey = "permissions"
 
-// RequireApprover requires a role that can approve/reject requests
-// Only General Manager can approve - Admin cannot approve, only view
-func RequireApprover() gin.HandlerFunc {
-	return RequireRole(models.RoleGeneralManager)
+// PermissionResolver looks up the permissions a role grants
+type PermissionResolver interface {
+	RolePermissions(role models.UserRole) models.PermissionSet
 }
 
-// RequireCanViewApprovals requires a role that can view all approvals
-// GM can approve/reject, Admin and Purchase Admin can only view
-func RequireCanViewApprovals() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RoleGeneralManager, models.RolePurchaseAdmin)
-}
-
-// RequireManager requires a manager-level role
-func RequireManager() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RoleSupplyChainManager, models.RoleGeneralManager)
-}
-
-// RequirePurchaseManager requires a role that can manage approved orders
-func RequirePurchaseManager() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RolePurchaseAdmin)
-}
-
-// RequirePurchaseConfig requires a role that can configure the purchase module
-func RequirePurchaseConfig() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RolePurchaseAdmin)
-}
-
-// CanViewAllRequests checks if user can view all requests
-func CanViewAllRequests() gin.HandlerFunc {
+// RequirePermission returns middleware that requires the user's role to
+// grant the permission
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := GetUserRole(c)
-		if userRole == "" {
+		if GetUserRole(c) == "" {
 			response.Unauthorized(c, "Authentication required")
 			c.Abort()
 			return
 		}
 
-		role := models.UserRole(userRole)
-		if role == models.RoleAdmin || role == models.RoleSupplyChainManager || role == models.RoleGeneralManager || role == models.RolePurchaseAdmin {
-			c.Set("can_view_all", true)
-		} else {
-			c.Set("can_view_all", false)
+		if !HasPermission(c, permission) {
+			response.Forbidden(c, "Insufficient permissions")
+			c.Abort()
+			return
 		}
 
 		c.Next()
 	}
 }
 
-// CanViewAll extracts the can_view_all flag from context
-func CanViewAll(c *gin.Context) bool {
-	if canView, exists := c.Get("can_view_all"); exists {
-		return canView.(bool)
+// GetPermissions returns the permissions of the authenticated user's role
+func GetPermissions(c *gin.Context) models.PermissionSet {
+	if permissions, exists := c.Get(PermissionsKey); exists {
+		return permissions.(models.PermissionSet)
 	}
-	return false
+	return models.PermissionSet{}
+}
+
+// HasPermission returns true if the authenticated user's role grants the
+// permission
+func HasPermission(c *gin.Context, permission models.Permission) bool {
+	return GetPermissions(c).Has(permission)
+}
+
+// CanViewAll returns true if the user can see everyone's requests
+func CanViewAll(c *gin.Context) bool {
+	return HasPermission(c, models.PermRequestsViewAll)
 }
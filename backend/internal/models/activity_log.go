@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // ActivityType represents the type of activity
@@ -14,6 +16,13 @@ const (
 	ActivityTokenRefresh  ActivityType = "token_refresh"
 	ActivityPasswordReset ActivityType = "password_reset"
 	ActivityRegistration  ActivityType = "registration"
+	ActivityTokenReuse    ActivityType = "token_reuse" // A rotated refresh token was used again; the session was revoked
+	ActivityLoginLocked   ActivityType = "login_locked"   // Login refused because of too many failed attempts
+	ActivityLoginUnlocked ActivityType = "login_unlocked" // An admin lifted an account lockout
+	ActivityLoginRefused  ActivityType = "login_refused"  // Login refused for a reason other than bad credentials, such as a pending or disabled account
+	ActivityTwoFactorEnabled  ActivityType = "two_factor_enabled"
+	ActivityTwoFactorDisabled ActivityType = "two_factor_disabled" // Turned off by the user or reset by an admin
+	ActivityRecoveryCodeUsed  ActivityType = "recovery_code_used"  // A recovery code was used instead of a TOTP code to log in
 )
 
 // ActivityLog tracks user authentication and session activities
@@ -28,6 +37,7 @@ type ActivityLog struct {
 	Details     string       `gorm:"type:text" json:"details,omitempty"`
 	Identifier  string       `gorm:"size:255;index" json:"identifier"` // Employee number or email used for login attempt
 	SessionID   string       `gorm:"size:100;index" json:"session_id,omitempty"`
+	RefreshTokenID string    `gorm:"size:100" json:"-"` // Only the session's latest refresh token (jti) is accepted
 	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
 	EndedAt     *time.Time   `json:"ended_at,omitempty"` // When session ended (logout or expiry)
 	CreatedAt   time.Time    `gorm:"index" json:"created_at"`
@@ -65,6 +75,12 @@ func (a *ActivityLog) WithSession(sessionID string, expiresAt time.Time) *Activi
 	return a
 }
 
+// IsSession returns true if this entry is a successful login, which is the
+// record of the session it started
+func (a *ActivityLog) IsSession() bool {
+	return a.Type == ActivityLogin && a.Success
+}
+
 // IsActive returns true if this is an active session (login without logout)
 func (a *ActivityLog) IsActive() bool {
 	if a.Type != ActivityLogin || !a.Success {
@@ -91,3 +107,33 @@ func (a *ActivityLog) GetDuration() *time.Duration {
 	d := endTime.Sub(a.CreatedAt)
 	return &d
 }
+
+
+// FindActiveSession returns the login that started sessionID if the session
+// hasn't ended or expired, or gorm.ErrRecordNotFound
+func FindActiveSession(db *gorm.DB, sessionID string) (*ActivityLog, error) {
+	if sessionID == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var session ActivityLog
+	err := db.Where("session_id = ? AND type = ? AND success = ? AND ended_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+		sessionID, ActivityLogin, true, time.Now()).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// EndSession ends an active session; tokens issued for it stop working
+func EndSession(db *gorm.DB, sessionID, details string) error {
+	return db.Model(&ActivityLog{}).
+		Where("session_id = ? AND type = ? AND success = ? AND ended_at IS NULL", sessionID, ActivityLogin, true).
+		Updates(map[string]interface{}{"ended_at": time.Now(), "details": details}).Error
+}
+
+// EndUserSessions ends every active session of a user
+func EndUserSessions(db *gorm.DB, userID uint, details string) error {
+	return db.Model(&ActivityLog{}).
+		Where("user_id = ? AND type = ? AND success = ? AND ended_at IS NULL", userID, ActivityLogin, true).
+		Updates(map[string]interface{}{"ended_at": time.Now(), "details": details}).Error
+}
@@ -4,9 +4,10 @@ import (
 	"time"
 )
 
-// AmazonConfig stores Amazon Business credentials for automation
+// AmazonConfig stores a company's Amazon Business credentials for automation
 type AmazonConfig struct {
-	ID uint `gorm:"primaryKey" json:"id"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	CompanyCode string `gorm:"size:50;uniqueIndex" json:"company_code"`
 
 	// Amazon Business account credentials
 	Email             string `gorm:"size:255" json:"email"`
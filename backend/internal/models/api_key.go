@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, so keys are recognizable in the
+// Authorization header and in leaked-secret scans
+const APIKeyPrefix = "vsk_"
+
+var (
+	ErrInvalidAPIKey          = errors.New("invalid API key")
+	ErrAPIKeyExpired          = errors.New("API key has expired")
+	ErrServiceAccountDisabled = errors.New("service account is disabled")
+)
+
+// APIKey lets a service account call the API. The key is shown once when
+// created; only its SHA-256 hash is stored. Prefix is the public part of the
+// key (vsk_ and 12 characters), used to find it and to tell keys apart.
+type APIKey struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	ServiceAccountID uint       `gorm:"not null;index" json:"service_account_id"`
+	ServiceAccount   *User      `gorm:"foreignKey:ServiceAccountID" json:"service_account,omitempty"`
+	Name             string     `gorm:"not null;size:100" json:"name"`
+	Prefix           string     `gorm:"size:20;not null;uniqueIndex" json:"prefix"`
+	KeyHash          string     `gorm:"size:64;not null" json:"-"`
+	Scopes           StringList `gorm:"type:text" json:"scopes"`
+	ExpiresAt        *time.Time `json:"expires_at"` // Nil never expires
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	LastUsedIP       string     `gorm:"size:45" json:"last_used_ip,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedByID      *uint      `json:"created_by_id,omitempty"`
+	CreatedBy        *User      `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// IsUsable returns true if the key hasn't been revoked and hasn't expired
+func (k *APIKey) IsUsable() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
+
+// HasScope returns true if the key was granted scope, directly or through
+// resource:* for every action on the resource
+func (k *APIKey) HasScope(scope string) bool {
+	resource, _, _ := strings.Cut(scope, ":")
+	for _, s := range k.Scopes {
+		if s == scope || s == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// APIResource is a part of the API that keys can be granted access to.
+// Scopes are "<resource>:read" for GET requests and "<resource>:write" for
+// everything else, on the routes under Paths (relative to /api/v1).
+type APIResource struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Paths       []string `json:"paths"`
+}
+
+// APIResources is the catalog of what API keys can be scoped to. Routes
+// outside it can't be called with a key.
+var APIResources = []APIResource{
+	{Name: "products", Description: "Product catalog and image uploads", Paths: []string{"/products", "/upload"}},
+	{Name: "requests", Description: "Purchase requests", Paths: []string{"/purchase-requests", "/requests"}},
+	{Name: "approvals", Description: "Pending approvals and approval decisions", Paths: []string{"/approvals"}},
+	{Name: "orders", Description: "Approved orders and purchasing", Paths: []string{"/admin/approved-orders", "/admin/orders"}},
+	{Name: "users", Description: "User accounts", Paths: []string{"/users"}},
+	{Name: "audit", Description: "Activity and audit logs", Paths: []string{"/admin/activity-logs", "/admin/audit-logs"}},
+}
+
+// APIScopeActions are the actions a scope can grant on a resource
+var APIScopeActions = []string{"read", "write", "*"}
+
+// IsValidAPIScope returns true if scope names a catalog resource and an action
+func IsValidAPIScope(scope string) bool {
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok || apiResource(resource) == nil {
+		return false
+	}
+	for _, a := range APIScopeActions {
+		if action == a {
+			return true
+		}
+	}
+	return false
+}
+
+// APIScopeFor returns the scope a request needs, from its method and route
+// path relative to /api/v1. ok is false for routes keys can't call.
+func APIScopeFor(method, path string) (scope string, ok bool) {
+	for _, resource := range APIResources {
+		for _, prefix := range resource.Paths {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				action := "write"
+				if method == "GET" || method == "HEAD" {
+					action = "read"
+				}
+				return resource.Name + ":" + action, true
+			}
+		}
+	}
+	return "", false
+}
+
+func apiResource(name string) *APIResource {
+	for i := range APIResources {
+		if APIResources[i].Name == name {
+			return &APIResources[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChatProvider is a chat service that accepts incoming webhook messages
+type ChatProvider string
+
+const (
+	ChatProviderWeCom    ChatProvider = "wecom"
+	ChatProviderDingTalk ChatProvider = "dingtalk"
+	ChatProviderSlack    ChatProvider = "slack"
+	ChatProviderTeams    ChatProvider = "teams"
+)
+
+// ChatProviders lists the supported chat providers
+var ChatProviders = []ChatProvider{
+	ChatProviderWeCom,
+	ChatProviderDingTalk,
+	ChatProviderSlack,
+	ChatProviderTeams,
+}
+
+// IsValid returns true if the provider is supported
+func (p ChatProvider) IsValid() bool {
+	for _, provider := range ChatProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatNotificationTypes lists the notifications that can be posted to chat
+// channels. The others only concern the individual requester.
+var ChatNotificationTypes = []NotificationType{
+	NotificationUrgentRequest,
+	NotificationNewPendingRequest,
+	NotificationRequestApproved,
+	NotificationNewApprovedOrder,
+	NotificationReminderPending,
+	NotificationReminderUnpurchased,
+}
+
+// IsChatNotificationType returns true if the type can be posted to chat channels
+func IsChatNotificationType(t NotificationType) bool {
+	for _, chatType := range ChatNotificationTypes {
+		if chatType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatChannel is a group chat (WeCom, DingTalk, Slack or Teams) that receives
+// cards for the notifications of an audience selected by role and department
+type ChatChannel struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"size:100;not null" json:"name"`
+	Provider    ChatProvider `gorm:"size:20;not null" json:"provider"`
+	WebhookURL  string       `gorm:"size:1000;not null" json:"-"` // Encrypted; contains the provider's access token
+	Secret      string       `gorm:"size:500" json:"-"`           // Encrypted DingTalk signing secret (optional)
+	Language    string       `gorm:"size:10;not null" json:"language"`
+	Roles       StringList   `gorm:"type:text" json:"roles"`       // Empty matches every role
+	Departments StringList   `gorm:"type:text" json:"departments"` // Empty matches every department
+	Types       StringList   `gorm:"type:text" json:"types"`       // Empty posts every chat notification type
+	IsActive    bool         `json:"is_active"`
+
+	LastTestAt      *time.Time `json:"last_test_at,omitempty"`
+	LastTestSuccess bool       `json:"last_test_success"`
+	LastTestError   string     `gorm:"size:500" json:"last_test_error,omitempty"`
+
+	CreatedByID *uint          `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WantsType returns true if the channel posts notifications of type t
+func (c *ChatChannel) WantsType(t NotificationType) bool {
+	return len(c.Types) == 0 || c.Types.Contains(string(t))
+}
+
+// Matches returns true if the user is part of the channel's audience: their
+// role and department must both be selected, where an empty list selects all
+func (c *ChatChannel) Matches(user *User) bool {
+	if len(c.Roles) > 0 && !c.Roles.Contains(string(user.Role)) {
+		return false
+	}
+	if len(c.Departments) > 0 && !c.Departments.Contains(user.Department) {
+		return false
+	}
+	return true
+}
+
+// ChatMessageStatus represents the state of a queued chat message
+type ChatMessageStatus string
+
+const (
+	ChatMessagePending ChatMessageStatus = "pending"
+	ChatMessageSending ChatMessageStatus = "sending"
+	ChatMessageSent    ChatMessageStatus = "sent"
+	ChatMessageFailed  ChatMessageStatus = "failed"
+)
+
+// ChatMessage is a card queued for one chat channel. The card is rendered in
+// the channel's language when queued; the dispatcher adds the provider format.
+type ChatMessage struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	ChannelID uint             `gorm:"not null;index" json:"channel_id"`
+	Type      NotificationType `gorm:"size:50;index" json:"type"`
+	RequestID *uint            `gorm:"index" json:"request_id,omitempty"`
+
+	Title     string `gorm:"size:300" json:"title"`
+	Text      string `gorm:"type:text" json:"text"`
+	Fields    JSONB  `gorm:"type:text" json:"fields,omitempty"`    // [{"label","value"}]
+	ActionURL string `gorm:"size:500" json:"action_url,omitempty"` // Relative to APP_URL
+	Action    string `gorm:"size:100" json:"action,omitempty"`     // Button label
+	Urgent    bool   `json:"urgent"`
+
+	Status        ChatMessageStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Attempts      int               `gorm:"default:0" json:"attempts"`
+	MaxAttempts   int               `gorm:"default:5" json:"max_attempts"`
+	NextAttemptAt time.Time         `gorm:"index" json:"next_attempt_at"`
+	LastError     string            `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time        `json:"sent_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// CanRetry returns true if the message has attempts left
+func (m *ChatMessage) CanRetry() bool {
+	return m.Attempts < m.MaxAttempts
+}
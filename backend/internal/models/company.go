@@ -0,0 +1,79 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Company is a legal entity with its own approvers, catalog, request and PO
+// numbering, and purchase, email and Amazon configuration. Users belong to
+// the company whose code is their CompanyCode; users whose code isn't a
+// company belong to the default company.
+type Company struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Code string `gorm:"size:50;not null;uniqueIndex" json:"code"`
+	Name string `gorm:"size:255;not null" json:"name"`
+	// NumberPrefix goes into request and PO numbers (PR-MX-2026-0001) so each
+	// company numbers its own. At most one company has none.
+	NumberPrefix string    `gorm:"size:10" json:"number_prefix"`
+	IsDefault    bool      `gorm:"default:false" json:"is_default"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+var (
+	companyCodePattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,49}$`)
+	numberPrefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}$`)
+)
+
+// IsValidCompanyCode returns true if code can be used for a new company
+func IsValidCompanyCode(code string) bool {
+	return companyCodePattern.MatchString(code)
+}
+
+// IsValidNumberPrefix returns true if prefix can go into request numbers.
+// Prefixes start with a letter so they can't be mistaken for the year.
+func IsValidNumberPrefix(prefix string) bool {
+	return prefix == "" || numberPrefixPattern.MatchString(prefix)
+}
+
+// FindCompany returns the company with the given code
+func FindCompany(db *gorm.DB, code string) (*Company, error) {
+	var company Company
+	if err := db.Where("code = ?", code).First(&company).Error; err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
+
+// CompanyExists returns true if there is a company with the given code
+func CompanyExists(db *gorm.DB, code string) bool {
+	var count int64
+	db.Model(&Company{}).Where("code = ?", code).Count(&count)
+	return count > 0
+}
+
+// DefaultCompanyCode returns the code of the default company
+func DefaultCompanyCode(db *gorm.DB) string {
+	var company Company
+	if err := db.Where("is_default = ?", true).First(&company).Error; err != nil {
+		return ""
+	}
+	return company.Code
+}
+
+// UserCompanyCode returns the code of the company the user belongs to
+func UserCompanyCode(db *gorm.DB, user *User) string {
+	if user.CompanyCode != "" && CompanyExists(db, user.CompanyCode) {
+		return user.CompanyCode
+	}
+	return DefaultCompanyCode(db)
+}
+
+// CompanyMembers narrows a users query to the members of the company
+func CompanyMembers(db *gorm.DB, code string) *gorm.DB {
+	return db.Where("users.company_code = ? OR (? = (SELECT code FROM companies WHERE is_default = ? LIMIT 1) "+
+		"AND COALESCE(users.company_code, '') NOT IN (SELECT code FROM companies))", code, code, true)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// DirectorySyncRun records one directory sync, scheduled or started by an
+// admin. Dry runs report what would change without changing anything.
+type DirectorySyncRun struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	DryRun        bool       `json:"dry_run"`
+	TriggeredByID *uint      `json:"triggered_by_id,omitempty"` // Nil for scheduled runs
+	TriggeredBy   *User      `gorm:"foreignKey:TriggeredByID" json:"triggered_by,omitempty"`
+	StartedAt     time.Time  `gorm:"not null" json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Entries       int        `json:"entries"` // Users found in the directory
+	Created       int        `json:"created"`
+	Updated       int        `json:"updated"`
+	Disabled      int        `json:"disabled"`
+	Enabled       int        `json:"enabled"`
+	Skipped       int        `json:"skipped"`
+	Error         string     `gorm:"size:1000" json:"error,omitempty"`
+	Report        JSONB      `gorm:"type:text" json:"report,omitempty"` // Per-user changes
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -1,12 +1,17 @@
 package models
 
 import (
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// EmailConfig stores configuration for email notifications (singleton)
+// EmailConfig stores configuration for email notifications (one per company;
+// companies without their own send with the default company's)
 type EmailConfig struct {
-	ID uint `gorm:"primaryKey" json:"id"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	CompanyCode string `gorm:"size:50;uniqueIndex" json:"company_code"`
 
 	// Provider Configuration
 	Provider           string `gorm:"size:50;default:resend" json:"provider"` // resend, smtp, etc.
@@ -16,6 +21,18 @@ type EmailConfig struct {
 	FromName           string `gorm:"size:100" json:"from_name"`
 	ReplyToEmail       string `gorm:"size:200" json:"reply_to_email"`
 
+	// SMTP relay (Provider "smtp")
+	SMTPHost     string `gorm:"size:200" json:"smtp_host"`
+	SMTPPort     int    `gorm:"default:587" json:"smtp_port"`
+	SMTPSecurity string `gorm:"size:20;default:starttls" json:"smtp_security"` // starttls, tls (implicit) or none
+	SMTPUsername string `gorm:"size:200" json:"smtp_username"`                 // Empty skips authentication
+	SMTPPassword string `gorm:"size:500" json:"-"`                             // Encrypted
+
+	// Optional DKIM signing for SMTP (Resend signs on its side)
+	DKIMDomain     string `gorm:"size:200" json:"dkim_domain"`
+	DKIMSelector   string `gorm:"size:100" json:"dkim_selector"`
+	DKIMPrivateKey string `gorm:"type:text" json:"-"` // Encrypted PEM
+
 	// Feature Flags
 	Enabled            bool   `gorm:"default:false" json:"enabled"`
 	SendOnApproval     bool   `gorm:"default:true" json:"send_on_approval"`
@@ -26,12 +43,8 @@ type EmailConfig struct {
 	SendOnUrgent       bool   `gorm:"default:true" json:"send_on_urgent"`
 	SendReminders      bool   `gorm:"default:false" json:"send_reminders"`
 
-	// Templates (optional custom overrides)
-	TemplateApproval   string `gorm:"type:text" json:"template_approval"`
-	TemplateRejection  string `gorm:"type:text" json:"template_rejection"`
-	TemplateInfoReq    string `gorm:"type:text" json:"template_info_request"`
-	TemplatePurchased  string `gorm:"type:text" json:"template_purchased"`
-	TemplateNewRequest string `gorm:"type:text" json:"template_new_request"`
+	// Templates (optional custom overrides). Notification templates are
+	// per language in EmailTemplate; reminders are not sent yet.
 	TemplateReminder   string `gorm:"type:text" json:"template_reminder"`
 
 	// Status tracking
@@ -46,15 +59,48 @@ type EmailConfig struct {
 	UpdatedBy   User      `gorm:"foreignKey:UpdatedByID" json:"updated_by,omitempty"`
 }
 
+// Email providers
+const (
+	EmailProviderResend = "resend"
+	EmailProviderSMTP   = "smtp"
+)
+
+// SMTP connection security modes
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+)
+
+// IsConfigured returns true if the selected provider has the settings it needs
+func (ec *EmailConfig) IsConfigured() bool {
+	if ec.FromEmail == "" {
+		return false
+	}
+	switch ec.Provider {
+	case EmailProviderSMTP:
+		return ec.SMTPHost != ""
+	default:
+		return ec.APIKey != ""
+	}
+}
+
 // CanSendEmail returns true if email sending is configured and enabled
 func (ec *EmailConfig) CanSendEmail() bool {
-	return ec.Enabled && ec.APIKey != "" && ec.FromEmail != ""
+	return ec.Enabled && ec.IsConfigured()
+}
+
+// HasDKIM returns true if outgoing SMTP mail should be DKIM signed
+func (ec *EmailConfig) HasDKIM() bool {
+	return ec.DKIMDomain != "" && ec.DKIMSelector != "" && ec.DKIMPrivateKey != ""
 }
 
 // GetDefaultEmailConfig returns an EmailConfig with default values
 func GetDefaultEmailConfig() EmailConfig {
 	return EmailConfig{
-		Provider:          "resend",
+		Provider:          EmailProviderResend,
+		SMTPPort:          587,
+		SMTPSecurity:      SMTPSecurityStartTLS,
 		FromName:          "IRIS Vista",
 		Enabled:           false,
 		SendOnApproval:    true,
@@ -66,3 +112,21 @@ func GetDefaultEmailConfig() EmailConfig {
 		SendReminders:     false,
 	}
 }
+
+// FindEmailConfig returns the email configuration a company sends with: its
+// own, or the default company's if it has none. It returns nil if neither
+// exists.
+func FindEmailConfig(db *gorm.DB, company string) (*EmailConfig, error) {
+	var config EmailConfig
+	err := db.Where("company_code = ?", company).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = db.Where("company_code = ?", DefaultCompanyCode(db)).First(&config).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &config, nil
+}
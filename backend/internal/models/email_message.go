@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// EmailStatus represents the delivery state of an outbox email
+type EmailStatus string
+
+const (
+	EmailPending EmailStatus = "pending"
+	EmailSending EmailStatus = "sending"
+	EmailSent    EmailStatus = "sent"
+	EmailFailed  EmailStatus = "failed"
+)
+
+// EmailMessage is an outgoing email in the outbox. The outbox worker sends
+// pending messages once NextAttemptAt has passed and retries failures with backoff.
+type EmailMessage struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	To       string `gorm:"column:to_addresses;size:1000;not null" json:"to"` // Comma-separated addresses
+	Subject  string `gorm:"size:500" json:"subject"`
+	HTMLBody string `gorm:"type:text" json:"html_body,omitempty"`
+	TextBody string `gorm:"type:text" json:"text_body,omitempty"`
+
+	// What the email is about, for the delivery log
+	Kind           string `gorm:"size:50;index" json:"kind"` // Notification type, "digest", ...
+	UserID         *uint  `gorm:"index" json:"user_id,omitempty"`
+	NotificationID *uint  `gorm:"index" json:"notification_id,omitempty"`      // Gets EmailSentAt when delivered
+	CompanyCode    string `gorm:"size:50;index" json:"company_code,omitempty"` // Whose email configuration sends it
+
+	Status            EmailStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Attempts          int         `gorm:"default:0" json:"attempts"`
+	MaxAttempts       int         `gorm:"default:6" json:"max_attempts"`
+	LastError         string      `gorm:"type:text" json:"last_error,omitempty"`
+	Provider          string      `gorm:"size:50" json:"provider,omitempty"`
+	ProviderMessageID string      `gorm:"size:200" json:"provider_message_id,omitempty"`
+	NextAttemptAt     time.Time   `gorm:"index" json:"next_attempt_at"`
+	SentAt            *time.Time  `json:"sent_at,omitempty"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// NewEmailMessage creates a pending message due at sendAt (now if zero)
+func NewEmailMessage(to []string, subject, htmlBody, textBody string, sendAt time.Time) *EmailMessage {
+	if sendAt.IsZero() {
+		sendAt = time.Now()
+	}
+	return &EmailMessage{
+		To:            strings.Join(to, ","),
+		Subject:       subject,
+		HTMLBody:      htmlBody,
+		TextBody:      textBody,
+		Status:        EmailPending,
+		MaxAttempts:   6,
+		NextAttemptAt: sendAt,
+	}
+}
+
+// Recipients returns the To addresses as a list
+func (m *EmailMessage) Recipients() []string {
+	var to []string
+	for _, addr := range strings.Split(m.To, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+// CanRetry returns true if the message has attempts left
+func (m *EmailMessage) CanRetry() bool {
+	return m.Attempts < m.MaxAttempts
+}
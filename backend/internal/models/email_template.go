@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// EmailTemplateKind identifies which notification email a template is for
+type EmailTemplateKind string
+
+const (
+	EmailTemplateApproval     EmailTemplateKind = "approval"
+	EmailTemplateRejection    EmailTemplateKind = "rejection"
+	EmailTemplateInfoRequest  EmailTemplateKind = "info_request"
+	EmailTemplateInfoResponse EmailTemplateKind = "info_response"
+	EmailTemplatePurchased    EmailTemplateKind = "purchased"
+	EmailTemplateNewRequest   EmailTemplateKind = "new_request" // Also used for urgent requests
+)
+
+// EmailTemplateKinds lists every editable template kind
+var EmailTemplateKinds = []EmailTemplateKind{
+	EmailTemplateApproval,
+	EmailTemplateRejection,
+	EmailTemplateInfoRequest,
+	EmailTemplateInfoResponse,
+	EmailTemplatePurchased,
+	EmailTemplateNewRequest,
+}
+
+// IsValid returns true if the kind is a known template kind
+func (k EmailTemplateKind) IsValid() bool {
+	for _, kind := range EmailTemplateKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailTemplate is an admin override of a notification email for one
+// language. Emails without an override use the built-in template.
+type EmailTemplate struct {
+	ID       uint              `gorm:"primaryKey" json:"id"`
+	Kind     EmailTemplateKind `gorm:"size:50;not null;uniqueIndex:idx_email_template_kind_lang" json:"kind"`
+	Language string            `gorm:"size:10;not null;uniqueIndex:idx_email_template_kind_lang" json:"language"`
+
+	// Subject is a text template; empty uses the built-in subject
+	Subject string `gorm:"size:500" json:"subject"`
+	// Body is an HTML template rendered inside the shared email layout
+	Body string `gorm:"type:text;not null" json:"body"`
+
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UpdatedByID *uint     `json:"updated_by_id,omitempty"`
+	UpdatedBy   *User     `gorm:"foreignKey:UpdatedByID" json:"updated_by,omitempty"`
+}
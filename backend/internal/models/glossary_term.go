@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// GlossaryTerm is an admin-managed translation for a company-specific term.
+// Term or any of its translations appearing in source text is replaced by the
+// target language's form. Protected terms are never translated.
+type GlossaryTerm struct {
+	ID            uint          `gorm:"primaryKey" json:"id"`
+	Term          string        `gorm:"size:200;not null;uniqueIndex" json:"term"`
+	Translations  LocalizedText `gorm:"type:text" json:"translations"`  // Language code -> term
+	Protected     bool          `gorm:"default:false" json:"protected"` // Pass through verbatim (brands, part numbers)
+	CaseSensitive bool          `gorm:"default:false" json:"case_sensitive"`
+	Notes         string        `gorm:"type:text" json:"notes,omitempty"`
+	IsActive      bool          `json:"is_active"`
+
+	CreatedByID *uint     `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Forms returns every non-empty spelling of the term
+func (g *GlossaryTerm) Forms() []string {
+	forms := []string{g.Term}
+	for _, f := range g.Translations {
+		if f != "" && f != g.Term {
+			forms = append(forms, f)
+		}
+	}
+	return forms
+}
+
+// ForLanguage returns the term in the given language, falling back to Term
+func (g *GlossaryTerm) ForLanguage(lang string) string {
+	if value := g.Translations.Get(lang); value != "" {
+		return value
+	}
+	return g.Term
+}
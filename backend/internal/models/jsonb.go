@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strings"
 )
 
 // JSONB is a custom type for handling JSON data in PostgreSQL without external dependencies
@@ -58,3 +59,51 @@ func (j *JSONB) UnmarshalJSON(data []byte) error {
 func (j JSONB) String() string {
 	return string(j)
 }
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+// Value implements driver.Valuer interface
+func (s StringList) Value() (driver.Value, error) {
+	if s == nil {
+		s = StringList{}
+	}
+	data, err := json.Marshal([]string(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner interface
+func (s *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("invalid type for StringList")
+	}
+	if len(data) == 0 {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// Contains returns true if the list has value, ignoring case
+func (s StringList) Contains(value string) bool {
+	for _, v := range s {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// LocalizedText maps language codes to text, e.g. {"en": "Gloves", "es": "Guantes"}.
+// Stored as a JSON column so new languages don't need schema changes.
+type LocalizedText map[string]string
+
+// Value implements driver.Valuer interface
+func (l LocalizedText) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner interface
+func (l *LocalizedText) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("invalid type for LocalizedText")
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+
+	m := make(map[string]string)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*l = m
+	return nil
+}
+
+// Get returns the text for a language, or "" if there is none
+func (l LocalizedText) Get(lang string) string {
+	if l == nil {
+		return ""
+	}
+	return l[lang]
+}
+
+// Set stores text for a language, removing the entry when text is empty
+func (l *LocalizedText) Set(lang, text string) {
+	if text == "" {
+		if *l != nil {
+			delete(*l, lang)
+		}
+		return
+	}
+	if *l == nil {
+		*l = make(LocalizedText)
+	}
+	(*l)[lang] = text
+}
+
+// Merge copies non-empty entries from other, overwriting existing ones
+func (l *LocalizedText) Merge(other LocalizedText) {
+	for lang, text := range other {
+		l.Set(lang, text)
+	}
+}
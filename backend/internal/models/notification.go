@@ -14,6 +14,7 @@ const (
 	NotificationRequestApproved     NotificationType = "request_approved"
 	NotificationRequestRejected     NotificationType = "request_rejected"
 	NotificationRequestInfoRequired NotificationType = "request_info_required"
+	NotificationRequestInfoProvided NotificationType = "request_info_provided"
 	NotificationRequestPurchased    NotificationType = "request_purchased"
 	NotificationNewPendingRequest   NotificationType = "new_pending_request"
 	NotificationUrgentRequest       NotificationType = "urgent_request"
@@ -35,6 +36,7 @@ type Notification struct {
 	ActionURL     string           `gorm:"size:500" json:"action_url,omitempty"`
 	ReadAt        *time.Time       `json:"read_at,omitempty"`
 	EmailSentAt   *time.Time       `json:"email_sent_at,omitempty"`
+	DigestOnly    bool             `gorm:"not null;default:false" json:"-"` // Kept only for the recipient's digest, not shown in-app
 	CreatedAt     time.Time        `json:"created_at"`
 	UpdatedAt     time.Time        `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt   `gorm:"index" json:"-"`
@@ -51,6 +53,11 @@ func (n *Notification) MarkAsRead() {
 	n.ReadAt = &now
 }
 
+// InAppNotifications limits a notification query to those shown in the in-app list
+func InAppNotifications(db *gorm.DB) *gorm.DB {
+	return db.Where("digest_only = ?", false)
+}
+
 // NotificationCount holds the count of unread notifications
 type NotificationCount struct {
 	Unread int64 `json:"unread"`
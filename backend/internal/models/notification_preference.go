@@ -0,0 +1,242 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NotificationChannel selects where a user receives a type of notification
+type NotificationChannel string
+
+const (
+	ChannelInApp NotificationChannel = "in_app"
+	ChannelEmail NotificationChannel = "email"
+	ChannelBoth  NotificationChannel = "both"
+	ChannelNone  NotificationChannel = "none"
+)
+
+// IsValid returns true if the channel is a known value
+func (c NotificationChannel) IsValid() bool {
+	switch c {
+	case ChannelInApp, ChannelEmail, ChannelBoth, ChannelNone:
+		return true
+	}
+	return false
+}
+
+// InApp returns true if the channel includes in-app notifications
+func (c NotificationChannel) InApp() bool {
+	return c == ChannelInApp || c == ChannelBoth
+}
+
+// Email returns true if the channel includes email
+func (c NotificationChannel) Email() bool {
+	return c == ChannelEmail || c == ChannelBoth
+}
+
+// DigestFrequency controls whether a user's emails are batched into a digest
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// IsValid returns true if the frequency is a known value
+func (f DigestFrequency) IsValid() bool {
+	switch f {
+	case DigestOff, DigestDaily, DigestWeekly:
+		return true
+	}
+	return false
+}
+
+// ConfigurableNotificationTypes lists the notification types users can set preferences for
+var ConfigurableNotificationTypes = []NotificationType{
+	NotificationNewPendingRequest,
+	NotificationUrgentRequest,
+	NotificationRequestApproved,
+	NotificationRequestRejected,
+	NotificationRequestInfoRequired,
+	NotificationRequestInfoProvided,
+	NotificationRequestPurchased,
+	NotificationNewApprovedOrder,
+}
+
+// IsConfigurableNotificationType returns true if users can set a preference for the type
+func IsConfigurableNotificationType(t NotificationType) bool {
+	for _, configurable := range ConfigurableNotificationTypes {
+		if configurable == t {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationChannels maps notification types to the channel a user chose
+type NotificationChannels map[NotificationType]NotificationChannel
+
+// Value implements driver.Valuer interface
+func (n NotificationChannels) Value() (driver.Value, error) {
+	if len(n) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[NotificationType]NotificationChannel(n))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner interface
+func (n *NotificationChannels) Scan(value interface{}) error {
+	if value == nil {
+		*n = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("invalid type for NotificationChannels")
+	}
+	if len(data) == 0 {
+		*n = nil
+		return nil
+	}
+
+	m := make(map[NotificationType]NotificationChannel)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*n = m
+	return nil
+}
+
+// NotificationPreference stores a user's notification choices. Users without
+// a row, or types missing from Channels, get both in-app and email.
+type NotificationPreference struct {
+	ID       uint                 `gorm:"primaryKey" json:"id"`
+	UserID   uint                 `gorm:"uniqueIndex;not null" json:"user_id"`
+	Channels NotificationChannels `gorm:"type:text" json:"channels"`
+
+	// Quiet hours as "HH:MM" in Timezone; emails due inside them are held
+	// until they end. Equal start and end disables quiet hours.
+	QuietHoursStart string `gorm:"size:5" json:"quiet_hours_start"`
+	QuietHoursEnd   string `gorm:"size:5" json:"quiet_hours_end"`
+	Timezone        string `gorm:"size:64" json:"timezone"` // IANA name, e.g. "America/Mexico_City"; empty means server time
+
+	// Digest mode replaces per-event emails with one summary email per day or
+	// week, sent at DigestHour (and on DigestWeekday for weekly) in Timezone
+	DigestFrequency DigestFrequency `gorm:"size:10;default:'off'" json:"digest_frequency"`
+	DigestHour      int             `gorm:"default:8" json:"digest_hour"`    // 0-23
+	DigestWeekday   int             `gorm:"default:1" json:"digest_weekday"` // 0 = Sunday
+	LastDigestAt    *time.Time      `json:"last_digest_at"`                  // Digests cover activity after this
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChannelFor returns the user's channel for a notification type
+func (p *NotificationPreference) ChannelFor(t NotificationType) NotificationChannel {
+	if p != nil {
+		if channel, ok := p.Channels[t]; ok && channel.IsValid() {
+			return channel
+		}
+	}
+	return ChannelBoth
+}
+
+// HasQuietHours returns true if quiet hours are set
+func (p *NotificationPreference) HasQuietHours() bool {
+	return p != nil && p.QuietHoursStart != "" && p.QuietHoursEnd != "" && p.QuietHoursStart != p.QuietHoursEnd
+}
+
+// Location returns the time zone quiet hours are expressed in
+func (p *NotificationPreference) Location() *time.Location {
+	if p != nil && p.Timezone != "" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// QuietUntil returns when the quiet hours containing t end, or the zero time
+// if t is outside quiet hours
+func (p *NotificationPreference) QuietUntil(t time.Time) time.Time {
+	if !p.HasQuietHours() {
+		return time.Time{}
+	}
+	start, err1 := ParseClock(p.QuietHoursStart)
+	end, err2 := ParseClock(p.QuietHoursEnd)
+	if err1 != nil || err2 != nil {
+		return time.Time{}
+	}
+
+	local := t.In(p.Location())
+	minute := local.Hour()*60 + local.Minute()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	if start < end {
+		// Same-day window, e.g. 12:00-14:00
+		if minute >= start && minute < end {
+			return midnight.Add(time.Duration(end) * time.Minute)
+		}
+		return time.Time{}
+	}
+
+	// Overnight window, e.g. 22:00-07:00
+	if minute >= start {
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(end) * time.Minute)
+	}
+	if minute < end {
+		return midnight.Add(time.Duration(end) * time.Minute)
+	}
+	return time.Time{}
+}
+
+// DigestEnabled returns true if the user receives digests instead of per-event emails
+func (p *NotificationPreference) DigestEnabled() bool {
+	return p != nil && (p.DigestFrequency == DigestDaily || p.DigestFrequency == DigestWeekly)
+}
+
+// NextDigestAt returns the first scheduled digest time after t, or the zero
+// time if digests are off
+func (p *NotificationPreference) NextDigestAt(t time.Time) time.Time {
+	if !p.DigestEnabled() {
+		return time.Time{}
+	}
+
+	local := t.In(p.Location())
+	next := time.Date(local.Year(), local.Month(), local.Day(), p.DigestHour, 0, 0, 0, local.Location())
+	if p.DigestFrequency == DigestWeekly {
+		days := (p.DigestWeekday - int(next.Weekday()) + 7) % 7
+		next = next.AddDate(0, 0, days)
+	}
+	for !next.After(t) {
+		if p.DigestFrequency == DigestWeekly {
+			next = next.AddDate(0, 0, 7)
+		} else {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
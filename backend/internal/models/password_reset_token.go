@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordResetToken is a one-time link emailed to reset a forgotten
+// password. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	User      *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`         // Set when the password was reset or the token replaced
+	IPAddress string     `gorm:"size:45" json:"ip_address"` // Where the reset was requested from
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsUsable returns true if the token hasn't been used and hasn't expired
+func (t *PasswordResetToken) IsUsable() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
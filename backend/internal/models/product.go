@@ -1,6 +1,8 @@
 package models
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,20 +15,23 @@ const (
 	SourceExternal ProductSource = "external"
 )
 
+// Product is an item in a company's catalog. SKUs are unique per company.
 type Product struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
-	SKU           string         `gorm:"uniqueIndex;not null;size:100" json:"sku"`
+	CompanyCode   string         `gorm:"size:50;uniqueIndex:idx_products_company_sku" json:"company_code"`
+	SKU           string         `gorm:"uniqueIndex:idx_products_company_sku;not null;size:100" json:"sku"`
 	Name          string         `gorm:"not null;size:255" json:"name"`
-	NameZh        string         `gorm:"size:255" json:"name_zh"`
-	NameEs        string         `gorm:"size:255" json:"name_es"`
 	Description   string         `gorm:"type:text" json:"description"`
-	DescZh        string         `gorm:"type:text" json:"desc_zh"`
-	DescEs        string         `gorm:"type:text" json:"desc_es"`
 	Category      string         `gorm:"index;size:100" json:"category"`
 	Model         string         `gorm:"size:100" json:"model"`
 	Specification string         `gorm:"type:text" json:"specification"`
-	SpecZh        string         `gorm:"type:text" json:"spec_zh"`
-	SpecEs        string         `gorm:"type:text" json:"spec_es"`
+	// Localized Name/Description/Specification keyed by language code
+	NameTranslations LocalizedText `gorm:"type:text" json:"name_translations"`
+	DescTranslations LocalizedText `gorm:"type:text" json:"description_translations"`
+	SpecTranslations LocalizedText `gorm:"type:text" json:"spec_translations"`
+	// Localized values filled by machine translation, keyed "<field>.<lang>"
+	// (e.g. "name.zh") with the source text they were translated from
+	MachineTranslated LocalizedText `gorm:"type:text" json:"-"`
 	Supplier      string         `gorm:"size:255" json:"supplier"`
 	SupplierCode  string         `gorm:"size:100" json:"supplier_code"` // Supplier's part number
 	Price         float64        `gorm:"not null" json:"price"`
@@ -64,34 +69,100 @@ type ProductImage struct {
 
 // GetLocalizedName returns the product name in the specified language
 func (p *Product) GetLocalizedName(lang string) string {
-	switch lang {
-	case "zh":
-		if p.NameZh != "" {
-			return p.NameZh
-		}
-	case "es":
-		if p.NameEs != "" {
-			return p.NameEs
-		}
+	if name := p.NameTranslations.Get(lang); name != "" {
+		return name
 	}
 	return p.Name
 }
 
+// GetLocalizedDescription returns the product description in the specified language
+func (p *Product) GetLocalizedDescription(lang string) string {
+	if desc := p.DescTranslations.Get(lang); desc != "" {
+		return desc
+	}
+	return p.Description
+}
+
 // GetLocalizedSpec returns the product specification in the specified language
 func (p *Product) GetLocalizedSpec(lang string) string {
-	switch lang {
-	case "zh":
-		if p.SpecZh != "" {
-			return p.SpecZh
-		}
-	case "es":
-		if p.SpecEs != "" {
-			return p.SpecEs
-		}
+	if spec := p.SpecTranslations.Get(lang); spec != "" {
+		return spec
 	}
 	return p.Specification
 }
 
+// Translatable product fields, named after their source columns
+const (
+	ProductFieldName          = "name"
+	ProductFieldDescription   = "description"
+	ProductFieldSpecification = "specification"
+)
+
+// ProductTranslatableFields lists the product fields that have localized values
+var ProductTranslatableFields = []string{ProductFieldName, ProductFieldDescription, ProductFieldSpecification}
+
+// LocalizedField returns the source text and localized values of a translatable field
+func (p *Product) LocalizedField(field string) (string, *LocalizedText) {
+	switch field {
+	case ProductFieldName:
+		return p.Name, &p.NameTranslations
+	case ProductFieldDescription:
+		return p.Description, &p.DescTranslations
+	case ProductFieldSpecification:
+		return p.Specification, &p.SpecTranslations
+	}
+	return "", nil
+}
+
+// IsMachineTranslated returns true if the field's value for lang was filled by machine translation
+func (p *Product) IsMachineTranslated(field, lang string) bool {
+	_, ok := p.MachineTranslated[field+"."+lang]
+	return ok
+}
+
+// MachineSource returns the source text a machine translation was made from
+func (p *Product) MachineSource(field, lang string) string {
+	return p.MachineTranslated.Get(field + "." + lang)
+}
+
+// SetMachineTranslation stores a machine translation, unless a person has
+// entered a value for that language. Returns false if it was skipped.
+func (p *Product) SetMachineTranslation(field, lang, text, source string) bool {
+	_, translations := p.LocalizedField(field)
+	if translations == nil || text == "" {
+		return false
+	}
+	if translations.Get(lang) != "" && !p.IsMachineTranslated(field, lang) {
+		return false
+	}
+	translations.Set(lang, text)
+	p.MachineTranslated.Set(field+"."+lang, source)
+	return true
+}
+
+// SetHumanTranslation stores a value entered by a person. Unchanged values
+// keep their machine-translated marker so round-tripped forms don't pin them.
+func (p *Product) SetHumanTranslation(field, lang, text string) {
+	_, translations := p.LocalizedField(field)
+	if translations == nil || translations.Get(lang) == text {
+		return
+	}
+	translations.Set(lang, text)
+	p.MachineTranslated.Set(field+"."+lang, "")
+}
+
+// MachineTranslatedLanguages returns the languages of a field filled by machine translation
+func (p *Product) MachineTranslatedLanguages(field string) []string {
+	var langs []string
+	for key := range p.MachineTranslated {
+		if strings.HasPrefix(key, field+".") {
+			langs = append(langs, strings.TrimPrefix(key, field+"."))
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
 // StockStatus returns the stock status
 func (p *Product) StockStatus() string {
 	if p.Stock == 0 {
@@ -3,11 +3,14 @@ package models
 import (
 	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// PurchaseConfig stores configuration for the purchase request module (singleton)
+// PurchaseConfig stores configuration for the purchase request module (one per company)
 type PurchaseConfig struct {
-	ID uint `gorm:"primaryKey" json:"id"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	CompanyCode string `gorm:"size:50;uniqueIndex" json:"company_code"`
 
 	// General Configuration
 	ModuleName           string `gorm:"size:100;default:Solicitudes de Compra" json:"module_name"`
@@ -241,6 +244,17 @@ func GetDefaultPurchaseConfig() PurchaseConfig {
 	}
 }
 
+// FindPurchaseConfig returns the company's purchase configuration, or the
+// defaults if it hasn't saved one
+func FindPurchaseConfig(db *gorm.DB, company string) PurchaseConfig {
+	var config PurchaseConfig
+	if err := db.Where("company_code = ?", company).First(&config).Error; err != nil {
+		config = GetDefaultPurchaseConfig()
+		config.CompanyCode = company
+	}
+	return config
+}
+
 // Helper function to split string by newlines
 func splitLines(s string) []string {
 	var lines []string
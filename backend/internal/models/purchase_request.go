@@ -32,6 +32,7 @@ const (
 type PurchaseRequest struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
 	RequestNumber string         `gorm:"uniqueIndex;not null;size:50" json:"request_number"`
+	CompanyCode   string         `gorm:"size:50;index" json:"company_code"`
 
 	// Legacy single-product fields (kept for backward compatibility)
 	// New multi-product requests should use Items instead
@@ -71,8 +72,14 @@ type PurchaseRequest struct {
 	RejectionReason string     `gorm:"type:text" json:"rejection_reason,omitempty"`
 
 	// Info request (when GM needs more info)
-	InfoRequestedAt *time.Time `json:"info_requested_at,omitempty"`
-	InfoRequestNote string     `gorm:"type:text" json:"info_request_note,omitempty"`
+	InfoRequestedByID *uint      `json:"info_requested_by_id,omitempty"`
+	InfoRequestedBy   *User      `gorm:"foreignKey:InfoRequestedByID" json:"info_requested_by,omitempty"`
+	InfoRequestedAt   *time.Time `json:"info_requested_at,omitempty"`
+	InfoRequestNote   string     `gorm:"type:text" json:"info_request_note,omitempty"`
+
+	// Requester's answer to the info request
+	InfoResponse    string     `gorm:"type:text" json:"info_response,omitempty"`
+	InfoRespondedAt *time.Time `json:"info_responded_at,omitempty"`
 
 	// Purchase Order number (assigned when approved)
 	// Using pointer so NULL values don't violate unique constraint
@@ -97,6 +104,9 @@ type PurchaseRequest struct {
 	CancelledAt       *time.Time `json:"cancelled_at,omitempty"`
 	CancellationNotes string     `gorm:"type:text" json:"cancellation_notes,omitempty"`
 
+	// Last overdue reminder (pending approval or approved but not purchased)
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty"`
+
 	// Admin notes (visible to admin, purchase_admin, gm, and requester)
 	AdminNotes string `gorm:"type:text" json:"admin_notes,omitempty"`
 
@@ -104,6 +114,7 @@ type PurchaseRequest struct {
 	JustificationTranslated     JSONB `gorm:"type:jsonb" json:"justification_translated,omitempty"`
 	RejectionReasonTranslated   JSONB `gorm:"type:jsonb" json:"rejection_reason_translated,omitempty"`
 	InfoRequestNoteTranslated   JSONB `gorm:"type:jsonb" json:"info_request_note_translated,omitempty"`
+	InfoResponseTranslated      JSONB `gorm:"type:jsonb" json:"info_response_translated,omitempty"`
 	PurchaseNotesTranslated     JSONB `gorm:"type:jsonb" json:"purchase_notes_translated,omitempty"`
 	DeliveryNotesTranslated     JSONB `gorm:"type:jsonb" json:"delivery_notes_translated,omitempty"`
 	CancellationNotesTranslated JSONB `gorm:"type:jsonb" json:"cancellation_notes_translated,omitempty"`
@@ -162,10 +173,14 @@ func (pr *PurchaseRequest) HasAmazonItems() bool {
 }
 
 // GenerateRequestNumber generates a unique purchase request number (PR-YYYY-XXXX)
+// Companies with a number prefix get their own sequence (PR-MX-YYYY-XXXX)
 // Uses MAX to find the highest number used this year, ensuring numbers are never reused
-func GenerateRequestNumber(db *gorm.DB) string {
+func GenerateRequestNumber(db *gorm.DB, numberPrefix string) string {
 	year := time.Now().Year()
 	prefix := fmt.Sprintf("PR-%d-", year)
+	if numberPrefix != "" {
+		prefix = fmt.Sprintf("PR-%s-%d-", numberPrefix, year)
+	}
 
 	// Find the highest PR number for this year
 	var lastRequest PurchaseRequest
@@ -175,7 +190,7 @@ func GenerateRequestNumber(db *gorm.DB) string {
 
 	if err != nil {
 		// No requests this year yet, start at 0001
-		return fmt.Sprintf("PR-%d-%04d", year, 1)
+		return fmt.Sprintf("%s%04d", prefix, 1)
 	}
 
 	// Extract the sequence number from the last request number
@@ -184,17 +199,17 @@ func GenerateRequestNumber(db *gorm.DB) string {
 	if len(lastNum) >= 4 {
 		seqStr := lastNum[len(lastNum)-4:]
 		seq, _ := strconv.Atoi(seqStr)
-		return fmt.Sprintf("PR-%d-%04d", year, seq+1)
+		return fmt.Sprintf("%s%04d", prefix, seq+1)
 	}
 
 	// Fallback: count all requests (shouldn't happen with proper data)
 	var count int64
 	db.Model(&PurchaseRequest{}).Where("request_number LIKE ?", prefix+"%").Count(&count)
-	return fmt.Sprintf("PR-%d-%04d", year, count+1)
+	return fmt.Sprintf("%s%04d", prefix, count+1)
 }
 
 // GeneratePONumber generates a purchase order number from the request number
-// This converts PR-YYYY-XXXX to PO-YYYY-XXXX so both numbers match
+// This converts PR-YYYY-XXXX to PO-YYYY-XXXX (PR-MX-YYYY-XXXX to PO-MX-YYYY-XXXX) so both numbers match
 // This is called when a request is approved
 func GeneratePONumber(requestNumber string) *string {
 	// Simply replace PR- prefix with PO- prefix to keep the same number
@@ -222,6 +237,11 @@ func (pr *PurchaseRequest) CanRequestInfo() bool {
 	return pr.Status == StatusPending
 }
 
+// CanRespondToInfo checks if the requester can answer an info request
+func (pr *PurchaseRequest) CanRespondToInfo() bool {
+	return pr.Status == StatusInfoRequested
+}
+
 // CanBeMarkedPurchased checks if the request can be marked as purchased
 func (pr *PurchaseRequest) CanBeMarkedPurchased() bool {
 	return pr.Status == StatusApproved
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode is a single-use code that can stand in for a TOTP code when
+// the user has lost their authenticator. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null;index" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
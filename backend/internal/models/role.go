@@ -0,0 +1,176 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Permission is something a role allows its users to do
+type Permission string
+
+const (
+	PermUsersManage         Permission = "users.manage"
+	PermRolesManage         Permission = "roles.manage"
+	PermSettingsManage      Permission = "settings.manage"
+	PermLogsView            Permission = "logs.view"
+	PermAnalyticsView       Permission = "analytics.view"
+	PermProductsManage      Permission = "products.manage"
+	PermRequestsViewAll     Permission = "requests.view_all"
+	PermRequestsAutoApprove Permission = "requests.auto_approve"
+	PermApprovalsView       Permission = "approvals.view"
+	PermApprovalsDecide     Permission = "approvals.decide"
+	PermOrdersView          Permission = "orders.view"
+	PermOrdersManage        Permission = "orders.manage"
+	PermPurchaseConfig      Permission = "purchase_config.manage"
+	PermCompaniesManage     Permission = "companies.manage"
+)
+
+// PermissionInfo describes a permission for the admin assigning it
+type PermissionInfo struct {
+	Key         Permission `json:"key"`
+	Description string     `json:"description"`
+}
+
+// PermissionCatalog lists every permission a role can grant
+var PermissionCatalog = []PermissionInfo{
+	{PermUsersManage, "Create, approve, edit and disable users and service accounts"},
+	{PermRolesManage, "Define roles and the permissions they grant"},
+	{PermSettingsManage, "Change system settings: email, webhooks, chat, Amazon, glossary, directory"},
+	{PermLogsView, "See activity and audit logs, and end sessions"},
+	{PermAnalyticsView, "See purchasing analytics"},
+	{PermProductsManage, "Add, edit and import catalog products and upload images"},
+	{PermRequestsViewAll, "See everyone's purchase requests"},
+	{PermRequestsAutoApprove, "Own purchase requests are approved when submitted"},
+	{PermApprovalsView, "See pending approvals and generate AI summaries"},
+	{PermApprovalsDecide, "Approve, reject and ask for more information on requests; receives approval notifications"},
+	{PermOrdersView, "See approved orders waiting to be purchased"},
+	{PermOrdersManage, "Purchase, deliver and cancel approved orders; receives new order notifications"},
+	{PermPurchaseConfig, "Configure the purchase request module"},
+	{PermCompaniesManage, "Add and edit companies and work in any company"},
+}
+
+// IsValid returns true if the permission is in the catalog
+func (p Permission) IsValid() bool {
+	for _, info := range PermissionCatalog {
+		if info.Key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is the set of permissions a role grants
+type PermissionSet map[Permission]bool
+
+// Has returns true if the set includes the permission
+func (s PermissionSet) Has(p Permission) bool {
+	return s[p]
+}
+
+// Role is a named set of permissions given to users; a user's Role field
+// holds its name. The five built-in roles are seeded with their default
+// permissions and can't be deleted. Admin always keeps roles.manage, so
+// roles can't be locked out of being changed.
+type Role struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        UserRole   `gorm:"size:50;not null;uniqueIndex" json:"name"`
+	DisplayName string     `gorm:"size:100" json:"display_name"`
+	Description string     `gorm:"size:500" json:"description"`
+	Permissions StringList `gorm:"type:text" json:"permissions"`
+	BuiltIn     bool       `gorm:"default:false" json:"built_in"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// PermissionSet returns the permissions the role grants
+func (r *Role) PermissionSet() PermissionSet {
+	set := make(PermissionSet, len(r.Permissions))
+	for _, p := range r.Permissions {
+		set[Permission(p)] = true
+	}
+	return set
+}
+
+// roleNamePattern is what role names look like: they're stored on users and
+// in tokens, so they stay simple identifiers
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// IsValidRoleName returns true if name can be used for a new role
+func IsValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// DefaultRoles are the built-in roles with the permissions they had before
+// roles were configurable. Admin gets everything except deciding on
+// requests, which was left to general managers.
+func DefaultRoles() []Role {
+	admin := StringList{}
+	for _, info := range PermissionCatalog {
+		if info.Key != PermApprovalsDecide && info.Key != PermRequestsAutoApprove {
+			admin = append(admin, string(info.Key))
+		}
+	}
+	return []Role{
+		{Name: RoleAdmin, DisplayName: "Admin", Permissions: admin},
+		{Name: RolePurchaseAdmin, DisplayName: "Purchase Admin", Permissions: StringList{
+			string(PermProductsManage), string(PermRequestsViewAll), string(PermApprovalsView),
+			string(PermOrdersView), string(PermOrdersManage), string(PermPurchaseConfig),
+		}},
+		{Name: RoleSupplyChainManager, DisplayName: "Supply Chain Manager", Permissions: StringList{
+			string(PermProductsManage), string(PermRequestsViewAll), string(PermAnalyticsView),
+		}},
+		{Name: RoleGeneralManager, DisplayName: "General Manager", Permissions: StringList{
+			string(PermRequestsViewAll), string(PermRequestsAutoApprove),
+			string(PermApprovalsView), string(PermApprovalsDecide),
+		}},
+		{Name: RoleEmployee, DisplayName: "Employee", Permissions: StringList{}},
+	}
+}
+
+// FindRole returns the role with the given name
+func FindRole(db *gorm.DB, name UserRole) (*Role, error) {
+	var role Role
+	if err := db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// RoleExists returns true if users can be given the role
+func RoleExists(db *gorm.DB, name UserRole) bool {
+	var count int64
+	db.Model(&Role{}).Where("name = ?", name).Count(&count)
+	return count > 0
+}
+
+// RolePermissions returns the permissions of the role with the given name;
+// unknown roles have none
+func RolePermissions(db *gorm.DB, name UserRole) PermissionSet {
+	role, err := FindRole(db, name)
+	if err != nil {
+		return PermissionSet{}
+	}
+	return role.PermissionSet()
+}
+
+// RolesWithPermission returns the names of the roles granting any of the
+// permissions, e.g. to find who to notify
+func RolesWithPermission(db *gorm.DB, permissions ...Permission) ([]UserRole, error) {
+	var roles []Role
+	if err := db.Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	names := []UserRole{}
+	for _, role := range roles {
+		set := role.PermissionSet()
+		for _, p := range permissions {
+			if set.Has(p) {
+				names = append(names, role.Name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
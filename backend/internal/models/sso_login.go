@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// SSOLogin is a single sign-on attempt waiting for the identity provider to
+// send the user back. It keeps what the callback needs to finish the
+// authorization code flow; only SHA-256 hashes of the state and of the
+// browser binding are stored.
+type SSOLogin struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	StateHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	BindingHash  string     `gorm:"size:64" json:"-"` // Hash of the browser cookie set when the login started
+	Nonce        string     `gorm:"size:100;not null" json:"-"`
+	CodeVerifier string     `gorm:"size:100;not null" json:"-"` // PKCE verifier
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	IPAddress    string     `gorm:"size:45" json:"ip_address"` // Where the login was started from
+	CreatedAt    time.Time  `json:"created_at"`
+}
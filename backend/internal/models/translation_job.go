@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// TranslationJobStatus represents the state of a queued translation job
+type TranslationJobStatus string
+
+const (
+	TranslationJobPending    TranslationJobStatus = "pending"
+	TranslationJobProcessing TranslationJobStatus = "processing"
+	TranslationJobCompleted  TranslationJobStatus = "completed"
+	TranslationJobFailed     TranslationJobStatus = "failed"
+)
+
+// TranslationJob is a persisted request to translate a text field into all
+// supported languages and write the result to (TargetTable, RecordID, TargetColumn)
+type TranslationJob struct {
+	ID           uint                 `gorm:"primaryKey" json:"id"`
+	TargetTable  string               `gorm:"size:100;not null;index:idx_translation_job_target" json:"target_table"`
+	RecordID     uint                 `gorm:"not null;index:idx_translation_job_target" json:"record_id"`
+	TargetColumn string               `gorm:"size:100;not null;index:idx_translation_job_target" json:"target_column"`
+	SourceText   string               `gorm:"type:text" json:"source_text"`
+	Initial      JSONB                `gorm:"type:jsonb" json:"initial,omitempty"` // Partial translation computed at request time
+	Status       TranslationJobStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Attempts     int                  `gorm:"default:0" json:"attempts"`
+	MaxAttempts  int                  `gorm:"default:5" json:"max_attempts"`
+	LastError    string               `gorm:"type:text" json:"last_error,omitempty"`
+	NextRunAt    time.Time            `gorm:"index" json:"next_run_at"`
+	CompletedAt  *time.Time           `json:"completed_at,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+// NewTranslationJob creates a pending job ready to run immediately
+func NewTranslationJob(table string, recordID uint, column, text string) *TranslationJob {
+	return &TranslationJob{
+		TargetTable:  table,
+		RecordID:     recordID,
+		TargetColumn: column,
+		SourceText:   text,
+		Status:       TranslationJobPending,
+		MaxAttempts:  5,
+		NextRunAt:    time.Now(),
+	}
+}
+
+// CanRetry returns true if the job has attempts left
+func (j *TranslationJob) CanRetry() bool {
+	return j.Attempts < j.MaxAttempts
+}
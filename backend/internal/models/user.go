@@ -6,6 +6,8 @@ import (
 	"gorm.io/gorm"
 )
 
+// UserRole is the name of the role a user has. Roles and the permissions
+// they grant are stored in the roles table; these are the built-in ones.
 type UserRole string
 
 const (
@@ -37,6 +39,7 @@ type User struct {
 	CostCenter     string         `gorm:"size:50" json:"cost_center"`
 	Department     string         `gorm:"size:100" json:"department"`
 	Status         UserStatus     `gorm:"default:'pending';size:20" json:"status"` // pending, approved, rejected, disabled
+	Language       string         `gorm:"size:10" json:"language"`                  // Preferred language for emails and notifications
 
 	// Approval tracking
 	ApprovedByID    *uint      `json:"approved_by_id,omitempty"`
@@ -44,11 +47,40 @@ type User struct {
 	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
 	RejectionReason string     `gorm:"size:500" json:"rejection_reason,omitempty"`
 
+	// When the last password reset link was sent, so reset emails can be
+	// limited per user
+	PasswordResetSentAt *time.Time `json:"-"`
+
+	// Two-factor authentication. The TOTP secret is encrypted; it's set when
+	// enrollment starts and only required at login once TOTPEnabledAt is set.
+	TOTPSecret      string     `gorm:"size:255" json:"-"`
+	TOTPEnabledAt   *time.Time `json:"two_factor_enabled_at,omitempty"`
+	TOTPLastCounter int64      `json:"-"` // Time step of the last accepted code, so a code can't be replayed
+
+	// Single sign-on. The subject is the user's ID at the identity provider;
+	// it links the account on the first SSO login.
+	SSOSubject string `gorm:"size:255;index" json:"-"`
+
+	// Directory (LDAP) accounts log in with their directory password and are
+	// kept up to date by directory sync. LDAPRemovedAt is set when sync
+	// disabled the user because they left the directory.
+	LDAPDN        string     `gorm:"column:ldap_dn;size:500;index" json:"-"`
+	LDAPRemovedAt *time.Time `json:"-"`
+
+	// Service accounts are used by scripts and integrations. They have no
+	// password, can't log in and call the API with API keys instead.
+	ServiceAccount bool `gorm:"default:false;index" json:"service_account"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// TwoFactorEnabled returns true if logging in needs a TOTP or recovery code
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
 // IsApproved returns true if user account is approved and can login
 func (u *User) IsApproved() bool {
 	return u.Status == UserStatusApproved
@@ -74,52 +106,3 @@ func (u *User) CanLogin() bool {
 	return u.Status == UserStatusApproved
 }
 
-func (u *User) IsAdmin() bool {
-	return u.Role == RoleAdmin
-}
-
-func (u *User) IsGeneralManager() bool {
-	return u.Role == RoleGeneralManager
-}
-
-func (u *User) IsSupplyChainManager() bool {
-	return u.Role == RoleSupplyChainManager
-}
-
-func (u *User) IsPurchaseAdmin() bool {
-	return u.Role == RolePurchaseAdmin
-}
-
-// CanApprove returns true if user can approve/reject purchase requests
-// Only General Manager can approve - Admin can view but not approve
-func (u *User) CanApprove() bool {
-	return u.Role == RoleGeneralManager
-}
-
-// CanViewApprovals returns true if user can view all approvals (admin, purchase_admin, GM)
-func (u *User) CanViewApprovals() bool {
-	return u.Role == RoleGeneralManager || u.Role == RoleAdmin || u.Role == RolePurchaseAdmin
-}
-
-func (u *User) CanManageUsers() bool {
-	return u.Role == RoleAdmin
-}
-
-// CanManagePurchases returns true if user can manage approved orders
-// (mark as purchased, add notes, export)
-func (u *User) CanManagePurchases() bool {
-	return u.Role == RoleAdmin || u.Role == RolePurchaseAdmin
-}
-
-// CanConfigurePurchases returns true if user can configure the purchase module
-func (u *User) CanConfigurePurchases() bool {
-	return u.Role == RoleAdmin || u.Role == RolePurchaseAdmin
-}
-
-func (u *User) CanConfigureAmazon() bool {
-	return u.Role == RoleAdmin
-}
-
-func (u *User) CanViewAllRequests() bool {
-	return u.Role == RoleAdmin || u.Role == RoleSupplyChainManager || u.Role == RoleGeneralManager || u.Role == RolePurchaseAdmin
-}
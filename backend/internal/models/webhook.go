@@ -0,0 +1,177 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is the type of event sent to webhook subscribers
+type WebhookEvent string
+
+const (
+	WebhookRequestCreated       WebhookEvent = "request.created"
+	WebhookRequestApproved      WebhookEvent = "request.approved"
+	WebhookRequestRejected      WebhookEvent = "request.rejected"
+	WebhookRequestInfoRequested WebhookEvent = "request.info_requested"
+	WebhookRequestInfoProvided  WebhookEvent = "request.info_provided"
+	WebhookRequestPurchased     WebhookEvent = "request.purchased"
+	WebhookRequestDelivered     WebhookEvent = "request.delivered"
+	WebhookRequestCancelled     WebhookEvent = "request.cancelled"
+
+	// WebhookPing is only sent by the admin "test" action
+	WebhookPing WebhookEvent = "webhook.ping"
+)
+
+// AllWebhookEvents lists the events a subscription can filter on
+var AllWebhookEvents = []WebhookEvent{
+	WebhookRequestCreated,
+	WebhookRequestApproved,
+	WebhookRequestRejected,
+	WebhookRequestInfoRequested,
+	WebhookRequestInfoProvided,
+	WebhookRequestPurchased,
+	WebhookRequestDelivered,
+	WebhookRequestCancelled,
+}
+
+// IsValid returns true if subscriptions can filter on the event
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range AllWebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventForStatus returns the event for a request entering a status.
+// Creation and answers to info requests are their own events; a request going
+// back to pending has none.
+func WebhookEventForStatus(status RequestStatus) (WebhookEvent, bool) {
+	switch status {
+	case StatusApproved:
+		return WebhookRequestApproved, true
+	case StatusRejected:
+		return WebhookRequestRejected, true
+	case StatusInfoRequested:
+		return WebhookRequestInfoRequested, true
+	case StatusPurchased:
+		return WebhookRequestPurchased, true
+	case StatusDelivered:
+		return WebhookRequestDelivered, true
+	case StatusCancelled:
+		return WebhookRequestCancelled, true
+	}
+	return "", false
+}
+
+// WebhookEvents is a list of events stored as a JSON array
+type WebhookEvents []WebhookEvent
+
+// Value implements driver.Valuer interface
+func (w WebhookEvents) Value() (driver.Value, error) {
+	if w == nil {
+		w = WebhookEvents{}
+	}
+	data, err := json.Marshal([]WebhookEvent(w))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner interface
+func (w *WebhookEvents) Scan(value interface{}) error {
+	if value == nil {
+		*w = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("invalid type for WebhookEvents")
+	}
+	if len(data) == 0 {
+		*w = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]WebhookEvent)(w))
+}
+
+// WebhookSubscription is an admin-registered endpoint that receives signed
+// JSON payloads for the events it subscribes to
+type WebhookSubscription struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Name        string        `gorm:"size:100;not null" json:"name"`
+	URL         string        `gorm:"size:2000;not null" json:"url"`
+	Description string        `gorm:"size:500" json:"description"`
+	Events      WebhookEvents `gorm:"type:text" json:"events"`    // Empty subscribes to every event
+	Secret      string        `gorm:"size:500;not null" json:"-"` // Encrypted HMAC-SHA256 key
+	IsActive    bool          `json:"is_active"`
+
+	CreatedByID *uint          `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Subscribes returns true if the subscription wants the event
+func (w *WebhookSubscription) Subscribes(event WebhookEvent) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus represents the state of one delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySending   WebhookDeliveryStatus = "sending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one subscription. The dispatcher
+// retries failed attempts with backoff until MaxAttempts is reached.
+type WebhookDelivery struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint         `gorm:"not null;index" json:"subscription_id"`
+	EventID        string       `gorm:"size:50;index" json:"event_id"` // Same for every subscription and redelivery of an event
+	Event          WebhookEvent `gorm:"size:50;index" json:"event"`
+	RequestID      *uint        `gorm:"index" json:"request_id,omitempty"`
+	Payload        string       `gorm:"type:text" json:"payload,omitempty"`
+	RedeliveryOfID *uint        `json:"redelivery_of_id,omitempty"`
+
+	Status         WebhookDeliveryStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Attempts       int                   `gorm:"default:0" json:"attempts"`
+	MaxAttempts    int                   `gorm:"default:10" json:"max_attempts"`
+	NextAttemptAt  time.Time             `gorm:"index" json:"next_attempt_at"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	ResponseBody   string                `gorm:"type:text" json:"response_body,omitempty"` // Truncated
+	LastError      string                `gorm:"type:text" json:"last_error,omitempty"`
+	DurationMs     int64                 `json:"duration_ms,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// CanRetry returns true if the delivery has attempts left
+func (d *WebhookDelivery) CanRetry() bool {
+	return d.Attempts < d.MaxAttempts
+}
@@ -69,11 +69,15 @@ func (s *AutomationService) Initialize() error {
 	return nil
 }
 
-// SetCredentials sets the Amazon Business credentials
+// SetCredentials sets the Amazon Business credentials. Switching to another
+// account (each company has its own) requires logging in again.
 func (s *AutomationService) SetCredentials(email, password, marketplace string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if email != s.email {
+		s.isLoggedIn = false
+	}
 	s.email = email
 	s.password = password
 	if marketplace != "" {
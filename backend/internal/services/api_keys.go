@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// apiKeyIDLength is the number of hex characters after vsk_ in a key's
+// public prefix
+const apiKeyIDLength = 12
+
+// AuditActionAPIKeyUsed is the audit log action of requests made with an API key
+const AuditActionAPIKeyUsed = "api_key.used"
+
+// CreateAPIKey issues a key for a service account. The key is returned only
+// here; just its hash is stored.
+func (as *AuthService) CreateAPIKey(account *models.User, name string, scopes []string, expiresAt *time.Time, createdByID uint) (string, *models.APIKey, error) {
+	if !account.ServiceAccount {
+		return "", nil, errors.New("API keys can only be issued to service accounts")
+	}
+
+	id := make([]byte, apiKeyIDLength/2)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	prefix := models.APIKeyPrefix + hex.EncodeToString(id)
+	key := prefix + "_" + hex.EncodeToString(secret)
+
+	apiKey := &models.APIKey{
+		ServiceAccountID: account.ID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          hashAPIKey(key),
+		Scopes:           models.StringList(scopes),
+		ExpiresAt:        expiresAt,
+		CreatedByID:      &createdByID,
+	}
+	if err := as.db.Create(apiKey).Error; err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// AuthenticateAPIKey returns the key, with its service account, if it's
+// valid, unexpired and its account is active
+func (as *AuthService) AuthenticateAPIKey(key string) (*models.APIKey, error) {
+	prefixLength := len(models.APIKeyPrefix) + apiKeyIDLength
+	if !strings.HasPrefix(key, models.APIKeyPrefix) || len(key) <= prefixLength {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	if err := as.db.Preload("ServiceAccount").Where("prefix = ?", key[:prefixLength]).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashAPIKey(key))) != 1 {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, models.ErrInvalidAPIKey
+	}
+	if !apiKey.IsUsable() {
+		return nil, models.ErrAPIKeyExpired
+	}
+	// The account is missing once deleted
+	account := apiKey.ServiceAccount
+	if account == nil || !account.ServiceAccount {
+		return nil, models.ErrInvalidAPIKey
+	}
+	if !account.CanLogin() {
+		return nil, models.ErrServiceAccountDisabled
+	}
+
+	return &apiKey, nil
+}
+
+// RecordAPIKeyUse marks the key as used and adds the request to the audit log
+func (as *AuthService) RecordAPIKeyUse(apiKey *models.APIKey, method, path string, status int, ipAddress, userAgent string) {
+	now := time.Now()
+	if err := as.db.Model(&models.APIKey{}).Where("id = ?", apiKey.ID).
+		UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ipAddress}).Error; err != nil {
+		log.Printf("Failed to update last use of API key %d: %v", apiKey.ID, err)
+	}
+
+	entry := models.NewAuditLog(apiKey.ServiceAccountID, AuditActionAPIKeyUsed, "api_key", apiKey.ID,
+		"", fmt.Sprintf("%s %s %d", method, path, status), ipAddress, userAgent)
+	if err := as.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to audit use of API key %d: %v", apiKey.ID, err)
+	}
+}
+
+// hashAPIKey returns the stored form of an API key
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,10 +2,14 @@ package services
 
 import (
 	"errors"
+	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"vista-backend/internal/models"
+	"vista-backend/internal/services/directory"
+	"vista-backend/pkg/crypto"
 	"vista-backend/pkg/jwt"
 )
 
@@ -17,60 +21,205 @@ var (
 	ErrUserDisabled         = errors.New("user account is disabled")
 	ErrEmployeeNumberExists = errors.New("employee number already exists")
 	ErrEmailExists          = errors.New("email already exists")
+	ErrSessionEnded         = errors.New("session has ended")
+	ErrRefreshTokenReused   = errors.New("refresh token was already used")
+	ErrDirectoryUnavailable = errors.New("directory is unavailable")
+	ErrDirectoryConflict    = errors.New("directory account conflicts with another user")
 )
 
 type AuthService struct {
-	db         *gorm.DB
-	jwtService *jwt.JWTService
+	db          *gorm.DB
+	jwtService  *jwt.JWTService
+	encryption  *crypto.EncryptionService // For TOTP secrets
+	loginPolicy LoginPolicy
+	twoFactor   TwoFactorPolicy
+	directory   *directory.Service // Directory users log in with their directory password
 }
 
-func NewAuthService(db *gorm.DB, jwtService *jwt.JWTService) *AuthService {
+func NewAuthService(db *gorm.DB, jwtService *jwt.JWTService, encryption *crypto.EncryptionService, loginPolicy LoginPolicy, twoFactor TwoFactorPolicy) *AuthService {
 	return &AuthService{
-		db:         db,
-		jwtService: jwtService,
+		db:          db,
+		jwtService:  jwtService,
+		encryption:  encryption,
+		loginPolicy: loginPolicy,
+		twoFactor:   twoFactor,
 	}
 }
 
-// Login authenticates a user by email and returns tokens
-func (as *AuthService) Login(email, password string) (*jwt.TokenPair, *models.User, error) {
+// WithDirectory lets users log in with their directory (LDAP) password
+func (as *AuthService) WithDirectory(dir *directory.Service) *AuthService {
+	as.directory = dir
+	return as
+}
+
+// Login authenticates a user by email and starts a session for them. If they
+// use two-factor authentication, or their role requires it, no session is
+// started; a *TwoFactorChallenge is returned with the user instead.
+func (as *AuthService) Login(email, password, ipAddress, userAgent string) (*jwt.TokenPair, *models.User, error) {
+	// Refuse attempts too soon after failed ones, before checking anything
+	if err := as.CheckLoginThrottle(email, ipAddress); err != nil {
+		return nil, nil, err
+	}
+
 	var user models.User
-	if err := as.db.Where("email = ?", email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	err := as.db.Where("email = ?", email).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	found := err == nil
+
+	if as.directory != nil && as.directory.Enabled() && (!found || user.LDAPDN != "") {
+		// Directory users, and anyone unknown here, are checked against the directory
+		dirUser, err := as.loginWithDirectory(email, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		user = *dirUser
+
+		if err := checkUserStatus(&user); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// Service accounts have no password; they use API keys
+		if !found || user.ServiceAccount {
+			return nil, nil, ErrInvalidCredentials
+		}
+
+		// Check user status
+		if err := checkUserStatus(&user); err != nil {
+			return nil, nil, err
+		}
+
+		// Verify password
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
 			return nil, nil, ErrInvalidCredentials
 		}
+	}
+
+	challenge, err := as.twoFactorChallenge(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return nil, &user, challenge
+	}
+
+	tokens, err := as.StartSession(&user, email, ipAddress, userAgent)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	// Check user status
+	return tokens, &user, nil
+}
+
+// loginWithDirectory checks a password against the directory and returns
+// the user, updated from their directory entry
+func (as *AuthService) loginWithDirectory(email, password string) (*models.User, error) {
+	user, err := as.directory.Authenticate(email, password)
+	switch {
+	case err == nil:
+		return user, nil
+	case errors.Is(err, directory.ErrInvalidCredentials):
+		return nil, ErrInvalidCredentials
+	case errors.Is(err, directory.ErrAccountConflict):
+		log.Printf("Directory login of %s refused: %v", email, err)
+		return nil, ErrDirectoryConflict
+	default:
+		log.Printf("Directory login of %s failed: %v", email, err)
+		return nil, ErrDirectoryUnavailable
+	}
+}
+
+// checkUserStatus returns why a user can't log in, or nil if they can
+func checkUserStatus(user *models.User) error {
 	switch user.Status {
 	case models.UserStatusPending:
-		return nil, nil, ErrUserPending
+		return ErrUserPending
 	case models.UserStatusRejected:
-		return nil, nil, ErrUserRejected
+		return ErrUserRejected
 	case models.UserStatusDisabled:
-		return nil, nil, ErrUserDisabled
+		return ErrUserDisabled
 	case models.UserStatusApproved:
-		// OK, continue with login
+		return nil
 	default:
-		return nil, nil, ErrUserDisabled
+		return ErrUserDisabled
 	}
+}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, nil, ErrInvalidCredentials
+// StartSession records a successful login as a new session and issues its
+// tokens. The session lasts as long as its refresh token and is extended on
+// every refresh. Its refresh tokens form a family: each refresh rotates the
+// token, and reusing an old one revokes the session.
+func (as *AuthService) StartSession(user *models.User, identifier, ipAddress, userAgent string) (*jwt.TokenPair, error) {
+	sessionID, err := jwt.NewID()
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate tokens
-	tokens, err := as.jwtService.GenerateTokenPair(user.ID, user.EmployeeNumber, string(user.Role))
+	tokens, err := as.jwtService.GenerateTokenPair(user.ID, user.EmployeeNumber, string(user.Role), sessionID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return tokens, &user, nil
+	session := models.NewActivityLog(models.ActivityLogin, &user.ID, identifier, ipAddress, userAgent).
+		WithSession(sessionID, time.Now().Add(as.jwtService.RefreshTokenExpiry()))
+	session.RefreshTokenID = tokens.RefreshTokenID
+	if err := as.db.Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// ValidateSession checks that the session a token was issued for is still
+// active. Tokens without a session, issued before sessions were tracked, are
+// rejected.
+func (as *AuthService) ValidateSession(claims *jwt.Claims) error {
+	_, err := as.findSession(claims)
+	return err
+}
+
+// RolePermissions returns the permissions the role grants
+func (as *AuthService) RolePermissions(role models.UserRole) models.PermissionSet {
+	return models.RolePermissions(as.db, role)
+}
+
+// UserCompany returns the code of the company the user belongs to
+func (as *AuthService) UserCompany(userID uint) (string, error) {
+	var user models.User
+	if err := as.db.Select("id", "company_code").First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	return models.UserCompanyCode(as.db, &user), nil
+}
+
+// CompanyExists returns true if there is a company with the given code
+func (as *AuthService) CompanyExists(code string) bool {
+	return models.CompanyExists(as.db, code)
+}
+
+// findSession returns the active session of the token's user
+func (as *AuthService) findSession(claims *jwt.Claims) (*models.ActivityLog, error) {
+	session, err := models.FindActiveSession(as.db, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionEnded
+		}
+		return nil, err
+	}
+	if session.UserID == nil || *session.UserID != claims.UserID {
+		return nil, ErrSessionEnded
+	}
+	return session, nil
+}
+
+// EndSession ends a session, e.g. on logout; its tokens stop working immediately
+func (as *AuthService) EndSession(sessionID, details string) error {
+	return models.EndSession(as.db, sessionID, details)
 }
 
 // Register creates a new user with pending status
-func (as *AuthService) Register(employeeNumber, name, email, password string) (*models.User, error) {
+func (as *AuthService) Register(employeeNumber, name, email, password, language string) (*models.User, error) {
 	// Check if employee number already exists
 	var existingUser models.User
 	if err := as.db.Where("employee_number = ?", employeeNumber).First(&existingUser).Error; err == nil {
@@ -96,6 +245,7 @@ func (as *AuthService) Register(employeeNumber, name, email, password string) (*
 		PasswordHash:   hashedPassword,
 		Role:           models.RoleEmployee, // Default role, admin will assign on approval
 		Status:         models.UserStatusPending,
+		Language:       language,
 	}
 
 	if err := as.db.Create(&user).Error; err != nil {
@@ -105,12 +255,22 @@ func (as *AuthService) Register(employeeNumber, name, email, password string) (*
 	return &user, nil
 }
 
-// RefreshTokens refreshes the access token using a valid refresh token
-func (as *AuthService) RefreshTokens(refreshToken string) (*jwt.TokenPair, error) {
+// RefreshTokens rotates a refresh token: it returns new tokens for the
+// session and the old refresh token stops working. Presenting a refresh token
+// that was already rotated means it leaked, so the session is revoked and the
+// reuse is logged.
+func (as *AuthService) RefreshTokens(refreshToken, ipAddress, userAgent string) (*jwt.TokenPair, error) {
 	claims, err := as.jwtService.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
+	session, err := as.findSession(claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID != session.RefreshTokenID {
+		return nil, as.revokeReusedSession(session, ipAddress, userAgent)
+	}
 
 	// Verify user still exists and is approved
 	var user models.User
@@ -123,7 +283,46 @@ func (as *AuthService) RefreshTokens(refreshToken string) (*jwt.TokenPair, error
 	}
 
 	// Generate new tokens
-	return as.jwtService.GenerateTokenPair(user.ID, user.EmployeeNumber, string(user.Role))
+	tokens, err := as.jwtService.GenerateTokenPair(user.ID, user.EmployeeNumber, string(user.Role), claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Swap in the new refresh token only if the old one is still current, so
+	// two refreshes racing with the same token can't both succeed. The session
+	// stays alive as long as the new refresh token.
+	result := as.db.Model(&models.ActivityLog{}).
+		Where("id = ? AND refresh_token_id = ? AND ended_at IS NULL", session.ID, claims.ID).
+		Updates(map[string]interface{}{
+			"refresh_token_id": tokens.RefreshTokenID,
+			"expires_at":       time.Now().Add(as.jwtService.RefreshTokenExpiry()),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, as.revokeReusedSession(session, ipAddress, userAgent)
+	}
+
+	return tokens, nil
+}
+
+// revokeReusedSession ends a session whose refresh token was reused and logs
+// the security event. It returns ErrRefreshTokenReused.
+func (as *AuthService) revokeReusedSession(session *models.ActivityLog, ipAddress, userAgent string) error {
+	if err := models.EndSession(as.db, session.SessionID, "Revoked: refresh token reused"); err != nil {
+		return err
+	}
+
+	entry := models.NewActivityLog(models.ActivityTokenReuse, session.UserID, session.Identifier, ipAddress, userAgent).
+		WithSuccess(false).
+		WithDetails("Refresh token reused; session revoked")
+	entry.SessionID = session.SessionID
+	if err := as.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to log refresh token reuse for session %s: %v", session.SessionID, err)
+	}
+
+	return ErrRefreshTokenReused
 }
 
 // GetUserByID retrieves a user by ID
@@ -0,0 +1,168 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+)
+
+// justificationLimit caps how much of the justification is shown on a card
+const justificationLimit = 300
+
+// Field is one label/value pair shown on a card
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Card is a provider-independent chat message
+type Card struct {
+	Title     string
+	Text      string
+	Fields    []Field
+	ActionURL string // Absolute link, or empty for no button
+	Action    string // Button label
+	Urgent    bool
+}
+
+// cardStrings are the message keys used for each notification type
+var cardStrings = map[models.NotificationType]struct{ title, text, action string }{
+	models.NotificationUrgentRequest:       {"urgent_title", "urgent_text", "review_request"},
+	models.NotificationNewPendingRequest:   {"pending_title", "pending_text", "review_request"},
+	models.NotificationRequestApproved:     {"approved_title", "approved_text", "view_request"},
+	models.NotificationNewApprovedOrder:    {"approved_order_title", "approved_order_text", "view_order"},
+	models.NotificationReminderPending:     {"reminder_pending_title", "reminder_pending_text", "review_request"},
+	models.NotificationReminderUnpurchased: {"reminder_unpurchased_title", "reminder_unpurchased_text", "view_order"},
+}
+
+// newMessage renders the card for a notification about a request (loaded
+// with Requester and Items) in lang, as a message queued for a channel
+func newMessage(channelID uint, t models.NotificationType, actionURL string, request *models.PurchaseRequest, lang string, now time.Time) (*models.ChatMessage, error) {
+	keys, ok := cardStrings[t]
+	if !ok {
+		return nil, fmt.Errorf("notification type %s has no chat card", t)
+	}
+
+	number := request.RequestNumber
+	var text string
+	switch t {
+	case models.NotificationReminderPending:
+		text = msg(lang, keys.text, int(now.Sub(request.CreatedAt).Hours()))
+	case models.NotificationReminderUnpurchased:
+		approvedAt := request.UpdatedAt
+		if request.ApprovedAt != nil {
+			approvedAt = *request.ApprovedAt
+		}
+		text = msg(lang, keys.text, int(now.Sub(approvedAt).Hours()))
+	default:
+		text = msg(lang, keys.text, request.Requester.Name)
+	}
+	// Orders are known by their PO number once approved
+	if (t == models.NotificationNewApprovedOrder || t == models.NotificationReminderUnpurchased) &&
+		request.PONumber != nil && *request.PONumber != "" {
+		number = *request.PONumber
+	}
+
+	fields, err := json.Marshal(requestFields(request, lang))
+	if err != nil {
+		return nil, err
+	}
+
+	requestID := request.ID
+	return &models.ChatMessage{
+		ChannelID:     channelID,
+		Type:          t,
+		RequestID:     &requestID,
+		Title:         msg(lang, keys.title, number),
+		Text:          text,
+		Fields:        models.JSONB(fields),
+		ActionURL:     actionURL,
+		Action:        msg(lang, keys.action),
+		Urgent:        t == models.NotificationUrgentRequest || request.IsUrgent(),
+		Status:        models.ChatMessagePending,
+		MaxAttempts:   5,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// requestFields lists the details shown on every request card
+func requestFields(request *models.PurchaseRequest, lang string) []Field {
+	fields := []Field{{Label: msg(lang, "requester"), Value: request.Requester.Name}}
+	if request.Requester.Department != "" {
+		fields = append(fields, Field{Label: msg(lang, "department"), Value: request.Requester.Department})
+	}
+
+	products := localized(request.ProductTitleTranslated, lang, request.ProductTitle)
+	if len(request.Items) > 0 {
+		first := request.Items[0]
+		products = localized(first.ProductTitleTranslated, lang, first.ProductTitle)
+		if len(request.Items) > 1 {
+			products = msg(lang, "more_products", products, len(request.Items)-1)
+		}
+	}
+	if products != "" {
+		fields = append(fields, Field{Label: msg(lang, "products"), Value: products})
+	}
+
+	if request.TotalEstimated != nil {
+		fields = append(fields, Field{
+			Label: msg(lang, "total"),
+			Value: fmt.Sprintf("%.2f %s", *request.TotalEstimated, request.Currency),
+		})
+	}
+	if justification := localized(request.JustificationTranslated, lang, request.Justification); justification != "" {
+		fields = append(fields, Field{Label: msg(lang, "justification"), Value: truncate(justification, justificationLimit)})
+	}
+	return fields
+}
+
+// testMessage is the card sent by the admin "test" action
+func testMessage(lang string) *models.ChatMessage {
+	return &models.ChatMessage{
+		Title:     msg(lang, "test_title"),
+		Text:      msg(lang, "test_text"),
+		ActionURL: "/",
+		Action:    msg(lang, "open_app"),
+	}
+}
+
+// cardFromMessage turns a queued message back into a card, resolving the
+// relative action URL against appURL
+func cardFromMessage(m *models.ChatMessage, appURL string) Card {
+	card := Card{
+		Title:  m.Title,
+		Text:   m.Text,
+		Action: m.Action,
+		Urgent: m.Urgent,
+	}
+	if len(m.Fields) > 0 {
+		json.Unmarshal(m.Fields, &card.Fields)
+	}
+	if m.ActionURL != "" {
+		card.ActionURL = absoluteURL(appURL, m.ActionURL)
+	}
+	return card
+}
+
+// localized returns the translation of a TranslatedText column for lang,
+// falling back to the given text
+func localized(data models.JSONB, lang, fallback string) string {
+	if t := translation.FromJSON(data); t != nil {
+		if text := t.Get(lang); text != "" {
+			return text
+		}
+	}
+	return fallback
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit]) + "…"
+}
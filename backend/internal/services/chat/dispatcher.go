@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/worker"
+	"vista-backend/pkg/crypto"
+)
+
+// responseBodyLimit caps how much of a provider's response is read
+const responseBodyLimit = 2000
+
+// Sender posts cards to chat channels
+type Sender struct {
+	encryptionSvc *crypto.EncryptionService
+	appURL        string
+	client        *http.Client
+}
+
+// NewSender creates a sender that links cards to the app at appURL
+func NewSender(encryptionSvc *crypto.EncryptionService, appURL string) *Sender {
+	return &Sender{
+		encryptionSvc: encryptionSvc,
+		appURL:        appURL,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Send posts a queued message to its channel
+func (s *Sender) Send(channel *models.ChatChannel, message *models.ChatMessage) error {
+	webhookURL, err := s.encryptionSvc.Decrypt(channel.WebhookURL)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt webhook URL: %w", err)
+	}
+	if channel.Provider == models.ChatProviderDingTalk && channel.Secret != "" {
+		secret, err := s.encryptionSvc.Decrypt(channel.Secret)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt signing secret: %w", err)
+		}
+		if webhookURL, err = dingTalkSignedURL(webhookURL, secret, time.Now()); err != nil {
+			return fmt.Errorf("invalid webhook URL: %w", err)
+		}
+	}
+
+	payload, err := buildPayload(channel.Provider, cardFromMessage(message, s.appURL))
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	return checkResponse(channel.Provider, resp.StatusCode, respBody)
+}
+
+// SendTest posts a test card in the channel's language
+func (s *Sender) SendTest(channel *models.ChatChannel) error {
+	return s.Send(channel, testMessage(channel.Language))
+}
+
+// Dispatcher sends queued chat messages in the background, retrying
+// failures with backoff
+type Dispatcher struct {
+	*worker.Worker[models.ChatMessage]
+	db     *gorm.DB
+	sender *Sender
+}
+
+// NewDispatcher creates a new chat dispatcher
+func NewDispatcher(db *gorm.DB, encryptionSvc *crypto.EncryptionService, appURL string) *Dispatcher {
+	d := &Dispatcher{
+		db:     db,
+		sender: NewSender(encryptionSvc, appURL),
+	}
+	d.Worker = worker.New(db, worker.Queue[models.ChatMessage]{
+		Name:         "Chat dispatcher",
+		Pending:      models.ChatMessagePending,
+		Running:      models.ChatMessageSending,
+		DueColumn:    "next_attempt_at",
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+		Process:      d.send,
+	})
+	return d
+}
+
+// send posts one message and records the outcome
+func (d *Dispatcher) send(message *models.ChatMessage) {
+	message.Attempts++
+
+	var channel models.ChatChannel
+	if err := d.db.First(&channel, message.ChannelID).Error; err != nil {
+		d.finish(message, models.ChatMessageFailed, map[string]interface{}{
+			"last_error": "channel not found",
+		})
+		return
+	}
+
+	err := d.sender.Send(&channel, message)
+	if err == nil {
+		d.finish(message, models.ChatMessageSent, map[string]interface{}{
+			"last_error": "",
+			"sent_at":    time.Now(),
+		})
+		return
+	}
+
+	updates := map[string]interface{}{"last_error": err.Error()}
+	if !message.CanRetry() || !channel.IsActive {
+		log.Printf("Chat message %d to channel %d failed permanently: %v", message.ID, channel.ID, err)
+		d.finish(message, models.ChatMessageFailed, updates)
+		return
+	}
+
+	// Cards lose their value quickly, so retry sooner than emails
+	delay := worker.Backoff(message.Attempts, 30*time.Second, time.Hour)
+	log.Printf("Chat message %d to channel %d failed (attempt %d/%d), retrying in %s: %v",
+		message.ID, channel.ID, message.Attempts, message.MaxAttempts, delay, err)
+	updates["next_attempt_at"] = time.Now().Add(delay)
+	d.finish(message, models.ChatMessagePending, updates)
+}
+
+func (d *Dispatcher) finish(message *models.ChatMessage, status models.ChatMessageStatus, updates map[string]interface{}) {
+	updates["status"] = status
+	updates["attempts"] = message.Attempts
+	if err := d.db.Model(message).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update chat message %d: %v", message.ID, err)
+	}
+}
@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"fmt"
+
+	"vista-backend/pkg/i18n"
+)
+
+// messages holds the card strings per language. Languages without an entry
+// (or keys missing from one) fall back to the default language, then English.
+var messages = map[string]map[string]string{
+	"en": {
+		"urgent_title":               "🔴 Urgent request #%s needs approval",
+		"urgent_text":                "%s submitted an urgent purchase request.",
+		"pending_title":              "New request #%s pending approval",
+		"pending_text":               "%s submitted a purchase request.",
+		"approved_title":             "Request #%s approved",
+		"approved_text":              "%s's purchase request was approved and is ready to be purchased.",
+		"approved_order_title":       "Approved order #%s ready to purchase",
+		"approved_order_text":        "The order from %s was approved and is waiting for the purchasing team.",
+		"reminder_pending_title":     "⏰ Request #%s is waiting for approval",
+		"reminder_pending_text":      "This request has been pending for %d hours.",
+		"reminder_unpurchased_title": "⏰ Order #%s has not been purchased",
+		"reminder_unpurchased_text":  "This order was approved %d hours ago and is still not purchased.",
+		"test_title":                 "IRIS Vista test message",
+		"test_text":                  "This channel is set up to receive IRIS Vista notifications.",
+		"requester":                  "Requester",
+		"department":                 "Department",
+		"products":                   "Products",
+		"more_products":              "%s and %d more",
+		"total":                      "Estimated total",
+		"justification":              "Justification",
+		"review_request":             "Review request",
+		"view_request":               "View request",
+		"view_order":                 "View order",
+		"open_app":                   "Open IRIS Vista",
+	},
+	"zh": {
+		"urgent_title":               "🔴 紧急申请 #%s 待审批",
+		"urgent_text":                "%s 提交了一份紧急采购申请。",
+		"pending_title":              "新申请 #%s 待审批",
+		"pending_text":               "%s 提交了一份采购申请。",
+		"approved_title":             "申请 #%s 已批准",
+		"approved_text":              "%s 的采购申请已批准，可以进行采购。",
+		"approved_order_title":       "已批准订单 #%s 待采购",
+		"approved_order_text":        "%s 的订单已批准，等待采购团队处理。",
+		"reminder_pending_title":     "⏰ 申请 #%s 等待审批",
+		"reminder_pending_text":      "该申请已等待审批 %d 小时。",
+		"reminder_unpurchased_title": "⏰ 订单 #%s 尚未采购",
+		"reminder_unpurchased_text":  "该订单已于 %d 小时前批准，但仍未采购。",
+		"test_title":                 "IRIS Vista 测试消息",
+		"test_text":                  "此群已设置为接收 IRIS Vista 通知。",
+		"requester":                  "申请人",
+		"department":                 "部门",
+		"products":                   "产品",
+		"more_products":              "%s 等 %d 件",
+		"total":                      "预估总额",
+		"justification":              "申请理由",
+		"review_request":             "审批申请",
+		"view_request":               "查看申请",
+		"view_order":                 "查看订单",
+		"open_app":                   "打开 IRIS Vista",
+	},
+	"es": {
+		"urgent_title":               "🔴 La solicitud urgente #%s requiere aprobación",
+		"urgent_text":                "%s envió una solicitud de compra urgente.",
+		"pending_title":              "Nueva solicitud #%s pendiente de aprobación",
+		"pending_text":               "%s envió una solicitud de compra.",
+		"approved_title":             "Solicitud #%s aprobada",
+		"approved_text":              "La solicitud de compra de %s fue aprobada y está lista para comprarse.",
+		"approved_order_title":       "Pedido aprobado #%s listo para comprar",
+		"approved_order_text":        "El pedido de %s fue aprobado y espera al equipo de compras.",
+		"reminder_pending_title":     "⏰ La solicitud #%s espera aprobación",
+		"reminder_pending_text":      "Esta solicitud lleva %d horas pendiente.",
+		"reminder_unpurchased_title": "⏰ El pedido #%s no se ha comprado",
+		"reminder_unpurchased_text":  "Este pedido se aprobó hace %d horas y aún no se ha comprado.",
+		"test_title":                 "Mensaje de prueba de IRIS Vista",
+		"test_text":                  "Este canal está configurado para recibir notificaciones de IRIS Vista.",
+		"requester":                  "Solicitante",
+		"department":                 "Departamento",
+		"products":                   "Productos",
+		"more_products":              "%s y %d más",
+		"total":                      "Total estimado",
+		"justification":              "Justificación",
+		"review_request":             "Revisar solicitud",
+		"view_request":               "Ver solicitud",
+		"view_order":                 "Ver pedido",
+		"open_app":                   "Abrir IRIS Vista",
+	},
+}
+
+// msg returns the string for key in lang, formatted with args if given
+func msg(lang, key string, args ...interface{}) string {
+	text, ok := messages[lang][key]
+	if !ok {
+		text, ok = messages[i18n.Default()][key]
+	}
+	if !ok {
+		text = messages["en"][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
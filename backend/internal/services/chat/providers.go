@@ -0,0 +1,250 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"vista-backend/internal/models"
+)
+
+// Provider limits on card size
+const (
+	slackFieldLimit = 10
+	wecomFieldLimit = 6
+	wecomTitleLimit = 26
+)
+
+// buildPayload formats a card as the JSON body the provider's incoming
+// webhook expects
+func buildPayload(provider models.ChatProvider, card Card) (interface{}, error) {
+	switch provider {
+	case models.ChatProviderSlack:
+		return slackPayload(card), nil
+	case models.ChatProviderTeams:
+		return teamsPayload(card), nil
+	case models.ChatProviderWeCom:
+		return wecomPayload(card), nil
+	case models.ChatProviderDingTalk:
+		return dingTalkPayload(card), nil
+	}
+	return nil, fmt.Errorf("unsupported chat provider: %s", provider)
+}
+
+// slackPayload uses Block Kit: header, text, fields and a link button
+func slackPayload(card Card) map[string]interface{} {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": card.Title, "emoji": true},
+		},
+		{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": slackEscape(card.Text)},
+		},
+	}
+
+	if len(card.Fields) > 0 {
+		var fields []map[string]interface{}
+		for i, f := range card.Fields {
+			if i == slackFieldLimit {
+				break
+			}
+			fields = append(fields, map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n%s", slackEscape(f.Label), slackEscape(f.Value)),
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+
+	if card.ActionURL != "" {
+		button := map[string]interface{}{
+			"type":  "button",
+			"text":  map[string]interface{}{"type": "plain_text", "text": card.Action},
+			"url":   card.ActionURL,
+			"style": "primary",
+		}
+		if card.Urgent {
+			button["style"] = "danger"
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"elements": []map[string]interface{}{button},
+		})
+	}
+
+	// text is the fallback shown in notifications
+	return map[string]interface{}{"text": card.Title, "blocks": blocks}
+}
+
+// slackEscape escapes the characters Slack treats as markup
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// teamsPayload wraps an Adaptive Card in the message format accepted by
+// Teams incoming webhooks and Workflows
+func teamsPayload(card Card) map[string]interface{} {
+	title := map[string]interface{}{
+		"type":   "TextBlock",
+		"text":   card.Title,
+		"weight": "Bolder",
+		"size":   "Medium",
+		"wrap":   true,
+	}
+	if card.Urgent {
+		title["color"] = "Attention"
+	}
+	body := []map[string]interface{}{
+		title,
+		{"type": "TextBlock", "text": card.Text, "wrap": true},
+	}
+
+	if len(card.Fields) > 0 {
+		facts := make([]map[string]string, 0, len(card.Fields))
+		for _, f := range card.Fields {
+			facts = append(facts, map[string]string{"title": f.Label, "value": f.Value})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+
+	content := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if card.ActionURL != "" {
+		content["actions"] = []map[string]interface{}{
+			{"type": "Action.OpenUrl", "title": card.Action, "url": card.ActionURL},
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": content},
+		},
+	}
+}
+
+// wecomPayload uses a WeCom group robot text_notice template card
+func wecomPayload(card Card) map[string]interface{} {
+	title := card.Title
+	desc := ""
+	// Long titles are cut off by WeCom; move the rest to the description
+	if runes := []rune(title); len(runes) > wecomTitleLimit {
+		title = string(runes[:wecomTitleLimit])
+		desc = string(runes[wecomTitleLimit:])
+	}
+
+	templateCard := map[string]interface{}{
+		"card_type":      "text_notice",
+		"source":         map[string]interface{}{"desc": "IRIS Vista"},
+		"main_title":     map[string]interface{}{"title": title, "desc": desc},
+		"sub_title_text": card.Text,
+	}
+
+	if len(card.Fields) > 0 {
+		var contents []map[string]interface{}
+		for i, f := range card.Fields {
+			if i == wecomFieldLimit {
+				break
+			}
+			contents = append(contents, map[string]interface{}{"keyname": f.Label, "value": f.Value})
+		}
+		templateCard["horizontal_content_list"] = contents
+	}
+
+	// WeCom requires a card action; every card we send has a link
+	if link := card.ActionURL; link != "" {
+		templateCard["jump_list"] = []map[string]interface{}{
+			{"type": 1, "title": card.Action, "url": link},
+		}
+		templateCard["card_action"] = map[string]interface{}{"type": 1, "url": link}
+	}
+
+	return map[string]interface{}{"msgtype": "template_card", "template_card": templateCard}
+}
+
+// dingTalkPayload uses a DingTalk robot action card with a markdown body
+func dingTalkPayload(card Card) map[string]interface{} {
+	var text strings.Builder
+	fmt.Fprintf(&text, "### %s\n\n%s\n\n", card.Title, card.Text)
+	for _, f := range card.Fields {
+		fmt.Fprintf(&text, "- **%s**: %s\n", f.Label, f.Value)
+	}
+
+	if card.ActionURL == "" {
+		return map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]interface{}{"title": card.Title, "text": text.String()},
+		}
+	}
+	return map[string]interface{}{
+		"msgtype": "actionCard",
+		"actionCard": map[string]interface{}{
+			"title":          card.Title,
+			"text":           text.String(),
+			"btnOrientation": "0",
+			"singleTitle":    card.Action,
+			"singleURL":      card.ActionURL,
+		},
+	}
+}
+
+// dingTalkSignedURL adds the timestamp and signature DingTalk robots with
+// "additional signature" security require
+func dingTalkSignedURL(webhookURL, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// checkResponse reports provider errors. WeCom and DingTalk answer 200 with
+// an error code in the body; Slack and Teams use the HTTP status.
+func checkResponse(provider models.ChatProvider, status int, body []byte) error {
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("%s responded with status %d: %s", provider, status, strings.TrimSpace(string(body)))
+	}
+
+	switch provider {
+	case models.ChatProviderWeCom, models.ChatProviderDingTalk:
+		var result struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("unexpected %s response: %s", provider, strings.TrimSpace(string(body)))
+		}
+		if result.ErrCode != 0 {
+			return fmt.Errorf("%s error %d: %s", provider, result.ErrCode, result.ErrMsg)
+		}
+	}
+	return nil
+}
+
+// absoluteURL resolves a notification's relative action URL against the app URL
+func absoluteURL(appURL, actionURL string) string {
+	if strings.HasPrefix(actionURL, "http://") || strings.HasPrefix(actionURL, "https://") {
+		return actionURL
+	}
+	return strings.TrimRight(appURL, "/") + "/" + strings.TrimLeft(actionURL, "/")
+}
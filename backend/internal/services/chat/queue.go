@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// Queue renders a notification about a request for every active channel that
+// posts its type and whose audience includes one of the recipients. Each
+// channel gets one card in its own language, however many recipients match.
+func Queue(db *gorm.DB, t models.NotificationType, actionURL string, requestID uint, recipients []models.User) error {
+	if !models.IsChatNotificationType(t) || len(recipients) == 0 {
+		return nil
+	}
+
+	var channels []models.ChatChannel
+	if err := db.Where("is_active = ?", true).Find(&channels).Error; err != nil {
+		return err
+	}
+
+	var matching []models.ChatChannel
+	for _, channel := range channels {
+		if channel.WantsType(t) && matchesAny(&channel, recipients) {
+			matching = append(matching, channel)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+
+	var request models.PurchaseRequest
+	if err := db.Preload("Requester").Preload("Items").First(&request, requestID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	for _, channel := range matching {
+		message, err := newMessage(channel.ID, t, actionURL, &request, channel.Language, now)
+		if err == nil {
+			err = db.Create(message).Error
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ErrNotFailed is returned when resending a message that hasn't failed
+var ErrNotFailed = errors.New("message has not failed")
+
+// Resend queues a failed message to be sent again
+func Resend(db *gorm.DB, id uint) (*models.ChatMessage, error) {
+	var message models.ChatMessage
+	if err := db.First(&message, id).Error; err != nil {
+		return nil, err
+	}
+	if message.Status != models.ChatMessageFailed {
+		return nil, ErrNotFailed
+	}
+
+	result := db.Model(&message).
+		Where("status = ?", models.ChatMessageFailed).
+		Updates(map[string]interface{}{
+			"status":          models.ChatMessagePending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFailed
+	}
+	if err := db.First(&message, id).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func matchesAny(channel *models.ChatChannel, users []models.User) bool {
+	for i := range users {
+		if channel.Matches(&users[i]) {
+			return true
+		}
+	}
+	return false
+}
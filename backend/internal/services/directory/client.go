@@ -0,0 +1,218 @@
+package directory
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ErrInvalidCredentials is returned when the directory doesn't know the
+// user or rejects their password
+var ErrInvalidCredentials = errors.New("invalid directory credentials")
+
+const (
+	// timeout bounds every directory request
+	timeout = 15 * time.Second
+	// pageSize is how many entries are fetched at a time when listing users;
+	// Active Directory returns at most 1000 per page
+	pageSize = 500
+)
+
+// Config says how to reach the directory and read users from it
+type Config struct {
+	URL          string // ldap://host:389 or ldaps://host:636
+	StartTLS     bool   // Upgrade ldap:// connections with StartTLS
+	BindDN       string // Service account used to look users up
+	BindPassword string
+	BaseDN       string // Where users are searched
+	UserFilter   string // Which entries are users, e.g. (objectClass=person)
+	Attributes   Attributes
+}
+
+// Attributes names the directory attributes user fields are read from
+type Attributes struct {
+	EmployeeNumber string
+	Name           string
+	Email          string // Also what users log in with
+	Department     string
+	CostCenter     string
+	CompanyCode    string
+}
+
+// DefaultAttributes are the usual Active Directory attribute names
+func DefaultAttributes() Attributes {
+	return Attributes{
+		EmployeeNumber: "employeeNumber",
+		Name:           "displayName",
+		Email:          "mail",
+		Department:     "department",
+		CostCenter:     "departmentNumber",
+		CompanyCode:    "company",
+	}
+}
+
+// Set changes the attribute a user field is read from, by the field's JSON name
+func (a *Attributes) Set(field, attribute string) error {
+	targets := map[string]*string{
+		"employee_number": &a.EmployeeNumber,
+		"name":            &a.Name,
+		"email":           &a.Email,
+		"department":      &a.Department,
+		"cost_center":     &a.CostCenter,
+		"company_code":    &a.CompanyCode,
+	}
+	target, ok := targets[field]
+	if !ok || attribute == "" {
+		return fmt.Errorf("unknown user field %q", field)
+	}
+	*target = attribute
+	return nil
+}
+
+func (a Attributes) list() []string {
+	return []string{a.EmployeeNumber, a.Name, a.Email, a.Department, a.CostCenter, a.CompanyCode}
+}
+
+// Entry is a user as the directory describes them
+type Entry struct {
+	DN             string `json:"dn"`
+	EmployeeNumber string `json:"employee_number"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Department     string `json:"department"`
+	CostCenter     string `json:"cost_center"`
+	CompanyCode    string `json:"company_code"`
+}
+
+// Client reads users from an LDAP directory and checks their passwords
+type Client struct {
+	config Config
+}
+
+func NewClient(config Config) *Client {
+	return &Client{config: config}
+}
+
+// Authenticate checks a user's password by binding as them. The user is
+// looked up by email with the service account first.
+func (c *Client) Authenticate(email, password string) (*Entry, error) {
+	// An empty password would be an unauthenticated bind, which servers accept
+	if email == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	filter := fmt.Sprintf("(&%s(%s=%s))", c.userFilter(), c.config.Attributes.Email, ldap.EscapeFilter(email))
+	entries, err := c.search(conn, filter, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := conn.Bind(entries[0].DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("directory bind failed: %w", err)
+	}
+
+	return &entries[0], nil
+}
+
+// Users returns every user in the directory
+func (c *Client) Users() ([]Entry, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return c.search(conn, c.userFilter(), 0)
+}
+
+// connect opens a connection bound as the service account
+func (c *Client) connect() (*ldap.Conn, error) {
+	u, err := url.Parse(c.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory URL: %w", err)
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname()}
+
+	conn, err := ldap.DialURL(c.config.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to directory: %w", err)
+	}
+	conn.SetTimeout(timeout)
+
+	if c.config.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("directory StartTLS failed: %w", err)
+		}
+	}
+
+	if c.config.BindDN != "" {
+		if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("directory service account bind failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *Client) search(conn *ldap.Conn, filter string, sizeLimit int) ([]Entry, error) {
+	request := ldap.NewSearchRequest(
+		c.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		sizeLimit, int(timeout.Seconds()), false,
+		filter, c.config.Attributes.list(), nil,
+	)
+
+	var result *ldap.SearchResult
+	var err error
+	if sizeLimit == 0 {
+		result, err = conn.SearchWithPaging(request, pageSize)
+	} else {
+		result, err = conn.Search(request)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("directory search failed: %w", err)
+	}
+
+	attrs := c.config.Attributes
+	entries := make([]Entry, 0, len(result.Entries))
+	for _, e := range result.Entries {
+		entries = append(entries, Entry{
+			DN:             e.DN,
+			EmployeeNumber: strings.TrimSpace(e.GetEqualFoldAttributeValue(attrs.EmployeeNumber)),
+			Name:           strings.TrimSpace(e.GetEqualFoldAttributeValue(attrs.Name)),
+			Email:          strings.ToLower(strings.TrimSpace(e.GetEqualFoldAttributeValue(attrs.Email))),
+			Department:     strings.TrimSpace(e.GetEqualFoldAttributeValue(attrs.Department)),
+			CostCenter:     strings.TrimSpace(e.GetEqualFoldAttributeValue(attrs.CostCenter)),
+			CompanyCode:    strings.TrimSpace(e.GetEqualFoldAttributeValue(attrs.CompanyCode)),
+		})
+	}
+	return entries, nil
+}
+
+func (c *Client) userFilter() string {
+	filter := strings.TrimSpace(c.config.UserFilter)
+	if filter == "" {
+		return "(objectClass=person)"
+	}
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+	return filter
+}
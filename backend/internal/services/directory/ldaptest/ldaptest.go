@@ -0,0 +1,384 @@
+// Package ldaptest provides a small embedded LDAP server for tests and for
+// cmd/mock-ldap. It serves LDIF entries and supports simple bind and search,
+// which is all the directory client uses.
+package ldaptest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Entry is a directory entry; attribute names are keyed in lower case
+type Entry struct {
+	DN    string
+	names []string // Attribute names as written, in order
+	attrs map[string][]string
+}
+
+// Values returns the values of an attribute, matched case-insensitively
+func (e *Entry) Values(name string) []string {
+	return e.attrs[strings.ToLower(name)]
+}
+
+// ParseLDIF reads LDIF content records: blank-line separated entries of
+// "name: value" lines, with "name:: base64" values and folded lines
+func ParseLDIF(data []byte) ([]*Entry, error) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, " ") && len(lines) > 0 && lines[len(lines)-1] != "" {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	lines = append(lines, "")
+
+	var result []*Entry
+	var current *Entry
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if current != nil {
+				result = append(result, current)
+				current = nil
+			}
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected name: value", i+1)
+		}
+		if strings.HasPrefix(value, ":") {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			value = string(decoded)
+		} else {
+			value = strings.TrimSpace(value)
+		}
+
+		if current == nil {
+			if !strings.EqualFold(name, "dn") {
+				return nil, fmt.Errorf("line %d: entry doesn't start with dn", i+1)
+			}
+			current = &Entry{DN: value, attrs: map[string][]string{}}
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, seen := current.attrs[key]; !seen {
+			current.names = append(current.names, name)
+		}
+		current.attrs[key] = append(current.attrs[key], value)
+	}
+	return result, nil
+}
+
+// Server answers simple binds and searches from the entries Directory returns
+type Server struct {
+	// Directory returns the current entries; it's called for every bind and
+	// search, so the directory can change while the server runs
+	Directory func() ([]*Entry, error)
+	// Logf logs binds and searches if set
+	Logf func(format string, args ...interface{})
+}
+
+// Serve accepts connections on the listener until it's closed
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+// normalizeDN makes DNs comparable: lower case without spaces around separators
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		name, value, _ := strings.Cut(part, "=")
+		parts[i] = strings.ToLower(strings.TrimSpace(name)) + "=" + strings.ToLower(strings.TrimSpace(value))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ServeConn answers the requests on one connection until it's closed or unbound
+func (s *Server) ServeConn(conn net.Conn) {
+	defer conn.Close()
+	bound := "" // DN of the authenticated user; empty when anonymous
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		messageID, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code, message, dn := s.bind(op)
+			bound = dn
+			s.write(conn, messageID, result(ldap.ApplicationBindResponse, code, message))
+		case ldap.ApplicationSearchRequest:
+			if bound == "" {
+				s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights, "bind before searching"))
+				continue
+			}
+			s.search(conn, messageID, op)
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationAbandonRequest:
+		case ldap.ApplicationExtendedRequest:
+			s.write(conn, messageID, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError, "extended operations, including StartTLS, aren't supported"))
+		default:
+			// Responses use the request's tag plus one
+			s.write(conn, messageID, result(int(op.Tag)+1, ldap.LDAPResultUnwillingToPerform, "only bind and search are supported"))
+		}
+	}
+}
+
+// bind checks a simple bind and returns the result and the DN now bound.
+// Like real servers, an empty password is an unauthenticated bind that
+// succeeds without authenticating anyone.
+func (s *Server) bind(op *ber.Packet) (int, string, string) {
+	if len(op.Children) < 3 {
+		return ldap.LDAPResultProtocolError, "malformed bind request", ""
+	}
+	dn := op.Children[1].Data.String()
+	auth := op.Children[2]
+	if auth.Tag != 0 {
+		return ldap.LDAPResultAuthMethodNotSupported, "only simple bind is supported", ""
+	}
+	password := auth.Data.String()
+	if dn == "" || password == "" {
+		return ldap.LDAPResultSuccess, "", ""
+	}
+
+	all, err := s.Directory()
+	if err != nil {
+		s.logf("Failed to load directory: %v", err)
+		return ldap.LDAPResultOperationsError, err.Error(), ""
+	}
+	for _, e := range all {
+		if normalizeDN(e.DN) != normalizeDN(dn) {
+			continue
+		}
+		for _, stored := range e.Values("userPassword") {
+			if stored == password {
+				s.logf("Bind as %s", e.DN)
+				return ldap.LDAPResultSuccess, "", e.DN
+			}
+		}
+	}
+	s.logf("Bind as %s failed", dn)
+	return ldap.LDAPResultInvalidCredentials, "invalid credentials", ""
+}
+
+func (s *Server) search(conn net.Conn, messageID int64, op *ber.Packet) {
+	if len(op.Children) < 8 {
+		s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError, "malformed search request"))
+		return
+	}
+	base := normalizeDN(op.Children[0].Data.String())
+	scope, _ := op.Children[1].Value.(int64)
+	filter := op.Children[6]
+	var requested []string
+	for _, attr := range op.Children[7].Children {
+		requested = append(requested, attr.Data.String())
+	}
+
+	all, err := s.Directory()
+	if err != nil {
+		s.logf("Failed to load directory: %v", err)
+		s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultOperationsError, err.Error()))
+		return
+	}
+
+	baseExists := false
+	sent := 0
+	for _, e := range all {
+		dn := normalizeDN(e.DN)
+		under := strings.HasSuffix(dn, ","+base)
+		if dn == base || under {
+			baseExists = true
+		}
+
+		inScope := false
+		switch scope {
+		case ldap.ScopeBaseObject:
+			inScope = dn == base
+		case ldap.ScopeSingleLevel:
+			_, parent, _ := strings.Cut(dn, ",")
+			inScope = parent == base
+		default:
+			inScope = dn == base || under
+		}
+		if !inScope || !matches(filter, e) {
+			continue
+		}
+
+		s.write(conn, messageID, searchEntry(e, requested))
+		sent++
+	}
+
+	if !baseExists {
+		s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject, "no such base DN"))
+		return
+	}
+	filterText, _ := ldap.DecompileFilter(filter)
+	s.logf("Search %s %s: %d entries", base, filterText, sent)
+	s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess, ""))
+}
+
+// matches evaluates a search filter. Extensible matches, such as Active
+// Directory's bitwise userAccountControl rules, are never true.
+func matches(filter *ber.Packet, e *Entry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matches(child, e) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matches(child, e) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(filter.Children) == 1 && !matches(filter.Children[0], e)
+	case ldap.FilterPresent:
+		name := filter.Data.String()
+		return strings.EqualFold(name, "objectClass") || len(e.Values(name)) > 0
+	case ldap.FilterEqualityMatch, ldap.FilterApproxMatch, ldap.FilterGreaterOrEqual, ldap.FilterLessOrEqual:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		want := strings.ToLower(filter.Children[1].Data.String())
+		for _, value := range e.Values(filter.Children[0].Data.String()) {
+			value = strings.ToLower(value)
+			switch {
+			case filter.Tag == ldap.FilterGreaterOrEqual && value >= want,
+				filter.Tag == ldap.FilterLessOrEqual && value <= want,
+				(filter.Tag == ldap.FilterEqualityMatch || filter.Tag == ldap.FilterApproxMatch) && value == want:
+				return true
+			}
+		}
+		return false
+	case ldap.FilterSubstrings:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, value := range e.Values(filter.Children[0].Data.String()) {
+			if matchesSubstrings(strings.ToLower(value), filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func matchesSubstrings(value string, parts []*ber.Packet) bool {
+	for _, part := range parts {
+		piece := strings.ToLower(part.Data.String())
+		switch part.Tag {
+		case ldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, piece) {
+				return false
+			}
+			value = value[len(piece):]
+		case ldap.FilterSubstringsAny:
+			i := strings.Index(value, piece)
+			if i < 0 {
+				return false
+			}
+			value = value[i+len(piece):]
+		case ldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(value, piece) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// searchEntry encodes an entry with the requested attributes; passwords are never returned
+func searchEntry(e *Entry, requested []string) *ber.Packet {
+	all := len(requested) == 0
+	wanted := map[string]bool{}
+	for _, name := range requested {
+		if name == "*" {
+			all = true
+		}
+		wanted[strings.ToLower(name)] = true
+	}
+
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "DN"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for _, name := range e.names {
+		key := strings.ToLower(name)
+		if key == "userpassword" || (!all && !wanted[key]) {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range e.attrs[key] {
+			values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(values)
+		attributes.AppendChild(attribute)
+	}
+	packet.AppendChild(attributes)
+	return packet
+}
+
+// result encodes an LDAPResult with the given response tag
+func result(tag int, code int, message string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ber.Tag(tag), nil, ldap.ApplicationMap[uint8(tag)])
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "Diagnostic Message"))
+	return packet
+}
+
+func (s *Server) write(conn net.Conn, messageID int64, op *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "Message ID"))
+	envelope.AppendChild(op)
+	if _, err := conn.Write(envelope.Bytes()); err != nil {
+		s.logf("Failed to write response: %v", err)
+	}
+}
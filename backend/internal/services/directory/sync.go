@@ -0,0 +1,422 @@
+package directory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var (
+	ErrNotConfigured   = errors.New("directory is not configured")
+	ErrSyncRunning     = errors.New("a directory sync is already running")
+	ErrEmptyDirectory  = errors.New("the directory returned no users")
+	ErrAccountConflict = errors.New("directory entry conflicts with another user")
+
+	// errDryRun rolls back the transaction of a dry run
+	errDryRun = errors.New("dry run")
+)
+
+// Service keeps users in line with the directory. Directory users are
+// created and updated from their entries, and disabled once they're gone
+// from the directory. It's also what directory users log in through.
+type Service struct {
+	db       *gorm.DB
+	client   *Client // Nil when no directory is configured
+	interval time.Duration
+	running  sync.Mutex
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewService creates the directory service. With a nil client the directory
+// is disabled; with a zero interval it's only synced on demand.
+func NewService(db *gorm.DB, client *Client, interval time.Duration) *Service {
+	return &Service{
+		db:       db,
+		client:   client,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Enabled returns true if a directory is configured
+func (s *Service) Enabled() bool {
+	return s.client != nil
+}
+
+// Interval returns how often the directory is synced, or 0 if it isn't scheduled
+func (s *Service) Interval() time.Duration {
+	if !s.Enabled() {
+		return 0
+	}
+	return s.interval
+}
+
+// Report lists what a sync changed, or would change for a dry run
+type Report struct {
+	Created  []Change `json:"created"`
+	Updated  []Change `json:"updated"`
+	Enabled  []Change `json:"enabled"` // Back in the directory after sync disabled them
+	Disabled []Change `json:"disabled"`
+	Skipped  []Change `json:"skipped"`
+}
+
+// Change describes what happened to one user
+type Change struct {
+	UserID         uint          `json:"user_id,omitempty"`
+	EmployeeNumber string        `json:"employee_number"`
+	Email          string        `json:"email"`
+	Name           string        `json:"name"`
+	Fields         []FieldChange `json:"fields,omitempty"`
+	Reason         string        `json:"reason,omitempty"` // Why the entry was skipped
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Start syncs the directory now and then at every interval. It does nothing
+// if no directory is configured or sync isn't scheduled.
+func (s *Service) Start() {
+	if s.Interval() <= 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Sync(false, nil); err != nil && !errors.Is(err, ErrSyncRunning) {
+				log.Printf("Directory sync failed: %v", err)
+			}
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Directory sync started (every %s)", s.interval)
+}
+
+// Stop signals the scheduler to exit and waits for the current sync to finish
+func (s *Service) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Sync brings users in line with the directory and records the run. A dry
+// run makes the same changes in a transaction that is rolled back, so its
+// report shows exactly what a real sync would do.
+func (s *Service) Sync(dryRun bool, triggeredByID *uint) (*models.DirectorySyncRun, error) {
+	if !s.Enabled() {
+		return nil, ErrNotConfigured
+	}
+	if !s.running.TryLock() {
+		return nil, ErrSyncRunning
+	}
+	defer s.running.Unlock()
+
+	run := &models.DirectorySyncRun{
+		DryRun:        dryRun,
+		TriggeredByID: triggeredByID,
+		StartedAt:     time.Now(),
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	report, entries, syncErr := s.sync(dryRun)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Entries = entries
+	run.Created = len(report.Created)
+	run.Updated = len(report.Updated)
+	run.Enabled = len(report.Enabled)
+	run.Disabled = len(report.Disabled)
+	run.Skipped = len(report.Skipped)
+	if syncErr != nil {
+		run.Error = syncErr.Error()
+	} else if data, err := json.Marshal(report); err == nil {
+		run.Report = models.JSONB(data)
+	}
+	if err := s.db.Save(run).Error; err != nil {
+		log.Printf("Failed to save directory sync run %d: %v", run.ID, err)
+	}
+
+	if syncErr != nil {
+		return run, syncErr
+	}
+	if !dryRun {
+		log.Printf("Directory sync: %d entries, %d created, %d updated, %d enabled, %d disabled, %d skipped",
+			run.Entries, run.Created, run.Updated, run.Enabled, run.Disabled, run.Skipped)
+	}
+	return run, nil
+}
+
+func (s *Service) sync(dryRun bool) (*Report, int, error) {
+	report := &Report{
+		Created:  []Change{},
+		Updated:  []Change{},
+		Enabled:  []Change{},
+		Disabled: []Change{},
+		Skipped:  []Change{},
+	}
+
+	entries, err := s.client.Users()
+	if err != nil {
+		return report, 0, err
+	}
+	// An empty result is far more likely a wrong base DN or filter than
+	// everyone leaving, and would disable every directory user
+	if len(entries) == 0 {
+		return report, 0, ErrEmptyDirectory
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		seen := make(map[uint]bool)
+		numbers := make(map[string]bool)
+		emails := make(map[string]bool)
+
+		for _, entry := range entries {
+			if numbers[entry.EmployeeNumber] || emails[entry.Email] {
+				report.Skipped = append(report.Skipped, skipped(entry, "Listed more than once in the directory"))
+				continue
+			}
+			numbers[entry.EmployeeNumber] = entry.EmployeeNumber != ""
+			emails[entry.Email] = entry.Email != ""
+
+			user, err := s.apply(tx, entry, report, now)
+			if err != nil {
+				return err
+			}
+			if user != nil {
+				seen[user.ID] = true
+			}
+		}
+
+		// Disable directory users who are no longer in it
+		var linked []models.User
+		if err := tx.Where("ldap_dn <> '' AND status IN ?",
+			[]models.UserStatus{models.UserStatusApproved, models.UserStatusPending}).
+			Find(&linked).Error; err != nil {
+			return err
+		}
+		for i := range linked {
+			user := &linked[i]
+			if seen[user.ID] {
+				continue
+			}
+			if err := tx.Model(user).Updates(map[string]interface{}{
+				"status":          models.UserStatusDisabled,
+				"ldap_removed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			if err := models.EndUserSessions(tx, user.ID, "Removed from directory"); err != nil {
+				return err
+			}
+			report.Disabled = append(report.Disabled, Change{
+				UserID:         user.ID,
+				EmployeeNumber: user.EmployeeNumber,
+				Email:          user.Email,
+				Name:           user.Name,
+			})
+		}
+
+		if dryRun {
+			// The users a dry run creates are rolled back with their IDs
+			for i := range report.Created {
+				report.Created[i].UserID = 0
+			}
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return report, len(entries), err
+}
+
+// Authenticate logs a directory user in with their directory password and
+// brings their account up to date with their entry, creating it on their
+// first login
+func (s *Service) Authenticate(email, password string) (*models.User, error) {
+	if !s.Enabled() {
+		return nil, ErrNotConfigured
+	}
+
+	entry, err := s.client.Authenticate(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		report := &Report{}
+		var err error
+		user, err = s.apply(tx, *entry, report, time.Now())
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("%w: %s", ErrAccountConflict, report.Skipped[0].Reason)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// apply creates or updates the user for a directory entry and adds what it
+// did to the report. It returns nil without an error if the entry was skipped.
+func (s *Service) apply(tx *gorm.DB, entry Entry, report *Report, now time.Time) (*models.User, error) {
+	if entry.EmployeeNumber == "" {
+		report.Skipped = append(report.Skipped, skipped(entry, "No employee number in the directory"))
+		return nil, nil
+	}
+	if entry.Email == "" {
+		report.Skipped = append(report.Skipped, skipped(entry, "No email address in the directory"))
+		return nil, nil
+	}
+
+	// Match on the employee number, or the email address for accounts that
+	// were created before the directory was connected. Deleted users are
+	// matched too, since they keep their employee number and email.
+	var user models.User
+	err := tx.Unscoped().Where("employee_number = ?", entry.EmployeeNumber).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Unscoped().Where("LOWER(email) = ?", entry.Email).First(&user).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.create(tx, entry, report, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.DeletedAt.Valid {
+		report.Skipped = append(report.Skipped, skipped(entry, "The user was deleted"))
+		return nil, nil
+	}
+	if !strings.EqualFold(user.Email, entry.Email) {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).
+			Where("LOWER(email) = ? AND id <> ?", entry.Email, user.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			report.Skipped = append(report.Skipped, skipped(entry, "The email address belongs to another user"))
+			return nil, nil
+		}
+	}
+
+	updates := make(map[string]interface{})
+	var fields []FieldChange
+	// Empty directory attributes are ignored, so an entry without one keeps
+	// the value set here
+	set := func(field, column, from, to string) {
+		if to != "" && from != to {
+			updates[column] = to
+			fields = append(fields, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	set("employee_number", "employee_number", user.EmployeeNumber, entry.EmployeeNumber)
+	set("email", "email", user.Email, entry.Email)
+	set("name", "name", user.Name, entry.Name)
+	set("department", "department", user.Department, entry.Department)
+	set("cost_center", "cost_center", user.CostCenter, entry.CostCenter)
+	set("company_code", "company_code", user.CompanyCode, entry.CompanyCode)
+	if user.LDAPDN != entry.DN {
+		// Linking a local account, or the entry moved in the directory
+		updates["ldap_dn"] = entry.DN
+		fields = append(fields, FieldChange{Field: "dn", From: user.LDAPDN, To: entry.DN})
+	}
+
+	reenable := user.Status == models.UserStatusDisabled && user.LDAPRemovedAt != nil
+	if reenable {
+		updates["status"] = models.UserStatusApproved
+		updates["ldap_removed_at"] = nil
+	}
+
+	if len(updates) == 0 {
+		return &user, nil
+	}
+	if err := tx.Model(&user).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	change := Change{
+		UserID:         user.ID,
+		EmployeeNumber: user.EmployeeNumber,
+		Email:          user.Email,
+		Name:           user.Name,
+		Fields:         fields,
+	}
+	if reenable {
+		report.Enabled = append(report.Enabled, change)
+	} else {
+		report.Updated = append(report.Updated, change)
+	}
+	return &user, nil
+}
+
+// create adds a directory user. Like bulk imported users they're approved
+// straight away, with the employee role; they have no local password.
+func (s *Service) create(tx *gorm.DB, entry Entry, report *Report, now time.Time) (*models.User, error) {
+	name := entry.Name
+	if name == "" {
+		name = entry.Email
+	}
+
+	user := models.User{
+		EmployeeNumber: entry.EmployeeNumber,
+		Email:          entry.Email,
+		Name:           name,
+		Role:           models.RoleEmployee,
+		CompanyCode:    entry.CompanyCode,
+		CostCenter:     entry.CostCenter,
+		Department:     entry.Department,
+		Status:         models.UserStatusApproved,
+		ApprovedAt:     &now,
+		LDAPDN:         entry.DN,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	report.Created = append(report.Created, Change{
+		UserID:         user.ID,
+		EmployeeNumber: user.EmployeeNumber,
+		Email:          user.Email,
+		Name:           user.Name,
+	})
+	return &user, nil
+}
+
+func skipped(entry Entry, reason string) Change {
+	return Change{
+		EmployeeNumber: entry.EmployeeNumber,
+		Email:          entry.Email,
+		Name:           entry.Name,
+		Reason:         reason,
+	}
+}
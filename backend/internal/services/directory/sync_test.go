@@ -0,0 +1,319 @@
+package directory
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/directory/ldaptest"
+)
+
+const (
+	serviceEntry = `dn: cn=vista-sync,ou=services,dc=company,dc=com
+objectClass: applicationProcess
+cn: vista-sync
+userPassword: sync-secret
+`
+	peopleEntry = `dn: ou=people,dc=company,dc=com
+objectClass: organizationalUnit
+ou: people
+`
+)
+
+// person returns the LDIF entry of a directory user whose password is "password"
+func person(uid, name, email, employeeNumber, department string) string {
+	return fmt.Sprintf(`dn: uid=%s,ou=people,dc=company,dc=com
+objectClass: person
+uid: %s
+displayName: %s
+mail: %s
+employeeNumber: %s
+department: %s
+company: 1000
+userPassword: password
+`, uid, uid, name, email, employeeNumber, department)
+}
+
+var (
+	jsmith  = person("jsmith", "John Smith", "John.Smith@company.com", "E2001", "Engineering")
+	mgarcia = person("mgarcia", "Maria Garcia", "maria.garcia@company.com", "E2002", "Finance")
+	wli     = person("wli", "Wei Li", "wei.li@company.com", "E2003", "Operations")
+)
+
+// testDirectory is an embedded LDAP server whose entries tests can change
+type testDirectory struct {
+	mu      sync.Mutex
+	entries []*ldaptest.Entry
+	url     string
+}
+
+func newTestDirectory(t *testing.T, entries ...string) *testDirectory {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	d := &testDirectory{url: "ldap://" + listener.Addr().String()}
+	d.set(t, entries...)
+	server := &ldaptest.Server{Directory: func() ([]*ldaptest.Entry, error) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.entries, nil
+	}}
+	go server.Serve(listener)
+	return d
+}
+
+// set replaces the directory with the service account, the people
+// organizational unit and the given entries
+func (d *testDirectory) set(t *testing.T, entries ...string) {
+	t.Helper()
+	parsed, err := ldaptest.ParseLDIF([]byte(serviceEntry + "\n" + peopleEntry + "\n" + strings.Join(entries, "\n")))
+	if err != nil {
+		t.Fatalf("parse LDIF: %v", err)
+	}
+	d.mu.Lock()
+	d.entries = parsed
+	d.mu.Unlock()
+}
+
+func (d *testDirectory) client() *Client {
+	return NewClient(Config{
+		URL:          d.url,
+		BindDN:       "cn=vista-sync,ou=services,dc=company,dc=com",
+		BindPassword: "sync-secret",
+		BaseDN:       "ou=people,dc=company,dc=com",
+		UserFilter:   "(objectClass=person)",
+		Attributes:   DefaultAttributes(),
+	})
+}
+
+func newTestService(t *testing.T, d *testDirectory) (*Service, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.ActivityLog{}, &models.DirectorySyncRun{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewService(db, d.client(), 0), db
+}
+
+func findUser(t *testing.T, db *gorm.DB, employeeNumber string) models.User {
+	t.Helper()
+	var user models.User
+	if err := db.Where("employee_number = ?", employeeNumber).First(&user).Error; err != nil {
+		t.Fatalf("user %s: %v", employeeNumber, err)
+	}
+	return user
+}
+
+func countUsers(db *gorm.DB) int64 {
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	return count
+}
+
+func TestClientAuthenticate(t *testing.T) {
+	d := newTestDirectory(t, jsmith, mgarcia)
+	client := d.client()
+
+	entry, err := client.Authenticate("john.smith@company.com", "password")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if entry.DN != "uid=jsmith,ou=people,dc=company,dc=com" || entry.EmployeeNumber != "E2001" ||
+		entry.Email != "john.smith@company.com" || entry.Name != "John Smith" || entry.Department != "Engineering" {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+	}{
+		{"wrong password", "john.smith@company.com", "wrong"},
+		{"another user's password", "john.smith@company.com", "sync-secret"},
+		{"unknown email", "nobody@company.com", "password"},
+		// The server accepts an empty password as an unauthenticated bind
+		{"empty password", "john.smith@company.com", ""},
+		{"empty email", "", "password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.Authenticate(tt.email, tt.password); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("err = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
+
+func TestServiceAuthenticateCreatesUser(t *testing.T) {
+	d := newTestDirectory(t, jsmith)
+	s, db := newTestService(t, d)
+
+	user, err := s.Authenticate("john.smith@company.com", "password")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Status != models.UserStatusApproved || user.LDAPDN == "" || user.Role != models.RoleEmployee {
+		t.Errorf("user = %+v, want an approved, linked employee", user)
+	}
+	if _, err := s.Authenticate("john.smith@company.com", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("empty password: err = %v, want ErrInvalidCredentials", err)
+	}
+	if n := countUsers(db); n != 1 {
+		t.Errorf("%d users, want 1", n)
+	}
+}
+
+func TestSyncRefusesEmptyDirectory(t *testing.T) {
+	d := newTestDirectory(t, jsmith, mgarcia)
+	s, db := newTestService(t, d)
+	if _, err := s.Sync(false, nil); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+
+	// Everyone is gone, as with a wrong filter or an emptied organizational unit
+	d.set(t)
+	run, err := s.Sync(false, nil)
+	if !errors.Is(err, ErrEmptyDirectory) {
+		t.Fatalf("err = %v, want ErrEmptyDirectory", err)
+	}
+	if run == nil || run.Error == "" || run.Disabled != 0 {
+		t.Errorf("run = %+v, want a failed run that disabled no one", run)
+	}
+	for _, number := range []string{"E2001", "E2002"} {
+		if user := findUser(t, db, number); user.Status != models.UserStatusApproved {
+			t.Errorf("user %s is %s after an empty sync", number, user.Status)
+		}
+	}
+}
+
+func TestSyncDryRunRollsBack(t *testing.T) {
+	d := newTestDirectory(t, jsmith, mgarcia, wli)
+	s, db := newTestService(t, d)
+
+	run, err := s.Sync(true, nil)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !run.DryRun || run.Entries != 3 || run.Created != 3 {
+		t.Errorf("run = %+v, want a dry run of 3 entries creating 3 users", run)
+	}
+	if n := countUsers(db); n != 0 {
+		t.Errorf("dry run left %d users", n)
+	}
+	if !strings.Contains(string(run.Report), `"employee_number":"E2003"`) || strings.Contains(string(run.Report), `"user_id"`) {
+		t.Errorf("report = %s, want created users without IDs", run.Report)
+	}
+
+	// A real sync, then a dry run of a removal
+	if _, err := s.Sync(false, nil); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	d.set(t, jsmith, mgarcia)
+	run, err = s.Sync(true, nil)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if run.Disabled != 1 {
+		t.Errorf("dry run would disable %d users, want 1", run.Disabled)
+	}
+	if user := findUser(t, db, "E2003"); user.Status != models.UserStatusApproved || user.LDAPRemovedAt != nil {
+		t.Errorf("dry run disabled the user: %+v", user)
+	}
+}
+
+func TestSyncDisablesAndReenablesRemovedUsers(t *testing.T) {
+	d := newTestDirectory(t, jsmith, mgarcia, wli)
+	s, db := newTestService(t, d)
+	if _, err := s.Sync(false, nil); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+
+	// Wei has a session, and an admin disabled Maria by hand
+	wei := findUser(t, db, "E2003")
+	session := models.NewActivityLog(models.ActivityLogin, &wei.ID, wei.Email, "127.0.0.1", "test").
+		WithSession("session-1", time.Now().Add(time.Hour))
+	db.Create(session)
+	maria := findUser(t, db, "E2002")
+	db.Model(&maria).Update("status", models.UserStatusDisabled)
+
+	d.set(t, jsmith, mgarcia)
+	run, err := s.Sync(false, nil)
+	if err != nil {
+		t.Fatalf("sync after removal: %v", err)
+	}
+	if run.Disabled != 1 {
+		t.Errorf("disabled %d users, want 1", run.Disabled)
+	}
+	wei = findUser(t, db, "E2003")
+	if wei.Status != models.UserStatusDisabled || wei.LDAPRemovedAt == nil {
+		t.Errorf("removed user = %s, removed at %v; want disabled by sync", wei.Status, wei.LDAPRemovedAt)
+	}
+	db.First(session, session.ID)
+	if session.EndedAt == nil {
+		t.Error("the removed user's session wasn't ended")
+	}
+
+	d.set(t, jsmith, mgarcia, wli)
+	run, err = s.Sync(false, nil)
+	if err != nil {
+		t.Fatalf("sync after return: %v", err)
+	}
+	if run.Enabled != 1 {
+		t.Errorf("enabled %d users, want 1", run.Enabled)
+	}
+	wei = findUser(t, db, "E2003")
+	if wei.Status != models.UserStatusApproved || wei.LDAPRemovedAt != nil {
+		t.Errorf("returning user = %s, removed at %v; want approved", wei.Status, wei.LDAPRemovedAt)
+	}
+	if maria = findUser(t, db, "E2002"); maria.Status != models.UserStatusDisabled {
+		t.Errorf("user disabled by an admin is %s after sync", maria.Status)
+	}
+}
+
+func TestSyncKeepsValuesForEmptyAttributes(t *testing.T) {
+	d := newTestDirectory(t, jsmith)
+	s, db := newTestService(t, d)
+	if _, err := s.Sync(false, nil); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	john := findUser(t, db, "E2001")
+	db.Model(&john).Update("cost_center", "CC-100")
+
+	// The entry lost its name, department and company attributes
+	d.set(t, `dn: uid=jsmith,ou=people,dc=company,dc=com
+objectClass: person
+uid: jsmith
+mail: John.Smith@company.com
+employeeNumber: E2001
+userPassword: password
+`)
+	run, err := s.Sync(false, nil)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if run.Updated != 0 {
+		t.Errorf("updated %d users, want 0", run.Updated)
+	}
+	john = findUser(t, db, "E2001")
+	if john.Name != "John Smith" || john.Department != "Engineering" || john.CostCenter != "CC-100" || john.CompanyCode != "1000" {
+		t.Errorf("user = %q, %q, %q, %q; want the values set before kept", john.Name, john.Department, john.CostCenter, john.CompanyCode)
+	}
+}
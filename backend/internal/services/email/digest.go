@@ -0,0 +1,133 @@
+package email
+
+import (
+	"fmt"
+	"time"
+
+	"vista-backend/internal/models"
+)
+
+// Digest is the content of one user's summary email
+type Digest struct {
+	Frequency models.DigestFrequency
+
+	Notifications      []models.Notification // Unread, newest first
+	TotalNotifications int64
+
+	PendingApprovals []models.PurchaseRequest // Only for approvers
+	TotalPending     int64
+
+	StatusChanges      []DigestStatusChange // Changes to the user's own requests
+	TotalStatusChanges int64
+}
+
+// DigestStatusChange is a status transition of one of the user's requests
+type DigestStatusChange struct {
+	Request   models.PurchaseRequest
+	Status    models.RequestStatus
+	ChangedAt time.Time
+}
+
+// IsEmpty returns true if there is nothing to send
+func (d *Digest) IsEmpty() bool {
+	return len(d.Notifications) == 0 && len(d.PendingApprovals) == 0 && len(d.StatusChanges) == 0
+}
+
+// SendDigestEmail queues a user's digest
+func (s *EmailService) SendDigestEmail(user *models.User, digest *Digest) error {
+	config, err := s.getConfig(models.UserCompanyCode(s.db, user))
+	if err != nil || config == nil || !config.CanSendEmail() {
+		return nil
+	}
+
+	lang := userLanguage(user)
+	subject := msg(lang, "digest_subject_daily")
+	if digest.Frequency == models.DigestWeekly {
+		subject = msg(lang, "digest_subject_weekly")
+	}
+	htmlBody, err := s.buildDigestEmail(digest, user.Name, lang)
+	if err != nil {
+		return fmt.Errorf("failed to render digest email: %w", err)
+	}
+
+	return s.queue(models.UserCompanyCode(s.db, user), user, "digest", subject, htmlBody, SendOptions{})
+}
+
+func (s *EmailService) buildDigestEmail(digest *Digest, userName, lang string) (string, error) {
+	tmpl := `
+            <p>{{.Greeting}}</p>
+            <p>{{.T.digest_intro}}</p>
+            {{if .PendingApprovals}}
+            <div class="section-title">{{.T.digest_pending}} ({{.TotalPending}})</div>
+            {{range .PendingApprovals}}
+            <div class="item">
+                <a href="{{.ActionURL}}">#{{.RequestNumber}}</a>{{if .Urgent}}<span class="badge">{{$.T.urgent}}</span>{{end}}
+                <div class="item-meta">{{.ProductTitle}} · {{.Requester}}</div>
+            </div>
+            {{end}}
+            {{if .MorePending}}<p class="item-meta">{{.MorePending}}</p>{{end}}
+            {{end}}
+            {{if .StatusChanges}}
+            <div class="section-title">{{.T.digest_status_changes}}</div>
+            {{range .StatusChanges}}
+            <div class="item">
+                <a href="{{.ActionURL}}">#{{.RequestNumber}}</a> · {{.Status}}
+                <div class="item-meta">{{.ProductTitle}}</div>
+            </div>
+            {{end}}
+            {{if .MoreStatusChanges}}<p class="item-meta">{{.MoreStatusChanges}}</p>{{end}}
+            {{end}}
+            {{if .Notifications}}
+            <div class="section-title">{{.T.digest_notifications}} ({{.TotalNotifications}})</div>
+            {{range .Notifications}}
+            <div class="item">
+                {{if .ActionURL}}<a href="{{.ActionURL}}">{{.Title}}</a>{{else}}<strong>{{.Title}}</strong>{{end}}
+                <div class="item-meta">{{.Message}}</div>
+            </div>
+            {{end}}
+            {{if .MoreNotifications}}<p class="item-meta">{{.MoreNotifications}}</p>{{end}}
+            {{end}}
+            <a href="/" class="btn">{{.T.open_app}}</a>
+`
+	more := func(total int64, shown int) string {
+		if remaining := int(total) - shown; remaining > 0 {
+			return msg(lang, "digest_more", remaining)
+		}
+		return ""
+	}
+
+	pending := make([]map[string]interface{}, 0, len(digest.PendingApprovals))
+	for _, request := range digest.PendingApprovals {
+		pending = append(pending, map[string]interface{}{
+			"RequestNumber": request.RequestNumber,
+			"ProductTitle":  localized(request.ProductTitleTranslated, lang, request.ProductTitle),
+			"Requester":     request.Requester.Name,
+			"Urgent":        request.IsUrgent(),
+			"ActionURL":     fmt.Sprintf("/approvals?id=%d", request.ID),
+		})
+	}
+
+	changes := make([]map[string]interface{}, 0, len(digest.StatusChanges))
+	for _, change := range digest.StatusChanges {
+		changes = append(changes, map[string]interface{}{
+			"RequestNumber": change.Request.RequestNumber,
+			"ProductTitle":  localized(change.Request.ProductTitleTranslated, lang, change.Request.ProductTitle),
+			"Status":        msg(lang, "status_"+string(change.Status)),
+			"ActionURL":     fmt.Sprintf("/requests?id=%d", change.Request.ID),
+		})
+	}
+
+	return renderTemplate(tmpl, map[string]interface{}{
+		"Lang":               lang,
+		"T":                  catalog(lang),
+		"Greeting":           msg(lang, "greeting", userName),
+		"PendingApprovals":   pending,
+		"TotalPending":       digest.TotalPending,
+		"MorePending":        more(digest.TotalPending, len(pending)),
+		"StatusChanges":      changes,
+		"MoreStatusChanges":  more(digest.TotalStatusChanges, len(changes)),
+		"Notifications":      digest.Notifications,
+		"TotalNotifications": digest.TotalNotifications,
+		"MoreNotifications":  more(digest.TotalNotifications, len(digest.Notifications)),
+	})
+}
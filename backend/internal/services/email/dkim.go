@@ -0,0 +1,150 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dkimSignedHeaders are the headers covered by the signature, in signing order
+var dkimSignedHeaders = []string{"from", "to", "reply-to", "subject", "date", "message-id", "mime-version", "content-type"}
+
+var dkimWhitespace = regexp.MustCompile(`[ \t]+`)
+
+// ParseDKIMPrivateKey parses a PEM encoded RSA key in PKCS#1 or PKCS#8 form
+func ParseDKIMPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("DKIM key must be an RSA key")
+	}
+	return key, nil
+}
+
+// dkimSign returns a DKIM-Signature header (with trailing CRLF) for a message
+// with CRLF line endings, using rsa-sha256 and relaxed/relaxed canonicalization
+func dkimSign(message []byte, domain, selector, pemKey string) (string, error) {
+	key, err := ParseDKIMPrivateKey(pemKey)
+	if err != nil {
+		return "", err
+	}
+
+	headerEnd := bytes.Index(message, []byte("\r\n\r\n"))
+	if headerEnd < 0 {
+		return "", fmt.Errorf("message has no body")
+	}
+	headers := parseHeaders(string(message[:headerEnd+2]))
+	body := message[headerEnd+4:]
+
+	bodyHash := sha256.Sum256(relaxedBody(body))
+
+	var signed []string
+	var canonical strings.Builder
+	for _, name := range dkimSignedHeaders {
+		if value, ok := headers[name]; ok {
+			signed = append(signed, name)
+			canonical.WriteString(relaxedHeader(name, value) + "\r\n")
+		}
+	}
+
+	signature := fmt.Sprintf("v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; t=%s; h=%s; bh=%s; b=",
+		domain, selector, strconv.FormatInt(time.Now().Unix(), 10),
+		strings.Join(signed, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	// The signature header itself is signed with an empty b= and no trailing CRLF
+	canonical.WriteString(relaxedHeader("dkim-signature", signature))
+	digest := sha256.Sum256([]byte(canonical.String()))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return "DKIM-Signature: " + signature + foldBase64(base64.StdEncoding.EncodeToString(sig)) + "\r\n", nil
+}
+
+// parseHeaders unfolds a header block into lower-cased name -> raw value.
+// Only the first occurrence of each header is kept.
+func parseHeaders(block string) map[string]string {
+	headers := make(map[string]string)
+	var name, value string
+	flush := func() {
+		if name != "" {
+			if _, exists := headers[name]; !exists {
+				headers[name] = value
+			}
+		}
+	}
+	for _, line := range strings.Split(block, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			value += "\r\n" + line
+			continue
+		}
+		flush()
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			name = ""
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(line[:colon]))
+		value = line[colon+1:]
+	}
+	flush()
+	return headers
+}
+
+// relaxedHeader applies relaxed header canonicalization (RFC 6376 3.4.2)
+func relaxedHeader(name, value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = dkimWhitespace.ReplaceAllString(value, " ")
+	return strings.ToLower(name) + ":" + strings.TrimSpace(value)
+}
+
+// relaxedBody applies relaxed body canonicalization (RFC 6376 3.4.4)
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		line = dkimWhitespace.ReplaceAllString(line, " ")
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// foldBase64 splits a long signature over continuation lines
+func foldBase64(s string) string {
+	var b strings.Builder
+	for len(s) > 72 {
+		b.WriteString(s[:72] + "\r\n\t")
+		s = s[72:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
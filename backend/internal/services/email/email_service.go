@@ -4,17 +4,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"net/http"
 	"time"
 
 	"gorm.io/gorm"
 	"vista-backend/internal/models"
+	"vista-backend/pkg/crypto"
 )
 
-// EmailService handles sending emails via Resend
+// EmailService handles sending emails via Resend or an SMTP relay
 type EmailService struct {
-	db *gorm.DB
+	db            *gorm.DB
+	encryptionSvc *crypto.EncryptionService // Decrypts stored provider secrets; only needed to deliver
 }
 
 // NewEmailService creates a new email service
@@ -22,6 +23,13 @@ func NewEmailService(db *gorm.DB) *EmailService {
 	return &EmailService{db: db}
 }
 
+// WithEncryption sets the service used to decrypt the stored API key,
+// SMTP password and DKIM key when delivering
+func (s *EmailService) WithEncryption(encryptionSvc *crypto.EncryptionService) *EmailService {
+	s.encryptionSvc = encryptionSvc
+	return s
+}
+
 // ResendRequest represents the Resend API request body
 type ResendRequest struct {
 	From    string   `json:"from"`
@@ -38,39 +46,86 @@ type ResendResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-// getConfig retrieves the email configuration
-func (s *EmailService) getConfig() (*models.EmailConfig, error) {
-	var config models.EmailConfig
-	if err := s.db.First(&config).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
-		return nil, err
+// getConfig retrieves the email configuration the company sends with
+func (s *EmailService) getConfig(company string) (*models.EmailConfig, error) {
+	return models.FindEmailConfig(s.db, company)
+}
+
+// getDeliveryConfig retrieves the company's email configuration with its
+// secrets decrypted
+func (s *EmailService) getDeliveryConfig(company string) (*models.EmailConfig, error) {
+	config, err := s.getConfig(company)
+	if err != nil || config == nil {
+		return config, err
 	}
-	return &config, nil
+	config.APIKey = s.decrypt(config.APIKey)
+	config.SMTPPassword = s.decrypt(config.SMTPPassword)
+	config.DKIMPrivateKey = s.decrypt(config.DKIMPrivateKey)
+	return config, nil
+}
+
+// decrypt returns the plaintext of a stored secret. Values that don't decrypt
+// are returned as is, since older versions stored the API key in plaintext.
+func (s *EmailService) decrypt(value string) string {
+	if value == "" || s.encryptionSvc == nil {
+		return value
+	}
+	if decrypted, err := s.encryptionSvc.Decrypt(value); err == nil {
+		return decrypted
+	}
+	return value
+}
+
+// SendOptions control how a notification email is queued
+type SendOptions struct {
+	NotificationID *uint     // In-app notification to mark with EmailSentAt once delivered
+	SendAt         time.Time // Hold the email until then (e.g. quiet hours); zero sends now
 }
 
-// SendEmail sends an email using the configured provider
+// SendEmail queues an email in the outbox; the outbox worker delivers it with
+// the default company's configuration
 func (s *EmailService) SendEmail(to []string, subject, htmlBody, textBody string) error {
-	config, err := s.getConfig()
+	message := models.NewEmailMessage(to, subject, htmlBody, textBody, time.Time{})
+	message.CompanyCode = models.DefaultCompanyCode(s.db)
+	return s.db.Create(message).Error
+}
+
+// queue stores a notification email for a user in the outbox, to be sent
+// with the company's configuration
+func (s *EmailService) queue(company string, user *models.User, kind, subject, htmlBody string, opts SendOptions) error {
+	message := models.NewEmailMessage([]string{user.Email}, subject, htmlBody, "", opts.SendAt)
+	message.CompanyCode = company
+	message.Kind = kind
+	message.UserID = &user.ID
+	message.NotificationID = opts.NotificationID
+	return s.db.Create(message).Error
+}
+
+// Deliver sends a message through its company's configured provider now and
+// returns the provider's message ID
+func (s *EmailService) Deliver(message *models.EmailMessage) (string, error) {
+	config, err := s.getDeliveryConfig(message.CompanyCode)
 	if err != nil {
-		return fmt.Errorf("failed to get email config: %w", err)
+		return "", fmt.Errorf("failed to get email config: %w", err)
 	}
 
 	if config == nil || !config.CanSendEmail() {
-		return fmt.Errorf("email service not configured")
+		return "", fmt.Errorf("email service not configured")
 	}
 
+	message.Provider = config.Provider
 	switch config.Provider {
-	case "resend":
-		return s.sendViaResend(config, to, subject, htmlBody, textBody)
+	case models.EmailProviderResend:
+		return s.sendViaResend(config, message.Recipients(), message.Subject, message.HTMLBody, message.TextBody)
+	case models.EmailProviderSMTP:
+		return s.sendViaSMTP(config, message.Recipients(), message.Subject, message.HTMLBody, message.TextBody)
 	default:
-		return fmt.Errorf("unsupported email provider: %s", config.Provider)
+		return "", fmt.Errorf("unsupported email provider: %s", config.Provider)
 	}
 }
 
 // sendViaResend sends email using Resend API
-func (s *EmailService) sendViaResend(config *models.EmailConfig, to []string, subject, htmlBody, textBody string) error {
+func (s *EmailService) sendViaResend(config *models.EmailConfig, to []string, subject, htmlBody, textBody string) (string, error) {
 	fromAddress := config.FromEmail
 	if config.FromName != "" {
 		fromAddress = fmt.Sprintf("%s <%s>", config.FromName, config.FromEmail)
@@ -90,12 +145,12 @@ func (s *EmailService) sendViaResend(config *models.EmailConfig, to []string, su
 
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	httpReq, err := http.NewRequest("POST", "https://api.resend.com/emails", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Authorization", "Bearer "+config.APIKey)
@@ -104,25 +159,25 @@ func (s *EmailService) sendViaResend(config *models.EmailConfig, to []string, su
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	var resendResp ResendResponse
 	if err := json.NewDecoder(resp.Body).Decode(&resendResp); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("resend API error: %s", resendResp.Message)
+		return "", fmt.Errorf("resend API error: %s", resendResp.Message)
 	}
 
-	return nil
+	return resendResp.ID, nil
 }
 
-// TestConnection tests the email connection by sending a test email
-func (s *EmailService) TestConnection(testEmail string) error {
-	config, err := s.getConfig()
+// TestConnection tests the company's email connection by sending a test email
+func (s *EmailService) TestConnection(company, testEmail string) error {
+	config, err := s.getDeliveryConfig(company)
 	if err != nil {
 		return fmt.Errorf("failed to get email config: %w", err)
 	}
@@ -131,12 +186,20 @@ func (s *EmailService) TestConnection(testEmail string) error {
 		return fmt.Errorf("email configuration not found")
 	}
 
-	if config.APIKey == "" {
+	if config.FromEmail == "" {
+		return fmt.Errorf("from email not configured")
+	}
+
+	if !config.IsConfigured() {
+		if config.Provider == models.EmailProviderSMTP {
+			return fmt.Errorf("SMTP host not configured")
+		}
 		return fmt.Errorf("API key not configured")
 	}
 
-	if config.FromEmail == "" {
-		return fmt.Errorf("from email not configured")
+	providerName := "Resend"
+	if config.Provider == models.EmailProviderSMTP {
+		providerName = "SMTP"
 	}
 
 	subject := "IRIS Vista - Email Test"
@@ -163,7 +226,7 @@ func (s *EmailService) TestConnection(testEmail string) error {
         <div class="content">
             <p class="success">Email configuration is working correctly!</p>
             <p>This is a test email from IRIS Vista to verify your email configuration.</p>
-            <p>If you received this email, your Resend integration is properly configured.</p>
+            <p>If you received this email, your ` + providerName + ` integration is properly configured.</p>
         </div>
         <div class="footer">
             <p>IRIS Vista - Supply Chain & Procurement</p>
@@ -174,402 +237,123 @@ func (s *EmailService) TestConnection(testEmail string) error {
 `
 	textBody := "IRIS Vista Email Test\n\nEmail configuration is working correctly!\n\nThis is a test email from IRIS Vista to verify your email configuration."
 
-	return s.sendViaResend(config, []string{testEmail}, subject, htmlBody, textBody)
+	// Sent directly rather than through the outbox so the result can be shown
+	if config.Provider == models.EmailProviderSMTP {
+		_, err = s.sendViaSMTP(config, []string{testEmail}, subject, htmlBody, textBody)
+	} else {
+		_, err = s.sendViaResend(config, []string{testEmail}, subject, htmlBody, textBody)
+	}
+	return err
 }
 
-// Email templates for notifications
+// Notification emails. Each renders the admin's template for the recipient's
+// language or the built-in one (see templates.go).
+// Whether a notification is emailed (EmailConfig.SendOn* and user preferences)
+// is decided by NotificationService; these only check that email is configured.
 
 // SendRequestApprovedEmail sends notification when a request is approved
-func (s *EmailService) SendRequestApprovedEmail(user *models.User, request *models.PurchaseRequest) error {
-	config, err := s.getConfig()
-	if err != nil || config == nil || !config.CanSendEmail() || !config.SendOnApproval {
+func (s *EmailService) SendRequestApprovedEmail(user *models.User, request *models.PurchaseRequest, opts SendOptions) error {
+	config, err := s.getConfig(request.CompanyCode)
+	if err != nil || config == nil || !config.CanSendEmail() {
 		return nil // Silently skip if not configured
 	}
 
-	subject := fmt.Sprintf("Request #%s Approved - IRIS Vista", request.RequestNumber)
-	htmlBody := s.buildApprovalEmail(request, user.Name)
+	lang := userLanguage(user)
+	subject, htmlBody, err := s.renderEmail(models.EmailTemplateApproval, NewTemplateContext(request, user.Name, lang))
+	if err != nil {
+		return err
+	}
 
-	return s.SendEmail([]string{user.Email}, subject, htmlBody, "")
+	return s.queue(request.CompanyCode, user, string(models.NotificationRequestApproved), subject, htmlBody, opts)
 }
 
 // SendRequestRejectedEmail sends notification when a request is rejected
-func (s *EmailService) SendRequestRejectedEmail(user *models.User, request *models.PurchaseRequest, reason string) error {
-	config, err := s.getConfig()
-	if err != nil || config == nil || !config.CanSendEmail() || !config.SendOnRejection {
+func (s *EmailService) SendRequestRejectedEmail(user *models.User, request *models.PurchaseRequest, reason string, opts SendOptions) error {
+	config, err := s.getConfig(request.CompanyCode)
+	if err != nil || config == nil || !config.CanSendEmail() {
 		return nil
 	}
 
-	subject := fmt.Sprintf("Request #%s Rejected - IRIS Vista", request.RequestNumber)
-	htmlBody := s.buildRejectionEmail(request, user.Name, reason)
+	lang := userLanguage(user)
+	ctx := NewTemplateContext(request, user.Name, lang)
+	ctx.Reason = localized(request.RejectionReasonTranslated, lang, reason)
+	subject, htmlBody, err := s.renderEmail(models.EmailTemplateRejection, ctx)
+	if err != nil {
+		return err
+	}
 
-	return s.SendEmail([]string{user.Email}, subject, htmlBody, "")
+	return s.queue(request.CompanyCode, user, string(models.NotificationRequestRejected), subject, htmlBody, opts)
 }
 
 // SendRequestInfoRequiredEmail sends notification when more info is needed
-func (s *EmailService) SendRequestInfoRequiredEmail(user *models.User, request *models.PurchaseRequest, note string) error {
-	config, err := s.getConfig()
-	if err != nil || config == nil || !config.CanSendEmail() || !config.SendOnInfoRequest {
+func (s *EmailService) SendRequestInfoRequiredEmail(user *models.User, request *models.PurchaseRequest, note string, opts SendOptions) error {
+	config, err := s.getConfig(request.CompanyCode)
+	if err != nil || config == nil || !config.CanSendEmail() {
 		return nil
 	}
 
-	subject := fmt.Sprintf("Information Required for #%s - IRIS Vista", request.RequestNumber)
-	htmlBody := s.buildInfoRequestEmail(request, user.Name, note)
+	lang := userLanguage(user)
+	ctx := NewTemplateContext(request, user.Name, lang)
+	ctx.Note = localized(request.InfoRequestNoteTranslated, lang, note)
+	subject, htmlBody, err := s.renderEmail(models.EmailTemplateInfoRequest, ctx)
+	if err != nil {
+		return err
+	}
 
-	return s.SendEmail([]string{user.Email}, subject, htmlBody, "")
+	return s.queue(request.CompanyCode, user, string(models.NotificationRequestInfoRequired), subject, htmlBody, opts)
 }
 
-// SendNewRequestEmail sends notification to approvers when a new request is created
-func (s *EmailService) SendNewRequestEmail(approvers []models.User, request *models.PurchaseRequest, isUrgent bool) error {
-	config, err := s.getConfig()
+// SendInfoResponseEmail sends notification to the approver who asked for more
+// information when the requester answers
+func (s *EmailService) SendInfoResponseEmail(approver *models.User, request *models.PurchaseRequest, opts SendOptions) error {
+	config, err := s.getConfig(request.CompanyCode)
 	if err != nil || config == nil || !config.CanSendEmail() {
 		return nil
 	}
 
-	if isUrgent && !config.SendOnUrgent {
-		return nil
+	ctx := NewTemplateContext(request, approver.Name, userLanguage(approver))
+	subject, htmlBody, err := s.renderEmail(models.EmailTemplateInfoResponse, ctx)
+	if err != nil {
+		return err
 	}
-	if !isUrgent && !config.SendOnNewRequest {
+
+	return s.queue(request.CompanyCode, approver, string(models.NotificationRequestInfoProvided), subject, htmlBody, opts)
+}
+
+// SendNewRequestEmail sends notification to an approver when a new request is created
+func (s *EmailService) SendNewRequestEmail(approver *models.User, request *models.PurchaseRequest, isUrgent bool, opts SendOptions) error {
+	config, err := s.getConfig(request.CompanyCode)
+	if err != nil || config == nil || !config.CanSendEmail() {
 		return nil
 	}
 
-	subject := fmt.Sprintf("New Request #%s - IRIS Vista", request.RequestNumber)
+	kind := models.NotificationNewPendingRequest
 	if isUrgent {
-		subject = fmt.Sprintf("[URGENT] New Request #%s - IRIS Vista", request.RequestNumber)
+		kind = models.NotificationUrgentRequest
 	}
 
-	for _, approver := range approvers {
-		htmlBody := s.buildNewRequestEmail(request, approver.Name, isUrgent)
-		if err := s.SendEmail([]string{approver.Email}, subject, htmlBody, ""); err != nil {
-			// Log but don't fail on individual email errors
-			continue
-		}
+	ctx := NewTemplateContext(request, approver.Name, userLanguage(approver))
+	ctx.Urgent = isUrgent
+	subject, htmlBody, err := s.renderEmail(models.EmailTemplateNewRequest, ctx)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return s.queue(request.CompanyCode, approver, string(kind), subject, htmlBody, opts)
 }
 
 // SendOrderPurchasedEmail sends notification when an order is marked as purchased
-func (s *EmailService) SendOrderPurchasedEmail(user *models.User, request *models.PurchaseRequest) error {
-	config, err := s.getConfig()
-	if err != nil || config == nil || !config.CanSendEmail() || !config.SendOnPurchased {
+func (s *EmailService) SendOrderPurchasedEmail(user *models.User, request *models.PurchaseRequest, opts SendOptions) error {
+	config, err := s.getConfig(request.CompanyCode)
+	if err != nil || config == nil || !config.CanSendEmail() {
 		return nil
 	}
 
-	subject := fmt.Sprintf("Order #%s Completed - IRIS Vista", request.RequestNumber)
-	htmlBody := s.buildPurchasedEmail(request, user.Name)
-
-	return s.SendEmail([]string{user.Email}, subject, htmlBody, "")
-}
-
-// Template builders
-
-func (s *EmailService) buildApprovalEmail(request *models.PurchaseRequest, userName string) string {
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f9fafb; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #75534B, #5D423C); padding: 24px; text-align: center; }
-        .header h1 { color: white; margin: 0; font-size: 20px; }
-        .content { padding: 24px; }
-        .status { background: #dcfce7; color: #166534; padding: 12px 16px; border-radius: 8px; font-weight: 600; margin-bottom: 20px; }
-        .detail-row { display: flex; justify-content: space-between; padding: 8px 0; border-bottom: 1px solid #f3f4f6; }
-        .detail-label { color: #6b7280; }
-        .detail-value { color: #111827; font-weight: 500; }
-        .btn { display: inline-block; background: #75534B; color: white; padding: 12px 24px; text-decoration: none; border-radius: 8px; margin-top: 20px; }
-        .footer { color: #9ca3af; font-size: 12px; text-align: center; padding: 16px; background: #f9fafb; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>IRIS Vista</h1>
-        </div>
-        <div class="content">
-            <p>Hello {{.UserName}},</p>
-            <div class="status">Your purchase request has been approved</div>
-            <div class="detail-row">
-                <span class="detail-label">Request Number</span>
-                <span class="detail-value">{{.RequestNumber}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Product</span>
-                <span class="detail-value">{{.ProductTitle}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Quantity</span>
-                <span class="detail-value">{{.Quantity}}</span>
-            </div>
-            <p>Your request is now ready to be processed by the purchasing team.</p>
-            <a href="{{.ActionURL}}" class="btn">View Request</a>
-        </div>
-        <div class="footer">
-            <p>IRIS Vista - Supply Chain & Procurement</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-	return s.renderTemplate(tmpl, map[string]interface{}{
-		"UserName":      userName,
-		"RequestNumber": request.RequestNumber,
-		"ProductTitle":  request.ProductTitle,
-		"Quantity":      request.Quantity,
-		"ActionURL":     fmt.Sprintf("/requests?id=%d", request.ID),
-	})
-}
-
-func (s *EmailService) buildRejectionEmail(request *models.PurchaseRequest, userName, reason string) string {
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f9fafb; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #75534B, #5D423C); padding: 24px; text-align: center; }
-        .header h1 { color: white; margin: 0; font-size: 20px; }
-        .content { padding: 24px; }
-        .status { background: #fee2e2; color: #991b1b; padding: 12px 16px; border-radius: 8px; font-weight: 600; margin-bottom: 20px; }
-        .reason { background: #f3f4f6; padding: 16px; border-radius: 8px; margin: 16px 0; }
-        .reason-label { color: #6b7280; font-size: 12px; text-transform: uppercase; margin-bottom: 4px; }
-        .reason-text { color: #111827; }
-        .btn { display: inline-block; background: #75534B; color: white; padding: 12px 24px; text-decoration: none; border-radius: 8px; margin-top: 20px; }
-        .footer { color: #9ca3af; font-size: 12px; text-align: center; padding: 16px; background: #f9fafb; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>IRIS Vista</h1>
-        </div>
-        <div class="content">
-            <p>Hello {{.UserName}},</p>
-            <div class="status">Your purchase request has been rejected</div>
-            <p><strong>Request #{{.RequestNumber}}</strong>: {{.ProductTitle}}</p>
-            <div class="reason">
-                <div class="reason-label">Rejection Reason</div>
-                <div class="reason-text">{{.Reason}}</div>
-            </div>
-            <p>If you have questions about this decision, please contact your manager.</p>
-            <a href="{{.ActionURL}}" class="btn">View Request</a>
-        </div>
-        <div class="footer">
-            <p>IRIS Vista - Supply Chain & Procurement</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-	return s.renderTemplate(tmpl, map[string]interface{}{
-		"UserName":      userName,
-		"RequestNumber": request.RequestNumber,
-		"ProductTitle":  request.ProductTitle,
-		"Reason":        reason,
-		"ActionURL":     fmt.Sprintf("/requests?id=%d", request.ID),
-	})
-}
-
-func (s *EmailService) buildInfoRequestEmail(request *models.PurchaseRequest, userName, note string) string {
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f9fafb; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #75534B, #5D423C); padding: 24px; text-align: center; }
-        .header h1 { color: white; margin: 0; font-size: 20px; }
-        .content { padding: 24px; }
-        .status { background: #fef3c7; color: #92400e; padding: 12px 16px; border-radius: 8px; font-weight: 600; margin-bottom: 20px; }
-        .note { background: #f3f4f6; padding: 16px; border-radius: 8px; margin: 16px 0; }
-        .note-label { color: #6b7280; font-size: 12px; text-transform: uppercase; margin-bottom: 4px; }
-        .note-text { color: #111827; }
-        .btn { display: inline-block; background: #75534B; color: white; padding: 12px 24px; text-decoration: none; border-radius: 8px; margin-top: 20px; }
-        .footer { color: #9ca3af; font-size: 12px; text-align: center; padding: 16px; background: #f9fafb; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>IRIS Vista</h1>
-        </div>
-        <div class="content">
-            <p>Hello {{.UserName}},</p>
-            <div class="status">Additional information required</div>
-            <p><strong>Request #{{.RequestNumber}}</strong>: {{.ProductTitle}}</p>
-            <div class="note">
-                <div class="note-label">Information Requested</div>
-                <div class="note-text">{{.Note}}</div>
-            </div>
-            <p>Please update your request with the required information.</p>
-            <a href="{{.ActionURL}}" class="btn">Update Request</a>
-        </div>
-        <div class="footer">
-            <p>IRIS Vista - Supply Chain & Procurement</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-	return s.renderTemplate(tmpl, map[string]interface{}{
-		"UserName":      userName,
-		"RequestNumber": request.RequestNumber,
-		"ProductTitle":  request.ProductTitle,
-		"Note":          note,
-		"ActionURL":     fmt.Sprintf("/requests?id=%d", request.ID),
-	})
-}
-
-func (s *EmailService) buildNewRequestEmail(request *models.PurchaseRequest, approverName string, isUrgent bool) string {
-	urgentBadge := ""
-	if isUrgent {
-		urgentBadge = `<span style="background: #dc2626; color: white; padding: 4px 8px; border-radius: 4px; font-size: 12px; margin-left: 8px;">URGENT</span>`
-	}
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f9fafb; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #75534B, #5D423C); padding: 24px; text-align: center; }
-        .header h1 { color: white; margin: 0; font-size: 20px; }
-        .content { padding: 24px; }
-        .status { background: #dbeafe; color: #1e40af; padding: 12px 16px; border-radius: 8px; font-weight: 600; margin-bottom: 20px; }
-        .detail-row { display: flex; justify-content: space-between; padding: 8px 0; border-bottom: 1px solid #f3f4f6; }
-        .detail-label { color: #6b7280; }
-        .detail-value { color: #111827; font-weight: 500; }
-        .btn { display: inline-block; background: #75534B; color: white; padding: 12px 24px; text-decoration: none; border-radius: 8px; margin-top: 20px; }
-        .footer { color: #9ca3af; font-size: 12px; text-align: center; padding: 16px; background: #f9fafb; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>IRIS Vista</h1>
-        </div>
-        <div class="content">
-            <p>Hello {{.ApproverName}},</p>
-            <div class="status">New request pending approval {{.UrgentBadge}}</div>
-            <div class="detail-row">
-                <span class="detail-label">Request Number</span>
-                <span class="detail-value">{{.RequestNumber}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Requester</span>
-                <span class="detail-value">{{.RequesterName}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Product</span>
-                <span class="detail-value">{{.ProductTitle}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Quantity</span>
-                <span class="detail-value">{{.Quantity}}</span>
-            </div>
-            <p>Please review and process this request.</p>
-            <a href="{{.ActionURL}}" class="btn">Review Request</a>
-        </div>
-        <div class="footer">
-            <p>IRIS Vista - Supply Chain & Procurement</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-	requesterName := ""
-	if request.Requester.ID != 0 {
-		requesterName = request.Requester.Name
-	}
-
-	return s.renderTemplate(tmpl, map[string]interface{}{
-		"ApproverName":  approverName,
-		"RequestNumber": request.RequestNumber,
-		"RequesterName": requesterName,
-		"ProductTitle":  request.ProductTitle,
-		"Quantity":      request.Quantity,
-		"UrgentBadge":   template.HTML(urgentBadge),
-		"ActionURL":     fmt.Sprintf("/approvals?id=%d", request.ID),
-	})
-}
-
-func (s *EmailService) buildPurchasedEmail(request *models.PurchaseRequest, userName string) string {
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f9fafb; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #75534B, #5D423C); padding: 24px; text-align: center; }
-        .header h1 { color: white; margin: 0; font-size: 20px; }
-        .content { padding: 24px; }
-        .status { background: #d1fae5; color: #065f46; padding: 12px 16px; border-radius: 8px; font-weight: 600; margin-bottom: 20px; }
-        .detail-row { display: flex; justify-content: space-between; padding: 8px 0; border-bottom: 1px solid #f3f4f6; }
-        .detail-label { color: #6b7280; }
-        .detail-value { color: #111827; font-weight: 500; }
-        .btn { display: inline-block; background: #75534B; color: white; padding: 12px 24px; text-decoration: none; border-radius: 8px; margin-top: 20px; }
-        .footer { color: #9ca3af; font-size: 12px; text-align: center; padding: 16px; background: #f9fafb; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>IRIS Vista</h1>
-        </div>
-        <div class="content">
-            <p>Hello {{.UserName}},</p>
-            <div class="status">Your order has been completed</div>
-            <div class="detail-row">
-                <span class="detail-label">Request Number</span>
-                <span class="detail-value">{{.RequestNumber}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Product</span>
-                <span class="detail-value">{{.ProductTitle}}</span>
-            </div>
-            <div class="detail-row">
-                <span class="detail-label">Quantity</span>
-                <span class="detail-value">{{.Quantity}}</span>
-            </div>
-            <p>The purchasing team has successfully processed your order.</p>
-            <a href="{{.ActionURL}}" class="btn">View Details</a>
-        </div>
-        <div class="footer">
-            <p>IRIS Vista - Supply Chain & Procurement</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-	return s.renderTemplate(tmpl, map[string]interface{}{
-		"UserName":      userName,
-		"RequestNumber": request.RequestNumber,
-		"ProductTitle":  request.ProductTitle,
-		"Quantity":      request.Quantity,
-		"ActionURL":     fmt.Sprintf("/requests?id=%d", request.ID),
-	})
-}
-
-func (s *EmailService) renderTemplate(tmplStr string, data map[string]interface{}) string {
-	tmpl, err := template.New("email").Parse(tmplStr)
+	lang := userLanguage(user)
+	subject, htmlBody, err := s.renderEmail(models.EmailTemplatePurchased, NewTemplateContext(request, user.Name, lang))
 	if err != nil {
-		return ""
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return ""
+		return err
 	}
 
-	return buf.String()
+	return s.queue(request.CompanyCode, user, string(models.NotificationRequestPurchased), subject, htmlBody, opts)
 }
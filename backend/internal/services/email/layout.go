@@ -0,0 +1,79 @@
+package email
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// layoutHTML is the shell shared by all notification emails. Each email
+// supplies a "content" template rendered inside it; {{.Lang}} and {{.T}}
+// must be set in the data.
+const layoutHTML = `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+    <meta charset="utf-8">
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; background: #f9fafb; margin: 0; padding: 20px; }
+        .container { max-width: 600px; margin: 0 auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
+        .header { background: linear-gradient(135deg, #75534B, #5D423C); padding: 24px; text-align: center; }
+        .header h1 { color: white; margin: 0; font-size: 20px; }
+        .content { padding: 24px; }
+        .status { padding: 12px 16px; border-radius: 8px; font-weight: 600; margin-bottom: 20px; }
+        .status-approved { background: #dcfce7; color: #166534; }
+        .status-rejected { background: #fee2e2; color: #991b1b; }
+        .status-info { background: #fef3c7; color: #92400e; }
+        .status-new { background: #dbeafe; color: #1e40af; }
+        .status-purchased { background: #d1fae5; color: #065f46; }
+        .detail-row { display: flex; justify-content: space-between; padding: 8px 0; border-bottom: 1px solid #f3f4f6; }
+        .detail-label { color: #6b7280; }
+        .detail-value { color: #111827; font-weight: 500; }
+        .note { background: #f3f4f6; padding: 16px; border-radius: 8px; margin: 16px 0; }
+        .note-label { color: #6b7280; font-size: 12px; text-transform: uppercase; margin-bottom: 4px; }
+        .note-text { color: #111827; }
+        .section-title { color: #111827; font-size: 16px; font-weight: 600; margin: 24px 0 8px; }
+        .item { padding: 10px 0; border-bottom: 1px solid #f3f4f6; }
+        .item a { color: #75534B; font-weight: 500; text-decoration: none; }
+        .item-meta { color: #6b7280; font-size: 13px; margin-top: 2px; }
+        .badge { background: #dc2626; color: white; padding: 2px 6px; border-radius: 4px; font-size: 11px; margin-left: 6px; }
+        .btn { display: inline-block; background: #75534B; color: white; padding: 12px 24px; text-decoration: none; border-radius: 8px; margin-top: 20px; }
+        .footer { color: #9ca3af; font-size: 12px; text-align: center; padding: 16px; background: #f9fafb; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>IRIS Vista</h1>
+        </div>
+        <div class="content">
+            {{template "content" .}}
+        </div>
+        <div class="footer">
+            <p>{{.T.footer}}</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+// layoutTemplate is parsed once; renderTemplate clones it per email
+var layoutTemplate = template.Must(template.New("layout").Parse(layoutHTML))
+
+// renderTemplate renders an email's content inside the shared layout.
+// Variables missing from data are errors rather than "<no value>".
+func renderTemplate(content string, data map[string]interface{}) (string, error) {
+	layout, err := layoutTemplate.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := layout.New("content").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,333 @@
+package email
+
+import (
+	"fmt"
+
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+	"vista-backend/pkg/i18n"
+)
+
+// messages holds the email strings per language. Languages without an
+// entry (or keys missing from one) fall back to the default language, then English.
+var messages = map[string]map[string]string{
+	"en": {
+		"greeting":              "Hello %s,",
+		"footer":                "IRIS Vista - Supply Chain & Procurement",
+		"request_number":        "Request Number",
+		"requester":             "Requester",
+		"product":               "Product",
+		"quantity":              "Quantity",
+		"view_request":          "View Request",
+		"approved_subject":      "Request #%s Approved - IRIS Vista",
+		"approved_status":       "Your purchase request has been approved",
+		"approved_body":         "Your request is now ready to be processed by the purchasing team.",
+		"rejected_subject":      "Request #%s Rejected - IRIS Vista",
+		"rejected_status":       "Your purchase request has been rejected",
+		"rejection_reason":      "Rejection Reason",
+		"rejected_body":         "If you have questions about this decision, please contact your manager.",
+		"info_subject":          "Information Required for #%s - IRIS Vista",
+		"info_status":           "Additional information required",
+		"info_label":            "Information Requested",
+		"info_body":             "Please update your request with the required information.",
+		"update_request":        "Update Request",
+		"info_response_subject": "Reply to Information Request #%s - IRIS Vista",
+		"info_response_status":  "The requester answered your question",
+		"info_response_label":   "Response",
+		"info_response_body":    "The request is back in your approval queue.",
+		"new_subject":           "New Request #%s - IRIS Vista",
+		"new_subject_urgent":    "[URGENT] New Request #%s - IRIS Vista",
+		"new_status":            "New request pending approval",
+		"urgent":                "URGENT",
+		"new_body":              "Please review and process this request.",
+		"review_request":        "Review Request",
+		"purchased_subject":     "Order #%s Completed - IRIS Vista",
+		"purchased_status":      "Your order has been completed",
+		"purchased_body":        "The purchasing team has successfully processed your order.",
+		"view_details":          "View Details",
+		"digest_subject_daily":  "Your daily summary - IRIS Vista",
+		"digest_subject_weekly": "Your weekly summary - IRIS Vista",
+		"digest_intro":          "Here is what happened since your last summary.",
+		"digest_notifications":  "Unread notifications",
+		"digest_pending":        "Waiting for your approval",
+		"digest_status_changes": "Updates to your requests",
+		"digest_more":           "And %d more",
+		"open_app":              "Open IRIS Vista",
+		"reset_subject":         "Reset your password - IRIS Vista",
+		"reset_intro":           "We received a request to reset the password of your IRIS Vista account.",
+		"reset_button":          "Reset Password",
+		"reset_expiry":          "This link expires in %d minutes and can only be used once.",
+		"reset_ignore":          "If you didn't ask to reset your password, you can ignore this email. Your password won't change.",
+		"status_pending":        "Pending",
+		"status_approved":       "Approved",
+		"status_rejected":       "Rejected",
+		"status_info_requested": "Information requested",
+		"status_purchased":      "Purchased",
+		"status_delivered":      "Delivered",
+		"status_cancelled":      "Cancelled",
+	},
+	"es": {
+		"greeting":              "Hola %s,",
+		"footer":                "IRIS Vista - Cadena de Suministro y Compras",
+		"request_number":        "Número de solicitud",
+		"requester":             "Solicitante",
+		"product":               "Producto",
+		"quantity":              "Cantidad",
+		"view_request":          "Ver solicitud",
+		"approved_subject":      "Solicitud #%s aprobada - IRIS Vista",
+		"approved_status":       "Su solicitud de compra ha sido aprobada",
+		"approved_body":         "Su solicitud está lista para ser procesada por el equipo de compras.",
+		"rejected_subject":      "Solicitud #%s rechazada - IRIS Vista",
+		"rejected_status":       "Su solicitud de compra ha sido rechazada",
+		"rejection_reason":      "Motivo del rechazo",
+		"rejected_body":         "Si tiene preguntas sobre esta decisión, comuníquese con su gerente.",
+		"info_subject":          "Se requiere información para #%s - IRIS Vista",
+		"info_status":           "Se requiere información adicional",
+		"info_label":            "Información solicitada",
+		"info_body":             "Actualice su solicitud con la información requerida.",
+		"update_request":        "Actualizar solicitud",
+		"info_response_subject": "Respuesta a la solicitud de información #%s - IRIS Vista",
+		"info_response_status":  "El solicitante respondió a su pregunta",
+		"info_response_label":   "Respuesta",
+		"info_response_body":    "La solicitud ha vuelto a su cola de aprobación.",
+		"new_subject":           "Nueva solicitud #%s - IRIS Vista",
+		"new_subject_urgent":    "[URGENTE] Nueva solicitud #%s - IRIS Vista",
+		"new_status":            "Nueva solicitud pendiente de aprobación",
+		"urgent":                "URGENTE",
+		"new_body":              "Revise y procese esta solicitud.",
+		"review_request":        "Revisar solicitud",
+		"purchased_subject":     "Pedido #%s completado - IRIS Vista",
+		"purchased_status":      "Su pedido ha sido completado",
+		"purchased_body":        "El equipo de compras ha procesado su pedido correctamente.",
+		"view_details":          "Ver detalles",
+		"digest_subject_daily":  "Su resumen diario - IRIS Vista",
+		"digest_subject_weekly": "Su resumen semanal - IRIS Vista",
+		"digest_intro":          "Esto es lo que ha pasado desde su último resumen.",
+		"digest_notifications":  "Notificaciones sin leer",
+		"digest_pending":        "Pendientes de su aprobación",
+		"digest_status_changes": "Novedades de sus solicitudes",
+		"digest_more":           "Y %d más",
+		"open_app":              "Abrir IRIS Vista",
+		"reset_subject":         "Restablezca su contraseña - IRIS Vista",
+		"reset_intro":           "Recibimos una solicitud para restablecer la contraseña de su cuenta de IRIS Vista.",
+		"reset_button":          "Restablecer contraseña",
+		"reset_expiry":          "Este enlace caduca en %d minutos y solo puede usarse una vez.",
+		"reset_ignore":          "Si usted no solicitó restablecer su contraseña, puede ignorar este correo. Su contraseña no cambiará.",
+		"status_pending":        "Pendiente",
+		"status_approved":       "Aprobada",
+		"status_rejected":       "Rechazada",
+		"status_info_requested": "Información solicitada",
+		"status_purchased":      "Comprada",
+		"status_delivered":      "Entregada",
+		"status_cancelled":      "Cancelada",
+	},
+	"zh": {
+		"greeting":              "%s，您好：",
+		"footer":                "IRIS Vista - 供应链与采购",
+		"request_number":        "申请编号",
+		"requester":             "申请人",
+		"product":               "产品",
+		"quantity":              "数量",
+		"view_request":          "查看申请",
+		"approved_subject":      "申请 #%s 已批准 - IRIS Vista",
+		"approved_status":       "您的采购申请已获批准",
+		"approved_body":         "您的申请已可由采购团队处理。",
+		"rejected_subject":      "申请 #%s 已拒绝 - IRIS Vista",
+		"rejected_status":       "您的采购申请已被拒绝",
+		"rejection_reason":      "拒绝原因",
+		"rejected_body":         "如对此决定有疑问，请联系您的主管。",
+		"info_subject":          "申请 #%s 需要补充信息 - IRIS Vista",
+		"info_status":           "需要补充信息",
+		"info_label":            "所需信息",
+		"info_body":             "请在申请中补充所需信息。",
+		"update_request":        "更新申请",
+		"info_response_subject": "申请 #%s 已回复补充信息 - IRIS Vista",
+		"info_response_status":  "申请人已回复您的问题",
+		"info_response_label":   "回复",
+		"info_response_body":    "该申请已重新进入您的审批队列。",
+		"new_subject":           "新申请 #%s - IRIS Vista",
+		"new_subject_urgent":    "[紧急] 新申请 #%s - IRIS Vista",
+		"new_status":            "新申请待审批",
+		"urgent":                "紧急",
+		"new_body":              "请审核并处理此申请。",
+		"review_request":        "审核申请",
+		"purchased_subject":     "订单 #%s 已完成 - IRIS Vista",
+		"purchased_status":      "您的订单已完成",
+		"purchased_body":        "采购团队已成功处理您的订单。",
+		"view_details":          "查看详情",
+		"digest_subject_daily":  "您的每日摘要 - IRIS Vista",
+		"digest_subject_weekly": "您的每周摘要 - IRIS Vista",
+		"digest_intro":          "以下是自上次摘要以来的动态。",
+		"digest_notifications":  "未读通知",
+		"digest_pending":        "等待您审批",
+		"digest_status_changes": "您的申请更新",
+		"digest_more":           "还有 %d 项",
+		"open_app":              "打开 IRIS Vista",
+		"reset_subject":         "重置您的密码 - IRIS Vista",
+		"reset_intro":           "我们收到了重置您 IRIS Vista 账户密码的请求。",
+		"reset_button":          "重置密码",
+		"reset_expiry":          "此链接将在 %d 分钟后失效，且只能使用一次。",
+		"reset_ignore":          "如果您没有申请重置密码，请忽略此邮件，您的密码不会改变。",
+		"status_pending":        "待审批",
+		"status_approved":       "已批准",
+		"status_rejected":       "已拒绝",
+		"status_info_requested": "需要补充信息",
+		"status_purchased":      "已采购",
+		"status_delivered":      "已交付",
+		"status_cancelled":      "已取消",
+	},
+	"ko": {
+		"greeting":              "%s님, 안녕하세요.",
+		"footer":                "IRIS Vista - 공급망 및 구매",
+		"request_number":        "요청 번호",
+		"requester":             "요청자",
+		"product":               "제품",
+		"quantity":              "수량",
+		"view_request":          "요청 보기",
+		"approved_subject":      "요청 #%s 승인됨 - IRIS Vista",
+		"approved_status":       "구매 요청이 승인되었습니다",
+		"approved_body":         "이제 구매팀이 요청을 처리할 수 있습니다.",
+		"rejected_subject":      "요청 #%s 반려됨 - IRIS Vista",
+		"rejected_status":       "구매 요청이 반려되었습니다",
+		"rejection_reason":      "반려 사유",
+		"rejected_body":         "이 결정에 대해 문의 사항이 있으면 관리자에게 연락하십시오.",
+		"info_subject":          "요청 #%s 추가 정보 필요 - IRIS Vista",
+		"info_status":           "추가 정보가 필요합니다",
+		"info_label":            "요청된 정보",
+		"info_body":             "필요한 정보로 요청을 업데이트하십시오.",
+		"update_request":        "요청 업데이트",
+		"info_response_subject": "요청 #%s 정보 요청에 대한 답변 - IRIS Vista",
+		"info_response_status":  "요청자가 질문에 답변했습니다",
+		"info_response_label":   "답변",
+		"info_response_body":    "요청이 승인 대기열로 돌아왔습니다.",
+		"new_subject":           "새 요청 #%s - IRIS Vista",
+		"new_subject_urgent":    "[긴급] 새 요청 #%s - IRIS Vista",
+		"new_status":            "승인 대기 중인 새 요청",
+		"urgent":                "긴급",
+		"new_body":              "이 요청을 검토하고 처리하십시오.",
+		"review_request":        "요청 검토",
+		"purchased_subject":     "주문 #%s 완료 - IRIS Vista",
+		"purchased_status":      "주문이 완료되었습니다",
+		"purchased_body":        "구매팀이 주문을 성공적으로 처리했습니다.",
+		"view_details":          "자세히 보기",
+		"digest_subject_daily":  "일일 요약 - IRIS Vista",
+		"digest_subject_weekly": "주간 요약 - IRIS Vista",
+		"digest_intro":          "지난 요약 이후의 활동입니다.",
+		"digest_notifications":  "읽지 않은 알림",
+		"digest_pending":        "승인 대기 중",
+		"digest_status_changes": "요청 업데이트",
+		"digest_more":           "외 %d건",
+		"open_app":              "IRIS Vista 열기",
+		"reset_subject":         "비밀번호 재설정 - IRIS Vista",
+		"reset_intro":           "IRIS Vista 계정의 비밀번호 재설정 요청을 받았습니다.",
+		"reset_button":          "비밀번호 재설정",
+		"reset_expiry":          "이 링크는 %d분 후에 만료되며 한 번만 사용할 수 있습니다.",
+		"reset_ignore":          "비밀번호 재설정을 요청하지 않으셨다면 이 이메일을 무시하셔도 됩니다. 비밀번호는 변경되지 않습니다.",
+		"status_pending":        "대기 중",
+		"status_approved":       "승인됨",
+		"status_rejected":       "거부됨",
+		"status_info_requested": "정보 요청됨",
+		"status_purchased":      "구매 완료",
+		"status_delivered":      "배송 완료",
+		"status_cancelled":      "취소됨",
+	},
+	"pt": {
+		"greeting":              "Olá %s,",
+		"footer":                "IRIS Vista - Cadeia de Suprimentos e Compras",
+		"request_number":        "Número da solicitação",
+		"requester":             "Solicitante",
+		"product":               "Produto",
+		"quantity":              "Quantidade",
+		"view_request":          "Ver solicitação",
+		"approved_subject":      "Solicitação #%s aprovada - IRIS Vista",
+		"approved_status":       "Sua solicitação de compra foi aprovada",
+		"approved_body":         "Sua solicitação está pronta para ser processada pela equipe de compras.",
+		"rejected_subject":      "Solicitação #%s rejeitada - IRIS Vista",
+		"rejected_status":       "Sua solicitação de compra foi rejeitada",
+		"rejection_reason":      "Motivo da rejeição",
+		"rejected_body":         "Se tiver dúvidas sobre esta decisão, entre em contato com seu gerente.",
+		"info_subject":          "Informações necessárias para #%s - IRIS Vista",
+		"info_status":           "Informações adicionais necessárias",
+		"info_label":            "Informações solicitadas",
+		"info_body":             "Atualize sua solicitação com as informações necessárias.",
+		"update_request":        "Atualizar solicitação",
+		"info_response_subject": "Resposta à solicitação de informações #%s - IRIS Vista",
+		"info_response_status":  "O solicitante respondeu à sua pergunta",
+		"info_response_label":   "Resposta",
+		"info_response_body":    "A solicitação voltou para sua fila de aprovação.",
+		"new_subject":           "Nova solicitação #%s - IRIS Vista",
+		"new_subject_urgent":    "[URGENTE] Nova solicitação #%s - IRIS Vista",
+		"new_status":            "Nova solicitação aguardando aprovação",
+		"urgent":                "URGENTE",
+		"new_body":              "Revise e processe esta solicitação.",
+		"review_request":        "Revisar solicitação",
+		"purchased_subject":     "Pedido #%s concluído - IRIS Vista",
+		"purchased_status":      "Seu pedido foi concluído",
+		"purchased_body":        "A equipe de compras processou seu pedido com sucesso.",
+		"view_details":          "Ver detalhes",
+		"digest_subject_daily":  "Seu resumo diário - IRIS Vista",
+		"digest_subject_weekly": "Seu resumo semanal - IRIS Vista",
+		"digest_intro":          "Veja o que aconteceu desde o seu último resumo.",
+		"digest_notifications":  "Notificações não lidas",
+		"digest_pending":        "Aguardando sua aprovação",
+		"digest_status_changes": "Atualizações das suas solicitações",
+		"digest_more":           "E mais %d",
+		"open_app":              "Abrir IRIS Vista",
+		"reset_subject":         "Redefina sua senha - IRIS Vista",
+		"reset_intro":           "Recebemos uma solicitação para redefinir a senha da sua conta IRIS Vista.",
+		"reset_button":          "Redefinir senha",
+		"reset_expiry":          "Este link expira em %d minutos e só pode ser usado uma vez.",
+		"reset_ignore":          "Se você não pediu para redefinir sua senha, pode ignorar este e-mail. Sua senha não será alterada.",
+		"status_pending":        "Pendente",
+		"status_approved":       "Aprovada",
+		"status_rejected":       "Rejeitada",
+		"status_info_requested": "Informações solicitadas",
+		"status_purchased":      "Comprada",
+		"status_delivered":      "Entregue",
+		"status_cancelled":      "Cancelada",
+	},
+}
+
+// userLanguage returns the enabled language emails to a user should use
+func userLanguage(user *models.User) string {
+	if user == nil {
+		return i18n.Default()
+	}
+	return i18n.Resolve(user.Language)
+}
+
+// msg returns the string for key in lang, formatted with args if given
+func msg(lang, key string, args ...interface{}) string {
+	text, ok := messages[lang][key]
+	if !ok {
+		text, ok = messages[i18n.Default()][key]
+	}
+	if !ok {
+		text = messages["en"][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// catalog returns every string for lang with fallbacks applied, for use as
+// {{.T.key}} in templates
+func catalog(lang string) map[string]string {
+	result := make(map[string]string, len(messages["en"]))
+	for key := range messages["en"] {
+		result[key] = msg(lang, key)
+	}
+	return result
+}
+
+// localized returns the translation of a TranslatedText column for lang,
+// falling back to the given text
+func localized(data models.JSONB, lang, fallback string) string {
+	if t := translation.FromJSON(data); t != nil {
+		if text := t.Get(lang); text != "" {
+			return text
+		}
+	}
+	return fallback
+}
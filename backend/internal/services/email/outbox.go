@@ -0,0 +1,138 @@
+package email
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/worker"
+	"vista-backend/pkg/crypto"
+)
+
+// Outbox delivers queued emails in the background, retrying failures with backoff
+type Outbox struct {
+	*worker.Worker[models.EmailMessage]
+	db       *gorm.DB
+	emailSvc *EmailService
+}
+
+// NewOutbox creates a new outbox worker
+func NewOutbox(db *gorm.DB, encryptionSvc *crypto.EncryptionService) *Outbox {
+	o := &Outbox{
+		db:       db,
+		emailSvc: NewEmailService(db).WithEncryption(encryptionSvc),
+	}
+	o.Worker = worker.New(db, worker.Queue[models.EmailMessage]{
+		Name:         "Email outbox",
+		Pending:      models.EmailPending,
+		Running:      models.EmailSending,
+		DueColumn:    "next_attempt_at",
+		PollInterval: 10 * time.Second,
+		BatchSize:    20,
+		Process:      o.send,
+	})
+	return o
+}
+
+// send delivers one message and records the outcome
+func (o *Outbox) send(message *models.EmailMessage) {
+	message.Attempts++
+
+	providerID, err := o.emailSvc.Deliver(message)
+	if err != nil {
+		if message.CanRetry() {
+			o.retry(message, err)
+		} else {
+			o.fail(message, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	o.db.Model(message).Updates(map[string]interface{}{
+		"status":              models.EmailSent,
+		"attempts":            message.Attempts,
+		"last_error":          "",
+		"provider":            message.Provider,
+		"provider_message_id": providerID,
+		"sent_at":             now,
+	})
+	if message.Kind == KindPasswordReset {
+		o.clearBody(message)
+	}
+	if message.NotificationID != nil {
+		o.db.Model(&models.Notification{}).
+			Where("id = ?", *message.NotificationID).
+			Update("email_sent_at", now)
+	}
+}
+
+// retry reschedules a message with exponential backoff
+func (o *Outbox) retry(message *models.EmailMessage, err error) {
+	delay := worker.Backoff(message.Attempts, time.Minute, time.Hour)
+
+	log.Printf("Email %d to %s failed (attempt %d/%d), retrying in %s: %v",
+		message.ID, message.To, message.Attempts, message.MaxAttempts, delay, err)
+
+	o.db.Model(message).Updates(map[string]interface{}{
+		"status":          models.EmailPending,
+		"attempts":        message.Attempts,
+		"last_error":      err.Error(),
+		"provider":        message.Provider,
+		"next_attempt_at": time.Now().Add(delay),
+	})
+}
+
+// fail marks a message as permanently failed
+func (o *Outbox) fail(message *models.EmailMessage, err error) {
+	log.Printf("Email %d to %s failed permanently: %v", message.ID, message.To, err)
+	o.db.Model(message).Updates(map[string]interface{}{
+		"status":     models.EmailFailed,
+		"attempts":   message.Attempts,
+		"last_error": err.Error(),
+		"provider":   message.Provider,
+	})
+}
+
+// clearBody removes the content of a sent message that shouldn't be kept,
+// such as a password reset link
+func (o *Outbox) clearBody(message *models.EmailMessage) {
+	o.db.Model(message).Updates(map[string]interface{}{
+		"html_body": "",
+		"text_body": "",
+	})
+}
+
+// ErrNotFailed is returned when resending a message that hasn't failed
+var ErrNotFailed = errors.New("only failed emails can be resent")
+
+// Resend queues a failed message to be sent again with a fresh set of attempts
+func Resend(db *gorm.DB, id uint) (*models.EmailMessage, error) {
+	var message models.EmailMessage
+	if err := db.First(&message, id).Error; err != nil {
+		return nil, err
+	}
+	if message.Status != models.EmailFailed {
+		return nil, ErrNotFailed
+	}
+
+	result := db.Model(&message).
+		Where("status = ?", models.EmailFailed).
+		Updates(map[string]interface{}{
+			"status":          models.EmailPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFailed
+	}
+	if err := db.First(&message, id).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
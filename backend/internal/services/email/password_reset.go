@@ -0,0 +1,45 @@
+package email
+
+import (
+	"fmt"
+	"time"
+
+	"vista-backend/internal/models"
+)
+
+// KindPasswordReset marks password reset emails in the outbox. Their body
+// holds a live reset link, so it is cleared once the email is sent.
+const KindPasswordReset = "password_reset"
+
+// SendPasswordResetEmail queues a password reset link for the user, in their
+// language. It returns an error if email isn't configured, since the link
+// can't be delivered any other way.
+func (s *EmailService) SendPasswordResetEmail(user *models.User, resetURL string, validFor time.Duration) error {
+	config, err := s.getConfig(models.UserCompanyCode(s.db, user))
+	if err != nil {
+		return err
+	}
+	if config == nil || !config.CanSendEmail() {
+		return fmt.Errorf("email service not configured")
+	}
+
+	lang := userLanguage(user)
+	htmlBody, err := renderTemplate(`
+            <p>{{.Greeting}}</p>
+            <p>{{.T.reset_intro}}</p>
+            <a href="{{.ResetURL}}" class="btn">{{.T.reset_button}}</a>
+            <p class="item-meta">{{.Expiry}}</p>
+            <p class="item-meta">{{.T.reset_ignore}}</p>
+`, map[string]interface{}{
+		"Lang":     lang,
+		"T":        catalog(lang),
+		"Greeting": msg(lang, "greeting", user.Name),
+		"ResetURL": resetURL,
+		"Expiry":   msg(lang, "reset_expiry", int(validFor.Minutes())),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render password reset email: %w", err)
+	}
+
+	return s.queue(models.UserCompanyCode(s.db, user), user, KindPasswordReset, msg(lang, "reset_subject"), htmlBody, SendOptions{})
+}
@@ -0,0 +1,224 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vista-backend/internal/models"
+)
+
+// smtpTimeout bounds connecting to and talking with the relay
+const smtpTimeout = 30 * time.Second
+
+// sendViaSMTP sends email through the configured SMTP relay and returns the
+// Message-ID it was sent with. The config's secrets must already be decrypted.
+func (s *EmailService) sendViaSMTP(config *models.EmailConfig, to []string, subject, htmlBody, textBody string) (string, error) {
+	if len(to) == 0 {
+		return "", fmt.Errorf("no recipients")
+	}
+
+	messageID, raw, err := buildMIMEMessage(config, to, subject, htmlBody, textBody)
+	if err != nil {
+		return "", err
+	}
+	if config.HasDKIM() {
+		signature, err := dkimSign(raw, config.DKIMDomain, config.DKIMSelector, config.DKIMPrivateKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to DKIM sign message: %w", err)
+		}
+		raw = append([]byte(signature), raw...)
+	}
+
+	client, err := dialSMTP(config)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if config.SMTPUsername != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return "", fmt.Errorf("SMTP server does not support authentication")
+		}
+		auth := smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return "", fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(config.FromEmail); err != nil {
+		return "", fmt.Errorf("SMTP MAIL FROM rejected: %w", err)
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return "", fmt.Errorf("SMTP recipient %s rejected: %w", addr, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("SMTP DATA rejected: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+
+	// The message is accepted at this point; a failed QUIT doesn't matter
+	client.Quit()
+	return messageID, nil
+}
+
+// dialSMTP connects to the relay using the configured security mode
+func dialSMTP(config *models.EmailConfig) (*smtp.Client, error) {
+	port := config.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(config.SMTPHost, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: config.SMTPHost}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	if config.SMTPSecurity == models.SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+
+	if err := client.Hello(heloName(config)); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("SMTP EHLO failed: %w", err)
+	}
+
+	if config.SMTPSecurity == "" || config.SMTPSecurity == models.SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// heloName is the name we greet the relay with: the sender's domain
+func heloName(config *models.EmailConfig) string {
+	if at := strings.LastIndex(config.FromEmail, "@"); at >= 0 && at < len(config.FromEmail)-1 {
+		return config.FromEmail[at+1:]
+	}
+	return "localhost"
+}
+
+// buildMIMEMessage renders the message with CRLF line endings and returns it
+// with its Message-ID
+func buildMIMEMessage(config *models.EmailConfig, to []string, subject, htmlBody, textBody string) (string, []byte, error) {
+	messageID, err := newMessageID(heloName(config))
+	if err != nil {
+		return "", nil, err
+	}
+
+	from := (&mail.Address{Name: config.FromName, Address: config.FromEmail}).String()
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from)
+	header("To", strings.Join(to, ", "))
+	if config.ReplyToEmail != "" {
+		header("Reply-To", config.ReplyToEmail)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+
+	if textBody == "" {
+		header("Content-Type", `text/html; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, htmlBody); err != nil {
+			return "", nil, err
+		}
+		return messageID, buf.Bytes(), nil
+	}
+
+	boundary, err := randomHex(16)
+	if err != nil {
+		return "", nil, err
+	}
+	header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", textBody},
+		{"text/html", htmlBody},
+	} {
+		buf.WriteString("--" + boundary + "\r\n")
+		buf.WriteString("Content-Type: " + part.contentType + "; charset=\"utf-8\"\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, part.body); err != nil {
+			return "", nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("--" + boundary + "--\r\n")
+	return messageID, buf.Bytes(), nil
+}
+
+// writeQuotedPrintable encodes body with CRLF line endings
+func writeQuotedPrintable(buf *bytes.Buffer, body string) error {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("\r\n")
+	return nil
+}
+
+// newMessageID returns a unique Message-ID for the sender's domain
+func newMessageID(domain string) (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), id, domain), nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
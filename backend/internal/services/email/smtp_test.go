@@ -0,0 +1,435 @@
+package email
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"vista-backend/internal/models"
+)
+
+// testSMTPServer is a minimal in-process SMTP relay. It records every message
+// it accepts and can require AUTH PLAIN or offer STARTTLS with a certificate
+// the client doesn't trust.
+type testSMTPServer struct {
+	listener net.Listener
+	username string // Require AUTH PLAIN with these credentials if set
+	password string
+	startTLS *tls.Config // Advertise STARTTLS and handshake with this config if set
+
+	mu       sync.Mutex
+	messages []receivedMessage
+}
+
+// receivedMessage is one message the test server accepted
+type receivedMessage struct {
+	From string
+	To   []string
+	Data string // With CRLF line endings, as sent
+}
+
+func newTestSMTPServer(t *testing.T) *testSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &testSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go srv.serve()
+	return srv
+}
+
+func (srv *testSMTPServer) port() int {
+	return srv.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (srv *testSMTPServer) received() []receivedMessage {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]receivedMessage(nil), srv.messages...)
+}
+
+func (srv *testSMTPServer) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		go srv.handle(conn)
+	}
+}
+
+func (srv *testSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 test ready")
+
+	authenticated := false
+	var msg receivedMessage
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"test greets " + arg}
+			if srv.startTLS != nil {
+				lines = append(lines, "STARTTLS")
+			}
+			if srv.username != "" {
+				lines = append(lines, "AUTH PLAIN")
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				text.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			if srv.startTLS == nil {
+				text.PrintfLine("502 not supported")
+				continue
+			}
+			text.PrintfLine("220 go ahead")
+			tlsConn := tls.Server(conn, srv.startTLS)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(decoded), "\x00")
+			if strings.ToUpper(mechanism) == "PLAIN" && len(parts) == 3 &&
+				parts[1] == srv.username && parts[2] == srv.password {
+				authenticated = true
+				text.PrintfLine("235 authenticated")
+			} else {
+				text.PrintfLine("535 authentication failed")
+			}
+		case "MAIL":
+			if srv.username != "" && !authenticated {
+				text.PrintfLine("530 authentication required")
+				continue
+			}
+			msg = receivedMessage{From: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 send it")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = strings.ReplaceAll(string(data), "\n", "\r\n")
+			srv.mu.Lock()
+			srv.messages = append(srv.messages, msg)
+			srv.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 ok")
+		}
+	}
+}
+
+// untrustedTLSConfig returns a server TLS config with a self-signed certificate
+func untrustedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Company{},
+		&models.EmailConfig{},
+		&models.EmailTemplate{},
+		&models.EmailMessage{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// smtpConfig saves an SMTP email configuration for company MX-01 pointing at srv
+func smtpConfig(t *testing.T, db *gorm.DB, srv *testSMTPServer, security string) *models.EmailConfig {
+	t.Helper()
+	config := models.GetDefaultEmailConfig()
+	config.CompanyCode = "MX-01"
+	config.Provider = models.EmailProviderSMTP
+	config.SMTPHost = "127.0.0.1"
+	config.SMTPPort = srv.port()
+	config.SMTPSecurity = security
+	config.FromEmail = "vista@example.com"
+	config.FromName = "IRIS Vista"
+	config.Enabled = true
+	if err := db.Create(&config).Error; err != nil {
+		t.Fatalf("create email config: %v", err)
+	}
+	return &config
+}
+
+// splitMessage returns a message's headers and its body
+func splitMessage(t *testing.T, data string) (map[string]string, string) {
+	t.Helper()
+	end := strings.Index(data, "\r\n\r\n")
+	if end < 0 {
+		t.Fatalf("message has no body:\n%s", data)
+	}
+	return parseHeaders(data[:end+2]), data[end+4:]
+}
+
+func TestTestConnectionSendsThroughSMTP(t *testing.T) {
+	db := newTestDB(t)
+	srv := newTestSMTPServer(t)
+	smtpConfig(t, db, srv, models.SMTPSecurityNone)
+
+	if err := NewEmailService(db).TestConnection("MX-01", "admin@example.com"); err != nil {
+		t.Fatalf("TestConnection: %v", err)
+	}
+
+	messages := srv.received()
+	if len(messages) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.From != "vista@example.com" {
+		t.Errorf("MAIL FROM = %q, want vista@example.com", msg.From)
+	}
+	if len(msg.To) != 1 || msg.To[0] != "admin@example.com" {
+		t.Errorf("RCPT TO = %v, want [admin@example.com]", msg.To)
+	}
+	headers, body := splitMessage(t, msg.Data)
+	if got := strings.TrimSpace(headers["subject"]); got != "IRIS Vista - Email Test" {
+		t.Errorf("Subject = %q", got)
+	}
+	if !strings.Contains(headers["content-type"], "multipart/alternative") {
+		t.Errorf("Content-Type = %q, want multipart/alternative", headers["content-type"])
+	}
+	if !strings.Contains(body, "Email configuration is working correctly!") {
+		t.Errorf("body is missing the test text:\n%s", body)
+	}
+}
+
+func TestTemplateEmailIsDKIMSigned(t *testing.T) {
+	db := newTestDB(t)
+	srv := newTestSMTPServer(t)
+	config := smtpConfig(t, db, srv, models.SMTPSecurityNone)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate DKIM key: %v", err)
+	}
+	config.DKIMDomain = "example.com"
+	config.DKIMSelector = "vista"
+	config.DKIMPrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	if err := db.Save(config).Error; err != nil {
+		t.Fatalf("save DKIM settings: %v", err)
+	}
+
+	if err := db.Create(&models.EmailTemplate{
+		Kind:     models.EmailTemplateApproval,
+		Language: "en",
+		Subject:  "{{.RequestNumber}} approved for {{.RecipientName}}",
+		Body:     "<p>{{.Greeting}}</p><p>{{.ProductTitle}} x{{.Quantity}} is approved.</p>",
+	}).Error; err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+
+	svc := NewEmailService(db)
+	user := &models.User{ID: 7, Email: "requester@example.com", Name: "Ana López", Language: "en"}
+	request := &models.PurchaseRequest{ID: 42, CompanyCode: "MX-01", RequestNumber: "PR-MX-2026-0042", ProductTitle: "Office chair", Quantity: 2}
+	if err := svc.SendRequestApprovedEmail(user, request, SendOptions{}); err != nil {
+		t.Fatalf("SendRequestApprovedEmail: %v", err)
+	}
+
+	var queued models.EmailMessage
+	if err := db.First(&queued).Error; err != nil {
+		t.Fatalf("load queued message: %v", err)
+	}
+	if _, err := svc.Deliver(&queued); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	messages := srv.received()
+	if len(messages) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(messages))
+	}
+	data := messages[0].Data
+	if !strings.HasPrefix(data, "DKIM-Signature: ") {
+		t.Fatalf("message does not start with a DKIM-Signature header:\n%s", data)
+	}
+	headers, body := splitMessage(t, data)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(strings.TrimSpace(headers["subject"]))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	if subject != "PR-MX-2026-0042 approved for Ana López" {
+		t.Errorf("Subject = %q", subject)
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if !strings.Contains(string(decoded), "Office chair x2 is approved.") {
+		t.Errorf("body is missing the rendered template:\n%s", decoded)
+	}
+
+	verifyDKIM(t, headers, body, &key.PublicKey)
+}
+
+// verifyDKIM checks a relaxed/relaxed rsa-sha256 DKIM signature
+func verifyDKIM(t *testing.T, headers map[string]string, body string, key *rsa.PublicKey) {
+	t.Helper()
+	raw := headers["dkim-signature"]
+	tags := map[string]string{}
+	for _, tag := range strings.Split(relaxedHeader("", raw)[1:], ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		tags[name] = strings.ReplaceAll(value, " ", "")
+	}
+	if tags["d"] != "example.com" || tags["s"] != "vista" {
+		t.Errorf("DKIM d=%q s=%q, want example.com and vista", tags["d"], tags["s"])
+	}
+	if _, err := strconv.ParseInt(tags["t"], 10, 64); err != nil {
+		t.Errorf("DKIM t=%q is not a timestamp", tags["t"])
+	}
+
+	bodyHash := sha256.Sum256(relaxedBody([]byte(body)))
+	if tags["bh"] != base64.StdEncoding.EncodeToString(bodyHash[:]) {
+		t.Errorf("DKIM body hash does not match the body")
+	}
+
+	var canonical strings.Builder
+	for _, name := range strings.Split(tags["h"], ":") {
+		canonical.WriteString(relaxedHeader(name, headers[name]) + "\r\n")
+	}
+	canonical.WriteString(relaxedHeader("dkim-signature", raw[:strings.LastIndex(raw, "b=")+2]))
+	digest := sha256.Sum256([]byte(canonical.String()))
+
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		t.Fatalf("decode DKIM signature: %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("DKIM signature does not verify: %v", err)
+	}
+}
+
+func TestSMTPAuthentication(t *testing.T) {
+	db := newTestDB(t)
+	srv := newTestSMTPServer(t)
+	srv.username = "vista"
+	srv.password = "secret"
+	config := smtpConfig(t, db, srv, models.SMTPSecurityNone)
+	svc := NewEmailService(db)
+	to := []string{"admin@example.com"}
+
+	config.SMTPUsername = "vista"
+	config.SMTPPassword = "wrong"
+	_, err := svc.sendViaSMTP(config, to, "Hello", "<p>Hello</p>", "")
+	if err == nil || !strings.Contains(err.Error(), "SMTP authentication failed") {
+		t.Errorf("wrong password: err = %v, want an authentication failure", err)
+	}
+
+	config.SMTPPassword = "secret"
+	if _, err := svc.sendViaSMTP(config, to, "Hello", "<p>Hello</p>", ""); err != nil {
+		t.Errorf("right password: %v", err)
+	}
+	if n := len(srv.received()); n != 1 {
+		t.Errorf("server received %d messages, want 1", n)
+	}
+}
+
+func TestSMTPAuthenticationUnsupported(t *testing.T) {
+	db := newTestDB(t)
+	srv := newTestSMTPServer(t)
+	config := smtpConfig(t, db, srv, models.SMTPSecurityNone)
+	config.SMTPUsername = "vista"
+	config.SMTPPassword = "secret"
+
+	_, err := NewEmailService(db).sendViaSMTP(config, []string{"admin@example.com"}, "Hello", "<p>Hello</p>", "")
+	if err == nil || !strings.Contains(err.Error(), "does not support authentication") {
+		t.Errorf("err = %v, want the relay to lack authentication", err)
+	}
+}
+
+func TestSMTPStartTLSFailures(t *testing.T) {
+	t.Run("not offered", func(t *testing.T) {
+		db := newTestDB(t)
+		srv := newTestSMTPServer(t)
+		config := smtpConfig(t, db, srv, models.SMTPSecurityStartTLS)
+		_, err := NewEmailService(db).sendViaSMTP(config, []string{"admin@example.com"}, "Hello", "<p>Hello</p>", "")
+		if err == nil || !strings.Contains(err.Error(), "does not support STARTTLS") {
+			t.Errorf("err = %v, want STARTTLS to be unsupported", err)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		db := newTestDB(t)
+		srv := newTestSMTPServer(t)
+		srv.startTLS = untrustedTLSConfig(t)
+		config := smtpConfig(t, db, srv, models.SMTPSecurityStartTLS)
+		_, err := NewEmailService(db).sendViaSMTP(config, []string{"admin@example.com"}, "Hello", "<p>Hello</p>", "")
+		if err == nil || !strings.Contains(err.Error(), "STARTTLS failed") {
+			t.Errorf("err = %v, want the TLS handshake to fail", err)
+		}
+		if n := len(srv.received()); n != 0 {
+			t.Errorf("server received %d messages over an untrusted connection", n)
+		}
+	})
+}
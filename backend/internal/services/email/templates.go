@@ -0,0 +1,338 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	texttemplate "text/template"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// TemplateVariable documents one value available to an email template
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// commonTemplateVariables are available to every notification template
+var commonTemplateVariables = []TemplateVariable{
+	{"Greeting", "Greeting with the recipient's name in their language", "{{.Greeting}}"},
+	{"RecipientName", "Name of the user receiving the email", "{{.RecipientName}}"},
+	{"RequestNumber", "Request number", "{{.RequestNumber}}"},
+	{"ProductTitle", "Product title, translated to the recipient's language when available", "{{.ProductTitle}}"},
+	{"Quantity", "Requested quantity", "{{.Quantity}}"},
+	{"RequesterName", "Name of the employee who made the request", "{{.RequesterName}}"},
+	{"Justification", "Justification, translated when available", "{{.Justification}}"},
+	{"TotalEstimated", "Estimated total with currency, empty if unknown", "{{if .TotalEstimated}}{{.TotalEstimated}}{{end}}"},
+	{"Urgent", "True for urgent requests", `{{if .Urgent}}<span class="badge">{{.T.urgent}}</span>{{end}}`},
+	{"ActionURL", "Link to the request in the app", `<a href="{{.ActionURL}}" class="btn">{{.T.view_request}}</a>`},
+	{"DefaultSubject", "The built-in subject, for use in a custom subject", "{{.DefaultSubject}} - ACME"},
+	{"Lang", "Recipient's language code", "{{.Lang}}"},
+	{"T", "Built-in strings in the recipient's language, e.g. labels and buttons", "{{.T.request_number}}"},
+}
+
+// templateDef is the built-in template for one kind
+type templateDef struct {
+	subjectKey string // messages key formatted with the request number
+	body       string
+	variables  []TemplateVariable // In addition to commonTemplateVariables
+}
+
+var templateDefs = map[models.EmailTemplateKind]templateDef{
+	models.EmailTemplateApproval: {
+		subjectKey: "approved_subject",
+		body: `
+            <p>{{.Greeting}}</p>
+            <div class="status status-approved">{{.T.approved_status}}</div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.request_number}}</span>
+                <span class="detail-value">{{.RequestNumber}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.product}}</span>
+                <span class="detail-value">{{.ProductTitle}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.quantity}}</span>
+                <span class="detail-value">{{.Quantity}}</span>
+            </div>
+            <p>{{.T.approved_body}}</p>
+            <a href="{{.ActionURL}}" class="btn">{{.T.view_request}}</a>
+`,
+	},
+	models.EmailTemplateRejection: {
+		subjectKey: "rejected_subject",
+		body: `
+            <p>{{.Greeting}}</p>
+            <div class="status status-rejected">{{.T.rejected_status}}</div>
+            <p><strong>Request #{{.RequestNumber}}</strong>: {{.ProductTitle}}</p>
+            <div class="note">
+                <div class="note-label">{{.T.rejection_reason}}</div>
+                <div class="note-text">{{.Reason}}</div>
+            </div>
+            <p>{{.T.rejected_body}}</p>
+            <a href="{{.ActionURL}}" class="btn">{{.T.view_request}}</a>
+`,
+		variables: []TemplateVariable{
+			{"Reason", "Rejection reason, translated to the recipient's language when available", "{{.Reason}}"},
+		},
+	},
+	models.EmailTemplateInfoRequest: {
+		subjectKey: "info_subject",
+		body: `
+            <p>{{.Greeting}}</p>
+            <div class="status status-info">{{.T.info_status}}</div>
+            <p><strong>Request #{{.RequestNumber}}</strong>: {{.ProductTitle}}</p>
+            <div class="note">
+                <div class="note-label">{{.T.info_label}}</div>
+                <div class="note-text">{{.Note}}</div>
+            </div>
+            <p>{{.T.info_body}}</p>
+            <a href="{{.ActionURL}}" class="btn">{{.T.update_request}}</a>
+`,
+		variables: []TemplateVariable{
+			{"Note", "The approver's question, translated to the recipient's language when available", "{{.Note}}"},
+		},
+	},
+	models.EmailTemplateInfoResponse: {
+		subjectKey: "info_response_subject",
+		body: `
+            <p>{{.Greeting}}</p>
+            <div class="status status-new">{{.T.info_response_status}}</div>
+            <p><strong>Request #{{.RequestNumber}}</strong>: {{.ProductTitle}}</p>
+            <div class="note">
+                <div class="note-label">{{.T.info_label}}</div>
+                <div class="note-text">{{.Note}}</div>
+            </div>
+            <div class="note">
+                <div class="note-label">{{.T.info_response_label}}</div>
+                <div class="note-text">{{.Response}}</div>
+            </div>
+            <p>{{.T.info_response_body}}</p>
+            <a href="{{.ActionURL}}" class="btn">{{.T.review_request}}</a>
+`,
+		variables: []TemplateVariable{
+			{"Note", "The approver's question, translated to the recipient's language when available", "{{.Note}}"},
+			{"Response", "The requester's answer, translated to the recipient's language when available", "{{.Response}}"},
+		},
+	},
+	models.EmailTemplatePurchased: {
+		subjectKey: "purchased_subject",
+		body: `
+            <p>{{.Greeting}}</p>
+            <div class="status status-purchased">{{.T.purchased_status}}</div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.request_number}}</span>
+                <span class="detail-value">{{.RequestNumber}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.product}}</span>
+                <span class="detail-value">{{.ProductTitle}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.quantity}}</span>
+                <span class="detail-value">{{.Quantity}}</span>
+            </div>
+            <p>{{.T.purchased_body}}</p>
+            <a href="{{.ActionURL}}" class="btn">{{.T.view_details}}</a>
+`,
+	},
+	models.EmailTemplateNewRequest: {
+		subjectKey: "new_subject",
+		body: `
+            <p>{{.Greeting}}</p>
+            <div class="status status-new">{{.T.new_status}} {{if .Urgent}}<span class="badge">{{.T.urgent}}</span>{{end}}</div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.request_number}}</span>
+                <span class="detail-value">{{.RequestNumber}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.requester}}</span>
+                <span class="detail-value">{{.RequesterName}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.product}}</span>
+                <span class="detail-value">{{.ProductTitle}}</span>
+            </div>
+            <div class="detail-row">
+                <span class="detail-label">{{.T.quantity}}</span>
+                <span class="detail-value">{{.Quantity}}</span>
+            </div>
+            <p>{{.T.new_body}}</p>
+            <a href="{{.ActionURL}}" class="btn">{{.T.review_request}}</a>
+`,
+	},
+}
+
+// TemplateVariables returns the variables a template kind can use
+func TemplateVariables(kind models.EmailTemplateKind) []TemplateVariable {
+	vars := make([]TemplateVariable, 0, len(commonTemplateVariables)+1)
+	vars = append(vars, commonTemplateVariables...)
+	return append(vars, templateDefs[kind].variables...)
+}
+
+// DefaultTemplateBody returns the built-in body of a template kind
+func DefaultTemplateBody(kind models.EmailTemplateKind) string {
+	return templateDefs[kind].body
+}
+
+// TemplateContext is what a notification email is rendered from
+type TemplateContext struct {
+	Request       *models.PurchaseRequest // Requester should be loaded for new_request
+	RecipientName string
+	Lang          string
+	Reason        string // Rejection reason in Lang
+	Note          string // Info request note in Lang
+	Response      string // Requester's answer to the info request in Lang
+	Urgent        bool
+}
+
+// NewTemplateContext builds the context for emailing a request to a
+// recipient, taking the rejection reason and info note from the request
+func NewTemplateContext(request *models.PurchaseRequest, recipientName, lang string) TemplateContext {
+	return TemplateContext{
+		Request:       request,
+		RecipientName: recipientName,
+		Lang:          lang,
+		Reason:        localized(request.RejectionReasonTranslated, lang, request.RejectionReason),
+		Note:          localized(request.InfoRequestNoteTranslated, lang, request.InfoRequestNote),
+		Response:      localized(request.InfoResponseTranslated, lang, request.InfoResponse),
+		Urgent:        request.IsUrgent(),
+	}
+}
+
+// SampleTemplateContext returns made-up data for previewing and validating templates
+func SampleTemplateContext(lang string) TemplateContext {
+	total := 379.80
+	request := &models.PurchaseRequest{
+		ID:             42,
+		RequestNumber:  "PR-2026-0042",
+		ProductTitle:   "Ergonomic office chair",
+		Quantity:       2,
+		Justification:  "Replacement for two broken chairs in the design team",
+		TotalEstimated: &total,
+		Currency:       "USD",
+		Urgency:        models.UrgencyUrgent,
+		Requester:      models.User{Name: "Alex Kim"},
+	}
+	return TemplateContext{
+		Request:       request,
+		RecipientName: "Jordan Lee",
+		Lang:          lang,
+		Reason:        "Please choose an equivalent model from the catalog.",
+		Note:          "Which cost center should this be charged to?",
+		Response:      "Design team, cost center 4410.",
+		Urgent:        true,
+	}
+}
+
+// templateData builds the variables documented by TemplateVariables
+func templateData(kind models.EmailTemplateKind, ctx TemplateContext) map[string]interface{} {
+	request := ctx.Request
+	lang := ctx.Lang
+
+	actionURL := fmt.Sprintf("/requests?id=%d", request.ID)
+	if kind == models.EmailTemplateNewRequest || kind == models.EmailTemplateInfoResponse {
+		actionURL = fmt.Sprintf("/approvals?id=%d", request.ID)
+	}
+	totalEstimated := ""
+	if request.TotalEstimated != nil {
+		totalEstimated = fmt.Sprintf("%.2f %s", *request.TotalEstimated, request.Currency)
+	}
+
+	data := map[string]interface{}{
+		"Lang":           lang,
+		"T":              catalog(lang),
+		"Greeting":       msg(lang, "greeting", ctx.RecipientName),
+		"RecipientName":  ctx.RecipientName,
+		"RequestNumber":  request.RequestNumber,
+		"ProductTitle":   localized(request.ProductTitleTranslated, lang, request.ProductTitle),
+		"Quantity":       request.Quantity,
+		"RequesterName":  request.Requester.Name,
+		"Justification":  localized(request.JustificationTranslated, lang, request.Justification),
+		"TotalEstimated": totalEstimated,
+		"Urgent":         ctx.Urgent,
+		"ActionURL":      actionURL,
+		"DefaultSubject": defaultSubject(kind, ctx),
+	}
+	switch kind {
+	case models.EmailTemplateRejection:
+		data["Reason"] = ctx.Reason
+	case models.EmailTemplateInfoRequest:
+		data["Note"] = ctx.Note
+	case models.EmailTemplateInfoResponse:
+		data["Note"] = ctx.Note
+		data["Response"] = ctx.Response
+	}
+	return data
+}
+
+// defaultSubject returns the built-in subject for a kind
+func defaultSubject(kind models.EmailTemplateKind, ctx TemplateContext) string {
+	key := templateDefs[kind].subjectKey
+	if kind == models.EmailTemplateNewRequest && ctx.Urgent {
+		key = "new_subject_urgent"
+	}
+	return msg(ctx.Lang, key, ctx.Request.RequestNumber)
+}
+
+// RenderEmailTemplate renders a subject and body template for a kind. An
+// empty subject uses the built-in one. Unknown variables are errors.
+func RenderEmailTemplate(kind models.EmailTemplateKind, subject, body string, ctx TemplateContext) (string, string, error) {
+	data := templateData(kind, ctx)
+
+	renderedSubject := data["DefaultSubject"].(string)
+	if strings.TrimSpace(subject) != "" {
+		tmpl, err := texttemplate.New("subject").Option("missingkey=error").Parse(subject)
+		if err != nil {
+			return "", "", fmt.Errorf("subject: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", "", fmt.Errorf("subject: %w", err)
+		}
+		// Header values must be a single line
+		renderedSubject = strings.Join(strings.Fields(buf.String()), " ")
+	}
+
+	html, err := renderTemplate(body, data)
+	if err != nil {
+		return "", "", fmt.Errorf("body: %w", err)
+	}
+	return renderedSubject, html, nil
+}
+
+// ValidateEmailTemplate checks that a template parses and renders with sample data
+func ValidateEmailTemplate(kind models.EmailTemplateKind, lang, subject, body string) error {
+	if !kind.IsValid() {
+		return fmt.Errorf("unknown template kind: %s", kind)
+	}
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("body is required")
+	}
+	_, _, err := RenderEmailTemplate(kind, subject, body, SampleTemplateContext(lang))
+	return err
+}
+
+// renderEmail renders a notification email with the admin's template for the
+// recipient's language, or the built-in one if there is none or it fails
+func (s *EmailService) renderEmail(kind models.EmailTemplateKind, ctx TemplateContext) (string, string, error) {
+	var custom models.EmailTemplate
+	err := s.db.Where("kind = ? AND language = ?", kind, ctx.Lang).First(&custom).Error
+	switch {
+	case err == nil:
+		subject, html, err := RenderEmailTemplate(kind, custom.Subject, custom.Body, ctx)
+		if err == nil {
+			return subject, html, nil
+		}
+		log.Printf("Failed to render %s email template for %s, using the built-in template: %v", kind, ctx.Lang, err)
+	case err != gorm.ErrRecordNotFound:
+		log.Printf("Failed to load %s email template for %s: %v", kind, ctx.Lang, err)
+	}
+
+	return RenderEmailTemplate(kind, "", templateDefs[kind].body, ctx)
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Handler reacts to an event after the change that emitted it is committed.
+// Handlers run in the background and must not assume any ordering.
+type Handler func(Event)
+
+// TxHandler reacts to an event inside the transaction that emits it, so its
+// writes are committed or rolled back together with the change. Returning an
+// error rolls the transaction back.
+type TxHandler func(tx *gorm.DB, e Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+type txSubscriber struct {
+	name    string
+	handler TxHandler
+}
+
+// Bus delivers domain events to their subscribers
+type Bus struct {
+	mu            sync.RWMutex
+	subscribers   []subscriber
+	txSubscribers []txSubscriber
+	wg            sync.WaitGroup
+}
+
+// NewBus creates an event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler run in the background after each commit
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber{name: name, handler: handler})
+}
+
+// SubscribeTx registers a handler run inside the emitting transaction
+func (b *Bus) SubscribeTx(name string, handler TxHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.txSubscribers = append(b.txSubscribers, txSubscriber{name: name, handler: handler})
+}
+
+// Transaction runs fn in a database transaction. Events passed to emit are
+// handed to the transactional subscribers before the commit and published
+// to the others once it succeeds; nothing is published if it fails.
+func (b *Bus) Transaction(db *gorm.DB, fn func(tx *gorm.DB, emit func(Event)) error) error {
+	var emitted []Event
+	err := db.Transaction(func(tx *gorm.DB) error {
+		emitted = nil
+		if err := fn(tx, func(e Event) { emitted = append(emitted, e) }); err != nil {
+			return err
+		}
+
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		for _, e := range emitted {
+			for _, s := range b.txSubscribers {
+				if err := s.handler(tx, e); err != nil {
+					log.Printf("Event subscriber %s failed on %s for request %d: %v", s.name, e.Name(), e.Meta().RequestID, err)
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.Publish(emitted...)
+	return nil
+}
+
+// Publish hands committed events to the background subscribers. Use
+// Transaction instead when the change is written in a transaction.
+func (b *Bus) Publish(events ...Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, e := range events {
+		for _, s := range b.subscribers {
+			b.wg.Add(1)
+			go b.run(s, e)
+		}
+	}
+}
+
+// Wait blocks until every published event has been handled
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
+
+func (b *Bus) run(s subscriber, e Event) {
+	defer b.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event subscriber %s panicked on %s for request %d: %v\n%s",
+				s.name, e.Name(), e.Meta().RequestID, r, debug.Stack())
+		}
+	}()
+	s.handler(e)
+}
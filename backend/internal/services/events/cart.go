@@ -0,0 +1,111 @@
+package events
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/amazon"
+	"vista-backend/pkg/crypto"
+)
+
+// SubscribeCartAutomation adds approved Amazon products to the configured
+// Amazon cart
+func SubscribeCartAutomation(bus *Bus, db *gorm.DB, amazonSvc *amazon.AutomationService, encryptionSvc *crypto.EncryptionService) {
+	if amazonSvc == nil {
+		return
+	}
+	automation := &cartAutomation{db: db, amazonSvc: amazonSvc, encryptionSvc: encryptionSvc}
+	bus.Subscribe("cart-automation", func(e Event) {
+		switch e := e.(type) {
+		case *RequestApproved:
+			automation.addToCart(e.RequestID)
+		case *RequestCreated:
+			if e.AutoApproved {
+				automation.addToCart(e.RequestID)
+			}
+		}
+	})
+}
+
+type cartAutomation struct {
+	db            *gorm.DB
+	amazonSvc     *amazon.AutomationService
+	encryptionSvc *crypto.EncryptionService
+}
+
+// addToCart adds an approved Amazon request to the cart, recording the outcome on the request
+func (a *cartAutomation) addToCart(requestID uint) {
+	var request models.PurchaseRequest
+	if err := a.db.First(&request, requestID).Error; err != nil {
+		log.Printf("Failed to load request %d for cart automation: %v", requestID, err)
+		return
+	}
+	if !request.IsAmazonURL {
+		return
+	}
+
+	// Check if the request's company has Amazon configured
+	var config models.AmazonConfig
+	if err := a.db.Where("company_code = ?", request.CompanyCode).First(&config).Error; err != nil {
+		log.Printf("Amazon config not found, skipping cart automation for request %d", request.ID)
+		return
+	}
+
+	if !config.CanConnect() {
+		log.Printf("Amazon not configured or inactive, skipping cart automation for request %d", request.ID)
+		return
+	}
+
+	// Decrypt password
+	password, err := a.encryptionSvc.Decrypt(config.EncryptedPassword)
+	if err != nil {
+		log.Printf("Failed to decrypt Amazon password: %v", err)
+		a.updateCartError(request.ID, "Failed to decrypt credentials")
+		return
+	}
+
+	// Set credentials
+	a.amazonSvc.SetCredentials(config.Email, password, config.Marketplace)
+
+	// Initialize browser if needed
+	if err := a.amazonSvc.Initialize(); err != nil {
+		log.Printf("Failed to initialize Amazon browser: %v", err)
+		a.updateCartError(request.ID, "Failed to initialize browser: "+err.Error())
+		return
+	}
+
+	// Login if needed
+	if !a.amazonSvc.IsLoggedIn() {
+		if err := a.amazonSvc.Login(); err != nil {
+			log.Printf("Failed to login to Amazon: %v", err)
+			a.updateCartError(request.ID, "Failed to login: "+err.Error())
+			return
+		}
+	}
+
+	// Add to cart
+	if err := a.amazonSvc.AddToCart(request.URL, request.Quantity); err != nil {
+		log.Printf("Failed to add to cart: %v", err)
+		a.updateCartError(request.ID, "Failed to add to cart: "+err.Error())
+		return
+	}
+
+	// Update request as successfully added to cart
+	now := time.Now()
+	a.db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
+		"added_to_cart":    true,
+		"added_to_cart_at": now,
+		"cart_error":       "",
+	})
+
+	log.Printf("Successfully added request %d to Amazon cart", request.ID)
+}
+
+// updateCartError updates the cart error for a request
+func (a *cartAutomation) updateCartError(requestID uint, errMsg string) {
+	a.db.Model(&models.PurchaseRequest{}).Where("id = ?", requestID).Updates(map[string]interface{}{
+		"cart_error": errMsg,
+	})
+}
@@ -0,0 +1,133 @@
+package events
+
+import (
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+)
+
+// Event is a domain event about a purchase request. Handlers emit events when
+// they change a request; side effects such as notifications, webhooks,
+// translation and audit subscribe to them on the Bus.
+type Event interface {
+	// Name identifies the event, e.g. "request.approved"
+	Name() string
+	// Meta returns what every event carries
+	Meta() *RequestEvent
+}
+
+// RequestEvent is embedded in every event
+type RequestEvent struct {
+	RequestID uint
+	Status    models.RequestStatus // Status after the change
+	Previous  models.RequestStatus // Status before the change; empty when created
+	ActorID   uint                 // User who made the change
+	IPAddress string
+	UserAgent string
+
+	// Translations lists the fields whose remaining languages still need
+	// translating, as returned by AsyncTranslator.TranslateField
+	Translations []Translation
+}
+
+// Translation is a translated column of the request waiting for its other languages
+type Translation struct {
+	Column string
+	Result *translation.TranslateFieldResult
+}
+
+// Meta returns the common event data
+func (e *RequestEvent) Meta() *RequestEvent {
+	return e
+}
+
+// Translate records that column needs its remaining languages translated.
+// A nil result (no text) is ignored.
+func (e *RequestEvent) Translate(column string, result *translation.TranslateFieldResult) {
+	if result == nil {
+		return
+	}
+	e.Translations = append(e.Translations, Translation{Column: column, Result: result})
+}
+
+// StatusChanged returns true if the event moved the request to another status
+func (e *RequestEvent) StatusChanged() bool {
+	return e.Status != e.Previous
+}
+
+// RequestCreated is emitted when a requester submits a request. Requests from
+// general managers are approved on creation.
+type RequestCreated struct {
+	RequestEvent
+	AutoApproved bool
+}
+
+// RequestUpdated is emitted when a requester edits a pending request or one
+// waiting for more information
+type RequestUpdated struct {
+	RequestEvent
+}
+
+// RequestApproved is emitted when a general manager approves a request
+type RequestApproved struct {
+	RequestEvent
+	Comment string
+}
+
+// RequestRejected is emitted when a general manager rejects a request
+type RequestRejected struct {
+	RequestEvent
+	Reason string
+}
+
+// InfoRequested is emitted when an approver asks the requester for more information
+type InfoRequested struct {
+	RequestEvent
+	Note string
+}
+
+// InfoProvided is emitted when the requester answers an info request, sending
+// the request back to pending
+type InfoProvided struct {
+	RequestEvent
+	Response string
+}
+
+// RequestCancelled is emitted when a requester withdraws their own request
+type RequestCancelled struct {
+	RequestEvent
+}
+
+// OrderPurchased is emitted when an approved order is purchased, either
+// explicitly or because its last item was marked as purchased
+type OrderPurchased struct {
+	RequestEvent
+	AllItemsPurchased bool
+}
+
+// OrderDelivered is emitted when a purchased order is delivered
+type OrderDelivered struct {
+	RequestEvent
+}
+
+// OrderCancelled is emitted when purchasing staff cancel an approved or purchased order
+type OrderCancelled struct {
+	RequestEvent
+	Reason string
+}
+
+// OrderNotesUpdated is emitted when purchasing staff change an order's admin notes
+type OrderNotesUpdated struct {
+	RequestEvent
+}
+
+func (*RequestCreated) Name() string    { return "request.created" }
+func (*RequestUpdated) Name() string    { return "request.updated" }
+func (*RequestApproved) Name() string   { return "request.approved" }
+func (*RequestRejected) Name() string   { return "request.rejected" }
+func (*InfoRequested) Name() string     { return "request.info_requested" }
+func (*InfoProvided) Name() string      { return "request.info_provided" }
+func (*RequestCancelled) Name() string  { return "request.cancelled" }
+func (*OrderPurchased) Name() string    { return "order.purchased" }
+func (*OrderDelivered) Name() string    { return "order.delivered" }
+func (*OrderCancelled) Name() string    { return "order.cancelled" }
+func (*OrderNotesUpdated) Name() string { return "order.notes_updated" }
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/notifications"
+	"vista-backend/internal/services/translation"
+	"vista-backend/internal/services/webhooks"
+)
+
+// SubscribeTranslations queues the remaining languages of the fields an event
+// translated, in the transaction that saves them
+func SubscribeTranslations(bus *Bus, db *gorm.DB) {
+	asyncTranslator := translation.NewAsyncTranslator(db)
+	bus.SubscribeTx("translations", func(tx *gorm.DB, e Event) error {
+		meta := e.Meta()
+		for _, t := range meta.Translations {
+			if err := asyncTranslator.EnqueueField(tx, "purchase_requests", meta.RequestID, t.Column, t.Result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SubscribeAudit records every event in the audit log, in the transaction
+// that makes the change
+func SubscribeAudit(bus *Bus) {
+	bus.SubscribeTx("audit", func(tx *gorm.DB, e Event) error {
+		meta := e.Meta()
+		entry := models.NewAuditLog(meta.ActorID, e.Name(), "purchase_request", meta.RequestID,
+			string(meta.Previous), string(meta.Status), meta.IPAddress, meta.UserAgent)
+		return tx.Create(entry).Error
+	})
+}
+
+// SubscribeWebhooks queues webhook deliveries for status changes
+func SubscribeWebhooks(bus *Bus, db *gorm.DB) {
+	bus.Subscribe("webhooks", func(e Event) {
+		meta := e.Meta()
+		switch e.(type) {
+		case *RequestCancelled:
+			// Withdrawn requests are stored as rejected
+			webhooks.PublishRequestEvent(db, models.WebhookRequestCancelled, meta.RequestID, meta.Previous)
+		case *InfoProvided:
+			webhooks.PublishRequestEvent(db, models.WebhookRequestInfoProvided, meta.RequestID, meta.Previous)
+		default:
+			if meta.StatusChanged() {
+				webhooks.PublishRequestStatus(db, meta.RequestID, meta.Previous)
+			}
+		}
+	})
+}
+
+// SubscribeNotifications pushes status changes to connected clients and
+// notifies the people concerned by each event
+func SubscribeNotifications(bus *Bus, db *gorm.DB) {
+	notificationSvc := notifications.NewNotificationService(db)
+	bus.Subscribe("notifications", func(e Event) {
+		meta := e.Meta()
+		request, err := loadRequest(db, meta.RequestID)
+		if err != nil {
+			log.Printf("Failed to load request %d for notifications: %v", meta.RequestID, err)
+			return
+		}
+
+		if meta.StatusChanged() {
+			notificationSvc.PublishStatusChange(request, meta.Previous)
+		}
+
+		switch e := e.(type) {
+		case *RequestCreated:
+			if e.AutoApproved {
+				// Approved on creation: purchasing staff can order it right away
+				err = notificationSvc.NotifyNewApprovedOrder(request)
+			} else {
+				err = notificationSvc.NotifyRequestCreated(request)
+			}
+		case *RequestApproved:
+			err = errors.Join(
+				notificationSvc.NotifyRequestApproved(request),
+				notificationSvc.NotifyNewApprovedOrder(request),
+			)
+		case *RequestRejected:
+			err = notificationSvc.NotifyRequestRejected(request, e.Reason)
+		case *InfoRequested:
+			err = notificationSvc.NotifyRequestInfoRequired(request, e.Note)
+		case *InfoProvided:
+			err = notificationSvc.NotifyRequestInfoProvided(request)
+		case *OrderPurchased:
+			err = notificationSvc.NotifyRequestPurchased(request)
+		}
+		if err != nil {
+			log.Printf("Failed to send %s notifications for request %d: %v", e.Name(), meta.RequestID, err)
+		}
+	})
+}
+
+// loadRequest fetches a request with the relations notifications render
+func loadRequest(db *gorm.DB, id uint) (*models.PurchaseRequest, error) {
+	var request models.PurchaseRequest
+	if err := db.Preload("Requester").Preload("Items").First(&request, id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
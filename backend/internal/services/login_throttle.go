@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// LoginPolicy limits repeated failed logins. Failures are read from the
+// activity log and forgotten after LockoutDuration.
+type LoginPolicy struct {
+	MaxFailures     int           // Failures for one identifier before it's locked out
+	IPMaxFailures   int           // Failures from one IP address before it's slowed down
+	LockoutDuration time.Duration // How long a lockout lasts
+}
+
+const (
+	// loginFreeFailures is how many failures per identifier are allowed
+	// before each attempt has to wait
+	loginFreeFailures = 2
+	// loginBackoffBase is the first wait; it doubles with every failure
+	loginBackoffBase = time.Second
+)
+
+// LoginThrottledError is returned when a login is attempted too soon after
+// failed ones. Unknown identifiers are throttled the same as real accounts.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool   // The identifier is locked out, rather than just slowed down
+	Reason     string // For the activity log
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed logins, retry in %s", e.RetryAfter)
+}
+
+// CheckLoginThrottle returns a LoginThrottledError if identifier or
+// ipAddress have to wait before trying again. Two-factor codes are checked
+// against the same limits as passwords.
+func (as *AuthService) CheckLoginThrottle(identifier, ipAddress string) error {
+	policy := as.loginPolicy
+	now := time.Now()
+	identifier = strings.ToLower(identifier)
+
+	// Per identifier, counting since the last success or admin unlock
+	since := now.Add(-policy.LockoutDuration)
+	var reset models.ActivityLog
+	err := as.db.Where("LOWER(identifier) = ? AND ((type = ? AND success = ?) OR type = ?) AND created_at > ?",
+		identifier, models.ActivityLogin, true, models.ActivityLoginUnlocked, since).
+		Order("created_at DESC").First(&reset).Error
+	if err == nil {
+		since = reset.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	failures, last, err := as.countLoginFailures("LOWER(identifier) = ?", identifier, since)
+	if err != nil {
+		return err
+	}
+	if policy.MaxFailures > 0 && failures >= int64(policy.MaxFailures) {
+		if wait := last.Add(policy.LockoutDuration).Sub(now); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait, Locked: true, Reason: "Account locked after repeated failed logins"}
+		}
+	}
+	if wait := loginBackoff(failures, loginFreeFailures, policy.LockoutDuration, last, now); wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait, Reason: "Too many failed logins for this account"}
+	}
+
+	// Per IP address, across identifiers
+	if policy.IPMaxFailures > 0 {
+		failures, last, err = as.countLoginFailures("ip_address = ?", ipAddress, now.Add(-policy.LockoutDuration))
+		if err != nil {
+			return err
+		}
+		if wait := loginBackoff(failures, int64(policy.IPMaxFailures), policy.LockoutDuration, last, now); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait, Reason: "Too many failed logins from this IP address"}
+		}
+	}
+
+	return nil
+}
+
+// countLoginFailures counts the failed logins where condition matches since a
+// time, and returns when the latest one happened
+func (as *AuthService) countLoginFailures(condition string, value interface{}, since time.Time) (int64, time.Time, error) {
+	failures := func() *gorm.DB {
+		return as.db.Model(&models.ActivityLog{}).
+			Where(condition+" AND type = ? AND created_at > ?", value, models.ActivityLoginFailed, since)
+	}
+
+	var count int64
+	if err := failures().Count(&count).Error; err != nil || count == 0 {
+		return count, time.Time{}, err
+	}
+
+	var last models.ActivityLog
+	if err := failures().Order("created_at DESC").First(&last).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+	return count, last.CreatedAt, nil
+}
+
+// loginBackoff returns how much longer to wait after the last failure: once
+// more than free failures happened, the wait doubles with each one, up to max
+func loginBackoff(failures, free int64, max time.Duration, last, now time.Time) time.Duration {
+	if failures <= free {
+		return 0
+	}
+	wait := max
+	if shift := failures - free - 1; shift < 30 {
+		if d := loginBackoffBase << shift; d < max {
+			wait = d
+		}
+	}
+	return last.Add(wait).Sub(now)
+}
@@ -13,15 +13,12 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"vista-backend/internal/services/translation"
+	"vista-backend/pkg/i18n"
 )
 
 // TranslatedText contains text in multiple languages
-type TranslatedText struct {
-	Original string `json:"original"`
-	En       string `json:"en"`
-	Zh       string `json:"zh"`
-	Es       string `json:"es"`
-}
+type TranslatedText = translation.TranslatedText
 
 // ProductMetadata contains extracted metadata from a product URL
 type ProductMetadata struct {
@@ -913,7 +910,7 @@ func (s *Service) Extract(url string) (*ProductMetadata, error) {
 	return metadata, nil
 }
 
-// translateText translates text to all supported languages
+// translateText translates text to all enabled languages
 func (s *Service) translateText(text string) *TranslatedText {
 	if text == "" {
 		return nil
@@ -924,35 +921,28 @@ func (s *Service) translateText(text string) *TranslatedText {
 		text = text[:500]
 	}
 
-	result := &TranslatedText{
-		Original: text,
-		// Initialize all fields with original text as fallback
-		En: text,
-		Zh: text,
-		Es: text,
+	// Detect source language
+	sourceLang := detectLanguage(text)
+
+	result := &TranslatedText{Original: text}
+	if sourceLang != "auto" {
+		result.SourceLanguage = sourceLang
 	}
 
-	// Detect source language using simple heuristic
-	sourceLang := detectLanguage(text)
+	// Translate to each enabled language
+	for _, targetLang := range i18n.Enabled() {
+		// Initialize with original text as fallback
+		result.Set(targetLang, text)
 
-	// Translate to each target language
-	targetLangs := []string{"en", "zh", "es"}
-	for _, targetLang := range targetLangs {
 		if sourceLang == targetLang || (sourceLang == "auto" && targetLang == "en") {
 			// Skip translation if source matches target
 			continue
 		}
 
-		translated, err := googleTranslate(text, sourceLang, targetLang)
+		protected, restore := translation.ProtectTerms(text, targetLang)
+		translated, err := googleTranslate(protected, sourceLang, targetLang)
 		if err == nil && translated != "" {
-			switch targetLang {
-			case "en":
-				result.En = translated
-			case "zh":
-				result.Zh = translated
-			case "es":
-				result.Es = translated
-			}
+			result.Set(targetLang, restore(translated))
 		}
 		// On error, keep the original text (already set as fallback)
 	}
@@ -960,27 +950,9 @@ func (s *Service) translateText(text string) *TranslatedText {
 	return result
 }
 
-// detectLanguage detects the language of text using simple heuristics
+// detectLanguage detects the language of text using the shared n-gram detector
 func detectLanguage(text string) string {
-	// Check for Chinese characters
-	for _, r := range text {
-		if r >= 0x4E00 && r <= 0x9FFF {
-			return "zh"
-		}
-	}
-
-	// Check for Spanish-specific characters
-	spanishChars := []rune{'ñ', 'Ñ', '¿', '¡', 'á', 'é', 'í', 'ó', 'ú', 'Á', 'É', 'Í', 'Ó', 'Ú'}
-	for _, r := range text {
-		for _, sc := range spanishChars {
-			if r == sc {
-				return "es"
-			}
-		}
-	}
-
-	// Default to auto (let translator decide)
-	return "auto"
+	return translation.DetectLanguage(text)
 }
 
 // googleTranslate uses Google Translate free API
@@ -0,0 +1,207 @@
+package notifications
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/email"
+)
+
+// digestSectionLimit caps how many items each digest section lists
+const digestSectionLimit = 10
+
+// DigestScheduler sends daily and weekly digest emails to users who chose them
+type DigestScheduler struct {
+	db           *gorm.DB
+	emailSvc     *email.EmailService
+	pollInterval time.Duration
+	stop         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewDigestScheduler creates a new digest scheduler
+func NewDigestScheduler(db *gorm.DB) *DigestScheduler {
+	return &DigestScheduler{
+		db:           db,
+		emailSvc:     email.NewEmailService(db),
+		pollInterval: time.Minute,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start begins checking for due digests
+func (d *DigestScheduler) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			d.RunOnce(time.Now())
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Println("Digest scheduler started")
+}
+
+// Stop signals the scheduler to exit and waits for the current run to finish
+func (d *DigestScheduler) Stop() {
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// RunOnce sends every digest due at now and returns how many were sent
+func (d *DigestScheduler) RunOnce(now time.Time) int {
+	if !d.canSendEmail() {
+		return 0
+	}
+
+	var prefs []models.NotificationPreference
+	if err := d.db.Where("digest_frequency IN ?", []models.DigestFrequency{models.DigestDaily, models.DigestWeekly}).
+		Find(&prefs).Error; err != nil {
+		log.Printf("Failed to fetch digest preferences: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range prefs {
+		pref := &prefs[i]
+		since := pref.UpdatedAt
+		if pref.LastDigestAt != nil {
+			since = *pref.LastDigestAt
+		}
+		if now.Before(pref.NextDigestAt(since)) {
+			continue
+		}
+
+		ok, err := d.send(pref, since)
+		if err != nil {
+			log.Printf("Failed to send digest to user %d: %v", pref.UserID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+
+		// Advance even when there was nothing to send so the next digest starts here
+		d.db.Model(pref).Update("last_digest_at", now)
+	}
+	return sent
+}
+
+// canSendEmail reports whether any company's email configuration can send;
+// each digest is then sent with its recipient's company configuration
+func (d *DigestScheduler) canSendEmail() bool {
+	var configs []models.EmailConfig
+	if err := d.db.Find(&configs).Error; err != nil {
+		return false
+	}
+	for i := range configs {
+		if configs[i].CanSendEmail() {
+			return true
+		}
+	}
+	return false
+}
+
+// send builds and emails one user's digest, returning false if it was empty
+func (d *DigestScheduler) send(pref *models.NotificationPreference, since time.Time) (bool, error) {
+	var user models.User
+	if err := d.db.Where("id = ? AND status = ?", pref.UserID, models.UserStatusApproved).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	digest, err := BuildDigest(d.db, &user, pref.DigestFrequency, since)
+	if err != nil {
+		return false, err
+	}
+	if digest.IsEmpty() {
+		return false, nil
+	}
+	return true, d.emailSvc.SendDigestEmail(&user, digest)
+}
+
+// BuildDigest collects a user's unread notifications, the requests waiting
+// for their approval and status changes to their own requests since a time
+func BuildDigest(db *gorm.DB, user *models.User, frequency models.DigestFrequency, since time.Time) (*email.Digest, error) {
+	digest := &email.Digest{Frequency: frequency}
+
+	unread := db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND created_at > ?", user.ID, since).
+		Session(&gorm.Session{})
+	if err := unread.Count(&digest.TotalNotifications).Error; err != nil {
+		return nil, err
+	}
+	if err := unread.Order("created_at DESC").Limit(digestSectionLimit).
+		Find(&digest.Notifications).Error; err != nil {
+		return nil, err
+	}
+
+	// Pending approvals are listed in every digest while they wait, not just new ones
+	if models.RolePermissions(db, user.Role).Has(models.PermApprovalsView) {
+		pending := db.Model(&models.PurchaseRequest{}).
+			Where("company_code = ? AND status = ?", models.UserCompanyCode(db, user), models.StatusPending).
+			Session(&gorm.Session{})
+		if err := pending.Count(&digest.TotalPending).Error; err != nil {
+			return nil, err
+		}
+		if err := pending.Preload("Requester").
+			Order("CASE WHEN urgency = 'urgent' THEN 0 ELSE 1 END, created_at ASC").
+			Limit(digestSectionLimit).
+			Find(&digest.PendingApprovals).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Status changes made by someone else to the user's requests
+	changes := db.Model(&models.RequestHistory{}).
+		Joins("JOIN purchase_requests ON purchase_requests.id = request_histories.request_id AND purchase_requests.deleted_at IS NULL").
+		Where("purchase_requests.requester_id = ? AND request_histories.user_id <> ?", user.ID, user.ID).
+		Where("request_histories.new_status <> '' AND request_histories.created_at > ?", since).
+		Session(&gorm.Session{})
+	if err := changes.Count(&digest.TotalStatusChanges).Error; err != nil {
+		return nil, err
+	}
+	var history []models.RequestHistory
+	if err := changes.Order("request_histories.created_at DESC").Limit(digestSectionLimit).
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	if len(history) > 0 {
+		ids := make([]uint, 0, len(history))
+		for _, h := range history {
+			ids = append(ids, h.RequestID)
+		}
+		var requests []models.PurchaseRequest
+		if err := db.Where("id IN ?", ids).Find(&requests).Error; err != nil {
+			return nil, err
+		}
+		byID := make(map[uint]models.PurchaseRequest, len(requests))
+		for _, r := range requests {
+			byID[r.ID] = r
+		}
+		for _, h := range history {
+			if request, ok := byID[h.RequestID]; ok {
+				digest.StatusChanges = append(digest.StatusChanges, email.DigestStatusChange{
+					Request:   request,
+					Status:    h.NewStatus,
+					ChangedAt: h.CreatedAt,
+				})
+			}
+		}
+	}
+
+	return digest, nil
+}
@@ -3,9 +3,11 @@ package notifications
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 	"vista-backend/internal/models"
+	"vista-backend/internal/services/chat"
 	"vista-backend/internal/services/email"
 )
 
@@ -25,9 +27,9 @@ func NewNotificationService(db *gorm.DB) *NotificationService {
 
 // NotifyRequestCreated sends notification to approvers when a new request is created
 func (s *NotificationService) NotifyRequestCreated(request *models.PurchaseRequest) error {
-	// Find all general managers to notify
-	var approvers []models.User
-	if err := s.db.Where("role = ? AND status = ?", models.RoleGeneralManager, models.UserStatusApproved).Find(&approvers).Error; err != nil {
+	// Find everyone who can approve it to notify
+	approvers, err := s.usersWithPermission(request.CompanyCode, models.PermApprovalsDecide)
+	if err != nil {
 		return err
 	}
 
@@ -45,27 +47,26 @@ func (s *NotificationService) NotifyRequestCreated(request *models.PurchaseReque
 		notificationType = models.NotificationUrgentRequest
 	}
 
-	for _, approver := range approvers {
+	actionURL := fmt.Sprintf("/approvals?id=%d", request.ID)
+	p := s.loadPolicy(request.CompanyCode)
+	for i := range approvers {
+		approver := &approvers[i]
 		notification := models.NewNotification(
 			approver.ID,
 			notificationType,
 			title,
 			message,
 		).WithReference("purchase_request", request.ID).
-			WithActionURL(fmt.Sprintf("/approvals?id=%d", request.ID))
+			WithActionURL(actionURL)
 
-		if err := s.db.Create(notification).Error; err != nil {
+		if err := s.deliver(p, approver, notification, func(u *models.User, opts email.SendOptions) error {
+			return s.emailSvc.SendNewRequestEmail(u, request, isUrgent, opts)
+		}); err != nil {
 			return err
 		}
 	}
 
-	// Send email notifications (async)
-	go func() {
-		if err := s.emailSvc.SendNewRequestEmail(approvers, request, isUrgent); err != nil {
-			log.Printf("Failed to send new request email: %v", err)
-		}
-	}()
-
+	s.postToChat(p, notificationType, actionURL, request.ID, approvers)
 	return nil
 }
 
@@ -87,21 +88,9 @@ func (s *NotificationService) NotifyRequestApproved(request *models.PurchaseRequ
 	).WithReference("purchase_request", request.ID).
 		WithActionURL(fmt.Sprintf("/requests?id=%d", request.ID))
 
-	if err := s.db.Create(notification).Error; err != nil {
-		return err
-	}
-
-	// Send email notification (async)
-	go func() {
-		var user models.User
-		if err := s.db.First(&user, request.RequesterID).Error; err == nil {
-			if err := s.emailSvc.SendRequestApprovedEmail(&user, request); err != nil {
-				log.Printf("Failed to send approval email: %v", err)
-			}
-		}
-	}()
-
-	return nil
+	return s.notifyRequester(request, notification, func(u *models.User, opts email.SendOptions) error {
+		return s.emailSvc.SendRequestApprovedEmail(u, request, opts)
+	})
 }
 
 // NotifyRequestRejected sends notification to requester when their request is rejected
@@ -117,21 +106,9 @@ func (s *NotificationService) NotifyRequestRejected(request *models.PurchaseRequ
 	).WithReference("purchase_request", request.ID).
 		WithActionURL(fmt.Sprintf("/requests?id=%d", request.ID))
 
-	if err := s.db.Create(notification).Error; err != nil {
-		return err
-	}
-
-	// Send email notification (async)
-	go func() {
-		var user models.User
-		if err := s.db.First(&user, request.RequesterID).Error; err == nil {
-			if err := s.emailSvc.SendRequestRejectedEmail(&user, request, reason); err != nil {
-				log.Printf("Failed to send rejection email: %v", err)
-			}
-		}
-	}()
-
-	return nil
+	return s.notifyRequester(request, notification, func(u *models.User, opts email.SendOptions) error {
+		return s.emailSvc.SendRequestRejectedEmail(u, request, reason, opts)
+	})
 }
 
 // NotifyRequestInfoRequired sends notification to requester when more info is needed
@@ -147,21 +124,45 @@ func (s *NotificationService) NotifyRequestInfoRequired(request *models.Purchase
 	).WithReference("purchase_request", request.ID).
 		WithActionURL(fmt.Sprintf("/requests?id=%d", request.ID))
 
-	if err := s.db.Create(notification).Error; err != nil {
+	return s.notifyRequester(request, notification, func(u *models.User, opts email.SendOptions) error {
+		return s.emailSvc.SendRequestInfoRequiredEmail(u, request, note, opts)
+	})
+}
+
+// NotifyRequestInfoProvided sends notification to the approver who asked for
+// more information when the requester answers
+func (s *NotificationService) NotifyRequestInfoProvided(request *models.PurchaseRequest) error {
+	approverID := request.InfoRequestedByID
+	if approverID == nil {
+		// Requests returned before the approver was recorded: use the history
+		var history models.RequestHistory
+		if err := s.db.Where("request_id = ? AND action = ? AND new_status = ?",
+			request.ID, models.ActionReturned, models.StatusInfoRequested).
+			Order("created_at DESC, id DESC").First(&history).Error; err != nil {
+			return err
+		}
+		approverID = &history.UserID
+	}
+
+	var approver models.User
+	if err := s.db.First(&approver, *approverID).Error; err != nil {
 		return err
 	}
 
-	// Send email notification (async)
-	go func() {
-		var user models.User
-		if err := s.db.First(&user, request.RequesterID).Error; err == nil {
-			if err := s.emailSvc.SendRequestInfoRequiredEmail(&user, request, note); err != nil {
-				log.Printf("Failed to send info required email: %v", err)
-			}
-		}
-	}()
+	title := fmt.Sprintf("%s answered your question on #%s", request.Requester.Name, request.RequestNumber)
+	message := fmt.Sprintf("Response: %s", request.InfoResponse)
 
-	return nil
+	notification := models.NewNotification(
+		approver.ID,
+		models.NotificationRequestInfoProvided,
+		title,
+		message,
+	).WithReference("purchase_request", request.ID).
+		WithActionURL(fmt.Sprintf("/approvals?id=%d", request.ID))
+
+	return s.deliver(s.loadPolicy(request.CompanyCode), &approver, notification, func(u *models.User, opts email.SendOptions) error {
+		return s.emailSvc.SendInfoResponseEmail(u, request, opts)
+	})
 }
 
 // NotifyRequestPurchased sends notification to requester when their order is purchased
@@ -182,29 +183,16 @@ func (s *NotificationService) NotifyRequestPurchased(request *models.PurchaseReq
 	).WithReference("purchase_request", request.ID).
 		WithActionURL(fmt.Sprintf("/requests?id=%d", request.ID))
 
-	if err := s.db.Create(notification).Error; err != nil {
-		return err
-	}
-
-	// Send email notification (async)
-	go func() {
-		var user models.User
-		if err := s.db.First(&user, request.RequesterID).Error; err == nil {
-			if err := s.emailSvc.SendOrderPurchasedEmail(&user, request); err != nil {
-				log.Printf("Failed to send purchased email: %v", err)
-			}
-		}
-	}()
-
-	return nil
+	return s.notifyRequester(request, notification, func(u *models.User, opts email.SendOptions) error {
+		return s.emailSvc.SendOrderPurchasedEmail(u, request, opts)
+	})
 }
 
 // NotifyNewApprovedOrder sends notification to purchase admins when a new order is approved
 func (s *NotificationService) NotifyNewApprovedOrder(request *models.PurchaseRequest) error {
-	// Find all purchase admins to notify
-	var admins []models.User
-	if err := s.db.Where("role IN ? AND status = ?",
-		[]models.UserRole{models.RoleAdmin, models.RolePurchaseAdmin}, models.UserStatusApproved).Find(&admins).Error; err != nil {
+	// Find everyone who purchases orders to notify
+	admins, err := s.usersWithPermission(request.CompanyCode, models.PermOrdersManage)
+	if err != nil {
 		return err
 	}
 
@@ -217,23 +205,106 @@ func (s *NotificationService) NotifyNewApprovedOrder(request *models.PurchaseReq
 	message := fmt.Sprintf("Order from %s ready to purchase. Total: $%.2f MXN",
 		request.Requester.Name, s.getTotalEstimated(request))
 
-	for _, admin := range admins {
+	actionURL := fmt.Sprintf("/admin/orders?id=%d", request.ID)
+	p := s.loadPolicy(request.CompanyCode)
+	for i := range admins {
 		notification := models.NewNotification(
-			admin.ID,
+			admins[i].ID,
 			models.NotificationNewApprovedOrder,
 			title,
 			message,
 		).WithReference("purchase_request", request.ID).
-			WithActionURL(fmt.Sprintf("/admin/orders?id=%d", request.ID))
+			WithActionURL(actionURL)
+
+		// There is no email version of this notification
+		if err := s.deliver(p, &admins[i], notification, nil); err != nil {
+			return err
+		}
+	}
+
+	s.postToChat(p, models.NotificationNewApprovedOrder, actionURL, request.ID, admins)
+	return nil
+}
+
+// notifyRequester delivers a notification to the request's requester and
+// posts it to the chat channels whose audience includes the requester
+func (s *NotificationService) notifyRequester(request *models.PurchaseRequest, notification *models.Notification, sendEmail emailSender) error {
+	var user models.User
+	if err := s.db.First(&user, request.RequesterID).Error; err != nil {
+		return err
+	}
+	p := s.loadPolicy(request.CompanyCode)
+	if err := s.deliver(p, &user, notification, sendEmail); err != nil {
+		return err
+	}
+	s.postToChat(p, notification.Type, notification.ActionURL, request.ID, []models.User{user})
+	return nil
+}
+
+// postToChat queues chat cards for a notification sent to recipients. Chat
+// channels follow global policy only; personal preferences don't apply.
+func (s *NotificationService) postToChat(p policy, t models.NotificationType, actionURL string, requestID uint, recipients []models.User) {
+	if !p.allows(t) {
+		return
+	}
+	if err := chat.Queue(s.db, t, actionURL, requestID, recipients); err != nil {
+		log.Printf("Failed to queue %s chat messages for request %d: %v", t, requestID, err)
+	}
+}
 
+// emailSender queues the email version of a notification for one recipient
+type emailSender func(user *models.User, opts email.SendOptions) error
+
+// deliver creates the in-app notification and queues the email for one
+// recipient, as allowed by global policy and the recipient's preferences.
+// Emails held by quiet hours are queued to be sent when the quiet hours end.
+// Emails batched into a digest are kept as notifications for the digest to
+// collect, hidden from the in-app list if the user doesn't want them there.
+func (s *NotificationService) deliver(p policy, user *models.User, notification *models.Notification, sendEmail emailSender) error {
+	now := time.Now()
+	d := s.resolve(p, user.ID, notification.Type, now)
+
+	opts := email.SendOptions{}
+	if d.InApp || d.Digest {
+		notification.DigestOnly = !d.InApp
 		if err := s.db.Create(notification).Error; err != nil {
 			return err
 		}
+		opts.NotificationID = &notification.ID
+		if d.InApp {
+			s.publishNotification(user, notification)
+		}
+	}
+
+	// Service accounts have no mailbox
+	if d.Email && sendEmail != nil && !user.ServiceAccount {
+		if d.EmailAt.After(now) {
+			log.Printf("Holding %s email to user %d until quiet hours end at %s", notification.Type, user.ID, d.EmailAt.Format(time.RFC3339))
+			opts.SendAt = d.EmailAt
+		}
+		if err := sendEmail(user, opts); err != nil {
+			log.Printf("Failed to queue %s email to user %d: %v", notification.Type, user.ID, err)
+		}
 	}
 
 	return nil
 }
 
+// usersWithPermission returns the company's active users, not service
+// accounts, whose role grants the permission
+func (s *NotificationService) usersWithPermission(company string, permission models.Permission) ([]models.User, error) {
+	roles, err := models.RolesWithPermission(s.db, permission)
+	if err != nil || len(roles) == 0 {
+		return nil, err
+	}
+	var users []models.User
+	if err := models.CompanyMembers(s.db, company).Where("role IN ? AND status = ? AND service_account = ?", roles, models.UserStatusApproved, false).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // getTotalEstimated returns the total estimated price for a request
 func (s *NotificationService) getTotalEstimated(request *models.PurchaseRequest) float64 {
 	if request.TotalEstimated != nil {
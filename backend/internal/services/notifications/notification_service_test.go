@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/email"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.PurchaseRequest{},
+		&models.RequestHistory{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// emailPolicy is a company policy that sends every notification by email
+func emailPolicy() policy {
+	purchase := models.GetDefaultPurchaseConfig()
+	config := models.GetDefaultEmailConfig()
+	config.Enabled = true
+	config.FromEmail = "vista@example.com"
+	config.APIKey = "key"
+	return policy{purchase: &purchase, email: &config}
+}
+
+// deliverTo creates a user with the given approval channel and digest
+// frequency and delivers one approval notification to them, returning the
+// number of emails queued
+func deliverTo(t *testing.T, db *gorm.DB, channel models.NotificationChannel, frequency models.DigestFrequency) (*models.User, int) {
+	t.Helper()
+	user := &models.User{Email: string(channel) + "@example.com", Name: "Requester", Role: "employee", Status: models.UserStatusApproved}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	pref := &models.NotificationPreference{
+		UserID:          user.ID,
+		Channels:        models.NotificationChannels{models.NotificationRequestApproved: channel},
+		DigestFrequency: frequency,
+	}
+	if err := db.Create(pref).Error; err != nil {
+		t.Fatalf("create preference: %v", err)
+	}
+
+	s := &NotificationService{db: db}
+	emails := 0
+	notification := models.NewNotification(user.ID, models.NotificationRequestApproved, "Order #PO-2026-0001 approved", "Approved")
+	if err := s.deliver(emailPolicy(), user, notification, func(*models.User, email.SendOptions) error {
+		emails++
+		return nil
+	}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	return user, emails
+}
+
+func TestDeliverEmailOnlyWithDigestKeepsNotificationForDigest(t *testing.T) {
+	db := newTestDB(t)
+	since := time.Now().Add(-time.Minute)
+
+	user, emails := deliverTo(t, db, models.ChannelEmail, models.DigestDaily)
+	if emails != 0 {
+		t.Errorf("queued %d emails, want 0 while digests are on", emails)
+	}
+
+	var inApp int64
+	db.Model(&models.Notification{}).Scopes(models.InAppNotifications).Where("user_id = ?", user.ID).Count(&inApp)
+	if inApp != 0 {
+		t.Errorf("in-app list has %d notifications, want 0 for the email channel", inApp)
+	}
+
+	digest, err := BuildDigest(db, user, models.DigestDaily, since)
+	if err != nil {
+		t.Fatalf("BuildDigest: %v", err)
+	}
+	if digest.TotalNotifications != 1 || len(digest.Notifications) != 1 {
+		t.Fatalf("digest has %d notifications, want 1", digest.TotalNotifications)
+	}
+	if got := digest.Notifications[0].Type; got != models.NotificationRequestApproved {
+		t.Errorf("digest notification type = %s, want %s", got, models.NotificationRequestApproved)
+	}
+}
+
+func TestDeliverBothWithDigestShowsNotificationInApp(t *testing.T) {
+	db := newTestDB(t)
+	since := time.Now().Add(-time.Minute)
+
+	user, emails := deliverTo(t, db, models.ChannelBoth, models.DigestWeekly)
+	if emails != 0 {
+		t.Errorf("queued %d emails, want 0 while digests are on", emails)
+	}
+
+	var inApp int64
+	db.Model(&models.Notification{}).Scopes(models.InAppNotifications).Where("user_id = ?", user.ID).Count(&inApp)
+	if inApp != 1 {
+		t.Errorf("in-app list has %d notifications, want 1", inApp)
+	}
+
+	digest, err := BuildDigest(db, user, models.DigestWeekly, since)
+	if err != nil {
+		t.Fatalf("BuildDigest: %v", err)
+	}
+	if digest.TotalNotifications != 1 {
+		t.Errorf("digest has %d notifications, want 1", digest.TotalNotifications)
+	}
+}
+
+func TestDeliverEmailOnlyWithoutDigestSendsEmail(t *testing.T) {
+	db := newTestDB(t)
+
+	user, emails := deliverTo(t, db, models.ChannelEmail, models.DigestOff)
+	if emails != 1 {
+		t.Errorf("queued %d emails, want 1", emails)
+	}
+
+	var stored int64
+	db.Model(&models.Notification{}).Where("user_id = ?", user.ID).Count(&stored)
+	if stored != 0 {
+		t.Errorf("stored %d notifications, want 0 for the email channel without digests", stored)
+	}
+}
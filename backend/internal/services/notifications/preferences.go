@@ -0,0 +1,159 @@
+package notifications
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// Delivery is the resolved outcome for one recipient of a notification
+type Delivery struct {
+	InApp bool
+	Email bool
+	// Digest is set when the email was replaced by the user's digest
+	Digest bool
+	// EmailAt is when the email may be sent; later than now during quiet hours
+	EmailAt time.Time
+}
+
+// policy holds a company's notification settings, loaded once per notification
+type policy struct {
+	purchase *models.PurchaseConfig
+	email    *models.EmailConfig
+}
+
+// loadPolicy reads the company's purchase and email configuration. Missing
+// configuration falls back to the defaults (everything on) and to the default
+// company's email configuration.
+func (s *NotificationService) loadPolicy(company string) policy {
+	purchase := models.FindPurchaseConfig(s.db, company)
+	p := policy{purchase: &purchase}
+
+	if email, err := models.FindEmailConfig(s.db, company); err == nil {
+		p.email = email
+	}
+	return p
+}
+
+// allows reports whether PurchaseConfig enables a notification type at all
+func (p policy) allows(t models.NotificationType) bool {
+	c := p.purchase
+	switch t {
+	case models.NotificationRequestApproved:
+		return c.NotifyRequesterApproved
+	case models.NotificationRequestRejected:
+		return c.NotifyRequesterRejected
+	case models.NotificationRequestInfoRequired:
+		return c.NotifyRequesterInfoRequested
+	case models.NotificationRequestPurchased:
+		return c.NotifyRequesterPurchased
+	case models.NotificationNewPendingRequest:
+		return c.NotifyApproverNewRequest
+	case models.NotificationUrgentRequest:
+		return c.NotifyApproverUrgent
+	case models.NotificationNewApprovedOrder:
+		return c.NotifyAdminNewApproved
+	}
+	return true
+}
+
+// allowsEmail reports whether EmailConfig is set up and sends email for a type
+func (p policy) allowsEmail(t models.NotificationType) bool {
+	c := p.email
+	if c == nil || !c.CanSendEmail() {
+		return false
+	}
+	switch t {
+	case models.NotificationRequestApproved:
+		return c.SendOnApproval
+	case models.NotificationRequestRejected:
+		return c.SendOnRejection
+	case models.NotificationRequestInfoRequired, models.NotificationRequestInfoProvided:
+		return c.SendOnInfoRequest
+	case models.NotificationRequestPurchased:
+		return c.SendOnPurchased
+	case models.NotificationNewPendingRequest:
+		return c.SendOnNewRequest
+	case models.NotificationUrgentRequest:
+		return c.SendOnUrgent
+	case models.NotificationReminderPending, models.NotificationReminderUnpurchased:
+		return c.SendReminders
+	}
+	return false
+}
+
+// HasEmailTemplate returns true if a notification type has an email version
+func HasEmailTemplate(t models.NotificationType) bool {
+	switch t {
+	case models.NotificationRequestApproved, models.NotificationRequestRejected,
+		models.NotificationRequestInfoRequired, models.NotificationRequestInfoProvided,
+		models.NotificationRequestPurchased,
+		models.NotificationNewPendingRequest, models.NotificationUrgentRequest:
+		return true
+	}
+	return false
+}
+
+// quietHoursExempt lists types that are emailed immediately even during quiet
+// hours or when the user receives digests
+var quietHoursExempt = map[models.NotificationType]bool{
+	models.NotificationUrgentRequest: true,
+}
+
+// GetPreference returns a user's notification preferences, or nil if they kept the defaults
+func GetPreference(db *gorm.DB, userID uint) *models.NotificationPreference {
+	var pref models.NotificationPreference
+	if err := db.Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		return nil
+	}
+	return &pref
+}
+
+// Availability describes what global policy allows for a notification type
+type Availability struct {
+	Enabled bool `json:"enabled"` // PurchaseConfig sends this notification at all
+	Email   bool `json:"email"`   // It can be emailed (template exists and EmailConfig allows it)
+}
+
+// Availability returns what the company's policy allows for each configurable
+// type, so users can see which of their preferences currently take effect
+func (s *NotificationService) Availability(company string) map[models.NotificationType]Availability {
+	p := s.loadPolicy(company)
+	result := make(map[models.NotificationType]Availability, len(models.ConfigurableNotificationTypes))
+	for _, t := range models.ConfigurableNotificationTypes {
+		enabled := p.allows(t)
+		result[t] = Availability{
+			Enabled: enabled,
+			Email:   enabled && HasEmailTemplate(t) && p.allowsEmail(t),
+		}
+	}
+	return result
+}
+
+// resolve combines global policy with a user's preferences for one notification
+func (s *NotificationService) resolve(p policy, userID uint, t models.NotificationType, now time.Time) Delivery {
+	if !p.allows(t) {
+		return Delivery{}
+	}
+
+	pref := GetPreference(s.db, userID)
+	channel := pref.ChannelFor(t)
+
+	d := Delivery{
+		InApp:   channel.InApp(),
+		Email:   channel.Email() && HasEmailTemplate(t) && p.allowsEmail(t),
+		EmailAt: now,
+	}
+	if d.Email && !quietHoursExempt[t] {
+		if pref.DigestEnabled() {
+			d.Email = false
+			d.Digest = true
+			return d
+		}
+		if until := pref.QuietUntil(now); !until.IsZero() {
+			d.EmailAt = until
+		}
+	}
+	return d
+}
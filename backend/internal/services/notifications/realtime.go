@@ -0,0 +1,138 @@
+package notifications
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/realtime"
+)
+
+// Counts holds the badge counts shown in the sidebar
+type Counts struct {
+	UnreadNotifications int64 `json:"unread_notifications"`
+	PendingApprovals    int64 `json:"pending_approvals"`
+	PendingOrders       int64 `json:"pending_orders"`
+}
+
+// GetCounts returns a user's badge counts. Approval and order counts are only
+// filled in for users whose permissions let them see those queues, and only
+// count the company's requests.
+func GetCounts(db *gorm.DB, userID uint, company string, permissions models.PermissionSet) Counts {
+	var counts Counts
+
+	db.Model(&models.Notification{}).Scopes(models.InAppNotifications).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&counts.UnreadNotifications)
+
+	// Pending approvals count
+	if permissions.Has(models.PermApprovalsView) {
+		db.Model(&models.PurchaseRequest{}).
+			Where("company_code = ? AND status = ?", company, models.StatusPending).
+			Count(&counts.PendingApprovals)
+	}
+
+	// Pending orders count
+	if permissions.Has(models.PermOrdersView) {
+		db.Model(&models.PurchaseRequest{}).
+			Where("company_code = ? AND status = ?", company, models.StatusApproved).
+			Count(&counts.PendingOrders)
+	}
+
+	return counts
+}
+
+// NotificationEvent is the payload of a realtime "notification" event
+type NotificationEvent struct {
+	ID            uint      `json:"id"`
+	Type          string    `json:"type"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   uint      `json:"reference_id,omitempty"`
+	ActionURL     string    `json:"action_url,omitempty"`
+	IsRead        bool      `json:"is_read"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RequestStatusEvent is the payload of a realtime "request_status" event
+type RequestStatusEvent struct {
+	RequestID      uint                 `json:"request_id"`
+	RequestNumber  string               `json:"request_number"`
+	Status         models.RequestStatus `json:"status"`
+	PreviousStatus models.RequestStatus `json:"previous_status"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// PublishCounts pushes a user's current badge counts to their open streams
+func PublishCounts(db *gorm.DB, userID uint) {
+	var user models.User
+	if err := db.Select("id", "role", "company_code").First(&user, userID).Error; err != nil {
+		return
+	}
+	realtime.Publish(user.ID, realtime.EventCounts,
+		GetCounts(db, user.ID, models.UserCompanyCode(db, &user), models.RolePermissions(db, user.Role)))
+}
+
+// publishNotification pushes a newly created in-app notification and the
+// recipient's updated counts
+func (s *NotificationService) publishNotification(user *models.User, n *models.Notification) {
+	realtime.Publish(user.ID, realtime.EventNotification, NotificationEvent{
+		ID:            n.ID,
+		Type:          string(n.Type),
+		Title:         n.Title,
+		Message:       n.Message,
+		ReferenceType: n.ReferenceType,
+		ReferenceID:   n.ReferenceID,
+		ActionURL:     n.ActionURL,
+		IsRead:        n.IsRead(),
+		CreatedAt:     n.CreatedAt,
+	})
+	realtime.Publish(user.ID, realtime.EventCounts,
+		GetCounts(s.db, user.ID, models.UserCompanyCode(s.db, user), models.RolePermissions(s.db, user.Role)))
+}
+
+// PublishStatusChange pushes a request's status transition to its requester
+// and refreshes the queue counts of approvers and purchasing staff. Call it
+// after the change is committed.
+func (s *NotificationService) PublishStatusChange(request *models.PurchaseRequest, previous models.RequestStatus) {
+	if request.Status == previous {
+		return
+	}
+
+	event := RequestStatusEvent{
+		RequestID:      request.ID,
+		RequestNumber:  request.RequestNumber,
+		Status:         request.Status,
+		PreviousStatus: previous,
+		UpdatedAt:      request.UpdatedAt,
+	}
+
+	// Staff are the users in the request's company whose badge counts depend
+	// on request statuses
+	var staff []models.User
+	queueRoles, err := models.RolesWithPermission(s.db, models.PermApprovalsView, models.PermOrdersView)
+	if err == nil && len(queueRoles) > 0 {
+		if err := models.CompanyMembers(s.db, request.CompanyCode).Select("id", "role").
+			Where("role IN ? AND status = ?", queueRoles, models.UserStatusApproved).
+			Find(&staff).Error; err != nil {
+			staff = nil
+		}
+	}
+	permissions := map[models.UserRole]models.PermissionSet{}
+
+	requesterNotified := false
+	for _, user := range staff {
+		if user.ID == request.RequesterID {
+			requesterNotified = true
+			realtime.Publish(user.ID, realtime.EventRequestStatus, event)
+		}
+		if _, ok := permissions[user.Role]; !ok {
+			permissions[user.Role] = models.RolePermissions(s.db, user.Role)
+		}
+		realtime.Publish(user.ID, realtime.EventCounts, GetCounts(s.db, user.ID, request.CompanyCode, permissions[user.Role]))
+	}
+	if !requesterNotified {
+		realtime.Publish(request.RequesterID, realtime.EventRequestStatus, event)
+	}
+}
@@ -0,0 +1,165 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// ReminderScheduler reminds approvers of requests pending too long and
+// purchasers of approved orders not purchased in time, using the thresholds
+// in each company's PurchaseConfig. Overdue requests are reminded again every
+// threshold.
+type ReminderScheduler struct {
+	db              *gorm.DB
+	notificationSvc *NotificationService
+	pollInterval    time.Duration
+	stop            chan struct{}
+	wg              sync.WaitGroup
+}
+
+// NewReminderScheduler creates a new reminder scheduler
+func NewReminderScheduler(db *gorm.DB) *ReminderScheduler {
+	return &ReminderScheduler{
+		db:              db,
+		notificationSvc: NewNotificationService(db),
+		pollInterval:    10 * time.Minute,
+		stop:            make(chan struct{}),
+	}
+}
+
+// Start begins checking for overdue requests
+func (r *ReminderScheduler) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			r.RunOnce(time.Now())
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Println("Reminder scheduler started")
+}
+
+// Stop signals the scheduler to exit and waits for the current run to finish
+func (r *ReminderScheduler) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// RunOnce sends every reminder due at now and returns how many requests were reminded
+func (r *ReminderScheduler) RunOnce(now time.Time) int {
+	var companies []string
+	if err := r.db.Model(&models.Company{}).Pluck("code", &companies).Error; err != nil {
+		log.Printf("Failed to fetch companies for reminders: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for _, company := range companies {
+		config := models.FindPurchaseConfig(r.db, company)
+		if hours := config.ReminderPendingHours; hours > 0 {
+			cutoff := now.Add(-time.Duration(hours) * time.Hour)
+			sent += r.remind("company_code = ? AND status = ? AND created_at <= ?",
+				[]interface{}{company, models.StatusPending, cutoff}, cutoff, now,
+				r.notificationSvc.NotifyReminderPending)
+		}
+		if hours := config.ReminderUnpurchasedHours; hours > 0 {
+			cutoff := now.Add(-time.Duration(hours) * time.Hour)
+			sent += r.remind("company_code = ? AND status = ? AND approved_at <= ?",
+				[]interface{}{company, models.StatusApproved, cutoff}, cutoff, now,
+				r.notificationSvc.NotifyReminderUnpurchased)
+		}
+	}
+	return sent
+}
+
+// remind notifies about the requests matching query that weren't reminded since cutoff
+func (r *ReminderScheduler) remind(query string, args []interface{}, cutoff, now time.Time, notify func(*models.PurchaseRequest) error) int {
+	var requests []models.PurchaseRequest
+	if err := r.db.Preload("Requester").
+		Where(query, args...).
+		Where("reminder_sent_at IS NULL OR reminder_sent_at <= ?", cutoff).
+		Find(&requests).Error; err != nil {
+		log.Printf("Failed to fetch overdue requests: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range requests {
+		request := &requests[i]
+		if err := notify(request); err != nil {
+			log.Printf("Failed to send reminder for request %d: %v", request.ID, err)
+			continue
+		}
+		r.db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).UpdateColumn("reminder_sent_at", now)
+		sent++
+	}
+	return sent
+}
+
+// NotifyReminderPending reminds approvers of a request waiting for approval
+func (s *NotificationService) NotifyReminderPending(request *models.PurchaseRequest) error {
+	approvers, err := s.usersWithPermission(request.CompanyCode, models.PermApprovalsDecide)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("Request #%s is waiting for approval", request.RequestNumber)
+	message := fmt.Sprintf("The request from %s has been pending for %d hours",
+		request.Requester.Name, int(time.Since(request.CreatedAt).Hours()))
+
+	return s.remind(models.NotificationReminderPending, title, message,
+		fmt.Sprintf("/approvals?id=%d", request.ID), request, approvers)
+}
+
+// NotifyReminderUnpurchased reminds purchase admins of an approved order not purchased yet
+func (s *NotificationService) NotifyReminderUnpurchased(request *models.PurchaseRequest) error {
+	admins, err := s.usersWithPermission(request.CompanyCode, models.PermOrdersManage)
+	if err != nil {
+		return err
+	}
+
+	orderNum := request.RequestNumber
+	if request.PONumber != nil && *request.PONumber != "" {
+		orderNum = *request.PONumber
+	}
+	approvedAt := request.UpdatedAt
+	if request.ApprovedAt != nil {
+		approvedAt = *request.ApprovedAt
+	}
+	title := fmt.Sprintf("Order #%s has not been purchased", orderNum)
+	message := fmt.Sprintf("Approved %d hours ago and still waiting to be purchased", int(time.Since(approvedAt).Hours()))
+
+	return s.remind(models.NotificationReminderUnpurchased, title, message,
+		fmt.Sprintf("/admin/orders?id=%d", request.ID), request, admins)
+}
+
+// remind delivers a reminder in-app to each recipient and posts it to chat.
+// Reminders have no email template.
+func (s *NotificationService) remind(t models.NotificationType, title, message, actionURL string, request *models.PurchaseRequest, recipients []models.User) error {
+	p := s.loadPolicy(request.CompanyCode)
+	for i := range recipients {
+		notification := models.NewNotification(recipients[i].ID, t, title, message).
+			WithReference("purchase_request", request.ID).
+			WithActionURL(actionURL)
+		if err := s.deliver(p, &recipients[i], notification, nil); err != nil {
+			return err
+		}
+	}
+
+	s.postToChat(p, t, actionURL, request.ID, recipients)
+	return nil
+}
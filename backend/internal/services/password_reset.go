@@ -0,0 +1,206 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/email"
+)
+
+const (
+	// passwordResetValidFor is how long a reset link works
+	passwordResetValidFor = time.Hour
+	// passwordResetInterval is the minimum time between two reset emails to one user
+	passwordResetInterval = time.Minute
+)
+
+// ErrInvalidResetToken is returned for unknown, used or expired reset tokens
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// PasswordResetService lets users who forgot their password set a new one
+// through a single-use link sent to their email address
+type PasswordResetService struct {
+	db       *gorm.DB
+	emailSvc *email.EmailService
+	appURL   string // Public frontend URL the reset link points to
+}
+
+func NewPasswordResetService(db *gorm.DB, emailSvc *email.EmailService, appURL string) *PasswordResetService {
+	return &PasswordResetService{
+		db:       db,
+		emailSvc: emailSvc,
+		appURL:   strings.TrimRight(appURL, "/"),
+	}
+}
+
+// RequestReset emails a reset link if emailAddress belongs to an active
+// account. It doesn't tell callers whether it did, so the response can't be
+// used to find out which addresses have accounts. Only the account lookup
+// and the rate limit happen before it returns; the link is created and
+// emailed in the background, so the response takes as long for unknown
+// addresses.
+func (s *PasswordResetService) RequestReset(emailAddress, ipAddress, userAgent string) {
+	var user models.User
+	if err := s.db.Where("email = ?", emailAddress).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to look up user for password reset: %v", err)
+		}
+		return
+	}
+	// Directory users change their password in the directory, and service
+	// accounts have none
+	if !user.CanLogin() || user.LDAPDN != "" || user.ServiceAccount {
+		return
+	}
+
+	sentAt, ok := s.reserveResetEmail(user.ID)
+	if !ok {
+		return
+	}
+	go s.sendResetLink(&user, sentAt, emailAddress, ipAddress, userAgent)
+}
+
+// reserveResetEmail claims the user's next reset email, so the endpoint
+// can't be used to flood someone's inbox. It returns false if a link was
+// sent less than passwordResetInterval ago. Concurrent requests can't both
+// claim it.
+func (s *PasswordResetService) reserveResetEmail(userID uint) (time.Time, bool) {
+	now := time.Now()
+	result := s.db.Model(&models.User{}).
+		Where("id = ? AND (password_reset_sent_at IS NULL OR password_reset_sent_at <= ?)",
+			userID, now.Add(-passwordResetInterval)).
+		UpdateColumn("password_reset_sent_at", now)
+	if result.Error != nil {
+		log.Printf("Failed to reserve password reset email for user %d: %v", userID, result.Error)
+		return time.Time{}, false
+	}
+	return now, result.RowsAffected == 1
+}
+
+// releaseResetEmail gives back a reservation whose email couldn't be sent,
+// so the user can ask again right away
+func (s *PasswordResetService) releaseResetEmail(userID uint, sentAt time.Time) {
+	s.db.Model(&models.User{}).
+		Where("id = ? AND password_reset_sent_at = ?", userID, sentAt).
+		UpdateColumn("password_reset_sent_at", nil)
+}
+
+// sendResetLink creates a reset token for the user and emails its link
+func (s *PasswordResetService) sendResetLink(user *models.User, sentAt time.Time, emailAddress, ipAddress, userAgent string) {
+	token, err := newResetToken()
+	if err != nil {
+		log.Printf("Failed to generate password reset token: %v", err)
+		s.releaseResetEmail(user.ID, sentAt)
+		return
+	}
+
+	reset := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: time.Now().Add(passwordResetValidFor),
+		IPAddress: ipAddress,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Only the latest link works
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&reset).Error
+	})
+	if err != nil {
+		log.Printf("Failed to create password reset token for user %d: %v", user.ID, err)
+		s.releaseResetEmail(user.ID, sentAt)
+		return
+	}
+
+	resetURL := s.appURL + "/reset-password?token=" + url.QueryEscape(token)
+	if err := s.emailSvc.SendPasswordResetEmail(user, resetURL, passwordResetValidFor); err != nil {
+		log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		s.db.Delete(&reset)
+		s.releaseResetEmail(user.ID, sentAt)
+		return
+	}
+
+	entry := models.NewActivityLog(models.ActivityPasswordReset, &user.ID, emailAddress, ipAddress, userAgent).
+		WithDetails("Reset link requested")
+	s.db.Create(entry)
+}
+
+// ResetPassword sets a new password using a reset token. The token can't be
+// used again, and every session of the user is ended.
+func (s *PasswordResetService) ResetPassword(token, newPassword, ipAddress, userAgent string) error {
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var reset models.PasswordResetToken
+		if err := tx.Where("token_hash = ?", hashResetToken(token)).First(&reset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		if !reset.IsUsable() {
+			return ErrInvalidResetToken
+		}
+
+		// Claim the token; a concurrent reset with the same token finds it used
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", reset.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+
+		if err := tx.First(&user, reset.UserID).Error; err != nil {
+			return err
+		}
+		if !user.CanLogin() {
+			return ErrInvalidResetToken
+		}
+
+		if err := tx.Model(&user).Update("password_hash", hashedPassword).Error; err != nil {
+			return err
+		}
+		return models.EndUserSessions(tx, user.ID, "Password reset")
+	})
+	if err != nil {
+		return err
+	}
+
+	entry := models.NewActivityLog(models.ActivityPasswordReset, &user.ID, user.Email, ipAddress, userAgent).
+		WithDetails("Password reset; all sessions ended")
+	s.db.Create(entry)
+	return nil
+}
+
+// newResetToken returns a random token for a reset link
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashResetToken returns the stored form of a reset token
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"vista-backend/internal/models"
+)
+
+func TestReserveResetEmailAllowsOnePerInterval(t *testing.T) {
+	db := newTestDB(t)
+	user := models.User{EmployeeNumber: "E1", Email: "ana@example.com", Name: "Ana", Status: models.UserStatusApproved}
+	db.Create(&user)
+	s := NewPasswordResetService(db, nil, "http://localhost:3000")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := s.reserveResetEmail(user.ID); ok {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if reserved != 1 {
+		t.Fatalf("%d concurrent requests reserved a reset email, want 1", reserved)
+	}
+
+	// Once the interval has passed, the next request gets the slot
+	db.Model(&user).UpdateColumn("password_reset_sent_at", time.Now().Add(-passwordResetInterval-time.Second))
+	if _, ok := s.reserveResetEmail(user.ID); !ok {
+		t.Error("request after the interval was refused")
+	}
+}
+
+func TestReleaseResetEmail(t *testing.T) {
+	db := newTestDB(t)
+	user := models.User{EmployeeNumber: "E1", Email: "ana@example.com", Name: "Ana", Status: models.UserStatusApproved}
+	db.Create(&user)
+	s := NewPasswordResetService(db, nil, "http://localhost:3000")
+
+	sentAt, ok := s.reserveResetEmail(user.ID)
+	if !ok {
+		t.Fatal("first request was refused")
+	}
+	s.releaseResetEmail(user.ID, sentAt)
+	if _, ok := s.reserveResetEmail(user.ID); !ok {
+		t.Error("request after a failed send was refused")
+	}
+}
@@ -0,0 +1,207 @@
+package realtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types pushed to clients
+const (
+	EventNotification  = "notification"   // A new in-app notification
+	EventCounts        = "counts"         // Unread/pending badge counts changed
+	EventRequestStatus = "request_status" // A purchase request changed status
+	EventReset         = "reset"          // Missed events can't be replayed; refetch state
+)
+
+// Event is a message for one user's stream
+type Event struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+
+	seq     uint64
+	created time.Time
+}
+
+// Subscription receives a user's events until Close is called. Events is
+// closed if the subscriber falls too far behind; the client should reconnect.
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+	userID uint
+	broker *Broker
+	once   sync.Once
+}
+
+// Close unsubscribes and releases the subscription
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// userLog keeps recent events for one user so reconnecting clients can catch up
+type userLog struct {
+	events  []Event
+	dropped uint64 // Highest sequence number no longer retained
+}
+
+// Broker fans events out to per-user subscribers in this process
+type Broker struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	subscribers map[uint]map[*Subscription]struct{}
+	logs        map[uint]*userLog
+
+	// Replay limits per user
+	historySize int
+	historyAge  time.Duration
+	bufferSize  int
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{
+		// IDs from a previous process can't be replayed; the epoch tells them apart
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: make(map[uint]map[*Subscription]struct{}),
+		logs:        make(map[uint]*userLog),
+		historySize: 100,
+		historyAge:  10 * time.Minute,
+		bufferSize:  32,
+	}
+}
+
+var defaultBroker = NewBroker()
+
+// Default returns the process-wide broker
+func Default() *Broker {
+	return defaultBroker
+}
+
+// Publish sends an event to a user on the default broker
+func Publish(userID uint, eventType string, data interface{}) {
+	defaultBroker.Publish(userID, eventType, data)
+}
+
+// Publish records an event for a user and delivers it to their open streams
+func (b *Broker) Publish(userID uint, eventType string, data interface{}) {
+	if userID == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		ID:      fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		Type:    eventType,
+		Data:    data,
+		seq:     b.seq,
+		created: time.Now(),
+	}
+
+	history := b.logs[userID]
+	if history == nil {
+		history = &userLog{}
+		b.logs[userID] = history
+	}
+	history.events = append(history.events, event)
+	b.trim(history, event.created)
+
+	for sub := range b.subscribers[userID] {
+		select {
+		case sub.events <- event:
+		default:
+			// Too slow to keep up; drop it and let the client replay on reconnect
+			b.remove(sub)
+		}
+	}
+}
+
+// trim drops events beyond the history limits
+func (b *Broker) trim(history *userLog, now time.Time) {
+	cut := 0
+	for cut < len(history.events) &&
+		(len(history.events)-cut > b.historySize || now.Sub(history.events[cut].created) > b.historyAge) {
+		cut++
+	}
+	if cut > 0 {
+		history.dropped = history.events[cut-1].seq
+		history.events = append([]Event(nil), history.events[cut:]...)
+	}
+}
+
+// Subscribe opens a stream for a user. If lastEventID is set, events after it
+// are returned for replay; if they can't all be replayed, a single reset event
+// is returned instead.
+func (b *Broker) Subscribe(userID uint, lastEventID string) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, b.bufferSize)
+	sub := &Subscription{Events: events, events: events, userID: userID, broker: b}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil
+	}
+	return sub, b.replay(userID, lastEventID)
+}
+
+// replay returns a user's events after lastEventID
+func (b *Broker) replay(userID uint, lastEventID string) []Event {
+	reset := []Event{{ID: fmt.Sprintf("%s-%d", b.epoch, b.seq), Type: EventReset, seq: b.seq}}
+
+	epoch, seqText, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != b.epoch {
+		return reset
+	}
+	last, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || last > b.seq {
+		return reset
+	}
+
+	history := b.logs[userID]
+	if history == nil {
+		return nil
+	}
+	b.trim(history, time.Now())
+	if last < history.dropped {
+		return reset
+	}
+
+	var missed []Event
+	for _, event := range history.events {
+		if event.seq > last {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+// unsubscribe removes a subscription
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// remove deletes a subscription and closes its channel; callers hold b.mu
+func (b *Broker) remove(sub *Subscription) {
+	sub.once.Do(func() {
+		if subs := b.subscribers[sub.userID]; subs != nil {
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(b.subscribers, sub.userID)
+			}
+		}
+		close(sub.events)
+	})
+}
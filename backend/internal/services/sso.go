@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/jwt"
+	"vista-backend/pkg/oidc"
+)
+
+// SSOLoginValidFor is how long a user has to log in at the identity provider
+const SSOLoginValidFor = 10 * time.Minute
+
+var (
+	ErrSSONotConfigured   = errors.New("single sign-on is not configured")
+	ErrInvalidSSOState    = errors.New("invalid or expired single sign-on state")
+	ErrSSOFailed          = errors.New("single sign-on failed")
+	ErrSSOAccountConflict = errors.New("account can't be linked to this single sign-on identity")
+)
+
+// SSOPolicy maps identity provider claims to users
+type SSOPolicy struct {
+	ProviderName        string // Shown on the login button
+	EmployeeNumberClaim string
+	DepartmentClaim     string
+	GroupsClaim         string
+	RoleGroups          []RoleGroup // Checked in order; the first group the user is in sets their role
+	AutoApprove         bool        // New users can log in right away instead of waiting for approval
+}
+
+// RoleGroup gives members of an identity provider group a role
+type RoleGroup struct {
+	Group string
+	Role  models.UserRole
+}
+
+// roleFor returns the role for a user in groups. The second result is false
+// if roles aren't managed by the identity provider.
+func (p SSOPolicy) roleFor(groups []string) (models.UserRole, bool) {
+	if len(p.RoleGroups) == 0 {
+		return "", false
+	}
+	for _, rg := range p.RoleGroups {
+		for _, group := range groups {
+			if group == rg.Group {
+				return rg.Role, true
+			}
+		}
+	}
+	return models.RoleEmployee, true
+}
+
+// SSOService logs users in through an OpenID Connect identity provider,
+// creating accounts for people who log in for the first time
+type SSOService struct {
+	db       *gorm.DB
+	auth     *AuthService
+	provider *oidc.Provider // nil if single sign-on isn't configured
+	policy   SSOPolicy
+}
+
+func NewSSOService(db *gorm.DB, auth *AuthService, provider *oidc.Provider, policy SSOPolicy) *SSOService {
+	return &SSOService{
+		db:       db,
+		auth:     auth,
+		provider: provider,
+		policy:   policy,
+	}
+}
+
+// Enabled returns true if single sign-on is configured
+func (s *SSOService) Enabled() bool {
+	return s.provider != nil
+}
+
+// ProviderName returns the name of the identity provider shown to users
+func (s *SSOService) ProviderName() string {
+	return s.policy.ProviderName
+}
+
+// StartLogin returns the identity provider URL to send the user to, and a
+// binding value for a cookie in the user's browser. The state, nonce and
+// PKCE verifier are kept until the user comes back, and the callback only
+// works in the browser that has the binding, so nobody can log a victim into
+// their own account by sending them a callback link.
+func (s *SSOService) StartLogin(ctx context.Context, ipAddress string) (string, string, error) {
+	if !s.Enabled() {
+		return "", "", ErrSSONotConfigured
+	}
+
+	var secrets [4]string
+	for i := range secrets {
+		value, err := oidc.RandomString()
+		if err != nil {
+			return "", "", err
+		}
+		secrets[i] = value
+	}
+	state, nonce, verifier, binding := secrets[0], secrets[1], secrets[2], secrets[3]
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+
+	login := models.SSOLogin{
+		StateHash:    hashSSOState(state),
+		BindingHash:  hashSSOState(binding),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(SSOLoginValidFor),
+		IPAddress:    ipAddress,
+	}
+	if err := s.db.Create(&login).Error; err != nil {
+		return "", "", err
+	}
+
+	// Forget logins that were never finished
+	if err := s.db.Where("expires_at < ?", time.Now().Add(-SSOLoginValidFor)).Delete(&models.SSOLogin{}).Error; err != nil {
+		log.Printf("Failed to delete expired SSO logins: %v", err)
+	}
+
+	return authURL, binding, nil
+}
+
+// CompleteLogin finishes a login when the identity provider sends the user
+// back with an authorization code and state, in the browser that has the
+// binding from StartLogin. The user's account is created or updated
+// from the ID token; new accounts wait for approval unless the policy
+// approves them. Like Login, it returns a *TwoFactorChallenge with the user
+// instead of starting a session if a second factor is needed.
+func (s *SSOService) CompleteLogin(ctx context.Context, code, state, binding, language, ipAddress, userAgent string) (*jwt.TokenPair, *models.User, error) {
+	if !s.Enabled() {
+		return nil, nil, ErrSSONotConfigured
+	}
+
+	login, err := s.claimLogin(state, binding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idToken, err := s.provider.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+
+	user, err := s.provision(idToken, language, ipAddress, userAgent)
+	if err != nil {
+		return nil, user, err
+	}
+
+	if err := checkUserStatus(user); err != nil {
+		return nil, user, err
+	}
+
+	challenge, err := s.auth.twoFactorChallenge(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return nil, user, challenge
+	}
+
+	tokens, err := s.auth.StartSession(user, user.Email, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
+
+// claimLogin marks the login started with state as used, so the callback
+// can't be replayed. The binding must be the one the login was started with.
+func (s *SSOService) claimLogin(state, binding string) (*models.SSOLogin, error) {
+	if state == "" || binding == "" {
+		return nil, ErrInvalidSSOState
+	}
+
+	var login models.SSOLogin
+	if err := s.db.Where("state_hash = ?", hashSSOState(state)).First(&login).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSSOState
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(login.BindingHash), []byte(hashSSOState(binding))) != 1 {
+		return nil, ErrInvalidSSOState
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.SSOLogin{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", login.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidSSOState
+	}
+	return &login, nil
+}
+
+// provision finds the user an ID token is for, linking an existing account
+// with the same email address on their first SSO login, or creates one. The
+// account is updated from the token's claims on every login.
+func (s *SSOService) provision(idToken *oidc.IDToken, language, ipAddress, userAgent string) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(idToken.String("email")))
+	if email == "" {
+		return nil, fmt.Errorf("%w: ID token has no email claim", ErrSSOFailed)
+	}
+	employeeNumber := strings.TrimSpace(idToken.String(s.policy.EmployeeNumberClaim))
+	department := strings.TrimSpace(idToken.String(s.policy.DepartmentClaim))
+	name := strings.TrimSpace(idToken.String("name"))
+	groups, hasGroups := idToken.Strings(s.policy.GroupsClaim)
+
+	var user models.User
+	err := s.db.Where("sso_subject = ?", idToken.Subject).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.db.Where("LOWER(email) = ?", email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.createUser(idToken.Subject, email, employeeNumber, name, department, groups, language, ipAddress, userAgent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Only take over an account whose address the provider says it
+		// has verified.
+		// The account is returned so the attempt is logged against it.
+		if user.SSOSubject != "" || !idToken.EmailVerified() {
+			return &user, ErrSSOAccountConflict
+		}
+		user.SSOSubject = idToken.Subject
+	} else if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		user.Name = name
+	}
+	if department != "" {
+		user.Department = department
+	}
+	if email != strings.ToLower(user.Email) && !s.taken("email", email, user.ID) {
+		user.Email = email
+	}
+	if employeeNumber != "" && employeeNumber != user.EmployeeNumber && !s.taken("employee_number", employeeNumber, user.ID) {
+		user.EmployeeNumber = employeeNumber
+	}
+	if hasGroups {
+		if role, ok := s.policy.roleFor(groups); ok {
+			user.Role = role
+		}
+	}
+
+	if err := s.db.Save(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// createUser provisions an account for someone logging in for the first time
+func (s *SSOService) createUser(subject, email, employeeNumber, name, department string, groups []string, language, ipAddress, userAgent string) (*models.User, error) {
+	if employeeNumber == "" {
+		return nil, fmt.Errorf("%w: ID token has no %s claim", ErrSSOFailed, s.policy.EmployeeNumberClaim)
+	}
+	if s.taken("employee_number", employeeNumber, 0) {
+		return nil, ErrEmployeeNumberExists
+	}
+	if name == "" {
+		name = email
+	}
+
+	user := models.User{
+		EmployeeNumber: employeeNumber,
+		Name:           name,
+		Email:          email,
+		Role:           models.RoleEmployee,
+		Department:     department,
+		Status:         models.UserStatusPending,
+		Language:       language,
+		SSOSubject:     subject,
+	}
+	if role, ok := s.policy.roleFor(groups); ok {
+		user.Role = role
+	}
+	details := "Registered through single sign-on"
+	if s.policy.AutoApprove {
+		now := time.Now()
+		user.Status = models.UserStatusApproved
+		user.ApprovedAt = &now
+		details = "Registered and approved through single sign-on"
+	}
+
+	// No password: the account logs in through the identity provider until
+	// the user sets one with a password reset
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	entry := models.NewActivityLog(models.ActivityRegistration, &user.ID, user.EmployeeNumber, ipAddress, userAgent).
+		WithDetails(details)
+	if err := s.db.Create(entry).Error; err != nil {
+		log.Printf("Failed to log SSO registration of user %d: %v", user.ID, err)
+	}
+
+	return &user, nil
+}
+
+// taken returns true if another user, deleted ones included, already has
+// value in column
+func (s *SSOService) taken(column, value string, userID uint) bool {
+	var count int64
+	s.db.Unscoped().Model(&models.User{}).Where(column+" = ? AND id <> ?", value, userID).Count(&count)
+	return count > 0
+}
+
+func hashSSOState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
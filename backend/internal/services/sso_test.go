@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/jwt"
+	"vista-backend/pkg/oidc"
+	"vista-backend/pkg/oidc/oidctest"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.ActivityLog{},
+		&models.SSOLogin{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// newTestSSOService returns an SSO service logging in through a test
+// identity provider
+func newTestSSOService(t *testing.T, policy SSOPolicy) (*SSOService, *oidctest.Server, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	idp := oidctest.NewServer("vista")
+	t.Cleanup(idp.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:      idp.Issuer(),
+		ClientID:    "vista",
+		RedirectURL: "http://localhost:3000/sso/callback",
+		Scopes:      []string{"openid", "email", "profile"},
+	})
+	auth := NewAuthService(db, jwt.NewJWTService("test-secret", time.Minute, time.Hour), nil, LoginPolicy{}, TwoFactorPolicy{})
+	if policy.EmployeeNumberClaim == "" {
+		policy.EmployeeNumberClaim = "employee_number"
+	}
+	if policy.GroupsClaim == "" {
+		policy.GroupsClaim = "groups"
+	}
+	return NewSSOService(db, auth, provider, policy), idp, db
+}
+
+// ssoLogin starts a login and signs in at the identity provider with claims,
+// returning the code and state the callback gets and the browser's binding
+func ssoLogin(t *testing.T, s *SSOService, idp *oidctest.Server, claims gojwt.MapClaims) (string, string, string) {
+	t.Helper()
+	authURL, binding, err := s.StartLogin(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	code, state, err := idp.Login(authURL, claims)
+	if err != nil {
+		t.Fatalf("identity provider login: %v", err)
+	}
+	return code, state, binding
+}
+
+func janeClaims() gojwt.MapClaims {
+	return gojwt.MapClaims{
+		"sub":             "idp-jane",
+		"email":           "Jane.Doe@example.com",
+		"email_verified":  true,
+		"name":            "Jane Doe",
+		"employee_number": "E1001",
+	}
+}
+
+func TestSSOStateCannotBeReplayed(t *testing.T) {
+	s, idp, _ := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+	code, state, binding := ssoLogin(t, s, idp, janeClaims())
+
+	tokens, user, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if tokens == nil || user == nil {
+		t.Fatal("CompleteLogin returned no tokens or user")
+	}
+
+	// The same callback again, and one with a state that was never issued
+	if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrInvalidSSOState) {
+		t.Errorf("replayed callback: err = %v, want ErrInvalidSSOState", err)
+	}
+	if _, _, err := s.CompleteLogin(context.Background(), code, "forged-state", binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrInvalidSSOState) {
+		t.Errorf("unknown state: err = %v, want ErrInvalidSSOState", err)
+	}
+}
+
+func TestSSOCallbackNeedsBrowserBinding(t *testing.T) {
+	s, idp, db := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+
+	// Someone else's callback link opened in a browser that didn't start
+	// the login, with its own binding or none
+	code, state, _ := ssoLogin(t, s, idp, janeClaims())
+	_, _, other := ssoLogin(t, s, idp, janeClaims())
+	for _, binding := range []string{other, ""} {
+		if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrInvalidSSOState) {
+			t.Errorf("binding %q: err = %v, want ErrInvalidSSOState", binding, err)
+		}
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users were created by callbacks from the wrong browser", count)
+	}
+}
+
+func TestSSOStateExpires(t *testing.T) {
+	s, idp, db := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+	code, state, binding := ssoLogin(t, s, idp, janeClaims())
+	db.Model(&models.SSOLogin{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Second))
+
+	if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrInvalidSSOState) {
+		t.Errorf("err = %v, want ErrInvalidSSOState", err)
+	}
+}
+
+func TestSSOUsesStoredVerifierAndNonce(t *testing.T) {
+	s, idp, db := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+
+	// A login whose stored PKCE verifier doesn't match the challenge sent
+	code, state, binding := ssoLogin(t, s, idp, janeClaims())
+	db.Model(&models.SSOLogin{}).Where("state_hash = ?", hashSSOState(state)).Update("code_verifier", "tampered")
+	if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrSSOFailed) {
+		t.Errorf("wrong verifier: err = %v, want ErrSSOFailed", err)
+	}
+
+	// A login whose stored nonce isn't the one in the ID token
+	code, state, binding = ssoLogin(t, s, idp, janeClaims())
+	db.Model(&models.SSOLogin{}).Where("state_hash = ?", hashSSOState(state)).Update("nonce", "tampered")
+	if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrSSOFailed) {
+		t.Errorf("wrong nonce: err = %v, want ErrSSOFailed", err)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users were created by failed logins", count)
+	}
+}
+
+func TestSSORefusesToLinkUnverifiedEmail(t *testing.T) {
+	s, idp, db := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+	existing := models.User{EmployeeNumber: "E1001", Name: "Jane", Email: "jane.doe@example.com", Role: models.RoleAdmin, Status: models.UserStatusApproved}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	claims := janeClaims()
+	claims["email_verified"] = false
+	code, state, binding := ssoLogin(t, s, idp, claims)
+	tokens, user, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test")
+	if !errors.Is(err, ErrSSOAccountConflict) {
+		t.Fatalf("err = %v, want ErrSSOAccountConflict", err)
+	}
+	if tokens != nil {
+		t.Error("tokens were issued for an unverified email")
+	}
+	if user == nil || user.ID != existing.ID {
+		t.Error("the refused attempt isn't attributed to the existing account")
+	}
+	db.First(&existing, existing.ID)
+	if existing.SSOSubject != "" {
+		t.Errorf("account was linked to %q", existing.SSOSubject)
+	}
+
+	// The same address, verified, links the account
+	code, state, binding = ssoLogin(t, s, idp, janeClaims())
+	if _, user, err = s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); err != nil {
+		t.Fatalf("verified email: %v", err)
+	}
+	if user.ID != existing.ID || user.SSOSubject != "idp-jane" {
+		t.Errorf("verified email linked user %d with subject %q, want %d with idp-jane", user.ID, user.SSOSubject, existing.ID)
+	}
+	if user.Role != models.RoleAdmin {
+		t.Errorf("role = %s, want the existing admin role kept", user.Role)
+	}
+}
+
+func TestSSORefusesToLinkWithoutEmailVerifiedClaim(t *testing.T) {
+	s, idp, db := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+	existing := models.User{EmployeeNumber: "E1001", Name: "Jane", Email: "jane.doe@example.com", Status: models.UserStatusApproved}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	claims := janeClaims()
+	delete(claims, "email_verified")
+	code, state, binding := ssoLogin(t, s, idp, claims)
+	if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrSSOAccountConflict) {
+		t.Fatalf("err = %v, want ErrSSOAccountConflict", err)
+	}
+	db.First(&existing, existing.ID)
+	if existing.SSOSubject != "" {
+		t.Errorf("account was linked to %q", existing.SSOSubject)
+	}
+}
+
+func TestSSOProvisionsUsers(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoApprove bool
+		wantStatus  models.UserStatus
+		wantErr     error
+	}{
+		{"pending approval", false, models.UserStatusPending, ErrUserPending},
+		{"auto approved", true, models.UserStatusApproved, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, idp, db := newTestSSOService(t, SSOPolicy{
+				AutoApprove:     tt.autoApprove,
+				DepartmentClaim: "department",
+				RoleGroups:      []RoleGroup{{Group: "vista-buyers", Role: models.RolePurchaseAdmin}},
+			})
+			claims := janeClaims()
+			claims["department"] = "Engineering"
+			claims["groups"] = []string{"staff", "vista-buyers"}
+
+			code, state, binding := ssoLogin(t, s, idp, claims)
+			tokens, user, err := s.CompleteLogin(context.Background(), code, state, binding, "es", "127.0.0.1", "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if (tokens != nil) != tt.autoApprove {
+				t.Errorf("tokens issued = %v, want %v", tokens != nil, tt.autoApprove)
+			}
+
+			var created models.User
+			if err := db.Where("sso_subject = ?", "idp-jane").First(&created).Error; err != nil {
+				t.Fatalf("user was not created: %v", err)
+			}
+			if user == nil || user.ID != created.ID {
+				t.Error("CompleteLogin didn't return the created user")
+			}
+			if created.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", created.Status, tt.wantStatus)
+			}
+			if (created.ApprovedAt != nil) != tt.autoApprove {
+				t.Errorf("approved_at set = %v, want %v", created.ApprovedAt != nil, tt.autoApprove)
+			}
+			if created.Email != "jane.doe@example.com" || created.EmployeeNumber != "E1001" ||
+				created.Name != "Jane Doe" || created.Department != "Engineering" || created.Language != "es" {
+				t.Errorf("user fields not taken from claims: %+v", created)
+			}
+			if created.Role != models.RolePurchaseAdmin {
+				t.Errorf("role = %s, want %s from the group", created.Role, models.RolePurchaseAdmin)
+			}
+			if created.PasswordHash != "" {
+				t.Error("SSO user got a password")
+			}
+
+			var registrations int64
+			db.Model(&models.ActivityLog{}).Where("user_id = ? AND type = ?", created.ID, models.ActivityRegistration).Count(&registrations)
+			if registrations != 1 {
+				t.Errorf("%d registrations logged, want 1", registrations)
+			}
+		})
+	}
+}
+
+func TestSSOProvisioningNeedsEmployeeNumber(t *testing.T) {
+	s, idp, db := newTestSSOService(t, SSOPolicy{AutoApprove: true})
+	claims := janeClaims()
+	delete(claims, "employee_number")
+
+	code, state, binding := ssoLogin(t, s, idp, claims)
+	if _, _, err := s.CompleteLogin(context.Background(), code, state, binding, "en", "127.0.0.1", "test"); !errors.Is(err, ErrSSOFailed) {
+		t.Errorf("err = %v, want ErrSSOFailed", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users created without an employee number", count)
+	}
+}
@@ -1,20 +1,16 @@
 package translation
 
 import (
-	"encoding/json"
-	"log"
-	"sync"
-
 	"vista-backend/internal/models"
 
 	"gorm.io/gorm"
 )
 
-// AsyncTranslator handles asynchronous translation with priority for user's current language
+// AsyncTranslator handles translation with priority for user's current language.
+// Remaining languages are completed by the persisted JobQueue.
 type AsyncTranslator struct {
 	translator *Translator
 	db         *gorm.DB
-	wg         sync.WaitGroup
 }
 
 // NewAsyncTranslator creates a new async translator
@@ -25,19 +21,16 @@ func NewAsyncTranslator(db *gorm.DB) *AsyncTranslator {
 	}
 }
 
-// TranslateFieldResult contains the initial translation and a channel for completion
+// TranslateFieldResult contains the initial translation returned to the caller
 type TranslateFieldResult struct {
 	Translated *TranslatedText
 	JSON       models.JSONB
 }
 
-// TranslateField translates text with priority for the user's language
-// Returns immediately with translation for userLang, then completes other languages in background
-func (at *AsyncTranslator) TranslateField(
-	text string,
-	userLang string,
-	onComplete func(translated *TranslatedText) error,
-) (*TranslateFieldResult, error) {
+// TranslateField translates text into the user's language synchronously.
+// sourceLang overrides detection when the user chose the language themselves.
+// Call EnqueueField once the record has an ID to complete the other languages.
+func (at *AsyncTranslator) TranslateField(text string, userLang string, sourceLang string) (*TranslateFieldResult, error) {
 	if text == "" {
 		return nil, nil
 	}
@@ -47,75 +40,54 @@ func (at *AsyncTranslator) TranslateField(
 		text = text[:1000]
 	}
 
-	// Detect source language
-	sourceLang := at.translator.DetectLanguage(text)
+	// Detect source language unless the user told us
+	confidence := 1.0
+	if sourceLang == "" {
+		detection := Detect(text)
+		sourceLang = detection.Source()
+		confidence = detection.Confidence
+	}
 
 	result := &TranslatedText{
 		Original: text,
 	}
 
-	// 1. SYNCHRONOUS: Translate to user's language first (fast response)
+	// Translate to user's language first (fast response)
 	if userLang != "" && userLang != sourceLang {
 		translated, err := at.translator.translate(text, sourceLang, userLang)
-		if err != nil {
-			translated = text // Fallback to original
+		if err == nil {
+			result.Set(userLang, translated)
 		}
-		at.setLanguageField(result, userLang, translated)
 	} else if userLang != "" {
 		// Source is same as target
-		at.setLanguageField(result, userLang, text)
+		result.Set(userLang, text)
 	}
 
 	// Also set the source language field
-	if sourceLang != "auto" && sourceLang != userLang {
-		at.setLanguageField(result, sourceLang, text)
+	if sourceLang != "auto" {
+		result.SourceLanguage = sourceLang
+		result.SourceConfidence = confidence
+		if sourceLang != userLang {
+			result.Set(sourceLang, text)
+		}
 	}
 
-	// Convert to JSON for immediate return
-	jsonData, _ := json.Marshal(result)
-	initialResult := &TranslateFieldResult{
+	return &TranslateFieldResult{
 		Translated: result,
-		JSON:       models.JSONB(jsonData),
-	}
-
-	// 2. ASYNCHRONOUS: Translate to remaining languages in background
-	at.wg.Add(1)
-	go func() {
-		defer at.wg.Done()
-
-		// Complete result with a copy to avoid race conditions
-		completeResult := &TranslatedText{
-			Original: result.Original,
-			En:       result.En,
-			Zh:       result.Zh,
-			Es:       result.Es,
-		}
-
-		allLangs := []string{"en", "zh", "es"}
-		for _, targetLang := range allLangs {
-			// Skip if already translated
-			if at.getLanguageField(completeResult, targetLang) != "" {
-				continue
-			}
-
-			// Translate
-			translated, err := at.translator.translate(text, sourceLang, targetLang)
-			if err != nil {
-				log.Printf("Async translation error for %s: %v", targetLang, err)
-				translated = text // Fallback
-			}
-			at.setLanguageField(completeResult, targetLang, translated)
-		}
-
-		// Call completion callback to save to DB
-		if onComplete != nil {
-			if err := onComplete(completeResult); err != nil {
-				log.Printf("Failed to save async translation: %v", err)
-			}
-		}
-	}()
+		JSON:       ToJSON(result),
+	}, nil
+}
 
-	return initialResult, nil
+// EnqueueField queues a job that completes the remaining languages for a field.
+// Pass the transaction that saves the record so the job is committed with it.
+func (at *AsyncTranslator) EnqueueField(tx *gorm.DB, table string, recordID uint, column string, result *TranslateFieldResult) error {
+	if result == nil || result.Translated == nil {
+		return nil
+	}
+	if tx == nil {
+		tx = at.db
+	}
+	return Enqueue(tx, table, recordID, column, result.Translated.Original, result.Translated)
 }
 
 // TranslateFieldSync translates text synchronously to all languages
@@ -131,60 +103,8 @@ func (at *AsyncTranslator) TranslateFieldSync(text string) (*TranslateFieldResul
 		return nil, err
 	}
 
-	jsonData, _ := json.Marshal(result)
 	return &TranslateFieldResult{
 		Translated: result,
-		JSON:       models.JSONB(jsonData),
+		JSON:       ToJSON(result),
 	}, nil
 }
-
-// Wait waits for all background translations to complete
-func (at *AsyncTranslator) Wait() {
-	at.wg.Wait()
-}
-
-// setLanguageField sets the appropriate language field in TranslatedText
-func (at *AsyncTranslator) setLanguageField(t *TranslatedText, lang, value string) {
-	switch lang {
-	case "en":
-		t.En = value
-	case "zh":
-		t.Zh = value
-	case "es":
-		t.Es = value
-	}
-}
-
-// getLanguageField gets the appropriate language field from TranslatedText
-func (at *AsyncTranslator) getLanguageField(t *TranslatedText, lang string) string {
-	switch lang {
-	case "en":
-		return t.En
-	case "zh":
-		return t.Zh
-	case "es":
-		return t.Es
-	}
-	return ""
-}
-
-// ToJSON converts TranslatedText to models.JSONB
-func ToJSON(t *TranslatedText) models.JSONB {
-	if t == nil {
-		return nil
-	}
-	data, _ := json.Marshal(t)
-	return models.JSONB(data)
-}
-
-// FromJSON converts models.JSONB to TranslatedText
-func FromJSON(data models.JSONB) *TranslatedText {
-	if data == nil {
-		return nil
-	}
-	var t TranslatedText
-	if err := json.Unmarshal(data, &t); err != nil {
-		return nil
-	}
-	return &t
-}
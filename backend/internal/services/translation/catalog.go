@@ -0,0 +1,91 @@
+package translation
+
+import (
+	"vista-backend/internal/models"
+	"vista-backend/pkg/i18n"
+
+	"gorm.io/gorm"
+)
+
+// ProductFields lists the catalog product fields the job queue fills. Their
+// targets are LocalizedText maps on the product rather than TranslatedText JSON.
+var ProductFields = []TranslatableField{
+	{Table: "products", SourceColumn: models.ProductFieldName, TargetColumn: "name_translations", Localized: true},
+	{Table: "products", SourceColumn: models.ProductFieldDescription, TargetColumn: "desc_translations", Localized: true},
+	{Table: "products", SourceColumn: models.ProductFieldSpecification, TargetColumn: "spec_translations", Localized: true},
+}
+
+// EnqueueProduct queues translation of a product's fields into every enabled
+// language that has no value, or whose machine translation is out of date.
+// With force, machine translations are redone even if current. Values entered
+// by a person are never queued. Returns the number of jobs queued.
+func EnqueueProduct(db *gorm.DB, product *models.Product, force bool) (int, error) {
+	queued := 0
+	for _, field := range ProductFields {
+		source, translations := product.LocalizedField(field.SourceColumn)
+		if source == "" || translations == nil {
+			continue
+		}
+
+		// Keep human values and current machine translations; the job fills the rest
+		initial := &TranslatedText{}
+		for _, lang := range i18n.Enabled() {
+			text := translations.Get(lang)
+			if text == "" {
+				continue
+			}
+			if !product.IsMachineTranslated(field.SourceColumn, lang) ||
+				(!force && product.MachineSource(field.SourceColumn, lang) == source) {
+				initial.Set(lang, text)
+			}
+		}
+		if len(initial.Missing()) == 0 {
+			continue
+		}
+
+		// A pending job for older text would be discarded as stale; replace it
+		if err := db.Where("target_table = ? AND record_id = ? AND target_column = ? AND status = ?",
+			field.Table, product.ID, field.TargetColumn, models.TranslationJobPending).
+			Delete(&models.TranslationJob{}).Error; err != nil {
+			return queued, err
+		}
+		if err := Enqueue(db, field.Table, product.ID, field.TargetColumn, source, initial); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// writeProduct stores a finished job's translations on the product, skipping
+// languages a person has edited. Results for text that changed since the job
+// was queued are dropped; the change queued its own job.
+func (q *JobQueue) writeProduct(job *models.TranslationJob, field *TranslatableField, result *TranslatedText) error {
+	return q.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, job.RecordID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		source, _ := product.LocalizedField(field.SourceColumn)
+		if source != job.SourceText {
+			return nil
+		}
+
+		for lang, text := range result.Translations {
+			if !i18n.IsEnabled(lang) {
+				continue
+			}
+			product.SetMachineTranslation(field.SourceColumn, lang, text, source)
+		}
+
+		_, translations := product.LocalizedField(field.SourceColumn)
+		return tx.Model(&product).Updates(map[string]interface{}{
+			field.TargetColumn:   *translations,
+			"machine_translated": product.MachineTranslated,
+		}).Error
+	})
+}
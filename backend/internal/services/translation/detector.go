@@ -0,0 +1,410 @@
+package translation
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"vista-backend/pkg/i18n"
+)
+
+// MinDetectionConfidence is the confidence below which DetectLanguage reports "auto"
+const MinDetectionConfidence = 0.6
+
+// Short text, such as a product name, has too few n-grams to tell languages
+// of the same script apart. Unless one language clearly wins, its share goes
+// to the fallback language instead.
+const (
+	MinDetectionWords      = 4
+	MinShortTextConfidence = 0.8
+)
+
+// Script groups languages that share a writing system. Text is first split by
+// script; n-gram scoring only has to separate languages within the same script.
+type Script string
+
+const (
+	ScriptLatin  Script = "latin"
+	ScriptHan    Script = "han"
+	ScriptHangul Script = "hangul"
+)
+
+// scriptTables maps each script to the Unicode ranges that belong to it
+var scriptTables = map[Script]*unicode.RangeTable{
+	ScriptLatin:  unicode.Latin,
+	ScriptHan:    unicode.Han,
+	ScriptHangul: unicode.Hangul,
+}
+
+// Detection is the result of language detection
+type Detection struct {
+	Language   string             `json:"language"`
+	Confidence float64            `json:"confidence"`
+	Scores     map[string]float64 `json:"scores"`
+}
+
+// languageProfile holds the trigram log-probabilities for one language
+type languageProfile struct {
+	code     string
+	script   Script
+	logProbs map[string]float64
+	unseen   float64
+}
+
+// Detector is a local character n-gram language detector
+type Detector struct {
+	mu       sync.RWMutex
+	profiles map[string]*languageProfile
+	fallback func() string
+}
+
+// NewDetector creates a detector with no languages registered
+func NewDetector() *Detector {
+	return &Detector{profiles: make(map[string]*languageProfile)}
+}
+
+// defaultDetector is preloaded with every language that has a profile below
+var defaultDetector = newDefaultDetector()
+
+func newDefaultDetector() *Detector {
+	d := NewDetector()
+	d.Register("en", ScriptLatin, englishSample)
+	d.Register("es", ScriptLatin, spanishSample)
+	d.Register("pt", ScriptLatin, portugueseSample)
+	d.Register("zh", ScriptHan, "")
+	d.Register("ko", ScriptHangul, "")
+	d.SetFallback(i18n.Default)
+	return d
+}
+
+// RegisterLanguage adds or replaces a language profile on the default detector
+func RegisterLanguage(code string, script Script, sample string) {
+	defaultDetector.Register(code, script, sample)
+}
+
+// Detect runs the default detector
+func Detect(text string) Detection {
+	return defaultDetector.Detect(text)
+}
+
+// DetectLanguage returns the detected language code, or "auto" when the
+// detector is not confident enough to pick one
+func DetectLanguage(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "en"
+	}
+	return Detect(text).Source()
+}
+
+// Source returns the detected language, or "auto" below MinDetectionConfidence
+func (d Detection) Source() string {
+	if d.Language == "" || d.Confidence < MinDetectionConfidence {
+		return "auto"
+	}
+	return d.Language
+}
+
+// IsSupportedLanguage returns true if text in the language can be translated.
+// The detector doesn't need a profile for it since users may override detection.
+func IsSupportedLanguage(code string) bool {
+	return i18n.IsKnown(code)
+}
+
+// Register builds a trigram profile from sample text. Languages that are the
+// only one registered for their script may pass an empty sample.
+func (d *Detector) Register(code string, script Script, sample string) {
+	counts := make(map[string]int)
+	total := 0
+	for _, word := range splitWords(sample, script) {
+		for _, g := range trigrams(word) {
+			counts[g]++
+			total++
+		}
+	}
+
+	// Add-one smoothing over the observed vocabulary
+	vocab := len(counts) + 1
+	profile := &languageProfile{
+		code:     code,
+		script:   script,
+		logProbs: make(map[string]float64, len(counts)),
+		unseen:   math.Log(1 / float64(total+vocab)),
+	}
+	for g, n := range counts {
+		profile.logProbs[g] = math.Log(float64(n+1) / float64(total+vocab))
+	}
+
+	d.mu.Lock()
+	d.profiles[code] = profile
+	d.mu.Unlock()
+}
+
+// SetFallback sets the function returning the language that short text is
+// attributed to when no language clearly wins. The default detector uses the
+// configured default language.
+func (d *Detector) SetFallback(fallback func() string) {
+	d.mu.Lock()
+	d.fallback = fallback
+	d.mu.Unlock()
+}
+
+// Supports returns true if a profile is registered for the language code
+func (d *Detector) Supports(code string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.profiles[code]
+	return ok
+}
+
+// Languages returns the registered language codes in sorted order
+func (d *Detector) Languages() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	codes := make([]string, 0, len(d.profiles))
+	for code := range d.profiles {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Detect scores text against every registered language. Scores sum to 1 and
+// Confidence is the score of the winning language.
+func (d *Detector) Detect(text string) Detection {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := Detection{Scores: make(map[string]float64)}
+
+	// Weight each script by how much of the text it covers. A Han or Hangul
+	// character carries roughly as much meaning as a Latin word, so count them that way.
+	weights := make(map[Script]float64)
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			weights[ScriptHan]++
+		} else if unicode.Is(unicode.Hangul, r) {
+			weights[ScriptHangul] += 0.5 // Hangul syllables are closer to half a word
+		}
+	}
+	weights[ScriptLatin] = float64(len(splitWords(text, ScriptLatin)))
+
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return result
+	}
+
+	byScript := make(map[Script][]*languageProfile)
+	for _, p := range d.profiles {
+		byScript[p.script] = append(byScript[p.script], p)
+	}
+
+	for script, weight := range weights {
+		profiles := byScript[script]
+		if weight == 0 || len(profiles) == 0 {
+			continue
+		}
+		share := weight / total
+
+		if len(profiles) == 1 {
+			result.Scores[profiles[0].code] += share
+			continue
+		}
+
+		words := splitWords(text, script)
+		scores := scoreNgrams(words, profiles)
+		if countWords(words) < MinDetectionWords && d.fallback != nil && maxScore(scores) < MinShortTextConfidence {
+			if fallback := d.fallback(); fallback != "" {
+				result.Scores[fallback] += share
+				continue
+			}
+		}
+		for code, p := range scores {
+			result.Scores[code] += share * p
+		}
+	}
+
+	// Renormalise in case a script had no registered languages
+	sum := 0.0
+	for _, s := range result.Scores {
+		sum += s
+	}
+	for code, s := range result.Scores {
+		score := s / sum
+		result.Scores[code] = score
+		if score > result.Confidence || (score == result.Confidence && code < result.Language) {
+			result.Language = code
+			result.Confidence = score
+		}
+	}
+
+	return result
+}
+
+// scoreNgrams returns the posterior probability of each profile given the words,
+// assuming a uniform prior
+func scoreNgrams(words []string, profiles []*languageProfile) map[string]float64 {
+	logLikelihood := make(map[string]float64, len(profiles))
+	n := 0
+	for _, word := range words {
+		for _, g := range trigrams(word) {
+			n++
+			for _, p := range profiles {
+				if lp, ok := p.logProbs[g]; ok {
+					logLikelihood[p.code] += lp
+				} else {
+					logLikelihood[p.code] += p.unseen
+				}
+			}
+		}
+	}
+
+	scores := make(map[string]float64, len(profiles))
+	if n == 0 {
+		for _, p := range profiles {
+			scores[p.code] = 1 / float64(len(profiles))
+		}
+		return scores
+	}
+
+	// Softmax over the average per-trigram likelihood so long texts don't
+	// saturate to 1.0 on a handful of distinctive trigrams
+	maxLL := math.Inf(-1)
+	for code := range logLikelihood {
+		logLikelihood[code] /= math.Sqrt(float64(n))
+		if logLikelihood[code] > maxLL {
+			maxLL = logLikelihood[code]
+		}
+	}
+	sum := 0.0
+	for code, ll := range logLikelihood {
+		scores[code] = math.Exp(ll - maxLL)
+		sum += scores[code]
+	}
+	for code := range scores {
+		scores[code] /= sum
+	}
+	return scores
+}
+
+// countWords counts the words longer than one letter, so fragments of model
+// numbers such as the "c" of "USB-C" don't make text look longer than it is
+func countWords(words []string) int {
+	n := 0
+	for _, word := range words {
+		if utf8.RuneCountInString(word) > 1 {
+			n++
+		}
+	}
+	return n
+}
+
+func maxScore(scores map[string]float64) float64 {
+	best := 0.0
+	for _, s := range scores {
+		best = math.Max(best, s)
+	}
+	return best
+}
+
+// splitWords lowercases text and returns its words in the given script
+func splitWords(text string, script Script) []string {
+	table := scriptTables[script]
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.Is(table, r)
+	})
+}
+
+// trigrams returns the character trigrams of a word padded with spaces
+func trigrams(word string) []string {
+	runes := []rune(" " + word + " ")
+	if len(runes) < 3 {
+		return nil
+	}
+	grams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+3]))
+	}
+	return grams
+}
+
+// Training samples: general prose plus the procurement vocabulary users actually write
+const englishSample = `
+I need a new laptop for the quality department because the current one is too slow.
+Please approve this request as soon as possible, the project deadline is next week.
+We need to replace the broken monitor and keyboard in the meeting room.
+The order was delivered yesterday and everything arrived in good condition.
+Could you provide more information about the vendor and the expected delivery date?
+This purchase is required for the new production line and the safety inspection.
+The price is lower than the previous quote and includes shipping and installation.
+Please cancel the order, we found the same item in the warehouse.
+The request was rejected because the budget for this quarter has already been spent.
+Thank you for your help, let me know if you have any questions about the items.
+We are running out of printer paper, toner, cleaning supplies and office chairs.
+The engineering team will use these tools for maintenance of the machines.
+Our customer requires samples before the end of the month.
+It is important that we receive the parts before the audit on Monday.
+Please purchase two units with the same model number as the last order.
+The supplier has confirmed the shipment and sent the tracking number.
+What is the status of this order and when will it arrive at the plant?
+The old equipment is damaged and cannot be repaired anymore.
+We would like to buy a replacement battery and a charger for the scanner.
+They said that this is the best option for our warehouse and our staff.
+There are several reasons why this should be purchased with high priority.
+The manager already approved the budget and signed the purchase order.
+`
+
+const spanishSample = `
+Necesito una laptop nueva para el area de calidad porque la actual es muy lenta.
+Por favor aprueben esta solicitud lo antes posible, la fecha limite del proyecto es la proxima semana.
+Necesitamos reemplazar el monitor y el teclado que estan dañados en la sala de juntas.
+El pedido fue entregado ayer y todo llego en buenas condiciones.
+Podrias dar mas informacion sobre el proveedor y la fecha de entrega esperada?
+Esta compra es necesaria para la nueva linea de produccion y la inspeccion de seguridad.
+El precio es mas bajo que la cotizacion anterior e incluye envio e instalacion.
+Por favor cancelen el pedido, encontramos el mismo articulo en el almacen.
+La solicitud fue rechazada porque el presupuesto de este trimestre ya se gasto.
+Gracias por su ayuda, avisenme si tienen alguna pregunta sobre los articulos.
+Se nos esta acabando el papel de la impresora, el toner, los productos de limpieza y las sillas de oficina.
+El equipo de ingenieria usara estas herramientas para el mantenimiento de las maquinas.
+Nuestro cliente requiere muestras antes del fin de mes.
+Es importante que recibamos las piezas antes de la auditoria del lunes.
+Por favor compren dos unidades con el mismo numero de modelo que el ultimo pedido.
+El proveedor confirmo el envio y mando el numero de rastreo.
+Cual es el estado de este pedido y cuando llegara a la planta?
+El equipo viejo esta dañado y ya no se puede reparar.
+Quisieramos comprar una bateria de repuesto y un cargador para el escaner.
+Dijeron que esta es la mejor opcion para nuestro almacen y nuestro personal.
+Hay varias razones por las que esto se debe comprar con alta prioridad.
+El gerente ya aprobo el presupuesto y firmo la orden de compra.
+`
+
+const portugueseSample = `
+Preciso de um notebook novo para a area de qualidade porque o atual esta muito lento.
+Por favor aprovem esta solicitacao o mais rapido possivel, o prazo do projeto e na proxima semana.
+Precisamos substituir o monitor e o teclado quebrados na sala de reunioes.
+O pedido foi entregue ontem e tudo chegou em boas condicoes.
+Voce pode dar mais informacoes sobre o fornecedor e a data de entrega prevista?
+Esta compra e necessaria para a nova linha de producao e a inspecao de seguranca.
+O preco e mais baixo que o orcamento anterior e inclui frete e instalacao.
+Por favor cancelem o pedido, encontramos o mesmo item no almoxarifado.
+A solicitacao foi rejeitada porque o orcamento deste trimestre ja foi gasto.
+Obrigado pela ajuda, me avisem se tiverem alguma duvida sobre os itens.
+Estamos ficando sem papel para a impressora, toner, produtos de limpeza e cadeiras de escritorio.
+A equipe de engenharia vai usar estas ferramentas para a manutencao das maquinas.
+Nosso cliente precisa de amostras antes do fim do mes.
+E importante que recebamos as pecas antes da auditoria de segunda-feira.
+Por favor comprem duas unidades com o mesmo numero de modelo do ultimo pedido.
+O fornecedor confirmou o envio e mandou o codigo de rastreamento.
+Qual e a situacao deste pedido e quando ele vai chegar na fabrica?
+O equipamento velho esta danificado e nao pode mais ser consertado.
+Gostariamos de comprar uma bateria de reposicao e um carregador para o scanner.
+Eles disseram que esta e a melhor opcao para o nosso almoxarifado e a nossa equipe.
+Existem varios motivos pelos quais isso deve ser comprado com alta prioridade.
+O gerente ja aprovou o orcamento e assinou a ordem de compra.
+`
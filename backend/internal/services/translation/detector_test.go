@@ -0,0 +1,82 @@
+package translation
+
+import (
+	"math"
+	"testing"
+
+	"vista-backend/pkg/i18n"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		// Spanish without accents, which the old heuristics sent to English
+		{"necesito una laptop para el area de calidad", "es"},
+		{"Por favor compren dos sillas de oficina para la sala de juntas", "es"},
+		{"Please buy two office chairs for the meeting room", "en"},
+		{"Precisamos de duas cadeiras de escritorio para a sala de reunioes", "pt"},
+		{"笔记本电脑", "zh"},
+		{"노트북 구매", "ko"},
+		// Mostly English with a Chinese brand name
+		{"Please buy the 联想 laptop for the quality department", "en"},
+		{"请购买一台笔记本电脑 Dell", "zh"},
+
+		// Short product text goes to the default language unless one
+		// language clearly wins
+		{"laptop Dell XPS 15", "en"},
+		{"Monitor LG 27 pulgadas", "en"},
+		{"USB-C cable 2m", "en"},
+		{"teclado", "en"},
+		{"silla de oficina", "es"},
+		{"office chair", "en"},
+
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage(%q) = %s, want %s (scores %v)", tt.text, got, tt.want, Detect(tt.text).Scores)
+			}
+		})
+	}
+}
+
+func TestDetectShortTextUsesDefaultLanguage(t *testing.T) {
+	i18n.Configure([]string{"en", "zh", "es"}, "es")
+	t.Cleanup(func() { i18n.Configure([]string{"en", "zh", "es"}, "en") })
+
+	if got := DetectLanguage("laptop Dell XPS 15"); got != "es" {
+		t.Errorf("short text = %s, want the default language es", got)
+	}
+	if got := DetectLanguage("office chair"); got != "en" {
+		t.Errorf("short English text = %s, want en", got)
+	}
+	if got := DetectLanguage("Please buy two office chairs for the meeting room"); got != "en" {
+		t.Errorf("English sentence = %s, want en", got)
+	}
+}
+
+func TestDetectorWithoutFallback(t *testing.T) {
+	d := NewDetector()
+	d.Register("en", ScriptLatin, englishSample)
+	d.Register("es", ScriptLatin, spanishSample)
+
+	detection := d.Detect("laptop Dell XPS 15")
+	if _, ok := detection.Scores["en"]; !ok || len(detection.Scores) != 2 {
+		t.Errorf("scores = %v, want n-gram scores for en and es", detection.Scores)
+	}
+}
+
+func TestDetectScoresSumToOne(t *testing.T) {
+	for _, text := range []string{"necesito una laptop", "请购买 Dell laptop", "laptop Dell XPS 15", "노트북 laptop"} {
+		sum := 0.0
+		for _, score := range Detect(text).Scores {
+			sum += score
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("scores of %q sum to %f", text, sum)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode"
+
+	"vista-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// protectedPattern matches identifiers that must never be translated.
+// Matches rejected by accept are left for the translator.
+type protectedPattern struct {
+	pattern *regexp.Regexp
+	accept  func(match string) bool
+}
+
+// protectedPatterns are applied in order, so URLs and emails are claimed
+// before the generic identifier pattern can split them up
+var protectedPatterns = []protectedPattern{
+	{pattern: regexp.MustCompile(`https?://\S+`)},                                                  // URLs
+	{pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},            // Email addresses
+	{pattern: regexp.MustCompile(`\b(?:PO|PR)-(?:[A-Z][A-Z0-9]*-)?\d{4}-\d+\b`)},                   // PO/PR numbers, with or without a company prefix
+	{pattern: regexp.MustCompile(`\bB0[A-Z0-9]{8}\b`)},                                             // Amazon ASINs
+	{pattern: regexp.MustCompile(`\b[A-Za-z0-9]+(?:[-_./][A-Za-z0-9]+)*\b`), accept: isIdentifier}, // SKUs and model numbers
+}
+
+// ordinalPattern excludes "1st", "2nd", "3er" etc. from identifier protection
+var ordinalPattern = regexp.MustCompile(`^(?i)\d+(?:st|nd|rd|th|er|ro|do|to|vo|no|o|a)$`)
+
+// placeholderPattern matches placeholders after a round trip through a
+// provider, which may add spaces or change case
+var placeholderPattern = regexp.MustCompile(`(?i)_{1,2}\s*G\s*(\d+)\s*_{1,2}`)
+
+type glossaryEntry struct {
+	term    models.GlossaryTerm
+	pattern *regexp.Regexp
+	length  int
+}
+
+// Glossary holds the active glossary terms used by every translation provider
+type Glossary struct {
+	mu      sync.RWMutex
+	entries []glossaryEntry
+}
+
+// defaultGlossary is shared by all translators in the process
+var defaultGlossary = &Glossary{}
+
+// LoadGlossary (re)loads active glossary terms from the database.
+// Call it on startup and after the glossary is edited.
+func LoadGlossary(db *gorm.DB) error {
+	var terms []models.GlossaryTerm
+	if err := db.Where("is_active = ?", true).Find(&terms).Error; err != nil {
+		return err
+	}
+	defaultGlossary.Set(terms)
+	return nil
+}
+
+// Set replaces the glossary contents
+func (g *Glossary) Set(terms []models.GlossaryTerm) {
+	var entries []glossaryEntry
+	for _, term := range terms {
+		for _, form := range term.Forms() {
+			entries = append(entries, glossaryEntry{
+				term:    term,
+				pattern: termPattern(form, term.CaseSensitive),
+				length:  len([]rune(form)),
+			})
+		}
+	}
+
+	// Longest forms first so "centro de costos" wins over "centro"
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].length > entries[j].length
+	})
+
+	g.mu.Lock()
+	g.entries = entries
+	g.mu.Unlock()
+}
+
+// termPattern matches a term as a whole word. Word boundaries are skipped at
+// edges that are Han characters since Chinese has no spaces between words.
+func termPattern(form string, caseSensitive bool) *regexp.Regexp {
+	runes := []rune(form)
+	expr := regexp.QuoteMeta(form)
+	if isWordRune(runes[0]) {
+		expr = `\b` + expr
+	}
+	if isWordRune(runes[len(runes)-1]) {
+		expr = expr + `\b`
+	}
+	if !caseSensitive {
+		expr = `(?i)` + expr
+	}
+	return regexp.MustCompile(expr)
+}
+
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// isIdentifier reports whether a token looks like a SKU or model number:
+// it mixes letters and digits and isn't an ordinal
+func isIdentifier(token string) bool {
+	hasLetter, hasDigit := false, false
+	for _, r := range token {
+		switch {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsLetter(r):
+			hasLetter = true
+		}
+	}
+	return hasLetter && hasDigit && len(token) >= 3 && !ordinalPattern.MatchString(token)
+}
+
+// protectedText is source text with glossary terms and identifiers replaced by placeholders
+type protectedText struct {
+	text         string
+	replacements []string
+}
+
+// protect swaps protected identifiers and glossary terms for placeholders.
+// Each placeholder is restored to the target-language form after translation.
+func (g *Glossary) protect(text, targetLang string) *protectedText {
+	p := &protectedText{text: text}
+
+	placeholder := func(replacement string) string {
+		p.replacements = append(p.replacements, replacement)
+		return fmt.Sprintf("__G%d__", len(p.replacements)-1)
+	}
+
+	for _, pp := range protectedPatterns {
+		p.text = pp.pattern.ReplaceAllStringFunc(p.text, func(match string) string {
+			if placeholderPattern.MatchString(match) {
+				return match
+			}
+			if pp.accept != nil && !pp.accept(match) {
+				return match
+			}
+			return placeholder(match)
+		})
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, entry := range g.entries {
+		p.text = entry.pattern.ReplaceAllStringFunc(p.text, func(match string) string {
+			if entry.term.Protected {
+				return placeholder(match)
+			}
+			return placeholder(entry.term.ForLanguage(targetLang))
+		})
+	}
+
+	return p
+}
+
+// restore replaces placeholders in translated text with their final values
+func (p *protectedText) restore(translated string) string {
+	if len(p.replacements) == 0 {
+		return translated
+	}
+	return placeholderPattern.ReplaceAllStringFunc(translated, func(match string) string {
+		sub := placeholderPattern.FindStringSubmatch(match)
+		idx, err := strconv.Atoi(sub[1])
+		if err != nil || idx >= len(p.replacements) {
+			return match
+		}
+		return p.replacements[idx]
+	})
+}
+
+// ProtectTerms prepares text for a translation provider. Translate the
+// returned text and pass the provider's output to restore to get the final
+// translation with glossary terms and identifiers applied.
+func ProtectTerms(text, targetLang string) (string, func(string) string) {
+	p := defaultGlossary.protect(text, targetLang)
+	return p.text, p.restore
+}
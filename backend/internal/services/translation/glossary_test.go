@@ -0,0 +1,25 @@
+package translation
+
+import "testing"
+
+func TestRequestNumbersAreAlwaysProtected(t *testing.T) {
+	tests := []string{"PR-2026-0042", "PO-2026-0042", "PR-MX-2026-0042", "PO-MX01-2026-0007"}
+	for _, number := range tests {
+		// Numbers must not depend on the identifier heuristics, which
+		// accept or reject tokens by their shape
+		protected := false
+		for _, pp := range protectedPatterns {
+			if pp.accept == nil && pp.pattern.FindString("Please review "+number+" today") == number {
+				protected = true
+			}
+		}
+		if !protected {
+			t.Errorf("%s isn't matched whole by an unconditional pattern", number)
+		}
+
+		p := (&Glossary{}).protect("Please review "+number+" today", "es")
+		if got := p.restore(p.text); got != "Please review "+number+" today" {
+			t.Errorf("%s: restored %q", number, got)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package translation
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/worker"
+
+	"gorm.io/gorm"
+)
+
+// TranslatableField describes a text column whose translations are stored in
+// TargetColumn of the same row, as TranslatedText JSON unless Localized is set
+type TranslatableField struct {
+	Table        string
+	SourceColumn string
+	TargetColumn string
+	Localized    bool // TargetColumn holds a LocalizedText map (see ProductFields)
+}
+
+// TranslatableFields lists every (table, column) the job queue is allowed to write to
+var TranslatableFields = []TranslatableField{
+	{Table: "purchase_requests", SourceColumn: "justification", TargetColumn: "justification_translated"},
+	{Table: "purchase_requests", SourceColumn: "rejection_reason", TargetColumn: "rejection_reason_translated"},
+	{Table: "purchase_requests", SourceColumn: "info_request_note", TargetColumn: "info_request_note_translated"},
+	{Table: "purchase_requests", SourceColumn: "info_response", TargetColumn: "info_response_translated"},
+	{Table: "purchase_requests", SourceColumn: "purchase_notes", TargetColumn: "purchase_notes_translated"},
+	{Table: "purchase_requests", SourceColumn: "delivery_notes", TargetColumn: "delivery_notes_translated"},
+	{Table: "purchase_requests", SourceColumn: "cancellation_notes", TargetColumn: "cancellation_notes_translated"},
+	{Table: "purchase_requests", SourceColumn: "admin_notes", TargetColumn: "admin_notes_translated"},
+	{Table: "purchase_requests", SourceColumn: "product_title", TargetColumn: "product_title_translated"},
+	{Table: "purchase_requests", SourceColumn: "product_description", TargetColumn: "product_desc_translated"},
+	{Table: "purchase_request_items", SourceColumn: "product_title", TargetColumn: "product_title_translated"},
+	{Table: "purchase_request_items", SourceColumn: "product_description", TargetColumn: "product_desc_translated"},
+	{Table: "request_histories", SourceColumn: "comment", TargetColumn: "comment_translated"},
+}
+
+// findTranslatableField returns the field definition for a target, or nil if it is not allowed
+func findTranslatableField(table, targetColumn string) *TranslatableField {
+	for i := range TranslatableFields {
+		if TranslatableFields[i].Table == table && TranslatableFields[i].TargetColumn == targetColumn {
+			return &TranslatableFields[i]
+		}
+	}
+	for i := range ProductFields {
+		if ProductFields[i].Table == table && ProductFields[i].TargetColumn == targetColumn {
+			return &ProductFields[i]
+		}
+	}
+	return nil
+}
+
+// Enqueue persists a translation job for (table, recordID, column).
+// Pass the transaction that writes the record so the job is only stored if the record is.
+func Enqueue(db *gorm.DB, table string, recordID uint, column, text string, initial *TranslatedText) error {
+	if text == "" {
+		return nil
+	}
+	if recordID == 0 {
+		return fmt.Errorf("cannot enqueue translation for %s.%s without a record ID", table, column)
+	}
+	if findTranslatableField(table, column) == nil {
+		return fmt.Errorf("%s.%s is not a translatable field", table, column)
+	}
+
+	job := models.NewTranslationJob(table, recordID, column, text)
+	if initial != nil {
+		job.Initial = ToJSON(initial)
+	}
+	return db.Create(job).Error
+}
+
+// JobQueue processes persisted translation jobs in the background with retries
+type JobQueue struct {
+	*worker.Worker[models.TranslationJob]
+	db         *gorm.DB
+	translator *Translator
+}
+
+// NewJobQueue creates a new translation job queue worker
+func NewJobQueue(db *gorm.DB) *JobQueue {
+	q := &JobQueue{
+		db:         db,
+		translator: NewTranslator(),
+	}
+	q.Worker = worker.New(db, worker.Queue[models.TranslationJob]{
+		Name:         "Translation job queue",
+		Pending:      models.TranslationJobPending,
+		Running:      models.TranslationJobProcessing,
+		DueColumn:    "next_run_at",
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+		Process:      q.process,
+	})
+	return q
+}
+
+// process translates a job's text into all languages and writes the result to its target
+func (q *JobQueue) process(job *models.TranslationJob) {
+	job.Attempts++
+
+	field := findTranslatableField(job.TargetTable, job.TargetColumn)
+	if field == nil {
+		q.fail(job, fmt.Errorf("%s.%s is not a translatable field", job.TargetTable, job.TargetColumn), false)
+		return
+	}
+
+	result := FromJSON(job.Initial)
+	if result == nil {
+		result = &TranslatedText{}
+	}
+	result.Original = job.SourceText
+
+	// Keep the language recorded at submission (it may be a user override)
+	sourceLang := result.SourceLanguage
+	if sourceLang == "" {
+		detection := Detect(job.SourceText)
+		sourceLang = detection.Source()
+		if sourceLang != "auto" {
+			result.SourceLanguage = sourceLang
+			result.SourceConfidence = detection.Confidence
+		}
+	}
+	var errs []string
+	for _, targetLang := range result.Missing() {
+		if sourceLang == targetLang {
+			result.Set(targetLang, job.SourceText)
+			continue
+		}
+
+		translated, err := q.translator.translate(job.SourceText, sourceLang, targetLang)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", targetLang, err))
+			continue
+		}
+		result.Set(targetLang, translated)
+	}
+
+	if len(errs) > 0 {
+		err := fmt.Errorf("translation failed for %s", strings.Join(errs, "; "))
+		if job.CanRetry() {
+			q.retry(job, result, err)
+			return
+		}
+		// Out of retries: fall back to the original text so readers still see something.
+		// Products already fall back to their base text, and gaps left there are
+		// picked up by the next catalog retranslation.
+		if !field.Localized {
+			for _, lang := range result.Missing() {
+				result.Set(lang, job.SourceText)
+			}
+		}
+		if writeErr := q.write(job, field, result); writeErr != nil {
+			err = writeErr
+		}
+		q.fail(job, err, true)
+		return
+	}
+
+	if err := q.write(job, field, result); err != nil {
+		if job.CanRetry() {
+			q.retry(job, result, err)
+		} else {
+			q.fail(job, err, true)
+		}
+		return
+	}
+
+	now := time.Now()
+	q.db.Model(job).Updates(map[string]interface{}{
+		"status":       models.TranslationJobCompleted,
+		"attempts":     job.Attempts,
+		"last_error":   "",
+		"completed_at": now,
+	})
+}
+
+// write stores the translated JSON in the job's target column
+func (q *JobQueue) write(job *models.TranslationJob, field *TranslatableField, result *TranslatedText) error {
+	if field.Localized {
+		return q.writeProduct(job, field, result)
+	}
+	return q.db.Table(job.TargetTable).
+		Where("id = ?", job.RecordID).
+		Update(job.TargetColumn, ToJSON(result)).Error
+}
+
+// retry reschedules a job with exponential backoff, keeping partial translations
+func (q *JobQueue) retry(job *models.TranslationJob, partial *TranslatedText, err error) {
+	delay := worker.Backoff(job.Attempts, 30*time.Second, time.Hour)
+
+	log.Printf("Translation job %d failed (attempt %d/%d), retrying in %s: %v",
+		job.ID, job.Attempts, job.MaxAttempts, delay, err)
+
+	q.db.Model(job).Updates(map[string]interface{}{
+		"status":      models.TranslationJobPending,
+		"attempts":    job.Attempts,
+		"last_error":  err.Error(),
+		"initial":     ToJSON(partial),
+		"next_run_at": time.Now().Add(delay),
+	})
+}
+
+// fail marks a job as permanently failed
+func (q *JobQueue) fail(job *models.TranslationJob, err error, logIt bool) {
+	if logIt {
+		log.Printf("Translation job %d failed permanently: %v", job.ID, err)
+	}
+	q.db.Model(job).Updates(map[string]interface{}{
+		"status":     models.TranslationJobFailed,
+		"attempts":   job.Attempts,
+		"last_error": err.Error(),
+	})
+}
+
+// HasOpenJob returns true if a pending or processing job already targets (table, recordID, column)
+func HasOpenJob(db *gorm.DB, table string, recordID uint, column string) bool {
+	var count int64
+	db.Model(&models.TranslationJob{}).
+		Where("target_table = ? AND record_id = ? AND target_column = ? AND status IN ?",
+			table, recordID, column,
+			[]models.TranslationJobStatus{models.TranslationJobPending, models.TranslationJobProcessing}).
+		Count(&count)
+	return count > 0
+}
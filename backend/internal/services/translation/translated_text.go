@@ -0,0 +1,110 @@
+package translation
+
+import (
+	"encoding/json"
+
+	"vista-backend/internal/models"
+	"vista-backend/pkg/i18n"
+)
+
+// TranslatedText contains text translated to multiple languages.
+//
+// Stored as JSON:
+//
+//	{"original": "...", "source_language": "es", "translations": {"en": "...", "zh": "..."}}
+//
+// The legacy flat format {"original", "en", "zh", "es"} is still accepted when reading.
+type TranslatedText struct {
+	Original string `json:"original"`
+
+	// Source language of Original, either detected or chosen by the user
+	SourceLanguage   string  `json:"source_language,omitempty"`
+	SourceConfidence float64 `json:"source_confidence,omitempty"`
+
+	Translations models.LocalizedText `json:"translations"`
+}
+
+// Get returns the translation for a language, or "" if there is none
+func (t *TranslatedText) Get(lang string) string {
+	return t.Translations.Get(lang)
+}
+
+// Set stores the translation for a language
+func (t *TranslatedText) Set(lang, text string) {
+	t.Translations.Set(lang, text)
+}
+
+// Missing returns the enabled languages that have no translation yet
+func (t *TranslatedText) Missing() []string {
+	var missing []string
+	for _, lang := range i18n.Enabled() {
+		if t.Get(lang) == "" {
+			missing = append(missing, lang)
+		}
+	}
+	return missing
+}
+
+// UnmarshalJSON reads both the current and the legacy flat format
+func (t *TranslatedText) UnmarshalJSON(data []byte) error {
+	type current TranslatedText
+	var c current
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	*t = TranslatedText(c)
+
+	// Legacy rows keep one key per language next to "original"
+	var flat map[string]interface{}
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return nil
+	}
+	for key, value := range flat {
+		text, ok := value.(string)
+		if !ok || text == "" || !i18n.IsKnown(key) || t.Get(key) != "" {
+			continue
+		}
+		t.Set(key, text)
+	}
+	return nil
+}
+
+// IsLegacyJSON reports whether stored JSON uses the flat per-language format
+func IsLegacyJSON(data models.JSONB) bool {
+	if len(data) == 0 {
+		return false
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return false
+	}
+	_, hasTranslations := raw["translations"]
+	return !hasTranslations
+}
+
+// ToJSON converts TranslatedText to models.JSONB
+func ToJSON(t *TranslatedText) models.JSONB {
+	if t == nil {
+		return nil
+	}
+	data, _ := json.Marshal(t)
+	return models.JSONB(data)
+}
+
+// FromJSON converts models.JSONB to TranslatedText
+func FromJSON(data models.JSONB) *TranslatedText {
+	if data == nil {
+		return nil
+	}
+	var t TranslatedText
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil
+	}
+	return &t
+}
+
+// IsComplete returns true if the JSON holds a translation for every enabled language
+func IsComplete(data models.JSONB) bool {
+	t := FromJSON(data)
+	return t != nil && len(t.Missing()) == 0
+}
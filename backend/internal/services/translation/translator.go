@@ -8,6 +8,8 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"vista-backend/pkg/i18n"
 )
 
 // Translator handles text translation between languages
@@ -15,14 +17,6 @@ type Translator struct {
 	client *http.Client
 }
 
-// TranslatedText contains text translated to multiple languages
-type TranslatedText struct {
-	Original string `json:"original"`
-	En       string `json:"en"`
-	Zh       string `json:"zh"`
-	Es       string `json:"es"`
-}
-
 // NewTranslator creates a new translator instance
 func NewTranslator() *Translator {
 	return &Translator{
@@ -32,7 +26,7 @@ func NewTranslator() *Translator {
 	}
 }
 
-// TranslateToAll translates text from source language to all supported languages
+// TranslateToAll translates text from source language to all enabled languages
 func (t *Translator) TranslateToAll(text string, sourceLanguage string) (*TranslatedText, error) {
 	if text == "" {
 		return &TranslatedText{}, nil
@@ -46,21 +40,14 @@ func (t *Translator) TranslateToAll(text string, sourceLanguage string) (*Transl
 	result := &TranslatedText{
 		Original: text,
 	}
+	if sourceLanguage != "auto" {
+		result.SourceLanguage = sourceLanguage
+	}
 
-	// Supported languages
-	targetLanguages := []string{"en", "zh", "es"}
-
-	for _, targetLang := range targetLanguages {
+	for _, targetLang := range i18n.Enabled() {
 		// Skip if source and target are the same
 		if sourceLanguage == targetLang {
-			switch targetLang {
-			case "en":
-				result.En = text
-			case "zh":
-				result.Zh = text
-			case "es":
-				result.Es = text
-			}
+			result.Set(targetLang, text)
 			continue
 		}
 
@@ -69,22 +56,24 @@ func (t *Translator) TranslateToAll(text string, sourceLanguage string) (*Transl
 			// On error, use original text
 			translated = text
 		}
-
-		switch targetLang {
-		case "en":
-			result.En = translated
-		case "zh":
-			result.Zh = translated
-		case "es":
-			result.Es = translated
-		}
+		result.Set(targetLang, translated)
 	}
 
 	return result, nil
 }
 
-// translate translates text using Google Translate free API
+// translate translates text, keeping glossary terms and protected identifiers intact
 func (t *Translator) translate(text, sourceLang, targetLang string) (string, error) {
+	protected, restore := ProtectTerms(text, targetLang)
+	translated, err := t.translateGoogle(protected, sourceLang, targetLang)
+	if err != nil {
+		return text, err
+	}
+	return restore(translated), nil
+}
+
+// translateGoogle translates text using Google Translate free API
+func (t *Translator) translateGoogle(text, sourceLang, targetLang string) (string, error) {
 	// Use Google Translate free API endpoint
 	baseURL := "https://translate.googleapis.com/translate_a/single"
 
@@ -157,31 +146,9 @@ func (t *Translator) translate(text, sourceLang, targetLang string) (string, err
 	return text, fmt.Errorf("could not parse translation response")
 }
 
-// DetectLanguage attempts to detect the language of the text
+// DetectLanguage detects the language of the text, returning "auto" when unsure
 func (t *Translator) DetectLanguage(text string) string {
-	if text == "" {
-		return "en"
-	}
-
-	// Simple heuristic detection based on character ranges
-	// Check for Chinese characters
-	for _, r := range text {
-		if r >= 0x4E00 && r <= 0x9FFF {
-			return "zh"
-		}
-	}
-
-	// Check for Spanish-specific characters or patterns
-	spanishPatterns := []string{"ñ", "¿", "¡", "ó", "á", "é", "í", "ú"}
-	lowerText := strings.ToLower(text)
-	for _, pattern := range spanishPatterns {
-		if strings.Contains(lowerText, pattern) {
-			return "es"
-		}
-	}
-
-	// Default to auto-detect (let Google decide)
-	return "auto"
+	return DetectLanguage(text)
 }
 
 // Service wraps the Translator for dependency injection
@@ -196,10 +163,14 @@ func NewService() *Service {
 	}
 }
 
-// Translate translates text to all supported languages
+// Translate translates text to all enabled languages
 func (s *Service) Translate(text string) (*TranslatedText, error) {
-	sourceLang := s.translator.DetectLanguage(text)
-	return s.translator.TranslateToAll(text, sourceLang)
+	detection := Detect(text)
+	result, err := s.translator.TranslateToAll(text, detection.Source())
+	if result != nil && result.SourceLanguage != "" {
+		result.SourceConfidence = detection.Confidence
+	}
+	return result, err
 }
 
 // TranslateWithSource translates text from a known source language
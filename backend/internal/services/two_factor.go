@@ -0,0 +1,372 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/jwt"
+	"vista-backend/pkg/totp"
+)
+
+var (
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotStarted     = errors.New("two-factor setup was not started")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this role")
+)
+
+// recoveryCodeCount is how many recovery codes a user gets at a time
+const recoveryCodeCount = 10
+
+// TwoFactorPolicy configures TOTP two-factor authentication
+type TwoFactorPolicy struct {
+	Issuer        string            // Shown in authenticator apps
+	RequiredRoles []models.UserRole // Roles that can't log in without two-factor authentication
+}
+
+// Requires returns true if users with the role must use two-factor authentication
+func (p TwoFactorPolicy) Requires(role models.UserRole) bool {
+	for _, r := range p.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorChallenge is returned by Login when the password was right but a
+// second factor is needed. Token is exchanged for a session with
+// VerifyTwoFactor, or, when Enroll is set because the user's role requires
+// two-factor authentication and they haven't set it up, with
+// EnableTwoFactorAtLogin.
+type TwoFactorChallenge struct {
+	Token  string
+	Enroll bool
+}
+
+func (e *TwoFactorChallenge) Error() string {
+	return "two-factor authentication required"
+}
+
+// TwoFactorSetup is what an authenticator app needs to be added
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, to be shown as a QR code
+}
+
+// RequiresTwoFactor returns true if the user's role requires two-factor
+// authentication
+func (as *AuthService) RequiresTwoFactor(user *models.User) bool {
+	return as.twoFactor.Requires(user.Role)
+}
+
+// twoFactorChallenge returns the challenge for a user who passed the password
+// check, or nil if they don't need a second factor
+func (as *AuthService) twoFactorChallenge(user *models.User) (*TwoFactorChallenge, error) {
+	if !user.TwoFactorEnabled() && !as.twoFactor.Requires(user.Role) {
+		return nil, nil
+	}
+	token, err := as.jwtService.GenerateTwoFactorToken(user.ID, user.EmployeeNumber, string(user.Role))
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorChallenge{Token: token, Enroll: !user.TwoFactorEnabled()}, nil
+}
+
+// challengedUser returns the user a second login step token was issued to
+func (as *AuthService) challengedUser(token string) (*models.User, error) {
+	claims, err := as.jwtService.ValidateTwoFactorToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := as.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.CanLogin() {
+		return nil, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code and starts
+// the session. Wrong codes count as failed logins for throttling.
+func (as *AuthService) VerifyTwoFactor(token, code, ipAddress, userAgent string) (*jwt.TokenPair, *models.User, error) {
+	user, err := as.challengedUser(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, nil, ErrTwoFactorNotEnabled
+	}
+	if err := as.CheckLoginThrottle(user.Email, ipAddress); err != nil {
+		return nil, user, err
+	}
+
+	usedRecoveryCode, err := as.verifySecondFactor(user, code, true)
+	if err != nil {
+		return nil, user, err
+	}
+	if usedRecoveryCode {
+		entry := models.NewActivityLog(models.ActivityRecoveryCodeUsed, &user.ID, user.Email, ipAddress, userAgent).
+			WithDetails("Logged in with a recovery code")
+		if err := as.db.Create(entry).Error; err != nil {
+			log.Printf("Failed to log recovery code use for user %d: %v", user.ID, err)
+		}
+	}
+
+	tokens, err := as.StartSession(user, user.Email, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
+
+// BeginTwoFactorSetupAtLogin starts enrollment for a user who has to set up
+// two-factor authentication before they can log in
+func (as *AuthService) BeginTwoFactorSetupAtLogin(token string) (*TwoFactorSetup, error) {
+	user, err := as.challengedUser(token)
+	if err != nil {
+		return nil, err
+	}
+	return as.BeginTwoFactorSetup(user)
+}
+
+// EnableTwoFactorAtLogin finishes enrollment started at login and starts the
+// session. It returns the new recovery codes, which are only shown once.
+func (as *AuthService) EnableTwoFactorAtLogin(token, code, ipAddress, userAgent string) (*jwt.TokenPair, *models.User, []string, error) {
+	user, err := as.challengedUser(token)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := as.CheckLoginThrottle(user.Email, ipAddress); err != nil {
+		return nil, user, nil, err
+	}
+
+	codes, err := as.EnableTwoFactor(user, code)
+	if err != nil {
+		return nil, user, nil, err
+	}
+
+	tokens, err := as.StartSession(user, user.Email, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tokens, user, codes, nil
+}
+
+// BeginTwoFactorSetup generates a new TOTP secret for the user. It's only
+// used at login once EnableTwoFactor confirmed the authenticator works.
+func (as *AuthService) BeginTwoFactorSetup(user *models.User) (*TwoFactorSetup, error) {
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := as.encryption.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := as.db.Model(user).Updates(map[string]interface{}{
+		"totp_secret":       encrypted,
+		"totp_last_counter": 0,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, as.twoFactor.Issuer, user.Email),
+	}, nil
+}
+
+// EnableTwoFactor turns on two-factor authentication once the user entered a
+// code from their authenticator, and returns their recovery codes
+func (as *AuthService) EnableTwoFactor(user *models.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotStarted
+	}
+	if _, err := as.verifySecondFactor(user, code, false); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(user).Update("totp_enabled_at", now).Error; err != nil {
+			return err
+		}
+		user.TOTPEnabledAt = &now
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a
+// TOTP code
+func (as *AuthService) RegenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if _, err := as.verifySecondFactor(user, code, false); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// DisableTwoFactor turns off two-factor authentication after checking a TOTP
+// or recovery code. Users whose role requires it can't turn it off.
+func (as *AuthService) DisableTwoFactor(user *models.User, code string) error {
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if as.twoFactor.Requires(user.Role) {
+		return ErrTwoFactorRequired
+	}
+	if _, err := as.verifySecondFactor(user, code, true); err != nil {
+		return err
+	}
+	return as.db.Transaction(func(tx *gorm.DB) error {
+		return clearTwoFactor(tx, user.ID)
+	})
+}
+
+// ResetTwoFactor removes a user's two-factor authentication, for when they
+// lost their authenticator and recovery codes, and ends their sessions. If
+// their role requires it they set it up again at their next login.
+func (as *AuthService) ResetTwoFactor(userID uint, details string) error {
+	return as.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearTwoFactor(tx, userID); err != nil {
+			return err
+		}
+		return models.EndUserSessions(tx, userID, details)
+	})
+}
+
+// RemainingRecoveryCodes counts the user's unused recovery codes
+func (as *AuthService) RemainingRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := as.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// verifySecondFactor checks a TOTP code, or a recovery code if allowed, and
+// uses it up so it can't be replayed. It returns true if a recovery code was
+// used.
+func (as *AuthService) verifySecondFactor(user *models.User, code string, allowRecoveryCode bool) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		secret, err := as.encryption.Decrypt(user.TOTPSecret)
+		if err != nil {
+			return false, err
+		}
+		counter, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return false, ErrInvalidTwoFactorCode
+		}
+		// Only accept time steps after the last accepted one
+		result := as.db.Model(&models.User{}).
+			Where("id = ? AND totp_last_counter < ?", user.ID, counter).
+			Update("totp_last_counter", counter)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return false, ErrInvalidTwoFactorCode
+		}
+		return false, nil
+	}
+
+	if !allowRecoveryCode {
+		return false, ErrInvalidTwoFactorCode
+	}
+	result := as.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrInvalidTwoFactorCode
+	}
+	return true, nil
+}
+
+// clearTwoFactor removes a user's TOTP secret and recovery codes
+func clearTwoFactor(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":       "",
+		"totp_enabled_at":   nil,
+		"totp_last_counter": 0,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and returns new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a random code like "4f2a9-c07be"
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(b)
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode returns the stored form of a recovery code. Dashes, spaces
+// and case are ignored, so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
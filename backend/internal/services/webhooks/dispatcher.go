@@ -0,0 +1,139 @@
+package webhooks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/worker"
+	"vista-backend/pkg/crypto"
+)
+
+// responseBodyLimit caps how much of a subscriber's response is kept
+const responseBodyLimit = 2000
+
+// Dispatcher sends queued webhook deliveries in the background, retrying
+// failures with exponential backoff
+type Dispatcher struct {
+	*worker.Worker[models.WebhookDelivery]
+	db            *gorm.DB
+	encryptionSvc *crypto.EncryptionService
+	client        *http.Client
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(db *gorm.DB, encryptionSvc *crypto.EncryptionService) *Dispatcher {
+	d := &Dispatcher{
+		db:            db,
+		encryptionSvc: encryptionSvc,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+			// Redirects would turn the POST into a GET; treat them as failures
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	d.Worker = worker.New(db, worker.Queue[models.WebhookDelivery]{
+		Name:         "Webhook dispatcher",
+		Pending:      models.WebhookDeliveryPending,
+		Running:      models.WebhookDeliverySending,
+		DueColumn:    "next_attempt_at",
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+		Process:      d.send,
+	})
+	return d
+}
+
+// send posts one delivery and records the outcome
+func (d *Dispatcher) send(delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	var sub models.WebhookSubscription
+	if err := d.db.First(&sub, delivery.SubscriptionID).Error; err != nil {
+		// Deleted subscriptions can't be delivered to, so don't retry
+		d.finish(delivery, models.WebhookDeliveryFailed, map[string]interface{}{
+			"last_error": "subscription not found",
+		})
+		return
+	}
+
+	started := time.Now()
+	status, body, err := d.post(&sub, delivery)
+	updates := map[string]interface{}{
+		"response_status": status,
+		"response_body":   body,
+		"duration_ms":     time.Since(started).Milliseconds(),
+	}
+
+	if err == nil {
+		updates["last_error"] = ""
+		updates["delivered_at"] = time.Now()
+		d.finish(delivery, models.WebhookDeliveryDelivered, updates)
+		return
+	}
+
+	updates["last_error"] = err.Error()
+	if !delivery.CanRetry() || !sub.IsActive {
+		log.Printf("Webhook delivery %d to %s failed permanently: %v", delivery.ID, sub.URL, err)
+		d.finish(delivery, models.WebhookDeliveryFailed, updates)
+		return
+	}
+
+	delay := worker.Backoff(delivery.Attempts, time.Minute, 6*time.Hour)
+	log.Printf("Webhook delivery %d to %s failed (attempt %d/%d), retrying in %s: %v",
+		delivery.ID, sub.URL, delivery.Attempts, delivery.MaxAttempts, delay, err)
+	updates["next_attempt_at"] = time.Now().Add(delay)
+	d.finish(delivery, models.WebhookDeliveryPending, updates)
+}
+
+// post signs and sends the payload, returning the response status and
+// (truncated) body. Non-2xx responses are errors.
+func (d *Dispatcher) post(sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	secret, err := d.encryptionSvc.Decrypt(sub.Secret)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to decrypt signing secret: %w", err)
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "IRIS-Vista-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("subscriber responded with %s", resp.Status)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+func (d *Dispatcher) finish(delivery *models.WebhookDelivery, status models.WebhookDeliveryStatus, updates map[string]interface{}) {
+	updates["status"] = status
+	updates["attempts"] = delivery.Attempts
+	if err := d.db.Model(delivery).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"vista-backend/internal/models"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Vista-Event"
+	HeaderEventID   = "X-Vista-Event-Id"
+	HeaderDelivery  = "X-Vista-Delivery"
+	HeaderTimestamp = "X-Vista-Timestamp"
+	HeaderSignature = "X-Vista-Signature"
+)
+
+// Envelope is the JSON body of every delivery
+type Envelope struct {
+	ID        string              `json:"id"`
+	Event     models.WebhookEvent `json:"event"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      interface{}         `json:"data"`
+}
+
+// RequestEventData is the data of request.* events
+type RequestEventData struct {
+	Request        RequestPayload       `json:"request"`
+	PreviousStatus models.RequestStatus `json:"previous_status,omitempty"`
+}
+
+// RequestPayload is a purchase request as sent to subscribers
+type RequestPayload struct {
+	ID                uint                 `json:"id"`
+	RequestNumber     string               `json:"request_number"`
+	CompanyCode       string               `json:"company_code"`
+	Status            models.RequestStatus `json:"status"`
+	Urgency           models.Urgency       `json:"urgency"`
+	Justification     string               `json:"justification"`
+	Requester         PersonPayload        `json:"requester"`
+	Items             []ItemPayload        `json:"items"`
+	TotalEstimated    *float64             `json:"total_estimated,omitempty"`
+	Currency          string               `json:"currency"`
+	PONumber          *string              `json:"po_number,omitempty"`
+	OrderNumber       string               `json:"order_number,omitempty"`
+	RejectionReason   string               `json:"rejection_reason,omitempty"`
+	InfoRequestNote   string               `json:"info_request_note,omitempty"`
+	PurchaseNotes     string               `json:"purchase_notes,omitempty"`
+	DeliveryNotes     string               `json:"delivery_notes,omitempty"`
+	CancellationNotes string               `json:"cancellation_notes,omitempty"`
+	ApprovedByID      *uint                `json:"approved_by_id,omitempty"`
+	ApprovedAt        *time.Time           `json:"approved_at,omitempty"`
+	RejectedByID      *uint                `json:"rejected_by_id,omitempty"`
+	RejectedAt        *time.Time           `json:"rejected_at,omitempty"`
+	PurchasedByID     *uint                `json:"purchased_by_id,omitempty"`
+	PurchasedAt       *time.Time           `json:"purchased_at,omitempty"`
+	DeliveredByID     *uint                `json:"delivered_by_id,omitempty"`
+	DeliveredAt       *time.Time           `json:"delivered_at,omitempty"`
+	CancelledByID     *uint                `json:"cancelled_by_id,omitempty"`
+	CancelledAt       *time.Time           `json:"cancelled_at,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+}
+
+// PersonPayload identifies a user to external systems
+type PersonPayload struct {
+	ID             uint   `json:"id"`
+	EmployeeNumber string `json:"employee_number"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	CompanyCode    string `json:"company_code,omitempty"`
+	CostCenter     string `json:"cost_center,omitempty"`
+	Department     string `json:"department,omitempty"`
+}
+
+// ItemPayload is one product of a request
+type ItemPayload struct {
+	ProductTitle   string     `json:"product_title"`
+	URL            string     `json:"url,omitempty"`
+	Quantity       int        `json:"quantity"`
+	EstimatedPrice *float64   `json:"estimated_price,omitempty"`
+	Currency       string     `json:"currency"`
+	AmazonASIN     string     `json:"amazon_asin,omitempty"`
+	IsPurchased    bool       `json:"is_purchased"`
+	PurchasedAt    *time.Time `json:"purchased_at,omitempty"`
+}
+
+// NewRequestPayload converts a request loaded with Requester and Items
+func NewRequestPayload(request *models.PurchaseRequest) RequestPayload {
+	payload := RequestPayload{
+		ID:            request.ID,
+		RequestNumber: request.RequestNumber,
+		CompanyCode:   request.CompanyCode,
+		Status:        request.Status,
+		Urgency:       request.Urgency,
+		Justification: request.Justification,
+		Requester: PersonPayload{
+			ID:             request.Requester.ID,
+			EmployeeNumber: request.Requester.EmployeeNumber,
+			Name:           request.Requester.Name,
+			Email:          request.Requester.Email,
+			CompanyCode:    request.Requester.CompanyCode,
+			CostCenter:     request.Requester.CostCenter,
+			Department:     request.Requester.Department,
+		},
+		Items:             make([]ItemPayload, 0, len(request.Items)),
+		TotalEstimated:    request.TotalEstimated,
+		Currency:          request.Currency,
+		PONumber:          request.PONumber,
+		OrderNumber:       request.OrderNumber,
+		RejectionReason:   request.RejectionReason,
+		InfoRequestNote:   request.InfoRequestNote,
+		PurchaseNotes:     request.PurchaseNotes,
+		DeliveryNotes:     request.DeliveryNotes,
+		CancellationNotes: request.CancellationNotes,
+		ApprovedByID:      request.ApprovedByID,
+		ApprovedAt:        request.ApprovedAt,
+		RejectedByID:      request.RejectedByID,
+		RejectedAt:        request.RejectedAt,
+		PurchasedByID:     request.PurchasedByID,
+		PurchasedAt:       request.PurchasedAt,
+		DeliveredByID:     request.DeliveredByID,
+		DeliveredAt:       request.DeliveredAt,
+		CancelledByID:     request.CancelledByID,
+		CancelledAt:       request.CancelledAt,
+		CreatedAt:         request.CreatedAt,
+		UpdatedAt:         request.UpdatedAt,
+	}
+
+	for _, item := range request.Items {
+		payload.Items = append(payload.Items, ItemPayload{
+			ProductTitle:   item.ProductTitle,
+			URL:            item.URL,
+			Quantity:       item.Quantity,
+			EstimatedPrice: item.EstimatedPrice,
+			Currency:       item.Currency,
+			AmazonASIN:     item.AmazonASIN,
+			IsPurchased:    item.IsPurchased,
+			PurchasedAt:    item.PurchasedAt,
+		})
+	}
+	// Legacy single-product requests have no items
+	if len(request.Items) == 0 && request.ProductTitle != "" {
+		payload.Items = append(payload.Items, ItemPayload{
+			ProductTitle:   request.ProductTitle,
+			URL:            request.URL,
+			Quantity:       request.Quantity,
+			EstimatedPrice: request.EstimatedPrice,
+			Currency:       request.Currency,
+			IsPurchased:    request.PurchasedAt != nil,
+			PurchasedAt:    request.PurchasedAt,
+		})
+	}
+	return payload
+}
+
+// Sign returns the X-Vista-Signature value for a body sent at timestamp:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// newEventID returns a unique ID shared by every delivery of one event
+func newEventID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// PublishRequestStatus queues webhook deliveries for a request's status
+// change. A request created with an empty previous status sends
+// request.created, plus the status event if it was created already approved.
+func PublishRequestStatus(db *gorm.DB, requestID uint, previous models.RequestStatus) {
+	var request models.PurchaseRequest
+	if err := db.Preload("Requester").Preload("Items").First(&request, requestID).Error; err != nil {
+		log.Printf("Failed to load request %d for webhooks: %v", requestID, err)
+		return
+	}
+	if request.Status == previous {
+		return
+	}
+
+	if previous == "" {
+		publishRequest(db, models.WebhookRequestCreated, &request, previous)
+		if request.Status == models.StatusPending {
+			return
+		}
+	}
+	if event, ok := models.WebhookEventForStatus(request.Status); ok {
+		publishRequest(db, event, &request, previous)
+	}
+}
+
+// PublishRequestEvent queues deliveries of a specific event for a request,
+// for transitions whose status doesn't identify the event
+func PublishRequestEvent(db *gorm.DB, event models.WebhookEvent, requestID uint, previous models.RequestStatus) {
+	var request models.PurchaseRequest
+	if err := db.Preload("Requester").Preload("Items").First(&request, requestID).Error; err != nil {
+		log.Printf("Failed to load request %d for webhooks: %v", requestID, err)
+		return
+	}
+	publishRequest(db, event, &request, previous)
+}
+
+func publishRequest(db *gorm.DB, event models.WebhookEvent, request *models.PurchaseRequest, previous models.RequestStatus) {
+	data := RequestEventData{Request: NewRequestPayload(request), PreviousStatus: previous}
+	if err := Publish(db, event, &request.ID, data); err != nil {
+		log.Printf("Failed to queue %s webhooks for request %d: %v", event, request.ID, err)
+	}
+}
+
+// Publish queues one delivery of an event for every active subscription to it
+func Publish(db *gorm.DB, event models.WebhookEvent, requestID *uint, data interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	var matching []models.WebhookSubscription
+	for _, sub := range subscriptions {
+		if sub.Subscribes(event) {
+			matching = append(matching, sub)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+
+	envelope := Envelope{ID: newEventID(), Event: event, CreatedAt: time.Now().UTC(), Data: data}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(matching))
+	for _, sub := range matching {
+		deliveries = append(deliveries, newDelivery(sub.ID, envelope.ID, event, requestID, string(payload)))
+	}
+	return db.Create(&deliveries).Error
+}
+
+// Ping queues a webhook.ping delivery to one subscription
+func Ping(db *gorm.DB, sub *models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	envelope := Envelope{
+		ID:        newEventID(),
+		Event:     models.WebhookPing,
+		CreatedAt: time.Now().UTC(),
+		Data: map[string]interface{}{
+			"subscription_id": sub.ID,
+			"events":          sub.Events,
+		},
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := newDelivery(sub.ID, envelope.ID, models.WebhookPing, nil, string(payload))
+	if err := db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ErrStillPending is returned when redelivering a delivery that hasn't finished
+var ErrStillPending = errors.New("delivery is still pending")
+
+// Redeliver queues a new delivery with the same payload as an earlier one.
+// The original is kept in the log.
+func Redeliver(db *gorm.DB, id uint) (*models.WebhookDelivery, error) {
+	var original models.WebhookDelivery
+	if err := db.First(&original, id).Error; err != nil {
+		return nil, err
+	}
+	if original.Status == models.WebhookDeliveryPending || original.Status == models.WebhookDeliverySending {
+		return nil, ErrStillPending
+	}
+
+	delivery := newDelivery(original.SubscriptionID, original.EventID, original.Event, original.RequestID, original.Payload)
+	delivery.RedeliveryOfID = &original.ID
+	if err := db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func newDelivery(subscriptionID uint, eventID string, event models.WebhookEvent, requestID *uint, payload string) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		Event:          event,
+		RequestID:      requestID,
+		Payload:        payload,
+		Status:         models.WebhookDeliveryPending,
+		MaxAttempts:    10,
+		NextAttemptAt:  time.Now(),
+	}
+}
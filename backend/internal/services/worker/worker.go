@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Queue describes a table of rows that a Worker processes. Each row has a
+// status column and a column holding the time it becomes due.
+type Queue[T any] struct {
+	Name         string        // used in log messages, e.g. "Email outbox"
+	Pending      interface{}   // status of rows waiting to run
+	Running      interface{}   // status a row is claimed into while it runs
+	DueColumn    string        // time column a pending row becomes due at
+	PollInterval time.Duration // how often to look for due rows
+	BatchSize    int           // how many due rows to fetch per poll
+
+	// Process runs a claimed row and records the outcome, moving it out of
+	// the Running status
+	Process func(row *T)
+}
+
+// Worker polls a Queue in the background, claiming due rows one at a time so
+// several workers can share a table
+type Worker[T any] struct {
+	db    *gorm.DB
+	queue Queue[T]
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New creates a worker for queue
+func New[T any](db *gorm.DB, queue Queue[T]) *Worker[T] {
+	return &Worker[T]{
+		db:    db,
+		queue: queue,
+		stop:  make(chan struct{}),
+	}
+}
+
+// Start recovers rows interrupted by a previous shutdown and starts polling
+// for due rows
+func (w *Worker[T]) Start() {
+	// A row left running was interrupted mid-flight; make it runnable again.
+	// For side effects such as sending, a retry risks a duplicate, which
+	// beats losing the row.
+	w.db.Model(new(T)).
+		Where("status = ?", w.queue.Running).
+		Updates(map[string]interface{}{
+			"status":          w.queue.Pending,
+			w.queue.DueColumn: time.Now(),
+		})
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.queue.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			w.RunOnce()
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("%s started", w.queue.Name)
+}
+
+// Stop signals the worker to exit and waits for the current batch to finish
+func (w *Worker[T]) Stop() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+// RunOnce processes one batch of due rows and returns how many were processed
+func (w *Worker[T]) RunOnce() int {
+	var rows []T
+	if err := w.db.
+		Where("status = ? AND "+w.queue.DueColumn+" <= ?", w.queue.Pending, time.Now()).
+		Order(w.queue.DueColumn + " ASC, id ASC").
+		Limit(w.queue.BatchSize).
+		Find(&rows).Error; err != nil {
+		log.Printf("%s: failed to fetch due rows: %v", w.queue.Name, err)
+		return 0
+	}
+
+	processed := 0
+	for i := range rows {
+		if !w.claim(&rows[i]) {
+			continue
+		}
+		w.queue.Process(&rows[i])
+		processed++
+	}
+	return processed
+}
+
+// claim marks a row as running, returning false if another worker took it first
+func (w *Worker[T]) claim(row *T) bool {
+	result := w.db.Model(row).
+		Where("status = ?", w.queue.Pending).
+		Update("status", w.queue.Running)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// Backoff returns the delay before retrying after the given number of
+// attempts: base doubled for each attempt after the first, capped at limit
+func Backoff(attempts int, base, limit time.Duration) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type testJob struct {
+	ID        uint `gorm:"primaryKey"`
+	Status    string
+	NextRunAt time.Time
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&testJob{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newTestWorker(db *gorm.DB, process func(*testJob)) *Worker[testJob] {
+	return New(db, Queue[testJob]{
+		Name:         "Test queue",
+		Pending:      "pending",
+		Running:      "running",
+		DueColumn:    "next_run_at",
+		PollInterval: time.Hour,
+		BatchSize:    10,
+		Process:      process,
+	})
+}
+
+func TestRunOnceProcessesDueRows(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	db.Create(&[]testJob{
+		{Status: "pending", NextRunAt: now.Add(-time.Minute)},
+		{Status: "pending", NextRunAt: now.Add(time.Hour)},
+		{Status: "done", NextRunAt: now.Add(-time.Minute)},
+	})
+
+	var seen []uint
+	w := newTestWorker(db, func(job *testJob) {
+		var stored testJob
+		db.First(&stored, job.ID)
+		if stored.Status != "running" {
+			t.Errorf("job %d is %s while processing, want running", job.ID, stored.Status)
+		}
+		seen = append(seen, job.ID)
+		db.Model(job).Update("status", "done")
+	})
+
+	if n := w.RunOnce(); n != 1 || len(seen) != 1 || seen[0] != 1 {
+		t.Fatalf("RunOnce processed %d (%v), want only the due job 1", n, seen)
+	}
+	if n := w.RunOnce(); n != 0 {
+		t.Errorf("second RunOnce processed %d, want 0", n)
+	}
+}
+
+func TestClaimSkipsRowsTakenByAnotherWorker(t *testing.T) {
+	db := newTestDB(t)
+	job := testJob{Status: "pending", NextRunAt: time.Now()}
+	db.Create(&job)
+
+	w := newTestWorker(db, func(*testJob) {})
+	if !w.claim(&job) {
+		t.Fatal("first claim failed")
+	}
+	stale := testJob{ID: job.ID, Status: "pending"}
+	if w.claim(&stale) {
+		t.Error("claimed a job that was already running")
+	}
+}
+
+func TestStartRecoversInterruptedRows(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&testJob{Status: "running", NextRunAt: time.Now().Add(time.Hour)})
+
+	processed := make(chan uint, 1)
+	w := newTestWorker(db, func(job *testJob) {
+		db.Model(job).Update("status", "done")
+		processed <- job.ID
+	})
+	w.Start()
+	defer w.Stop()
+
+	select {
+	case id := <-processed:
+		if id != 1 {
+			t.Errorf("processed job %d, want 1", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupted job was not picked up again")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts, time.Minute, time.Hour); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
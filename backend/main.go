@@ -4,24 +4,35 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"vista-backend/config"
 	"vista-backend/internal/handlers"
 	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
 	"vista-backend/internal/services"
 	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/chat"
+	"vista-backend/internal/services/directory"
 	"vista-backend/internal/services/email"
+	"vista-backend/internal/services/events"
 	"vista-backend/internal/services/metadata"
+	"vista-backend/internal/services/notifications"
+	"vista-backend/internal/services/translation"
+	"vista-backend/internal/services/webhooks"
 	"vista-backend/migrations"
 	"vista-backend/pkg/crypto"
+	"vista-backend/pkg/i18n"
 	"vista-backend/pkg/jwt"
+	"vista-backend/pkg/oidc"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	i18n.Configure(cfg.Language.Enabled, cfg.Language.Default)
 
 	// Initialize database
 	db, err := config.InitDatabase(cfg)
@@ -46,18 +57,130 @@ func main() {
 		log.Fatalf("Failed to initialize encryption service: %v", err)
 	}
 
-	authService := services.NewAuthService(db, jwtService)
+	twoFactorRoles := make([]models.UserRole, 0, len(cfg.TwoFactor.RequiredRoles))
+	for _, role := range cfg.TwoFactor.RequiredRoles {
+		if !models.RoleExists(db, models.UserRole(role)) {
+			log.Fatalf("Invalid role in TWO_FACTOR_REQUIRED_ROLES: %s", role)
+		}
+		twoFactorRoles = append(twoFactorRoles, models.UserRole(role))
+	}
+	authService := services.NewAuthService(db, jwtService, encryptionService, services.LoginPolicy{
+		MaxFailures:     cfg.Login.MaxFailures,
+		IPMaxFailures:   cfg.Login.IPMaxFailures,
+		LockoutDuration: cfg.Login.LockoutDuration,
+	}, services.TwoFactorPolicy{
+		Issuer:        cfg.TwoFactor.Issuer,
+		RequiredRoles: twoFactorRoles,
+	})
+
+	// Single sign-on is optional; without an issuer the login page has no SSO button
+	var ssoProvider *oidc.Provider
+	if cfg.SSO.Issuer != "" {
+		if cfg.SSO.ClientID == "" {
+			log.Fatalf("OIDC_CLIENT_ID is required with OIDC_ISSUER")
+		}
+		ssoProvider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.SSO.Issuer,
+			ClientID:     cfg.SSO.ClientID,
+			ClientSecret: cfg.SSO.ClientSecret,
+			RedirectURL:  cfg.SSO.RedirectURL,
+			Scopes:       cfg.SSO.Scopes,
+		})
+	}
+	ssoPolicy := services.SSOPolicy{
+		ProviderName:        cfg.SSO.ProviderName,
+		EmployeeNumberClaim: cfg.SSO.EmployeeNumberClaim,
+		DepartmentClaim:     cfg.SSO.DepartmentClaim,
+		GroupsClaim:         cfg.SSO.GroupsClaim,
+		AutoApprove:         cfg.SSO.AutoApprove,
+	}
+	for _, pair := range cfg.SSO.RoleGroups {
+		role, group, ok := strings.Cut(pair, "=")
+		if !ok || group == "" || !models.RoleExists(db, models.UserRole(role)) {
+			log.Fatalf("Invalid entry in OIDC_ROLE_GROUPS (expected role=group): %s", pair)
+		}
+		ssoPolicy.RoleGroups = append(ssoPolicy.RoleGroups, services.RoleGroup{Group: group, Role: models.UserRole(role)})
+	}
+	ssoService := services.NewSSOService(db, authService, ssoProvider, ssoPolicy)
+
+	// Directory login and sync are optional too; directory users log in with
+	// their directory password and are kept in line with it
+	var directoryClient *directory.Client
+	if cfg.LDAP.URL != "" {
+		if cfg.LDAP.BaseDN == "" {
+			log.Fatalf("LDAP_BASE_DN is required with LDAP_URL")
+		}
+		attributes := directory.DefaultAttributes()
+		for _, pair := range cfg.LDAP.Attributes {
+			field, attribute, _ := strings.Cut(pair, "=")
+			if err := attributes.Set(field, attribute); err != nil {
+				log.Fatalf("Invalid entry in LDAP_ATTRIBUTES (expected field=attribute): %s", pair)
+			}
+		}
+		directoryClient = directory.NewClient(directory.Config{
+			URL:          cfg.LDAP.URL,
+			StartTLS:     cfg.LDAP.StartTLS,
+			BindDN:       cfg.LDAP.BindDN,
+			BindPassword: cfg.LDAP.BindPassword,
+			BaseDN:       cfg.LDAP.BaseDN,
+			UserFilter:   cfg.LDAP.UserFilter,
+			Attributes:   attributes,
+		})
+	}
+	directoryService := directory.NewService(db, directoryClient, cfg.LDAP.SyncInterval)
+	authService.WithDirectory(directoryService)
+	directoryService.Start()
+	defer directoryService.Stop()
+
 	amazonService := amazon.NewAutomationService()
 	metadataService := metadata.NewService()
-	emailService := email.NewEmailService(db)
+	emailService := email.NewEmailService(db).WithEncryption(encryptionService)
+
+	// Load translation glossary and start background translation worker
+	if err := translation.LoadGlossary(db); err != nil {
+		log.Printf("Failed to load translation glossary: %v", err)
+	}
+	translationQueue := translation.NewJobQueue(db)
+	translationQueue.Start()
+	defer translationQueue.Stop()
+
+	emailOutbox := email.NewOutbox(db, encryptionService)
+	emailOutbox.Start()
+	defer emailOutbox.Stop()
+
+	chatDispatcher := chat.NewDispatcher(db, encryptionService, cfg.Server.AppURL)
+	chatDispatcher.Start()
+	defer chatDispatcher.Stop()
+
+	reminderScheduler := notifications.NewReminderScheduler(db)
+	reminderScheduler.Start()
+	defer reminderScheduler.Stop()
+
+	webhookDispatcher := webhooks.NewDispatcher(db, encryptionService)
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+
+	digestScheduler := notifications.NewDigestScheduler(db)
+	digestScheduler.Start()
+	defer digestScheduler.Stop()
+
+	// Side effects of request changes subscribe to domain events
+	eventBus := events.NewBus()
+	events.SubscribeTranslations(eventBus, db)
+	events.SubscribeAudit(eventBus)
+	events.SubscribeWebhooks(eventBus, db)
+	events.SubscribeNotifications(eventBus, db)
+	events.SubscribeCartAutomation(eventBus, db, amazonService, encryptionService)
+	defer eventBus.Wait()
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService, db)
+	authHandler := handlers.NewAuthHandler(authService, services.NewPasswordResetService(db, emailService, cfg.Server.AppURL), ssoService, db)
 	userHandler := handlers.NewUserHandler(db)
+	directoryHandler := handlers.NewDirectoryHandler(db, directoryService)
 	productHandler := handlers.NewProductHandler(db)
-	requestHandler := handlers.NewRequestHandler(db)
-	approvalHandler := handlers.NewApprovalHandler(db, amazonService, encryptionService)
-	adminHandler := handlers.NewAdminHandler(db, encryptionService, amazonService)
+	requestHandler := handlers.NewRequestHandler(db, eventBus)
+	approvalHandler := handlers.NewApprovalHandler(db, eventBus)
+	adminHandler := handlers.NewAdminHandler(db, eventBus, encryptionService, amazonService)
 	purchaseConfigHandler := handlers.NewPurchaseConfigHandler(db, metadataService)
 	emailConfigHandler := handlers.NewEmailConfigHandler(db, emailService, encryptionService)
 	notificationHandler := handlers.NewNotificationHandler(db)
@@ -65,9 +188,18 @@ func main() {
 	cartHandler := handlers.NewCartHandler(db, metadataService)
 	activityLogHandler := handlers.NewActivityLogHandler(db)
 	aiSummaryHandler := handlers.NewAISummaryHandler()
-
-	// Setup router
-	router := gin.Default()
+	glossaryHandler := handlers.NewGlossaryHandler(db)
+	emailOutboxHandler := handlers.NewEmailOutboxHandler(db)
+	emailTemplateHandler := handlers.NewEmailTemplateHandler(db)
+	webhookHandler := handlers.NewWebhookHandler(db, encryptionService)
+	chatChannelHandler := handlers.NewChatChannelHandler(db, encryptionService, cfg.Server.AppURL)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(db, authService)
+	roleHandler := handlers.NewRoleHandler(db)
+	companyHandler := handlers.NewCompanyHandler(db)
+
+	// Setup router. Logging and recovery are our own middleware below; gin's
+	// default logger would print query strings, including stream access tokens.
+	router := gin.New()
 
 	// CORS middleware
 	corsConfig := middleware.DefaultCORSConfig()
@@ -81,26 +213,37 @@ func main() {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Enabled languages (public, needed before login)
+		v1.GET("/languages", handlers.ListLanguages)
+
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+			auth.POST("/2fa/setup", authHandler.BeginTwoFactorSetupAtLogin)
+			auth.POST("/2fa/enable", authHandler.EnableTwoFactorAtLogin)
+			auth.GET("/sso", authHandler.GetSSOConfig)
+			auth.POST("/sso/start", authHandler.StartSSOLogin)
+			auth.POST("/sso/callback", authHandler.CompleteSSOLogin)
 		}
 
 		// Auth routes (protected)
 		authProtected := v1.Group("/auth")
-		authProtected.Use(middleware.Auth(jwtService))
+		authProtected.Use(middleware.Auth(jwtService, authService))
 		{
 			authProtected.POST("/logout", authHandler.Logout)
 			authProtected.GET("/me", authHandler.Me)
 		}
 
-		// User routes (admin only)
+		// User routes
 		users := v1.Group("/users")
-		users.Use(middleware.Auth(jwtService))
-		users.Use(middleware.RequireAdmin())
+		users.Use(middleware.Auth(jwtService, authService))
+		users.Use(middleware.RequirePermission(models.PermUsersManage))
 		{
 			users.GET("", userHandler.ListUsers)
 			users.GET("/pending", userHandler.ListPendingUsers)
@@ -113,28 +256,43 @@ func main() {
 			users.PATCH("/:id/toggle", userHandler.ToggleUserStatus)
 			users.POST("/:id/approve", userHandler.ApproveUser)
 			users.POST("/:id/reject", userHandler.RejectUser)
+			users.POST("/:id/unlock", userHandler.UnlockUser)
+			users.DELETE("/:id/2fa", authHandler.ResetUserTwoFactor)
 		}
 
 		// User self-service routes
 		profile := v1.Group("/profile")
-		profile.Use(middleware.Auth(jwtService))
+		profile.Use(middleware.Auth(jwtService, authService))
 		{
 			profile.PUT("/password", userHandler.ChangePassword)
+			profile.PUT("/language", userHandler.UpdateLanguage)
+			profile.GET("/2fa", authHandler.GetTwoFactorStatus)
+			profile.POST("/2fa/setup", authHandler.BeginTwoFactorSetup)
+			profile.POST("/2fa/enable", authHandler.EnableTwoFactor)
+			profile.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+			profile.POST("/2fa/disable", authHandler.DisableTwoFactor)
+		}
+
+		// Roles users can have (all authenticated users, for showing them)
+		rolesList := v1.Group("/roles")
+		rolesList.Use(middleware.Auth(jwtService, authService))
+		{
+			rolesList.GET("", roleHandler.ListRoles)
 		}
 
 		// Product routes (all authenticated users)
 		products := v1.Group("/products")
-		products.Use(middleware.Auth(jwtService))
+		products.Use(middleware.Auth(jwtService, authService))
 		{
 			products.GET("", productHandler.ListProducts)
 			products.GET("/categories", productHandler.GetCategories)
 			products.GET("/:id", productHandler.GetProduct)
 		}
 
-		// Product management routes (admin/purchase_admin/supply chain)
+		// Product management routes
 		productsMgmt := v1.Group("/products")
-		productsMgmt.Use(middleware.Auth(jwtService))
-		productsMgmt.Use(middleware.RequireInventoryAccess())
+		productsMgmt.Use(middleware.Auth(jwtService, authService))
+		productsMgmt.Use(middleware.RequirePermission(models.PermProductsManage))
 		{
 			productsMgmt.POST("", productHandler.CreateProduct)
 			productsMgmt.POST("/bulk-import", productHandler.BulkImportProducts)
@@ -145,7 +303,7 @@ func main() {
 
 		// Purchase request routes (all authenticated users)
 		requests := v1.Group("/purchase-requests")
-		requests.Use(middleware.Auth(jwtService))
+		requests.Use(middleware.Auth(jwtService, authService))
 		{
 			requests.GET("/config", purchaseConfigHandler.GetPublicConfig)
 			requests.POST("/extract-metadata", requestHandler.ExtractMetadata)
@@ -153,12 +311,13 @@ func main() {
 			requests.GET("/my", requestHandler.GetMyRequests)
 			requests.GET("/:id", requestHandler.GetRequest)
 			requests.PUT("/:id", requestHandler.UpdateRequest)
+			requests.POST("/:id/respond", requestHandler.RespondToInfoRequest)
 			requests.DELETE("/:id", requestHandler.CancelRequest)
 		}
 
 		// Cart routes (all authenticated users)
 		cart := v1.Group("/cart")
-		cart.Use(middleware.Auth(jwtService))
+		cart.Use(middleware.Auth(jwtService, authService))
 		{
 			cart.GET("", cartHandler.GetCart)
 			cart.GET("/count", cartHandler.GetCartCount)
@@ -168,46 +327,45 @@ func main() {
 			cart.DELETE("", cartHandler.ClearCart)
 		}
 
-		// All requests route (for admin/gm/scm)
+		// All requests route (everyone's requests with requests.view_all)
 		allRequests := v1.Group("/requests")
-		allRequests.Use(middleware.Auth(jwtService))
-		allRequests.Use(middleware.CanViewAllRequests())
+		allRequests.Use(middleware.Auth(jwtService, authService))
 		{
 			allRequests.GET("", requestHandler.ListRequests)
 		}
 
-		// Approval routes - View (GM + Admin can view)
+		// Approval routes - View
 		approvalsView := v1.Group("/approvals")
-		approvalsView.Use(middleware.Auth(jwtService))
-		approvalsView.Use(middleware.RequireCanViewApprovals())
+		approvalsView.Use(middleware.Auth(jwtService, authService))
+		approvalsView.Use(middleware.RequirePermission(models.PermApprovalsView))
 		{
 			approvalsView.GET("", approvalHandler.ListPendingApprovals)
 			approvalsView.GET("/stats", approvalHandler.GetApprovalStats)
 			approvalsView.GET("/:id", approvalHandler.GetApprovalDetails)
 		}
 
-		// Approval routes - Actions (Only GM can approve/reject)
+		// Approval routes - Actions
 		approvalsAction := v1.Group("/approvals")
-		approvalsAction.Use(middleware.Auth(jwtService))
-		approvalsAction.Use(middleware.RequireApprover())
+		approvalsAction.Use(middleware.Auth(jwtService, authService))
+		approvalsAction.Use(middleware.RequirePermission(models.PermApprovalsDecide))
 		{
 			approvalsAction.POST("/:id/approve", approvalHandler.ApproveRequest)
 			approvalsAction.POST("/:id/reject", approvalHandler.RejectRequest)
 			approvalsAction.POST("/:id/request-info", approvalHandler.RequestInfo)
 		}
 
-		// AI Summary route (GM can generate summaries)
+		// AI Summary route (for whoever reviews approvals)
 		aiRoutes := v1.Group("/ai")
-		aiRoutes.Use(middleware.Auth(jwtService))
-		aiRoutes.Use(middleware.RequireCanViewApprovals())
+		aiRoutes.Use(middleware.Auth(jwtService, authService))
+		aiRoutes.Use(middleware.RequirePermission(models.PermApprovalsView))
 		{
 			aiRoutes.POST("/generate-summary", aiSummaryHandler.GenerateSummary)
 		}
 
-		// Admin routes - System administration (Admin only)
+		// Admin routes - System administration
 		admin := v1.Group("/admin")
-		admin.Use(middleware.Auth(jwtService))
-		admin.Use(middleware.RequireAdmin())
+		admin.Use(middleware.Auth(jwtService, authService))
+		admin.Use(middleware.RequirePermission(models.PermSettingsManage))
 		{
 			admin.GET("/dashboard", adminHandler.GetDashboardStats)
 
@@ -216,12 +374,62 @@ func main() {
 			admin.PUT("/amazon/config", adminHandler.SaveAmazonConfig)
 			admin.POST("/amazon/test", adminHandler.TestAmazonConnection)
 			admin.GET("/amazon/session", adminHandler.GetAmazonSessionStatus)
+
+			// Translation glossary
+			admin.POST("/catalog/retranslate", productHandler.RetranslateCatalog)
+			admin.GET("/glossary", glossaryHandler.ListTerms)
+			admin.POST("/glossary", glossaryHandler.CreateTerm)
+			admin.POST("/glossary/preview", glossaryHandler.PreviewTerms)
+			admin.PUT("/glossary/:id", glossaryHandler.UpdateTerm)
+			admin.DELETE("/glossary/:id", glossaryHandler.DeleteTerm)
+
+			// Directory (LDAP) sync
+			admin.GET("/directory", directoryHandler.GetDirectoryStatus)
+			admin.POST("/directory/sync", directoryHandler.SyncDirectory)
+			admin.GET("/directory/syncs", directoryHandler.ListSyncRuns)
+			admin.GET("/directory/syncs/:id", directoryHandler.GetSyncRun)
+		}
+
+		// Service accounts and their API keys
+		serviceAccounts := v1.Group("/admin")
+		serviceAccounts.Use(middleware.Auth(jwtService, authService))
+		serviceAccounts.Use(middleware.RequirePermission(models.PermUsersManage))
+		{
+			serviceAccounts.GET("/api-scopes", serviceAccountHandler.GetAPIScopes)
+			serviceAccounts.GET("/service-accounts", serviceAccountHandler.ListServiceAccounts)
+			serviceAccounts.POST("/service-accounts", serviceAccountHandler.CreateServiceAccount)
+			serviceAccounts.PUT("/service-accounts/:id", serviceAccountHandler.UpdateServiceAccount)
+			serviceAccounts.DELETE("/service-accounts/:id", serviceAccountHandler.DeleteServiceAccount)
+			serviceAccounts.POST("/service-accounts/:id/keys", serviceAccountHandler.CreateAPIKey)
+			serviceAccounts.DELETE("/service-accounts/:id/keys/:key_id", serviceAccountHandler.RevokeAPIKey)
+		}
+
+		// Roles and the permissions they grant
+		roles := v1.Group("/admin")
+		roles.Use(middleware.Auth(jwtService, authService))
+		roles.Use(middleware.RequirePermission(models.PermRolesManage))
+		{
+			roles.GET("/permissions", roleHandler.ListPermissions)
+			roles.POST("/roles", roleHandler.CreateRole)
+			roles.PUT("/roles/:id", roleHandler.UpdateRole)
+			roles.DELETE("/roles/:id", roleHandler.DeleteRole)
 		}
 
-		// Purchase config routes (Admin + PurchaseAdmin)
+		// Companies, each with its own catalog, approvers, numbering and configs
+		companies := v1.Group("/admin")
+		companies.Use(middleware.Auth(jwtService, authService))
+		companies.Use(middleware.RequirePermission(models.PermCompaniesManage))
+		{
+			companies.GET("/companies", companyHandler.ListCompanies)
+			companies.POST("/companies", companyHandler.CreateCompany)
+			companies.PUT("/companies/:id", companyHandler.UpdateCompany)
+			companies.DELETE("/companies/:id", companyHandler.DeleteCompany)
+		}
+
+		// Purchase config routes
 		purchaseConfig := v1.Group("/admin")
-		purchaseConfig.Use(middleware.Auth(jwtService))
-		purchaseConfig.Use(middleware.RequirePurchaseConfig())
+		purchaseConfig.Use(middleware.Auth(jwtService, authService))
+		purchaseConfig.Use(middleware.RequirePermission(models.PermPurchaseConfig))
 		{
 			purchaseConfig.GET("/purchase-config", purchaseConfigHandler.GetPurchaseConfig)
 			purchaseConfig.PUT("/purchase-config", purchaseConfigHandler.SavePurchaseConfig)
@@ -229,20 +437,50 @@ func main() {
 			purchaseConfig.GET("/purchase-config/users", purchaseConfigHandler.GetApprovers)
 		}
 
-		// Email config routes (Admin only)
+		// Email, webhook and chat config routes
 		emailConfig := v1.Group("/admin")
-		emailConfig.Use(middleware.Auth(jwtService))
-		emailConfig.Use(middleware.RequireAdmin())
+		emailConfig.Use(middleware.Auth(jwtService, authService))
+		emailConfig.Use(middleware.RequirePermission(models.PermSettingsManage))
 		{
 			emailConfig.GET("/email-config", emailConfigHandler.GetEmailConfig)
 			emailConfig.PUT("/email-config", emailConfigHandler.SaveEmailConfig)
 			emailConfig.POST("/email-config/test", emailConfigHandler.TestEmailConfig)
-		}
-
-		// Activity logs routes (Admin only)
+			emailConfig.GET("/email-outbox", emailOutboxHandler.ListEmails)
+			emailConfig.GET("/email-outbox/:id", emailOutboxHandler.GetEmail)
+			emailConfig.POST("/email-outbox/:id/resend", emailOutboxHandler.ResendEmail)
+			emailConfig.GET("/email-templates", emailTemplateHandler.ListEmailTemplates)
+			emailConfig.GET("/email-templates/variables", emailTemplateHandler.GetTemplateVariables)
+			emailConfig.POST("/email-templates/preview", emailTemplateHandler.PreviewEmailTemplate)
+			emailConfig.PUT("/email-templates/:kind/:language", emailTemplateHandler.SaveEmailTemplate)
+			emailConfig.DELETE("/email-templates/:kind/:language", emailTemplateHandler.ResetEmailTemplate)
+			emailConfig.GET("/webhooks", webhookHandler.ListWebhooks)
+			emailConfig.GET("/webhooks/events", webhookHandler.GetWebhookEvents)
+			emailConfig.POST("/webhooks", webhookHandler.CreateWebhook)
+			emailConfig.GET("/webhooks/:id", webhookHandler.GetWebhook)
+			emailConfig.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+			emailConfig.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+			emailConfig.POST("/webhooks/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+			emailConfig.POST("/webhooks/:id/test", webhookHandler.TestWebhook)
+			emailConfig.GET("/webhooks/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+			emailConfig.GET("/webhook-deliveries", webhookHandler.ListWebhookDeliveries)
+			emailConfig.GET("/webhook-deliveries/:id", webhookHandler.GetWebhookDelivery)
+			emailConfig.POST("/webhook-deliveries/:id/redeliver", webhookHandler.RedeliverWebhook)
+			emailConfig.GET("/chat-channels", chatChannelHandler.ListChatChannels)
+			emailConfig.GET("/chat-channels/options", chatChannelHandler.GetChatChannelOptions)
+			emailConfig.POST("/chat-channels", chatChannelHandler.CreateChatChannel)
+			emailConfig.GET("/chat-channels/:id", chatChannelHandler.GetChatChannel)
+			emailConfig.PUT("/chat-channels/:id", chatChannelHandler.UpdateChatChannel)
+			emailConfig.DELETE("/chat-channels/:id", chatChannelHandler.DeleteChatChannel)
+			emailConfig.POST("/chat-channels/:id/test", chatChannelHandler.TestChatChannel)
+			emailConfig.GET("/chat-channels/:id/messages", chatChannelHandler.ListChatMessages)
+			emailConfig.GET("/chat-messages", chatChannelHandler.ListChatMessages)
+			emailConfig.POST("/chat-messages/:id/resend", chatChannelHandler.ResendChatMessage)
+		}
+
+		// Activity logs routes
 		activityLogs := v1.Group("/admin/activity-logs")
-		activityLogs.Use(middleware.Auth(jwtService))
-		activityLogs.Use(middleware.RequireAdmin())
+		activityLogs.Use(middleware.Auth(jwtService, authService))
+		activityLogs.Use(middleware.RequirePermission(models.PermLogsView))
 		{
 			activityLogs.GET("", activityLogHandler.GetActivityLogs)
 			activityLogs.GET("/stats", activityLogHandler.GetActivityStats)
@@ -250,12 +488,27 @@ func main() {
 			activityLogs.DELETE("/sessions/:id", activityLogHandler.EndSession)
 		}
 
-		// Approved orders management (Admin + PurchaseAdmin)
+		// Audit trail of request changes
+		auditLogs := v1.Group("/admin/audit-logs")
+		auditLogs.Use(middleware.Auth(jwtService, authService))
+		auditLogs.Use(middleware.RequirePermission(models.PermLogsView))
+		{
+			auditLogs.GET("", activityLogHandler.GetAuditLogs)
+		}
+
+		// Approved orders
+		ordersView := v1.Group("/admin")
+		ordersView.Use(middleware.Auth(jwtService, authService))
+		ordersView.Use(middleware.RequirePermission(models.PermOrdersView))
+		{
+			ordersView.GET("/approved-orders", adminHandler.GetApprovedOrders)
+		}
+
+		// Approved orders management
 		orders := v1.Group("/admin")
-		orders.Use(middleware.Auth(jwtService))
-		orders.Use(middleware.RequirePurchaseManager())
+		orders.Use(middleware.Auth(jwtService, authService))
+		orders.Use(middleware.RequirePermission(models.PermOrdersManage))
 		{
-			orders.GET("/approved-orders", adminHandler.GetApprovedOrders)
 			orders.PATCH("/orders/:id/purchased", adminHandler.MarkAsPurchased)
 			orders.PATCH("/orders/:id/delivered", adminHandler.MarkAsDelivered)
 			orders.PATCH("/orders/:id/cancel", adminHandler.CancelOrder)
@@ -265,10 +518,10 @@ func main() {
 			orders.PATCH("/orders/:id/items/purchased-all", adminHandler.MarkAllItemsPurchased)
 		}
 
-		// Upload routes (admin/purchase_admin/supply chain)
+		// Upload routes (for product management)
 		upload := v1.Group("/upload")
-		upload.Use(middleware.Auth(jwtService))
-		upload.Use(middleware.RequireInventoryAccess())
+		upload.Use(middleware.Auth(jwtService, authService))
+		upload.Use(middleware.RequirePermission(models.PermProductsManage))
 		{
 			upload.GET("/requirements", uploadHandler.GetUploadRequirements)
 			upload.POST("/image", uploadHandler.UploadImage)
@@ -278,14 +531,25 @@ func main() {
 
 		// Notification routes (all authenticated users)
 		notifications := v1.Group("/notifications")
-		notifications.Use(middleware.Auth(jwtService))
+		notifications.Use(middleware.Auth(jwtService, authService))
 		{
 			notifications.GET("", notificationHandler.ListNotifications)
 			notifications.GET("/count", notificationHandler.GetNotificationCount)
 			notifications.GET("/pending-counts", notificationHandler.GetPendingCounts)
+			notifications.GET("/preferences", notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
 			notifications.PATCH("/:id/read", notificationHandler.MarkAsRead)
 			notifications.POST("/read-all", notificationHandler.MarkAllAsRead)
 		}
+
+		// Notification stream (SSE); EventSource can't send headers, so the
+		// token may also come from ?access_token=
+		notificationStream := v1.Group("/notifications")
+		notificationStream.Use(middleware.TokenFromQuery())
+		notificationStream.Use(middleware.Auth(jwtService, authService))
+		{
+			notificationStream.GET("/stream", notificationHandler.StreamNotifications)
+		}
 	}
 
 	// Serve uploaded files (with cache headers)
@@ -0,0 +1,150 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// defaultCompanyCode is the company created when there are no users to take
+// company codes from; the seeded admin belongs to it
+const defaultCompanyCode = "CC-001"
+
+// seedCompanies creates a company for each company code users have the first
+// time it runs, making the most common one the default, and puts the
+// products, requests and configuration that predate companies into one.
+// Each step only touches rows without a company, so it is safe to run on
+// every startup.
+func seedCompanies(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.Company{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		if err := createCompaniesFromUsers(db); err != nil {
+			return err
+		}
+	}
+
+	defaultCode := models.DefaultCompanyCode(db)
+	if defaultCode == "" {
+		return fmt.Errorf("no default company")
+	}
+
+	// Requests belong to their requester's company
+	if err := db.Exec(`UPDATE purchase_requests SET company_code = (
+			SELECT users.company_code FROM users WHERE users.id = purchase_requests.requester_id)
+		WHERE COALESCE(company_code, '') = '' AND EXISTS (
+			SELECT 1 FROM users JOIN companies ON companies.code = users.company_code
+			WHERE users.id = purchase_requests.requester_id)`).Error; err != nil {
+		return fmt.Errorf("purchase_requests: %w", err)
+	}
+	for _, table := range []string{"purchase_requests", "products", "email_messages"} {
+		if err := db.Exec(fmt.Sprintf("UPDATE %s SET company_code = ? WHERE COALESCE(company_code, '') = ''", table),
+			defaultCode).Error; err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	// A company has one configuration of each kind, so one without a
+	// company only goes to the default company if it has none yet
+	for _, table := range configTables {
+		if err := db.Exec(fmt.Sprintf(`UPDATE %[1]s SET company_code = ? WHERE COALESCE(company_code, '') = ''
+			AND NOT EXISTS (SELECT 1 FROM %[1]s AS other WHERE other.company_code = ?)`, table),
+			defaultCode, defaultCode).Error; err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+
+	// SKUs used to be unique across all products; they're unique per company now
+	if db.Migrator().HasIndex(&models.Product{}, "idx_products_sku") {
+		if err := db.Migrator().DropIndex(&models.Product{}, "idx_products_sku"); err != nil {
+			return fmt.Errorf("products: %w", err)
+		}
+	}
+	return nil
+}
+
+// configTables hold one configuration per company
+var configTables = []string{"purchase_configs", "email_configs", "amazon_configs"}
+
+// uniqueConfigCompanies prepares the configuration tables for their unique
+// company_code index, which replaced a plain one. Only the first
+// configuration of a company was ever used, so any others are removed, and
+// the plain index is dropped so AutoMigrate creates the unique one. It runs
+// before AutoMigrate and does nothing once the index is unique.
+func uniqueConfigCompanies(db *gorm.DB) error {
+	for _, table := range configTables {
+		if !db.Migrator().HasTable(table) {
+			continue
+		}
+		indexes, err := db.Migrator().GetIndexes(table)
+		if err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+		for _, index := range indexes {
+			if index.Name() != "idx_"+table+"_company_code" {
+				continue
+			}
+			if unique, _ := index.Unique(); unique {
+				break
+			}
+
+			result := db.Exec(fmt.Sprintf(`DELETE FROM %[1]s WHERE id NOT IN (
+				SELECT MIN(id) FROM %[1]s GROUP BY COALESCE(company_code, ''))`, table))
+			if result.Error != nil {
+				return fmt.Errorf("%s: %w", table, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("Removed %d unused duplicate rows from %s", result.RowsAffected, table)
+			}
+			if err := db.Migrator().DropIndex(table, index.Name()); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// createCompaniesFromUsers creates the first companies and lets the admin
+// role manage them
+func createCompaniesFromUsers(db *gorm.DB) error {
+	var codes []struct {
+		CompanyCode string
+		Users       int64
+	}
+	if err := db.Model(&models.User{}).Select("company_code, COUNT(*) AS users").
+		Where("COALESCE(company_code, '') <> ''").
+		Group("company_code").Order("users DESC, company_code").
+		Scan(&codes).Error; err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		codes = append(codes, struct {
+			CompanyCode string
+			Users       int64
+		}{CompanyCode: defaultCompanyCode})
+	}
+
+	for i, code := range codes {
+		company := models.Company{Code: code.CompanyCode, Name: code.CompanyCode, IsDefault: i == 0}
+		if err := db.Create(&company).Error; err != nil {
+			return err
+		}
+		log.Printf("Created company %s", company.Code)
+	}
+
+	// The admin role predates the permission; it gets it along with the companies
+	admin, err := models.FindRole(db, models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if !admin.Permissions.Contains(string(models.PermCompaniesManage)) {
+		permissions := append(admin.Permissions, string(models.PermCompaniesManage))
+		if err := db.Model(admin).Update("permissions", permissions).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
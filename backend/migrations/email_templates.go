@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/i18n"
+)
+
+// legacyEmailTemplateColumns maps the single-language template overrides that
+// used to live on email_configs to their template kind
+var legacyEmailTemplateColumns = map[string]models.EmailTemplateKind{
+	"template_approval":    models.EmailTemplateApproval,
+	"template_rejection":   models.EmailTemplateRejection,
+	"template_info_req":    models.EmailTemplateInfoRequest,
+	"template_purchased":   models.EmailTemplatePurchased,
+	"template_new_request": models.EmailTemplateNewRequest,
+}
+
+// migrateLegacyEmailTemplates moves overrides from email_configs into
+// email_templates for the default language and drops the old columns
+func migrateLegacyEmailTemplates(db *gorm.DB) error {
+	lang := i18n.Default()
+	for column, kind := range legacyEmailTemplateColumns {
+		if !db.Migrator().HasColumn("email_configs", column) {
+			continue
+		}
+
+		var bodies []string
+		if err := db.Table("email_configs").
+			Where(column+" IS NOT NULL AND "+column+" <> ''").
+			Pluck(column, &bodies).Error; err != nil {
+			return err
+		}
+
+		for _, body := range bodies {
+			var count int64
+			db.Model(&models.EmailTemplate{}).Where("kind = ? AND language = ?", kind, lang).Count(&count)
+			if count > 0 {
+				continue
+			}
+			if err := db.Create(&models.EmailTemplate{Kind: kind, Language: lang, Body: body}).Error; err != nil {
+				return err
+			}
+			log.Printf("Migrated %s email template to %s", kind, lang)
+		}
+
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE email_configs DROP COLUMN %s", column)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package migrations
 
 import (
+	"fmt"
 	"log"
 
 	"gorm.io/gorm"
@@ -12,6 +13,10 @@ import (
 func RunMigrations(db *gorm.DB) error {
 	log.Println("Running database migrations...")
 
+	if err := uniqueConfigCompanies(db); err != nil {
+		return fmt.Errorf("configuration companies: %w", err)
+	}
+
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.Product{},
@@ -26,11 +31,46 @@ func RunMigrations(db *gorm.DB) error {
 		&models.AuditLog{},
 		&models.CartItem{},
 		&models.ActivityLog{},
+		&models.TranslationJob{},
+		&models.GlossaryTerm{},
+		&models.NotificationPreference{},
+		&models.EmailMessage{},
+		&models.EmailTemplate{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.ChatChannel{},
+		&models.ChatMessage{},
+		&models.PasswordResetToken{},
+		&models.RecoveryCode{},
+		&models.SSOLogin{},
+		&models.DirectorySyncRun{},
+		&models.APIKey{},
+		&models.Role{},
+		&models.Company{},
 	)
 	if err != nil {
 		return err
 	}
 
+	if err := migrateLanguageData(db); err != nil {
+		return err
+	}
+
+	if err := migrateLegacyEmailTemplates(db); err != nil {
+		return fmt.Errorf("email templates: %w", err)
+	}
+
+	if err := seedRoles(db); err != nil {
+		return fmt.Errorf("roles: %w", err)
+	}
+	if err := removeSeededOrdersView(db); err != nil {
+		return fmt.Errorf("roles: %w", err)
+	}
+
+	if err := seedCompanies(db); err != nil {
+		return fmt.Errorf("companies: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
@@ -64,7 +104,7 @@ func SeedData(db *gorm.DB) error {
 				PasswordHash:   hashedPassword,
 				Name:           "System Admin",
 				Role:           models.RoleAdmin,
-				CompanyCode:    "CC-001",
+				CompanyCode:    models.DefaultCompanyCode(db),
 				CostCenter:     "CC-ADMIN",
 				Department:     "IT",
 				Status:         models.UserStatusApproved,
@@ -87,11 +127,12 @@ func SeedData(db *gorm.DB) error {
 		seedAmazonProducts(db)
 	}
 
-	// Seed PurchaseConfig (singleton)
+	// Seed the default company's PurchaseConfig
 	var purchaseConfigCount int64
 	db.Model(&models.PurchaseConfig{}).Count(&purchaseConfigCount)
 	if purchaseConfigCount == 0 {
 		config := models.GetDefaultPurchaseConfig()
+		config.CompanyCode = models.DefaultCompanyCode(db)
 		db.Create(&config)
 		log.Println("Created default purchase configuration")
 	}
@@ -188,7 +229,9 @@ func seedAmazonProducts(db *gorm.DB) {
 		{SKU: "AMZ-B0CX8Y95S3", Name: "Aurrera Trapeador Completo", Category: "Kitchen", Brand: "Aurrera", Price: 45.0, Currency: "MXN", ASIN: "B0CX8Y95S3", ProductURL: "https://www.amazon.com.mx/dp/B0CX8Y95S3", IsEcommerce: true, IsActive: true, Source: models.SourceExternal},
 	}
 
+	company := models.DefaultCompanyCode(db)
 	for _, product := range products {
+		product.CompanyCode = company
 		db.Create(&product)
 	}
 	log.Printf("Created %d products from Amazon order history", len(products))
@@ -0,0 +1,131 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/translation"
+)
+
+// migrateLanguageData moves data stored with fixed en/zh/es fields into
+// language-keyed maps. Each step only touches rows still in the old format,
+// so it is safe to run on every startup.
+func migrateLanguageData(db *gorm.DB) error {
+	if err := migrateLegacyColumns(db, "products", map[string]string{
+		"name_zh": "name_translations",
+		"name_es": "name_translations",
+		"desc_zh": "desc_translations",
+		"desc_es": "desc_translations",
+		"spec_zh": "spec_translations",
+		"spec_es": "spec_translations",
+	}); err != nil {
+		return fmt.Errorf("products: %w", err)
+	}
+
+	if err := migrateLegacyColumns(db, "glossary_terms", map[string]string{
+		"en": "translations",
+		"es": "translations",
+		"zh": "translations",
+	}); err != nil {
+		return fmt.Errorf("glossary_terms: %w", err)
+	}
+
+	if err := migrateTranslatedTextJSON(db); err != nil {
+		return fmt.Errorf("translated text: %w", err)
+	}
+
+	return nil
+}
+
+// migrateLegacyColumns copies per-language columns (e.g. name_zh) into a
+// LocalizedText column and drops them. The language is the column suffix,
+// or the whole column name for columns like "es".
+func migrateLegacyColumns(db *gorm.DB, table string, columns map[string]string) error {
+	for legacy, target := range columns {
+		if !db.Migrator().HasColumn(table, legacy) {
+			continue
+		}
+
+		lang := legacy
+		if len(legacy) > 3 && legacy[len(legacy)-3] == '_' {
+			lang = legacy[len(legacy)-2:]
+		}
+
+		var rows []struct {
+			ID     uint
+			Legacy string
+			Target models.LocalizedText
+		}
+		if err := db.Table(table).
+			Select("id, " + legacy + " AS legacy, " + target + " AS target").
+			Where(legacy + " IS NOT NULL AND " + legacy + " <> ''").
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			// Values already in the map were set after the upgrade and win
+			if row.Target.Get(lang) != "" {
+				continue
+			}
+			row.Target.Set(lang, row.Legacy)
+			if err := db.Table(table).Where("id = ?", row.ID).Update(target, row.Target).Error; err != nil {
+				return err
+			}
+		}
+
+		// Migrator().DropColumn needs a model; the legacy fields no longer have one
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, legacy)).Error; err != nil {
+			return err
+		}
+		log.Printf("Migrated %d rows of %s.%s into %s", len(rows), table, legacy, target)
+	}
+	return nil
+}
+
+// migrateTranslatedTextJSON rewrites TranslatedText JSON from the flat
+// {"original","en","zh","es"} format to {"original","translations":{...}}
+func migrateTranslatedTextJSON(db *gorm.DB) error {
+	targets := make([]translation.TranslatableField, 0, len(translation.TranslatableFields)+1)
+	targets = append(targets, translation.TranslatableFields...)
+	targets = append(targets, translation.TranslatableField{Table: "translation_jobs", TargetColumn: "initial"})
+
+	for _, field := range targets {
+		if !db.Migrator().HasTable(field.Table) {
+			continue
+		}
+
+		var rows []struct {
+			ID   uint
+			Data models.JSONB
+		}
+		if err := db.Table(field.Table).
+			Select("id, "+field.TargetColumn+" AS data").
+			Where(field.TargetColumn+" IS NOT NULL AND "+field.TargetColumn+" NOT LIKE ?", `%"translations"%`).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		migrated := 0
+		for _, row := range rows {
+			if !translation.IsLegacyJSON(row.Data) {
+				continue
+			}
+			t := translation.FromJSON(row.Data)
+			if t == nil {
+				continue
+			}
+			if err := db.Table(field.Table).Where("id = ?", row.ID).
+				Update(field.TargetColumn, translation.ToJSON(t)).Error; err != nil {
+				return err
+			}
+			migrated++
+		}
+		if migrated > 0 {
+			log.Printf("Migrated %d rows of %s.%s to language-keyed JSON", migrated, field.Table, field.TargetColumn)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// seedRoles creates the built-in roles that don't exist yet with their
+// default permissions. Roles already in the database keep the permissions an
+// admin gave them.
+func seedRoles(db *gorm.DB) error {
+	for _, role := range models.DefaultRoles() {
+		existing, err := models.FindRole(db, role.Name)
+		if err != nil {
+			role.BuiltIn = true
+			if err := db.Create(&role).Error; err != nil {
+				return err
+			}
+			log.Printf("Created built-in role %s", role.Name)
+			continue
+		}
+
+		// A custom role created before a built-in one of the same name
+		// becomes the built-in role
+		if !existing.BuiltIn {
+			if err := db.Model(existing).Update("built_in", true).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeSeededOrdersView takes orders.view away from the supply chain manager
+// role, which earlier versions seeded with it by mistake. A role whose
+// permissions an admin has changed is left alone, since they may have given
+// it on purpose.
+func removeSeededOrdersView(db *gorm.DB) error {
+	role, err := models.FindRole(db, models.RoleSupplyChainManager)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !role.PermissionSet().Has(models.PermOrdersView) {
+		return nil
+	}
+
+	var changes int64
+	if err := db.Model(&models.AuditLog{}).
+		Where("action = ? AND resource = ? AND resource_id = ?", "role.permissions_changed", "role", role.ID).
+		Count(&changes).Error; err != nil {
+		return err
+	}
+	if changes > 0 {
+		return nil
+	}
+
+	permissions := models.StringList{}
+	for _, p := range role.Permissions {
+		if p != string(models.PermOrdersView) {
+			permissions = append(permissions, p)
+		}
+	}
+	if err := db.Model(role).Update("permissions", permissions).Error; err != nil {
+		return err
+	}
+	log.Printf("Removed %s from the %s role", models.PermOrdersView, role.Name)
+	return nil
+}
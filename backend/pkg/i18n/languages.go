@@ -0,0 +1,167 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Language describes a language the application can be configured to use
+type Language struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	NativeName string `json:"native_name"`
+}
+
+// known lists every language that can be enabled. Adding a language here
+// (plus translation strings where needed) is enough to make it configurable.
+var known = map[string]Language{
+	"en": {Code: "en", Name: "English", NativeName: "English"},
+	"es": {Code: "es", Name: "Spanish", NativeName: "Español"},
+	"zh": {Code: "zh", Name: "Chinese", NativeName: "中文"},
+	"ko": {Code: "ko", Name: "Korean", NativeName: "한국어"},
+	"pt": {Code: "pt", Name: "Portuguese", NativeName: "Português"},
+	"ja": {Code: "ja", Name: "Japanese", NativeName: "日本語"},
+	"fr": {Code: "fr", Name: "French", NativeName: "Français"},
+	"de": {Code: "de", Name: "German", NativeName: "Deutsch"},
+	"vi": {Code: "vi", Name: "Vietnamese", NativeName: "Tiếng Việt"},
+}
+
+var (
+	mu          sync.RWMutex
+	enabled     = []string{"en", "zh", "es"}
+	defaultLang = "en"
+)
+
+// Configure sets the enabled languages and the default language.
+// Unknown codes are ignored; the default is always enabled.
+func Configure(codes []string, def string) {
+	var list []string
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		code = Normalize(code)
+		if _, ok := known[code]; !ok || seen[code] {
+			continue
+		}
+		seen[code] = true
+		list = append(list, code)
+	}
+
+	def = Normalize(def)
+	if _, ok := known[def]; !ok {
+		def = "en"
+	}
+	if !seen[def] {
+		list = append([]string{def}, list...)
+	}
+
+	mu.Lock()
+	enabled = list
+	defaultLang = def
+	mu.Unlock()
+}
+
+// Enabled returns the enabled language codes in configured order
+func Enabled() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string(nil), enabled...)
+}
+
+// EnabledLanguages returns details of the enabled languages
+func EnabledLanguages() []Language {
+	codes := Enabled()
+	languages := make([]Language, len(codes))
+	for i, code := range codes {
+		languages[i] = known[code]
+	}
+	return languages
+}
+
+// Default returns the default language code
+func Default() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLang
+}
+
+// IsEnabled returns true if the language code is enabled
+func IsEnabled(code string) bool {
+	code = Normalize(code)
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, c := range enabled {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKnown returns true if the language code can be enabled
+func IsKnown(code string) bool {
+	_, ok := known[Normalize(code)]
+	return ok
+}
+
+// Lookup returns details of a known language
+func Lookup(code string) (Language, bool) {
+	lang, ok := known[Normalize(code)]
+	return lang, ok
+}
+
+// Normalize lowercases a language tag and strips its region ("pt-BR" -> "pt")
+func Normalize(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+	return code
+}
+
+// Resolve returns code if it is enabled, otherwise the default language
+func Resolve(code string) string {
+	if IsEnabled(code) {
+		return Normalize(code)
+	}
+	return Default()
+}
+
+// FromRequest picks the user's language from an explicit preference
+// (e.g. the X-User-Language header) or an Accept-Language header
+func FromRequest(preferred, acceptLanguage string) string {
+	if preferred != "" && IsEnabled(preferred) {
+		return Normalize(preferred)
+	}
+
+	type candidate struct {
+		code string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		if fields[0] == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		candidates = append(candidates, candidate{code: fields[0], q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if IsEnabled(c.code) {
+			return Normalize(c.code)
+		}
+	}
+	return Default()
+}
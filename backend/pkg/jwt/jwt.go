@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -18,13 +20,20 @@ type TokenType string
 const (
 	AccessToken  TokenType = "access"
 	RefreshToken TokenType = "refresh"
+	// TwoFactorToken proves the password was right; it's exchanged for a
+	// session once the second factor is verified
+	TwoFactorToken TokenType = "two_factor"
 )
 
+// twoFactorTokenExpiry is how long the user has to enter their second factor
+const twoFactorTokenExpiry = 5 * time.Minute
+
 type Claims struct {
-	UserID uint      `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
-	Type   TokenType `json:"type"`
+	UserID    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Type      TokenType `json:"type"`
+	SessionID string    `json:"sid"` // Login session the token belongs to
 	jwt.RegisteredClaims
 }
 
@@ -43,31 +52,65 @@ func NewJWTService(secretKey string, accessExpiry, refreshExpiry time.Duration)
 	}
 }
 
-// GenerateAccessToken generates an access token for the user
-func (js *JWTService) GenerateAccessToken(userID uint, email, role string) (string, error) {
-	return js.generateToken(userID, email, role, AccessToken, js.accessTokenExpiry)
+// RefreshTokenExpiry returns how long refresh tokens, and so sessions, stay valid
+func (js *JWTService) RefreshTokenExpiry() time.Duration {
+	return js.refreshTokenExpiry
+}
+
+// NewID returns a random identifier for sessions and token IDs
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateAccessToken generates an access token for the user's session
+func (js *JWTService) GenerateAccessToken(userID uint, email, role, sessionID string) (string, error) {
+	token, _, err := js.generateToken(userID, email, role, sessionID, AccessToken, js.accessTokenExpiry)
+	return token, err
 }
 
-// GenerateRefreshToken generates a refresh token for the user
-func (js *JWTService) GenerateRefreshToken(userID uint, email, role string) (string, error) {
-	return js.generateToken(userID, email, role, RefreshToken, js.refreshTokenExpiry)
+// GenerateRefreshToken generates a refresh token for the user's session
+func (js *JWTService) GenerateRefreshToken(userID uint, email, role, sessionID string) (string, error) {
+	token, _, err := js.generateToken(userID, email, role, sessionID, RefreshToken, js.refreshTokenExpiry)
+	return token, err
 }
 
-func (js *JWTService) generateToken(userID uint, email, role string, tokenType TokenType, expiry time.Duration) (string, error) {
+// GenerateTwoFactorToken generates a short-lived token for the second login
+// step. It has no session and isn't accepted as an access token.
+func (js *JWTService) GenerateTwoFactorToken(userID uint, email, role string) (string, error) {
+	token, _, err := js.generateToken(userID, email, role, "", TwoFactorToken, twoFactorTokenExpiry)
+	return token, err
+}
+
+// generateToken returns the signed token and its ID (jti)
+func (js *JWTService) generateToken(userID uint, email, role, sessionID string, tokenType TokenType, expiry time.Duration) (string, string, error) {
+	tokenID, err := NewID()
+	if err != nil {
+		return "", "", err
+	}
+
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		Type:   tokenType,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		Type:      tokenType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(js.secretKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(js.secretKey)
+	if err != nil {
+		return "", "", err
+	}
+	return token, tokenID, nil
 }
 
 // ValidateToken validates a token and returns the claims
@@ -118,28 +161,45 @@ func (js *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error)
 	return claims, nil
 }
 
+// ValidateTwoFactorToken validates a second login step token specifically
+func (js *JWTService) ValidateTwoFactorToken(tokenString string) (*Claims, error) {
+	claims, err := js.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != TwoFactorToken {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
 // TokenPair represents both access and refresh tokens
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"` // Access token expiry in seconds
+
+	// RefreshTokenID is the refresh token's jti. Refresh tokens are single
+	// use: the session only accepts the latest one.
+	RefreshTokenID string `json:"-"`
 }
 
-// GenerateTokenPair generates both access and refresh tokens
-func (js *JWTService) GenerateTokenPair(userID uint, email, role string) (*TokenPair, error) {
-	accessToken, err := js.GenerateAccessToken(userID, email, role)
+// GenerateTokenPair generates both access and refresh tokens for a session
+func (js *JWTService) GenerateTokenPair(userID uint, email, role, sessionID string) (*TokenPair, error) {
+	accessToken, err := js.GenerateAccessToken(userID, email, role, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := js.GenerateRefreshToken(userID, email, role)
+	refreshToken, refreshTokenID, err := js.generateToken(userID, email, role, sessionID, RefreshToken, js.refreshTokenExpiry)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(js.accessTokenExpiry.Seconds()),
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		ExpiresIn:      int64(js.accessTokenExpiry.Seconds()),
+		RefreshTokenID: refreshTokenID,
 	}, nil
 }
@@ -0,0 +1,361 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OpenID Connect authorization code flow with PKCE (RFC 7636), for a
+// confidential or public client of one identity provider. ID tokens must be
+// signed with RS256, which every mainstream provider supports.
+const (
+	// metadataMaxAge is how long the discovery document is cached
+	metadataMaxAge = time.Hour
+	// keyRefreshInterval is the minimum time between two JWKS downloads, for
+	// tokens signed with a key we don't know
+	keyRefreshInterval = 10 * time.Second
+)
+
+// Config identifies the client at the provider
+type Config struct {
+	Issuer       string // Issuer URL; discovery is read from /.well-known/openid-configuration below it
+	ClientID     string
+	ClientSecret string // Empty for public clients, which rely on PKCE alone
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Provider talks to an OpenID Connect provider
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu        sync.Mutex
+	metadata  *metadata
+	fetchedAt time.Time
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+// metadata is the part of the discovery document we use
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDToken holds the verified claims of an ID token
+type IDToken struct {
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+func NewProvider(config Config) *Provider {
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// RandomString returns a random URL-safe string, for state, nonce and PKCE
+// code verifiers
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE challenge for a code verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the provider URL to send the user to for logging in
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token.
+// nonce is the one sent with the authorization request.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("invalid token response (HTTP %d)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token request rejected (HTTP %d): %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return p.Verify(ctx, token.IDToken, nonce)
+}
+
+// Verify checks an ID token's signature, issuer, audience, expiry and nonce
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, meta, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	// A token issued to several clients must name us as the one it's for
+	if audience, _ := claims.GetAudience(); len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, errors.New("invalid ID token: authorized party mismatch")
+		}
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, errors.New("invalid ID token: no subject")
+	}
+
+	return &IDToken{Subject: subject, Claims: claims}, nil
+}
+
+// String returns a string claim, or "" if it's missing or not a string
+func (t *IDToken) String(name string) string {
+	switch v := t.Claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		// Some directories send numeric attributes, e.g. employee numbers, as numbers
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Strings returns a claim that is a list of strings, or a single string.
+// The second result is false if the claim is missing.
+func (t *IDToken) Strings(name string) ([]string, bool) {
+	switch v := t.Claims[name].(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values, true
+	case string:
+		return []string{v}, true
+	}
+	return nil, false
+}
+
+// EmailVerified returns true only if the provider says it verified the email
+// address. A missing claim counts as unverified.
+func (t *IDToken) EmailVerified() bool {
+	switch v := t.Claims["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// discover returns the provider's discovery document, cached
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil && time.Since(p.fetchedAt) < metadataMaxAge {
+		return p.metadata, nil
+	}
+
+	var meta metadata
+	if err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		if p.metadata != nil {
+			// Keep using the old document while the provider is unreachable
+			return p.metadata, nil
+		}
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q doesn't match %q", meta.Issuer, p.config.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	p.metadata = &meta
+	p.fetchedAt = time.Now()
+	return p.metadata, nil
+}
+
+// key returns the provider's signing key with the given ID, downloading the
+// key set again if it's unknown, e.g. after the provider rotated its keys
+func (p *Provider) key(ctx context.Context, meta *metadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	p.keys = keys
+	p.keysAt = time.Now()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// findKey looks up a cached key. Tokens without a key ID are accepted if
+// the provider has only one key.
+func (p *Provider) findKey(kid string) *rsa.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,171 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"vista-backend/pkg/oidc"
+	"vista-backend/pkg/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:3000/sso/callback"
+
+func newProvider(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+	idp := oidctest.NewServer("vista")
+	t.Cleanup(idp.Close)
+	return idp, oidc.NewProvider(oidc.Config{
+		Issuer:      idp.Issuer(),
+		ClientID:    "vista",
+		RedirectURL: redirectURL,
+		Scopes:      []string{"openid", "email", "profile"},
+	})
+}
+
+// login starts a login with fresh secrets and signs the user in at the
+// provider, returning the code and the verifier and nonce it was started with
+func login(t *testing.T, idp *oidctest.Server, provider *oidc.Provider, claims jwt.MapClaims) (code, verifier, nonce string) {
+	t.Helper()
+	state, _ := oidc.RandomString()
+	nonce, _ = oidc.RandomString()
+	verifier, _ = oidc.RandomString()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if strings.Contains(authURL, verifier) {
+		t.Fatal("authorization URL contains the PKCE verifier")
+	}
+
+	code, returnedState, err := idp.Login(authURL, claims)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if returnedState != state {
+		t.Fatalf("provider returned state %q, want %q", returnedState, state)
+	}
+	return code, verifier, nonce
+}
+
+func TestExchange(t *testing.T) {
+	idp, provider := newProvider(t)
+	code, verifier, nonce := login(t, idp, provider, jwt.MapClaims{
+		"sub":             "user-1",
+		"email":           "jane@example.com",
+		"email_verified":  false,
+		"employee_number": 1001.0,
+		"groups":          []string{"vista-admins", "staff"},
+	})
+
+	token, err := provider.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if token.Subject != "user-1" {
+		t.Errorf("Subject = %q, want user-1", token.Subject)
+	}
+	if got := token.String("email"); got != "jane@example.com" {
+		t.Errorf("email = %q", got)
+	}
+	if got := token.String("employee_number"); got != "1001" {
+		t.Errorf("numeric employee_number = %q, want 1001", got)
+	}
+	if groups, ok := token.Strings("groups"); !ok || len(groups) != 2 || groups[0] != "vista-admins" {
+		t.Errorf("groups = %v, %v", groups, ok)
+	}
+	if token.EmailVerified() {
+		t.Error("EmailVerified() = true for email_verified false")
+	}
+}
+
+func TestEmailVerified(t *testing.T) {
+	tests := []struct {
+		claim interface{}
+		want  bool
+	}{
+		{true, true},
+		{"true", true},
+		{false, false},
+		{"false", false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		token := &oidc.IDToken{Claims: map[string]interface{}{}}
+		if tt.claim != nil {
+			token.Claims["email_verified"] = tt.claim
+		}
+		if got := token.EmailVerified(); got != tt.want {
+			t.Errorf("EmailVerified() with email_verified %v = %v, want %v", tt.claim, got, tt.want)
+		}
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp, provider := newProvider(t)
+	code, _, nonce := login(t, idp, provider, jwt.MapClaims{"sub": "user-1"})
+
+	other, _ := oidc.RandomString()
+	_, err := provider.Exchange(context.Background(), code, other, nonce)
+	if err == nil || !strings.Contains(err.Error(), "PKCE verification failed") {
+		t.Errorf("err = %v, want PKCE verification to fail", err)
+	}
+}
+
+func TestExchangeRejectsWrongNonce(t *testing.T) {
+	idp, provider := newProvider(t)
+	code, verifier, _ := login(t, idp, provider, jwt.MapClaims{"sub": "user-1"})
+
+	_, err := provider.Exchange(context.Background(), code, verifier, "another-nonce")
+	if err == nil || !strings.Contains(err.Error(), "nonce mismatch") {
+		t.Errorf("err = %v, want a nonce mismatch", err)
+	}
+}
+
+func TestExchangeCodeIsSingleUse(t *testing.T) {
+	idp, provider := newProvider(t)
+	code, verifier, nonce := login(t, idp, provider, jwt.MapClaims{"sub": "user-1"})
+
+	if _, err := provider.Exchange(context.Background(), code, verifier, nonce); err != nil {
+		t.Fatalf("first Exchange: %v", err)
+	}
+	if _, err := provider.Exchange(context.Background(), code, verifier, nonce); err == nil {
+		t.Error("second Exchange with the same code succeeded")
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	idp, provider := newProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"unknown key", idp.SignIDTokenWithKey(otherKey, "other-key", jwt.MapClaims{"sub": "user-1", "nonce": "n"}), "unknown signing key"},
+		{"forged with the published key ID", idp.SignIDTokenWithKey(otherKey, oidctest.KeyID, jwt.MapClaims{"sub": "user-1", "nonce": "n"}), "signature is invalid"},
+		{"other audience", idp.SignIDToken(jwt.MapClaims{"sub": "user-1", "nonce": "n", "aud": "other-client"}), "aud"},
+		{"other issuer", idp.SignIDToken(jwt.MapClaims{"sub": "user-1", "nonce": "n", "iss": "https://evil.example.com"}), "iss"},
+		{"expired", idp.SignIDToken(jwt.MapClaims{"sub": "user-1", "nonce": "n", "exp": time.Now().Add(-time.Hour).Unix()}), "expired"},
+		{"several audiences without azp", idp.SignIDToken(jwt.MapClaims{"sub": "user-1", "nonce": "n", "aud": []string{"vista", "other-client"}}), "authorized party"},
+		{"no nonce", idp.SignIDToken(jwt.MapClaims{"sub": "user-1"}), "nonce mismatch"},
+		{"no subject", idp.SignIDToken(jwt.MapClaims{"nonce": "n"}), "no subject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.Verify(context.Background(), tt.token, "n")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Package oidctest provides an in-process OpenID Connect identity provider
+// for tests, in the spirit of net/http/httptest. It serves discovery, JWKS
+// and token endpoints like cmd/mock-oidc; instead of a login page, tests sign
+// a user in with Login and get the code the provider would redirect back with.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyID is the key ID the provider signs ID tokens with
+const KeyID = "test-key"
+
+// Server is a running test identity provider
+type Server struct {
+	*httptest.Server
+	ClientID string
+
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]*grant
+}
+
+// grant is an authorization code waiting to be redeemed
+type grant struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	claims        jwt.MapClaims
+}
+
+// NewServer starts a provider that accepts the client ID. Callers should
+// Close it when done.
+func NewServer(clientID string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("oidctest: failed to generate signing key: " + err.Error())
+	}
+	s := &Server{
+		ClientID: clientID,
+		key:      key,
+		grants:   map[string]*grant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/jwks", s.handleJWKS)
+	mux.HandleFunc("/token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer returns the provider's issuer URL
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// Login signs a user in with the given claims at an authorization URL from
+// the client, as if they had logged in at the provider, and returns the code
+// and state the provider redirects back with. "sub" is required.
+func (s *Server) Login(authURL string, claims jwt.MapClaims) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.HasPrefix(authURL, s.URL+"/authorize") {
+		return "", "", errors.New("not this provider's authorization endpoint")
+	}
+	q := u.Query()
+	switch {
+	case q.Get("client_id") != s.ClientID:
+		return "", "", errors.New("unknown client_id")
+	case q.Get("response_type") != "code":
+		return "", "", errors.New("unsupported response_type")
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		return "", "", errors.New("PKCE with S256 is required")
+	case claims["sub"] == nil:
+		return "", "", errors.New("claims have no subject")
+	}
+
+	code = randomString()
+	s.mu.Lock()
+	s.grants[code] = &grant{
+		redirectURI:   q.Get("redirect_uri"),
+		codeChallenge: q.Get("code_challenge"),
+		nonce:         q.Get("nonce"),
+		claims:        claims,
+	}
+	s.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+// SignIDToken returns an ID token for the client with the given claims added
+// to the standard ones; the claims may override them
+func (s *Server) SignIDToken(claims jwt.MapClaims) string {
+	return s.sign(s.key, KeyID, claims)
+}
+
+// SignIDTokenWithKey is SignIDToken with a key the provider doesn't publish
+func (s *Server) SignIDTokenWithKey(key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	return s.sign(key, kid, claims)
+}
+
+func (s *Server) sign(key *rsa.PrivateKey, kid string, extra jwt.MapClaims) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": s.Issuer(),
+		"aud": s.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic("oidctest: failed to sign ID token: " + err.Error())
+	}
+	return signed
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                 s.Issuer(),
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+// handleToken redeems codes once, checking the redirect URI and PKCE verifier
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "invalid_request", "")
+		return
+	}
+	if r.PostForm.Get("client_id") != s.ClientID {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "")
+		return
+	}
+
+	s.mu.Lock()
+	g := s.grants[r.PostForm.Get("code")]
+	delete(s.grants, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if g == nil || g.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "unknown, used or mismatched code")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	claims := jwt.MapClaims{"nonce": g.nonce}
+	for name, value := range g.claims {
+		claims[name] = value
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     s.SignIDToken(claims),
+	})
+}
+
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic("oidctest: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
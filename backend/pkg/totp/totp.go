@@ -0,0 +1,84 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Time-based one-time passwords (RFC 6238) with the parameters authenticator
+// apps assume by default: HMAC-SHA1, 6 digits, 30 second steps.
+const (
+	Digits = 6
+	Period = 30 * time.Second
+
+	// skew is how many steps before and after the current one are accepted,
+	// for clocks that are a little off
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, base32 encoded
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// ProvisioningURI returns the otpauth:// URI authenticator apps read from a
+// QR code
+func ProvisioningURI(secret, issuer, account string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Validate checks a code against the secret at time t. It returns the time
+// step the code belongs to, so callers can refuse a code that was already used.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := t.Unix() / int64(Period.Seconds())
+	for counter := current - skew; counter <= current+skew; counter++ {
+		if subtle.ConstantTimeCompare([]byte(generate(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// generate returns the code for a time step (RFC 4226 HOTP)
+func generate(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
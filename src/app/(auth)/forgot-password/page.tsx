@@ -0,0 +1,145 @@
+'use client';
+
+import { useState } from 'react';
+import Link from 'next/link';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import { ArrowLeft, Loader2, MailCheck } from 'lucide-react';
+
+export default function ForgotPasswordPage() {
+  const { language } = useLanguage();
+  const [email, setEmail] = useState('');
+  const [error, setError] = useState('');
+  const [sent, setSent] = useState(false);
+  const [isLoading, setIsLoading] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Forgot your password?',
+      subtitle: "Enter your email and we'll send you a link to reset it",
+      email: 'Email',
+      emailPlaceholder: 'Enter your email',
+      send: 'Send Reset Link',
+      sending: 'Sending...',
+      sent: 'If an account exists for this email, we sent a link to reset your password. It expires in one hour.',
+      error: 'Something went wrong. Please try again.',
+      backToLogin: 'Back to sign in',
+    },
+    zh: {
+      title: '忘记密码？',
+      subtitle: '输入您的邮箱，我们将发送重置密码的链接',
+      email: '邮箱',
+      emailPlaceholder: '请输入邮箱',
+      send: '发送重置链接',
+      sending: '发送中...',
+      sent: '如果该邮箱已注册，我们已发送重置密码的链接，链接将在一小时后失效。',
+      error: '出现错误，请重试。',
+      backToLogin: '返回登录',
+    },
+    es: {
+      title: '¿Olvidó su contraseña?',
+      subtitle: 'Ingrese su correo y le enviaremos un enlace para restablecerla',
+      email: 'Correo electrónico',
+      emailPlaceholder: 'Ingrese su correo',
+      send: 'Enviar enlace',
+      sending: 'Enviando...',
+      sent: 'Si existe una cuenta con este correo, le enviamos un enlace para restablecer su contraseña. Caduca en una hora.',
+      error: 'Algo salió mal. Intente de nuevo.',
+      backToLogin: 'Volver a iniciar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setIsLoading(true);
+
+    try {
+      await authApi.forgotPassword(email);
+      setSent(true);
+    } catch {
+      setError(t.error);
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <div className="w-full max-w-md p-8">
+      <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#ABC0B9]">
+        <div className="text-center mb-6">
+          <h2
+            className="text-xl text-[#2D363F] mb-1"
+            style={{ fontWeight: 600 }}
+          >
+            {t.title}
+          </h2>
+          <p className="text-sm text-[#4E616F]">{t.subtitle}</p>
+        </div>
+
+        {sent ? (
+          <div className="mb-4 rounded-lg p-3 text-sm bg-[#5C2F0E]/10 border border-[#ABC0B9] text-[#5C2F0E] flex gap-2">
+            <MailCheck className="h-5 w-5 flex-shrink-0" />
+            {t.sent}
+          </div>
+        ) : (
+          <>
+            {error && (
+              <div className="mb-4 rounded-lg p-3 text-sm bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]">
+                {error}
+              </div>
+            )}
+
+            <form onSubmit={handleSubmit} className="space-y-4">
+              <div>
+                <label
+                  htmlFor="email"
+                  className="block text-sm font-medium text-[#2D363F] mb-1.5"
+                >
+                  {t.email}
+                </label>
+                <input
+                  id="email"
+                  type="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  placeholder={t.emailPlaceholder}
+                  required
+                  autoComplete="email"
+                  className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
+                />
+              </div>
+
+              <button
+                type="submit"
+                disabled={isLoading}
+                className="w-full rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+              >
+                {isLoading ? (
+                  <>
+                    <Loader2 className="h-4 w-4 animate-spin" />
+                    {t.sending}
+                  </>
+                ) : (
+                  t.send
+                )}
+              </button>
+            </form>
+          </>
+        )}
+
+        <div className="mt-6 pt-4 border-t border-[#ABC0B9] text-center">
+          <Link
+            href="/login"
+            className="text-sm text-[#5C2F0E] font-medium hover:underline inline-flex items-center gap-1"
+          >
+            <ArrowLeft className="h-3.5 w-3.5" />
+            {t.backToLogin}
+          </Link>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -1,11 +1,14 @@
 'use client';
 
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useRouter } from 'next/navigation';
 import Link from 'next/link';
 import { useAuth } from '@/contexts/AuthContext';
 import { useLanguage } from '@/contexts/LanguageContext';
-import { Globe, ChevronDown, Eye, EyeOff, Loader2, UserPlus } from 'lucide-react';
+import TwoFactorLoginStep from '@/components/auth/TwoFactorLoginStep';
+import { authApi } from '@/lib/api';
+import type { SSOConfig, TwoFactorChallenge } from '@/types';
+import { Globe, ChevronDown, Eye, EyeOff, Loader2, UserPlus, KeyRound } from 'lucide-react';
 
 export default function LoginPage() {
   const router = useRouter();
@@ -18,6 +21,9 @@ export default function LoginPage() {
   const [errorCode, setErrorCode] = useState('');
   const [isLoading, setIsLoading] = useState(false);
   const [showLangMenu, setShowLangMenu] = useState(false);
+  const [challenge, setChallenge] = useState<TwoFactorChallenge | null>(null);
+  const [sso, setSSO] = useState<SSOConfig | null>(null);
+  const [isStartingSSO, setIsStartingSSO] = useState(false);
 
   const text = {
     en: {
@@ -27,12 +33,20 @@ export default function LoginPage() {
       emailPlaceholder: 'Enter your email',
       password: 'Password',
       passwordPlaceholder: 'Enter your password',
+      forgotPassword: 'Forgot password?',
       signIn: 'Sign In',
       signingIn: 'Signing in...',
+      or: 'or',
+      signInWith: 'Sign in with',
+      ssoUnavailable: 'Single sign-on is unavailable right now. Please try again later.',
       loginError: 'Invalid email or password',
       pendingError: 'Your account is awaiting admin approval. Please wait for confirmation.',
       rejectedError: 'Your registration was rejected. Please contact the administrator.',
       disabledError: 'Your account has been disabled. Please contact the administrator.',
+      lockedError: 'Your account is temporarily locked after too many failed login attempts. Try again later or contact the administrator.',
+      tooManyAttemptsError: 'Too many failed login attempts. Please wait a moment and try again.',
+      directoryUnavailableError: "The company directory can't be reached. Please try again later.",
+      directoryConflictError: "Your directory account can't be linked to an account here. Please contact the administrator.",
       noAccount: "Don't have an account?",
       register: 'Register',
       languages: {
@@ -48,12 +62,20 @@ export default function LoginPage() {
       emailPlaceholder: '输入您的邮箱',
       password: '密码',
       passwordPlaceholder: '输入您的密码',
+      forgotPassword: '忘记密码？',
       signIn: '登录',
       signingIn: '登录中...',
+      or: '或',
+      signInWith: '使用',
+      ssoUnavailable: '单点登录暂时不可用，请稍后再试。',
       loginError: '邮箱或密码错误',
       pendingError: '您的账户正在等待管理员审批，请耐心等待确认。',
       rejectedError: '您的注册申请已被拒绝，请联系管理员。',
       disabledError: '您的账户已被禁用，请联系管理员。',
+      lockedError: '登录失败次数过多，您的账户已被暂时锁定。请稍后再试或联系管理员。',
+      tooManyAttemptsError: '登录失败次数过多，请稍候再试。',
+      directoryUnavailableError: '无法连接公司目录，请稍后再试。',
+      directoryConflictError: '您的目录账户无法关联到此处的账户，请联系管理员。',
       noAccount: '还没有账户？',
       register: '注册',
       languages: {
@@ -69,12 +91,20 @@ export default function LoginPage() {
       emailPlaceholder: 'Ingrese su correo electrónico',
       password: 'Contraseña',
       passwordPlaceholder: 'Ingrese su contraseña',
+      forgotPassword: '¿Olvidó su contraseña?',
       signIn: 'Iniciar Sesión',
       signingIn: 'Iniciando sesión...',
+      or: 'o',
+      signInWith: 'Iniciar sesión con',
+      ssoUnavailable: 'El inicio de sesión único no está disponible en este momento. Intente más tarde.',
       loginError: 'Correo electrónico o contraseña inválidos',
       pendingError: 'Su cuenta está pendiente de aprobación. Por favor espere la confirmación.',
       rejectedError: 'Su registro fue rechazado. Por favor contacte al administrador.',
       disabledError: 'Su cuenta ha sido deshabilitada. Por favor contacte al administrador.',
+      lockedError: 'Su cuenta está bloqueada temporalmente por demasiados intentos fallidos. Intente más tarde o contacte al administrador.',
+      tooManyAttemptsError: 'Demasiados intentos fallidos. Espere un momento e intente de nuevo.',
+      directoryUnavailableError: 'No se puede conectar con el directorio de la empresa. Intente más tarde.',
+      directoryConflictError: 'Su cuenta del directorio no se puede vincular a una cuenta aquí. Por favor contacte al administrador.',
       noAccount: '¿No tiene cuenta?',
       register: 'Registrarse',
       languages: {
@@ -93,6 +123,26 @@ export default function LoginPage() {
     es: 'ES',
   };
 
+  useEffect(() => {
+    authApi
+      .getSSOConfig()
+      .then(setSSO)
+      .catch(() => setSSO(null));
+  }, []);
+
+  // Sends the browser to the identity provider, which returns it to /sso/callback
+  const handleSSO = async () => {
+    setError('');
+    setErrorCode('');
+    setIsStartingSSO(true);
+    try {
+      window.location.href = await authApi.startSSO();
+    } catch {
+      setError(t.ssoUnavailable);
+      setIsStartingSSO(false);
+    }
+  };
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setError('');
@@ -100,12 +150,18 @@ export default function LoginPage() {
     setIsLoading(true);
 
     try {
-      await login({ email, password });
+      const twoFactorChallenge = await login({ email, password });
+      if (twoFactorChallenge) {
+        setChallenge(twoFactorChallenge);
+        return;
+      }
       router.push('/');
     } catch (err: unknown) {
-      // Check for specific error codes from the API
-      const apiError = err as { response?: { data?: { code?: string } } };
-      const code = apiError?.response?.data?.code;
+      // Check for specific error codes from the API; account status errors
+      // carry the code at the top level, others inside error
+      const apiError = err as { response?: { data?: { code?: string; error?: { code?: string } } } };
+      const data = apiError?.response?.data;
+      const code = data?.code || data?.error?.code;
       setErrorCode(code || '');
 
       if (code === 'PENDING_APPROVAL') {
@@ -114,6 +170,14 @@ export default function LoginPage() {
         setError(t.rejectedError);
       } else if (code === 'DISABLED') {
         setError(t.disabledError);
+      } else if (code === 'LOCKED') {
+        setError(t.lockedError);
+      } else if (code === 'TOO_MANY_ATTEMPTS') {
+        setError(t.tooManyAttemptsError);
+      } else if (code === 'DIRECTORY_UNAVAILABLE') {
+        setError(t.directoryUnavailableError);
+      } else if (code === 'DIRECTORY_ACCOUNT_CONFLICT') {
+        setError(t.directoryConflictError);
       } else {
         setError(t.loginError);
       }
@@ -182,107 +246,151 @@ export default function LoginPage() {
 
       {/* Login Form */}
       <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#ABC0B9]">
-        <div className="text-center mb-6">
-          <h2
-            className="text-xl text-[#2D363F] mb-1"
-            style={{ fontWeight: 600 }}
-          >
-            {t.welcomeBack}
-          </h2>
-          <p className="text-sm text-[#4E616F]">{t.signInToContinue}</p>
-        </div>
+        {challenge ? (
+          <TwoFactorLoginStep
+            challenge={challenge}
+            onCancel={() => {
+              setChallenge(null);
+              setPassword('');
+            }}
+          />
+        ) : (
+          <>
+            <div className="text-center mb-6">
+              <h2
+                className="text-xl text-[#2D363F] mb-1"
+                style={{ fontWeight: 600 }}
+              >
+                {t.welcomeBack}
+              </h2>
+              <p className="text-sm text-[#4E616F]">{t.signInToContinue}</p>
+            </div>
 
-        {error && (
-          <div className={`mb-4 rounded-lg p-3 text-sm ${
-            errorCode === 'PENDING_APPROVAL'
-              ? 'bg-[#F38756]/20 border border-amber-200 text-[#E95F20]'
-              : 'bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]'
-          }`}>
-            {error}
-          </div>
-        )}
+            {error && (
+              <div className={`mb-4 rounded-lg p-3 text-sm ${
+                errorCode === 'PENDING_APPROVAL'
+                  ? 'bg-[#F38756]/20 border border-amber-200 text-[#E95F20]'
+                  : 'bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]'
+              }`}>
+                {error}
+              </div>
+            )}
 
-        <form onSubmit={handleSubmit} className="space-y-4">
-          <div>
-            <label
-              htmlFor="email"
-              className="block text-sm font-medium text-[#2D363F] mb-1.5"
-            >
-              {t.email}
-            </label>
-            <input
-              id="email"
-              type="email"
-              value={email}
-              onChange={(e) => setEmail(e.target.value)}
-              placeholder={t.emailPlaceholder}
-              required
-              autoComplete="email"
-              className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
-            />
-          </div>
+            <form onSubmit={handleSubmit} className="space-y-4">
+              <div>
+                <label
+                  htmlFor="email"
+                  className="block text-sm font-medium text-[#2D363F] mb-1.5"
+                >
+                  {t.email}
+                </label>
+                <input
+                  id="email"
+                  type="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  placeholder={t.emailPlaceholder}
+                  required
+                  autoComplete="email"
+                  className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
+                />
+              </div>
+
+              <div>
+                <div className="flex items-center justify-between mb-1.5">
+                  <label
+                    htmlFor="password"
+                    className="block text-sm font-medium text-[#2D363F]"
+                  >
+                    {t.password}
+                  </label>
+                  <Link
+                    href="/forgot-password"
+                    className="text-xs text-[#5C2F0E] font-medium hover:underline"
+                  >
+                    {t.forgotPassword}
+                  </Link>
+                </div>
+                <div className="relative">
+                  <input
+                    id="password"
+                    type={showPassword ? 'text' : 'password'}
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    placeholder={t.passwordPlaceholder}
+                    required
+                    autoComplete="current-password"
+                    className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 pr-12 text-sm text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
+                  />
+                  <button
+                    type="button"
+                    onClick={() => setShowPassword(!showPassword)}
+                    className="absolute right-3 top-1/2 -translate-y-1/2 text-[#4E616F] hover:text-[#2D363F] transition-colors"
+                  >
+                    {showPassword ? (
+                      <EyeOff className="h-5 w-5" />
+                    ) : (
+                      <Eye className="h-5 w-5" />
+                    )}
+                  </button>
+                </div>
+              </div>
 
-          <div>
-            <label
-              htmlFor="password"
-              className="block text-sm font-medium text-[#2D363F] mb-1.5"
-            >
-              {t.password}
-            </label>
-            <div className="relative">
-              <input
-                id="password"
-                type={showPassword ? 'text' : 'password'}
-                value={password}
-                onChange={(e) => setPassword(e.target.value)}
-                placeholder={t.passwordPlaceholder}
-                required
-                autoComplete="current-password"
-                className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 pr-12 text-sm text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
-              />
               <button
-                type="button"
-                onClick={() => setShowPassword(!showPassword)}
-                className="absolute right-3 top-1/2 -translate-y-1/2 text-[#4E616F] hover:text-[#2D363F] transition-colors"
+                type="submit"
+                disabled={isLoading}
+                className="w-full rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
               >
-                {showPassword ? (
-                  <EyeOff className="h-5 w-5" />
+                {isLoading ? (
+                  <>
+                    <Loader2 className="h-4 w-4 animate-spin" />
+                    {t.signingIn}
+                  </>
                 ) : (
-                  <Eye className="h-5 w-5" />
+                  t.signIn
                 )}
               </button>
-            </div>
-          </div>
+            </form>
 
-          <button
-            type="submit"
-            disabled={isLoading}
-            className="w-full rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
-          >
-            {isLoading ? (
+            {/* Single sign-on */}
+            {sso?.enabled && (
               <>
-                <Loader2 className="h-4 w-4 animate-spin" />
-                {t.signingIn}
+                <div className="my-4 flex items-center gap-3">
+                  <div className="h-px flex-1 bg-[#ABC0B9]" />
+                  <span className="text-xs text-[#4E616F]">{t.or}</span>
+                  <div className="h-px flex-1 bg-[#ABC0B9]" />
+                </div>
+                <button
+                  type="button"
+                  onClick={handleSSO}
+                  disabled={isStartingSSO}
+                  className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm font-medium text-[#2D363F] transition-all hover:border-[#5C2F0E] hover:bg-[#FAFBFA] active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+                >
+                  {isStartingSSO ? (
+                    <Loader2 className="h-4 w-4 animate-spin" />
+                  ) : (
+                    <KeyRound className="h-4 w-4 text-[#5C2F0E]" />
+                  )}
+                  {t.signInWith} {sso.provider_name}
+                </button>
               </>
-            ) : (
-              t.signIn
             )}
-          </button>
-        </form>
 
-        {/* Register Link */}
-        <div className="mt-6 pt-4 border-t border-[#ABC0B9] text-center">
-          <p className="text-sm text-[#4E616F]">
-            {t.noAccount}{' '}
-            <Link
-              href="/register"
-              className="text-[#5C2F0E] font-medium hover:underline inline-flex items-center gap-1"
-            >
-              <UserPlus className="h-3.5 w-3.5" />
-              {t.register}
-            </Link>
-          </p>
-        </div>
+            {/* Register Link */}
+            <div className="mt-6 pt-4 border-t border-[#ABC0B9] text-center">
+              <p className="text-sm text-[#4E616F]">
+                {t.noAccount}{' '}
+                <Link
+                  href="/register"
+                  className="text-[#5C2F0E] font-medium hover:underline inline-flex items-center gap-1"
+                >
+                  <UserPlus className="h-3.5 w-3.5" />
+                  {t.register}
+                </Link>
+              </p>
+            </div>
+          </>
+        )}
       </div>
 
       {/* Demo credentials hint */}
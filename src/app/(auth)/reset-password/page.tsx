@@ -0,0 +1,213 @@
+'use client';
+
+import { Suspense, useState } from 'react';
+import { useRouter, useSearchParams } from 'next/navigation';
+import Link from 'next/link';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import { ArrowLeft, Eye, EyeOff, Loader2 } from 'lucide-react';
+
+function ResetPasswordForm() {
+  const router = useRouter();
+  const searchParams = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const { language } = useLanguage();
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [showPassword, setShowPassword] = useState(false);
+  const [error, setError] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Choose a new password',
+      subtitle: "You'll be signed out everywhere and can sign in with the new password",
+      password: 'New password',
+      confirmPassword: 'Confirm new password',
+      passwordPlaceholder: 'At least 8 characters',
+      submit: 'Reset Password',
+      submitting: 'Saving...',
+      mismatch: 'The passwords do not match',
+      tooShort: 'The password must be at least 8 characters',
+      invalidLink: 'This reset link is invalid or has expired. Please request a new one.',
+      error: 'Something went wrong. Please try again.',
+      requestNew: 'Request a new link',
+      backToLogin: 'Back to sign in',
+    },
+    zh: {
+      title: '设置新密码',
+      subtitle: '所有设备将退出登录，之后请使用新密码登录',
+      password: '新密码',
+      confirmPassword: '确认新密码',
+      passwordPlaceholder: '至少 8 个字符',
+      submit: '重置密码',
+      submitting: '保存中...',
+      mismatch: '两次输入的密码不一致',
+      tooShort: '密码至少需要 8 个字符',
+      invalidLink: '此重置链接无效或已过期，请重新申请。',
+      error: '出现错误，请重试。',
+      requestNew: '重新申请链接',
+      backToLogin: '返回登录',
+    },
+    es: {
+      title: 'Elija una nueva contraseña',
+      subtitle: 'Se cerrarán todas sus sesiones y podrá entrar con la nueva contraseña',
+      password: 'Nueva contraseña',
+      confirmPassword: 'Confirmar nueva contraseña',
+      passwordPlaceholder: 'Al menos 8 caracteres',
+      submit: 'Restablecer contraseña',
+      submitting: 'Guardando...',
+      mismatch: 'Las contraseñas no coinciden',
+      tooShort: 'La contraseña debe tener al menos 8 caracteres',
+      invalidLink: 'Este enlace no es válido o ha caducado. Solicite uno nuevo.',
+      error: 'Algo salió mal. Intente de nuevo.',
+      requestNew: 'Solicitar un nuevo enlace',
+      backToLogin: 'Volver a iniciar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+
+    if (password.length < 8) {
+      setError(t.tooShort);
+      return;
+    }
+    if (password !== confirmPassword) {
+      setError(t.mismatch);
+      return;
+    }
+
+    setIsLoading(true);
+    try {
+      await authApi.resetPassword(token, password);
+      router.push('/login');
+    } catch (err: unknown) {
+      const apiError = err as { response?: { status?: number } };
+      setError(apiError?.response?.status === 400 ? t.invalidLink : t.error);
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const inputClass =
+    'w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 pr-12 text-sm text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20';
+
+  return (
+    <div className="w-full max-w-md p-8">
+      <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#ABC0B9]">
+        <div className="text-center mb-6">
+          <h2
+            className="text-xl text-[#2D363F] mb-1"
+            style={{ fontWeight: 600 }}
+          >
+            {t.title}
+          </h2>
+          <p className="text-sm text-[#4E616F]">{t.subtitle}</p>
+        </div>
+
+        {!token ? (
+          <div className="mb-4 rounded-lg p-3 text-sm bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]">
+            {t.invalidLink}
+          </div>
+        ) : (
+          <>
+            {error && (
+              <div className="mb-4 rounded-lg p-3 text-sm bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]">
+                {error}
+              </div>
+            )}
+
+            <form onSubmit={handleSubmit} className="space-y-4">
+              <div>
+                <label
+                  htmlFor="password"
+                  className="block text-sm font-medium text-[#2D363F] mb-1.5"
+                >
+                  {t.password}
+                </label>
+                <div className="relative">
+                  <input
+                    id="password"
+                    type={showPassword ? 'text' : 'password'}
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    placeholder={t.passwordPlaceholder}
+                    required
+                    autoComplete="new-password"
+                    className={inputClass}
+                  />
+                  <button
+                    type="button"
+                    onClick={() => setShowPassword(!showPassword)}
+                    className="absolute right-3 top-1/2 -translate-y-1/2 text-[#4E616F] hover:text-[#2D363F] transition-colors"
+                  >
+                    {showPassword ? <EyeOff className="h-5 w-5" /> : <Eye className="h-5 w-5" />}
+                  </button>
+                </div>
+              </div>
+
+              <div>
+                <label
+                  htmlFor="confirmPassword"
+                  className="block text-sm font-medium text-[#2D363F] mb-1.5"
+                >
+                  {t.confirmPassword}
+                </label>
+                <input
+                  id="confirmPassword"
+                  type={showPassword ? 'text' : 'password'}
+                  value={confirmPassword}
+                  onChange={(e) => setConfirmPassword(e.target.value)}
+                  required
+                  autoComplete="new-password"
+                  className={inputClass}
+                />
+              </div>
+
+              <button
+                type="submit"
+                disabled={isLoading}
+                className="w-full rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+              >
+                {isLoading ? (
+                  <>
+                    <Loader2 className="h-4 w-4 animate-spin" />
+                    {t.submitting}
+                  </>
+                ) : (
+                  t.submit
+                )}
+              </button>
+            </form>
+          </>
+        )}
+
+        <div className="mt-6 pt-4 border-t border-[#ABC0B9] flex justify-between text-sm">
+          <Link
+            href="/login"
+            className="text-[#5C2F0E] font-medium hover:underline inline-flex items-center gap-1"
+          >
+            <ArrowLeft className="h-3.5 w-3.5" />
+            {t.backToLogin}
+          </Link>
+          <Link href="/forgot-password" className="text-[#5C2F0E] font-medium hover:underline">
+            {t.requestNew}
+          </Link>
+        </div>
+      </div>
+    </div>
+  );
+}
+
+export default function ResetPasswordPage() {
+  // useSearchParams needs a Suspense boundary
+  return (
+    <Suspense fallback={null}>
+      <ResetPasswordForm />
+    </Suspense>
+  );
+}
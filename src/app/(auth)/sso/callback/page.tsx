@@ -0,0 +1,162 @@
+'use client';
+
+import { Suspense, useEffect, useRef, useState } from 'react';
+import { useRouter, useSearchParams } from 'next/navigation';
+import Link from 'next/link';
+import { useAuth } from '@/contexts/AuthContext';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import TwoFactorLoginStep from '@/components/auth/TwoFactorLoginStep';
+import type { TwoFactorChallenge } from '@/types';
+import { ArrowLeft, Loader2 } from 'lucide-react';
+
+// The identity provider sends the browser back here with a code and state,
+// which the backend exchanges for a session
+function SSOCallback() {
+  const router = useRouter();
+  const searchParams = useSearchParams();
+  const { completeLogin } = useAuth();
+  const { language } = useLanguage();
+  const [error, setError] = useState('');
+  const [errorCode, setErrorCode] = useState('');
+  const [challenge, setChallenge] = useState<TwoFactorChallenge | null>(null);
+  // The code can only be redeemed once, even when effects run twice in development
+  const started = useRef(false);
+
+  const text = {
+    en: {
+      signingIn: 'Signing you in...',
+      failedTitle: 'Sign-in failed',
+      pendingError: 'Your account was created and is awaiting admin approval. You can sign in once it has been approved.',
+      rejectedError: 'Your registration was rejected. Please contact the administrator.',
+      disabledError: 'Your account has been disabled. Please contact the administrator.',
+      expiredError: 'This sign-in attempt has expired. Please try again.',
+      conflictError: "Your identity provider account can't be linked to an account here. Please contact the administrator.",
+      cancelledError: 'Sign-in was cancelled at the identity provider.',
+      failedError: 'Single sign-on failed. Please try again.',
+      backToLogin: 'Back to sign in',
+    },
+    zh: {
+      signingIn: '正在登录...',
+      failedTitle: '登录失败',
+      pendingError: '您的账户已创建，正在等待管理员审批。审批通过后即可登录。',
+      rejectedError: '您的注册申请已被拒绝，请联系管理员。',
+      disabledError: '您的账户已被禁用，请联系管理员。',
+      expiredError: '此次登录已过期，请重试。',
+      conflictError: '您的身份提供商账户无法关联到此处的账户，请联系管理员。',
+      cancelledError: '已在身份提供商处取消登录。',
+      failedError: '单点登录失败，请重试。',
+      backToLogin: '返回登录',
+    },
+    es: {
+      signingIn: 'Iniciando sesión...',
+      failedTitle: 'Error al iniciar sesión',
+      pendingError: 'Su cuenta fue creada y está pendiente de aprobación. Podrá iniciar sesión cuando sea aprobada.',
+      rejectedError: 'Su registro fue rechazado. Por favor contacte al administrador.',
+      disabledError: 'Su cuenta ha sido deshabilitada. Por favor contacte al administrador.',
+      expiredError: 'Este intento de inicio de sesión caducó. Intente de nuevo.',
+      conflictError: 'Su cuenta del proveedor de identidad no se puede vincular a una cuenta aquí. Por favor contacte al administrador.',
+      cancelledError: 'El inicio de sesión se canceló en el proveedor de identidad.',
+      failedError: 'El inicio de sesión único falló. Intente de nuevo.',
+      backToLogin: 'Volver a iniciar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  useEffect(() => {
+    if (started.current) return;
+    started.current = true;
+
+    const code = searchParams.get('code');
+    const state = searchParams.get('state');
+    if (searchParams.get('error') || !code || !state) {
+      setError(searchParams.get('error') === 'access_denied' ? t.cancelledError : t.failedError);
+      return;
+    }
+
+    authApi
+      .completeSSO(code, state)
+      .then((response) => {
+        if ('two_factor_required' in response) {
+          setChallenge(response);
+          return;
+        }
+        completeLogin(response);
+        router.replace('/');
+      })
+      .catch((err: unknown) => {
+        // Account status errors carry the code at the top level, like login
+        const apiError = err as { response?: { data?: { code?: string; error?: { code?: string } } } };
+        const data = apiError?.response?.data;
+        const code = data?.code || data?.error?.code || '';
+        setErrorCode(code);
+
+        if (code === 'PENDING_APPROVAL') {
+          setError(t.pendingError);
+        } else if (code === 'REJECTED') {
+          setError(t.rejectedError);
+        } else if (code === 'DISABLED') {
+          setError(t.disabledError);
+        } else if (code === 'INVALID_STATE') {
+          setError(t.expiredError);
+        } else if (code === 'SSO_ACCOUNT_CONFLICT') {
+          setError(t.conflictError);
+        } else {
+          setError(t.failedError);
+        }
+      });
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  return (
+    <div className="w-full max-w-md p-8">
+      <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#ABC0B9]">
+        {challenge ? (
+          <TwoFactorLoginStep challenge={challenge} onCancel={() => router.push('/login')} />
+        ) : error ? (
+          <>
+            <div className="text-center mb-6">
+              <h2
+                className="text-xl text-[#2D363F] mb-1"
+                style={{ fontWeight: 600 }}
+              >
+                {t.failedTitle}
+              </h2>
+            </div>
+            <div className={`mb-4 rounded-lg p-3 text-sm ${
+              errorCode === 'PENDING_APPROVAL'
+                ? 'bg-[#F38756]/20 border border-amber-200 text-[#E95F20]'
+                : 'bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]'
+            }`}>
+              {error}
+            </div>
+            <div className="mt-6 pt-4 border-t border-[#ABC0B9] text-center">
+              <Link
+                href="/login"
+                className="text-sm text-[#5C2F0E] font-medium hover:underline inline-flex items-center gap-1"
+              >
+                <ArrowLeft className="h-3.5 w-3.5" />
+                {t.backToLogin}
+              </Link>
+            </div>
+          </>
+        ) : (
+          <div className="flex flex-col items-center gap-3 py-8 text-sm text-[#4E616F]">
+            <Loader2 className="h-6 w-6 animate-spin text-[#5C2F0E]" />
+            {t.signingIn}
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
+
+export default function SSOCallbackPage() {
+  // useSearchParams needs a Suspense boundary
+  return (
+    <Suspense fallback={null}>
+      <SSOCallback />
+    </Suspense>
+  );
+}
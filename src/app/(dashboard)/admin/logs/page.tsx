@@ -21,11 +21,12 @@ import {
   UserPlus,
   Key,
   Shield,
+  Lock,
   X,
 } from 'lucide-react';
 
 export default function ActivityLogsPage() {
-  const { user } = useAuth();
+  const { hasPermission } = useAuth();
   const { language } = useLanguage();
   const [stats, setStats] = useState<ActivityStats | null>(null);
   const [logs, setLogs] = useState<ActivityLog[]>([]);
@@ -52,6 +53,7 @@ export default function ActivityLogsPage() {
         uniqueUsers: 'Unique Users',
         todayLogins: 'Today Logins',
         todayFailed: 'Today Failed',
+        lockedLogins: 'Locked Logins',
       },
       tabs: {
         all: 'All Activity',
@@ -62,6 +64,7 @@ export default function ActivityLogsPage() {
         all: 'All',
         login: 'Login',
         loginFailed: 'Login Failed',
+        loginRefused: 'Login Refused',
         logout: 'Logout',
         registration: 'Registration',
         success: 'Status',
@@ -92,6 +95,13 @@ export default function ActivityLogsPage() {
         registration: 'Registration',
         token_refresh: 'Token Refresh',
         password_reset: 'Password Reset',
+        token_reuse: 'Token Reuse Blocked',
+        login_locked: 'Login Locked',
+        login_unlocked: 'Login Unlocked',
+        login_refused: 'Login Refused',
+        two_factor_enabled: '2FA Enabled',
+        two_factor_disabled: '2FA Disabled',
+        recovery_code_used: 'Recovery Code Used',
       },
       pagination: {
         showing: 'Showing',
@@ -112,6 +122,7 @@ export default function ActivityLogsPage() {
         uniqueUsers: '独立用户',
         todayLogins: '今日登录',
         todayFailed: '今日失败',
+        lockedLogins: '锁定登录',
       },
       tabs: {
         all: '所有活动',
@@ -122,6 +133,7 @@ export default function ActivityLogsPage() {
         all: '全部',
         login: '登录',
         loginFailed: '登录失败',
+        loginRefused: '登录被拒绝',
         logout: '登出',
         registration: '注册',
         success: '状态',
@@ -152,6 +164,13 @@ export default function ActivityLogsPage() {
         registration: '注册',
         token_refresh: '令牌刷新',
         password_reset: '密码重置',
+        token_reuse: '令牌重复使用已拦截',
+        login_locked: '登录已锁定',
+        login_unlocked: '登录已解锁',
+        login_refused: '登录被拒绝',
+        two_factor_enabled: '双重验证已开启',
+        two_factor_disabled: '双重验证已关闭',
+        recovery_code_used: '已使用恢复码',
       },
       pagination: {
         showing: '显示',
@@ -172,6 +191,7 @@ export default function ActivityLogsPage() {
         uniqueUsers: 'Usuarios Únicos',
         todayLogins: 'Hoy Inicios',
         todayFailed: 'Hoy Fallidos',
+        lockedLogins: 'Accesos Bloqueados',
       },
       tabs: {
         all: 'Toda la Actividad',
@@ -182,6 +202,7 @@ export default function ActivityLogsPage() {
         all: 'Todos',
         login: 'Inicio de Sesión',
         loginFailed: 'Inicio Fallido',
+        loginRefused: 'Acceso Rechazado',
         logout: 'Cierre de Sesión',
         registration: 'Registro',
         success: 'Estado',
@@ -212,6 +233,13 @@ export default function ActivityLogsPage() {
         registration: 'Registro',
         token_refresh: 'Actualización de Token',
         password_reset: 'Restablecimiento de Contraseña',
+        token_reuse: 'Reutilización de Token Bloqueada',
+        login_locked: 'Acceso Bloqueado',
+        login_unlocked: 'Acceso Desbloqueado',
+        login_refused: 'Acceso Rechazado',
+        two_factor_enabled: '2FA Activada',
+        two_factor_disabled: '2FA Desactivada',
+        recovery_code_used: 'Código de Recuperación Usado',
       },
       pagination: {
         showing: 'Mostrando',
@@ -310,6 +338,7 @@ export default function ActivityLogsPage() {
       case 'login':
         return <LogIn className="h-4 w-4" />;
       case 'login_failed':
+      case 'login_refused':
         return <AlertTriangle className="h-4 w-4" />;
       case 'logout':
         return <LogOut className="h-4 w-4" />;
@@ -318,7 +347,14 @@ export default function ActivityLogsPage() {
       case 'token_refresh':
         return <Key className="h-4 w-4" />;
       case 'password_reset':
+      case 'token_reuse':
+      case 'two_factor_enabled':
+      case 'two_factor_disabled':
+      case 'recovery_code_used':
         return <Shield className="h-4 w-4" />;
+      case 'login_locked':
+      case 'login_unlocked':
+        return <Lock className="h-4 w-4" />;
       default:
         return <Activity className="h-4 w-4" />;
     }
@@ -346,7 +382,7 @@ export default function ActivityLogsPage() {
     setPage(1);
   };
 
-  if (user?.role !== 'admin') {
+  if (!hasPermission('logs.view')) {
     return (
       <div className="flex items-center justify-center min-h-[400px]">
         <p className="text-[#4E616F]">{t.accessDenied}</p>
@@ -372,7 +408,7 @@ export default function ActivityLogsPage() {
 
       {/* Stats Cards */}
       {stats && (
-        <div className="grid grid-cols-2 sm:grid-cols-3 lg:grid-cols-6 gap-3 sm:gap-4">
+        <div className="grid grid-cols-2 sm:grid-cols-3 lg:grid-cols-7 gap-3 sm:gap-4">
           <div className="bg-white rounded-xl border border-[#ABC0B9] p-4">
             <div className="flex items-center gap-2 text-[#5C2F0E] mb-2">
               <CheckCircle className="h-5 w-5" />
@@ -415,6 +451,13 @@ export default function ActivityLogsPage() {
             </div>
             <p className="text-2xl font-bold text-[#2D363F]">{stats.today_failed_logins.toLocaleString()}</p>
           </div>
+          <div className="bg-white rounded-xl border border-[#ABC0B9] p-4">
+            <div className="flex items-center gap-2 text-[#AA2F0D] mb-2">
+              <Lock className="h-5 w-5" />
+              <span className="text-sm font-medium">{t.stats.lockedLogins}</span>
+            </div>
+            <p className="text-2xl font-bold text-[#2D363F]">{stats.locked_logins.toLocaleString()}</p>
+          </div>
         </div>
       )}
 
@@ -477,6 +520,7 @@ export default function ActivityLogsPage() {
                 <option value="">{t.filters.all}</option>
                 <option value="login">{t.filters.login}</option>
                 <option value="login_failed">{t.filters.loginFailed}</option>
+                <option value="login_refused">{t.filters.loginRefused}</option>
                 <option value="logout">{t.filters.logout}</option>
                 <option value="registration">{t.filters.registration}</option>
               </select>
@@ -19,16 +19,28 @@ import {
   Upload,
   Download,
   FileText,
+  LockOpen,
+  ShieldOff,
+  FolderSync,
+  KeyRound,
+  ShieldCheck,
+  Building2,
 } from 'lucide-react';
 import { useLanguage } from '@/contexts/LanguageContext';
-import { usersApi } from '@/lib/api';
+import { useAuth } from '@/contexts/AuthContext';
+import { usersApi, directoryApi, rolesApi } from '@/lib/api';
 import { Badge } from '@/components/ui/badge';
-import type { User, PendingUser, UserRole, ApproveUserPayload } from '@/types';
+import { DirectorySyncModal } from '@/components/admin/DirectorySyncModal';
+import { ServiceAccountsModal } from '@/components/admin/ServiceAccountsModal';
+import { RolesModal } from '@/components/admin/RolesModal';
+import { CompaniesModal } from '@/components/admin/CompaniesModal';
+import type { User, PendingUser, UserRole, ApproveUserPayload, DirectoryStatus, Role } from '@/types';
 
 type TabId = 'all' | 'pending';
 
 export default function UsersPage() {
   const { language } = useLanguage();
+  const { hasPermission } = useAuth();
   const [activeTab, setActiveTab] = useState<TabId>('all');
   const [users, setUsers] = useState<User[]>([]);
   const [pendingUsers, setPendingUsers] = useState<PendingUser[]>([]);
@@ -69,6 +81,16 @@ export default function UsersPage() {
   } | null>(null);
   const [isImporting, setIsImporting] = useState(false);
 
+  // Directory (LDAP) sync, offered when a directory is connected
+  const [directoryStatus, setDirectoryStatus] = useState<DirectoryStatus | null>(null);
+  const [showDirectoryModal, setShowDirectoryModal] = useState(false);
+  const [showServiceAccountsModal, setShowServiceAccountsModal] = useState(false);
+
+  // Roles users can be given, including ones defined by admins
+  const [roles, setRoles] = useState<Role[]>([]);
+  const [showRolesModal, setShowRolesModal] = useState(false);
+  const [showCompaniesModal, setShowCompaniesModal] = useState(false);
+
   const text = {
     en: {
       title: 'User Management',
@@ -89,6 +111,11 @@ export default function UsersPage() {
       pending: 'Pending',
       rejected: 'Rejected',
       disabled: 'Disabled',
+      unlock: 'Unlock login',
+      unlocked: 'Login unlocked for',
+      resetTwoFactor: 'Reset two-factor authentication',
+      resetTwoFactorConfirm: 'Reset two-factor authentication for',
+      twoFactorReset: 'Two-factor authentication reset for',
       edit: 'Edit',
       delete: 'Delete',
       approve: 'Approve',
@@ -118,6 +145,10 @@ export default function UsersPage() {
         employee: 'Employee',
       },
       importUsers: 'Import Users',
+      directorySync: 'Directory Sync',
+      serviceAccounts: 'Service Accounts',
+      manageRoles: 'Roles',
+      manageCompanies: 'Companies',
       downloadTemplate: 'Download Template',
       importFromCSV: 'Import from CSV',
       csvPlaceholder: 'Paste CSV data here or upload a file...\n\nFormat: employee_number,email,password,name,role,company_code,cost_center,department',
@@ -147,6 +178,11 @@ export default function UsersPage() {
       pending: '待审批',
       rejected: '已拒绝',
       disabled: '已禁用',
+      unlock: '解除登录锁定',
+      unlocked: '已解除登录锁定：',
+      resetTwoFactor: '重置双重验证',
+      resetTwoFactorConfirm: '确定重置以下用户的双重验证：',
+      twoFactorReset: '已重置双重验证：',
       edit: '编辑',
       delete: '删除',
       approve: '批准',
@@ -176,6 +212,10 @@ export default function UsersPage() {
         employee: '员工',
       },
       importUsers: '导入用户',
+      directorySync: '目录同步',
+      serviceAccounts: '服务账户',
+      manageRoles: '角色',
+      manageCompanies: '公司',
       downloadTemplate: '下载模板',
       importFromCSV: '从CSV导入',
       csvPlaceholder: '在此粘贴CSV数据或上传文件...\n\n格式: employee_number,email,password,name,role,company_code,cost_center,department',
@@ -205,6 +245,11 @@ export default function UsersPage() {
       pending: 'Pendiente',
       rejected: 'Rechazado',
       disabled: 'Deshabilitado',
+      unlock: 'Desbloquear acceso',
+      unlocked: 'Acceso desbloqueado para',
+      resetTwoFactor: 'Restablecer verificación en dos pasos',
+      resetTwoFactorConfirm: '¿Restablecer la verificación en dos pasos de',
+      twoFactorReset: 'Verificación en dos pasos restablecida para',
       edit: 'Editar',
       delete: 'Eliminar',
       approve: 'Aprobar',
@@ -234,6 +279,10 @@ export default function UsersPage() {
         employee: 'Empleado',
       },
       importUsers: 'Importar Usuarios',
+      directorySync: 'Sincronizar Directorio',
+      serviceAccounts: 'Cuentas de Servicio',
+      manageRoles: 'Roles',
+      manageCompanies: 'Empresas',
       downloadTemplate: 'Descargar Plantilla',
       importFromCSV: 'Importar desde CSV',
       csvPlaceholder: 'Pegue datos CSV aqui o suba un archivo...\n\nFormato: employee_number,email,password,name,role,company_code,cost_center,department',
@@ -275,6 +324,37 @@ export default function UsersPage() {
     fetchData();
   }, [fetchUsers, fetchPendingUsers]);
 
+  const fetchDirectoryStatus = useCallback(async () => {
+    try {
+      setDirectoryStatus(await directoryApi.getStatus());
+    } catch (error) {
+      console.error('Failed to fetch directory status:', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchDirectoryStatus();
+  }, [fetchDirectoryStatus]);
+
+  const fetchRoles = useCallback(async () => {
+    try {
+      setRoles(await rolesApi.list());
+    } catch (error) {
+      console.error('Failed to fetch roles:', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchRoles();
+  }, [fetchRoles]);
+
+  // Built-in roles use their translated name, roles defined by admins their display name
+  const roleLabels: Record<string, string> = roles.length > 0
+    ? Object.fromEntries(
+        roles.map((role) => [role.name, t.roles[role.name as keyof typeof t.roles] || role.display_name || role.name])
+      )
+    : t.roles;
+
   const handleOpenModal = (user?: User) => {
     if (user) {
       setEditingUser(user);
@@ -392,6 +472,28 @@ export default function UsersPage() {
     }
   };
 
+  const handleUnlock = async (user: User) => {
+    try {
+      await usersApi.unlock(user.id);
+      alert(`${t.unlocked} ${user.name}`);
+    } catch (error) {
+      console.error('Failed to unlock user:', error);
+    }
+  };
+
+  // For users who lost their authenticator; they sign in again and, if their
+  // role requires it, set two-factor authentication up again
+  const handleResetTwoFactor = async (user: User) => {
+    if (!confirm(`${t.resetTwoFactorConfirm} ${user.name}?`)) return;
+    try {
+      await usersApi.resetTwoFactor(user.id);
+      alert(`${t.twoFactorReset} ${user.name}`);
+      fetchUsers();
+    } catch (error) {
+      console.error('Failed to reset two-factor authentication:', error);
+    }
+  };
+
   const handleDelete = async (user: User) => {
     if (!confirm(`Delete user ${user.name}?`)) return;
     try {
@@ -591,6 +693,40 @@ export default function UsersPage() {
             <p className="text-base text-[#4E616F]">{t.subtitle}</p>
           </div>
           <div className="flex gap-3">
+            {hasPermission('roles.manage') && (
+              <button
+                onClick={() => setShowRolesModal(true)}
+                className="flex items-center gap-2 rounded-lg border border-[#5C2F0E] px-5 py-3 text-[#5C2F0E] font-medium transition-all hover:bg-[#5C2F0E]/5 active:scale-95"
+              >
+                <ShieldCheck className="h-5 w-5" />
+                {t.manageRoles}
+              </button>
+            )}
+            {hasPermission('companies.manage') && (
+              <button
+                onClick={() => setShowCompaniesModal(true)}
+                className="flex items-center gap-2 rounded-lg border border-[#5C2F0E] px-5 py-3 text-[#5C2F0E] font-medium transition-all hover:bg-[#5C2F0E]/5 active:scale-95"
+              >
+                <Building2 className="h-5 w-5" />
+                {t.manageCompanies}
+              </button>
+            )}
+            <button
+              onClick={() => setShowServiceAccountsModal(true)}
+              className="flex items-center gap-2 rounded-lg border border-[#5C2F0E] px-5 py-3 text-[#5C2F0E] font-medium transition-all hover:bg-[#5C2F0E]/5 active:scale-95"
+            >
+              <KeyRound className="h-5 w-5" />
+              {t.serviceAccounts}
+            </button>
+            {directoryStatus?.enabled && (
+              <button
+                onClick={() => setShowDirectoryModal(true)}
+                className="flex items-center gap-2 rounded-lg border border-[#5C2F0E] px-5 py-3 text-[#5C2F0E] font-medium transition-all hover:bg-[#5C2F0E]/5 active:scale-95"
+              >
+                <FolderSync className="h-5 w-5" />
+                {t.directorySync}
+              </button>
+            )}
             <button
               onClick={() => {
                 setShowImportModal(true);
@@ -715,7 +851,7 @@ export default function UsersPage() {
                         <td className="px-6 py-4 text-sm text-[#4E616F]">{user.email}</td>
                         <td className="px-6 py-4">
                           <Badge className="bg-[#5C2F0E]/10 text-[#5C2F0E] hover:bg-[#5C2F0E]/10 border-0">
-                            {t.roles[user.role as keyof typeof t.roles] || user.role}
+                            {roleLabels[user.role] || user.role}
                           </Badge>
                         </td>
                         <td className="px-6 py-4">
@@ -748,6 +884,22 @@ export default function UsersPage() {
                             >
                               <Edit className="h-4 w-4" />
                             </button>
+                            <button
+                              onClick={() => handleUnlock(user)}
+                              title={t.unlock}
+                              className="p-2 text-[#4E616F] hover:bg-[#4E616F]/10 rounded-lg transition-colors"
+                            >
+                              <LockOpen className="h-4 w-4" />
+                            </button>
+                            {user.two_factor_enabled_at && (
+                              <button
+                                onClick={() => handleResetTwoFactor(user)}
+                                title={t.resetTwoFactor}
+                                className="p-2 text-[#4E616F] hover:bg-[#4E616F]/10 rounded-lg transition-colors"
+                              >
+                                <ShieldOff className="h-4 w-4" />
+                              </button>
+                            )}
                             <button
                               onClick={() => handleDelete(user)}
                               className="p-2 text-[#AA2F0D] hover:bg-[#AA2F0D]/10 rounded-lg transition-colors"
@@ -896,7 +1048,7 @@ export default function UsersPage() {
                   }
                   className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm text-[#2D363F] transition-all focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
                 >
-                  {Object.entries(t.roles).map(([key, label]) => (
+                  {Object.entries(roleLabels).map(([key, label]) => (
                     <option key={key} value={key}>
                       {label}
                     </option>
@@ -994,7 +1146,7 @@ export default function UsersPage() {
                     }
                     className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm text-[#2D363F] transition-all focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
                   >
-                    {Object.entries(t.roles).map(([key, label]) => (
+                    {Object.entries(roleLabels).map(([key, label]) => (
                       <option key={key} value={key}>
                         {label}
                       </option>
@@ -1214,6 +1366,46 @@ export default function UsersPage() {
           </div>
         </div>
       )}
+
+      {/* Directory Sync Modal */}
+      {showDirectoryModal && directoryStatus && (
+        <DirectorySyncModal
+          status={directoryStatus}
+          onClose={() => {
+            setShowDirectoryModal(false);
+            fetchDirectoryStatus();
+          }}
+          onSynced={() => {
+            fetchUsers();
+            fetchPendingUsers();
+          }}
+        />
+      )}
+
+      {/* Service Accounts Modal */}
+      {showServiceAccountsModal && (
+        <ServiceAccountsModal
+          roleLabels={roleLabels}
+          onClose={() => setShowServiceAccountsModal(false)}
+        />
+      )}
+
+      {/* Roles Modal */}
+      {showRolesModal && (
+        <RolesModal
+          roleLabels={roleLabels}
+          onClose={() => setShowRolesModal(false)}
+          onChanged={fetchRoles}
+        />
+      )}
+
+      {/* Companies Modal */}
+      {showCompaniesModal && (
+        <CompaniesModal
+          onClose={() => setShowCompaniesModal(false)}
+          onChanged={fetchUsers}
+        />
+      )}
     </div>
   );
 }
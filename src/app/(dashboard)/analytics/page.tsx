@@ -98,7 +98,7 @@ const DonutChart = ({ segments }: { segments: { value: number; color: string; la
 
 export default function AnalyticsPage() {
   const { language } = useLanguage();
-  const { user } = useAuth();
+  const { user, hasPermission } = useAuth();
   const [isLoading, setIsLoading] = useState(true);
   const [approvalStats, setApprovalStats] = useState<ApprovalStats | null>(null);
   const [dashboardStats, setDashboardStats] = useState<DashboardStats | null>(null);
@@ -224,7 +224,7 @@ export default function AnalyticsPage() {
         setRecentRequests(recentData.data || []);
 
         // Try to get dashboard stats (admin only)
-        if (user?.role === 'admin' || user?.role === 'general_manager') {
+        if (hasPermission('settings.manage')) {
           try {
             const dashboardData = await adminApi.getDashboardStats();
             setDashboardStats(dashboardData);
@@ -173,11 +173,11 @@ const getDisplayNumber = (request: PurchaseRequest): string => {
 
 export default function ApprovalsPage() {
   const { language, setLanguage } = useLanguage();
-  const { user } = useAuth();
+  const { hasPermission } = useAuth();
   const [approvals, setApprovals] = useState<PurchaseRequest[]>([]);
   const [isLoading, setIsLoading] = useState(true);
-  // GM, Admin, and Purchase Admin see all requests by default
-  const canViewAll = user?.role === 'general_manager' || user?.role === 'admin' || user?.role === 'purchase_admin';
+  // Everyone who reviews approvals sees all requests by default
+  const canViewAll = hasPermission('approvals.view');
   const [selectedTab, setSelectedTab] = useState(canViewAll ? 'all' : 'pending');
   const [selectedApproval, setSelectedApproval] = useState<PurchaseRequest | null>(null);
   const [comment, setComment] = useState('');
@@ -194,8 +194,8 @@ export default function ApprovalsPage() {
   const [streamingText, setStreamingText] = useState('');
   const [isThinking, setIsThinking] = useState(false);
 
-  // Only general_manager can approve/reject - admin can only view
-  const canApprove = user?.role === 'general_manager';
+  // Only roles with approvals.decide can approve/reject - others can only view
+  const canApprove = hasPermission('approvals.decide');
 
   const text = {
     en: {
@@ -5,6 +5,7 @@ import { Search, ShoppingCart, AlertCircle, CheckCircle, X, Loader2 } from 'luci
 import { useLanguage } from '@/contexts/LanguageContext';
 import { useAuth } from '@/contexts/AuthContext';
 import { productsApi, purchaseRequestsApi, type CreatePurchaseRequestInput, type CreatePurchaseRequestItemInput } from '@/lib/api';
+import { getLocalizedField } from '@/lib/translations';
 import { Badge } from '@/components/ui/badge';
 import type { Product } from '@/types';
 
@@ -26,7 +27,7 @@ const formatPrice = (price: number): string => {
 };
 
 export default function CatalogPage() {
-  const { language } = useLanguage();
+  const { language, contentLanguage } = useLanguage();
   const { user } = useAuth();
   const [products, setProducts] = useState<Product[]>([]);
   const [categories, setCategories] = useState<string[]>([]);
@@ -187,17 +188,11 @@ export default function CatalogPage() {
     fetchData();
   }, []);
 
-  const getProductName = (product: Product) => {
-    if (language === 'zh') return product.name_zh || product.name;
-    if (language === 'es') return product.name_es || product.name;
-    return product.name;
-  };
+  const getProductName = (product: Product) =>
+    getLocalizedField(product.name_translations, product.name, contentLanguage);
 
-  const getProductSpec = (product: Product) => {
-    if (language === 'zh') return product.spec_zh || product.specification;
-    if (language === 'es') return product.spec_es || product.specification;
-    return product.specification;
-  };
+  const getProductSpec = (product: Product) =>
+    getLocalizedField(product.spec_translations, product.specification, contentLanguage);
 
   const filteredProducts = products
     .filter((product) => selectedCategory === 'all' || product.category === selectedCategory)
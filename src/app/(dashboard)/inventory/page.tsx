@@ -2,7 +2,7 @@
 
 import { useState, useEffect, useCallback, useRef } from 'react';
 import { Package, Plus, Search, Edit2, Trash2, Upload, X, AlertCircle, ImageIcon, ExternalLink, Download, FileSpreadsheet } from 'lucide-react';
-import { useLanguage } from '@/contexts/LanguageContext';
+import { useLanguage, useEnabledLanguages } from '@/contexts/LanguageContext';
 
 // Format price to show all significant decimals (minimum 2)
 const formatPrice = (price: number): string => {
@@ -21,14 +21,13 @@ import { Product, ProductImage, ApiResponse } from '@/types';
 interface ProductFormData {
   sku: string;
   name: string;
-  name_zh: string;
-  name_es: string;
+  // Language code -> name, for the enabled languages other than English
+  name_translations: Record<string, string>;
   description: string;
   category: string;
   model: string;
   specification: string;
-  spec_zh: string;
-  spec_es: string;
+  spec_translations: Record<string, string>;
   supplier: string;
   supplier_code: string;
   price: number;
@@ -59,14 +58,12 @@ interface UploadRequirements {
 const emptyFormData: ProductFormData = {
   sku: '',
   name: '',
-  name_zh: '',
-  name_es: '',
+  name_translations: {},
   description: '',
   category: '',
   model: '',
   specification: '',
-  spec_zh: '',
-  spec_es: '',
+  spec_translations: {},
   supplier: '',
   supplier_code: '',
   price: 0,
@@ -87,9 +84,19 @@ const emptyFormData: ProductFormData = {
   brand: '',
 };
 
+// Returns the translations that differ from the product's current ones. Only
+// edited languages are sent, so saving a product doesn't turn its machine
+// translations into human ones that are never updated again.
+const editedTranslations = (
+  translations: Record<string, string>,
+  original: Record<string, string> = {}
+): Record<string, string> =>
+  Object.fromEntries(Object.entries(translations).filter(([code, text]) => text !== (original[code] ?? '')));
+
 export default function InventoryPage() {
   const { language } = useLanguage();
-  const { user } = useAuth();
+  const translationLanguages = useEnabledLanguages().filter((lang) => lang.code !== 'en');
+  const { hasPermission } = useAuth();
   const [products, setProducts] = useState<Product[]>([]);
   const [loading, setLoading] = useState(true);
   const [searchTerm, setSearchTerm] = useState('');
@@ -120,7 +127,7 @@ export default function InventoryPage() {
   } | null>(null);
   const csvFileInputRef = useRef<HTMLInputElement>(null);
 
-  const canEdit = user?.role === 'admin' || user?.role === 'purchase_admin' || user?.role === 'supply_chain_manager';
+  const canEdit = hasPermission('products.manage');
 
   const text = {
     en: {
@@ -134,14 +141,10 @@ export default function InventoryPage() {
       sku: 'SKU',
       name: 'Name',
       nameEn: 'Name (English)',
-      nameZh: 'Name (Chinese)',
-      nameEs: 'Name (Spanish)',
       description: 'Description',
       model: 'Model',
       specification: 'Specification',
       specEn: 'Specification (English)',
-      specZh: 'Specification (Chinese)',
-      specEs: 'Specification (Spanish)',
       supplier: 'Supplier',
       supplierCode: 'Supplier Code',
       price: 'Price',
@@ -203,14 +206,10 @@ export default function InventoryPage() {
       sku: 'SKU',
       name: '名称',
       nameEn: '名称（英文）',
-      nameZh: '名称（中文）',
-      nameEs: '名称（西班牙语）',
       description: '描述',
       model: '型号',
       specification: '规格',
       specEn: '规格（英文）',
-      specZh: '规格（中文）',
-      specEs: '规格（西班牙语）',
       supplier: '供应商',
       supplierCode: '供应商编码',
       price: '价格',
@@ -272,14 +271,10 @@ export default function InventoryPage() {
       sku: 'SKU',
       name: 'Nombre',
       nameEn: 'Nombre (Inglés)',
-      nameZh: 'Nombre (Chino)',
-      nameEs: 'Nombre (Español)',
       description: 'Descripción',
       model: 'Modelo',
       specification: 'Especificación',
       specEn: 'Especificación (Inglés)',
-      specZh: 'Especificación (Chino)',
-      specEs: 'Especificación (Español)',
       supplier: 'Proveedor',
       supplierCode: 'Código de Proveedor',
       price: 'Precio',
@@ -396,14 +391,12 @@ export default function InventoryPage() {
       setFormData({
         sku: product.sku,
         name: product.name,
-        name_zh: product.name_zh || '',
-        name_es: product.name_es || '',
+        name_translations: { ...product.name_translations },
         description: product.description || '',
         category: product.category,
         model: product.model,
         specification: product.specification,
-        spec_zh: product.spec_zh || '',
-        spec_es: product.spec_es || '',
+        spec_translations: { ...product.spec_translations },
         supplier: product.supplier,
         supplier_code: product.supplier_code || '',
         price: product.price,
@@ -453,10 +446,15 @@ export default function InventoryPage() {
     setError('');
 
     try {
+      const data = {
+        ...formData,
+        name_translations: editedTranslations(formData.name_translations, editingProduct?.name_translations),
+        spec_translations: editedTranslations(formData.spec_translations, editingProduct?.spec_translations),
+      };
       if (editingProduct) {
-        await api.put(`/products/${editingProduct.id}`, formData);
+        await api.put(`/products/${editingProduct.id}`, data);
       } else {
-        await api.post('/products', formData);
+        await api.post('/products', data);
       }
       handleCloseModal();
       fetchProducts();
@@ -1007,24 +1005,17 @@ export default function InventoryPage() {
                       className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
                     />
                   </div>
-                  <div>
-                    <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.nameZh}</label>
-                    <input
-                      type="text"
-                      value={formData.name_zh}
-                      onChange={(e) => setFormData(prev => ({ ...prev, name_zh: e.target.value }))}
-                      className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.nameEs}</label>
-                    <input
-                      type="text"
-                      value={formData.name_es}
-                      onChange={(e) => setFormData(prev => ({ ...prev, name_es: e.target.value }))}
-                      className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
-                    />
-                  </div>
+                  {translationLanguages.map((lang) => (
+                    <div key={lang.code}>
+                      <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.name} ({lang.native_name})</label>
+                      <input
+                        type="text"
+                        value={formData.name_translations[lang.code] ?? ''}
+                        onChange={(e) => setFormData(prev => ({ ...prev, name_translations: { ...prev.name_translations, [lang.code]: e.target.value } }))}
+                        className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
+                      />
+                    </div>
+                  ))}
                   <div>
                     <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.specEn}</label>
                     <input
@@ -1034,24 +1025,17 @@ export default function InventoryPage() {
                       className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
                     />
                   </div>
-                  <div>
-                    <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.specZh}</label>
-                    <input
-                      type="text"
-                      value={formData.spec_zh}
-                      onChange={(e) => setFormData(prev => ({ ...prev, spec_zh: e.target.value }))}
-                      className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.specEs}</label>
-                    <input
-                      type="text"
-                      value={formData.spec_es}
-                      onChange={(e) => setFormData(prev => ({ ...prev, spec_es: e.target.value }))}
-                      className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
-                    />
-                  </div>
+                  {translationLanguages.map((lang) => (
+                    <div key={lang.code}>
+                      <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.specification} ({lang.native_name})</label>
+                      <input
+                        type="text"
+                        value={formData.spec_translations[lang.code] ?? ''}
+                        onChange={(e) => setFormData(prev => ({ ...prev, spec_translations: { ...prev.spec_translations, [lang.code]: e.target.value } }))}
+                        className="w-full px-3 py-2 border border-[#ABC0B9] rounded-lg focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]"
+                      />
+                    </div>
+                  ))}
                 </div>
                 <div className="mt-4">
                   <label className="block text-sm font-medium text-[#4E616F] mb-1">{t.description}</label>
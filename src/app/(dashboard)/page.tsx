@@ -264,8 +264,8 @@ export default function HomePage() {
           setApprovalStats(stats);
         }
 
-        // Employee: fetch my requests
-        if (user?.role === 'employee' || !user?.role) {
+        // Employee and custom roles, who get the employee dashboard: fetch my requests
+        if (!user?.role || !['admin', 'purchase_admin', 'supply_chain_manager', 'general_manager'].includes(user.role)) {
           try {
             const response = await requestsApi.getMyRequests({ per_page: 5 });
             setMyRequests(response.data || []);
@@ -0,0 +1,303 @@
+'use client';
+
+import { useCallback, useEffect, useState } from 'react';
+import { Building2, X, Loader2, AlertCircle, Plus, Trash2, Edit, Star } from 'lucide-react';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { companiesApi } from '@/lib/api';
+import type { Company } from '@/types';
+
+interface CompaniesModalProps {
+  onClose: () => void;
+  onChanged: () => void; // Companies were added, changed or deleted
+}
+
+// Lets admins manage the companies users, requests, catalogs and configuration belong to
+export function CompaniesModal({ onClose, onChanged }: CompaniesModalProps) {
+  const { language } = useLanguage();
+  const [companies, setCompanies] = useState<Company[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [isWorking, setIsWorking] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Company being edited, or null for a new company when the form is open
+  const [editingCompany, setEditingCompany] = useState<Company | null>(null);
+  const [showForm, setShowForm] = useState(false);
+  const [formCode, setFormCode] = useState('');
+  const [formName, setFormName] = useState('');
+  const [formPrefix, setFormPrefix] = useState('');
+
+  const text = {
+    en: {
+      title: 'Companies',
+      info: 'Each company has its own approvers, catalog, request numbering and purchase, email and Amazon settings. Users belong to the company of their company code; anyone else belongs to the default company.',
+      newCompany: 'New Company',
+      code: 'Code',
+      codePlaceholder: 'e.g. MX-02',
+      name: 'Name',
+      prefix: 'Number prefix',
+      prefixPlaceholder: 'e.g. MX',
+      prefixHint: 'Request numbers become PR-MX-2026-0001. One company may have none.',
+      noPrefix: 'No prefix',
+      default: 'Default',
+      makeDefault: 'Make default',
+      users: 'users',
+      edit: 'Edit',
+      delete: 'Delete',
+      confirmDelete: 'Delete this company and its settings?',
+      save: 'Save',
+      cancel: 'Cancel',
+      failed: 'Something went wrong',
+    },
+    zh: {
+      title: '公司',
+      info: '每个公司有自己的审批人、目录、申请编号以及采购、邮件和亚马逊设置。用户属于其公司代码对应的公司；其他用户属于默认公司。',
+      newCompany: '新建公司',
+      code: '代码',
+      codePlaceholder: '例如 MX-02',
+      name: '名称',
+      prefix: '编号前缀',
+      prefixPlaceholder: '例如 MX',
+      prefixHint: '申请编号将变为 PR-MX-2026-0001。最多一个公司可以没有前缀。',
+      noPrefix: '无前缀',
+      default: '默认',
+      makeDefault: '设为默认',
+      users: '位用户',
+      edit: '编辑',
+      delete: '删除',
+      confirmDelete: '删除此公司及其设置？',
+      save: '保存',
+      cancel: '取消',
+      failed: '操作失败',
+    },
+    es: {
+      title: 'Empresas',
+      info: 'Cada empresa tiene sus propios aprobadores, catálogo, numeración de solicitudes y configuración de compras, correo y Amazon. Los usuarios pertenecen a la empresa de su código de empresa; los demás pertenecen a la empresa predeterminada.',
+      newCompany: 'Nueva Empresa',
+      code: 'Código',
+      codePlaceholder: 'p. ej. MX-02',
+      name: 'Nombre',
+      prefix: 'Prefijo de numeración',
+      prefixPlaceholder: 'p. ej. MX',
+      prefixHint: 'Los números de solicitud serán PR-MX-2026-0001. Solo una empresa puede no tener prefijo.',
+      noPrefix: 'Sin prefijo',
+      default: 'Predeterminada',
+      makeDefault: 'Hacer predeterminada',
+      users: 'usuarios',
+      edit: 'Editar',
+      delete: 'Eliminar',
+      confirmDelete: '¿Eliminar esta empresa y su configuración?',
+      save: 'Guardar',
+      cancel: 'Cancelar',
+      failed: 'Algo salió mal',
+    },
+  };
+
+  const t = text[language];
+
+  const fetchCompanies = useCallback(async () => {
+    try {
+      setCompanies(await companiesApi.list());
+    } catch (err) {
+      console.error('Failed to fetch companies:', err);
+    } finally {
+      setIsLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchCompanies();
+  }, [fetchCompanies]);
+
+  // Runs an action and refreshes the list, showing the API's error if it fails
+  const run = async (action: () => Promise<void>) => {
+    setIsWorking(true);
+    setError(null);
+    try {
+      await action();
+      await fetchCompanies();
+      onChanged();
+    } catch (err: unknown) {
+      const apiError = err as { response?: { data?: { error?: { message?: string } } } };
+      setError(apiError?.response?.data?.error?.message || t.failed);
+    } finally {
+      setIsWorking(false);
+    }
+  };
+
+  const openForm = (company?: Company) => {
+    setEditingCompany(company || null);
+    setFormCode(company?.code || '');
+    setFormName(company?.name || '');
+    setFormPrefix(company?.number_prefix || '');
+    setShowForm(true);
+  };
+
+  const handleSave = () =>
+    run(async () => {
+      const data = {
+        name: formName.trim(),
+        number_prefix: formPrefix.trim().toUpperCase(),
+      };
+      if (editingCompany) {
+        await companiesApi.update(editingCompany.id, data);
+      } else {
+        await companiesApi.create({ code: formCode.trim(), ...data });
+      }
+      setShowForm(false);
+    });
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/50 flex items-center justify-center p-4 z-[100]"
+      onClick={onClose}
+    >
+      <div
+        className="bg-white rounded-xl shadow-2xl max-w-2xl w-full max-h-[90vh] overflow-hidden flex flex-col"
+        onClick={(e) => e.stopPropagation()}
+      >
+        {/* Modal Header */}
+        <div className="flex items-center justify-between px-6 py-4 border-b border-[#ABC0B9]">
+          <h2 className="text-lg font-semibold text-[#2D363F] flex items-center gap-2">
+            <Building2 className="h-5 w-5 text-[#5C2F0E]" />
+            {t.title}
+          </h2>
+          <button
+            onClick={onClose}
+            className="p-2 hover:bg-[#FAFBFA] rounded-lg transition-colors"
+          >
+            <X className="h-5 w-5 text-[#4E616F]" />
+          </button>
+        </div>
+
+        <div className="p-6 space-y-4 flex-1 overflow-y-auto">
+          <p className="text-sm text-[#4E616F]">{t.info}</p>
+
+          {error && (
+            <div className="flex items-start gap-2 rounded-lg bg-[#AA2F0D]/10 border border-[#AA2F0D]/20 p-3 text-sm text-[#AA2F0D]">
+              <AlertCircle className="h-4 w-4 mt-0.5 shrink-0" />
+              {error}
+            </div>
+          )}
+
+          {showForm ? (
+            <div className="rounded-lg bg-[#FAFBFA] border border-[#ABC0B9]/50 p-4 space-y-3">
+              <div className="grid grid-cols-1 sm:grid-cols-3 gap-2">
+                <label className="text-sm text-[#4E616F]">
+                  {t.code}
+                  <input
+                    type="text"
+                    value={formCode}
+                    onChange={(e) => setFormCode(e.target.value)}
+                    placeholder={t.codePlaceholder}
+                    disabled={!!editingCompany}
+                    className="mt-1 w-full rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none disabled:bg-[#FAFBFA]"
+                  />
+                </label>
+                <label className="text-sm text-[#4E616F] sm:col-span-2">
+                  {t.name}
+                  <input
+                    type="text"
+                    value={formName}
+                    onChange={(e) => setFormName(e.target.value)}
+                    className="mt-1 w-full rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+                  />
+                </label>
+              </div>
+              <label className="block text-sm text-[#4E616F]">
+                {t.prefix}
+                <input
+                  type="text"
+                  value={formPrefix}
+                  onChange={(e) => setFormPrefix(e.target.value.toUpperCase())}
+                  placeholder={t.prefixPlaceholder}
+                  maxLength={10}
+                  className="mt-1 w-full rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+                />
+                <span className="mt-1 block text-xs">{t.prefixHint}</span>
+              </label>
+              <div className="flex justify-end gap-2">
+                <button
+                  onClick={() => setShowForm(false)}
+                  className="px-3 py-1.5 text-sm text-[#4E616F] hover:text-[#2D363F]"
+                >
+                  {t.cancel}
+                </button>
+                <button
+                  onClick={handleSave}
+                  disabled={isWorking || !formName.trim() || (!editingCompany && !formCode.trim())}
+                  className="px-3 py-1.5 rounded-lg bg-[#5C2F0E] text-white text-sm font-medium disabled:opacity-50"
+                >
+                  {t.save}
+                </button>
+              </div>
+            </div>
+          ) : (
+            <button
+              onClick={() => openForm()}
+              className="px-4 py-2 rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] text-white text-sm font-medium shadow-sm transition-all hover:shadow-lg active:scale-95 flex items-center justify-center gap-2"
+            >
+              <Plus className="h-4 w-4" />
+              {t.newCompany}
+            </button>
+          )}
+
+          {isLoading ? (
+            <div className="flex justify-center py-8">
+              <Loader2 className="h-6 w-6 animate-spin text-[#5C2F0E]" />
+            </div>
+          ) : (
+            <div className="space-y-3">
+              {companies.map((company) => (
+                <div key={company.id} className="rounded-lg border border-[#ABC0B9] p-4">
+                  <div className="flex flex-wrap items-center justify-between gap-2">
+                    <div>
+                      <span className="font-medium text-[#2D363F]">{company.name}</span>
+                      <span className="text-sm text-[#4E616F]">
+                        {' '}· {company.code} · {company.number_prefix || t.noPrefix} · {company.user_count} {t.users}
+                      </span>
+                      {company.is_default && (
+                        <span className="ml-2 rounded bg-[#ABC0B9]/30 px-2 py-0.5 text-xs text-[#4E616F]">{t.default}</span>
+                      )}
+                    </div>
+                    <div className="flex gap-2 text-sm">
+                      {!company.is_default && (
+                        <button
+                          onClick={() => run(() => companiesApi.update(company.id, { is_default: true }).then(() => undefined))}
+                          disabled={isWorking}
+                          className="px-3 py-1.5 rounded-lg text-[#4E616F] hover:bg-[#FAFBFA] disabled:opacity-50 flex items-center gap-1"
+                        >
+                          <Star className="h-4 w-4" />
+                          {t.makeDefault}
+                        </button>
+                      )}
+                      <button
+                        onClick={() => openForm(company)}
+                        disabled={isWorking}
+                        className="px-3 py-1.5 rounded-lg border border-[#5C2F0E] text-[#5C2F0E] hover:bg-[#5C2F0E]/5 disabled:opacity-50 flex items-center gap-1"
+                      >
+                        <Edit className="h-4 w-4" />
+                        {t.edit}
+                      </button>
+                      {!company.is_default && (
+                        <button
+                          onClick={() => {
+                            if (confirm(t.confirmDelete)) run(() => companiesApi.delete(company.id));
+                          }}
+                          disabled={isWorking || company.user_count > 0}
+                          className="p-1.5 rounded-lg text-[#AA2F0D] hover:bg-[#AA2F0D]/10 disabled:opacity-50"
+                          title={t.delete}
+                        >
+                          <Trash2 className="h-4 w-4" />
+                        </button>
+                      )}
+                    </div>
+                  </div>
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,236 @@
+'use client';
+
+import { useState } from 'react';
+import { FolderSync, X, Loader2, AlertCircle, Eye } from 'lucide-react';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { directoryApi } from '@/lib/api';
+import type { DirectoryStatus, DirectorySyncChange, DirectorySyncReport, DirectorySyncRun } from '@/types';
+
+interface DirectorySyncModalProps {
+  status: DirectoryStatus;
+  onClose: () => void;
+  onSynced: () => void;
+}
+
+const sections: Array<keyof DirectorySyncReport> = ['created', 'updated', 'enabled', 'disabled', 'skipped'];
+
+// Lets admins preview and run a sync of users with the directory (LDAP)
+export function DirectorySyncModal({ status, onClose, onSynced }: DirectorySyncModalProps) {
+  const { language } = useLanguage();
+  const [run, setRun] = useState<DirectorySyncRun | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [isWorking, setIsWorking] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Directory Sync',
+      info: 'Users are created, updated and disabled to match the directory. Preview first to see what would change.',
+      every: 'Syncs automatically every {n} minutes.',
+      onDemand: 'Syncs only when started here.',
+      lastRun: 'Last sync',
+      never: 'Never synced',
+      preview: 'Preview',
+      syncNow: 'Sync Now',
+      close: 'Close',
+      dryRunResult: 'Preview - nothing was changed',
+      syncResult: 'Sync completed',
+      entries: 'users in the directory',
+      created: 'Created',
+      updated: 'Updated',
+      enabled: 'Re-enabled',
+      disabled: 'Disabled',
+      skipped: 'Skipped',
+      noChanges: 'Everyone is up to date.',
+      failed: 'Directory sync failed',
+    },
+    zh: {
+      title: '目录同步',
+      info: '根据目录创建、更新和禁用用户。建议先预览将要发生的变更。',
+      every: '每 {n} 分钟自动同步。',
+      onDemand: '仅在此处手动同步。',
+      lastRun: '上次同步',
+      never: '尚未同步',
+      preview: '预览',
+      syncNow: '立即同步',
+      close: '关闭',
+      dryRunResult: '预览 - 未做任何更改',
+      syncResult: '同步完成',
+      entries: '个目录用户',
+      created: '已创建',
+      updated: '已更新',
+      enabled: '已重新启用',
+      disabled: '已禁用',
+      skipped: '已跳过',
+      noChanges: '所有用户均已是最新。',
+      failed: '目录同步失败',
+    },
+    es: {
+      title: 'Sincronización del Directorio',
+      info: 'Los usuarios se crean, actualizan y deshabilitan según el directorio. Use la vista previa para ver qué cambiaría.',
+      every: 'Se sincroniza automáticamente cada {n} minutos.',
+      onDemand: 'Solo se sincroniza cuando se inicia aquí.',
+      lastRun: 'Última sincronización',
+      never: 'Nunca sincronizado',
+      preview: 'Vista previa',
+      syncNow: 'Sincronizar',
+      close: 'Cerrar',
+      dryRunResult: 'Vista previa - no se cambió nada',
+      syncResult: 'Sincronización completada',
+      entries: 'usuarios en el directorio',
+      created: 'Creados',
+      updated: 'Actualizados',
+      enabled: 'Rehabilitados',
+      disabled: 'Deshabilitados',
+      skipped: 'Omitidos',
+      noChanges: 'Todos están al día.',
+      failed: 'La sincronización del directorio falló',
+    },
+  };
+
+  const t = text[language];
+
+  const handleSync = async (dryRun: boolean) => {
+    setIsWorking(true);
+    setError(null);
+    try {
+      const result = await directoryApi.sync(dryRun);
+      setRun(result);
+      if (!dryRun) onSynced();
+    } catch (err: unknown) {
+      const apiError = err as { response?: { data?: { error?: { message?: string } } } };
+      setError(apiError?.response?.data?.error?.message || t.failed);
+    } finally {
+      setIsWorking(false);
+    }
+  };
+
+  const describe = (change: DirectorySyncChange) => {
+    if (change.reason) return change.reason;
+    return (change.fields || [])
+      .filter((f) => f.field !== 'dn')
+      .map((f) => `${f.field}: ${f.from || '—'} → ${f.to || '—'}`)
+      .join(', ');
+  };
+
+  const lastRun = status.last_run;
+  const report = run?.report;
+  const hasChanges = report && sections.some((s) => report[s].length > 0);
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/50 flex items-center justify-center p-4 z-[100]"
+      onClick={onClose}
+    >
+      <div
+        className="bg-white rounded-xl shadow-2xl max-w-2xl w-full max-h-[90vh] overflow-hidden flex flex-col"
+        onClick={(e) => e.stopPropagation()}
+      >
+        {/* Modal Header */}
+        <div className="flex items-center justify-between px-6 py-4 border-b border-[#ABC0B9]">
+          <h2 className="text-lg font-semibold text-[#2D363F] flex items-center gap-2">
+            <FolderSync className="h-5 w-5 text-[#5C2F0E]" />
+            {t.title}
+          </h2>
+          <button
+            onClick={onClose}
+            className="p-2 hover:bg-[#FAFBFA] rounded-lg transition-colors"
+          >
+            <X className="h-5 w-5 text-[#4E616F]" />
+          </button>
+        </div>
+
+        <div className="p-6 space-y-4 flex-1 overflow-y-auto">
+          <p className="text-sm text-[#4E616F]">
+            {t.info}{' '}
+            {status.sync_interval_minutes > 0
+              ? t.every.replace('{n}', String(status.sync_interval_minutes))
+              : t.onDemand}
+          </p>
+
+          {!run && (
+            <div className="rounded-lg border border-[#ABC0B9] bg-[#FAFBFA] p-3 text-sm text-[#4E616F]">
+              <span className="font-medium text-[#2D363F]">{t.lastRun}:</span>{' '}
+              {lastRun ? (
+                <>
+                  {new Date(lastRun.started_at).toLocaleString()}
+                  {lastRun.dry_run && ` (${t.preview})`}
+                  {lastRun.error
+                    ? ` - ${lastRun.error}`
+                    : ` - ${t.created} ${lastRun.created}, ${t.updated} ${lastRun.updated}, ${t.disabled} ${lastRun.disabled}, ${t.skipped} ${lastRun.skipped}`}
+                </>
+              ) : (
+                t.never
+              )}
+            </div>
+          )}
+
+          {error && (
+            <div className="flex items-start gap-2 rounded-lg bg-[#AA2F0D]/10 border border-[#AA2F0D]/20 p-3 text-sm text-[#AA2F0D]">
+              <AlertCircle className="h-4 w-4 mt-0.5 shrink-0" />
+              {error}
+            </div>
+          )}
+
+          {run && report && (
+            <div className="space-y-4">
+              <div className="text-sm text-[#2D363F]">
+                <span className="font-medium">{run.dry_run ? t.dryRunResult : t.syncResult}</span>
+                <span className="text-[#4E616F]"> · {run.entries} {t.entries}</span>
+              </div>
+
+              {!hasChanges && <p className="text-sm text-[#4E616F]">{t.noChanges}</p>}
+
+              {sections.filter((s) => report[s].length > 0).map((section) => (
+                <div key={section}>
+                  <h3 className={`text-sm font-semibold mb-2 ${
+                    section === 'disabled' || section === 'skipped' ? 'text-[#AA2F0D]' : 'text-[#5C2F0E]'
+                  }`}>
+                    {t[section]} ({report[section].length})
+                  </h3>
+                  <div className="space-y-1 max-h-48 overflow-y-auto">
+                    {report[section].map((change, i) => (
+                      <div key={i} className="rounded bg-[#FAFBFA] border border-[#ABC0B9]/50 px-3 py-2 text-sm">
+                        <div className="text-[#2D363F]">
+                          <span className="font-medium">{change.name || change.email}</span>
+                          <span className="text-[#4E616F]"> · {change.employee_number} · {change.email}</span>
+                        </div>
+                        {describe(change) && (
+                          <div className="text-xs text-[#4E616F] mt-0.5">{describe(change)}</div>
+                        )}
+                      </div>
+                    ))}
+                  </div>
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+
+        <div className="px-6 py-4 border-t border-[#ABC0B9] flex justify-end gap-3">
+          <button
+            onClick={onClose}
+            className="px-5 py-2.5 text-[#4E616F] font-medium transition-colors hover:text-[#2D363F]"
+          >
+            {t.close}
+          </button>
+          <button
+            onClick={() => handleSync(true)}
+            disabled={isWorking}
+            className="px-5 py-2.5 rounded-lg border border-[#5C2F0E] text-[#5C2F0E] font-medium transition-all hover:bg-[#5C2F0E]/5 active:scale-95 disabled:opacity-50 flex items-center gap-2"
+          >
+            <Eye className="h-4 w-4" />
+            {t.preview}
+          </button>
+          <button
+            onClick={() => handleSync(false)}
+            disabled={isWorking}
+            className="px-5 py-2.5 rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] text-white font-medium shadow-sm transition-all hover:shadow-lg active:scale-95 disabled:opacity-50 flex items-center gap-2"
+          >
+            {isWorking ? <Loader2 className="h-4 w-4 animate-spin" /> : <FolderSync className="h-4 w-4" />}
+            {t.syncNow}
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,326 @@
+'use client';
+
+import { useCallback, useEffect, useState } from 'react';
+import { ShieldCheck, X, Loader2, AlertCircle, Plus, Trash2, Edit } from 'lucide-react';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { rolesApi } from '@/lib/api';
+import type { Permission, PermissionInfo, Role } from '@/types';
+
+interface RolesModalProps {
+  roleLabels: Record<string, string>; // Role name -> label, for every role
+  onClose: () => void;
+  onChanged: () => void; // Roles were added, changed or deleted
+}
+
+// Lets admins define roles and choose the permissions each role grants
+export function RolesModal({ roleLabels, onClose, onChanged }: RolesModalProps) {
+  const { language } = useLanguage();
+  const [roles, setRoles] = useState<Role[]>([]);
+  const [catalog, setCatalog] = useState<PermissionInfo[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [isWorking, setIsWorking] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Role being edited, or null for a new role when the form is open
+  const [editingRole, setEditingRole] = useState<Role | null>(null);
+  const [showForm, setShowForm] = useState(false);
+  const [formName, setFormName] = useState('');
+  const [formDisplayName, setFormDisplayName] = useState('');
+  const [formDescription, setFormDescription] = useState('');
+  const [formPermissions, setFormPermissions] = useState<Permission[]>([]);
+
+  const text = {
+    en: {
+      title: 'Roles & Permissions',
+      info: 'Each role grants a set of permissions. Built-in roles can be changed but not deleted; a role can only be deleted once no user has it.',
+      newRole: 'New Role',
+      name: 'Name',
+      namePlaceholder: 'e.g. finance',
+      displayName: 'Display name',
+      description: 'Description',
+      permissions: 'Permissions',
+      builtIn: 'Built-in',
+      users: 'users',
+      noPermissions: 'No permissions',
+      edit: 'Edit',
+      delete: 'Delete',
+      confirmDelete: 'Delete this role?',
+      save: 'Save',
+      cancel: 'Cancel',
+      failed: 'Something went wrong',
+    },
+    zh: {
+      title: '角色与权限',
+      info: '每个角色授予一组权限。内置角色可以修改但不能删除；没有用户使用的角色才能删除。',
+      newRole: '新建角色',
+      name: '名称',
+      namePlaceholder: '例如 finance',
+      displayName: '显示名称',
+      description: '描述',
+      permissions: '权限',
+      builtIn: '内置',
+      users: '位用户',
+      noPermissions: '无权限',
+      edit: '编辑',
+      delete: '删除',
+      confirmDelete: '删除此角色？',
+      save: '保存',
+      cancel: '取消',
+      failed: '操作失败',
+    },
+    es: {
+      title: 'Roles y Permisos',
+      info: 'Cada rol otorga un conjunto de permisos. Los roles predefinidos se pueden cambiar pero no eliminar; un rol solo se puede eliminar cuando ningún usuario lo tiene.',
+      newRole: 'Nuevo Rol',
+      name: 'Nombre',
+      namePlaceholder: 'p. ej. finance',
+      displayName: 'Nombre visible',
+      description: 'Descripción',
+      permissions: 'Permisos',
+      builtIn: 'Predefinido',
+      users: 'usuarios',
+      noPermissions: 'Sin permisos',
+      edit: 'Editar',
+      delete: 'Eliminar',
+      confirmDelete: '¿Eliminar este rol?',
+      save: 'Guardar',
+      cancel: 'Cancelar',
+      failed: 'Algo salió mal',
+    },
+  };
+
+  const t = text[language];
+
+  const fetchRoles = useCallback(async () => {
+    try {
+      const [roleList, permissionCatalog] = await Promise.all([rolesApi.list(), rolesApi.permissions()]);
+      setRoles(roleList);
+      setCatalog(permissionCatalog);
+    } catch (err) {
+      console.error('Failed to fetch roles:', err);
+    } finally {
+      setIsLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchRoles();
+  }, [fetchRoles]);
+
+  // Runs an action and refreshes the list, showing the API's error if it fails
+  const run = async (action: () => Promise<void>) => {
+    setIsWorking(true);
+    setError(null);
+    try {
+      await action();
+      await fetchRoles();
+      onChanged();
+    } catch (err: unknown) {
+      const apiError = err as { response?: { data?: { error?: { message?: string } } } };
+      setError(apiError?.response?.data?.error?.message || t.failed);
+    } finally {
+      setIsWorking(false);
+    }
+  };
+
+  const openForm = (role?: Role) => {
+    setEditingRole(role || null);
+    setFormName(role?.name || '');
+    setFormDisplayName(role?.display_name || '');
+    setFormDescription(role?.description || '');
+    setFormPermissions(role?.permissions || []);
+    setShowForm(true);
+  };
+
+  const handleSave = () =>
+    run(async () => {
+      const data = {
+        display_name: formDisplayName.trim(),
+        description: formDescription.trim(),
+        permissions: formPermissions,
+      };
+      if (editingRole) {
+        await rolesApi.update(editingRole.id, data);
+      } else {
+        await rolesApi.create({ name: formName.trim(), ...data });
+      }
+      setShowForm(false);
+    });
+
+  const togglePermission = (permission: Permission) => {
+    setFormPermissions((prev) =>
+      prev.includes(permission) ? prev.filter((p) => p !== permission) : [...prev, permission]
+    );
+  };
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/50 flex items-center justify-center p-4 z-[100]"
+      onClick={onClose}
+    >
+      <div
+        className="bg-white rounded-xl shadow-2xl max-w-3xl w-full max-h-[90vh] overflow-hidden flex flex-col"
+        onClick={(e) => e.stopPropagation()}
+      >
+        {/* Modal Header */}
+        <div className="flex items-center justify-between px-6 py-4 border-b border-[#ABC0B9]">
+          <h2 className="text-lg font-semibold text-[#2D363F] flex items-center gap-2">
+            <ShieldCheck className="h-5 w-5 text-[#5C2F0E]" />
+            {t.title}
+          </h2>
+          <button
+            onClick={onClose}
+            className="p-2 hover:bg-[#FAFBFA] rounded-lg transition-colors"
+          >
+            <X className="h-5 w-5 text-[#4E616F]" />
+          </button>
+        </div>
+
+        <div className="p-6 space-y-4 flex-1 overflow-y-auto">
+          <p className="text-sm text-[#4E616F]">{t.info}</p>
+
+          {error && (
+            <div className="flex items-start gap-2 rounded-lg bg-[#AA2F0D]/10 border border-[#AA2F0D]/20 p-3 text-sm text-[#AA2F0D]">
+              <AlertCircle className="h-4 w-4 mt-0.5 shrink-0" />
+              {error}
+            </div>
+          )}
+
+          {showForm ? (
+            <div className="rounded-lg bg-[#FAFBFA] border border-[#ABC0B9]/50 p-4 space-y-3">
+              <div className="grid grid-cols-1 sm:grid-cols-2 gap-2">
+                <label className="text-sm text-[#4E616F]">
+                  {t.name}
+                  <input
+                    type="text"
+                    value={formName}
+                    onChange={(e) => setFormName(e.target.value)}
+                    placeholder={t.namePlaceholder}
+                    disabled={!!editingRole}
+                    className="mt-1 w-full rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none disabled:bg-[#FAFBFA]"
+                  />
+                </label>
+                <label className="text-sm text-[#4E616F]">
+                  {t.displayName}
+                  <input
+                    type="text"
+                    value={formDisplayName}
+                    onChange={(e) => setFormDisplayName(e.target.value)}
+                    className="mt-1 w-full rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+                  />
+                </label>
+              </div>
+              <label className="block text-sm text-[#4E616F]">
+                {t.description}
+                <input
+                  type="text"
+                  value={formDescription}
+                  onChange={(e) => setFormDescription(e.target.value)}
+                  className="mt-1 w-full rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+                />
+              </label>
+              <div>
+                <p className="text-xs font-semibold text-[#2D363F] mb-1">{t.permissions}</p>
+                <div className="space-y-1">
+                  {catalog.map((permission) => (
+                    <label key={permission.key} className="flex items-start gap-2 text-sm">
+                      <input
+                        type="checkbox"
+                        checked={formPermissions.includes(permission.key)}
+                        onChange={() => togglePermission(permission.key)}
+                        className="mt-1 accent-[#5C2F0E]"
+                      />
+                      <span>
+                        <code className="text-xs text-[#2D363F]">{permission.key}</code>
+                        <span className="text-[#4E616F]"> · {permission.description}</span>
+                      </span>
+                    </label>
+                  ))}
+                </div>
+              </div>
+              <div className="flex justify-end gap-2">
+                <button
+                  onClick={() => setShowForm(false)}
+                  className="px-3 py-1.5 text-sm text-[#4E616F] hover:text-[#2D363F]"
+                >
+                  {t.cancel}
+                </button>
+                <button
+                  onClick={handleSave}
+                  disabled={isWorking || !formDisplayName.trim() || (!editingRole && !formName.trim())}
+                  className="px-3 py-1.5 rounded-lg bg-[#5C2F0E] text-white text-sm font-medium disabled:opacity-50"
+                >
+                  {t.save}
+                </button>
+              </div>
+            </div>
+          ) : (
+            <button
+              onClick={() => openForm()}
+              className="px-4 py-2 rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] text-white text-sm font-medium shadow-sm transition-all hover:shadow-lg active:scale-95 flex items-center justify-center gap-2"
+            >
+              <Plus className="h-4 w-4" />
+              {t.newRole}
+            </button>
+          )}
+
+          {isLoading ? (
+            <div className="flex justify-center py-8">
+              <Loader2 className="h-6 w-6 animate-spin text-[#5C2F0E]" />
+            </div>
+          ) : (
+            <div className="space-y-3">
+              {roles.map((role) => (
+                <div key={role.id} className="rounded-lg border border-[#ABC0B9] p-4">
+                  <div className="flex flex-wrap items-center justify-between gap-2">
+                    <div>
+                      <span className="font-medium text-[#2D363F]">{roleLabels[role.name] || role.display_name}</span>
+                      <span className="text-sm text-[#4E616F]"> · {role.name} · {role.user_count} {t.users}</span>
+                      {role.built_in && (
+                        <span className="ml-2 rounded bg-[#ABC0B9]/30 px-2 py-0.5 text-xs text-[#4E616F]">{t.builtIn}</span>
+                      )}
+                    </div>
+                    <div className="flex gap-2 text-sm">
+                      <button
+                        onClick={() => openForm(role)}
+                        disabled={isWorking}
+                        className="px-3 py-1.5 rounded-lg border border-[#5C2F0E] text-[#5C2F0E] hover:bg-[#5C2F0E]/5 disabled:opacity-50 flex items-center gap-1"
+                      >
+                        <Edit className="h-4 w-4" />
+                        {t.edit}
+                      </button>
+                      {!role.built_in && (
+                        <button
+                          onClick={() => {
+                            if (confirm(t.confirmDelete)) run(() => rolesApi.delete(role.id));
+                          }}
+                          disabled={isWorking || role.user_count > 0}
+                          className="p-1.5 rounded-lg text-[#AA2F0D] hover:bg-[#AA2F0D]/10 disabled:opacity-50"
+                          title={t.delete}
+                        >
+                          <Trash2 className="h-4 w-4" />
+                        </button>
+                      )}
+                    </div>
+                  </div>
+                  {role.description && <p className="mt-1 text-sm text-[#4E616F]">{role.description}</p>}
+                  <div className="mt-2 flex flex-wrap gap-1">
+                    {role.permissions.length === 0 ? (
+                      <span className="text-xs text-[#4E616F]">{t.noPermissions}</span>
+                    ) : (
+                      role.permissions.map((permission) => (
+                        <code key={permission} className="rounded bg-[#FAFBFA] border border-[#ABC0B9]/50 px-1.5 py-0.5 text-xs text-[#2D363F]">
+                          {permission}
+                        </code>
+                      ))
+                    )}
+                  </div>
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,485 @@
+'use client';
+
+import { useCallback, useEffect, useState } from 'react';
+import { KeyRound, X, Loader2, AlertCircle, Plus, Trash2, Copy, Check, Ban } from 'lucide-react';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { serviceAccountsApi } from '@/lib/api';
+import type { APIScopes, ServiceAccount, UserRole } from '@/types';
+
+interface ServiceAccountsModalProps {
+  roleLabels: Record<string, string>; // Role name -> label, for every role
+  onClose: () => void;
+}
+
+const expiryOptions = [30, 90, 365];
+
+// Lets admins manage service accounts for scripts and integrations, and
+// issue and revoke their API keys
+export function ServiceAccountsModal({ roleLabels, onClose }: ServiceAccountsModalProps) {
+  const { language } = useLanguage();
+  const [accounts, setAccounts] = useState<ServiceAccount[]>([]);
+  const [scopes, setScopes] = useState<APIScopes | null>(null);
+  const [isLoading, setIsLoading] = useState(true);
+  const [isWorking, setIsWorking] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const [newName, setNewName] = useState('');
+  const [newRole, setNewRole] = useState<UserRole>('employee');
+
+  // Key being issued, for one account at a time
+  const [keyAccountId, setKeyAccountId] = useState<number | null>(null);
+  const [keyName, setKeyName] = useState('');
+  const [keyScopes, setKeyScopes] = useState<string[]>([]);
+  const [keyExpiry, setKeyExpiry] = useState(90);
+  const [createdKey, setCreatedKey] = useState<string | null>(null);
+  const [copied, setCopied] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Service Accounts',
+      info: 'Scripts and integrations call the API with a service account\'s API key instead of logging in. A key can only do what both its scopes and the account\'s role allow.',
+      name: 'Name',
+      role: 'Role',
+      create: 'Create Account',
+      noAccounts: 'No service accounts yet.',
+      disabled: 'Disabled',
+      enable: 'Enable',
+      disable: 'Disable',
+      delete: 'Delete',
+      confirmDelete: 'Delete this service account? Its keys stop working.',
+      newKey: 'New Key',
+      keyName: 'Key name',
+      scopes: 'Scopes',
+      read: 'Read',
+      write: 'Write',
+      expiresIn: 'Expires in',
+      days: 'days',
+      issue: 'Issue Key',
+      cancel: 'Cancel',
+      keyCreated: 'Copy this key now. It won\'t be shown again.',
+      copy: 'Copy',
+      copied: 'Copied',
+      noKeys: 'No keys',
+      expires: 'Expires',
+      expired: 'Expired',
+      revoked: 'Revoked',
+      lastUsed: 'Last used',
+      never: 'Never used',
+      revoke: 'Revoke',
+      confirmRevoke: 'Revoke this key? Scripts using it will stop working.',
+      close: 'Close',
+      failed: 'Something went wrong',
+    },
+    zh: {
+      title: '服务账户',
+      info: '脚本和集成使用服务账户的 API 密钥调用接口，无需登录。密钥只能执行其权限范围和账户角色同时允许的操作。',
+      name: '名称',
+      role: '角色',
+      create: '创建账户',
+      noAccounts: '暂无服务账户。',
+      disabled: '已禁用',
+      enable: '启用',
+      disable: '禁用',
+      delete: '删除',
+      confirmDelete: '删除此服务账户？其密钥将失效。',
+      newKey: '新建密钥',
+      keyName: '密钥名称',
+      scopes: '权限范围',
+      read: '读取',
+      write: '写入',
+      expiresIn: '有效期',
+      days: '天',
+      issue: '生成密钥',
+      cancel: '取消',
+      keyCreated: '请立即复制此密钥，它不会再次显示。',
+      copy: '复制',
+      copied: '已复制',
+      noKeys: '暂无密钥',
+      expires: '到期',
+      expired: '已过期',
+      revoked: '已撤销',
+      lastUsed: '上次使用',
+      never: '从未使用',
+      revoke: '撤销',
+      confirmRevoke: '撤销此密钥？使用它的脚本将停止工作。',
+      close: '关闭',
+      failed: '操作失败',
+    },
+    es: {
+      title: 'Cuentas de Servicio',
+      info: 'Los scripts e integraciones llaman a la API con la clave de una cuenta de servicio en lugar de iniciar sesión. Una clave solo puede hacer lo que permiten sus alcances y el rol de la cuenta.',
+      name: 'Nombre',
+      role: 'Rol',
+      create: 'Crear Cuenta',
+      noAccounts: 'Aún no hay cuentas de servicio.',
+      disabled: 'Deshabilitada',
+      enable: 'Habilitar',
+      disable: 'Deshabilitar',
+      delete: 'Eliminar',
+      confirmDelete: '¿Eliminar esta cuenta de servicio? Sus claves dejarán de funcionar.',
+      newKey: 'Nueva Clave',
+      keyName: 'Nombre de la clave',
+      scopes: 'Alcances',
+      read: 'Lectura',
+      write: 'Escritura',
+      expiresIn: 'Expira en',
+      days: 'días',
+      issue: 'Emitir Clave',
+      cancel: 'Cancelar',
+      keyCreated: 'Copie esta clave ahora. No se volverá a mostrar.',
+      copy: 'Copiar',
+      copied: 'Copiada',
+      noKeys: 'Sin claves',
+      expires: 'Expira',
+      expired: 'Expirada',
+      revoked: 'Revocada',
+      lastUsed: 'Último uso',
+      never: 'Nunca usada',
+      revoke: 'Revocar',
+      confirmRevoke: '¿Revocar esta clave? Los scripts que la usan dejarán de funcionar.',
+      close: 'Cerrar',
+      failed: 'Algo salió mal',
+    },
+  };
+
+  const t = text[language];
+
+  const fetchAccounts = useCallback(async () => {
+    try {
+      const [accountList, scopeCatalog] = await Promise.all([
+        serviceAccountsApi.list(),
+        serviceAccountsApi.scopes(),
+      ]);
+      setAccounts(accountList);
+      setScopes(scopeCatalog);
+    } catch (err) {
+      console.error('Failed to fetch service accounts:', err);
+    } finally {
+      setIsLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchAccounts();
+  }, [fetchAccounts]);
+
+  // Runs an action and refreshes the list, showing the API's error if it fails
+  const run = async (action: () => Promise<void>) => {
+    setIsWorking(true);
+    setError(null);
+    try {
+      await action();
+      await fetchAccounts();
+    } catch (err: unknown) {
+      const apiError = err as { response?: { data?: { error?: { message?: string } } } };
+      setError(apiError?.response?.data?.error?.message || t.failed);
+    } finally {
+      setIsWorking(false);
+    }
+  };
+
+  const handleCreateAccount = () =>
+    run(async () => {
+      await serviceAccountsApi.create({ name: newName.trim(), role: newRole });
+      setNewName('');
+    });
+
+  const openKeyForm = (accountId: number) => {
+    setKeyAccountId(accountId);
+    setKeyName('');
+    setKeyScopes([]);
+    setKeyExpiry(90);
+    setCreatedKey(null);
+  };
+
+  const handleIssueKey = () =>
+    run(async () => {
+      if (keyAccountId === null) return;
+      const result = await serviceAccountsApi.createKey(keyAccountId, {
+        name: keyName.trim(),
+        scopes: keyScopes,
+        expires_in_days: keyExpiry,
+      });
+      setCreatedKey(result.key);
+      setCopied(false);
+    });
+
+  const toggleScope = (scope: string) => {
+    setKeyScopes((prev) => (prev.includes(scope) ? prev.filter((s) => s !== scope) : [...prev, scope]));
+  };
+
+  const copyKey = async () => {
+    if (!createdKey) return;
+    await navigator.clipboard.writeText(createdKey);
+    setCopied(true);
+  };
+
+  const formatDate = (value?: string) => (value ? new Date(value).toLocaleDateString() : '');
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/50 flex items-center justify-center p-4 z-[100]"
+      onClick={onClose}
+    >
+      <div
+        className="bg-white rounded-xl shadow-2xl max-w-3xl w-full max-h-[90vh] overflow-hidden flex flex-col"
+        onClick={(e) => e.stopPropagation()}
+      >
+        {/* Modal Header */}
+        <div className="flex items-center justify-between px-6 py-4 border-b border-[#ABC0B9]">
+          <h2 className="text-lg font-semibold text-[#2D363F] flex items-center gap-2">
+            <KeyRound className="h-5 w-5 text-[#5C2F0E]" />
+            {t.title}
+          </h2>
+          <button
+            onClick={onClose}
+            className="p-2 hover:bg-[#FAFBFA] rounded-lg transition-colors"
+          >
+            <X className="h-5 w-5 text-[#4E616F]" />
+          </button>
+        </div>
+
+        <div className="p-6 space-y-4 flex-1 overflow-y-auto">
+          <p className="text-sm text-[#4E616F]">{t.info}</p>
+
+          {error && (
+            <div className="flex items-start gap-2 rounded-lg bg-[#AA2F0D]/10 border border-[#AA2F0D]/20 p-3 text-sm text-[#AA2F0D]">
+              <AlertCircle className="h-4 w-4 mt-0.5 shrink-0" />
+              {error}
+            </div>
+          )}
+
+          {/* New account */}
+          <div className="flex flex-col sm:flex-row gap-2">
+            <input
+              type="text"
+              value={newName}
+              onChange={(e) => setNewName(e.target.value)}
+              placeholder={t.name}
+              className="flex-1 rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+            />
+            <select
+              value={newRole}
+              onChange={(e) => setNewRole(e.target.value as UserRole)}
+              className="rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+            >
+              {Object.entries(roleLabels).map(([role, label]) => (
+                <option key={role} value={role}>{label}</option>
+              ))}
+            </select>
+            <button
+              onClick={handleCreateAccount}
+              disabled={isWorking || !newName.trim()}
+              className="px-4 py-2 rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] text-white text-sm font-medium shadow-sm transition-all hover:shadow-lg active:scale-95 disabled:opacity-50 flex items-center justify-center gap-2"
+            >
+              <Plus className="h-4 w-4" />
+              {t.create}
+            </button>
+          </div>
+
+          {isLoading ? (
+            <div className="flex justify-center py-8">
+              <Loader2 className="h-6 w-6 animate-spin text-[#5C2F0E]" />
+            </div>
+          ) : accounts.length === 0 ? (
+            <p className="text-sm text-[#4E616F]">{t.noAccounts}</p>
+          ) : (
+            <div className="space-y-3">
+              {accounts.map((account) => {
+                const isDisabled = account.status === 'disabled';
+                return (
+                  <div key={account.id} className="rounded-lg border border-[#ABC0B9] p-4">
+                    <div className="flex flex-wrap items-center justify-between gap-2">
+                      <div>
+                        <span className="font-medium text-[#2D363F]">{account.name}</span>
+                        <span className="text-sm text-[#4E616F]"> · {account.employee_number} · {roleLabels[account.role] || account.role}</span>
+                        {isDisabled && (
+                          <span className="ml-2 rounded bg-[#AA2F0D]/10 px-2 py-0.5 text-xs text-[#AA2F0D]">{t.disabled}</span>
+                        )}
+                      </div>
+                      <div className="flex gap-2 text-sm">
+                        <button
+                          onClick={() => openKeyForm(account.id)}
+                          disabled={isWorking || isDisabled}
+                          className="px-3 py-1.5 rounded-lg border border-[#5C2F0E] text-[#5C2F0E] hover:bg-[#5C2F0E]/5 disabled:opacity-50 flex items-center gap-1"
+                        >
+                          <KeyRound className="h-4 w-4" />
+                          {t.newKey}
+                        </button>
+                        <button
+                          onClick={() => run(async () => { await serviceAccountsApi.update(account.id, { active: isDisabled }); })}
+                          disabled={isWorking}
+                          className="px-3 py-1.5 rounded-lg border border-[#ABC0B9] text-[#4E616F] hover:bg-[#FAFBFA] disabled:opacity-50"
+                        >
+                          {isDisabled ? t.enable : t.disable}
+                        </button>
+                        <button
+                          onClick={() => {
+                            if (confirm(t.confirmDelete)) run(() => serviceAccountsApi.delete(account.id));
+                          }}
+                          disabled={isWorking}
+                          className="p-1.5 rounded-lg text-[#AA2F0D] hover:bg-[#AA2F0D]/10 disabled:opacity-50"
+                          title={t.delete}
+                        >
+                          <Trash2 className="h-4 w-4" />
+                        </button>
+                      </div>
+                    </div>
+
+                    {/* Issue a key */}
+                    {keyAccountId === account.id && (
+                      <div className="mt-3 rounded-lg bg-[#FAFBFA] border border-[#ABC0B9]/50 p-3 space-y-3">
+                        {createdKey ? (
+                          <div className="space-y-2">
+                            <p className="text-sm text-[#5C2F0E] font-medium">{t.keyCreated}</p>
+                            <div className="flex gap-2">
+                              <code className="flex-1 break-all rounded bg-white border border-[#ABC0B9] px-2 py-1.5 text-xs text-[#2D363F]">
+                                {createdKey}
+                              </code>
+                              <button
+                                onClick={copyKey}
+                                className="px-3 py-1.5 rounded-lg border border-[#5C2F0E] text-[#5C2F0E] text-sm flex items-center gap-1"
+                              >
+                                {copied ? <Check className="h-4 w-4" /> : <Copy className="h-4 w-4" />}
+                                {copied ? t.copied : t.copy}
+                              </button>
+                            </div>
+                            <button
+                              onClick={() => setKeyAccountId(null)}
+                              className="text-sm text-[#4E616F] hover:text-[#2D363F]"
+                            >
+                              {t.close}
+                            </button>
+                          </div>
+                        ) : (
+                          <>
+                            <div className="flex flex-col sm:flex-row gap-2">
+                              <input
+                                type="text"
+                                value={keyName}
+                                onChange={(e) => setKeyName(e.target.value)}
+                                placeholder={t.keyName}
+                                className="flex-1 rounded-lg border border-[#ABC0B9] px-3 py-2 text-sm text-[#2D363F] focus:border-[#5C2F0E] focus:outline-none"
+                              />
+                              <label className="flex items-center gap-2 text-sm text-[#4E616F]">
+                                {t.expiresIn}
+                                <select
+                                  value={keyExpiry}
+                                  onChange={(e) => setKeyExpiry(Number(e.target.value))}
+                                  className="rounded-lg border border-[#ABC0B9] px-2 py-2 text-sm text-[#2D363F]"
+                                >
+                                  {expiryOptions.map((days) => (
+                                    <option key={days} value={days}>{days} {t.days}</option>
+                                  ))}
+                                </select>
+                              </label>
+                            </div>
+                            <div>
+                              <p className="text-xs font-semibold text-[#2D363F] mb-1">{t.scopes}</p>
+                              <div className="grid grid-cols-1 sm:grid-cols-2 gap-1">
+                                {scopes?.resources.map((resource) => (
+                                  <div key={resource.name} className="flex items-center justify-between gap-2 text-sm">
+                                    <span className="text-[#2D363F]" title={resource.description}>{resource.name}</span>
+                                    <span className="flex gap-3 text-[#4E616F]">
+                                      {(['read', 'write'] as const).map((action) => (
+                                        <label key={action} className="flex items-center gap-1">
+                                          <input
+                                            type="checkbox"
+                                            checked={keyScopes.includes(`${resource.name}:${action}`)}
+                                            onChange={() => toggleScope(`${resource.name}:${action}`)}
+                                            className="accent-[#5C2F0E]"
+                                          />
+                                          {t[action]}
+                                        </label>
+                                      ))}
+                                    </span>
+                                  </div>
+                                ))}
+                              </div>
+                            </div>
+                            <div className="flex justify-end gap-2">
+                              <button
+                                onClick={() => setKeyAccountId(null)}
+                                className="px-3 py-1.5 text-sm text-[#4E616F] hover:text-[#2D363F]"
+                              >
+                                {t.cancel}
+                              </button>
+                              <button
+                                onClick={handleIssueKey}
+                                disabled={isWorking || !keyName.trim() || keyScopes.length === 0}
+                                className="px-3 py-1.5 rounded-lg bg-[#5C2F0E] text-white text-sm font-medium disabled:opacity-50"
+                              >
+                                {t.issue}
+                              </button>
+                            </div>
+                          </>
+                        )}
+                      </div>
+                    )}
+
+                    {/* Keys */}
+                    <div className="mt-3 space-y-1">
+                      {account.api_keys.length === 0 && (
+                        <p className="text-xs text-[#4E616F]">{t.noKeys}</p>
+                      )}
+                      {account.api_keys.map((key) => {
+                        const expired = !!key.expires_at && new Date(key.expires_at) < new Date();
+                        const inactive = !!key.revoked_at || expired;
+                        return (
+                          <div
+                            key={key.id}
+                            className={`flex flex-wrap items-center justify-between gap-2 rounded bg-[#FAFBFA] border border-[#ABC0B9]/50 px-3 py-2 text-sm ${inactive ? 'opacity-60' : ''}`}
+                          >
+                            <div className="min-w-0">
+                              <div className="text-[#2D363F]">
+                                <span className="font-medium">{key.name}</span>
+                                <code className="ml-2 text-xs text-[#4E616F]">{key.prefix}…</code>
+                                {key.revoked_at && <span className="ml-2 text-xs text-[#AA2F0D]">{t.revoked}</span>}
+                                {!key.revoked_at && expired && <span className="ml-2 text-xs text-[#AA2F0D]">{t.expired}</span>}
+                              </div>
+                              <div className="text-xs text-[#4E616F] mt-0.5">
+                                {key.scopes.join(', ')}
+                                {key.expires_at && ` · ${t.expires} ${formatDate(key.expires_at)}`}
+                                {' · '}
+                                {key.last_used_at
+                                  ? `${t.lastUsed} ${new Date(key.last_used_at).toLocaleString()}${key.last_used_ip ? ` (${key.last_used_ip})` : ''}`
+                                  : t.never}
+                              </div>
+                            </div>
+                            {!key.revoked_at && (
+                              <button
+                                onClick={() => {
+                                  if (confirm(t.confirmRevoke)) {
+                                    run(async () => { await serviceAccountsApi.revokeKey(account.id, key.id); });
+                                  }
+                                }}
+                                disabled={isWorking}
+                                className="px-2 py-1 rounded text-xs text-[#AA2F0D] hover:bg-[#AA2F0D]/10 disabled:opacity-50 flex items-center gap-1"
+                              >
+                                <Ban className="h-3.5 w-3.5" />
+                                {t.revoke}
+                              </button>
+                            )}
+                          </div>
+                        );
+                      })}
+                    </div>
+                  </div>
+                );
+              })}
+            </div>
+          )}
+        </div>
+
+        <div className="px-6 py-4 border-t border-[#ABC0B9] flex justify-end">
+          <button
+            onClick={onClose}
+            className="px-5 py-2.5 text-[#4E616F] font-medium transition-colors hover:text-[#2D363F]"
+          >
+            {t.close}
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,240 @@
+'use client';
+
+import { useEffect, useState } from 'react';
+import { useRouter } from 'next/navigation';
+import { useAuth } from '@/contexts/AuthContext';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import type { AuthResponse, TwoFactorChallenge, TwoFactorSetup } from '@/types';
+import { ArrowLeft, Copy, Loader2, ShieldCheck } from 'lucide-react';
+
+interface TwoFactorLoginStepProps {
+  challenge: TwoFactorChallenge;
+  onCancel: () => void;
+}
+
+// Second login step: asks for a TOTP or recovery code, or, for users whose
+// role requires two-factor authentication, sets it up first
+export default function TwoFactorLoginStep({ challenge, onCancel }: TwoFactorLoginStepProps) {
+  const router = useRouter();
+  const { completeLogin } = useAuth();
+  const { language } = useLanguage();
+  const [code, setCode] = useState('');
+  const [setup, setSetup] = useState<TwoFactorSetup | null>(null);
+  const [session, setSession] = useState<AuthResponse | null>(null);
+  const [error, setError] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+
+  const text = {
+    en: {
+      verifyTitle: 'Two-factor authentication',
+      verifySubtitle: 'Enter the 6-digit code from your authenticator app, or one of your recovery codes',
+      enrollTitle: 'Set up two-factor authentication',
+      enrollSubtitle: 'Your role requires two-factor authentication. Add this account to an authenticator app, then enter the code it shows.',
+      openInApp: 'Open in authenticator app',
+      secretKey: 'Or enter this key manually',
+      code: 'Verification code',
+      verify: 'Verify',
+      verifying: 'Verifying...',
+      invalidCode: 'Invalid verification code',
+      expired: 'Your login has expired. Please sign in again.',
+      tooManyAttempts: 'Too many failed attempts. Please wait a moment and try again.',
+      recoveryTitle: 'Save your recovery codes',
+      recoveryHint: 'Each code can be used once instead of an authenticator code. Store them somewhere safe; they won\'t be shown again.',
+      copy: 'Copy',
+      continue: 'Continue',
+      back: 'Back to sign in',
+    },
+    zh: {
+      verifyTitle: '双重验证',
+      verifySubtitle: '请输入身份验证器应用中的 6 位验证码，或一个恢复码',
+      enrollTitle: '设置双重验证',
+      enrollSubtitle: '您的角色需要双重验证。请将此账户添加到身份验证器应用，然后输入其显示的验证码。',
+      openInApp: '在身份验证器应用中打开',
+      secretKey: '或手动输入此密钥',
+      code: '验证码',
+      verify: '验证',
+      verifying: '验证中...',
+      invalidCode: '验证码无效',
+      expired: '登录已过期，请重新登录。',
+      tooManyAttempts: '失败次数过多，请稍候再试。',
+      recoveryTitle: '保存您的恢复码',
+      recoveryHint: '每个恢复码可代替验证码使用一次。请妥善保存，它们不会再次显示。',
+      copy: '复制',
+      continue: '继续',
+      back: '返回登录',
+    },
+    es: {
+      verifyTitle: 'Verificación en dos pasos',
+      verifySubtitle: 'Ingrese el código de 6 dígitos de su aplicación de autenticación, o uno de sus códigos de recuperación',
+      enrollTitle: 'Configurar verificación en dos pasos',
+      enrollSubtitle: 'Su rol requiere verificación en dos pasos. Agregue esta cuenta a una aplicación de autenticación e ingrese el código que muestra.',
+      openInApp: 'Abrir en la aplicación de autenticación',
+      secretKey: 'O ingrese esta clave manualmente',
+      code: 'Código de verificación',
+      verify: 'Verificar',
+      verifying: 'Verificando...',
+      invalidCode: 'Código de verificación inválido',
+      expired: 'Su inicio de sesión caducó. Inicie sesión de nuevo.',
+      tooManyAttempts: 'Demasiados intentos fallidos. Espere un momento e intente de nuevo.',
+      recoveryTitle: 'Guarde sus códigos de recuperación',
+      recoveryHint: 'Cada código puede usarse una vez en lugar de un código de autenticación. Guárdelos en un lugar seguro; no se mostrarán de nuevo.',
+      copy: 'Copiar',
+      continue: 'Continuar',
+      back: 'Volver a iniciar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  useEffect(() => {
+    if (!challenge.enrollment_required) return;
+    authApi
+      .beginTwoFactorSetup(challenge.two_factor_token)
+      .then(setSetup)
+      .catch(() => setError(t.expired));
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [challenge]);
+
+  const finish = (response: AuthResponse) => {
+    completeLogin(response);
+    router.push('/');
+  };
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setIsLoading(true);
+
+    try {
+      if (challenge.enrollment_required) {
+        const response = await authApi.enableTwoFactor(challenge.two_factor_token, code);
+        // Show the recovery codes before going on
+        setSession(response);
+      } else {
+        finish(await authApi.verifyTwoFactor(challenge.two_factor_token, code));
+      }
+    } catch (err: unknown) {
+      const apiError = err as { response?: { status?: number; data?: { error?: { code?: string } } } };
+      if (apiError?.response?.status === 429) {
+        setError(t.tooManyAttempts);
+      } else if (apiError?.response?.data?.error?.code === 'INVALID_CODE') {
+        setError(t.invalidCode);
+      } else {
+        setError(t.expired);
+      }
+      setCode('');
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  if (session?.recovery_codes) {
+    return (
+      <div>
+        <div className="text-center mb-6">
+          <ShieldCheck className="h-10 w-10 text-[#5C2F0E] mx-auto mb-2" />
+          <h2 className="text-xl text-[#2D363F] mb-1" style={{ fontWeight: 600 }}>
+            {t.recoveryTitle}
+          </h2>
+          <p className="text-sm text-[#4E616F]">{t.recoveryHint}</p>
+        </div>
+        <div className="grid grid-cols-2 gap-2 rounded-lg bg-[#FAFBFA] border border-[#ABC0B9] p-4 font-mono text-sm text-[#2D363F] mb-3">
+          {session.recovery_codes.map((recoveryCode) => (
+            <span key={recoveryCode}>{recoveryCode}</span>
+          ))}
+        </div>
+        <button
+          type="button"
+          onClick={() => navigator.clipboard.writeText(session.recovery_codes!.join('\n'))}
+          className="w-full mb-3 rounded-lg border border-[#ABC0B9] px-4 py-2 text-sm text-[#5C2F0E] hover:bg-[#FAFBFA] flex items-center justify-center gap-2"
+        >
+          <Copy className="h-4 w-4" />
+          {t.copy}
+        </button>
+        <button
+          type="button"
+          onClick={() => finish(session)}
+          className="w-full rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98]"
+        >
+          {t.continue}
+        </button>
+      </div>
+    );
+  }
+
+  return (
+    <div>
+      <div className="text-center mb-6">
+        <h2 className="text-xl text-[#2D363F] mb-1" style={{ fontWeight: 600 }}>
+          {challenge.enrollment_required ? t.enrollTitle : t.verifyTitle}
+        </h2>
+        <p className="text-sm text-[#4E616F]">
+          {challenge.enrollment_required ? t.enrollSubtitle : t.verifySubtitle}
+        </p>
+      </div>
+
+      {error && (
+        <div className="mb-4 rounded-lg p-3 text-sm bg-[#AA2F0D]/10 border border-[#AA2F0D]-200 text-[#AA2F0D]">
+          {error}
+        </div>
+      )}
+
+      {challenge.enrollment_required && setup && (
+        <div className="mb-4 rounded-lg bg-[#FAFBFA] border border-[#ABC0B9] p-4 text-sm">
+          <a href={setup.provisioning_uri} className="text-[#5C2F0E] font-medium hover:underline">
+            {t.openInApp}
+          </a>
+          <p className="mt-3 text-xs text-[#4E616F]">{t.secretKey}</p>
+          <p className="font-mono text-[#2D363F] break-all select-all">{setup.secret}</p>
+        </div>
+      )}
+
+      <form onSubmit={handleSubmit} className="space-y-4">
+        <div>
+          <label htmlFor="code" className="block text-sm font-medium text-[#2D363F] mb-1.5">
+            {t.code}
+          </label>
+          <input
+            id="code"
+            type="text"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+            required
+            autoFocus
+            autoComplete="one-time-code"
+            inputMode={challenge.enrollment_required ? 'numeric' : 'text'}
+            placeholder="123456"
+            className="w-full rounded-lg border border-[#ABC0B9] bg-white px-4 py-3 text-sm tracking-widest text-[#2D363F] transition-all placeholder:text-[#4E616F] focus:border-[#5C2F0E] focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20"
+          />
+        </div>
+
+        <button
+          type="submit"
+          disabled={isLoading || (challenge.enrollment_required && !setup)}
+          className="w-full rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+        >
+          {isLoading ? (
+            <>
+              <Loader2 className="h-4 w-4 animate-spin" />
+              {t.verifying}
+            </>
+          ) : (
+            t.verify
+          )}
+        </button>
+      </form>
+
+      <div className="mt-6 pt-4 border-t border-[#ABC0B9] text-center">
+        <button
+          type="button"
+          onClick={onCancel}
+          className="text-sm text-[#5C2F0E] font-medium hover:underline inline-flex items-center gap-1"
+        >
+          <ArrowLeft className="h-3.5 w-3.5" />
+          {t.back}
+        </button>
+      </div>
+    </div>
+  );
+}
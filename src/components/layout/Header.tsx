@@ -3,18 +3,26 @@
 import { useState, useEffect, useRef, useCallback } from 'react';
 import Link from 'next/link';
 import { useRouter } from 'next/navigation';
-import { Search, Bell, Globe, ChevronDown, LogOut, Check, CheckCheck, Key, X, Eye, EyeOff, Loader2, AlertCircle, CheckCircle, ShoppingCart } from 'lucide-react';
+import { Search, Bell, Globe, ChevronDown, LogOut, Check, CheckCheck, Key, X, Eye, EyeOff, Loader2, AlertCircle, CheckCircle, ShoppingCart, ShieldCheck, Building2 } from 'lucide-react';
 import { useAuth } from '@/contexts/AuthContext';
-import { useLanguage, Language } from '@/contexts/LanguageContext';
+import { useLanguage, useEnabledLanguages, Language } from '@/contexts/LanguageContext';
 import { useCart } from '@/contexts/CartContext';
-import { notificationsApi, authApi, type NotificationData } from '@/lib/api';
+import { notificationsApi, authApi, companiesApi, getActiveCompany, setActiveCompany, type NotificationData } from '@/lib/api';
+import type { Company } from '@/types';
+import { TwoFactorModal } from './TwoFactorModal';
 
 export function Header() {
   const router = useRouter();
-  const { user, logout } = useAuth();
-  const { language, setLanguage } = useLanguage();
+  const { user, logout, hasPermission } = useAuth();
+  const { language, setLanguage, contentLanguage, setContentLanguage } = useLanguage();
+  // Enabled languages the interface isn't translated into can still be
+  // picked for content, with the interface staying in the current language
+  const contentOnlyLanguages = useEnabledLanguages().filter((lang) => !['en', 'zh', 'es'].includes(lang.code));
+  const contentLanguageLabel = contentOnlyLanguages.find((lang) => lang.code === contentLanguage)?.native_name;
   const { itemCount } = useCart();
   const [showLangMenu, setShowLangMenu] = useState(false);
+  const [showCompanyMenu, setShowCompanyMenu] = useState(false);
+  const [companies, setCompanies] = useState<Company[]>([]);
   const [showUserMenu, setShowUserMenu] = useState(false);
   const [showNotifications, setShowNotifications] = useState(false);
   const [notifications, setNotifications] = useState<NotificationData[]>([]);
@@ -22,6 +30,7 @@ export function Header() {
   const [isLoadingNotifications, setIsLoadingNotifications] = useState(false);
   const notificationRef = useRef<HTMLDivElement>(null);
   const langMenuRef = useRef<HTMLDivElement>(null);
+  const companyMenuRef = useRef<HTMLDivElement>(null);
   const userMenuRef = useRef<HTMLDivElement>(null);
 
   // Mobile search state
@@ -38,6 +47,7 @@ export function Header() {
   const [isChangingPassword, setIsChangingPassword] = useState(false);
   const [passwordError, setPasswordError] = useState<string | null>(null);
   const [passwordSuccess, setPasswordSuccess] = useState(false);
+  const [showTwoFactorModal, setShowTwoFactorModal] = useState(false);
 
   // Fetch notifications
   const fetchNotifications = useCallback(async () => {
@@ -62,6 +72,27 @@ export function Header() {
     }
   }, [user, fetchNotifications]);
 
+  // Companies users with companies.manage can switch to
+  const canSwitchCompany = hasPermission('companies.manage');
+  useEffect(() => {
+    if (canSwitchCompany) {
+      companiesApi.list().then(setCompanies).catch((err) => console.error('Failed to fetch companies:', err));
+    }
+  }, [canSwitchCompany]);
+
+  // The company the app works in: the one switched to, or the user's own
+  const defaultCompany = companies.find((c) => c.is_default)?.code;
+  const ownCompany = companies.some((c) => c.code === user?.company_code) ? user?.company_code : defaultCompany;
+  const activeCompany = getActiveCompany() || ownCompany;
+
+  // Every page's data belongs to the company, so switching reloads
+  const switchCompany = (code: string) => {
+    setShowCompanyMenu(false);
+    if (code === activeCompany) return;
+    setActiveCompany(code === ownCompany ? null : code);
+    window.location.reload();
+  };
+
   // Close dropdowns when clicking outside
   useEffect(() => {
     const handleClickOutside = (event: MouseEvent) => {
@@ -73,6 +104,9 @@ export function Header() {
       if (langMenuRef.current && !langMenuRef.current.contains(target)) {
         setShowLangMenu(false);
       }
+      if (companyMenuRef.current && !companyMenuRef.current.contains(target)) {
+        setShowCompanyMenu(false);
+      }
       if (userMenuRef.current && !userMenuRef.current.contains(target)) {
         setShowUserMenu(false);
       }
@@ -126,6 +160,7 @@ export function Header() {
       notifications: 'Notifications',
       logout: 'Sign Out',
       changePassword: 'Change Password',
+      twoFactor: 'Two-Factor Authentication',
       passwordModalTitle: 'Change Password',
       currentPassword: 'Current Password',
       newPassword: 'New Password',
@@ -147,6 +182,7 @@ export function Header() {
       notifications: '通知',
       logout: '退出登录',
       changePassword: '修改密码',
+      twoFactor: '双重验证',
       passwordModalTitle: '修改密码',
       currentPassword: '当前密码',
       newPassword: '新密码',
@@ -168,6 +204,7 @@ export function Header() {
       notifications: 'Notificaciones',
       logout: 'Cerrar Sesion',
       changePassword: 'Cambiar Contrasena',
+      twoFactor: 'Verificacion en Dos Pasos',
       passwordModalTitle: 'Cambiar Contrasena',
       currentPassword: 'Contrasena Actual',
       newPassword: 'Nueva Contrasena',
@@ -396,6 +433,40 @@ export function Header() {
             )}
           </div>
 
+          {/* Company Switch - Only for users who can work in any company */}
+          {canSwitchCompany && companies.length > 1 && (
+            <div className="relative hidden sm:block" ref={companyMenuRef}>
+              <button
+                onClick={() => setShowCompanyMenu(!showCompanyMenu)}
+                className="flex items-center gap-2 rounded-xl border border-[#ABC0B9]/50 bg-white px-3.5 py-2 text-sm text-[#4E616F] transition-all duration-200 hover:border-[#5C2F0E]/30 hover:bg-[#FAFBFA] hover:text-[#5C2F0E] active:scale-[0.98] shadow-sm"
+              >
+                <Building2 className="h-4 w-4" />
+                <span className="font-medium tracking-tight">{activeCompany}</span>
+                <ChevronDown className={`h-3 w-3 transition-transform duration-200 ${showCompanyMenu ? 'rotate-180' : ''}`} />
+              </button>
+
+              {showCompanyMenu && (
+                <div className="absolute right-0 top-14 w-60 rounded-2xl bg-white shadow-dropdown border border-[#ABC0B9]/40 overflow-hidden z-50 animate-scale-in">
+                  {companies.map((company) => (
+                    <button
+                      key={company.id}
+                      onClick={() => switchCompany(company.code)}
+                      className={`w-full px-4 py-3 text-left text-sm transition-colors ${
+                        activeCompany === company.code
+                          ? 'bg-[#5C2F0E]/10 text-[#5C2F0E]'
+                          : 'text-[#2D363F] hover:bg-[#FAFBFA]'
+                      }`}
+                      style={{ fontWeight: activeCompany === company.code ? 600 : 400 }}
+                    >
+                      {company.name}
+                      <span className="block text-xs text-[#4E616F]" style={{ fontWeight: 400 }}>{company.code}</span>
+                    </button>
+                  ))}
+                </div>
+              )}
+            </div>
+          )}
+
           {/* Language Switch - Hidden on small screens */}
           <div className="relative hidden sm:block" ref={langMenuRef}>
             <button
@@ -403,7 +474,7 @@ export function Header() {
               className="flex items-center gap-2 rounded-xl border border-[#ABC0B9]/50 bg-white px-3.5 py-2 text-sm text-[#4E616F] transition-all duration-200 hover:border-[#5C2F0E]/30 hover:bg-[#FAFBFA] hover:text-[#5C2F0E] active:scale-[0.98] shadow-sm"
             >
               <Globe className="h-4 w-4" />
-              <span className="font-medium tracking-tight">{languageLabels[language]}</span>
+              <span className="font-medium tracking-tight">{contentLanguageLabel ?? languageLabels[language]}</span>
               <ChevronDown className={`h-3 w-3 transition-transform duration-200 ${showLangMenu ? 'rotate-180' : ''}`} />
             </button>
 
@@ -417,15 +488,32 @@ export function Header() {
                       setShowLangMenu(false);
                     }}
                     className={`w-full px-4 py-3 text-left text-sm transition-colors ${
-                      language === key
+                      contentLanguage === key
                         ? 'bg-[#5C2F0E]/10 text-[#5C2F0E]'
                         : 'text-[#2D363F] hover:bg-[#FAFBFA]'
                     }`}
-                    style={{ fontWeight: language === key ? 600 : 400 }}
+                    style={{ fontWeight: contentLanguage === key ? 600 : 400 }}
                   >
                     {label}
                   </button>
                 ))}
+                {contentOnlyLanguages.map((lang) => (
+                  <button
+                    key={lang.code}
+                    onClick={() => {
+                      setContentLanguage(lang.code);
+                      setShowLangMenu(false);
+                    }}
+                    className={`w-full px-4 py-3 text-left text-sm transition-colors ${
+                      contentLanguage === lang.code
+                        ? 'bg-[#5C2F0E]/10 text-[#5C2F0E]'
+                        : 'text-[#2D363F] hover:bg-[#FAFBFA]'
+                    }`}
+                    style={{ fontWeight: contentLanguage === lang.code ? 600 : 400 }}
+                  >
+                    {lang.native_name}
+                  </button>
+                ))}
               </div>
             )}
           </div>
@@ -463,7 +551,7 @@ export function Header() {
                           setShowUserMenu(false);
                         }}
                         className={`flex-1 py-2 text-xs rounded-lg transition-colors ${
-                          language === lang
+                          contentLanguage === lang
                             ? 'bg-[#5C2F0E] text-white'
                             : 'bg-[#FAFBFA] text-[#2D363F] hover:bg-[#ABC0B9]'
                         }`}
@@ -471,6 +559,22 @@ export function Header() {
                         {t.languages[lang]}
                       </button>
                     ))}
+                    {contentOnlyLanguages.map((lang) => (
+                      <button
+                        key={lang.code}
+                        onClick={() => {
+                          setContentLanguage(lang.code);
+                          setShowUserMenu(false);
+                        }}
+                        className={`flex-1 py-2 text-xs rounded-lg transition-colors ${
+                          contentLanguage === lang.code
+                            ? 'bg-[#5C2F0E] text-white'
+                            : 'bg-[#FAFBFA] text-[#2D363F] hover:bg-[#ABC0B9]'
+                        }`}
+                      >
+                        {lang.native_name}
+                      </button>
+                    ))}
                   </div>
                 </div>
                 <button
@@ -480,6 +584,16 @@ export function Header() {
                   <Key className="h-4 w-4" />
                   {t.changePassword}
                 </button>
+                <button
+                  onClick={() => {
+                    setShowUserMenu(false);
+                    setShowTwoFactorModal(true);
+                  }}
+                  className="w-full px-4 py-3 text-left text-sm text-[#2D363F] hover:bg-[#FAFBFA] transition-colors flex items-center gap-2 border-b border-[#ABC0B9]"
+                >
+                  <ShieldCheck className="h-4 w-4" />
+                  {t.twoFactor}
+                </button>
                 <button
                   onClick={handleLogout}
                   className="w-full px-4 py-3 text-left text-sm text-[#AA2F0D] hover:bg-[#AA2F0D]/10 transition-colors flex items-center gap-2"
@@ -628,6 +742,9 @@ export function Header() {
         </div>
       )}
 
+      {/* Two-Factor Authentication Modal */}
+      {showTwoFactorModal && <TwoFactorModal onClose={() => setShowTwoFactorModal(false)} />}
+
       {/* Mobile Search Modal */}
       {showMobileSearch && (
         <div className="fixed inset-0 bg-black/50 z-[100] md:hidden">
@@ -23,7 +23,7 @@ interface NavItem {
 
 export function MobileBottomNav() {
   const pathname = usePathname();
-  const { user } = useAuth();
+  const { hasPermission } = useAuth();
   const { language } = useLanguage();
 
   const text = {
@@ -66,7 +66,7 @@ export function MobileBottomNav() {
     return pathname === href || pathname.startsWith(href + '/');
   };
 
-  // Get 5 nav items based on user role
+  // Get 5 nav items based on user permissions
   const getNavItems = (): NavItem[] => {
     const baseItems: NavItem[] = [
       { icon: Home, labelKey: 'home', href: '/' },
@@ -75,15 +75,15 @@ export function MobileBottomNav() {
       { icon: ClipboardList, labelKey: 'requests', href: '/requests' },
     ];
 
-    // 5th item based on role
-    if (user?.role === 'general_manager') {
-      // GM sees Approvals as 5th item
+    // 5th item based on what the role can do
+    if (hasPermission('approvals.decide')) {
+      // Approvers see Approvals as 5th item
       baseItems.push({ icon: CheckSquare, labelKey: 'approvals', href: '/approvals' });
-    } else if (user?.role === 'admin') {
-      // Admin sees Admin settings as 5th item
+    } else if (hasPermission('settings.manage')) {
+      // Admins see Admin settings as 5th item
       baseItems.push({ icon: Settings, labelKey: 'admin', href: '/admin' });
-    } else if (user?.role === 'purchase_admin' || user?.role === 'supply_chain_manager') {
-      // Purchase admin and supply chain see Orders as 5th item
+    } else if (hasPermission('orders.view')) {
+      // Purchasing staff see Orders as 5th item
       baseItems.push({ icon: Truck, labelKey: 'orders', href: '/admin/orders' });
     } else {
       // Regular employees see Inventory as 5th item (view only)
@@ -19,12 +19,13 @@ import {
 import { useAuth } from '@/contexts/AuthContext';
 import { useLanguage } from '@/contexts/LanguageContext';
 import { notificationsApi, type PendingCounts } from '@/lib/api';
+import type { Permission } from '@/types';
 
 interface MenuItem {
   icon: React.ElementType;
   labelKey: string;
   href: string;
-  roles?: string[];
+  permission?: Permission; // Hidden from users whose role doesn't grant it
   badgeKey?: keyof PendingCounts | 'pending_combined';
 }
 
@@ -34,34 +35,34 @@ const menuItems: MenuItem[] = [
   { icon: ExternalLink, labelKey: 'newPurchase', href: '/purchase/new' },
   { icon: ClipboardList, labelKey: 'requests', href: '/requests' },
 
-  // Approvals - GM only sees pending approvals badge (only GM can approve)
-  { icon: CheckSquare, labelKey: 'approvals', href: '/approvals', roles: ['general_manager'], badgeKey: 'pending_approvals' },
+  // Approvals - for whoever decides on requests
+  { icon: CheckSquare, labelKey: 'approvals', href: '/approvals', permission: 'approvals.decide', badgeKey: 'pending_approvals' },
 
-  // Orders - Supply Chain, Admin, Purchase Admin see pending orders to purchase/deliver
-  { icon: Truck, labelKey: 'orders', href: '/admin/orders', roles: ['admin', 'purchase_admin', 'supply_chain_manager'], badgeKey: 'pending_orders' },
+  // Orders - approved orders to purchase/deliver
+  { icon: Truck, labelKey: 'orders', href: '/admin/orders', permission: 'orders.view', badgeKey: 'pending_orders' },
 
-  // Inventory - Admin + Purchase Admin + Supply Chain Manager
-  { icon: Package, labelKey: 'inventory', href: '/inventory', roles: ['admin', 'purchase_admin', 'supply_chain_manager'] },
+  // Inventory - product management
+  { icon: Package, labelKey: 'inventory', href: '/inventory', permission: 'products.manage' },
 
-  // Analytics - Admin + Supply Chain Manager
-  { icon: BarChart3, labelKey: 'analytics', href: '/analytics', roles: ['admin', 'supply_chain_manager'] },
+  // Analytics
+  { icon: BarChart3, labelKey: 'analytics', href: '/analytics', permission: 'analytics.view' },
 
-  // Purchase Settings - Admin + Purchase Admin
-  { icon: ShoppingCart, labelKey: 'purchaseConfig', href: '/admin/purchase-config', roles: ['admin', 'purchase_admin'] },
+  // Purchase Settings
+  { icon: ShoppingCart, labelKey: 'purchaseConfig', href: '/admin/purchase-config', permission: 'purchase_config.manage' },
 
-  // User Management - Admin only
-  { icon: Users, labelKey: 'users', href: '/admin/users', roles: ['admin'] },
+  // User Management
+  { icon: Users, labelKey: 'users', href: '/admin/users', permission: 'users.manage' },
 
-  // Activity Logs - Admin only
-  { icon: Activity, labelKey: 'logs', href: '/admin/logs', roles: ['admin'] },
+  // Activity Logs
+  { icon: Activity, labelKey: 'logs', href: '/admin/logs', permission: 'logs.view' },
 
-  // System Admin - Admin only
-  { icon: Settings, labelKey: 'admin', href: '/admin', roles: ['admin'] },
+  // System Admin
+  { icon: Settings, labelKey: 'admin', href: '/admin', permission: 'settings.manage' },
 ];
 
 export function Sidebar() {
   const pathname = usePathname();
-  const { user } = useAuth();
+  const { user, hasPermission } = useAuth();
   const { language } = useLanguage();
   const [pendingCounts, setPendingCounts] = useState<PendingCounts | null>(null);
 
@@ -129,8 +130,8 @@ export function Sidebar() {
   const t = text[language];
 
   const filteredMenuItems = menuItems.filter((item) => {
-    if (!item.roles) return true;
-    return user && item.roles.includes(user.role);
+    if (!item.permission) return true;
+    return hasPermission(item.permission);
   });
 
   const isActive = (href: string) => {
@@ -0,0 +1,301 @@
+'use client';
+
+import { useEffect, useState } from 'react';
+import { ShieldCheck, X, Loader2, AlertCircle, Copy } from 'lucide-react';
+import { useAuth } from '@/contexts/AuthContext';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { twoFactorApi } from '@/lib/api';
+import type { TwoFactorSetup, TwoFactorStatus } from '@/types';
+
+interface TwoFactorModalProps {
+  onClose: () => void;
+}
+
+// Lets the current user set up, turn off, and renew recovery codes for
+// two-factor authentication
+export function TwoFactorModal({ onClose }: TwoFactorModalProps) {
+  const { refreshUser } = useAuth();
+  const { language } = useLanguage();
+  const [status, setStatus] = useState<TwoFactorStatus | null>(null);
+  const [setup, setSetup] = useState<TwoFactorSetup | null>(null);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[] | null>(null);
+  const [code, setCode] = useState('');
+  const [error, setError] = useState<string | null>(null);
+  const [isWorking, setIsWorking] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Two-Factor Authentication',
+      disabledInfo: 'Protect your account with a code from an authenticator app in addition to your password.',
+      enabledInfo: 'Two-factor authentication is on. You will be asked for a code from your authenticator app when you sign in.',
+      requiredInfo: 'Your role requires two-factor authentication, so it can\'t be turned off.',
+      remaining: 'Unused recovery codes',
+      setUp: 'Set Up',
+      openInApp: 'Open in authenticator app',
+      secretKey: 'Or enter this key manually',
+      code: 'Verification code',
+      codeHint: 'Enter the 6-digit code from your authenticator app',
+      enable: 'Turn On',
+      disable: 'Turn Off',
+      newCodes: 'New Recovery Codes',
+      recoveryTitle: 'Save your recovery codes',
+      recoveryHint: 'Each code can be used once instead of an authenticator code. Store them somewhere safe; they won\'t be shown again.',
+      copy: 'Copy',
+      done: 'Done',
+      close: 'Close',
+      invalidCode: 'Invalid verification code',
+      tooManyAttempts: 'Too many failed attempts. Please wait a moment and try again.',
+      failed: 'Something went wrong. Please try again.',
+    },
+    zh: {
+      title: '双重验证',
+      disabledInfo: '除密码外，使用身份验证器应用中的验证码保护您的账户。',
+      enabledInfo: '双重验证已开启。登录时需要输入身份验证器应用中的验证码。',
+      requiredInfo: '您的角色要求双重验证，因此无法关闭。',
+      remaining: '未使用的恢复码',
+      setUp: '设置',
+      openInApp: '在身份验证器应用中打开',
+      secretKey: '或手动输入此密钥',
+      code: '验证码',
+      codeHint: '输入身份验证器应用中的 6 位验证码',
+      enable: '开启',
+      disable: '关闭',
+      newCodes: '生成新恢复码',
+      recoveryTitle: '保存您的恢复码',
+      recoveryHint: '每个恢复码可代替验证码使用一次。请妥善保存，它们不会再次显示。',
+      copy: '复制',
+      done: '完成',
+      close: '关闭',
+      invalidCode: '验证码无效',
+      tooManyAttempts: '失败次数过多，请稍候再试。',
+      failed: '出现错误，请重试。',
+    },
+    es: {
+      title: 'Verificación en Dos Pasos',
+      disabledInfo: 'Proteja su cuenta con un código de una aplicación de autenticación además de su contraseña.',
+      enabledInfo: 'La verificación en dos pasos está activada. Se le pedirá un código de su aplicación de autenticación al iniciar sesión.',
+      requiredInfo: 'Su rol requiere verificación en dos pasos, por lo que no se puede desactivar.',
+      remaining: 'Códigos de recuperación sin usar',
+      setUp: 'Configurar',
+      openInApp: 'Abrir en la aplicación de autenticación',
+      secretKey: 'O ingrese esta clave manualmente',
+      code: 'Código de verificación',
+      codeHint: 'Ingrese el código de 6 dígitos de su aplicación de autenticación',
+      enable: 'Activar',
+      disable: 'Desactivar',
+      newCodes: 'Nuevos Códigos de Recuperación',
+      recoveryTitle: 'Guarde sus códigos de recuperación',
+      recoveryHint: 'Cada código puede usarse una vez en lugar de un código de autenticación. Guárdelos en un lugar seguro; no se mostrarán de nuevo.',
+      copy: 'Copiar',
+      done: 'Listo',
+      close: 'Cerrar',
+      invalidCode: 'Código de verificación inválido',
+      tooManyAttempts: 'Demasiados intentos fallidos. Espere un momento e intente de nuevo.',
+      failed: 'Algo salió mal. Intente de nuevo.',
+    },
+  };
+
+  const t = text[language];
+
+  const loadStatus = async () => {
+    try {
+      setStatus(await twoFactorApi.getStatus());
+    } catch {
+      setError(t.failed);
+    }
+  };
+
+  useEffect(() => {
+    loadStatus();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  // Runs a change that needs a code, and shows why it failed
+  const run = async (action: () => Promise<void>) => {
+    setError(null);
+    setIsWorking(true);
+    try {
+      await action();
+      setCode('');
+    } catch (err: unknown) {
+      const apiError = err as { response?: { status?: number; data?: { error?: { code?: string } } } };
+      if (apiError?.response?.status === 429) {
+        setError(t.tooManyAttempts);
+      } else if (apiError?.response?.data?.error?.code === 'INVALID_CODE') {
+        setError(t.invalidCode);
+      } else {
+        setError(t.failed);
+      }
+    } finally {
+      setIsWorking(false);
+    }
+  };
+
+  const handleSetup = () =>
+    run(async () => {
+      setSetup(await twoFactorApi.setup());
+    });
+
+  const handleEnable = () =>
+    run(async () => {
+      setRecoveryCodes(await twoFactorApi.enable(code));
+      setSetup(null);
+      await Promise.all([loadStatus(), refreshUser()]);
+    });
+
+  const handleNewCodes = () =>
+    run(async () => {
+      setRecoveryCodes(await twoFactorApi.regenerateRecoveryCodes(code));
+      await loadStatus();
+    });
+
+  const handleDisable = () =>
+    run(async () => {
+      await twoFactorApi.disable(code);
+      await Promise.all([loadStatus(), refreshUser()]);
+    });
+
+  const codeInput = (
+    <div>
+      <label className="block text-sm font-medium text-[#2D363F] mb-1">{t.code}</label>
+      <input
+        type="text"
+        value={code}
+        onChange={(e) => setCode(e.target.value)}
+        autoComplete="one-time-code"
+        placeholder="123456"
+        className="w-full px-4 py-2.5 rounded-lg border border-[#ABC0B9] text-[#2D363F] tracking-widest focus:outline-none focus:ring-2 focus:ring-[#5C2F0E]/20 focus:border-[#5C2F0E]"
+      />
+      <p className="text-xs text-[#4E616F] mt-1">{t.codeHint}</p>
+    </div>
+  );
+
+  const primaryButton =
+    'flex items-center gap-2 px-4 py-2 rounded-lg bg-gradient-to-r from-[#5C2F0E] to-[#2D363F] text-white font-medium hover:shadow-lg transition-all disabled:opacity-50';
+  const secondaryButton =
+    'px-4 py-2 rounded-lg border border-[#ABC0B9] bg-white text-[#2D363F] font-medium hover:bg-[#FAFBFA] transition-colors disabled:opacity-50';
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/50 flex items-center justify-center p-4 z-[100]"
+      onClick={onClose}
+    >
+      <div
+        className="bg-white rounded-xl shadow-2xl max-w-md w-full overflow-hidden"
+        onClick={(e) => e.stopPropagation()}
+      >
+        {/* Modal Header */}
+        <div className="flex items-center justify-between px-6 py-4 border-b border-[#ABC0B9]">
+          <h2 className="text-lg font-semibold text-[#2D363F] flex items-center gap-2">
+            <ShieldCheck className="h-5 w-5 text-[#5C2F0E]" />
+            {t.title}
+          </h2>
+          <button
+            onClick={onClose}
+            className="p-2 hover:bg-[#FAFBFA] rounded-lg transition-colors"
+          >
+            <X className="h-5 w-5 text-[#4E616F]" />
+          </button>
+        </div>
+
+        {/* Modal Body */}
+        <div className="px-6 py-4 space-y-4">
+          {error && (
+            <div className="flex items-center gap-2 p-3 rounded-lg bg-[#AA2F0D]/10 text-[#AA2F0D] text-sm">
+              <AlertCircle className="h-4 w-4 flex-shrink-0" />
+              {error}
+            </div>
+          )}
+
+          {!status ? (
+            <div className="flex justify-center py-8">
+              <Loader2 className="h-6 w-6 animate-spin text-[#5C2F0E]" />
+            </div>
+          ) : recoveryCodes ? (
+            <>
+              <div>
+                <p className="font-medium text-[#2D363F]">{t.recoveryTitle}</p>
+                <p className="text-sm text-[#4E616F]">{t.recoveryHint}</p>
+              </div>
+              <div className="grid grid-cols-2 gap-2 rounded-lg bg-[#FAFBFA] border border-[#ABC0B9] p-4 font-mono text-sm text-[#2D363F]">
+                {recoveryCodes.map((recoveryCode) => (
+                  <span key={recoveryCode}>{recoveryCode}</span>
+                ))}
+              </div>
+              <button
+                onClick={() => navigator.clipboard.writeText(recoveryCodes.join('\n'))}
+                className={`${secondaryButton} w-full flex items-center justify-center gap-2`}
+              >
+                <Copy className="h-4 w-4" />
+                {t.copy}
+              </button>
+            </>
+          ) : status.enabled ? (
+            <>
+              <p className="text-sm text-[#4E616F]">{t.enabledInfo}</p>
+              {status.required && <p className="text-sm text-[#4E616F]">{t.requiredInfo}</p>}
+              <p className="text-sm text-[#2D363F]">
+                {t.remaining}: <span className="font-medium">{status.recovery_codes_remaining}</span>
+              </p>
+              {codeInput}
+            </>
+          ) : setup ? (
+            <>
+              <div className="rounded-lg bg-[#FAFBFA] border border-[#ABC0B9] p-4 text-sm">
+                <a href={setup.provisioning_uri} className="text-[#5C2F0E] font-medium hover:underline">
+                  {t.openInApp}
+                </a>
+                <p className="mt-3 text-xs text-[#4E616F]">{t.secretKey}</p>
+                <p className="font-mono text-[#2D363F] break-all select-all">{setup.secret}</p>
+              </div>
+              {codeInput}
+            </>
+          ) : (
+            <p className="text-sm text-[#4E616F]">{t.disabledInfo}</p>
+          )}
+        </div>
+
+        {/* Modal Footer */}
+        <div className="flex items-center justify-end gap-3 px-6 py-4 border-t border-[#ABC0B9] bg-[#FAFBFA]">
+          {recoveryCodes ? (
+            <button onClick={() => setRecoveryCodes(null)} className={primaryButton}>
+              {t.done}
+            </button>
+          ) : (
+            <>
+              <button onClick={onClose} disabled={isWorking} className={secondaryButton}>
+                {t.close}
+              </button>
+              {status?.enabled && (
+                <>
+                  {!status.required && (
+                    <button onClick={handleDisable} disabled={isWorking || !code} className={secondaryButton}>
+                      {t.disable}
+                    </button>
+                  )}
+                  <button onClick={handleNewCodes} disabled={isWorking || !code} className={primaryButton}>
+                    {isWorking && <Loader2 className="h-4 w-4 animate-spin" />}
+                    {t.newCodes}
+                  </button>
+                </>
+              )}
+              {status && !status.enabled && (
+                setup ? (
+                  <button onClick={handleEnable} disabled={isWorking || !code} className={primaryButton}>
+                    {isWorking && <Loader2 className="h-4 w-4 animate-spin" />}
+                    {t.enable}
+                  </button>
+                ) : (
+                  <button onClick={handleSetup} disabled={isWorking} className={primaryButton}>
+                    {isWorking && <Loader2 className="h-4 w-4 animate-spin" />}
+                    {t.setUp}
+                  </button>
+                )
+              )}
+            </>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -2,15 +2,19 @@
 
 import { createContext, useContext, useState, useEffect, ReactNode } from 'react';
 import { authApi, setAccessToken, getAccessToken } from '@/lib/api';
-import type { User, LoginCredentials } from '@/types';
+import type { User, LoginCredentials, AuthResponse, TwoFactorChallenge, Permission } from '@/types';
 
 interface AuthContextType {
   user: User | null;
   isLoading: boolean;
   isAuthenticated: boolean;
-  login: (credentials: LoginCredentials) => Promise<void>;
+  // Resolves with a challenge if a second factor is needed; finish with completeLogin
+  login: (credentials: LoginCredentials) => Promise<TwoFactorChallenge | null>;
+  completeLogin: (response: AuthResponse) => void;
   logout: () => Promise<void>;
   refreshUser: () => Promise<void>;
+  // Whether the user's role grants the permission; the backend checks it too
+  hasPermission: (permission: Permission) => boolean;
 }
 
 const AuthContext = createContext<AuthContextType | undefined>(undefined);
@@ -43,6 +47,14 @@ export function AuthProvider({ children }: { children: ReactNode }) {
 
   const login = async (credentials: LoginCredentials) => {
     const response = await authApi.login(credentials);
+    if ('two_factor_required' in response) {
+      return response;
+    }
+    setUser(response.user);
+    return null;
+  };
+
+  const completeLogin = (response: AuthResponse) => {
     setUser(response.user);
   };
 
@@ -54,6 +66,8 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   };
 
+  const hasPermission = (permission: Permission) => !!user?.permissions?.includes(permission);
+
   return (
     <AuthContext.Provider
       value={{
@@ -61,8 +75,10 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         isLoading,
         isAuthenticated: !!user,
         login,
+        completeLogin,
         logout,
         refreshUser,
+        hasPermission,
       }}
     >
       {children}
@@ -1,12 +1,18 @@
 'use client';
 
 import { createContext, useContext, useState, useEffect, ReactNode, useSyncExternalStore } from 'react';
+import { languagesApi, type LanguageInfo } from '@/lib/api';
 
 export type Language = 'en' | 'zh' | 'es';
 
 interface LanguageContextType {
+  // Interface language
   language: Language;
   setLanguage: (lang: Language) => void;
+  // Language of product names, specifications and translated requests. It is
+  // the interface language unless the user picked another enabled language.
+  contentLanguage: string;
+  setContentLanguage: (code: string) => void;
 }
 
 const LanguageContext = createContext<LanguageContextType | undefined>(undefined);
@@ -21,6 +27,12 @@ const getLanguageFromStorage = (): Language => {
   return 'en';
 };
 
+// Get the content language from localStorage, if the user picked one
+const getContentLanguageFromStorage = (): string => {
+  if (typeof window === 'undefined') return '';
+  return localStorage.getItem('content_language') || '';
+};
+
 // Subscribe to storage changes (for cross-tab sync)
 const subscribeToStorage = (callback: () => void) => {
   window.addEventListener('storage', callback);
@@ -35,22 +47,44 @@ export function LanguageProvider({ children }: { children: ReactNode }) {
     () => 'en' as Language // Server snapshot
   );
 
+  const storedContentLanguage = useSyncExternalStore(
+    subscribeToStorage,
+    getContentLanguageFromStorage,
+    () => ''
+  );
+
   const [language, setLanguageState] = useState<Language>(storedLanguage);
+  const [contentLanguage, setContentLanguageState] = useState(storedContentLanguage);
 
   // Sync with storage on mount and when storage changes
   useEffect(() => {
     setLanguageState(storedLanguage);
   }, [storedLanguage]);
 
+  useEffect(() => {
+    setContentLanguageState(storedContentLanguage);
+  }, [storedContentLanguage]);
+
+  // Picking an interface language shows content in it too
   const setLanguage = (lang: Language) => {
     setLanguageState(lang);
+    setContentLanguageState('');
     localStorage.setItem('language', lang);
+    localStorage.removeItem('content_language');
     // Dispatch storage event for cross-tab sync
     window.dispatchEvent(new StorageEvent('storage', { key: 'language', newValue: lang }));
   };
 
+  const setContentLanguage = (code: string) => {
+    setContentLanguageState(code);
+    localStorage.setItem('content_language', code);
+    window.dispatchEvent(new StorageEvent('storage', { key: 'content_language', newValue: code }));
+  };
+
   return (
-    <LanguageContext.Provider value={{ language, setLanguage }}>
+    <LanguageContext.Provider
+      value={{ language, setLanguage, contentLanguage: contentLanguage || language, setContentLanguage }}
+    >
       {children}
     </LanguageContext.Provider>
   );
@@ -63,3 +97,33 @@ export function useLanguage() {
   }
   return context;
 }
+
+// The enabled languages are configured on the server and don't change while
+// the app runs, so they're fetched once and shared
+let enabledLanguagesRequest: Promise<LanguageInfo[]> | null = null;
+
+// Returns the languages content can be translated into, in configured order.
+// Interface text is only available in en, zh and es; product names and
+// specifications exist for every enabled language.
+export function useEnabledLanguages(): LanguageInfo[] {
+  const [languages, setLanguages] = useState<LanguageInfo[]>([]);
+
+  useEffect(() => {
+    if (!enabledLanguagesRequest) {
+      enabledLanguagesRequest = languagesApi.list().then((data) => data.languages).catch((error) => {
+        console.error('Failed to fetch languages:', error);
+        enabledLanguagesRequest = null;
+        return [];
+      });
+    }
+    let active = true;
+    enabledLanguagesRequest.then((list) => {
+      if (active) setLanguages(list);
+    });
+    return () => {
+      active = false;
+    };
+  }, []);
+
+  return languages;
+}
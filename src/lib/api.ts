@@ -15,6 +15,20 @@ import type {
   ApprovalStats,
   PurchaseConfig,
   UserBasic,
+  UserRole,
+  TwoFactorChallenge,
+  TwoFactorSetup,
+  TwoFactorStatus,
+  SSOConfig,
+  DirectoryStatus,
+  DirectorySyncRun,
+  APIKey,
+  APIScopes,
+  ServiceAccount,
+  Role,
+  Permission,
+  PermissionInfo,
+  Company,
   ProductMetadata as ProductMetadataType
 } from '@/types';
 
@@ -49,34 +63,76 @@ export const getAccessToken = (): string | null => {
   return null;
 };
 
+// Company switching: users with companies.manage can work in another company
+// than their own. Requests send it in X-Company; nothing means their own.
+export const getActiveCompany = (): string | null => {
+  if (typeof window !== 'undefined') {
+    return localStorage.getItem('company');
+  }
+  return null;
+};
+
+export const setActiveCompany = (code: string | null) => {
+  if (code) {
+    localStorage.setItem('company', code);
+  } else {
+    localStorage.removeItem('company');
+  }
+};
+
 // Request interceptor
 api.interceptors.request.use((config: InternalAxiosRequestConfig) => {
   const token = getAccessToken();
   if (token) {
     config.headers.Authorization = `Bearer ${token}`;
   }
-  // Add user language header for translations
+  // Add user language header for translations. Content may be in a language
+  // the interface isn't translated into.
   if (typeof window !== 'undefined') {
-    const language = localStorage.getItem('language') || 'en';
+    const language = localStorage.getItem('content_language') || localStorage.getItem('language') || 'en';
     config.headers['X-User-Language'] = language;
+    const company = getActiveCompany();
+    if (company) {
+      config.headers['X-Company'] = company;
+    }
   }
   return config;
 });
 
+// Refresh tokens are single use and reusing one ends the session, so
+// requests failing at the same time share one refresh
+let refreshing: Promise<string> | null = null;
+
+const refreshAccessToken = (refreshToken: string): Promise<string> => {
+  if (!refreshing) {
+    refreshing = axios
+      .post(`${API_BASE_URL}/auth/refresh`, { refresh_token: refreshToken })
+      .then((response) => {
+        const { access_token, refresh_token } = response.data.data;
+        setAccessToken(access_token);
+        localStorage.setItem('refresh_token', refresh_token);
+        return access_token as string;
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
 // Response interceptor
 api.interceptors.response.use(
   (response) => response,
   async (error: AxiosError) => {
-    if (error.response?.status === 401) {
+    // A wrong password, two-factor code or failed single sign-on is answered
+    // on the login page itself
+    const url = error.config?.url || '';
+    const isLoginStep = url.startsWith('/auth/login') || url.startsWith('/auth/2fa/') || url.startsWith('/auth/sso');
+    if (error.response?.status === 401 && !isLoginStep) {
       const refreshToken = localStorage.getItem('refresh_token');
       if (refreshToken) {
         try {
-          const response = await axios.post(`${API_BASE_URL}/auth/refresh`, {
-            refresh_token: refreshToken,
-          });
-          const { access_token, refresh_token } = response.data.data;
-          setAccessToken(access_token);
-          localStorage.setItem('refresh_token', refresh_token);
+          const access_token = await refreshAccessToken(refreshToken);
 
           const originalRequest = error.config;
           if (originalRequest) {
@@ -96,14 +152,85 @@ api.interceptors.response.use(
   }
 );
 
+// Keeps the tokens of a session that was just started
+const storeSession = (data: AuthResponse): AuthResponse => {
+  setAccessToken(data.access_token);
+  localStorage.setItem('refresh_token', data.refresh_token);
+  return data;
+};
+
+// Languages API
+export interface LanguageInfo {
+  code: string;
+  name: string;
+  native_name: string;
+}
+
+export const languagesApi = {
+  // Enabled languages in configured order, and the default language
+  list: async (): Promise<{ languages: LanguageInfo[]; default: string }> => {
+    const response = await api.get<ApiResponse<{ languages: LanguageInfo[]; default: string }>>('/languages');
+    return response.data.data!;
+  },
+};
+
 // Auth API
 export const authApi = {
-  login: async (credentials: LoginCredentials): Promise<AuthResponse> => {
-    const response = await api.post<ApiResponse<AuthResponse>>('/auth/login', credentials);
+  // Returns a two-factor challenge instead of logging in if a second factor is needed
+  login: async (credentials: LoginCredentials): Promise<AuthResponse | TwoFactorChallenge> => {
+    const response = await api.post<ApiResponse<AuthResponse | TwoFactorChallenge>>('/auth/login', credentials);
     const data = response.data.data!;
-    setAccessToken(data.access_token);
-    localStorage.setItem('refresh_token', data.refresh_token);
-    return data;
+    if ('two_factor_required' in data) {
+      return data;
+    }
+    return storeSession(data);
+  },
+
+  verifyTwoFactor: async (twoFactorToken: string, code: string): Promise<AuthResponse> => {
+    const response = await api.post<ApiResponse<AuthResponse>>('/auth/2fa/verify', {
+      two_factor_token: twoFactorToken,
+      code,
+    });
+    return storeSession(response.data.data!);
+  },
+
+  // For users who must set up two-factor authentication before logging in
+  beginTwoFactorSetup: async (twoFactorToken: string): Promise<TwoFactorSetup> => {
+    const response = await api.post<ApiResponse<TwoFactorSetup>>('/auth/2fa/setup', {
+      two_factor_token: twoFactorToken,
+    });
+    return response.data.data!;
+  },
+
+  enableTwoFactor: async (twoFactorToken: string, code: string): Promise<AuthResponse> => {
+    const response = await api.post<ApiResponse<AuthResponse>>('/auth/2fa/enable', {
+      two_factor_token: twoFactorToken,
+      code,
+    });
+    return storeSession(response.data.data!);
+  },
+
+  getSSOConfig: async (): Promise<SSOConfig> => {
+    const response = await api.get<ApiResponse<SSOConfig>>('/auth/sso');
+    return response.data.data!;
+  },
+
+  // Returns the identity provider URL to send the browser to
+  startSSO: async (): Promise<string> => {
+    const response = await api.post<ApiResponse<{ authorization_url: string }>>('/auth/sso/start');
+    return response.data.data!.authorization_url;
+  },
+
+  // Finishes single sign-on with the code the identity provider sent back;
+  // like login, it may return a two-factor challenge instead. The browser
+  // must send the cookie set by startSSO, which withCredentials does.
+  completeSSO: async (code: string, state: string): Promise<AuthResponse | TwoFactorChallenge> => {
+    const response = await api.post<ApiResponse<AuthResponse | TwoFactorChallenge>>('/auth/sso/callback', { code, state });
+    const data = response.data.data!;
+    if ('two_factor_required' in data) {
+      return data;
+    }
+    return storeSession(data);
   },
 
   register: async (credentials: RegisterCredentials): Promise<RegisterResponse> => {
@@ -116,6 +243,7 @@ export const authApi = {
       await api.post('/auth/logout');
     } finally {
       setAccessToken(null);
+      setActiveCompany(null);
       localStorage.removeItem('refresh_token');
     }
   },
@@ -131,6 +259,42 @@ export const authApi = {
       new_password: newPassword,
     });
   },
+
+  forgotPassword: async (email: string): Promise<void> => {
+    await api.post('/auth/forgot-password', { email });
+  },
+
+  resetPassword: async (token: string, newPassword: string): Promise<void> => {
+    await api.post('/auth/reset-password', { token, new_password: newPassword });
+  },
+};
+
+// Two-factor authentication settings of the current user
+export const twoFactorApi = {
+  getStatus: async (): Promise<TwoFactorStatus> => {
+    const response = await api.get<ApiResponse<TwoFactorStatus>>('/profile/2fa');
+    return response.data.data!;
+  },
+
+  setup: async (): Promise<TwoFactorSetup> => {
+    const response = await api.post<ApiResponse<TwoFactorSetup>>('/profile/2fa/setup');
+    return response.data.data!;
+  },
+
+  // Returns the recovery codes; they're only shown once
+  enable: async (code: string): Promise<string[]> => {
+    const response = await api.post<ApiResponse<{ recovery_codes: string[] }>>('/profile/2fa/enable', { code });
+    return response.data.data!.recovery_codes;
+  },
+
+  regenerateRecoveryCodes: async (code: string): Promise<string[]> => {
+    const response = await api.post<ApiResponse<{ recovery_codes: string[] }>>('/profile/2fa/recovery-codes', { code });
+    return response.data.data!.recovery_codes;
+  },
+
+  disable: async (code: string): Promise<void> => {
+    await api.post('/profile/2fa/disable', { code });
+  },
 };
 
 // Users API (Admin)
@@ -185,6 +349,16 @@ export const usersApi = {
     return response.data.data!;
   },
 
+  // Lift a lockout caused by repeated failed logins
+  unlock: async (id: number): Promise<void> => {
+    await api.post(`/users/${id}/unlock`);
+  },
+
+  // Remove two-factor authentication for a user who lost their authenticator
+  resetTwoFactor: async (id: number): Promise<void> => {
+    await api.delete(`/users/${id}/2fa`);
+  },
+
   bulkImport: async (users: Array<{
     employee_number: string;
     email: string;
@@ -220,6 +394,126 @@ export const usersApi = {
   },
 };
 
+// Directory (LDAP) sync API
+export const directoryApi = {
+  getStatus: async (): Promise<DirectoryStatus> => {
+    const response = await api.get<ApiResponse<DirectoryStatus>>('/admin/directory');
+    return response.data.data!;
+  },
+
+  // With dryRun nothing is changed; the report shows what a sync would do
+  sync: async (dryRun: boolean): Promise<DirectorySyncRun> => {
+    const response = await api.post<ApiResponse<DirectorySyncRun>>('/admin/directory/sync', null, {
+      params: dryRun ? { dry_run: true } : undefined,
+    });
+    return response.data.data!;
+  },
+};
+
+// Service accounts and their API keys
+export const serviceAccountsApi = {
+  scopes: async (): Promise<APIScopes> => {
+    const response = await api.get<ApiResponse<APIScopes>>('/admin/api-scopes');
+    return response.data.data!;
+  },
+
+  list: async (): Promise<ServiceAccount[]> => {
+    const response = await api.get<ApiResponse<ServiceAccount[]>>('/admin/service-accounts');
+    return response.data.data!;
+  },
+
+  create: async (data: { name: string; role: UserRole; employee_number?: string }): Promise<ServiceAccount> => {
+    const response = await api.post<ApiResponse<ServiceAccount>>('/admin/service-accounts', data);
+    return response.data.data!;
+  },
+
+  update: async (id: number, data: { name?: string; role?: UserRole; active?: boolean }): Promise<User> => {
+    const response = await api.put<ApiResponse<User>>(`/admin/service-accounts/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number): Promise<void> => {
+    await api.delete(`/admin/service-accounts/${id}`);
+  },
+
+  // The key is only returned here; it can't be retrieved later
+  createKey: async (
+    id: number,
+    data: { name: string; scopes: string[]; expires_in_days?: number }
+  ): Promise<{ key: string; api_key: APIKey }> => {
+    const response = await api.post<ApiResponse<{ key: string; api_key: APIKey }>>(
+      `/admin/service-accounts/${id}/keys`,
+      data
+    );
+    return response.data.data!;
+  },
+
+  revokeKey: async (id: number, keyId: number): Promise<APIKey> => {
+    const response = await api.delete<ApiResponse<APIKey>>(`/admin/service-accounts/${id}/keys/${keyId}`);
+    return response.data.data!;
+  },
+};
+
+// Roles API
+export const rolesApi = {
+  list: async (): Promise<Role[]> => {
+    const response = await api.get<ApiResponse<Role[]>>('/roles');
+    return response.data.data!;
+  },
+
+  permissions: async (): Promise<PermissionInfo[]> => {
+    const response = await api.get<ApiResponse<PermissionInfo[]>>('/admin/permissions');
+    return response.data.data!;
+  },
+
+  create: async (data: {
+    name: string;
+    display_name: string;
+    description?: string;
+    permissions: Permission[];
+  }): Promise<Role> => {
+    const response = await api.post<ApiResponse<Role>>('/admin/roles', data);
+    return response.data.data!;
+  },
+
+  update: async (
+    id: number,
+    data: { display_name?: string; description?: string; permissions?: Permission[] }
+  ): Promise<Role> => {
+    const response = await api.put<ApiResponse<Role>>(`/admin/roles/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number): Promise<void> => {
+    await api.delete(`/admin/roles/${id}`);
+  },
+};
+
+// Companies API
+export const companiesApi = {
+  list: async (): Promise<Company[]> => {
+    const response = await api.get<ApiResponse<Company[]>>('/admin/companies');
+    return response.data.data!;
+  },
+
+  create: async (data: { code: string; name: string; number_prefix?: string }): Promise<Company> => {
+    const response = await api.post<ApiResponse<Company>>('/admin/companies', data);
+    return response.data.data!;
+  },
+
+  update: async (
+    id: number,
+    data: { name?: string; number_prefix?: string; is_default?: boolean }
+  ): Promise<Company> => {
+    const response = await api.put<ApiResponse<Company>>(`/admin/companies/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number): Promise<void> => {
+    await api.delete(`/admin/companies/${id}`);
+  },
+};
+
 // Products API
 export const productsApi = {
   list: async (params?: {
@@ -348,13 +642,15 @@ export interface CreatePurchaseRequestInput {
   // Common fields
   justification: string;
   urgency?: 'normal' | 'urgent';
+  justification_language?: string; // Overrides automatic language detection
 }
 
 export interface TranslatedText {
   original: string;
-  en: string;
-  zh: string;
-  es: string;
+  source_language?: string;
+  source_confidence?: number;
+  // Language code -> translated text, for every enabled language
+  translations?: Record<string, string>;
 }
 
 export interface ProductMetadata {
@@ -422,6 +718,15 @@ export const purchaseRequestsApi = {
     return response.data.data!;
   },
 
+  // Answer an approver's info request, optionally editing the request
+  respond: async (
+    id: number,
+    data: { message: string; language?: string } & Partial<CreatePurchaseRequestInput>
+  ): Promise<PurchaseRequest> => {
+    const response = await api.post<ApiResponse<PurchaseRequest>>(`/purchase-requests/${id}/respond`, data);
+    return response.data.data!;
+  },
+
   // Cancel a request
   cancel: async (id: number): Promise<void> => {
     await api.delete(`/purchase-requests/${id}`);
@@ -692,9 +997,12 @@ export const adminApi = {
 };
 
 // Email Config API
+export type EmailProvider = 'resend' | 'smtp';
+export type SMTPSecurity = 'starttls' | 'tls' | 'none';
+
 export interface EmailConfig {
   id: number;
-  provider: string;
+  provider: EmailProvider;
   api_key_set: boolean;
   from_email: string;
   from_name: string;
@@ -707,6 +1015,14 @@ export interface EmailConfig {
   send_on_new_request: boolean;
   send_on_urgent: boolean;
   send_reminders: boolean;
+  smtp_host: string;
+  smtp_port: number;
+  smtp_security: SMTPSecurity;
+  smtp_username: string;
+  smtp_password_set: boolean;
+  dkim_domain: string;
+  dkim_selector: string;
+  dkim_key_set: boolean;
   last_test_at?: string;
   last_test_success: boolean;
   last_test_error?: string;
@@ -715,6 +1031,7 @@ export interface EmailConfig {
 }
 
 export interface EmailConfigInput {
+  provider?: EmailProvider;
   api_key?: string;
   from_email?: string;
   from_name?: string;
@@ -727,6 +1044,14 @@ export interface EmailConfigInput {
   send_on_new_request?: boolean;
   send_on_urgent?: boolean;
   send_reminders?: boolean;
+  smtp_host?: string;
+  smtp_port?: number;
+  smtp_security?: SMTPSecurity;
+  smtp_username?: string;
+  smtp_password?: string;
+  dkim_domain?: string;
+  dkim_selector?: string;
+  dkim_private_key?: string; // PEM encoded RSA key
 }
 
 export const emailConfigApi = {
@@ -745,6 +1070,332 @@ export const emailConfigApi = {
   },
 };
 
+// Email outbox (delivery log)
+export type EmailStatus = 'pending' | 'sending' | 'sent' | 'failed';
+
+export interface EmailMessage {
+  id: number;
+  to: string;
+  subject: string;
+  kind: string; // Notification type or "digest"
+  user_id?: number;
+  notification_id?: number;
+  status: EmailStatus;
+  attempts: number;
+  max_attempts: number;
+  last_error?: string;
+  provider?: string;
+  provider_message_id?: string;
+  next_attempt_at: string;
+  sent_at?: string;
+  created_at: string;
+  html_body?: string; // Only from get()
+  text_body?: string;
+}
+
+export const emailOutboxApi = {
+  list: async (params?: { page?: number; per_page?: number; status?: EmailStatus; kind?: string; search?: string }) => {
+    const response = await api.get<ApiResponse<EmailMessage[]>>('/admin/email-outbox', { params });
+    return response.data;
+  },
+
+  get: async (id: number): Promise<EmailMessage> => {
+    const response = await api.get<ApiResponse<EmailMessage>>(`/admin/email-outbox/${id}`);
+    return response.data.data!;
+  },
+
+  resend: async (id: number): Promise<EmailMessage> => {
+    const response = await api.post<ApiResponse<EmailMessage>>(`/admin/email-outbox/${id}/resend`);
+    return response.data.data!;
+  },
+};
+
+// Email Templates API
+export type EmailTemplateKind = 'approval' | 'rejection' | 'info_request' | 'purchased' | 'new_request' | 'info_response';
+
+export interface EmailTemplate {
+  kind: EmailTemplateKind;
+  language: string;
+  subject: string; // Empty uses the built-in subject
+  body: string; // Built-in template when is_custom is false
+  is_custom: boolean;
+  updated_at?: string;
+  updated_by_id?: number;
+}
+
+export interface EmailTemplateVariable {
+  name: string;
+  description: string;
+  example: string;
+}
+
+export interface EmailTemplatePreviewInput {
+  kind: EmailTemplateKind;
+  language: string;
+  subject?: string;
+  body?: string; // Omit to preview the saved template
+  request_id?: number; // Omit to use sample data
+}
+
+export interface EmailTemplatePreview {
+  subject: string;
+  html: string;
+  sample: boolean;
+}
+
+export const emailTemplatesApi = {
+  list: async (): Promise<EmailTemplate[]> => {
+    const response = await api.get<ApiResponse<EmailTemplate[]>>('/admin/email-templates');
+    return response.data.data || [];
+  },
+
+  variables: async (): Promise<Record<EmailTemplateKind, EmailTemplateVariable[]>> => {
+    const response = await api.get<ApiResponse<Record<EmailTemplateKind, EmailTemplateVariable[]>>>('/admin/email-templates/variables');
+    return response.data.data!;
+  },
+
+  save: async (kind: EmailTemplateKind, language: string, data: { subject?: string; body: string }): Promise<EmailTemplate> => {
+    const response = await api.put<ApiResponse<EmailTemplate>>(`/admin/email-templates/${kind}/${language}`, data);
+    return response.data.data!;
+  },
+
+  reset: async (kind: EmailTemplateKind, language: string): Promise<EmailTemplate> => {
+    const response = await api.delete<ApiResponse<EmailTemplate>>(`/admin/email-templates/${kind}/${language}`);
+    return response.data.data!;
+  },
+
+  preview: async (data: EmailTemplatePreviewInput): Promise<EmailTemplatePreview> => {
+    const response = await api.post<ApiResponse<EmailTemplatePreview>>('/admin/email-templates/preview', data);
+    return response.data.data!;
+  },
+};
+
+// Webhooks API
+export type WebhookEvent =
+  | 'request.created'
+  | 'request.approved'
+  | 'request.rejected'
+  | 'request.info_requested'
+  | 'request.info_provided'
+  | 'request.purchased'
+  | 'request.delivered'
+  | 'request.cancelled'
+  | 'webhook.ping';
+
+export interface WebhookSubscription {
+  id: number;
+  name: string;
+  url: string;
+  description: string;
+  events: WebhookEvent[]; // Empty subscribes to every event
+  is_active: boolean;
+  created_by_id?: number;
+  created_at: string;
+  updated_at: string;
+  secret?: string; // Only returned on create and rotate-secret
+}
+
+export interface WebhookSubscriptionInput {
+  name?: string;
+  url?: string;
+  description?: string;
+  events?: WebhookEvent[];
+  is_active?: boolean;
+  secret?: string; // Create only; generated when omitted
+}
+
+export type WebhookDeliveryStatus = 'pending' | 'sending' | 'delivered' | 'failed';
+
+export interface WebhookDelivery {
+  id: number;
+  subscription_id: number;
+  event_id: string;
+  event: WebhookEvent;
+  request_id?: number;
+  redelivery_of_id?: number;
+  status: WebhookDeliveryStatus;
+  attempts: number;
+  max_attempts: number;
+  next_attempt_at: string;
+  response_status?: number;
+  last_error?: string;
+  duration_ms?: number;
+  delivered_at?: string;
+  created_at: string;
+  payload?: string; // Only from getDelivery()
+  response_body?: string;
+}
+
+export const webhooksApi = {
+  events: async (): Promise<WebhookEvent[]> => {
+    const response = await api.get<ApiResponse<WebhookEvent[]>>('/admin/webhooks/events');
+    return response.data.data || [];
+  },
+
+  list: async (): Promise<WebhookSubscription[]> => {
+    const response = await api.get<ApiResponse<WebhookSubscription[]>>('/admin/webhooks');
+    return response.data.data || [];
+  },
+
+  get: async (id: number): Promise<WebhookSubscription> => {
+    const response = await api.get<ApiResponse<WebhookSubscription>>(`/admin/webhooks/${id}`);
+    return response.data.data!;
+  },
+
+  create: async (data: WebhookSubscriptionInput): Promise<WebhookSubscription> => {
+    const response = await api.post<ApiResponse<WebhookSubscription>>('/admin/webhooks', data);
+    return response.data.data!;
+  },
+
+  update: async (id: number, data: WebhookSubscriptionInput): Promise<WebhookSubscription> => {
+    const response = await api.put<ApiResponse<WebhookSubscription>>(`/admin/webhooks/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number) => {
+    const response = await api.delete<ApiResponse<null>>(`/admin/webhooks/${id}`);
+    return response.data;
+  },
+
+  rotateSecret: async (id: number): Promise<WebhookSubscription> => {
+    const response = await api.post<ApiResponse<WebhookSubscription>>(`/admin/webhooks/${id}/rotate-secret`);
+    return response.data.data!;
+  },
+
+  test: async (id: number): Promise<WebhookDelivery> => {
+    const response = await api.post<ApiResponse<WebhookDelivery>>(`/admin/webhooks/${id}/test`);
+    return response.data.data!;
+  },
+
+  deliveries: async (params?: { page?: number; per_page?: number; subscription_id?: number; status?: WebhookDeliveryStatus; event?: WebhookEvent; request_id?: number }) => {
+    const response = await api.get<ApiResponse<WebhookDelivery[]>>('/admin/webhook-deliveries', { params });
+    return response.data;
+  },
+
+  getDelivery: async (id: number): Promise<WebhookDelivery> => {
+    const response = await api.get<ApiResponse<WebhookDelivery>>(`/admin/webhook-deliveries/${id}`);
+    return response.data.data!;
+  },
+
+  redeliver: async (id: number): Promise<WebhookDelivery> => {
+    const response = await api.post<ApiResponse<WebhookDelivery>>(`/admin/webhook-deliveries/${id}/redeliver`);
+    return response.data.data!;
+  },
+};
+
+// Chat channels API
+export type ChatProvider = 'wecom' | 'dingtalk' | 'slack' | 'teams';
+
+export type ChatNotificationType =
+  | 'urgent_request'
+  | 'new_pending_request'
+  | 'request_approved'
+  | 'new_approved_order'
+  | 'reminder_pending'
+  | 'reminder_unpurchased';
+
+export interface ChatChannel {
+  id: number;
+  name: string;
+  provider: ChatProvider;
+  language: string;
+  roles: UserRole[]; // Empty matches every role
+  departments: string[]; // Empty matches every department
+  types: ChatNotificationType[]; // Empty posts every type
+  is_active: boolean;
+  webhook_url_set: boolean;
+  secret_set: boolean;
+  last_test_at?: string;
+  last_test_success: boolean;
+  last_test_error?: string;
+  created_by_id?: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ChatChannelInput {
+  name?: string;
+  provider?: ChatProvider;
+  webhook_url?: string; // Omit on update to keep the current one
+  secret?: string; // DingTalk signing secret; empty clears it
+  language?: string;
+  roles?: UserRole[];
+  departments?: string[];
+  types?: ChatNotificationType[];
+  is_active?: boolean;
+}
+
+export interface ChatChannelOptions {
+  providers: ChatProvider[];
+  types: ChatNotificationType[];
+  roles: UserRole[];
+  languages: LanguageInfo[];
+}
+
+export type ChatMessageStatus = 'pending' | 'sending' | 'sent' | 'failed';
+
+export interface ChatMessage {
+  id: number;
+  channel_id: number;
+  type: ChatNotificationType;
+  request_id?: number;
+  title: string;
+  text: string;
+  fields?: { label: string; value: string }[];
+  action_url?: string;
+  action?: string;
+  urgent: boolean;
+  status: ChatMessageStatus;
+  attempts: number;
+  max_attempts: number;
+  next_attempt_at: string;
+  last_error?: string;
+  sent_at?: string;
+  created_at: string;
+}
+
+export const chatChannelsApi = {
+  options: async (): Promise<ChatChannelOptions> => {
+    const response = await api.get<ApiResponse<ChatChannelOptions>>('/admin/chat-channels/options');
+    return response.data.data!;
+  },
+
+  list: async (): Promise<ChatChannel[]> => {
+    const response = await api.get<ApiResponse<ChatChannel[]>>('/admin/chat-channels');
+    return response.data.data || [];
+  },
+
+  create: async (data: ChatChannelInput): Promise<ChatChannel> => {
+    const response = await api.post<ApiResponse<ChatChannel>>('/admin/chat-channels', data);
+    return response.data.data!;
+  },
+
+  update: async (id: number, data: ChatChannelInput): Promise<ChatChannel> => {
+    const response = await api.put<ApiResponse<ChatChannel>>(`/admin/chat-channels/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number) => {
+    const response = await api.delete<ApiResponse<null>>(`/admin/chat-channels/${id}`);
+    return response.data;
+  },
+
+  test: async (id: number): Promise<ChatChannel> => {
+    const response = await api.post<ApiResponse<ChatChannel>>(`/admin/chat-channels/${id}/test`);
+    return response.data.data!;
+  },
+
+  messages: async (params?: { page?: number; per_page?: number; channel_id?: number; status?: ChatMessageStatus; type?: ChatNotificationType; request_id?: number }) => {
+    const response = await api.get<ApiResponse<ChatMessage[]>>('/admin/chat-messages', { params });
+    return response.data;
+  },
+
+  resend: async (id: number): Promise<ChatMessage> => {
+    const response = await api.post<ApiResponse<ChatMessage>>(`/admin/chat-messages/${id}/resend`);
+    return response.data.data!;
+  },
+};
+
 // Amazon Config API
 export interface AmazonConfig {
   id: number;
@@ -820,6 +1471,38 @@ export interface PendingCounts {
   pending_orders: number;
 }
 
+export type NotificationChannel = 'in_app' | 'email' | 'both' | 'none';
+
+export interface NotificationPreferences {
+  preferences: {
+    type: string;
+    channel: NotificationChannel;
+    // What global settings allow, regardless of the user's choice
+    available: { enabled: boolean; email: boolean };
+  }[];
+  quiet_hours_start: string; // "HH:MM", empty when unset
+  quiet_hours_end: string;
+  timezone: string; // IANA name, empty for server time
+  // Digest mode batches emails into one summary; urgent requests are still sent immediately
+  digest_frequency: DigestFrequency;
+  digest_hour: number; // 0-23 in timezone
+  digest_weekday: number; // 0 = Sunday, weekly digests only
+  last_digest_at?: string;
+  next_digest_at?: string;
+}
+
+export type DigestFrequency = 'off' | 'daily' | 'weekly';
+
+export interface UpdateNotificationPreferences {
+  channels?: Record<string, NotificationChannel>;
+  quiet_hours_start?: string;
+  quiet_hours_end?: string;
+  timezone?: string;
+  digest_frequency?: DigestFrequency;
+  digest_hour?: number;
+  digest_weekday?: number;
+}
+
 export const notificationsApi = {
   list: async (params?: { page?: number; per_page?: number; unread?: boolean }) => {
     const response = await api.get<ApiResponse<NotificationData[]>>('/notifications', { params });
@@ -845,15 +1528,61 @@ export const notificationsApi = {
     const response = await api.post<ApiResponse<{ updated: number }>>('/notifications/read-all');
     return response.data.data!;
   },
+
+  getPreferences: async (): Promise<NotificationPreferences> => {
+    const response = await api.get<ApiResponse<NotificationPreferences>>('/notifications/preferences');
+    return response.data.data!;
+  },
+
+  updatePreferences: async (data: UpdateNotificationPreferences): Promise<NotificationPreferences> => {
+    const response = await api.put<ApiResponse<NotificationPreferences>>('/notifications/preferences', data);
+    return response.data.data!;
+  },
+
+  // Opens the realtime stream. EventSource can't send headers, so the token goes
+  // in the query string; the browser resends Last-Event-ID on reconnect.
+  stream: (handlers: NotificationStreamHandlers): EventSource | null => {
+    const token = getAccessToken();
+    if (!token || typeof EventSource === 'undefined') return null;
+
+    const source = new EventSource(`${API_BASE_URL}/notifications/stream?access_token=${encodeURIComponent(token)}`);
+    const listen = <T,>(type: string, handler?: (data: T) => void) => {
+      if (!handler) return;
+      source.addEventListener(type, (event) => handler(JSON.parse((event as MessageEvent).data)));
+    };
+    listen('notification', handlers.onNotification);
+    listen('counts', handlers.onCounts);
+    listen('request_status', handlers.onRequestStatus);
+    if (handlers.onReset) {
+      source.addEventListener('reset', () => handlers.onReset!());
+    }
+    return source;
+  },
 };
 
+export interface RequestStatusEvent {
+  request_id: number;
+  request_number: string;
+  status: string;
+  previous_status: string;
+  updated_at: string;
+}
+
+export interface NotificationStreamHandlers {
+  onNotification?: (notification: NotificationData) => void;
+  onCounts?: (counts: PendingCounts) => void;
+  onRequestStatus?: (event: RequestStatusEvent) => void;
+  // Events were missed and can't be replayed; refetch notifications and lists
+  onReset?: () => void;
+}
+
 // Activity Logs types
 export interface ActivityLog {
   id: number;
   user_id: number | null;
   user_name: string;
   user_email: string;
-  type: 'login' | 'login_failed' | 'logout' | 'token_refresh' | 'password_reset' | 'registration';
+  type: 'login' | 'login_failed' | 'logout' | 'token_refresh' | 'password_reset' | 'registration' | 'token_reuse' | 'login_locked' | 'login_unlocked' | 'login_refused' | 'two_factor_enabled' | 'two_factor_disabled' | 'recovery_code_used';
   success: boolean;
   ip_address: string;
   user_agent: string;
@@ -874,6 +1603,8 @@ export interface ActivityStats {
   unique_users: number;
   today_logins: number;
   today_failed_logins: number;
+  locked_logins: number;
+  today_locked_logins: number;
 }
 
 export interface ActivityLogsResponse {
@@ -956,4 +1687,26 @@ export const activityLogsApi = {
   },
 };
 
+// Audit log of request changes, one entry per domain event
+export interface AuditLog {
+  id: number;
+  user_id: number;
+  user: User;
+  action: string; // Event name, e.g. request.approved
+  resource: string;
+  resource_id: number;
+  old_value: string; // Status before the change
+  new_value: string; // Status after the change
+  ip_address: string;
+  user_agent: string;
+  created_at: string;
+}
+
+export const auditLogsApi = {
+  list: async (params?: { page?: number; per_page?: number; resource?: string; resource_id?: number; user_id?: number; action?: string }) => {
+    const response = await api.get<ApiResponse<AuditLog[]>>('/admin/audit-logs', { params });
+    return response.data;
+  },
+};
+
 export default api;
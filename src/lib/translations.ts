@@ -27,7 +27,7 @@ export const getTranslatedText = (
   lang: Language
 ): string => {
   if (!translated) return decodeText(fallback);
-  const result = translated[lang] || translated.original || fallback;
+  const result = translated.translations?.[lang] || translated.original || fallback;
   return decodeText(result);
 };
 
@@ -36,5 +36,17 @@ export const getTranslatedText = (
  */
 export const hasTranslations = (translated: TranslatedText | null | undefined): boolean => {
   if (!translated) return false;
-  return !!(translated.en || translated.zh || translated.es);
+  return Object.values(translated.translations ?? {}).some(Boolean);
+};
+
+/**
+ * Gets a product field in the given language from its language-keyed values
+ * (name_translations, spec_translations, ...), falling back to the source text
+ */
+export const getLocalizedField = (
+  translations: Record<string, string> | null | undefined,
+  fallback: string,
+  lang: string
+): string => {
+  return translations?.[lang] || fallback;
 };
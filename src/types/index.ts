@@ -1,5 +1,7 @@
 // User types
-export type UserRole = 'admin' | 'purchase_admin' | 'supply_chain_manager' | 'general_manager' | 'employee';
+export type BuiltInRole = 'admin' | 'purchase_admin' | 'supply_chain_manager' | 'general_manager' | 'employee';
+// Admins can define roles besides the built-in ones
+export type UserRole = BuiltInRole | (string & {});
 export type UserStatus = 'pending' | 'approved' | 'rejected' | 'disabled';
 
 export interface User {
@@ -8,10 +10,59 @@ export interface User {
   email: string;
   name: string;
   role: UserRole;
+  permissions?: Permission[]; // Only on the signed-in user
   company_code: string;
   cost_center: string;
   department: string;
   status: UserStatus;
+  two_factor_enabled?: boolean;
+  two_factor_enabled_at?: string;
+}
+
+// Roles and permissions
+export type Permission =
+  | 'users.manage'
+  | 'roles.manage'
+  | 'settings.manage'
+  | 'logs.view'
+  | 'analytics.view'
+  | 'products.manage'
+  | 'requests.view_all'
+  | 'requests.auto_approve'
+  | 'approvals.view'
+  | 'approvals.decide'
+  | 'orders.view'
+  | 'orders.manage'
+  | 'purchase_config.manage'
+  | 'companies.manage';
+
+export interface PermissionInfo {
+  key: Permission;
+  description: string;
+}
+
+export interface Role {
+  id: number;
+  name: UserRole;
+  display_name: string;
+  description: string;
+  permissions: Permission[];
+  built_in: boolean;
+  user_count: number;
+  created_at: string;
+  updated_at: string;
+}
+
+// Companies: legal entities with their own approvers, catalog, numbering and configuration
+export interface Company {
+  id: number;
+  code: string;
+  name: string;
+  number_prefix: string; // Goes into request and PO numbers, e.g. PR-MX-2026-0001
+  is_default: boolean; // Users whose company code isn't a company belong to it
+  user_count: number;
+  created_at: string;
+  updated_at: string;
 }
 
 export interface PendingUser {
@@ -48,6 +99,108 @@ export interface AuthResponse {
   access_token: string;
   refresh_token: string;
   expires_in: number;
+  recovery_codes?: string[]; // Only when two-factor authentication was set up at login
+}
+
+// Returned by login instead of tokens when a second factor is needed
+export interface TwoFactorChallenge {
+  two_factor_required: true;
+  two_factor_token: string;
+  enrollment_required: boolean; // Two-factor must be set up before logging in
+}
+
+export interface SSOConfig {
+  enabled: boolean;
+  provider_name?: string; // Shown on the single sign-on button
+}
+
+// Directory (LDAP) sync
+export interface DirectoryStatus {
+  enabled: boolean;
+  sync_interval_minutes: number; // 0 when sync only runs on demand
+  last_run?: DirectorySyncRun;
+}
+
+export interface DirectoryFieldChange {
+  field: string;
+  from: string;
+  to: string;
+}
+
+export interface DirectorySyncChange {
+  user_id?: number;
+  employee_number: string;
+  email: string;
+  name: string;
+  fields?: DirectoryFieldChange[];
+  reason?: string; // Why the entry was skipped
+}
+
+export interface DirectorySyncReport {
+  created: DirectorySyncChange[];
+  updated: DirectorySyncChange[];
+  enabled: DirectorySyncChange[];
+  disabled: DirectorySyncChange[];
+  skipped: DirectorySyncChange[];
+}
+
+export interface DirectorySyncRun {
+  id: number;
+  dry_run: boolean;
+  triggered_by?: UserBasic;
+  started_at: string;
+  finished_at?: string;
+  entries: number;
+  created: number;
+  updated: number;
+  disabled: number;
+  enabled: number;
+  skipped: number;
+  error?: string;
+  report?: DirectorySyncReport;
+}
+
+// Service accounts call the API with scoped API keys instead of logging in
+export interface APIKey {
+  id: number;
+  service_account_id: number;
+  name: string;
+  prefix: string; // Public start of the key, e.g. vsk_1a2b3c4d5e6f
+  scopes: string[]; // <resource>:read, <resource>:write or <resource>:*
+  expires_at?: string;
+  last_used_at?: string;
+  last_used_ip?: string;
+  revoked_at?: string;
+  created_by?: UserBasic;
+  created_at: string;
+}
+
+export interface ServiceAccount extends User {
+  service_account: true;
+  api_keys: APIKey[];
+}
+
+export interface APIResource {
+  name: string;
+  description: string;
+  paths: string[];
+}
+
+export interface APIScopes {
+  resources: APIResource[];
+  actions: string[];
+}
+
+export interface TwoFactorSetup {
+  secret: string;
+  provisioning_uri: string; // otpauth:// URI for authenticator apps
+}
+
+export interface TwoFactorStatus {
+  enabled: boolean;
+  required: boolean;
+  enabled_at?: string;
+  recovery_codes_remaining: number;
 }
 
 export interface ApproveUserPayload {
@@ -85,6 +238,12 @@ export interface Product {
   specification: string;
   spec_zh?: string;
   spec_es?: string;
+  // Language code -> text; name_zh/name_es/spec_zh/spec_es are derived from these
+  name_translations?: Record<string, string>;
+  description_translations?: Record<string, string>;
+  spec_translations?: Record<string, string>;
+  // Field -> languages filled by machine translation (human edits are never overwritten)
+  machine_translated?: Record<string, string[]>;
   supplier: string;
   supplier_code?: string;
   price: number;
@@ -119,9 +278,10 @@ export type Urgency = 'normal' | 'urgent';
 
 export interface TranslatedText {
   original: string;
-  en: string;
-  zh: string;
-  es: string;
+  source_language?: string;
+  source_confidence?: number;
+  // Language code -> translated text, for every enabled language
+  translations?: Record<string, string>;
 }
 
 export interface RequestHistory {
@@ -162,6 +322,7 @@ export interface PurchaseRequestItem {
 export interface PurchaseRequest {
   id: number;
   request_number: string;
+  company_code?: string;
 
   // Multi-product support
   items?: PurchaseRequestItem[];
@@ -212,6 +373,9 @@ export interface PurchaseRequest {
   // Info request
   info_requested_at?: string;
   info_request_note?: string;
+  info_requested_by?: User;
+  info_response?: string;
+  info_responded_at?: string;
 
   // Purchase completion
   purchased_by?: User;
@@ -239,6 +403,7 @@ export interface PurchaseRequest {
   justification_translated?: TranslatedText;
   rejection_reason_translated?: TranslatedText;
   info_request_note_translated?: TranslatedText;
+  info_response_translated?: TranslatedText;
   purchase_notes_translated?: TranslatedText;
   delivery_notes_translated?: TranslatedText;
   cancellation_notes_translated?: TranslatedText;